@@ -2,35 +2,85 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 
 	"github.com/iSparshP/real-time-task-management-system/internal/ai"
+	"github.com/iSparshP/real-time-task-management-system/internal/analytics"
+	"github.com/iSparshP/real-time-task-management-system/internal/attachment"
 	"github.com/iSparshP/real-time-task-management-system/internal/auth"
+	"github.com/iSparshP/real-time-task-management-system/internal/breaker"
+	"github.com/iSparshP/real-time-task-management-system/internal/chaos"
+	"github.com/iSparshP/real-time-task-management-system/internal/clienterror"
 	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"github.com/iSparshP/real-time-task-management-system/internal/compliance"
 	"github.com/iSparshP/real-time-task-management-system/internal/database"
+	"github.com/iSparshP/real-time-task-management-system/internal/devdata"
+	"github.com/iSparshP/real-time-task-management-system/internal/export"
+	"github.com/iSparshP/real-time-task-management-system/internal/health"
+	"github.com/iSparshP/real-time-task-management-system/internal/intake"
+	"github.com/iSparshP/real-time-task-management-system/internal/janitor"
+	"github.com/iSparshP/real-time-task-management-system/internal/metrics"
+	"github.com/iSparshP/real-time-task-management-system/internal/moderation"
 	"github.com/iSparshP/real-time-task-management-system/internal/notification"
+	"github.com/iSparshP/real-time-task-management-system/internal/openapi"
+	"github.com/iSparshP/real-time-task-management-system/internal/preferences"
+	"github.com/iSparshP/real-time-task-management-system/internal/readonly"
+	"github.com/iSparshP/real-time-task-management-system/internal/runtimeconfig"
+	"github.com/iSparshP/real-time-task-management-system/internal/security"
+	"github.com/iSparshP/real-time-task-management-system/internal/slack"
+	"github.com/iSparshP/real-time-task-management-system/internal/slo"
+	"github.com/iSparshP/real-time-task-management-system/internal/status"
 	"github.com/iSparshP/real-time-task-management-system/internal/task"
+	"github.com/iSparshP/real-time-task-management-system/internal/tasklink"
+	"github.com/iSparshP/real-time-task-management-system/internal/telemetry"
+	"github.com/iSparshP/real-time-task-management-system/internal/version"
+	"github.com/iSparshP/real-time-task-management-system/internal/webapp"
 )
 
 func main() {
+	// --exit-after-migrate runs migrations and exits, so the same image can
+	// run as a Kubernetes migration job
+	exitAfterMigrate := flag.Bool("exit-after-migrate", false, "run database migrations and exit")
+	// --contract runs the contract migrations and exits, once every
+	// replica runs this build
+	contract := flag.Bool("contract", false, "run contract database migrations and exit")
+	flag.Parse()
+
+	// "seed" fills the database with a demo or load test dataset, after
+	// migrations, and exits
+	seeding := flag.Arg(0) == "seed"
+	seedFlags := flag.NewFlagSet("seed", flag.ExitOnError)
+	seedProfile := seedFlags.String("profile", "demo", "dataset to create: "+strings.Join(devdata.ProfileNames(), ", "))
+	seedValue := seedFlags.Int64("seed", 1, "seed of the random data; the same seed gives the same dataset")
+	seedPassword := seedFlags.String("password", "seed-password", "password of the seeded users")
+	if seeding {
+		seedFlags.Parse(flag.Args()[1:])
+	}
+
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
 		log.Printf("Warning: Error loading .env file: %v", err)
 	}
 
-	// Load application config
+	// Load application config, refusing to start until every problem is
+	// fixed
 	if err := common.LoadConfig(); err != nil {
-		log.Fatal("Failed to load config:", err)
+		log.Fatal(err)
 	}
 
 	// Initialize logger
@@ -40,113 +90,974 @@ func main() {
 	logger := common.Logger
 	defer logger.Sync()
 
+	// Initialize tracing
+	tracingConfig := telemetry.Config{
+		Enabled:     common.AppConfig.OTelEnabled,
+		Endpoint:    common.AppConfig.OTelEndpoint,
+		ServiceName: common.AppConfig.OTelServiceName,
+		SampleRatio: common.AppConfig.OTelSampleRatio,
+	}
+	if common.AppConfig.OTelExporterAuth != "" {
+		tracingConfig.Headers = map[string]string{"Authorization": common.AppConfig.OTelExporterAuth}
+	}
+	shutdownTracing, err := telemetry.Init(tracingConfig, logger)
+	if err != nil {
+		logger.Fatal("Failed to initialize tracing", zap.Error(err))
+	}
+
 	// Initialize router with middleware
 	router := gin.New()
 	router.Use(gin.Recovery())
+	router.Use(common.RequestID())
+	router.Use(common.CORSMiddleware(common.AppConfig.CORS))
+	router.Use(telemetry.Middleware())
 	router.Use(common.RequestLogger(logger))
 
-	// Add after loading environment variables
 	dbConfig := database.Config{
-		Host:        os.Getenv("DB_HOST"),
-		Port:        common.GetEnvInt("DB_PORT", 5432),
-		User:        os.Getenv("DB_USER"),
-		Password:    os.Getenv("DB_PASSWORD"),
-		DBName:      os.Getenv("DB_NAME"),
-		SSLMode:     os.Getenv("DB_SSLMODE"),
+		Host:        common.AppConfig.DBHost,
+		Port:        common.AppConfig.DBPort,
+		User:        common.AppConfig.DBUser,
+		Password:    common.AppConfig.DBPassword,
+		DBName:      common.AppConfig.DBName,
+		SSLMode:     common.AppConfig.DBSSLMode,
 		ConnTimeout: 10 * time.Second,
-		MaxRetries:  3,
+		// The startup wait below retries instead
+		MaxRetries: 1,
+
+		Replicas:             common.AppConfig.DBReplicas,
+		ReplicaCheckInterval: common.AppConfig.DBReplicaCheckInterval,
+		SlowQueryThreshold:   common.AppConfig.DBSlowQueryThreshold,
+		ExplainSlowQueries:   common.AppConfig.DBExplainSlowQueries,
 	}
 
-	db, err := database.NewGormDB(dbConfig)
-	if err != nil {
+	// Wait for dependencies that may still be starting, such as a Postgres
+	// container deployed alongside the server
+	startupCtx, cancelStartup := context.WithCancel(context.Background())
+	defer cancelStartup()
+	startupWait := common.AppConfig.StartupWaitTimeout
+
+	var db *gorm.DB
+	if err := health.WaitFor(startupCtx, "database", func(ctx context.Context) error {
+		var err error
+		db, err = database.NewGormDB(dbConfig)
+		return err
+	}, startupWait, logger); err != nil {
 		logger.Fatal("Failed to initialize database", zap.Error(err))
 	}
 	defer database.CloseDB(db)
 
+	if err := telemetry.RegisterGormCallbacks(db); err != nil {
+		logger.Fatal("Failed to register tracing callbacks", zap.Error(err))
+	}
+
 	// Verify database connection
 	if err := database.CheckConnection(db); err != nil {
 		logger.Fatal("Database connection check failed", zap.Error(err))
 	}
 
-	// Run migrations
-	if err := database.AutoMigrate(db); err != nil {
-		logger.Fatal("Failed to run database migrations", zap.Error(err))
+	// Run migrations, or wait for the migration job to run them
+	guard := database.NewMigrationGuard(common.AppConfig.MigrationGuardEnforce, int64(common.AppConfig.MigrationLargeTableRows))
+	if *contract {
+		if err := database.Contract(db, guard); err != nil {
+			logger.Fatal("Failed to run contract migrations", zap.Error(err))
+		}
+		logger.Info("Contract migrations applied, exiting")
+		return
+	}
+	if err := database.CheckSchemaVersion(startupCtx, db); err != nil {
+		logger.Fatal("Database schema is newer than this build", zap.Error(err))
+	}
+	if common.AppConfig.MigrateOnStart || *exitAfterMigrate {
+		if err := database.AutoMigrate(db, guard); err != nil {
+			logger.Fatal("Failed to run database migrations", zap.Error(err))
+		}
+		if *exitAfterMigrate {
+			logger.Info("Migrations applied, exiting")
+			return
+		}
+	} else if err := health.WaitFor(startupCtx, "migrations", func(ctx context.Context) error {
+		return database.CheckMigrations(ctx, db)
+	}, startupWait, logger); err != nil {
+		logger.Fatal("Database migrations not applied", zap.Error(err))
+	}
+
+	if seeding {
+		if common.AppConfig.Environment == "production" {
+			logger.Fatal("Refusing to seed a production database")
+		}
+		result, err := devdata.NewService(db).Seed(startupCtx, *seedProfile, *seedValue, *seedPassword)
+		if err != nil {
+			logger.Fatal("Failed to seed the database", zap.Error(err))
+		}
+		logger.Info("Database seeded, exiting",
+			zap.String("profile", result.Profile),
+			zap.Int64("seed", result.Seed),
+			zap.Int64("created", result.Created),
+			zap.Int64("existing", result.Existing),
+			zap.Strings("users", result.Emails))
+		return
 	}
 
 	// Initialize services
 	taskService := task.NewService(db, logger)
 	taskHandler := task.NewHandler(taskService, logger)
 
+	analyticsService := analytics.NewService(db, logger)
+	analyticsService.SetDailyCapacity(common.AppConfig.OrgDailyCapacityHours)
+	analyticsHandler := analytics.NewHandler(analyticsService, logger)
+
+	// The AI provider and each notification webhook get a circuit breaker,
+	// so one that keeps failing is skipped until a probe call succeeds
+	newBreaker := func(kind string) *breaker.Set {
+		breakers := breaker.New(common.AppConfig.BreakerFailures, common.AppConfig.BreakerCooldown)
+		breakers.SetObserver(func(dest string, state breaker.State) {
+			logger.Warn("Circuit breaker changed state",
+				zap.String("kind", kind), zap.String("destination", dest), zap.String("state", string(state)))
+		})
+		return breakers
+	}
+
 	aiConfig := ai.AIProviderConfig{
-		Provider:    os.Getenv("AI_PROVIDER"),
-		APIKey:      os.Getenv("AI_API_KEY"),
-		ModelName:   os.Getenv("AI_MODEL_NAME"),
+		Provider:    common.AppConfig.AIProvider,
+		APIKey:      common.AppConfig.AIAPIKey,
+		ModelName:   common.AppConfig.AIModelName,
+		BaseURL:     common.AppConfig.AIBaseURL,
 		MaxTokens:   150,
 		Temperature: 0.7,
+
+		EmbeddingModel: common.AppConfig.AIEmbeddingModel,
+
+		BatchMaxTasks: common.AppConfig.AIBatchMaxTasks,
+		BatchWorkers:  common.AppConfig.AIBatchWorkers,
+
+		AllowedModels:   common.AppConfig.AIAllowedModels,
+		MaxTemperature:  float32(common.AppConfig.AIMaxTemperature),
+		MaxOutputTokens: common.AppConfig.AIMaxOutputTokens,
+
+		CallTimeout: common.AppConfig.AICallTimeout,
 	}
-	aiService, err := ai.NewService(aiConfig, logger)
-	if err != nil {
-		logger.Fatal("Failed to initialize AI service", zap.Error(err))
+	// The AI features are optional: without them their routes answer 501
+	// and the rest of the API runs as usual
+	versionHandler := version.NewHandler()
+	var aiService *ai.Service
+	var aiHandler *ai.Handler
+	aiFeature := version.Feature{}
+	switch {
+	case aiConfig.APIKey == "":
+		aiFeature.Reason = "no AI provider API key configured"
+	case !common.AppConfig.AIEnabled:
+		aiFeature.Reason = "disabled by configuration"
+	default:
+		if aiService, err = ai.NewService(aiConfig, logger); err != nil {
+			logger.Error("Failed to initialize AI service; AI features are disabled", zap.Error(err))
+			aiFeature.Reason = "the AI provider client could not be created"
+		}
+	}
+	aiFeature.Enabled = aiService != nil
+	versionHandler.SetFeature("ai", aiFeature)
+	var ocrExtractor attachment.Extractor
+	if aiService != nil {
+		aiHandler = ai.NewHandler(aiService, logger)
+		aiService.SetBreaker(newBreaker("ai"))
+		taskService.SetTranslator(aiService)
+		if aiService.EmbeddingModel() != "" {
+			taskService.SetEmbedder(aiService, common.AppConfig.AIDuplicateThreshold)
+		}
+		aiService.SetTaskLoader(taskService)
+		aiService.SetChecklistWriter(taskService)
+		aiService.SetWorkloadLoader(taskService)
+		aiService.SetCalendarLoader(taskService)
+		aiService.SetSettingsStore(ai.NewSettingsStore(db))
+		// AI_PLAN_QUOTAS was checked with the rest of the configuration
+		quotas, _ := ai.ParsePlanQuotas(common.AppConfig.AIPlanQuotas)
+		aiService.SetUsage(ai.NewUsageStore(db), quotas)
+		if common.AppConfig.AIPromptDir != "" {
+			prompts, err := ai.LoadPromptTemplates(common.AppConfig.AIPromptDir)
+			if err != nil {
+				logger.Fatal("Invalid AI prompt templates", zap.Error(err))
+			}
+			aiService.SetPromptTemplates(prompts)
+			logger.Info("Loaded AI prompt templates", zap.Strings("suggestion_types", prompts.Types()))
+		}
+		if common.AppConfig.AIModerationURL != "" {
+			aiService.SetInputModerator(moderation.NewAPIModerator(
+				common.AppConfig.AIModerationURL, common.AppConfig.AIModerationAPIKey, moderation.ActionQuarantine))
+		}
+		ocrExtractor = aiService
+	} else {
+		logger.Warn("AI features disabled", zap.String("reason", aiFeature.Reason))
+	}
+
+	// Public intake is only enabled when an owner is configured for the
+	// tasks it creates
+	var intakeHandler *intake.Handler
+	if common.AppConfig.IntakeOwnerID != "" {
+		moderator := moderation.Chain{
+			moderation.NewWordlist(moderation.ActionQuarantine, common.AppConfig.ModerationExtraTerms),
+		}
+		if common.AppConfig.ModerationAIEnabled && aiService != nil {
+			moderator = append(moderator, moderation.NewAIModerator(aiService, moderation.ActionFlag))
+		}
+		intakeService := intake.NewService(db, taskService, moderator, common.AppConfig.IntakeOwnerID, logger)
+		intakeHandler = intake.NewHandler(intakeService, common.AppConfig.IntakeEmailToken, logger)
 	}
-	aiHandler := ai.NewHandler(aiService, logger)
 
 	notificationConfig := notification.NotificationConfig{
-		SlackWebhookURL:   os.Getenv("SLACK_WEBHOOK_URL"),
-		DiscordWebhookURL: os.Getenv("DISCORD_WEBHOOK_URL"),
+		SlackWebhookURL:   common.AppConfig.SlackWebhookURL,
+		DiscordWebhookURL: common.AppConfig.DiscordWebhookURL,
 		DefaultChannels: []notification.NotificationChannel{
 			notification.ChannelSlack,
 			notification.ChannelDiscord,
 		},
+		Targets: map[notification.NotificationChannel]string{
+			notification.ChannelTeams: common.AppConfig.TeamsWebhookURL,
+		},
+		Workers: common.AppConfig.NotificationWorkers,
+		PriorityBudgets: map[notification.Priority]int{
+			notification.PriorityUrgent: common.AppConfig.NotificationUrgentConcurrency,
+			notification.PriorityNormal: common.AppConfig.NotificationNormalConcurrency,
+			notification.PriorityLow:    common.AppConfig.NotificationLowConcurrency,
+		},
+	}
+	if notificationConfig.Targets[notification.ChannelTeams] != "" {
+		notificationConfig.DefaultChannels = append(notificationConfig.DefaultChannels, notification.ChannelTeams)
+	}
+	// Inbound Slack commands run as the account matching the Slack user's
+	// email
+	var slackHandler *slack.Handler
+	if common.AppConfig.SlackSigningSecret != "" && common.AppConfig.SlackBotToken != "" {
+		slackService := slack.NewService(db, taskService, slack.NewAPIDirectory(common.AppConfig.SlackBotToken), logger)
+		slackHandler = slack.NewHandler(slackService, common.AppConfig.SlackSigningSecret, logger)
 	}
+
 	notificationService, err := notification.NewService(notificationConfig, logger)
 	if err != nil {
 		logger.Fatal("Failed to initialize notification service", zap.Error(err))
 	}
 	defer notificationService.Close()
 	notificationHandler := notification.NewHandler(notificationService, logger)
+	notificationService.SetWatcherLookup(taskService)
+	notificationService.SetBreaker(newBreaker("notification"))
+	notificationService.SetProjectRoutes(notification.NewProjectRoutes(db, notificationService.Channels()))
+
+	// Background workers stop when the server shuts down
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	// Security events are recorded for audit and delivered to security
+	// webhooks in order
+	securityService := security.NewService(db, logger)
+	securityService.Start(backgroundCtx)
+	securityHandler := security.NewHandler(securityService, logger)
+
+	// Failed notification sends are retried with backoff from the database
+	if attempts := common.AppConfig.NotificationRetryMaxAttempts; attempts > 0 {
+		notificationService.SetRetryQueue(notification.NewRetryQueue(db, attempts, logger))
+		notificationService.StartRetries(backgroundCtx)
+	}
+
+	// Weekly AI summaries of each active project, posted to the default
+	// notification channels
+	if aiService != nil {
+		aiService.SetReports(ai.NewReportStore(db), taskService, notificationService)
+		if common.AppConfig.AIWeeklyReports {
+			aiService.StartWeeklyReports(backgroundCtx, time.Hour)
+		}
+	}
+
+	// Frontend error reports are sampled and kept for a while
+	clientErrorService := clienterror.NewService(db, common.AppConfig.ClientErrorSampleRate, logger)
+	clientErrorService.Start(backgroundCtx, common.AppConfig.ClientErrorRetention)
+	clientErrorHandler := clienterror.NewHandler(clientErrorService, logger)
+
+	// Pre-authorized links create tasks without a login, for field workers
+	taskLinkHandler := tasklink.NewHandler(
+		tasklink.NewService(db, taskService, common.AppConfig.PublicBaseURL, logger), logger)
+
+	// UI preferences follow users across devices
+	preferencesService := preferences.NewService(db, logger)
+	notificationService.SetLanguageLookup(preferencesService)
+	preferencesHandler := preferences.NewHandler(preferencesService, logger)
+
+	// Uploaded images and PDFs are OCR'd in the background for task search
+	attachmentService := attachment.NewService(db, ocrExtractor, common.AppConfig.AttachmentMaxBytes,
+		common.AppConfig.OCRInterval, common.AppConfig.OCRMaxAttempts, logger)
+	attachmentService.Start(backgroundCtx)
+	attachmentHandler := attachment.NewHandler(attachmentService, logger)
+
+	// Transfers the new assignee did not accept in time go back to the sender
+	taskService.StartTransferExpiry(backgroundCtx, common.AppConfig.TransferExpiryInterval)
+	// Completed tasks leave task lists for the archive after a while
+	taskService.StartArchiver(backgroundCtx, common.AppConfig.TaskArchiveInterval, common.AppConfig.TaskArchiveAfter)
+	// Tasks that went overdue during the day are announced nightly, and
+	// missed hard deadlines escalated to the notification channels
+	taskService.SetDeadlineEscalator(notificationService)
+	taskService.StartOverdueScan(backgroundCtx, common.AppConfig.OverdueScanHour)
+	// Pending tasks nearing their due date get a higher priority
+	taskService.SetPriorityEscalator(notificationService)
+	taskService.StartEscalation(backgroundCtx, common.AppConfig.TaskEscalationInterval, common.AppConfig.TaskEscalationWithinHours)
+	// Project subscriptions are revoked once their user leaves the project
+	taskService.StartSubscriptionReconciler(backgroundCtx, common.AppConfig.SubscriptionReconcileInterval)
+	// Tasks not embedded when written are embedded for duplicate detection
+	taskService.StartEmbeddingBackfill(backgroundCtx, common.AppConfig.AIEmbeddingInterval)
+	taskService.SetMinProtocolVersion(common.AppConfig.WSMinProtocolVersion)
+	if err := taskService.SetSlowClientPolicy(common.AppConfig.WSClientBuffer, task.SlowClientPolicy(common.AppConfig.WSSlowClientPolicy)); err != nil {
+		logger.Fatal("Invalid WebSocket slow client policy", zap.Error(err))
+	}
+	if err := taskService.SetEditLockPolicy(common.AppConfig.TaskEditLockTTL, task.EditLockMode(common.AppConfig.TaskEditLockMode)); err != nil {
+		logger.Fatal("Invalid task edit lock policy", zap.Error(err))
+	}
 
 	authConfig := auth.Config{
-		JWTSecret:              os.Getenv("JWT_SECRET"),
-		TokenExpiration:        24 * time.Hour,
-		RefreshTokenExpiration: 7 * 24 * time.Hour,
+		JWTSecret:              common.AppConfig.JWTSecret,
+		TokenExpiration:        common.AppConfig.JWTExpiration,
+		RefreshTokenExpiration: common.AppConfig.JWTRefreshExpiration,
+		FoldGmail:              common.AppConfig.EmailFoldGmail,
 	}
 	authService := auth.NewService(db, authConfig)
+	authService.SetLogger(logger)
+	authService.SetEventRecorder(securityService)
+	authService.StartAccountSync(backgroundCtx, common.AppConfig.AccountSyncInterval, logger)
 	authHandler := auth.NewHandler(authService, logger)
 
+	// Warehouse export is only enabled when a destination is configured
+	var exportHandler *export.Handler
+	if common.AppConfig.ExportDestination != "" {
+		warehouse, err := export.NewWarehouse(backgroundCtx, common.AppConfig.ExportDestination,
+			export.BigQueryConfig{
+				Project: common.AppConfig.BigQueryProject,
+				Dataset: common.AppConfig.BigQueryDataset,
+			},
+			export.SnowflakeConfig{
+				AccountURL: common.AppConfig.SnowflakeAccountURL,
+				Token:      common.AppConfig.SnowflakeToken,
+				TokenType:  common.AppConfig.SnowflakeTokenType,
+				Database:   common.AppConfig.SnowflakeDatabase,
+				Schema:     common.AppConfig.SnowflakeSchema,
+				Warehouse:  common.AppConfig.SnowflakeWarehouse,
+				Role:       common.AppConfig.SnowflakeRole,
+			},
+		)
+		if err != nil {
+			logger.Fatal("Failed to initialize warehouse export", zap.Error(err))
+		}
+		exportService := export.NewService(db, warehouse, common.AppConfig.ExportInterval, logger)
+		exportService.SetEventRecorder(securityService)
+		exportService.Start(backgroundCtx)
+		exportHandler = export.NewHandler(exportService, logger)
+	}
+
+	// Compliance reports are only enabled when a signing key is configured
+	var complianceHandler *compliance.Handler
+	if common.AppConfig.ComplianceSigningKey != "" {
+		complianceService := compliance.NewService(db, common.AppConfig.ComplianceSigningKey, logger)
+		complianceService.SetEventRecorder(securityService)
+		complianceService.Start(backgroundCtx)
+		complianceHandler = compliance.NewHandler(complianceService, logger)
+	}
+
+	// Redis is shared by the features configured to use it
+	var redisClient *redis.Client
+	if common.AppConfig.RateLimitStore == "redis" || common.AppConfig.NotificationDedupeStore == "redis" ||
+		common.AppConfig.TypeaheadCacheStore == "redis" || common.AppConfig.TaskCountersCacheStore == "redis" ||
+		(aiService != nil && common.AppConfig.AICacheStore == "redis") {
+		redisClient = common.NewRedisClient(common.AppConfig.RedisHost, common.AppConfig.RedisPort,
+			common.AppConfig.RedisPassword, common.AppConfig.RedisDB)
+		defer redisClient.Close()
+
+		// Features backed by Redis fail open, so an unreachable Redis only
+		// degrades them
+		if err := health.WaitFor(startupCtx, "redis", func(ctx context.Context) error {
+			return redisClient.Ping(ctx).Err()
+		}, startupWait, logger); err != nil {
+			logger.Warn("Starting without Redis", zap.Error(err))
+		}
+	}
+
+	// Rate limit buckets live in Redis when shared across replicas
+	// Stale entries of in-process stores are swept periodically, keeping
+	// memory flat on long-running replicas
+	storeJanitor := janitor.New(logger)
+	storeJanitor.Register("websocket_clients", janitor.SweepFunc(taskService.SweepClients))
+	var rateLimitStore common.RateLimitStore
+	var redisStore *common.RedisRateLimitStore
+	if common.AppConfig.RateLimitStore == "redis" {
+		redisStore = common.NewRedisRateLimitStore(redisClient)
+		rateLimitStore = redisStore
+	} else {
+		memoryStore := common.NewMemoryRateLimitStore()
+		rateLimitStore = memoryStore
+		storeJanitor.Register("rate_limit_buckets", memoryStore)
+	}
+
+	// Retried notification events are suppressed by event_id
+	if ttl := common.AppConfig.NotificationDedupeTTL; ttl > 0 {
+		if common.AppConfig.NotificationDedupeStore == "redis" {
+			notificationService.SetDeduplicator(notification.NewRedisDeduplicator(redisClient, ttl))
+		} else {
+			dedupe := notification.NewMemoryDeduplicator(ttl)
+			notificationService.SetDeduplicator(dedupe)
+			storeJanitor.Register("notification_dedupe", dedupe)
+		}
+	}
+
+	// Typeahead results are cached briefly per user and query
+	if ttl := common.AppConfig.TypeaheadCacheTTL; ttl > 0 {
+		if common.AppConfig.TypeaheadCacheStore == "redis" {
+			taskService.SetTypeaheadCache(task.NewRedisTypeaheadCache(redisClient, ttl))
+		} else {
+			typeaheadCache := task.NewMemoryTypeaheadCache(ttl)
+			taskService.SetTypeaheadCache(typeaheadCache)
+			storeJanitor.Register("typeahead_cache", typeaheadCache)
+		}
+	}
+
+	// Dashboard counters are cached per user until their tasks change
+	if ttl := common.AppConfig.TaskCountersCacheTTL; ttl > 0 {
+		if common.AppConfig.TaskCountersCacheStore == "redis" {
+			taskService.SetCountersCache(task.NewRedisCountersCache(redisClient, ttl))
+		} else {
+			countersCache := task.NewMemoryCountersCache(ttl)
+			taskService.SetCountersCache(countersCache)
+			storeJanitor.Register("task_counters_cache", countersCache)
+		}
+	}
+
+	// AI replies are cached per task and request
+	if aiService != nil {
+		switch ttl := common.AppConfig.AICacheTTL; {
+		case ttl <= 0:
+			aiService.SetResponseCache(nil, 0)
+		case common.AppConfig.AICacheStore == "redis":
+			responseCache := ai.NewRedisResponseCache(redisClient)
+			aiService.SetResponseCache(responseCache, ttl)
+			storeJanitor.Register("ai_response_cache", responseCache)
+		default:
+			responseCache := ai.NewMemoryResponseCache()
+			aiService.SetResponseCache(responseCache, ttl)
+			storeJanitor.Register("ai_response_cache", responseCache)
+		}
+	}
+
+	// Dependency checks for readiness probes
+	healthChecker := health.NewChecker()
+	healthChecker.Register("database", true, func(ctx context.Context) error {
+		return database.PingContext(ctx, db)
+	})
+	if len(common.AppConfig.DBReplicas) > 0 {
+		// Replica reads fall back to the primary, so losing every replica
+		// only degrades readiness
+		healthChecker.Register("database_replicas", false, func(ctx context.Context) error {
+			return database.CheckReplicas(ctx, db)
+		})
+	}
+	// The AI check calls the paid Gemini API, so probes share one result
+	var aiPing health.CheckFunc
+	if aiService != nil {
+		aiPing = health.Cached(aiService.Ping, common.AppConfig.AIHealthCheckTTL)
+		healthChecker.Register("ai_provider", false, aiPing)
+	}
+	if redisStore != nil {
+		// Rate limiting fails open, so a Redis outage only degrades readiness
+		healthChecker.Register("redis", false, redisStore.Ping)
+	}
+	healthHandler := health.NewHandler(healthChecker, logger)
+
+	// Integration status for administrators, from real traffic and on-demand
+	// probes. Webhook probes only GET the webhook URL, which posts nothing.
+	integrations := health.NewIntegrations()
+	integrations.Register("slack", notificationService.ChannelConfigured(notification.ChannelSlack),
+		func(ctx context.Context) error {
+			return notificationService.PingChannel(ctx, notification.ChannelSlack)
+		})
+	integrations.Register("discord", notificationService.ChannelConfigured(notification.ChannelDiscord),
+		func(ctx context.Context) error {
+			return notificationService.PingChannel(ctx, notification.ChannelDiscord)
+		})
+	integrations.Register("teams", notificationService.ChannelConfigured(notification.ChannelTeams),
+		func(ctx context.Context) error {
+			return notificationService.PingChannel(ctx, notification.ChannelTeams)
+		})
+	// Inbound email has nothing to probe; it is only seen when mail arrives
+	integrations.Register("email", intakeHandler != nil && common.AppConfig.IntakeEmailToken != "", nil)
+	integrations.Register("ai_provider", aiService != nil, aiPing)
+	// Attachments are stored in PostgreSQL
+	integrations.Register("storage", true, func(ctx context.Context) error {
+		return database.PingContext(ctx, db)
+	})
+	integrations.Register("redis", redisClient != nil, func(ctx context.Context) error {
+		return redisClient.Ping(ctx).Err()
+	})
+	notificationService.SetChannelObserver(func(ch notification.NotificationChannel, err error) {
+		integrations.Record(string(ch), err)
+	})
+	if aiService != nil {
+		aiService.SetCallObserver(integrations.Observer("ai_provider"))
+	}
+	if intakeHandler != nil {
+		intakeHandler.SetEmailObserver(integrations.Observer("email"))
+	}
+	integrationsHandler := health.NewIntegrationsHandler(integrations)
+
+	// Public status page, for sharing availability with users. The API is
+	// up whenever it can answer.
+	statusService := status.NewService(db, logger)
+	statusService.Register("api", true, func(ctx context.Context) error { return nil })
+	statusService.Register("websocket", false, taskService.CheckBroadcast)
+	statusService.Register("database", true, func(ctx context.Context) error {
+		return database.PingContext(ctx, db)
+	})
+	statusService.Register("integrations", false, integrations.Check)
+	statusHandler := status.NewHandler(statusService, logger)
+
+	// Writes are refused during failover while reads and WebSocket
+	// delivery continue
+	readOnly := readonly.NewMode(logger)
+	if common.AppConfig.ReadOnly {
+		readOnly.Set(readonly.SourceConfig, common.AppConfig.ReadOnlyReason)
+	}
+	if err := readOnly.RegisterCallbacks(db); err != nil {
+		logger.Fatal("Failed to register read-only callbacks", zap.Error(err))
+	}
+	if common.AppConfig.ReadOnlyCheckInterval > 0 {
+		go readOnly.Watch(backgroundCtx, db, common.AppConfig.ReadOnlyCheckInterval)
+	}
+	// Admins must be able to end the mode, and users to sign in meanwhile
+	readOnly.Exempt("/api/admin/read-only", "/api/auth/login", "/api/auth/refresh")
+	readOnlyHandler := readonly.NewHandler(readOnly, logger)
+
+	// Operational settings administrators change without a restart, which
+	// would drop every WebSocket connection. Overrides are kept in the
+	// database, so every replica picks them up.
+	var channelNames []string
+	for _, ch := range notificationService.Channels().Channels() {
+		channelNames = append(channelNames, string(ch))
+	}
+	runtimeConfig := runtimeconfig.NewService(db, logger)
+	runtimeConfig.Register(
+		runtimeconfig.Enum("log_level", "Lowest level of the log entries written",
+			common.LogLevel.String(), []string{"debug", "info", "warn", "error"}, func(level string) {
+				_ = common.LogLevel.UnmarshalText([]byte(level))
+			}),
+		runtimeconfig.Int("task_page_size", "Page size of task lists that ask for none",
+			common.AppConfig.TaskPageSize, 1, 100, taskService.SetDefaultPageSize),
+		runtimeconfig.Bool("notifications_paused", "Drop new notification messages on every channel",
+			false, notificationService.SetPaused),
+		runtimeconfig.List("notifications_muted_channels", "Channels whose new notification messages are dropped",
+			nil, channelNames, func(names []string) {
+				channels := make([]notification.NotificationChannel, len(names))
+				for i, name := range names {
+					channels[i] = notification.NotificationChannel(name)
+				}
+				notificationService.MuteChannels(channels)
+			}),
+	)
+	runtimeConfigHandler := runtimeconfig.NewHandler(runtimeConfig, logger)
+
+	// Fault injection is only wired up outside production
+	var faults *chaos.Injector
+	var chaosHandler *chaos.Handler
+	if common.AppConfig.ChaosEnabled {
+		faults = chaos.NewInjector(logger)
+		if err := faults.RegisterGormCallbacks(db); err != nil {
+			logger.Fatal("Failed to register fault injection callbacks", zap.Error(err))
+		}
+		taskService.SetFaultInjector(faults)
+		if aiService != nil {
+			aiService.SetFaultInjector(faults)
+		}
+		chaosHandler = chaos.NewHandler(faults, logger)
+		logger.Warn("Fault injection mode available; configure via /api/admin/chaos")
+	}
+
+	// Seeded test data for frontend development and load tests
+	var devDataHandler *devdata.Handler
+	if common.AppConfig.DevDataEnabled {
+		devDataHandler = devdata.NewHandler(devdata.NewService(db), logger)
+		logger.Warn("Test data generator available at /api/dev/generate")
+	}
+
+	// Service level indicators, reported against their objectives
+	sloTracker := slo.NewTracker(common.AppConfig.SLOWindow,
+		slo.Objective{
+			Name:      "task_create_latency",
+			Kind:      slo.KindLatency,
+			Threshold: common.AppConfig.SLOTaskCreateThreshold,
+			Target:    common.AppConfig.SLOTaskCreateTarget,
+		},
+		slo.Objective{
+			Name:      "websocket_delivery_latency",
+			Kind:      slo.KindLatency,
+			Threshold: common.AppConfig.SLOWebSocketDeliveryThreshold,
+			Target:    common.AppConfig.SLOWebSocketDeliveryTarget,
+		},
+		slo.Objective{
+			Name:   "notification_delivery_success",
+			Kind:   slo.KindSuccessRate,
+			Target: common.AppConfig.SLONotificationTarget,
+		},
+	)
+	notificationService.SetResultObserver(func(ok bool) {
+		sloTracker.ObserveResult("notification_delivery_success", ok)
+	})
+	sloHandler := slo.NewHandler(sloTracker)
+
+	// Load signals for KEDA/HPA, so replicas scale with real-time load
+	metricsRegistry := metrics.NewRegistry()
+	metricsRegistry.RegisterGauge("websocket_connections", "Open WebSocket connections", func() float64 {
+		return float64(taskService.ConnectedClients())
+	})
+	metricsRegistry.RegisterGauge("websocket_deprecated_protocol_connections", "Open WebSocket connections on a deprecated protocol version", func() float64 {
+		return float64(taskService.DeprecatedProtocolClients())
+	})
+	metricsRegistry.RegisterGauge("websocket_broadcast_backlog", "Task events waiting to be broadcast", func() float64 {
+		return float64(taskService.BroadcastBacklog())
+	})
+	metricsRegistry.RegisterGauge("websocket_pending_writes", "WebSocket messages queued but not yet written", func() float64 {
+		return float64(taskService.PendingWrites())
+	})
+	metricsRegistry.RegisterGauge("websocket_max_client_queue", "Messages queued for the WebSocket client furthest behind", func() float64 {
+		return float64(taskService.MaxClientQueue())
+	})
+	metricsRegistry.RegisterGauge("notification_queue_depth", "Notification messages still being sent", func() float64 {
+		return float64(notificationService.QueueDepth())
+	})
+
+	// Delivery latency from mutation to WebSocket frame, by where it was
+	// measured: frame written by the server, received by the built-in
+	// probe, or acknowledged by a client
+	deliveryLatency := func(source string) *metrics.Histogram {
+		return metricsRegistry.RegisterHistogram("websocket_delivery_latency_seconds",
+			"Time from task mutation to WebSocket delivery", metrics.LatencyBuckets, map[string]string{
+				"source":   source,
+				"region":   common.AppConfig.Region,
+				"instance": common.AppConfig.InstanceID,
+			})
+	}
+	serverDelivery := deliveryLatency("server")
+	clientDelivery := deliveryLatency("client")
+	taskService.SetDeliveryObserver(func(latency time.Duration) {
+		sloTracker.ObserveLatency("websocket_delivery_latency", latency, true)
+		serverDelivery.Observe(latency.Seconds())
+	})
+	taskService.SetReceiptObserver(func(latency time.Duration) {
+		clientDelivery.Observe(latency.Seconds())
+	})
+	// Backpressure: clients whose queue was full when a message was
+	// broadcast to them
+	slowClients := metricsRegistry.RegisterCounter("websocket_slow_client_total",
+		"WebSocket clients found with a full queue, by the policy applied", map[string]string{
+			"policy": common.AppConfig.WSSlowClientPolicy,
+		})
+	taskService.SetSlowClientObserver(func(task.SlowClientPolicy) {
+		slowClients.Inc()
+	})
+	broadcastDrops := metricsRegistry.RegisterCounter("websocket_broadcast_dropped_total",
+		"Task events dropped because the broadcast queue was full", nil)
+	taskService.SetBroadcastDropObserver(broadcastDrops.Inc)
+	if common.AppConfig.DeliveryProbeEnabled {
+		probeDelivery := deliveryLatency("probe")
+		if err := taskService.StartDeliveryProbe(backgroundCtx, func(latency time.Duration) {
+			probeDelivery.Observe(latency.Seconds())
+		}); err != nil {
+			logger.Error("Failed to start delivery probe", zap.Error(err))
+		}
+	}
+	storeJanitor.SetMetrics(metricsRegistry)
+	storeJanitor.Start(backgroundCtx, common.AppConfig.JanitorInterval)
+	metricsHandler := metrics.NewHandler(metricsRegistry, common.AppConfig.MetricsToken)
+	routeMetrics := metrics.NewRouteMetrics(metricsRegistry)
+	router.Use(routeMetrics.Middleware)
+	// Innermost, so the middleware above sees the status of handler errors
+	router.Use(common.ErrorHandler(logger))
+	router.NoRoute(func(c *gin.Context) {
+		_ = c.Error(common.NewNotFoundError("no route for " + c.Request.Method + " " + c.Request.URL.Path))
+	})
+
+	// Probe routes
+	router.GET("/healthz", healthHandler.Liveness)
+	router.GET("/readyz", healthHandler.Readiness)
+	router.GET("/status", statusHandler.Page)
+
+	// API reference built from the registered routes and the handlers'
+	// annotations, so clients need not reverse-engineer the API
+	apiSpec := openapi.New("Real-Time Task Management API", version.Version)
+	authHandler.Describe(apiSpec)
+	taskHandler.Describe(apiSpec)
+	apiSpec.Public("/api/version", "/api/openapi.json", "/api/client-errors",
+		"/api/intake/form", "/api/intake/email", "/api/integrations/slack/commands")
+	openapiHandler := openapi.NewHandler(apiSpec, router.Routes, common.AppConfig.SwaggerUIAssets, logger)
+	if common.AppConfig.APIDocsEnabled {
+		router.GET("/docs", openapiHandler.UI("/api/openapi.json"))
+	}
+	if common.AppConfig.WebAppEnabled {
+		router.StaticFS("/app", webapp.FS())
+	}
+
+	// Internal scaling signals, outside /api so autoscalers need no user
+	router.GET("/internal/scaling", metricsHandler.RequireToken, metricsHandler.Scaling)
+	router.GET("/internal/metrics", metricsHandler.RequireToken, metricsHandler.Prometheus)
+	router.GET("/internal/slo", metricsHandler.RequireToken, sloHandler.Report)
+
+	// Rate limits are enforced per user, or per client IP before login.
+	// Their budgets are runtime settings.
+	rateLimit := func(name string, perMinute int) gin.HandlerFunc {
+		var budget atomic.Int64
+		budget.Store(int64(perMinute))
+		runtimeConfig.Register(runtimeconfig.Int("rate_limit_"+name+"_per_minute",
+			"Requests per minute of the "+name+" rate limit; 0 disables it", perMinute, 0, 100000,
+			func(n int) { budget.Store(int64(n)) }))
+		return common.DynamicRateLimiter(name, func() common.RateLimit {
+			n := int(budget.Load())
+			return common.RateLimit{PerMinute: n, Burst: n}
+		}, rateLimitStore, logger)
+	}
+	authLimit := rateLimit("auth", common.AppConfig.RateLimitAuth)
+	taskLimit := rateLimit("tasks", common.AppConfig.RateLimitTasks)
+	aiLimit := rateLimit("ai", common.AppConfig.RateLimitAI)
+	intakeLimit := rateLimit("intake", common.AppConfig.RateLimitIntake)
+	clientErrorLimit := rateLimit("client_errors", common.AppConfig.RateLimitClientErrors)
+	taskLinkLimit := rateLimit("task_links", common.AppConfig.RateLimitTaskLinks)
+	go runtimeConfig.Watch(backgroundCtx, common.AppConfig.RuntimeConfigInterval)
+
+	// Task link forms, opened from QR codes and emails without a login
+	taskLinkTimeout := common.Timeout(common.AppConfig.TaskRouteTimeout)
+	router.GET(tasklink.FormPath, taskLinkLimit, taskLinkTimeout, taskLinkHandler.Form)
+	router.POST(tasklink.FormPath, readOnly.Middleware(), taskLinkLimit, taskLinkTimeout, taskLinkHandler.Submit)
+
 	// API routes - simplified structure
 	api := router.Group("/api")
+	api.Use(readOnly.Middleware())
+	if faults != nil {
+		// Latency is injected on API routes only so probes stay truthful
+		api.Use(faults.Middleware())
+	}
 	{
 		// Unprotected routes
-		api.POST("/auth/register", authHandler.Register)
-		api.POST("/auth/login", authHandler.Login)
-		api.POST("/auth/refresh", authHandler.RefreshToken)
+		api.GET("/version", versionHandler.Get)
+		if common.AppConfig.APIDocsEnabled {
+			api.GET("/openapi.json", openapiHandler.Document)
+		}
+		api.POST("/auth/register", authLimit, authHandler.Register)
+		api.POST("/auth/login", authLimit, authHandler.Login)
+		api.POST("/auth/refresh", authLimit, authHandler.RefreshToken)
+
+		// Error reports can come from pages shown before login
+		api.POST("/client-errors", clientErrorLimit, clientErrorHandler.Report)
+
+		if intakeHandler != nil {
+			api.POST("/intake/form", intakeLimit, intakeHandler.SubmitForm)
+			api.POST("/intake/email", intakeHandler.SubmitEmail)
+		}
+
+		// Slack authenticates these with its request signature
+		if slackHandler != nil {
+			api.POST("/integrations/slack/commands", common.Timeout(common.AppConfig.TaskRouteTimeout), slackHandler.Commands)
+		}
 
 		// Protected routes
 		api.Use(auth.AuthMiddleware(authService))
 		{
+			requireAdmin := auth.RequireAdmin(common.AppConfig.AdminUserIDs)
+
+			api.POST("/auth/reporting-tokens", requireAdmin, authHandler.CreateReportingToken)
+			api.GET("/auth/reporting-tokens", requireAdmin, authHandler.ListReportingTokens)
+			api.DELETE("/auth/reporting-tokens/:id", requireAdmin, authHandler.RevokeReportingToken)
+			api.DELETE("/auth/me", authHandler.DeleteAccount)
+			api.PUT("/auth/password", authHandler.ChangePassword)
+			api.GET("/users/me/sessions", authHandler.ListSessions)
+			api.DELETE("/users/me/sessions/:id", authHandler.RevokeSession)
+
 			// Task routes
+			taskTimeout := common.Timeout(common.AppConfig.TaskRouteTimeout)
+			exportTimeout := common.Timeout(common.AppConfig.ExportRouteTimeout)
 			api.GET("/tasks/ws", taskHandler.WebSocket)
-			api.POST("/tasks", taskHandler.CreateTask)
-			api.GET("/tasks", taskHandler.ListTasks)
-			api.GET("/tasks/:id", taskHandler.GetTask)
-			api.PUT("/tasks/:id", taskHandler.UpdateTask)
-			api.DELETE("/tasks/:id", taskHandler.DeleteTask)
-			api.POST("/tasks/:id/assign", taskHandler.AssignTask)
+			api.POST("/tasks", taskLimit, sloTracker.ObserveRequests("task_create_latency"), taskTimeout, taskHandler.CreateTask)
+			api.GET("/tasks", taskLimit, taskTimeout, taskHandler.ListTasks)
+			api.GET("/tasks/archive", taskLimit, taskTimeout, taskHandler.ListArchivedTasks)
+			api.GET("/tasks/search", taskLimit, taskTimeout, taskHandler.SearchTasks)
+			api.GET("/sync", taskLimit, exportTimeout, taskHandler.Sync)
+			api.POST("/sync/apply", taskLimit, exportTimeout, taskHandler.ApplySync)
+			api.GET("/tasks/typeahead", taskLimit, common.Timeout(common.AppConfig.TypeaheadTimeout), taskHandler.Typeahead)
+			api.GET("/tasks/counters", taskLimit, taskTimeout, taskHandler.Counters)
+			api.GET("/presence", taskLimit, taskTimeout, taskHandler.Presence)
+			api.GET("/tasks/:id", taskLimit, taskTimeout, taskHandler.GetTask)
+			api.PUT("/tasks/:id", taskLimit, taskTimeout, taskHandler.UpdateTask)
+			api.DELETE("/tasks/:id", taskLimit, taskTimeout, taskHandler.DeleteTask)
+			api.POST("/tasks/:id/assign", taskLimit, taskTimeout, taskHandler.AssignTask)
+			api.POST("/tasks/:id/transfer", taskLimit, taskTimeout, taskHandler.TransferTask)
+			api.POST("/tasks/:id/transfer/accept", taskLimit, taskTimeout, taskHandler.AcceptTransfer)
+			api.POST("/tasks/:id/transfer/decline", taskLimit, taskTimeout, taskHandler.DeclineTransfer)
+			api.GET("/tasks/:id/assignment-history", taskLimit, taskTimeout, taskHandler.AssignmentHistory)
+			api.GET("/tasks/:id/escalations", taskLimit, taskTimeout, taskHandler.TaskEscalations)
+			api.POST("/tasks/:id/clone", taskLimit, taskTimeout, taskHandler.CloneTask)
+			api.GET("/tasks/:id/checklist", taskLimit, taskTimeout, taskHandler.GetChecklist)
+			api.POST("/tasks/:id/checklist", taskLimit, taskTimeout, taskHandler.AddChecklistItem)
+			api.PUT("/tasks/:id/checklist/:item_id", taskLimit, taskTimeout, taskHandler.UpdateChecklistItem)
+			api.DELETE("/tasks/:id/checklist/:item_id", taskLimit, taskTimeout, taskHandler.DeleteChecklistItem)
+			api.GET("/tasks/:id/watchers", taskLimit, taskTimeout, taskHandler.ListWatchers)
+			api.POST("/tasks/:id/watch", taskLimit, taskTimeout, taskHandler.WatchTask)
+			api.DELETE("/tasks/:id/watch", taskLimit, taskTimeout, taskHandler.UnwatchTask)
+			api.POST("/tasks/:id/restore", taskLimit, taskTimeout, taskHandler.RestoreTask)
+			api.POST("/tasks/:id/lock", taskLimit, taskTimeout, taskHandler.LockTask)
+			api.DELETE("/tasks/:id/lock", taskLimit, taskTimeout, taskHandler.UnlockTask)
+			api.POST("/tasks/from-template/:id", taskLimit, taskTimeout, taskHandler.CreateTaskFromTemplate)
+			api.GET("/task-templates", taskLimit, taskTimeout, taskHandler.ListTemplates)
+			api.POST("/task-templates", taskLimit, taskTimeout, taskHandler.CreateTemplate)
+			api.POST("/task-links", taskLimit, taskTimeout, taskLinkHandler.CreateLink)
+			api.GET("/task-links", taskLimit, taskTimeout, taskLinkHandler.ListLinks)
+			api.DELETE("/task-links/:id", taskLimit, taskTimeout, taskLinkHandler.RevokeLink)
+			api.GET("/projects/:project/field-schema", taskLimit, taskTimeout, taskHandler.GetFieldSchema)
+			api.PUT("/projects/:project/field-schema", requireAdmin, taskLimit, taskTimeout, taskHandler.SetFieldSchema)
+			api.GET("/projects/:project/escalation-rule", taskLimit, taskTimeout, taskHandler.GetEscalationRule)
+			api.PUT("/projects/:project/escalation-rule", requireAdmin, taskLimit, taskTimeout, taskHandler.SetEscalationRule)
+			api.GET("/projects/:project/calendar", taskLimit, taskTimeout, taskHandler.GetCalendar)
+			api.PUT("/projects/:project/calendar", requireAdmin, taskLimit, taskTimeout, taskHandler.SetCalendar)
+			api.GET("/projects/:project/webhooks", requireAdmin, taskTimeout, notificationHandler.ListProjectWebhooks)
+			api.PUT("/projects/:project/webhooks/:channel", requireAdmin, taskTimeout, notificationHandler.SetProjectWebhook)
+			api.DELETE("/projects/:project/webhooks/:channel", requireAdmin, taskTimeout, notificationHandler.DeleteProjectWebhook)
+			api.GET("/projects/:project/notification-rules", requireAdmin, taskTimeout, notificationHandler.ListNotificationRules)
+			api.POST("/projects/:project/notification-rules", requireAdmin, taskTimeout, notificationHandler.CreateNotificationRule)
+			api.PUT("/projects/:project/notification-rules/:id", requireAdmin, taskTimeout, notificationHandler.UpdateNotificationRule)
+			api.DELETE("/projects/:project/notification-rules/:id", requireAdmin, taskTimeout, notificationHandler.DeleteNotificationRule)
+			api.POST("/tasks/balance", taskLimit, taskTimeout, taskHandler.ProposeBalance)
+			api.POST("/tasks/balance/apply", taskLimit, exportTimeout, taskHandler.ApplyBalance)
+			api.POST("/tasks/import", taskLimit, exportTimeout, taskHandler.ImportTasks)
+
+			// Attachment routes; uploads get the longer budget for large files
+			api.POST("/tasks/:id/attachments", taskLimit, exportTimeout, attachmentHandler.Upload)
+			api.GET("/tasks/:id/attachments", taskLimit, taskTimeout, attachmentHandler.List)
+			api.GET("/tasks/:id/attachments/:attachment_id", taskLimit, exportTimeout, attachmentHandler.Download)
+
+			// Time tracking routes
+			api.POST("/tasks/:id/timer/start", taskLimit, taskTimeout, taskHandler.StartTimer)
+			api.POST("/tasks/:id/timer/stop", taskLimit, taskTimeout, taskHandler.StopTimer)
+			api.POST("/tasks/:id/worklogs", taskLimit, taskTimeout, taskHandler.LogWork)
+			api.GET("/tasks/:id/time", taskLimit, taskTimeout, taskHandler.GetTaskTime)
+			api.GET("/users/me/time", taskLimit, taskTimeout, taskHandler.GetMyTime)
+
+			// AI routes; disabled deployments keep them, answering 501
+			if aiHandler != nil {
+				aiTimeout := common.Timeout(common.AppConfig.AIRouteTimeout)
+				api.POST("/ai/suggest", aiLimit, aiTimeout, aiHandler.GetSuggestions)
+				api.POST("/ai/suggest/batch", aiLimit, aiTimeout, aiHandler.BatchSuggestions)
+				api.POST("/ai/breakdown", aiLimit, aiTimeout, aiHandler.Breakdown)
+				api.GET("/ai/settings", taskTimeout, aiHandler.GetSettings)
+				api.PUT("/ai/settings", requireAdmin, taskTimeout, aiHandler.UpdateSettings)
+				api.GET("/ai/reports", taskTimeout, aiHandler.ListReports)
+				api.GET("/ai/usage", taskTimeout, aiHandler.GetUsage)
+				api.PUT("/admin/users/:id/ai-plan", requireAdmin, taskTimeout, aiHandler.SetUserPlan)
+				api.POST("/tasks/:id/translate", aiLimit, aiTimeout, taskHandler.TranslateTask)
+				api.GET("/tasks/:id/similar", taskLimit, taskTimeout, taskHandler.SimilarTasks)
+			} else {
+				aiDisabled := common.FeatureDisabled("AI features are disabled on this deployment: " + aiFeature.Reason)
+				api.POST("/ai/suggest", aiDisabled)
+				api.POST("/ai/suggest/batch", aiDisabled)
+				api.POST("/ai/breakdown", aiDisabled)
+				api.GET("/ai/settings", aiDisabled)
+				api.PUT("/ai/settings", requireAdmin, aiDisabled)
+				api.GET("/ai/reports", aiDisabled)
+				api.GET("/ai/usage", aiDisabled)
+				api.PUT("/admin/users/:id/ai-plan", requireAdmin, aiDisabled)
+				api.POST("/tasks/:id/translate", aiDisabled)
+				api.GET("/tasks/:id/similar", aiDisabled)
+			}
+
+			// Analytics routes
+			api.GET("/analytics/burndown", exportTimeout, analyticsHandler.Burndown)
+			api.GET("/analytics/summary", exportTimeout, analyticsHandler.Summary)
+			api.GET("/org/calendar", exportTimeout, analyticsHandler.Calendar)
+
+			// UI preference routes; organization defaults are set by administrators
+			api.GET("/preferences", taskLimit, taskTimeout, preferencesHandler.Get)
+			api.PUT("/preferences", taskLimit, taskTimeout, preferencesHandler.UpdateUser)
+			api.PUT("/org/preferences", requireAdmin, taskLimit, taskTimeout, preferencesHandler.UpdateOrg)
+
+			// Warehouse export routes
+			if exportHandler != nil {
+				api.GET("/exports/status", requireAdmin, exportTimeout, exportHandler.Status)
+				api.POST("/exports/run", requireAdmin, exportTimeout, exportHandler.TriggerRun)
+			}
 
-			// AI routes
-			api.POST("/ai/suggest", aiHandler.GetSuggestions)
+			// Intake triage routes (administrators only)
+			if intakeHandler != nil {
+				api.GET("/intake/submissions", requireAdmin, taskTimeout, intakeHandler.ListSubmissions)
+				api.POST("/intake/submissions/:id/approve", requireAdmin, taskTimeout, intakeHandler.ApproveSubmission)
+				api.POST("/intake/submissions/:id/reject", requireAdmin, taskTimeout, intakeHandler.RejectSubmission)
+			}
+
+			// Security webhook routes (administrators only)
+			api.GET("/admin/security-webhooks", requireAdmin, taskTimeout, securityHandler.ListWebhooks)
+			api.POST("/admin/security-webhooks", requireAdmin, taskTimeout, securityHandler.CreateWebhook)
+			api.DELETE("/admin/security-webhooks/:id", requireAdmin, taskTimeout, securityHandler.DeleteWebhook)
+
+			// Compliance report routes (administrators only)
+			if complianceHandler != nil {
+				api.GET("/admin/compliance/report", requireAdmin, taskTimeout, complianceHandler.Report)
+			}
+
+			// Account and usage routes (administrators only)
+			api.GET("/admin/users", requireAdmin, taskTimeout, authHandler.ListUsers)
+			api.POST("/admin/users/:id/disable", requireAdmin, taskTimeout, authHandler.DisableUser)
+			api.POST("/admin/users/:id/enable", requireAdmin, taskTimeout, authHandler.EnableUser)
+			api.POST("/admin/users/:id/password-reset", requireAdmin, taskTimeout, authHandler.ForcePasswordReset)
+			api.POST("/admin/impersonate/:user_id", requireAdmin, taskTimeout, authHandler.Impersonate)
+			api.GET("/admin/stats/tasks", requireAdmin, exportTimeout, taskHandler.TaskStats)
+			api.GET("/admin/websocket", requireAdmin, taskHandler.WebSocketStats)
+
+			// Failed notification routes (administrators only)
+			api.GET("/admin/notifications/failed", requireAdmin, taskTimeout, notificationHandler.ListFailedNotifications)
+			api.POST("/admin/notifications/failed/:id/requeue", requireAdmin, taskTimeout, notificationHandler.RequeueNotification)
+
+			// Client error reports (administrators only)
+			api.GET("/admin/client-errors/summary", requireAdmin, exportTimeout, clientErrorHandler.Summary)
+			api.GET("/admin/client-errors", requireAdmin, taskTimeout, clientErrorHandler.List)
+
+			// Integration status (administrators only)
+			api.GET("/admin/integrations/status", requireAdmin, exportTimeout, integrationsHandler.Status)
+
+			// Incident notes on the public status page
+			api.POST("/admin/status/incidents", requireAdmin, taskTimeout, statusHandler.CreateIncident)
+			api.PUT("/admin/status/incidents/:id", requireAdmin, taskTimeout, statusHandler.UpdateIncident)
+			api.DELETE("/admin/status/incidents/:id", requireAdmin, taskTimeout, statusHandler.DeleteIncident)
 
 			// Notification routes
 			api.POST("/notifications/events", notificationHandler.HandleTaskEvent)
+
+			// Fault injection routes (dev/staging only)
+			api.GET("/read-only", readOnlyHandler.Get)
+			api.PUT("/admin/read-only", requireAdmin, readOnlyHandler.Update)
+			api.GET("/admin/runtime-config", requireAdmin, taskTimeout, runtimeConfigHandler.List)
+			api.PUT("/admin/runtime-config", requireAdmin, taskTimeout, runtimeConfigHandler.Update)
+
+			if chaosHandler != nil {
+				api.GET("/admin/chaos", requireAdmin, chaosHandler.GetConfig)
+				api.PUT("/admin/chaos", requireAdmin, chaosHandler.UpdateConfig)
+			}
+
+			// Test data generator (dev only)
+			if devDataHandler != nil {
+				api.POST("/dev/generate", exportTimeout, devDataHandler.Generate)
+			}
 		}
 	}
 
+	// Routes outside METRICS_ROUTES share the "other" label; by default
+	// every registered route has its own
+	metricsRoutes := common.AppConfig.MetricsRoutes
+	if len(metricsRoutes) == 0 {
+		for _, route := range router.Routes() {
+			metricsRoutes = append(metricsRoutes, route.Path)
+		}
+	}
+	routeMetrics.Allow(metricsRoutes...)
+
 	// Server configuration
 	srv := &http.Server{
-		Addr:         fmt.Sprintf(":%s", os.Getenv("PORT")),
+		Addr:         fmt.Sprintf(":%d", common.AppConfig.ServerPort),
 		Handler:      router,
 		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
+		WriteTimeout: common.AppConfig.WriteTimeout(),
 		IdleTimeout:  60 * time.Second,
 	}
 
@@ -172,5 +1083,19 @@ func main() {
 		logger.Fatal("Server forced to shutdown", zap.Error(err))
 	}
 
+	// Stop schedulers and manual exports only after in-flight requests have
+	// finished, so none of them starts work on a cancelled context
+	stopBackground()
+
+	// WebSocket connections are hijacked and not tracked by srv.Shutdown,
+	// so close them explicitly once in-flight requests have drained
+	if err := taskService.Shutdown(ctx); err != nil {
+		logger.Warn("WebSocket hub did not drain cleanly", zap.Error(err))
+	}
+
+	if err := shutdownTracing(ctx); err != nil {
+		logger.Warn("Failed to flush traces", zap.Error(err))
+	}
+
 	logger.Info("Server exiting")
 }
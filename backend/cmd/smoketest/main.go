@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+type wsMessage struct {
+	Type    string `json:"type"`
+	Payload struct {
+		ID string `json:"id"`
+	} `json:"payload"`
+}
+
+type smokeTest struct {
+	baseURL  string
+	token    string
+	client   *http.Client
+	messages chan wsMessage
+	timeout  time.Duration
+}
+
+func main() {
+	baseURL := flag.String("url", envOr("SMOKE_BASE_URL", "http://localhost:8080"), "base URL of the deployment")
+	timeout := flag.Duration("timeout", 10*time.Second, "timeout for each step")
+	skipAI := flag.Bool("skip-ai", false, "skip the AI suggestion check")
+	flag.Parse()
+
+	st := &smokeTest{
+		baseURL:  strings.TrimRight(*baseURL, "/"),
+		client:   &http.Client{Timeout: *timeout},
+		messages: make(chan wsMessage, 16),
+		timeout:  *timeout,
+	}
+
+	if err := st.run(*skipAI); err != nil {
+		log.Printf("smoke test FAILED: %v", err)
+		os.Exit(1)
+	}
+	log.Println("smoke test passed")
+}
+
+func (st *smokeTest) run(skipAI bool) (err error) {
+	email := fmt.Sprintf("smoke-%s@example.com", uuid.New().String()[:8])
+	password := "smoke-" + uuid.New().String()[:8] + "1"
+
+	var auth struct {
+		Token string `json:"token"`
+		User  struct {
+			ID string `json:"id"`
+		} `json:"user"`
+	}
+	if err := st.call("POST", "/api/auth/register", map[string]string{
+		"email":    email,
+		"password": password,
+	}, http.StatusCreated, &auth); err != nil {
+		return fmt.Errorf("register: %w", err)
+	}
+	st.token = auth.Token
+	log.Printf("registered %s", email)
+
+	// Remove the throwaway user even when a later step fails
+	defer func() {
+		if deleteErr := st.call("DELETE", "/api/auth/me", nil, http.StatusNoContent, nil); deleteErr != nil {
+			log.Printf("failed to delete smoke test user %s: %v", email, deleteErr)
+			if err == nil {
+				err = fmt.Errorf("delete user: %w", deleteErr)
+			}
+			return
+		}
+		log.Printf("deleted user %s", email)
+	}()
+
+	conn, err := st.dialWebSocket()
+	if err != nil {
+		return fmt.Errorf("websocket: %w", err)
+	}
+	defer conn.Close()
+	go st.readMessages(conn)
+
+	var created struct {
+		Task struct {
+			ID string `json:"id"`
+		} `json:"task"`
+	}
+	if err := st.call("POST", "/api/tasks", map[string]interface{}{
+		"title":       "Smoke test task",
+		"description": "Created by cmd/smoketest",
+		"priority":    "medium",
+		"assigned_to": auth.User.ID,
+		"due_date":    time.Now().Add(24 * time.Hour).Format(time.RFC3339),
+	}, http.StatusCreated, &created); err != nil {
+		return fmt.Errorf("create task: %w", err)
+	}
+	taskID := created.Task.ID
+	if err := st.waitFor("task_created", taskID); err != nil {
+		return err
+	}
+	log.Printf("created task %s", taskID)
+
+	if err := st.call("PUT", "/api/tasks/"+taskID, map[string]string{
+		"status": "in_progress",
+	}, http.StatusOK, nil); err != nil {
+		return fmt.Errorf("update task: %w", err)
+	}
+	if err := st.waitFor("task_updated", taskID); err != nil {
+		return err
+	}
+	log.Printf("updated task %s", taskID)
+
+	if !skipAI {
+		if err := st.call("POST", "/api/ai/suggest", map[string]interface{}{
+			"task": map[string]string{
+				"id":          taskID,
+				"title":       "Smoke test task",
+				"description": "Created by cmd/smoketest",
+			},
+			"suggest_for": "priority",
+		}, http.StatusOK, nil); err != nil {
+			return fmt.Errorf("ai suggestion: %w", err)
+		}
+		log.Println("received AI suggestion")
+	}
+
+	if err := st.call("DELETE", "/api/tasks/"+taskID, nil, http.StatusOK, nil); err != nil {
+		return fmt.Errorf("delete task: %w", err)
+	}
+	if err := st.waitFor("task_deleted", taskID); err != nil {
+		return err
+	}
+	log.Printf("deleted task %s", taskID)
+
+	return nil
+}
+
+func (st *smokeTest) call(method, path string, body interface{}, wantStatus int, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, st.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if st.token != "" {
+		req.Header.Set("Authorization", "Bearer "+st.token)
+	}
+
+	resp, err := st.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != wantStatus {
+		return fmt.Errorf("expected status %d, got %d: %s", wantStatus, resp.StatusCode, data)
+	}
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+func (st *smokeTest) dialWebSocket() (*websocket.Conn, error) {
+	wsURL := "ws" + strings.TrimPrefix(st.baseURL, "http") + "/api/tasks/ws"
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+st.token)
+
+	dialer := websocket.Dialer{HandshakeTimeout: st.timeout}
+	conn, _, err := dialer.Dial(wsURL, header)
+	return conn, err
+}
+
+func (st *smokeTest) readMessages(conn *websocket.Conn) {
+	defer close(st.messages)
+	for {
+		var msg wsMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			return
+		}
+		st.messages <- msg
+	}
+}
+
+func (st *smokeTest) waitFor(msgType, taskID string) error {
+	deadline := time.After(st.timeout)
+	for {
+		select {
+		case msg, ok := <-st.messages:
+			if !ok {
+				return fmt.Errorf("websocket closed while waiting for %s", msgType)
+			}
+			if msg.Type == msgType && msg.Payload.ID == taskID {
+				return nil
+			}
+		case <-deadline:
+			return fmt.Errorf("timed out waiting for %s event for task %s", msgType, taskID)
+		}
+	}
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,162 @@
+package ai
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+)
+
+// anthropicMaxTokens caps replies. The Messages API requires a limit where
+// the other providers default to the model's.
+const anthropicMaxTokens = 4096
+
+// anthropicImageTypes are the image types the Messages API accepts. PDFs
+// are sent as documents.
+var anthropicImageTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+type anthropicProvider struct {
+	client      anthropic.Client
+	model       string
+	temperature float32
+}
+
+func newAnthropicProvider(config AIProviderConfig) *anthropicProvider {
+	// The service retries itself, with its own backoff
+	opts := []option.RequestOption{option.WithAPIKey(config.APIKey), option.WithMaxRetries(0)}
+	if config.BaseURL != "" {
+		opts = append(opts, option.WithBaseURL(config.BaseURL))
+	}
+	return &anthropicProvider{
+		client:      anthropic.NewClient(opts...),
+		model:       config.ModelName,
+		temperature: config.Temperature,
+	}
+}
+
+func (p *anthropicProvider) Name() string  { return ProviderAnthropic }
+func (p *anthropicProvider) Model() string { return p.model }
+
+// Ping fetches the model's metadata
+func (p *anthropicProvider) Ping(ctx context.Context) error {
+	_, err := p.client.Models.Get(ctx, p.model, anthropic.ModelGetParams{})
+	if err != nil {
+		return anthropicError(err)
+	}
+	return nil
+}
+
+func (p *anthropicProvider) Generate(ctx context.Context, prompt Prompt) (Completion, error) {
+	params, err := p.messageParams(prompt)
+	if err != nil {
+		return Completion{}, err
+	}
+	message, err := p.client.Messages.New(ctx, params)
+	if err != nil {
+		return Completion{}, anthropicError(err)
+	}
+
+	var text strings.Builder
+	for _, block := range message.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	if text.Len() == 0 {
+		return Completion{}, ErrInvalidResponse
+	}
+	return Completion{
+		Text:         text.String(),
+		Truncated:    message.StopReason == anthropic.StopReasonMaxTokens,
+		InputTokens:  int(message.Usage.InputTokens),
+		OutputTokens: int(message.Usage.OutputTokens),
+	}, nil
+}
+
+func (p *anthropicProvider) GenerateStream(ctx context.Context, prompt Prompt, onText func(text string) error) (Completion, error) {
+	params, err := p.messageParams(prompt)
+	if err != nil {
+		return Completion{}, err
+	}
+	stream := p.client.Messages.NewStreaming(ctx, params)
+	defer stream.Close()
+
+	var completion Completion
+	var text strings.Builder
+	for stream.Next() {
+		event := stream.Current()
+		switch event.Type {
+		case "message_start":
+			completion.InputTokens = int(event.Message.Usage.InputTokens)
+		case "content_block_delta":
+			if event.Delta.Type != "text_delta" || event.Delta.Text == "" {
+				continue
+			}
+			text.WriteString(event.Delta.Text)
+			if err := onText(event.Delta.Text); err != nil {
+				return Completion{}, err
+			}
+		case "message_delta":
+			completion.Truncated = event.Delta.StopReason == anthropic.StopReasonMaxTokens
+			completion.OutputTokens = int(event.Usage.OutputTokens)
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return Completion{}, anthropicError(err)
+	}
+	if text.Len() == 0 {
+		return Completion{}, ErrInvalidResponse
+	}
+	completion.Text = text.String()
+	return completion, nil
+}
+
+// messageParams is the Messages API request of prompt
+func (p *anthropicProvider) messageParams(prompt Prompt) (anthropic.MessageNewParams, error) {
+	var blocks []anthropic.ContentBlockParamUnion
+	if file := prompt.File; file != nil {
+		data := base64.StdEncoding.EncodeToString(file.Data)
+		switch {
+		case file.MIMEType == "application/pdf":
+			blocks = append(blocks, anthropic.NewDocumentBlock(anthropic.Base64PDFSourceParam{Data: data}))
+		case anthropicImageTypes[file.MIMEType]:
+			blocks = append(blocks, anthropic.NewImageBlockBase64(file.MIMEType, data))
+		default:
+			return anthropic.MessageNewParams{}, fmt.Errorf("%w: %s", ErrUnsupportedFile, file.MIMEType)
+		}
+	}
+	blocks = append(blocks, anthropic.NewTextBlock(prompt.Text))
+
+	maxTokens := int64(anthropicMaxTokens)
+	if prompt.Options.MaxTokens > 0 {
+		maxTokens = int64(prompt.Options.MaxTokens)
+	}
+	return anthropic.MessageNewParams{
+		Model:       anthropic.Model(prompt.Options.model(p.model)),
+		MaxTokens:   maxTokens,
+		Messages:    []anthropic.MessageParam{anthropic.NewUserMessage(blocks...)},
+		Temperature: anthropic.Float(float64(prompt.Options.temperature(p.temperature))),
+	}, nil
+}
+
+// anthropicError maps a failed Anthropic call. Running out of credits is a
+// 400 whose message names the credit balance.
+func anthropicError(err error) error {
+	var apiErr *anthropic.Error
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+	if strings.Contains(apiErr.RawJSON(), "credit balance") {
+		return fmt.Errorf("%w: %v", ErrQuota, err)
+	}
+	return statusError(apiErr.StatusCode, err)
+}
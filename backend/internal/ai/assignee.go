@@ -0,0 +1,174 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/chaos"
+	"github.com/iSparshP/real-time-task-management-system/internal/task"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// SuggestAssignee asks who should take a task
+const SuggestAssignee = "assignee"
+
+var (
+	ErrAssigneeUnavailable = errors.New("assignee suggestions are not configured")
+	ErrNoCandidates        = errors.New("no candidates to suggest an assignee from")
+)
+
+// WorkloadLoader is implemented by the task service so assignee
+// suggestions can weigh each candidate's workload
+type WorkloadLoader interface {
+	AssignmentCandidates(ctx context.Context, project string, userIDs []string) ([]task.Workload, error)
+}
+
+// SetWorkloadLoader enables assignee suggestions
+func (s *Service) SetWorkloadLoader(workloads WorkloadLoader) {
+	s.workloads = workloads
+}
+
+// suggestAssignees ranks the candidates for req.Task by how well they
+// could take it on, best first. Candidates are req.CandidateIDs, or the
+// members of the task's project.
+func (s *Service) suggestAssignees(ctx context.Context, req SuggestionRequest, userID string) (*SuggestionResponse, error) {
+	if s.workloads == nil {
+		return nil, ErrAssigneeUnavailable
+	}
+	candidates, err := s.workloads.AssignmentCandidates(ctx, req.Task.Project, req.CandidateIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, ErrNoCandidates
+	}
+	prompt, err := buildAssigneePrompt(req, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp *SuggestionResponse
+	err = s.withRetry(ctx, func() error {
+		if s.faults.ShouldFailAI() {
+			return fmt.Errorf("%w: %v", ErrAIProviderUnavailable, chaos.ErrInjectedFault)
+		}
+		ctx, span := s.startSpan(ctx, "GenerateContent",
+			attribute.String("ai.suggest_for", SuggestAssignee),
+			attribute.Int("ai.candidates", len(candidates)),
+		)
+		defer span.End()
+
+		completion, err := s.generate(ctx, Prompt{Text: prompt}, userID)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return err
+		}
+		resp, err = parseAssigneeReply(completion.Text, candidates)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.cacheResponse(ctx, s.getCacheKey(req), resp, 0)
+	return resp, nil
+}
+
+func buildAssigneePrompt(req SuggestionRequest, candidates []task.Workload) (string, error) {
+	type promptCandidate struct {
+		UserID            string  `json:"user_id"`
+		Email             string  `json:"email"`
+		OpenTasks         int64   `json:"open_tasks"`
+		OpenHigh          int64   `json:"open_high_priority"`
+		OpenMedium        int64   `json:"open_medium_priority"`
+		OpenLow           int64   `json:"open_low_priority"`
+		Overdue           int64   `json:"overdue"`
+		OpenEffort        float64 `json:"open_effort_hours"`
+		CompletedPerWeek  float64 `json:"completed_per_week"`
+		CompletedRecently int64   `json:"completed_last_30_days"`
+	}
+	weeks := task.WorkloadWindow.Hours() / (24 * 7)
+	list := make([]promptCandidate, len(candidates))
+	for i, c := range candidates {
+		list[i] = promptCandidate{
+			UserID:            c.UserID,
+			Email:             c.Email,
+			OpenTasks:         c.OpenTasks,
+			OpenHigh:          c.OpenHigh,
+			OpenMedium:        c.OpenMedium,
+			OpenLow:           c.OpenLow,
+			Overdue:           c.Overdue,
+			OpenEffort:        c.OpenEffort,
+			CompletedPerWeek:  math.Round(float64(c.CompletedRecently)/weeks*10) / 10,
+			CompletedRecently: c.CompletedRecently,
+		}
+	}
+	people, err := json.Marshal(list)
+	if err != nil {
+		return "", err
+	}
+
+	prompt := fmt.Sprintf("For the task:\nTitle: %s\nDescription: %s\nPriority: %s\nDue Date: %s\n"+
+		"Rank the following candidates by how well they could take it on, best first. "+
+		"Weigh their open work, especially high-priority and overdue tasks, against how many tasks they "+
+		"have been completing, and explain each ranking. Reply with only a JSON array with one object per "+
+		`candidate and the keys "user_id" and "reasoning".`+"\n\n%s",
+		req.Task.Title, req.Task.Description, req.Task.Priority, req.Task.DueDate.Format("2006-01-02"), people)
+	if req.UserContext != "" {
+		prompt += fmt.Sprintf("\nAdditional context: %s", req.UserContext)
+	}
+	return prompt, nil
+}
+
+// parseAssigneeReply reads the model's ranking, which may be wrapped in a
+// markdown code fence. Users who are not candidates are dropped, as are
+// repeats.
+func parseAssigneeReply(reply string, candidates []task.Workload) (*SuggestionResponse, error) {
+	var items []struct {
+		UserID    string `json:"user_id"`
+		Reasoning string `json:"reasoning"`
+	}
+	if err := json.Unmarshal([]byte(trimCodeFence(reply)), &items); err != nil {
+		return nil, ErrInvalidResponse
+	}
+
+	emails := make(map[string]string, len(candidates))
+	for _, c := range candidates {
+		emails[c.UserID] = c.Email
+	}
+	resp := &SuggestionResponse{Suggestions: []Suggestion{}}
+	for _, item := range items {
+		id := strings.TrimSpace(item.UserID)
+		email, ok := emails[id]
+		if !ok {
+			continue
+		}
+		delete(emails, id)
+		resp.Suggestions = append(resp.Suggestions, Suggestion{
+			Type:       SuggestAssignee,
+			Suggestion: email,
+			Reasoning:  item.Reasoning,
+			Confidence: 1.0,
+			UserID:     id,
+			Rank:       len(resp.Suggestions) + 1,
+		})
+	}
+	if len(resp.Suggestions) == 0 {
+		return nil, ErrInvalidResponse
+	}
+	return resp, nil
+}
+
+// candidateKey identifies the candidate list of a request in the cache key
+func candidateKey(ids []string) string {
+	sorted := append([]string(nil), ids...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
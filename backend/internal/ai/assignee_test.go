@@ -0,0 +1,69 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/task"
+	"go.uber.org/zap"
+)
+
+type fakeWorkloads struct {
+	workloads []task.Workload
+	project   string
+}
+
+func (f *fakeWorkloads) AssignmentCandidates(ctx context.Context, project string, userIDs []string) ([]task.Workload, error) {
+	f.project = project
+	return f.workloads, nil
+}
+
+func TestAssigneeSuggestionsAreRankedCandidates(t *testing.T) {
+	provider := &fakeProvider{completion: Completion{Text: "```json\n" + `[
+		{"user_id": "user-2", "reasoning": "Lightest load"},
+		{"user_id": "stranger", "reasoning": "Not a candidate"},
+		{"user_id": "user-1", "reasoning": "Three overdue tasks"},
+		{"user_id": "user-2", "reasoning": "Repeated"}
+	]` + "\n```"}}
+	s := NewServiceWithProvider(provider, AIProviderConfig{}, zap.NewNop())
+	workloads := &fakeWorkloads{workloads: []task.Workload{
+		{UserID: "user-1", Email: "raj@example.com", OpenTasks: 5, Overdue: 3},
+		{UserID: "user-2", Email: "ana@example.com", OpenTasks: 1, CompletedRecently: 12},
+	}}
+	s.SetWorkloadLoader(workloads)
+
+	resp, err := s.GetSuggestions(context.Background(), SuggestionRequest{
+		Task:       task.Task{Title: "Fix checkout", Project: "web"},
+		SuggestFor: SuggestAssignee,
+	}, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if workloads.project != "web" {
+		t.Fatalf("candidates loaded for project %q, want the task's", workloads.project)
+	}
+	got := resp.Suggestions
+	if len(got) != 2 || got[0].UserID != "user-2" || got[0].Suggestion != "ana@example.com" || got[0].Rank != 1 ||
+		got[1].UserID != "user-1" || got[1].Rank != 2 {
+		t.Fatalf("suggestions = %+v, want the two candidates ranked", got)
+	}
+	if prompt := provider.prompts[0].Text; !strings.Contains(prompt, `"completed_per_week":2.8`) ||
+		!strings.Contains(prompt, `"overdue":3`) {
+		t.Fatalf("prompt = %q, want each candidate's workload", prompt)
+	}
+}
+
+func TestAssigneeSuggestionsNeedCandidates(t *testing.T) {
+	s := NewServiceWithProvider(&fakeProvider{}, AIProviderConfig{}, zap.NewNop())
+	req := SuggestionRequest{Task: task.Task{Title: "Fix checkout"}, SuggestFor: SuggestAssignee}
+
+	if _, err := s.GetSuggestions(context.Background(), req, "user-1"); !errors.Is(err, ErrAssigneeUnavailable) {
+		t.Fatalf("err = %v, want ErrAssigneeUnavailable without a workload loader", err)
+	}
+	s.SetWorkloadLoader(&fakeWorkloads{})
+	if _, err := s.GetSuggestions(context.Background(), req, "user-1"); !errors.Is(err, ErrNoCandidates) {
+		t.Fatalf("err = %v, want ErrNoCandidates", err)
+	}
+}
@@ -0,0 +1,318 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/chaos"
+	"github.com/iSparshP/real-time-task-management-system/internal/task"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultBatchMaxTasks = 20
+	defaultBatchWorkers  = 4
+	// batchPromptSize is how many tasks share one prompt. Larger prompts
+	// save quota but make a truncated reply lose more tasks.
+	batchPromptSize = 5
+)
+
+var (
+	ErrTooManyTasks     = errors.New("too many tasks in batch")
+	ErrBatchUnavailable = errors.New("batch suggestions are not configured")
+)
+
+// TaskLoader is implemented by the task service so batches can be asked
+// for by task ID
+type TaskLoader interface {
+	GetTasksByIDs(ctx context.Context, ids []string) ([]task.Task, error)
+}
+
+// SetTaskLoader enables batch suggestions for stored tasks
+func (s *Service) SetTaskLoader(tasks TaskLoader) {
+	s.tasks = tasks
+}
+
+// batchItem is one entry of the model's JSON reply to a batch prompt
+type batchItem struct {
+	TaskID     string `json:"task_id"`
+	Suggestion string `json:"suggestion"`
+	Reasoning  string `json:"reasoning"`
+}
+
+// GetBatchSuggestions suggests req.SuggestFor for each task in req.TaskIDs.
+// Cached suggestions are reused, the rest are sent a few tasks per prompt
+// by a small worker pool, and every prompt waits for userID's rate bucket
+// and counts towards its daily quota like single suggestions. Failures are
+// reported per task in request order.
+func (s *Service) GetBatchSuggestions(ctx context.Context, req BatchSuggestionRequest, userID string) (*BatchSuggestionResponse, error) {
+	if s.tasks == nil {
+		return nil, ErrBatchUnavailable
+	}
+	ids := uniqueIDs(req.TaskIDs)
+	if len(ids) > s.batchMaxTasks() {
+		return nil, fmt.Errorf("%w: at most %d", ErrTooManyTasks, s.batchMaxTasks())
+	}
+
+	tasks, err := s.tasks.GetTasksByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]task.Task, len(tasks))
+	for _, t := range tasks {
+		byID[t.ID] = t
+	}
+
+	results := make([]BatchSuggestionResult, len(ids))
+	index := make(map[string]int, len(ids))
+	var pending []task.Task
+	for i, id := range ids {
+		results[i].TaskID = id
+		index[id] = i
+		t, ok := byID[id]
+		if !ok {
+			results[i].Error = "task not found"
+			continue
+		}
+		t, req.UserContext, err = s.guardInput(ctx, t, req.UserContext, userID)
+		if err != nil {
+			results[i].Error = batchErrorMessage(err)
+			continue
+		}
+		key := s.getCacheKey(SuggestionRequest{Task: t, SuggestFor: req.SuggestFor, UserContext: req.UserContext})
+		var cached SuggestionResponse
+		if s.cachedResponse(ctx, key, &cached) {
+			results[i].Suggestions = cached.Suggestions
+			continue
+		}
+		pending = append(pending, t)
+	}
+
+	chunks := chunkTasks(pending, batchPromptSize)
+	runPool(len(chunks), s.batchWorkers(), func(n int) {
+		chunk := chunks[n]
+		replies, err := s.suggestChunk(ctx, chunk, req, userID)
+		for _, t := range chunk {
+			r := &results[index[t.ID]]
+			if err != nil {
+				r.Error = batchErrorMessage(err)
+				continue
+			}
+			item, ok := replies[t.ID]
+			if !ok || item.Suggestion == "" {
+				r.Error = "no suggestion returned"
+				continue
+			}
+			resp := &SuggestionResponse{Suggestions: []Suggestion{{
+				Type:       "primary",
+				Suggestion: item.Suggestion,
+				Reasoning:  item.Reasoning,
+				Confidence: 1.0,
+			}}}
+			s.cacheResponse(ctx, s.getCacheKey(SuggestionRequest{Task: t, SuggestFor: req.SuggestFor, UserContext: req.UserContext}),
+				resp, 0)
+			r.Suggestions = resp.Suggestions
+		}
+		if err != nil && !isExpectedBatchError(err) {
+			s.logger.Error("Batch suggestion prompt failed",
+				zap.Error(err),
+				zap.Int("tasks", len(chunk)),
+				zap.String("suggest_for", req.SuggestFor),
+			)
+		}
+	})
+
+	return &BatchSuggestionResponse{Results: results}, nil
+}
+
+// suggestChunk sends one prompt for chunk once userID's rate bucket
+// allows, retrying like single suggestions, and returns the replies by task
+// ID
+func (s *Service) suggestChunk(ctx context.Context, chunk []task.Task, req BatchSuggestionRequest, userID string) (map[string]batchItem, error) {
+	if err := s.userLimiter(userID).Wait(ctx); err != nil {
+		return nil, ErrRateLimitExceeded
+	}
+	if err := s.checkQuota(ctx, userID); err != nil {
+		return nil, err
+	}
+	prompt, err := buildBatchPrompt(chunk, req.SuggestFor, req.UserContext)
+	if err != nil {
+		return nil, err
+	}
+
+	var replies map[string]batchItem
+	err = s.withRetry(ctx, func() error {
+		reply, err := s.generateBatch(ctx, prompt, req.SuggestFor, len(chunk), userID)
+		if err != nil {
+			return err
+		}
+		replies, err = parseBatchReply(reply)
+		return err
+	})
+	return replies, err
+}
+
+func (s *Service) generateBatch(ctx context.Context, prompt, suggestFor string, size int, userID string) (string, error) {
+	if s.faults.ShouldFailAI() {
+		return "", fmt.Errorf("%w: %v", ErrAIProviderUnavailable, chaos.ErrInjectedFault)
+	}
+
+	ctx, span := s.startSpan(ctx, "GenerateBatch",
+		attribute.String("ai.suggest_for", suggestFor),
+		attribute.Int("ai.batch_size", size),
+	)
+	defer span.End()
+
+	completion, err := s.generate(ctx, Prompt{Text: prompt}, userID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return "", err
+	}
+	return completion.Text, nil
+}
+
+// batchInstructions mirrors buildPrompt's per-type wording for several tasks
+var batchInstructions = map[string]string{
+	"priority": "suggest an appropriate priority level (low/medium/high), considering task complexity, due date, and impact",
+	"deadline": "suggest an appropriate deadline considering the task complexity and priority",
+	"approach": "suggest the best approach to complete it efficiently, breaking it down into smaller steps if appropriate",
+}
+
+func buildBatchPrompt(chunk []task.Task, suggestFor, userContext string) (string, error) {
+	type promptTask struct {
+		TaskID      string `json:"task_id"`
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		Priority    string `json:"priority"`
+		DueDate     string `json:"due_date"`
+	}
+	list := make([]promptTask, len(chunk))
+	for i, t := range chunk {
+		list[i] = promptTask{
+			TaskID:      t.ID,
+			Title:       t.Title,
+			Description: t.Description,
+			Priority:    string(t.Priority),
+			DueDate:     t.DueDate.Format("2006-01-02"),
+		}
+	}
+	tasks, err := json.Marshal(list)
+	if err != nil {
+		return "", err
+	}
+
+	prompt := fmt.Sprintf("For each of the following tasks, %s, and provide reasoning. "+
+		"Reply with only a JSON array with one object per task and the keys "+
+		`"task_id", "suggestion" and "reasoning".`+"\n\n%s",
+		batchInstructions[suggestFor], tasks)
+	if userContext != "" {
+		prompt += fmt.Sprintf("\nAdditional context: %s", userContext)
+	}
+	return prompt, nil
+}
+
+// parseBatchReply reads the model's JSON array, which may be wrapped in a
+// markdown code fence
+func parseBatchReply(reply string) (map[string]batchItem, error) {
+	var items []batchItem
+	if err := json.Unmarshal([]byte(trimCodeFence(reply)), &items); err != nil {
+		return nil, ErrInvalidResponse
+	}
+	byID := make(map[string]batchItem, len(items))
+	for _, item := range items {
+		byID[item.TaskID] = item
+	}
+	return byID, nil
+}
+
+// runPool calls fn for each of n jobs on at most workers goroutines and
+// returns once all are done
+func runPool(n, workers int, fn func(job int)) {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(workers, n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				fn(job)
+			}
+		}()
+	}
+	for job := 0; job < n; job++ {
+		jobs <- job
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+func chunkTasks(tasks []task.Task, size int) [][]task.Task {
+	var chunks [][]task.Task
+	for len(tasks) > size {
+		chunks = append(chunks, tasks[:size])
+		tasks = tasks[size:]
+	}
+	if len(tasks) > 0 {
+		chunks = append(chunks, tasks)
+	}
+	return chunks
+}
+
+// uniqueIDs drops repeated IDs so a task is only suggested for once
+func uniqueIDs(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	unique := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// batchErrorMessage is the per-task error shown to clients, matching the
+// messages of the single suggestion endpoint
+func batchErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, ErrRateLimitExceeded):
+		return "Rate limit exceeded"
+	case errors.Is(err, ErrDailyQuotaExceeded):
+		return "Daily AI quota exceeded"
+	case errors.Is(err, ErrRateLimit):
+		return "AI provider rate limit exceeded"
+	case errors.Is(err, ErrQuota):
+		return "AI provider quota exceeded"
+	case errors.Is(err, ErrInvalidResponse):
+		return "Failed to process AI response"
+	case errors.Is(err, ErrInputRejected):
+		return "Input rejected by moderation"
+	default:
+		return "AI service temporarily unavailable"
+	}
+}
+
+func isExpectedBatchError(err error) bool {
+	return errors.Is(err, ErrRateLimitExceeded) || errors.Is(err, ErrDailyQuotaExceeded) || errors.Is(err, ErrRateLimit) ||
+		errors.Is(err, ErrQuota) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+func (s *Service) batchMaxTasks() int {
+	if s.config.BatchMaxTasks > 0 {
+		return s.config.BatchMaxTasks
+	}
+	return defaultBatchMaxTasks
+}
+
+func (s *Service) batchWorkers() int {
+	if s.config.BatchWorkers > 0 {
+		return s.config.BatchWorkers
+	}
+	return defaultBatchWorkers
+}
@@ -0,0 +1,130 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/task"
+	"github.com/patrickmn/go-cache"
+	"go.uber.org/zap"
+)
+
+type fakeLoader struct {
+	tasks []task.Task
+}
+
+func (f fakeLoader) GetTasksByIDs(ctx context.Context, ids []string) ([]task.Task, error) {
+	return f.tasks, nil
+}
+
+func newBatchTestService(loader TaskLoader, maxTasks int) *Service {
+	s := &Service{
+		provider:     &fakeProvider{},
+		config:       AIProviderConfig{BatchMaxTasks: maxTasks},
+		logger:       zap.NewNop(),
+		cache:        cache.New(time.Minute, time.Minute),
+		responses:    NewMemoryResponseCache(),
+		responseTTL:  time.Minute,
+		prompts:      DefaultPromptTemplates(),
+		userLimiters: cache.New(time.Minute, time.Minute),
+	}
+	s.SetTaskLoader(loader)
+	return s
+}
+
+func TestGetBatchSuggestionsServesCacheAndReportsMissingTasks(t *testing.T) {
+	cached := task.Task{ID: "task-1", Title: "Ship release"}
+	s := newBatchTestService(fakeLoader{tasks: []task.Task{cached}}, 5)
+	want := []Suggestion{{Type: "primary", Suggestion: "high"}}
+	s.cacheResponse(context.Background(), s.getCacheKey(SuggestionRequest{Task: cached, SuggestFor: "priority"}),
+		&SuggestionResponse{Suggestions: want}, 0)
+
+	resp, err := s.GetBatchSuggestions(context.Background(), BatchSuggestionRequest{
+		TaskIDs:    []string{"missing", "task-1", "missing"},
+		SuggestFor: "priority",
+	}, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Results) != 2 {
+		t.Fatalf("results = %+v, want one per distinct ID", resp.Results)
+	}
+	if resp.Results[0].TaskID != "missing" || resp.Results[0].Error != "task not found" {
+		t.Fatalf("first result = %+v, want task not found", resp.Results[0])
+	}
+	if resp.Results[1].TaskID != "task-1" || len(resp.Results[1].Suggestions) != 1 ||
+		resp.Results[1].Suggestions[0].Suggestion != "high" {
+		t.Fatalf("second result = %+v, want the cached suggestion", resp.Results[1])
+	}
+}
+
+func TestGetBatchSuggestionsRejectsTooManyTasks(t *testing.T) {
+	s := newBatchTestService(fakeLoader{}, 2)
+	_, err := s.GetBatchSuggestions(context.Background(), BatchSuggestionRequest{
+		TaskIDs:    []string{"a", "b", "c"},
+		SuggestFor: "approach",
+	}, "user-1")
+	if !errors.Is(err, ErrTooManyTasks) {
+		t.Fatalf("err = %v, want ErrTooManyTasks", err)
+	}
+}
+
+func TestParseBatchReplyAcceptsFencedJSON(t *testing.T) {
+	reply := "```json\n[{\"task_id\":\"a\",\"suggestion\":\"low\",\"reasoning\":\"minor\"}]\n```"
+	items, err := parseBatchReply(reply)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if items["a"].Suggestion != "low" || items["a"].Reasoning != "minor" {
+		t.Fatalf("items = %+v", items)
+	}
+
+	if _, err := parseBatchReply("high for all of them"); !errors.Is(err, ErrInvalidResponse) {
+		t.Fatalf("err = %v, want ErrInvalidResponse", err)
+	}
+}
+
+func TestChunkTasks(t *testing.T) {
+	tasks := make([]task.Task, 11)
+	chunks := chunkTasks(tasks, 5)
+	if len(chunks) != 3 || len(chunks[0]) != 5 || len(chunks[2]) != 1 {
+		t.Fatalf("chunk sizes wrong: %d chunks", len(chunks))
+	}
+	if chunkTasks(nil, 5) != nil {
+		t.Fatal("want no chunks for no tasks")
+	}
+}
+
+func TestRunPoolBoundsConcurrency(t *testing.T) {
+	var running, peak atomic.Int32
+	var mu sync.Mutex
+	done := make([]bool, 10)
+
+	runPool(len(done), 3, func(job int) {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		done[job] = true
+		mu.Unlock()
+		running.Add(-1)
+	})
+
+	for i, ok := range done {
+		if !ok {
+			t.Fatalf("job %d did not run", i)
+		}
+	}
+	if peak.Load() > 3 {
+		t.Fatalf("peak concurrency = %d, want at most 3", peak.Load())
+	}
+}
@@ -0,0 +1,192 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/chaos"
+	"github.com/iSparshP/real-time-task-management-system/internal/task"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+const (
+	defaultBreakdownSubtasks = 8
+	// maxSubtaskTitle leaves room for the estimate in a checklist item
+	maxSubtaskTitle = 200
+)
+
+var (
+	ErrBreakdownUnavailable = errors.New("task breakdown is not configured")
+	ErrTaskNotFound         = errors.New("task not found")
+)
+
+// ChecklistWriter is implemented by the task service so a breakdown can be
+// applied to the task. Tasks have no subtasks yet, so the proposals are
+// added as checklist items.
+type ChecklistWriter interface {
+	AddChecklistItems(ctx context.Context, taskID string, texts []string, userID string) (*task.ChecklistResponse, error)
+}
+
+// SetChecklistWriter lets breakdown requests apply their proposals
+func (s *Service) SetChecklistWriter(checklist ChecklistWriter) {
+	s.checklist = checklist
+}
+
+// Breakdown proposes subtasks for a stored task and, when req.Apply is set,
+// adds them to the task's checklist as userID
+func (s *Service) Breakdown(ctx context.Context, req BreakdownRequest, userID string) (*BreakdownResponse, error) {
+	if s.tasks == nil || (req.Apply && s.checklist == nil) {
+		return nil, ErrBreakdownUnavailable
+	}
+	tasks, err := s.tasks.GetTasksByIDs(ctx, []string{req.TaskID})
+	if err != nil {
+		return nil, err
+	}
+	if len(tasks) == 0 {
+		return nil, ErrTaskNotFound
+	}
+	t := tasks[0]
+
+	if err := s.admit(ctx, userID); err != nil {
+		return nil, err
+	}
+	t, userContext, err := s.guardInput(ctx, t, req.UserContext, userID)
+	if err != nil {
+		return nil, err
+	}
+	limit := req.MaxSubtasks
+	if limit <= 0 {
+		limit = defaultBreakdownSubtasks
+	}
+	prompt, err := buildBreakdownPrompt(t, limit, userContext)
+	if err != nil {
+		return nil, err
+	}
+
+	var subtasks []ProposedSubtask
+	err = s.withRetry(ctx, func() error {
+		reply, err := s.generateBreakdown(ctx, prompt, userID)
+		if err != nil {
+			return err
+		}
+		subtasks, err = parseBreakdownReply(reply, limit)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &BreakdownResponse{TaskID: t.ID, Subtasks: subtasks}
+	for _, st := range subtasks {
+		resp.TotalEstimatedHours += st.EstimatedHours
+	}
+	if !req.Apply {
+		return resp, nil
+	}
+
+	texts := make([]string, len(subtasks))
+	for i, st := range subtasks {
+		texts[i] = st.checklistText()
+	}
+	if resp.Checklist, err = s.checklist.AddChecklistItems(ctx, t.ID, texts, userID); err != nil {
+		return nil, err
+	}
+	resp.Applied = true
+	return resp, nil
+}
+
+func (s *Service) generateBreakdown(ctx context.Context, prompt, userID string) (string, error) {
+	if s.faults.ShouldFailAI() {
+		return "", fmt.Errorf("%w: %v", ErrAIProviderUnavailable, chaos.ErrInjectedFault)
+	}
+
+	ctx, span := s.startSpan(ctx, "GenerateBreakdown")
+	defer span.End()
+
+	completion, err := s.generate(ctx, Prompt{Text: prompt}, userID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return "", err
+	}
+	if completion.Truncated {
+		span.SetAttributes(attribute.Bool("ai.truncated", true))
+		return "", ErrInvalidResponse
+	}
+	return completion.Text, nil
+}
+
+func buildBreakdownPrompt(t task.Task, limit int, userContext string) (string, error) {
+	source, err := json.Marshal(struct {
+		Title           string  `json:"title"`
+		Description     string  `json:"description"`
+		Priority        string  `json:"priority"`
+		DueDate         string  `json:"due_date"`
+		EstimatedEffort float64 `json:"estimated_effort_hours,omitempty"`
+	}{
+		Title:           t.Title,
+		Description:     t.Description,
+		Priority:        string(t.Priority),
+		DueDate:         t.DueDate.Format("2006-01-02"),
+		EstimatedEffort: t.EstimatedEffort,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	prompt := fmt.Sprintf("Break the following task down into at most %d concrete subtasks in the order "+
+		"they should be done, and estimate each in hours. Reply with only a JSON array with one object "+
+		`per subtask and the keys "title" and "estimate_hours".`+"\n\n%s", limit, source)
+	if userContext != "" {
+		prompt += fmt.Sprintf("\nAdditional context: %s", userContext)
+	}
+	return prompt, nil
+}
+
+// parseBreakdownReply reads the model's JSON array, which may be wrapped in
+// a markdown code fence. Untitled entries are dropped, and the list is cut
+// to limit.
+func parseBreakdownReply(reply string, limit int) ([]ProposedSubtask, error) {
+	var items []struct {
+		Title         string  `json:"title"`
+		EstimateHours float64 `json:"estimate_hours"`
+	}
+	if err := json.Unmarshal([]byte(trimCodeFence(reply)), &items); err != nil {
+		return nil, ErrInvalidResponse
+	}
+
+	subtasks := make([]ProposedSubtask, 0, min(len(items), limit))
+	for _, item := range items {
+		title := strings.TrimSpace(item.Title)
+		if title == "" {
+			continue
+		}
+		if len(subtasks) == limit {
+			break
+		}
+		if len(title) > maxSubtaskTitle {
+			title = strings.ToValidUTF8(title[:maxSubtaskTitle], "")
+		}
+		subtasks = append(subtasks, ProposedSubtask{
+			Title:          title,
+			EstimatedHours: math.Round(max(item.EstimateHours, 0)*4) / 4,
+		})
+	}
+	if len(subtasks) == 0 {
+		return nil, ErrInvalidResponse
+	}
+	return subtasks, nil
+}
+
+// checklistText is how an applied subtask reads on the checklist
+func (p ProposedSubtask) checklistText() string {
+	if p.EstimatedHours == 0 {
+		return p.Title
+	}
+	return fmt.Sprintf("%s (est. %gh)", p.Title, p.EstimatedHours)
+}
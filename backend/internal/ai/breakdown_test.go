@@ -0,0 +1,94 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/task"
+	"go.uber.org/zap"
+)
+
+type fakeChecklist struct {
+	taskID string
+	texts  []string
+	userID string
+}
+
+func (f *fakeChecklist) AddChecklistItems(ctx context.Context, taskID string, texts []string, userID string) (*task.ChecklistResponse, error) {
+	f.taskID, f.texts, f.userID = taskID, texts, userID
+	return &task.ChecklistResponse{TaskID: taskID}, nil
+}
+
+func TestParseBreakdownReply(t *testing.T) {
+	reply := "```json\n" + `[
+		{"title": " Write the migration ", "estimate_hours": 1.9},
+		{"title": "", "estimate_hours": 3},
+		{"title": "Backfill rows", "estimate_hours": -1},
+		{"title": "Drop the old column", "estimate_hours": 1}
+	]` + "\n```"
+
+	subtasks, err := parseBreakdownReply(reply, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ProposedSubtask{{"Write the migration", 2}, {"Backfill rows", 0}}
+	if len(subtasks) != len(want) || subtasks[0] != want[0] || subtasks[1] != want[1] {
+		t.Fatalf("subtasks = %+v, want %+v", subtasks, want)
+	}
+
+	for _, reply := range []string{"Sure! Here are the steps", `[{"title": " "}]`} {
+		if _, err := parseBreakdownReply(reply, 5); !errors.Is(err, ErrInvalidResponse) {
+			t.Errorf("reply %q: err = %v, want ErrInvalidResponse", reply, err)
+		}
+	}
+}
+
+func TestBreakdownAppliesProposalsToChecklist(t *testing.T) {
+	provider := &fakeProvider{completion: Completion{
+		Text: `[{"title": "Design", "estimate_hours": 2.5}, {"title": "Review", "estimate_hours": 0}]`,
+	}}
+	s := NewServiceWithProvider(provider, AIProviderConfig{}, zap.NewNop())
+	s.SetTaskLoader(fakeLoader{tasks: []task.Task{{ID: "task-1", Title: "Launch page"}}})
+	checklist := &fakeChecklist{}
+	s.SetChecklistWriter(checklist)
+
+	resp, err := s.Breakdown(context.Background(), BreakdownRequest{TaskID: "task-1", MaxSubtasks: 4}, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Applied || resp.TotalEstimatedHours != 2.5 || checklist.texts != nil {
+		t.Fatalf("resp = %+v, want proposals only", resp)
+	}
+	if !strings.Contains(provider.prompts[0].Text, "at most 4") || !strings.Contains(provider.prompts[0].Text, "Launch page") {
+		t.Fatalf("prompt = %q, want the task and limit", provider.prompts[0].Text)
+	}
+
+	resp, err = s.Breakdown(context.Background(), BreakdownRequest{TaskID: "task-1", Apply: true}, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Applied || resp.Checklist == nil || checklist.taskID != "task-1" || checklist.userID != "user-1" {
+		t.Fatalf("resp = %+v, checklist = %+v, want the proposals applied", resp, checklist)
+	}
+	if len(checklist.texts) != 2 || checklist.texts[0] != "Design (est. 2.5h)" || checklist.texts[1] != "Review" {
+		t.Fatalf("texts = %q, want titles with estimates", checklist.texts)
+	}
+}
+
+func TestBreakdownUnknownTask(t *testing.T) {
+	provider := &fakeProvider{}
+	s := NewServiceWithProvider(provider, AIProviderConfig{}, zap.NewNop())
+	s.SetTaskLoader(fakeLoader{})
+
+	if _, err := s.Breakdown(context.Background(), BreakdownRequest{TaskID: "missing"}, "user-1"); !errors.Is(err, ErrTaskNotFound) {
+		t.Fatalf("err = %v, want ErrTaskNotFound", err)
+	}
+	if _, err := s.Breakdown(context.Background(), BreakdownRequest{TaskID: "missing", Apply: true}, "user-1"); !errors.Is(err, ErrBreakdownUnavailable) {
+		t.Fatalf("err = %v, want ErrBreakdownUnavailable without a checklist writer", err)
+	}
+	if len(provider.prompts) != 0 {
+		t.Fatalf("prompts = %d, want no provider calls", len(provider.prompts))
+	}
+}
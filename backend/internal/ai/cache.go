@@ -0,0 +1,159 @@
+package ai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	// responseCacheVersion is part of every response cache key. Bump it
+	// when prompts or the cached types change, so replicas running either
+	// version never read each other's replies.
+	responseCacheVersion = 1
+	defaultResponseTTL   = 5 * time.Minute
+)
+
+// ResponseCache keeps the model's replies for reuse, as JSON. Get reports
+// found as false on a miss.
+type ResponseCache interface {
+	Get(ctx context.Context, key string) (value []byte, found bool, err error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// SetResponseCache caches suggestions in responses for ttl; a nil cache or
+// a ttl of 0 disables caching. Translations are kept for an hour, since
+// their key holds the task text.
+func (s *Service) SetResponseCache(responses ResponseCache, ttl time.Duration) {
+	if ttl <= 0 {
+		responses = nil
+	}
+	s.responses = responses
+	s.responseTTL = ttl
+}
+
+// responseKey makes the cache key of a reply to key. It holds the
+// generation options in effect, so changing the settings or the configured
+// model stops old replies from being served on every replica.
+func (s *Service) responseKey(ctx context.Context, key string) string {
+	opts := s.generationOptions(ctx)
+	sum := sha256.Sum256(fmt.Appendf(nil, "%s\x00%g\x00%d\x00%s",
+		opts.model(s.provider.Model()), opts.temperature(s.config.Temperature), opts.MaxTokens, key))
+	return fmt.Sprintf("v%d:%s", responseCacheVersion, hex.EncodeToString(sum[:]))
+}
+
+// cachedResponse reads the cached reply to key into v, reporting whether
+// there was one. A cache that cannot be read counts as a miss.
+func (s *Service) cachedResponse(ctx context.Context, key string, v any) bool {
+	if s.responses == nil {
+		return false
+	}
+	data, found, err := s.responses.Get(ctx, s.responseKey(ctx, key))
+	if err == nil && found {
+		err = json.Unmarshal(data, v)
+	}
+	if err != nil {
+		s.logger.Warn("AI response cache unavailable", zap.Error(err))
+		return false
+	}
+	return found
+}
+
+// cacheResponse caches v as the reply to key for ttl, or for the
+// configured TTL when ttl is 0. Failures are only logged.
+func (s *Service) cacheResponse(ctx context.Context, key string, v any, ttl time.Duration) {
+	if s.responses == nil {
+		return
+	}
+	if ttl == 0 {
+		ttl = s.responseTTL
+	}
+	data, err := json.Marshal(v)
+	if err == nil {
+		err = s.responses.Set(ctx, s.responseKey(ctx, key), data, ttl)
+	}
+	if err != nil {
+		s.logger.Warn("Failed to cache AI response", zap.Error(err))
+	}
+}
+
+// MemoryResponseCache keeps replies in process, for single-replica
+// deployments. Expired replies are never served, but are only dropped by
+// Sweep.
+type MemoryResponseCache struct {
+	cache *cache.Cache
+}
+
+func NewMemoryResponseCache() *MemoryResponseCache {
+	return &MemoryResponseCache{cache: cache.New(defaultResponseTTL, 0)}
+}
+
+func (c *MemoryResponseCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	cached, found := c.cache.Get(key)
+	if !found {
+		return nil, false, nil
+	}
+	return cached.([]byte), true, nil
+}
+
+func (c *MemoryResponseCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.cache.Set(key, value, ttl)
+	return nil
+}
+
+// Sweep drops the expired replies
+func (c *MemoryResponseCache) Sweep(context.Context) (int, error) {
+	before := c.cache.ItemCount()
+	c.cache.DeleteExpired()
+	// Replies set meanwhile can make the difference negative
+	return max(before-c.cache.ItemCount(), 0), nil
+}
+
+// RedisResponseCache shares replies across replicas as values that expire
+// after their TTL. While Redis cannot be reached, replies are cached in
+// process instead.
+type RedisResponseCache struct {
+	client   *redis.Client
+	fallback *MemoryResponseCache
+}
+
+func NewRedisResponseCache(client *redis.Client) *RedisResponseCache {
+	return &RedisResponseCache{client: client, fallback: NewMemoryResponseCache()}
+}
+
+func (c *RedisResponseCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	data, err := c.client.Get(ctx, "ai:"+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, false, err
+		}
+		return c.fallback.Get(ctx, key)
+	}
+	return data, true, nil
+}
+
+func (c *RedisResponseCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := c.client.Set(ctx, "ai:"+key, value, ttl).Err(); err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		return c.fallback.Set(ctx, key, value, ttl)
+	}
+	return nil
+}
+
+// Sweep drops the expired replies cached while Redis was unreachable
+func (c *RedisResponseCache) Sweep(ctx context.Context) (int, error) {
+	return c.fallback.Sweep(ctx)
+}
@@ -0,0 +1,69 @@
+package ai
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/iSparshP/real-time-task-management-system/internal/task"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+func TestRedisResponseCacheSharesRepliesAcrossReplicas(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	// Two replicas sharing one Redis
+	first := &fakeProvider{completion: Completion{Text: "high"}}
+	a := NewServiceWithProvider(first, AIProviderConfig{}, zap.NewNop())
+	a.SetResponseCache(NewRedisResponseCache(client), time.Minute)
+	second := &fakeProvider{completion: Completion{Text: "low"}}
+	b := NewServiceWithProvider(second, AIProviderConfig{}, zap.NewNop())
+	b.SetResponseCache(NewRedisResponseCache(client), time.Minute)
+
+	req := SuggestionRequest{Task: task.Task{ID: "task-1"}, SuggestFor: "priority"}
+	if _, err := a.GetSuggestions(context.Background(), req, "user-1"); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := b.GetSuggestions(context.Background(), req, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(second.prompts) != 0 || resp.Suggestions[0].Suggestion != "high" {
+		t.Fatalf("second replica got %+v after %d prompts, want the first replica's reply", resp.Suggestions, len(second.prompts))
+	}
+	if ttl := mr.TTL("ai:" + a.responseKey(context.Background(), a.getCacheKey(req))); ttl != time.Minute {
+		t.Fatalf("TTL = %v, want the configured minute", ttl)
+	}
+
+	// Without Redis, each replica caches its own replies
+	mr.Close()
+	if _, err := b.GetSuggestions(context.Background(), req, "user-2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.GetSuggestions(context.Background(), req, "user-2"); err != nil {
+		t.Fatal(err)
+	}
+	if len(second.prompts) != 1 {
+		t.Fatalf("%d prompts without Redis, want the reply cached in process", len(second.prompts))
+	}
+}
+
+func TestSetResponseCacheDisablesCachingWithoutTTL(t *testing.T) {
+	provider := &fakeProvider{completion: Completion{Text: "high"}}
+	s := NewServiceWithProvider(provider, AIProviderConfig{}, zap.NewNop())
+	s.SetResponseCache(NewMemoryResponseCache(), 0)
+
+	req := SuggestionRequest{Task: task.Task{ID: "task-1"}, SuggestFor: "priority"}
+	for range 2 {
+		if _, err := s.GetSuggestions(context.Background(), req, "user-1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(provider.prompts) != 2 {
+		t.Fatalf("%d prompts, want every request sent", len(provider.prompts))
+	}
+}
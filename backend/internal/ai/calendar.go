@@ -0,0 +1,69 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/task"
+	"go.uber.org/zap"
+)
+
+// promptHolidays bounds the upcoming holidays a prompt lists
+const promptHolidays = 10
+
+// CalendarLoader is implemented by the task service so prompts can count
+// in the working days of a task's project
+type CalendarLoader interface {
+	GetCalendar(ctx context.Context, project string) (*task.ProjectCalendar, error)
+}
+
+// SetCalendarLoader has suggestion prompts describe the business calendar
+// of the task's project
+func (s *Service) SetCalendarLoader(calendars CalendarLoader) {
+	s.calendars = calendars
+}
+
+// describeCalendar describes the business calendar of req's task project
+// as of now, or returns "" if it has none. Prompts are still sent if the
+// calendar cannot be loaded.
+func (s *Service) describeCalendar(ctx context.Context, req SuggestionRequest, now time.Time) string {
+	if s.calendars == nil || req.SuggestFor == SuggestAssignee || req.Task.Project == "" {
+		return ""
+	}
+	calendar, err := s.calendars.GetCalendar(ctx, req.Task.Project)
+	if err != nil {
+		s.logger.Warn("Failed to load project calendar for prompt", zap.Error(err), zap.String("project", req.Task.Project))
+		return ""
+	}
+	if calendar == nil {
+		return ""
+	}
+	return calendarText(*calendar, now)
+}
+
+// calendarText tells the model which days the project works, what day it
+// is there and which holidays are coming up
+func calendarText(calendar task.ProjectCalendar, now time.Time) string {
+	days := make([]string, len(calendar.WorkingDays))
+	for i, day := range calendar.WorkingDays {
+		days[i] = day.String()
+	}
+	today := now.In(calendar.Location())
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "The project works %s in %s, where today is %s %s.",
+		strings.Join(days, ", "), calendar.Timezone, today.Weekday(), today.Format(time.DateOnly))
+	var upcoming []string
+	for _, holiday := range calendar.Holidays {
+		if holiday >= today.Format(time.DateOnly) && len(upcoming) < promptHolidays {
+			upcoming = append(upcoming, holiday)
+		}
+	}
+	if len(upcoming) > 0 {
+		fmt.Fprintf(&text, " Upcoming holidays: %s.", strings.Join(upcoming, ", "))
+	}
+	text.WriteString(" Count durations in working days and suggest a deadline on a working day.")
+	return text.String()
+}
@@ -0,0 +1,49 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/task"
+	"go.uber.org/zap"
+)
+
+type fakeCalendars map[string]*task.ProjectCalendar
+
+func (f fakeCalendars) GetCalendar(_ context.Context, project string) (*task.ProjectCalendar, error) {
+	return f[project], nil
+}
+
+func TestCalendarText(t *testing.T) {
+	calendar := task.ProjectCalendar{
+		Timezone:    "Asia/Tokyo",
+		WorkingDays: []time.Weekday{time.Monday, time.Tuesday},
+		Holidays:    []string{"2024-03-01", "2024-03-20"},
+	}
+	// Still the 19th in UTC, already the 20th in Tokyo
+	got := calendarText(calendar, time.Date(2024, 3, 19, 20, 0, 0, 0, time.UTC))
+	want := "The project works Monday, Tuesday in Asia/Tokyo, where today is Wednesday 2024-03-20. " +
+		"Upcoming holidays: 2024-03-20. Count durations in working days and suggest a deadline on a working day."
+	if got != want {
+		t.Fatalf("calendarText = %q, want %q", got, want)
+	}
+}
+
+func TestDeadlinePromptDescribesProjectCalendar(t *testing.T) {
+	provider := &fakeProvider{completion: Completion{Text: "Next Friday"}}
+	s := NewServiceWithProvider(provider, AIProviderConfig{}, zap.NewNop())
+	s.SetCalendarLoader(fakeCalendars{"ops": {Timezone: "UTC", WorkingDays: []time.Weekday{time.Friday}}})
+
+	for _, project := range []string{"web", "ops"} {
+		req := SuggestionRequest{Task: task.Task{ID: "task-" + project, Title: "Rotate keys", Project: project}, SuggestFor: "deadline"}
+		if _, err := s.GetSuggestions(context.Background(), req, "user-1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(provider.prompts) != 2 || strings.Contains(provider.prompts[0].Text, "working day") ||
+		!strings.Contains(provider.prompts[1].Text, "The project works Friday in UTC") {
+		t.Fatalf("prompts = %+v, want only the ops prompt to describe its calendar", provider.prompts)
+	}
+}
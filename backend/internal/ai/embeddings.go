@@ -0,0 +1,58 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/chaos"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+var ErrEmbeddingsUnsupported = errors.New("AI provider does not support embeddings")
+
+// EmbeddingModel is the model EmbedText uses, empty when the provider has
+// no embeddings API
+func (s *Service) EmbeddingModel() string {
+	if embedder, ok := s.provider.(EmbeddingProvider); ok {
+		return embedder.EmbeddingModel()
+	}
+	return ""
+}
+
+// EmbedText returns the embedding of text. It waits for its own rate
+// budget rather than failing, so embedding tasks does not use up the
+// budget of suggestions.
+func (s *Service) EmbedText(ctx context.Context, text string) ([]float32, error) {
+	embedder, ok := s.provider.(EmbeddingProvider)
+	if !ok {
+		return nil, ErrEmbeddingsUnsupported
+	}
+	if err := s.embedLimiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	if s.faults.ShouldFailAI() {
+		return nil, fmt.Errorf("%w: %v", ErrAIProviderUnavailable, chaos.ErrInjectedFault)
+	}
+
+	ctx, span := s.startSpan(ctx, "Embed", attribute.Int("ai.text_length", len(text)))
+	defer span.End()
+	span.SetAttributes(attribute.String("gen_ai.request.model", embedder.EmbeddingModel()))
+
+	callCtx, cancel := s.callContext(ctx)
+	defer cancel()
+	var vector []float32
+	err := s.callProvider(ctx, func() error {
+		var err error
+		vector, err = embedder.Embed(callCtx, text)
+		return s.callError(ctx, callCtx, err)
+	})
+	s.recordCall(ctx, err)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	return vector, nil
+}
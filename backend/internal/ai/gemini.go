@@ -0,0 +1,177 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type geminiProvider struct {
+	client         *genai.Client
+	model          *genai.GenerativeModel
+	modelName      string
+	temperature    float32
+	embeddingModel string
+}
+
+func newGeminiProvider(config AIProviderConfig) (*geminiProvider, error) {
+	client, err := genai.NewClient(context.Background(), option.WithAPIKey(config.APIKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Gemini client: %w", err)
+	}
+
+	model := client.GenerativeModel(config.ModelName)
+	model.SetTemperature(config.Temperature)
+	return &geminiProvider{
+		client:         client,
+		model:          model,
+		modelName:      config.ModelName,
+		temperature:    config.Temperature,
+		embeddingModel: config.EmbeddingModel,
+	}, nil
+}
+
+func (p *geminiProvider) Name() string  { return ProviderGemini }
+func (p *geminiProvider) Model() string { return p.modelName }
+
+// Ping fetches the model's metadata
+func (p *geminiProvider) Ping(ctx context.Context) error {
+	_, err := p.model.Info(ctx)
+	return err
+}
+
+func (p *geminiProvider) Generate(ctx context.Context, prompt Prompt) (Completion, error) {
+	resp, err := p.modelFor(prompt.Options).GenerateContent(ctx, geminiParts(prompt)...)
+	if err != nil {
+		return Completion{}, geminiError(err)
+	}
+	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil {
+		return Completion{}, ErrInvalidResponse
+	}
+
+	text := geminiText(resp.Candidates[0])
+	if text == "" {
+		return Completion{}, ErrInvalidResponse
+	}
+	completion := Completion{
+		Text:      text,
+		Truncated: resp.Candidates[0].FinishReason == genai.FinishReasonMaxTokens,
+	}
+	geminiUsage(&completion, resp)
+	return completion, nil
+}
+
+func (p *geminiProvider) GenerateStream(ctx context.Context, prompt Prompt, onText func(text string) error) (Completion, error) {
+	iter := p.modelFor(prompt.Options).GenerateContentStream(ctx, geminiParts(prompt)...)
+
+	var completion Completion
+	var text strings.Builder
+	for {
+		resp, err := iter.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return Completion{}, geminiError(err)
+		}
+		geminiUsage(&completion, resp)
+		if len(resp.Candidates) == 0 {
+			continue
+		}
+		if resp.Candidates[0].FinishReason == genai.FinishReasonMaxTokens {
+			completion.Truncated = true
+		}
+		chunk := geminiText(resp.Candidates[0])
+		if chunk == "" {
+			continue
+		}
+		text.WriteString(chunk)
+		if err := onText(chunk); err != nil {
+			return Completion{}, err
+		}
+	}
+	if text.Len() == 0 {
+		return Completion{}, ErrInvalidResponse
+	}
+	completion.Text = text.String()
+	return completion, nil
+}
+
+// modelFor is the model to call with opts. Models are cheap client-side
+// handles, so overrides get their own.
+func (p *geminiProvider) modelFor(opts GenerationOptions) *genai.GenerativeModel {
+	if opts == (GenerationOptions{}) {
+		return p.model
+	}
+	model := p.client.GenerativeModel(opts.model(p.modelName))
+	model.SetTemperature(opts.temperature(p.temperature))
+	if opts.MaxTokens > 0 {
+		model.SetMaxOutputTokens(int32(opts.MaxTokens))
+	}
+	return model
+}
+
+func geminiParts(prompt Prompt) []genai.Part {
+	if prompt.File != nil {
+		return []genai.Part{genai.Blob{MIMEType: prompt.File.MIMEType, Data: prompt.File.Data}, genai.Text(prompt.Text)}
+	}
+	return []genai.Part{genai.Text(prompt.Text)}
+}
+
+// geminiUsage sets the tokens resp reports on completion. Streamed
+// responses report the running totals, so the last one counts.
+func geminiUsage(completion *Completion, resp *genai.GenerateContentResponse) {
+	if resp.UsageMetadata == nil {
+		return
+	}
+	completion.InputTokens = int(resp.UsageMetadata.PromptTokenCount)
+	completion.OutputTokens = int(resp.UsageMetadata.CandidatesTokenCount)
+}
+
+// geminiText joins the text parts of candidate
+func geminiText(candidate *genai.Candidate) string {
+	if candidate.Content == nil {
+		return ""
+	}
+	var text strings.Builder
+	for _, part := range candidate.Content.Parts {
+		if t, ok := part.(genai.Text); ok {
+			text.WriteString(string(t))
+		}
+	}
+	return text.String()
+}
+
+func (p *geminiProvider) EmbeddingModel() string { return p.embeddingModel }
+
+func (p *geminiProvider) Embed(ctx context.Context, text string) ([]float32, error) {
+	resp, err := p.client.EmbeddingModel(p.embeddingModel).EmbedContent(ctx, genai.Text(text))
+	if err != nil {
+		return nil, geminiError(err)
+	}
+	if resp.Embedding == nil || len(resp.Embedding.Values) == 0 {
+		return nil, ErrInvalidResponse
+	}
+	return resp.Embedding.Values, nil
+}
+
+// geminiError maps a failed Gemini call. Quota and rate limit errors share
+// the RESOURCE_EXHAUSTED code, so they are told apart by their message.
+func geminiError(err error) error {
+	switch {
+	case strings.Contains(err.Error(), "quota"):
+		return fmt.Errorf("%w: %v", ErrQuota, err)
+	case strings.Contains(err.Error(), "rate"), status.Code(err) == codes.ResourceExhausted:
+		return fmt.Errorf("%w: %v", ErrRateLimit, err)
+	case status.Code(err) == codes.Unavailable:
+		return fmt.Errorf("%w: %v", ErrAIProviderUnavailable, err)
+	}
+	return err
+}
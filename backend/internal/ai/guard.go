@@ -0,0 +1,120 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/moderation"
+	"github.com/iSparshP/real-time-task-management-system/internal/task"
+	"go.uber.org/zap"
+)
+
+// Longest text of each field put into a prompt, in characters
+const (
+	maxPromptTitle       = 200
+	maxPromptDescription = 2000
+	maxPromptContext     = 500
+)
+
+// removedLine replaces the lines of user text that address the model
+const removedLine = "[removed]"
+
+var ErrInputRejected = errors.New("input rejected by moderation")
+
+// injectionPatterns match text that talks to the model rather than about
+// the task, such as "ignore the previous instructions" or a "system:" turn
+var injectionPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b(ignore|disregard|forget|override)\b.{0,40}\b(previous|prior|above|earlier|all|any|system|these)\b.{0,20}\b(instructions?|prompts?|rules|directions)\b`),
+	regexp.MustCompile(`(?i)\b(reveal|print|repeat|show|output)\b.{0,30}\b(system prompt|your (instructions|prompt|rules)|the prompt above)\b`),
+	regexp.MustCompile(`(?i)\byou are now\b|\bnew instructions\s*:|\b(system|developer) (prompt|message)\b`),
+	regexp.MustCompile(`(?i)^\s*(system|assistant|developer)\s*:`),
+	regexp.MustCompile(`(?i)</?\s*(system|instructions?|prompt|im_start|im_end)\s*>|<\|im_(start|end)\|>`),
+}
+
+// SetInputModerator has text from users checked by moderator before it is
+// put into a prompt. Inputs it does not allow are refused with
+// ErrInputRejected; when it fails, the input goes through.
+func (s *Service) SetInputModerator(moderator moderation.Moderator) {
+	s.moderator = moderator
+}
+
+// sanitizeInput caps text at limit characters, drops control characters
+// and replaces the lines that address the model. It reports whether any
+// line was replaced.
+func sanitizeInput(text string, limit int) (string, bool) {
+	text = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != '\n' && r != '\t' {
+			return -1
+		}
+		return r
+	}, text)
+	if runes := []rune(text); len(runes) > limit {
+		text = string(runes[:limit])
+	}
+
+	stripped := false
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		for _, pattern := range injectionPatterns {
+			if pattern.MatchString(line) {
+				lines[i] = removedLine
+				stripped = true
+				break
+			}
+		}
+	}
+	return strings.Join(lines, "\n"), stripped
+}
+
+// guardInput returns t and userContext sanitized for a prompt, after
+// moderation. Flagged inputs are logged.
+func (s *Service) guardInput(ctx context.Context, t task.Task, userContext, userID string) (task.Task, string, error) {
+	var fields []string
+	var stripped bool
+	if t.Title, stripped = sanitizeInput(t.Title, maxPromptTitle); stripped {
+		fields = append(fields, "title")
+	}
+	if t.Description, stripped = sanitizeInput(t.Description, maxPromptDescription); stripped {
+		fields = append(fields, "description")
+	}
+	if userContext, stripped = sanitizeInput(userContext, maxPromptContext); stripped {
+		fields = append(fields, "user_context")
+	}
+	if len(fields) > 0 {
+		s.logger.Warn("Removed instructions from AI input",
+			zap.String("user_id", userID),
+			zap.String("task_id", t.ID),
+			zap.Strings("fields", fields),
+		)
+	}
+
+	if s.moderator == nil {
+		return t, userContext, nil
+	}
+	verdict, err := s.moderator.Moderate(ctx, strings.Join([]string{t.Title, t.Description, userContext}, "\n\n"))
+	if err != nil {
+		s.logger.Warn("AI input moderation failed", zap.String("task_id", t.ID), zap.Error(err))
+		return t, userContext, nil
+	}
+	if !verdict.Allowed() {
+		s.logger.Warn("AI input flagged by moderation",
+			zap.String("user_id", userID),
+			zap.String("task_id", t.ID),
+			zap.String("action", string(verdict.Action)),
+			zap.Strings("reasons", verdict.Reasons),
+			zap.String("title", t.Title),
+		)
+		return t, userContext, ErrInputRejected
+	}
+	return t, userContext, nil
+}
+
+// guardRequest returns req with its task and context guarded by guardInput
+func (s *Service) guardRequest(ctx context.Context, req SuggestionRequest, userID string) (SuggestionRequest, error) {
+	var err error
+	req.Task, req.UserContext, err = s.guardInput(ctx, req.Task, req.UserContext, userID)
+	return req, err
+}
@@ -1,10 +1,15 @@
 package ai
 
 import (
+	"context"
 	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"github.com/iSparshP/real-time-task-management-system/internal/task"
 	"go.uber.org/zap"
 )
 
@@ -24,64 +29,95 @@ func (h *Handler) GetSuggestions(c *gin.Context) {
 	var req SuggestionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.logger.Error("Invalid suggestion request", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request format",
-			"details": err.Error(),
-		})
+		_ = c.Error(common.NewValidationError(err))
 		return
 	}
 
 	if err := h.validateRequest(req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Validation failed",
-			"details": err.Error(),
-		})
+		_ = c.Error(common.NewInvalidInputError(err.Error()))
 		return
 	}
 
-	resp, err := h.service.GetSuggestions(req)
+	if c.Query("stream") == "true" {
+		h.streamSuggestions(c, req)
+		return
+	}
+
+	resp, err := h.service.GetSuggestions(c.Request.Context(), req, c.GetString("user_id"))
 	if err != nil {
-		switch {
-		case errors.Is(err, ErrRateLimitExceeded):
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error":       "Rate limit exceeded",
-				"retry_after": "60s",
-			})
-		case errors.Is(err, ErrRateLimit):
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error":       "AI provider rate limit exceeded",
-				"retry_after": "30s",
-			})
-		case errors.Is(err, ErrQuota):
-			c.JSON(http.StatusServiceUnavailable, gin.H{
-				"error":   "AI provider quota exceeded",
-				"message": "Please contact support to increase your quota",
-			})
-		case errors.Is(err, ErrAIProviderUnavailable):
-			c.JSON(http.StatusServiceUnavailable, gin.H{
-				"error":       "AI service temporarily unavailable",
-				"retry_after": "30s",
-			})
-		case errors.Is(err, ErrInvalidResponse):
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Failed to process AI response",
-			})
-		default:
-			h.logger.Error("Failed to get AI suggestions",
-				zap.Error(err),
-				zap.String("task_id", req.Task.ID),
-				zap.String("suggest_for", req.SuggestFor),
-			)
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Internal server error",
-			})
-		}
+		_ = c.Error(h.suggestionError(err, req))
 		return
 	}
 
 	c.JSON(http.StatusOK, resp)
 }
 
+// streamSuggestions sends the suggestion as server-sent events: chunk
+// events with the text as the model writes it, then a done event with
+// the response. Failures before the first event get the status codes of
+// GetSuggestions; later ones end the stream with an error event.
+func (h *Handler) streamSuggestions(c *gin.Context, req SuggestionRequest) {
+	ctx := c.Request.Context()
+	started := false
+	resp, err := h.service.StreamSuggestions(ctx, req, c.GetString("user_id"), func(text string) error {
+		started = true
+		c.SSEvent("chunk", gin.H{"text": text})
+		c.Writer.Flush()
+		return ctx.Err()
+	})
+	if err != nil {
+		if !started {
+			_ = c.Error(h.suggestionError(err, req))
+			return
+		}
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return // the client went away
+		}
+		c.SSEvent("error", common.ErrorBody(c, h.suggestionError(err, req)))
+		c.Writer.Flush()
+		return
+	}
+	c.SSEvent("done", resp)
+	c.Writer.Flush()
+}
+
+// dailyQuotaError answers a user whose daily quota is used up, retried
+// once it resets at midnight UTC
+func dailyQuotaError() common.AppError {
+	return common.NewRateLimitError("Daily AI quota exceeded").
+		With("retry_after", time.Until(usageDay(time.Now()).AddDate(0, 0, 1)).Round(time.Second).String())
+}
+
+// suggestionError is the error answering a failed suggestion
+func (h *Handler) suggestionError(err error, req SuggestionRequest) common.AppError {
+	switch {
+	case errors.Is(err, ErrRateLimitExceeded):
+		return common.NewRateLimitError("Rate limit exceeded").With("retry_after", "60s")
+	case errors.Is(err, ErrDailyQuotaExceeded):
+		return dailyQuotaError()
+	case errors.Is(err, ErrRateLimit):
+		return common.NewRateLimitError("AI provider rate limit exceeded").With("retry_after", "30s")
+	case errors.Is(err, ErrQuota):
+		return common.NewUnavailableError("AI provider quota exceeded: please contact support to increase your quota")
+	case errors.Is(err, ErrAIProviderUnavailable):
+		return common.NewUnavailableError("AI service temporarily unavailable").With("retry_after", "30s")
+	case errors.Is(err, ErrInvalidResponse):
+		return common.NewInternalServerError("Failed to process AI response")
+	case errors.Is(err, ErrNoCandidates), errors.Is(err, task.ErrInvalidAssignment), errors.Is(err, ErrUnknownSuggestionType):
+		return common.NewInvalidInputError(err.Error())
+	case errors.Is(err, ErrInputRejected):
+		return common.NewInvalidInputError("Input rejected by moderation")
+	case errors.Is(err, ErrAssigneeUnavailable):
+		return common.NewUnavailableError("Assignee suggestions are not available")
+	}
+	h.logger.Error("Failed to get AI suggestions",
+		zap.Error(err),
+		zap.String("task_id", req.Task.ID),
+		zap.String("suggest_for", req.SuggestFor),
+	)
+	return common.NewInternalServerError("")
+}
+
 func (h *Handler) validateRequest(req SuggestionRequest) error {
 	if req.Task.Title == "" {
 		return errors.New("task title is required")
@@ -91,13 +127,7 @@ func (h *Handler) validateRequest(req SuggestionRequest) error {
 		return errors.New("suggest_for field is required")
 	}
 
-	validSuggestionTypes := map[string]bool{
-		"priority": true,
-		"deadline": true,
-		"approach": true,
-	}
-
-	if !validSuggestionTypes[req.SuggestFor] {
+	if !h.service.SupportsSuggestion(req.SuggestFor) {
 		return errors.New("invalid suggestion type")
 	}
 
@@ -111,3 +141,198 @@ func (h *Handler) validateRequest(req SuggestionRequest) error {
 
 	return nil
 }
+
+// BatchSuggestions returns one kind of suggestion for several stored tasks.
+// Per-task failures are reported in the results rather than failing the
+// whole request.
+func (h *Handler) BatchSuggestions(c *gin.Context) {
+	var req BatchSuggestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(common.NewValidationError(err))
+		return
+	}
+
+	resp, err := h.service.GetBatchSuggestions(c.Request.Context(), req, c.GetString("user_id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrTooManyTasks):
+			_ = c.Error(common.NewInvalidInputError(err.Error()))
+		case errors.Is(err, ErrBatchUnavailable):
+			_ = c.Error(common.NewUnavailableError("Batch suggestions are not available"))
+		default:
+			h.logger.Error("Failed to get batch AI suggestions",
+				zap.Error(err),
+				zap.Int("tasks", len(req.TaskIDs)),
+				zap.String("suggest_for", req.SuggestFor),
+			)
+			_ = c.Error(common.NewInternalServerError(""))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Breakdown proposes subtasks with estimates for a stored task, adding
+// them to its checklist when apply is set
+func (h *Handler) Breakdown(c *gin.Context) {
+	var req BreakdownRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(common.NewValidationError(err))
+		return
+	}
+
+	resp, err := h.service.Breakdown(c.Request.Context(), req, c.GetString("user_id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrTaskNotFound), errors.Is(err, task.ErrTaskNotFound):
+			_ = c.Error(common.NewNotFoundError("task not found"))
+		case errors.Is(err, task.ErrUnauthorized):
+			_ = c.Error(common.NewForbiddenError(err.Error()))
+		case errors.Is(err, ErrBreakdownUnavailable):
+			_ = c.Error(common.NewUnavailableError("Task breakdown is not available"))
+		case errors.Is(err, ErrInputRejected):
+			_ = c.Error(common.NewInvalidInputError("Input rejected by moderation"))
+		case errors.Is(err, ErrRateLimitExceeded):
+			_ = c.Error(common.NewRateLimitError("Rate limit exceeded").With("retry_after", "60s"))
+		case errors.Is(err, ErrDailyQuotaExceeded):
+			_ = c.Error(dailyQuotaError())
+		case errors.Is(err, ErrRateLimit):
+			_ = c.Error(common.NewRateLimitError("AI provider rate limit exceeded").With("retry_after", "30s"))
+		case errors.Is(err, ErrQuota), errors.Is(err, ErrAIProviderUnavailable):
+			_ = c.Error(common.NewUnavailableError("AI service temporarily unavailable").With("retry_after", "30s"))
+		case errors.Is(err, ErrInvalidResponse):
+			_ = c.Error(common.NewInternalServerError("Failed to process AI response"))
+		default:
+			h.logger.Error("Failed to break down task",
+				zap.Error(err),
+				zap.String("task_id", req.TaskID),
+				zap.Bool("apply", req.Apply),
+			)
+			_ = c.Error(common.NewInternalServerError(""))
+		}
+		return
+	}
+
+	status := http.StatusOK
+	if resp.Applied {
+		status = http.StatusCreated
+	}
+	c.JSON(status, resp)
+}
+
+// GetSettings returns the organization's model and generation settings
+// with the bounds they must keep to
+func (h *Handler) GetSettings(c *gin.Context) {
+	resp, err := h.service.GetSettings(c.Request.Context())
+	if err != nil {
+		h.settingsError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// UpdateSettings replaces the organization's model and generation
+// settings
+func (h *Handler) UpdateSettings(c *gin.Context) {
+	var req UpdateSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(common.NewValidationError(err))
+		return
+	}
+
+	resp, err := h.service.UpdateSettings(c.Request.Context(), req, c.GetString("user_id"))
+	if err != nil {
+		h.settingsError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) settingsError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidSettings):
+		_ = c.Error(common.NewInvalidInputError(err.Error()))
+	case errors.Is(err, ErrSettingsUnavailable):
+		_ = c.Error(common.NewUnavailableError("AI settings are not available"))
+	default:
+		h.logger.Error("Failed to handle AI settings", zap.Error(err))
+		_ = c.Error(common.NewInternalServerError(""))
+	}
+}
+
+// ListReports returns the weekly reports of the project in the query, for
+// its members
+func (h *Handler) ListReports(c *gin.Context) {
+	project := c.Query("project")
+	if project == "" {
+		_ = c.Error(common.NewInvalidInputError("project is required"))
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil || limit < 0 {
+		_ = c.Error(common.NewInvalidInputError("limit must be a positive number"))
+		return
+	}
+
+	reports, err := h.service.ListReports(c.Request.Context(), project, c.GetString("user_id"), limit)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNotProjectMember):
+			_ = c.Error(common.NewForbiddenError(err.Error()))
+		case errors.Is(err, ErrReportsUnavailable):
+			_ = c.Error(common.NewUnavailableError("Weekly reports are not available"))
+		default:
+			h.logger.Error("Failed to list weekly reports", zap.Error(err), zap.String("project", project))
+			_ = c.Error(common.NewInternalServerError(""))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"project": project, "reports": reports})
+}
+
+// GetUsage returns the current user's AI plan, its daily quota and their
+// usage of the last days
+func (h *Handler) GetUsage(c *gin.Context) {
+	days, err := strconv.Atoi(c.DefaultQuery("days", "0"))
+	if err != nil || days < 0 {
+		_ = c.Error(common.NewInvalidInputError("days must be a positive number"))
+		return
+	}
+
+	resp, err := h.service.GetUsage(c.Request.Context(), c.GetString("user_id"), days)
+	if err != nil {
+		h.usageError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// SetUserPlan moves a user to another AI plan
+func (h *Handler) SetUserPlan(c *gin.Context) {
+	var req SetPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(common.NewValidationError(err))
+		return
+	}
+
+	if err := h.service.SetUserPlan(c.Request.Context(), c.Param("id"), req.Plan); err != nil {
+		h.usageError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"user_id": c.Param("id"), "plan": req.Plan})
+}
+
+func (h *Handler) usageError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrUnknownPlan):
+		_ = c.Error(common.NewInvalidInputError(err.Error()))
+	case errors.Is(err, ErrUserNotFound):
+		_ = c.Error(common.NewNotFoundError(err.Error()))
+	case errors.Is(err, ErrUsageUnavailable):
+		_ = c.Error(common.NewUnavailableError("AI usage is not available"))
+	default:
+		h.logger.Error("Failed to handle AI usage", zap.Error(err))
+		_ = c.Error(common.NewInternalServerError(""))
+	}
+}
@@ -15,9 +15,17 @@ type Task struct {
 }
 
 type SuggestionRequest struct {
-	Task        task.Task `json:"task"`
-	SuggestFor  string    `json:"suggest_for" binding:"required,oneof=priority deadline approach"`
-	UserContext string    `json:"user_context,omitempty"`
+	Task task.Task `json:"task"`
+	// SuggestFor is assignee or a suggestion type with a prompt template
+	SuggestFor  string `json:"suggest_for" binding:"required,max=30"`
+	UserContext string `json:"user_context,omitempty"`
+	// CandidateIDs are the users an assignee is picked from; by default
+	// the members of the task's project
+	CandidateIDs []string `json:"candidate_ids,omitempty" binding:"max=20"`
+
+	// calendar describes the task project's business calendar for the
+	// prompt
+	calendar string
 }
 
 type Suggestion struct {
@@ -25,16 +33,131 @@ type Suggestion struct {
 	Suggestion string  `json:"suggestion"`
 	Reasoning  string  `json:"reasoning"`
 	Confidence float64 `json:"confidence"`
+	// UserID and Rank are set for assignee suggestions, which are ranked
+	// best first
+	UserID string `json:"user_id,omitempty"`
+	Rank   int    `json:"rank,omitempty"`
 }
 
 type SuggestionResponse struct {
 	Suggestions []Suggestion `json:"suggestions"`
 }
 
+// BatchSuggestionRequest asks for one kind of suggestion for several stored
+// tasks
+type BatchSuggestionRequest struct {
+	TaskIDs     []string `json:"task_ids" binding:"required,min=1,dive,required"`
+	SuggestFor  string   `json:"suggest_for" binding:"required,oneof=priority deadline approach"`
+	UserContext string   `json:"user_context,omitempty" binding:"max=500"`
+}
+
+// BatchSuggestionResult holds either the suggestions for one task or why
+// there are none
+type BatchSuggestionResult struct {
+	TaskID      string       `json:"task_id"`
+	Suggestions []Suggestion `json:"suggestions,omitempty"`
+	Error       string       `json:"error,omitempty"`
+}
+
+type BatchSuggestionResponse struct {
+	Results []BatchSuggestionResult `json:"results"`
+}
+
+// BreakdownRequest asks for a stored task to be split into subtasks.
+// Apply adds the proposals to the task's checklist.
+type BreakdownRequest struct {
+	TaskID      string `json:"task_id" binding:"required"`
+	MaxSubtasks int    `json:"max_subtasks" binding:"omitempty,min=1,max=20"`
+	UserContext string `json:"user_context,omitempty" binding:"max=500"`
+	Apply       bool   `json:"apply"`
+}
+
+type ProposedSubtask struct {
+	Title          string  `json:"title"`
+	EstimatedHours float64 `json:"estimated_hours"`
+}
+
+// BreakdownResponse holds the proposed subtasks and, once applied, the
+// task's checklist with them added
+type BreakdownResponse struct {
+	TaskID              string                  `json:"task_id"`
+	Subtasks            []ProposedSubtask       `json:"subtasks"`
+	TotalEstimatedHours float64                 `json:"total_estimated_hours"`
+	Applied             bool                    `json:"applied"`
+	Checklist           *task.ChecklistResponse `json:"checklist,omitempty"`
+}
+
 type AIProviderConfig struct {
 	Provider    string  `json:"provider"`
 	APIKey      string  `json:"api_key"`
 	ModelName   string  `json:"model_name"`
 	MaxTokens   int     `json:"max_tokens"`
 	Temperature float32 `json:"temperature"`
+	// BaseURL points the OpenAI and Anthropic clients at a compatible
+	// gateway instead of the vendor's API
+	BaseURL string `json:"base_url"`
+	// EmbeddingModel is the model that embeds task text; empty picks the
+	// provider's default
+	EmbeddingModel string `json:"embedding_model"`
+
+	// BatchMaxTasks caps the task IDs of one batch request; BatchWorkers
+	// is how many provider calls a batch makes at once
+	BatchMaxTasks int `json:"batch_max_tasks"`
+	BatchWorkers  int `json:"batch_workers"`
+
+	// AllowedModels, besides ModelName, and the maximum temperature and
+	// reply tokens bound what the organization's settings may choose
+	AllowedModels   []string `json:"allowed_models"`
+	MaxTemperature  float32  `json:"max_temperature"`
+	MaxOutputTokens int      `json:"max_output_tokens"`
+
+	// CallTimeout bounds each call to the provider, so a stalled call is
+	// retried within the caller's deadline; 0 leaves only that deadline
+	CallTimeout time.Duration `json:"call_timeout"`
+}
+
+// UpdateSettingsRequest replaces the organization's AI settings; a field
+// left out uses the server's configuration
+type UpdateSettingsRequest struct {
+	Model       string   `json:"model" binding:"max=100"`
+	Temperature *float32 `json:"temperature"`
+	MaxTokens   int      `json:"max_tokens"`
+}
+
+// SettingsBounds are the models and limits the settings must keep to
+type SettingsBounds struct {
+	Models         []string `json:"models"`
+	MaxTemperature float32  `json:"max_temperature"`
+	MaxTokens      int      `json:"max_tokens"`
+}
+
+// EffectiveSettings are what requests use. MaxTokens is 0 when the
+// provider's default applies.
+type EffectiveSettings struct {
+	Model       string  `json:"model"`
+	Temperature float32 `json:"temperature"`
+	MaxTokens   int     `json:"max_tokens"`
+}
+
+// SettingsResponse holds the stored settings, null until they are first
+// changed, the values they result in and the bounds they must keep to
+type SettingsResponse struct {
+	Settings  *Settings         `json:"settings"`
+	Effective EffectiveSettings `json:"effective"`
+	Bounds    SettingsBounds    `json:"bounds"`
+}
+
+// UsageResponse is a user's AI plan and its daily quota with their usage
+// today and on each of the last days they used AI, newest first
+type UsageResponse struct {
+	Plan     string    `json:"plan"`
+	Quota    PlanQuota `json:"quota"`
+	Today    Usage     `json:"today"`
+	ResetsAt time.Time `json:"resets_at"`
+	Days     []Usage   `json:"days"`
+}
+
+// SetPlanRequest moves a user to another AI plan
+type SetPlanRequest struct {
+	Plan string `json:"plan" binding:"required,max=50"`
 }
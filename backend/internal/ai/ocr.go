@@ -0,0 +1,46 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/chaos"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// noTextReply is what the model is asked to answer when a file has no text
+const noTextReply = "NO_TEXT"
+
+// ExtractText reads the text in an image or PDF. It waits for its own rate
+// budget rather than failing, since it only runs in the background.
+func (s *Service) ExtractText(ctx context.Context, contentType string, data []byte) (string, error) {
+	if err := s.ocrLimiter.Wait(ctx); err != nil {
+		return "", err
+	}
+	if s.faults.ShouldFailAI() {
+		return "", fmt.Errorf("%w: %v", ErrAIProviderUnavailable, chaos.ErrInjectedFault)
+	}
+
+	ctx, span := s.startSpan(ctx, "ExtractText",
+		attribute.String("ai.content_type", contentType),
+		attribute.Int("ai.content_bytes", len(data)),
+	)
+	defer span.End()
+
+	prompt := "Transcribe all text visible in this file, in reading order, as plain text. " +
+		"Do not describe or summarize it. If there is no text, reply with exactly " + noTextReply + "."
+	completion, err := s.generate(ctx, Prompt{Text: prompt, File: &File{MIMEType: contentType, Data: data}}, "")
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return "", err
+	}
+
+	extracted := strings.TrimSpace(completion.Text)
+	if extracted == noTextReply {
+		return "", nil
+	}
+	return extracted, nil
+}
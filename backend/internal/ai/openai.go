@@ -0,0 +1,178 @@
+package ai
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// openAIImageTypes are the file types chat completions accept. PDFs are not
+// among them.
+var openAIImageTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+type openAIProvider struct {
+	client         *openai.Client
+	model          string
+	temperature    float32
+	embeddingModel string
+}
+
+func newOpenAIProvider(config AIProviderConfig) *openAIProvider {
+	clientConfig := openai.DefaultConfig(config.APIKey)
+	if config.BaseURL != "" {
+		clientConfig.BaseURL = config.BaseURL
+	}
+	return &openAIProvider{
+		client:         openai.NewClientWithConfig(clientConfig),
+		model:          config.ModelName,
+		temperature:    config.Temperature,
+		embeddingModel: config.EmbeddingModel,
+	}
+}
+
+func (p *openAIProvider) Name() string  { return ProviderOpenAI }
+func (p *openAIProvider) Model() string { return p.model }
+
+// Ping fetches the model's metadata
+func (p *openAIProvider) Ping(ctx context.Context) error {
+	_, err := p.client.GetModel(ctx, p.model)
+	if err != nil {
+		return openAIError(err)
+	}
+	return nil
+}
+
+func (p *openAIProvider) Generate(ctx context.Context, prompt Prompt) (Completion, error) {
+	req, err := p.chatRequest(prompt)
+	if err != nil {
+		return Completion{}, err
+	}
+	resp, err := p.client.CreateChatCompletion(ctx, req)
+	if err != nil {
+		return Completion{}, openAIError(err)
+	}
+	if len(resp.Choices) == 0 || resp.Choices[0].Message.Content == "" {
+		return Completion{}, ErrInvalidResponse
+	}
+	return Completion{
+		Text:         resp.Choices[0].Message.Content,
+		Truncated:    resp.Choices[0].FinishReason == openai.FinishReasonLength,
+		InputTokens:  resp.Usage.PromptTokens,
+		OutputTokens: resp.Usage.CompletionTokens,
+	}, nil
+}
+
+func (p *openAIProvider) GenerateStream(ctx context.Context, prompt Prompt, onText func(text string) error) (Completion, error) {
+	req, err := p.chatRequest(prompt)
+	if err != nil {
+		return Completion{}, err
+	}
+	req.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+	stream, err := p.client.CreateChatCompletionStream(ctx, req)
+	if err != nil {
+		return Completion{}, openAIError(err)
+	}
+	defer stream.Close()
+
+	var completion Completion
+	var text strings.Builder
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Completion{}, openAIError(err)
+		}
+		// The usage comes in a last chunk without choices
+		if resp.Usage != nil {
+			completion.InputTokens = resp.Usage.PromptTokens
+			completion.OutputTokens = resp.Usage.CompletionTokens
+		}
+		if len(resp.Choices) == 0 {
+			continue
+		}
+		choice := resp.Choices[0]
+		if choice.FinishReason == openai.FinishReasonLength {
+			completion.Truncated = true
+		}
+		if choice.Delta.Content == "" {
+			continue
+		}
+		text.WriteString(choice.Delta.Content)
+		if err := onText(choice.Delta.Content); err != nil {
+			return Completion{}, err
+		}
+	}
+	if text.Len() == 0 {
+		return Completion{}, ErrInvalidResponse
+	}
+	completion.Text = text.String()
+	return completion, nil
+}
+
+// chatRequest is the chat completion request of prompt
+func (p *openAIProvider) chatRequest(prompt Prompt) (openai.ChatCompletionRequest, error) {
+	message := openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: prompt.Text}
+	if prompt.File != nil {
+		if !openAIImageTypes[prompt.File.MIMEType] {
+			return openai.ChatCompletionRequest{}, fmt.Errorf("%w: %s", ErrUnsupportedFile, prompt.File.MIMEType)
+		}
+		message.Content = ""
+		message.MultiContent = []openai.ChatMessagePart{
+			{Type: openai.ChatMessagePartTypeImageURL, ImageURL: &openai.ChatMessageImageURL{
+				URL: "data:" + prompt.File.MIMEType + ";base64," + base64.StdEncoding.EncodeToString(prompt.File.Data),
+			}},
+			{Type: openai.ChatMessagePartTypeText, Text: prompt.Text},
+		}
+	}
+	return openai.ChatCompletionRequest{
+		Model:               prompt.Options.model(p.model),
+		Messages:            []openai.ChatCompletionMessage{message},
+		Temperature:         prompt.Options.temperature(p.temperature),
+		MaxCompletionTokens: prompt.Options.MaxTokens,
+	}, nil
+}
+
+func (p *openAIProvider) EmbeddingModel() string { return p.embeddingModel }
+
+func (p *openAIProvider) Embed(ctx context.Context, text string) ([]float32, error) {
+	resp, err := p.client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
+		Input: []string{text},
+		Model: openai.EmbeddingModel(p.embeddingModel),
+	})
+	if err != nil {
+		return nil, openAIError(err)
+	}
+	if len(resp.Data) == 0 || len(resp.Data[0].Embedding) == 0 {
+		return nil, ErrInvalidResponse
+	}
+	return resp.Data[0].Embedding, nil
+}
+
+// openAIError maps a failed OpenAI call. An exhausted quota is also a 429,
+// told apart from rate limiting by its code.
+func openAIError(err error) error {
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		if apiErr.Code == "insufficient_quota" {
+			return fmt.Errorf("%w: %v", ErrQuota, err)
+		}
+		return statusError(apiErr.HTTPStatusCode, err)
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) {
+		return statusError(reqErr.HTTPStatusCode, err)
+	}
+	return err
+}
@@ -0,0 +1,174 @@
+package ai
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+	"github.com/iSparshP/real-time-task-management-system/internal/task"
+)
+
+// promptExt is the extension of prompt template files; the rest of the
+// file name is the suggestion type
+const promptExt = ".tmpl"
+
+var (
+	ErrUnknownSuggestionType = errors.New("unknown suggestion type")
+
+	suggestionTypePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,29}$`)
+)
+
+// defaultPrompts are the built-in suggestion prompts. Templates in the
+// prompt directory replace them, or add suggestion types.
+var defaultPrompts = map[string]string{
+	"priority": "Given the following task details:\nTitle: {{.Task.Title}}\nDescription: {{.Task.Description}}\n" +
+		"Due Date: {{.Task.DueDate.Format \"2006-01-02\"}}\n" +
+		"Please suggest an appropriate priority level (low/medium/high) and provide reasoning.\n" +
+		"Consider task complexity, due date, and impact.",
+	"deadline": "For the following task:\nTitle: {{.Task.Title}}\nDescription: {{.Task.Description}}\nPriority: {{.Task.Priority}}\n" +
+		"Suggest an appropriate deadline considering the task complexity and priority.\n" +
+		"{{if .Calendar}}{{.Calendar}}\n{{end}}" +
+		"Provide reasoning for the suggested deadline.",
+	"approach": "For the task:\nTitle: {{.Task.Title}}\nDescription: {{.Task.Description}}\n" +
+		"Suggest the best approach to complete this task efficiently.\n" +
+		"Consider breaking it down into smaller steps if appropriate.",
+}
+
+// PromptData is what prompt templates can use, such as {{.Task.Title}} or
+// {{.Task.DueDate.Format "2006-01-02"}}
+type PromptData struct {
+	Task task.Task
+	// Calendar describes the business calendar of the task's project, or
+	// is empty if it has none
+	Calendar string
+}
+
+// PromptTemplates are the prompts of each suggestion type, as Go
+// text/template templates
+type PromptTemplates struct {
+	templates map[string]*template.Template
+	// digests identify each template's text, so replies to an older
+	// version of a prompt are not served from the cache
+	digests map[string]string
+}
+
+// DefaultPromptTemplates returns the built-in prompts
+func DefaultPromptTemplates() *PromptTemplates {
+	prompts, err := newPromptTemplates(defaultPrompts)
+	if err != nil {
+		panic(err)
+	}
+	return prompts
+}
+
+// LoadPromptTemplates returns the built-in prompts with those of the
+// <type>.tmpl files in dir, which replace a built-in prompt or add a
+// suggestion type. Every template is checked against a sample task, so a
+// broken one fails startup rather than requests.
+func LoadPromptTemplates(dir string) (*PromptTemplates, error) {
+	sources := make(map[string]string, len(defaultPrompts))
+	for name, text := range defaultPrompts {
+		sources[name] = text
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*"+promptExt))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("failed to read prompt directory: %w", err)
+	}
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), promptExt)
+		if !suggestionTypePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid prompt template %s: the name must be lowercase letters, digits and underscores", filepath.Base(file))
+		}
+		if name == SuggestAssignee {
+			return nil, fmt.Errorf("invalid prompt template %s: assignee suggestions cannot be templated", filepath.Base(file))
+		}
+		text, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read prompt template: %w", err)
+		}
+		sources[name] = string(text)
+	}
+	return newPromptTemplates(sources)
+}
+
+func newPromptTemplates(sources map[string]string) (*PromptTemplates, error) {
+	prompts := &PromptTemplates{
+		templates: make(map[string]*template.Template, len(sources)),
+		digests:   make(map[string]string, len(sources)),
+	}
+	sample := PromptData{Task: task.Task{
+		ID:          "00000000-0000-0000-0000-000000000000",
+		Title:       "Sample task",
+		Description: "Sample description",
+		Status:      models.StatusPending,
+		Priority:    models.PriorityMedium,
+		Project:     "sample",
+		DueDate:     time.Now(),
+	}, Calendar: "Sample calendar"}
+	for name, text := range sources {
+		tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid prompt template %q: %w", name, err)
+		}
+		var out bytes.Buffer
+		if err := tmpl.Execute(&out, sample); err != nil {
+			return nil, fmt.Errorf("invalid prompt template %q: %w", name, err)
+		}
+		if strings.TrimSpace(out.String()) == "" {
+			return nil, fmt.Errorf("invalid prompt template %q: it renders no text", name)
+		}
+		sum := sha256.Sum256([]byte(text))
+		prompts.templates[name] = tmpl
+		prompts.digests[name] = hex.EncodeToString(sum[:6])
+	}
+	return prompts, nil
+}
+
+// Types lists the suggestion types with a prompt, by name
+func (p *PromptTemplates) Types() []string {
+	types := make([]string, 0, len(p.templates))
+	for name := range p.templates {
+		types = append(types, name)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// render returns the prompt of suggestFor about data's task
+func (p *PromptTemplates) render(suggestFor string, data PromptData) (string, error) {
+	tmpl, ok := p.templates[suggestFor]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownSuggestionType, suggestFor)
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("failed to render %s prompt: %w", suggestFor, err)
+	}
+	return out.String(), nil
+}
+
+// SetPromptTemplates replaces the suggestion prompts
+func (s *Service) SetPromptTemplates(prompts *PromptTemplates) {
+	s.prompts = prompts
+}
+
+// SupportsSuggestion reports whether suggestions of suggestFor can be asked
+// for: assignees, or a type with a prompt
+func (s *Service) SupportsSuggestion(suggestFor string) bool {
+	_, ok := s.prompts.templates[suggestFor]
+	return ok || suggestFor == SuggestAssignee
+}
@@ -0,0 +1,69 @@
+package ai
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/task"
+	"go.uber.org/zap"
+)
+
+func writePrompt(t *testing.T, dir, name, text string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadPromptTemplatesOverridesAndAddsTypes(t *testing.T) {
+	dir := t.TempDir()
+	writePrompt(t, dir, "approach.tmpl", "Plan {{.Task.Title}} in three steps.")
+	writePrompt(t, dir, "risk.tmpl", "What could delay {{.Task.Title}} (due {{.Task.DueDate.Format \"2006-01-02\"}})?")
+	prompts, err := LoadPromptTemplates(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(prompts.Types(), ","); got != "approach,deadline,priority,risk" {
+		t.Fatalf("types = %s, want the built-in types and risk", got)
+	}
+
+	provider := &fakeProvider{completion: Completion{Text: "Vendor delays"}}
+	s := NewServiceWithProvider(provider, AIProviderConfig{}, zap.NewNop())
+	s.SetPromptTemplates(prompts)
+	if !s.SupportsSuggestion("risk") || !s.SupportsSuggestion(SuggestAssignee) || s.SupportsSuggestion("budget") {
+		t.Fatal("want risk and assignee suggestions supported, and no others")
+	}
+	for _, suggestFor := range []string{"approach", "risk"} {
+		req := SuggestionRequest{Task: task.Task{ID: "task-1", Title: "Migrate billing"}, SuggestFor: suggestFor, UserContext: "Q3"}
+		if _, err := s.GetSuggestions(context.Background(), req, "user-1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(provider.prompts) != 2 ||
+		provider.prompts[0].Text != "Plan Migrate billing in three steps.\nAdditional context: Q3" ||
+		!strings.HasPrefix(provider.prompts[1].Text, "What could delay Migrate billing (due 0001-01-01)?") {
+		t.Fatalf("prompts = %+v, want the templates rendered", provider.prompts)
+	}
+}
+
+func TestLoadPromptTemplatesRejectsBrokenTemplates(t *testing.T) {
+	for name, text := range map[string]string{
+		"unclosed.tmpl": "Plan {{.Task.Title",
+		"missing.tmpl":  "Plan {{.Task.Budget}}",
+		"empty.tmpl":    "  ",
+		"Bad-Name.tmpl": "Plan {{.Task.Title}}",
+		"assignee.tmpl": "Who should do {{.Task.Title}}?",
+	} {
+		dir := t.TempDir()
+		writePrompt(t, dir, name, text)
+		if _, err := LoadPromptTemplates(dir); err == nil {
+			t.Errorf("%s: want an error", name)
+		}
+	}
+	if _, err := LoadPromptTemplates(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("missing directory: want an error")
+	}
+}
@@ -0,0 +1,149 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Supported values of AI_PROVIDER
+const (
+	ProviderGemini    = "gemini"
+	ProviderOpenAI    = "openai"
+	ProviderAnthropic = "anthropic"
+)
+
+var (
+	ErrUnknownProvider = errors.New("unknown AI provider")
+	ErrUnsupportedFile = errors.New("file type not supported by the AI provider")
+)
+
+// defaultModels is the model of each provider when AI_MODEL_NAME is unset
+var defaultModels = map[string]string{
+	ProviderGemini:    "gemini-pro",
+	ProviderOpenAI:    "gpt-4o-mini",
+	ProviderAnthropic: "claude-haiku-4-5",
+}
+
+// defaultEmbeddingModels is the embedding model of each provider that has
+// one when AI_EMBEDDING_MODEL is unset
+var defaultEmbeddingModels = map[string]string{
+	ProviderGemini: "text-embedding-004",
+	ProviderOpenAI: "text-embedding-3-small",
+}
+
+// AIProvider is one vendor's model API. Implementations map their failures
+// onto ErrRateLimit, ErrQuota, ErrAIProviderUnavailable and
+// ErrInvalidResponse so callers never look at vendor errors.
+type AIProvider interface {
+	// Name is the provider's AI_PROVIDER value, also used as gen_ai.system
+	Name() string
+	// Model is the model the provider calls
+	Model() string
+	// Generate sends one prompt and returns the model's reply
+	Generate(ctx context.Context, prompt Prompt) (Completion, error)
+	// Ping checks the provider is reachable without using generation quota
+	Ping(ctx context.Context) error
+}
+
+// EmbeddingProvider is implemented by providers with an embeddings API.
+// Its failures are mapped like those of Generate.
+type EmbeddingProvider interface {
+	// EmbeddingModel is the model Embed calls
+	EmbeddingModel() string
+	// Embed returns the embedding of text
+	Embed(ctx context.Context, text string) ([]float32, error)
+}
+
+// StreamingProvider is implemented by providers that can stream replies.
+// Its failures are mapped like those of Generate.
+type StreamingProvider interface {
+	// GenerateStream sends one prompt and passes the reply to onText piece
+	// by piece as the model writes it. It returns the whole reply, or stops
+	// with the first error onText returns.
+	GenerateStream(ctx context.Context, prompt Prompt, onText func(text string) error) (Completion, error)
+}
+
+// Prompt is a text prompt with an optional image or PDF
+type Prompt struct {
+	Text    string
+	File    *File
+	Options GenerationOptions
+}
+
+// GenerationOptions override the provider's configured model and
+// parameters for one call. Zero values keep the configuration.
+type GenerationOptions struct {
+	Model       string
+	Temperature *float32
+	MaxTokens   int
+}
+
+func (o GenerationOptions) model(configured string) string {
+	if o.Model != "" {
+		return o.Model
+	}
+	return configured
+}
+
+func (o GenerationOptions) temperature(configured float32) float32 {
+	if o.Temperature != nil {
+		return *o.Temperature
+	}
+	return configured
+}
+
+type File struct {
+	MIMEType string
+	Data     []byte
+}
+
+// Completion is a model's reply. Truncated is set when the reply was cut
+// off by the token limit.
+type Completion struct {
+	Text      string
+	Truncated bool
+	// InputTokens and OutputTokens are the tokens the call used, as the
+	// provider reported them
+	InputTokens  int
+	OutputTokens int
+}
+
+// NewProvider creates the client for config.Provider, Gemini when it is
+// unset. An empty ModelName picks the provider's default model.
+func NewProvider(config AIProviderConfig) (AIProvider, error) {
+	name := strings.ToLower(config.Provider)
+	if name == "" {
+		name = ProviderGemini
+	}
+	if config.ModelName == "" {
+		config.ModelName = defaultModels[name]
+	}
+	if config.EmbeddingModel == "" {
+		config.EmbeddingModel = defaultEmbeddingModels[name]
+	}
+
+	switch name {
+	case ProviderGemini:
+		return newGeminiProvider(config)
+	case ProviderOpenAI:
+		return newOpenAIProvider(config), nil
+	case ProviderAnthropic:
+		return newAnthropicProvider(config), nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownProvider, config.Provider)
+}
+
+// statusError maps the HTTP status of a failed provider call onto the
+// sentinel errors, keeping the provider's message
+func statusError(status int, err error) error {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return fmt.Errorf("%w: %v", ErrRateLimit, err)
+	case status >= http.StatusInternalServerError:
+		return fmt.Errorf("%w: %v", ErrAIProviderUnavailable, err)
+	}
+	return err
+}
@@ -0,0 +1,207 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/breaker"
+	"go.uber.org/zap"
+)
+
+type fakeProvider struct {
+	completion Completion
+	err        error
+	prompts    []Prompt
+}
+
+func (f *fakeProvider) Name() string                   { return "fake" }
+func (f *fakeProvider) Model() string                  { return "fake-model" }
+func (f *fakeProvider) Ping(ctx context.Context) error { return f.err }
+
+func (f *fakeProvider) Generate(ctx context.Context, prompt Prompt) (Completion, error) {
+	f.prompts = append(f.prompts, prompt)
+	return f.completion, f.err
+}
+
+// stalledProvider never replies, failing once ctx is done
+type stalledProvider struct {
+	fakeProvider
+}
+
+func (f *stalledProvider) Generate(ctx context.Context, prompt Prompt) (Completion, error) {
+	f.prompts = append(f.prompts, prompt)
+	<-ctx.Done()
+	return Completion{}, ctx.Err()
+}
+
+// apiServer answers every request with status and body
+func apiServer(t *testing.T, status int, body string) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestProviderErrorsMapToSentinels(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		status   int
+		body     string
+		want     error
+	}{
+		{"openai rate limit", ProviderOpenAI, 429,
+			`{"error":{"message":"Rate limit reached","type":"requests","code":"rate_limit_exceeded"}}`, ErrRateLimit},
+		{"openai quota", ProviderOpenAI, 429,
+			`{"error":{"message":"You exceeded your current quota","type":"insufficient_quota","code":"insufficient_quota"}}`, ErrQuota},
+		{"openai outage", ProviderOpenAI, 503,
+			`{"error":{"message":"The engine is currently overloaded","type":"server_error"}}`, ErrAIProviderUnavailable},
+		{"anthropic rate limit", ProviderAnthropic, 429,
+			`{"type":"error","error":{"type":"rate_limit_error","message":"Number of requests has exceeded your rate limit"}}`, ErrRateLimit},
+		{"anthropic overloaded", ProviderAnthropic, 529,
+			`{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`, ErrAIProviderUnavailable},
+		{"anthropic credits", ProviderAnthropic, 400,
+			`{"type":"error","error":{"type":"invalid_request_error","message":"Your credit balance is too low to access the Anthropic API."}}`, ErrQuota},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := NewProvider(AIProviderConfig{
+				Provider: tt.provider,
+				APIKey:   "test-key",
+				BaseURL:  apiServer(t, tt.status, tt.body),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := provider.Generate(context.Background(), Prompt{Text: "hi"}); !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnthropicProviderReadsReply(t *testing.T) {
+	provider, err := NewProvider(AIProviderConfig{
+		Provider: ProviderAnthropic,
+		APIKey:   "test-key",
+		BaseURL: apiServer(t, 200, `{"id":"msg_1","type":"message","role":"assistant","model":"claude-haiku-4-5",
+			"content":[{"type":"text","text":"high"}],"stop_reason":"max_tokens",
+			"usage":{"input_tokens":10,"output_tokens":1}}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if provider.Model() != "claude-haiku-4-5" {
+		t.Errorf("model = %q, want the Anthropic default", provider.Model())
+	}
+
+	completion, err := provider.Generate(context.Background(), Prompt{Text: "priority?"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if completion.Text != "high" || !completion.Truncated {
+		t.Fatalf("completion = %+v, want the truncated text", completion)
+	}
+
+	_, err = provider.Generate(context.Background(), Prompt{Text: "read this", File: &File{MIMEType: "image/heic"}})
+	if !errors.Is(err, ErrUnsupportedFile) {
+		t.Fatalf("err = %v, want ErrUnsupportedFile", err)
+	}
+}
+
+func TestOpenAIProviderEmbeds(t *testing.T) {
+	provider, err := NewProvider(AIProviderConfig{
+		Provider: ProviderOpenAI,
+		APIKey:   "test-key",
+		BaseURL: apiServer(t, 200, `{"object":"list","model":"text-embedding-3-small",
+			"data":[{"object":"embedding","index":0,"embedding":[0.25,-0.5]}]}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewServiceWithProvider(provider, AIProviderConfig{}, zap.NewNop())
+	if model := s.EmbeddingModel(); model != "text-embedding-3-small" {
+		t.Errorf("embedding model = %q, want the OpenAI default", model)
+	}
+
+	vector, err := s.EmbedText(context.Background(), "Fix login")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vector) != 2 || vector[0] != 0.25 || vector[1] != -0.5 {
+		t.Fatalf("vector = %v, want the provider's embedding", vector)
+	}
+
+	anthropic := NewServiceWithProvider(newAnthropicProvider(AIProviderConfig{}), AIProviderConfig{}, zap.NewNop())
+	if _, err := anthropic.EmbedText(context.Background(), "Fix login"); !errors.Is(err, ErrEmbeddingsUnsupported) {
+		t.Fatalf("err = %v, want ErrEmbeddingsUnsupported", err)
+	}
+}
+
+func TestNewProviderRejectsUnknownProvider(t *testing.T) {
+	if _, err := NewProvider(AIProviderConfig{Provider: "watson"}); !errors.Is(err, ErrUnknownProvider) {
+		t.Fatalf("err = %v, want ErrUnknownProvider", err)
+	}
+}
+
+func TestGetSuggestionsUsesProvider(t *testing.T) {
+	provider := &fakeProvider{completion: Completion{Text: "medium", Truncated: true}}
+	s := NewServiceWithProvider(provider, AIProviderConfig{}, zap.NewNop())
+
+	resp, err := s.GetSuggestions(context.Background(), SuggestionRequest{SuggestFor: "approach"}, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(provider.prompts) != 1 || resp.Suggestions[0].Suggestion != "medium" || resp.Suggestions[0].Confidence != 0 {
+		t.Fatalf("suggestions = %+v, want the provider's truncated reply", resp.Suggestions)
+	}
+}
+
+func TestGetSuggestionsRetriesStalledCalls(t *testing.T) {
+	provider := &stalledProvider{}
+	s := NewServiceWithProvider(provider, AIProviderConfig{CallTimeout: 10 * time.Millisecond}, zap.NewNop())
+	s.retryDelay = time.Millisecond
+
+	_, err := s.GetSuggestions(context.Background(), SuggestionRequest{SuggestFor: "approach"}, "user-1")
+	if !errors.Is(err, ErrAIProviderUnavailable) || len(provider.prompts) != s.maxRetries+1 {
+		t.Fatalf("err = %v after %d calls, want every attempt to time out", err, len(provider.prompts))
+	}
+}
+
+func TestGetSuggestionsStopsWhenCallerGivesUp(t *testing.T) {
+	provider := &stalledProvider{}
+	s := NewServiceWithProvider(provider, AIProviderConfig{CallTimeout: time.Minute}, zap.NewNop())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := s.GetSuggestions(ctx, SuggestionRequest{SuggestFor: "approach"}, "user-1")
+	if !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrAIProviderUnavailable) || len(provider.prompts) != 1 {
+		t.Fatalf("err = %v after %d calls, want the caller's deadline after one call", err, len(provider.prompts))
+	}
+}
+
+func TestGetSuggestionsFailsFastWhileBreakerIsOpen(t *testing.T) {
+	provider := &stalledProvider{}
+	s := NewServiceWithProvider(provider, AIProviderConfig{CallTimeout: 10 * time.Millisecond}, zap.NewNop())
+	s.retryDelay = time.Millisecond
+	s.SetBreaker(breaker.New(2, time.Minute))
+
+	_, err := s.GetSuggestions(context.Background(), SuggestionRequest{SuggestFor: "approach"}, "user-1")
+	if !errors.Is(err, ErrAIProviderUnavailable) || !errors.Is(err, breaker.ErrOpen) || len(provider.prompts) != 2 {
+		t.Fatalf("err = %v after %d calls, want the breaker to open after two", err, len(provider.prompts))
+	}
+
+	_, err = s.GetSuggestions(context.Background(), SuggestionRequest{SuggestFor: "priority"}, "user-1")
+	if !errors.Is(err, ErrAIProviderUnavailable) || len(provider.prompts) != 2 {
+		t.Fatalf("err = %v after %d calls, want ErrAIProviderUnavailable without calling the provider", err, len(provider.prompts))
+	}
+}
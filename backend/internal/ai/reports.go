@@ -0,0 +1,238 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/chaos"
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+	"github.com/iSparshP/real-time-task-management-system/internal/notification"
+	"github.com/iSparshP/real-time-task-management-system/internal/task"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ProjectReport is the weekly summary of one project
+type ProjectReport = models.ProjectReport
+
+const (
+	week = 7 * 24 * time.Hour
+	// maxReportsPerRun bounds the provider calls of one run; the rest of
+	// the week's reports are written by the next
+	maxReportsPerRun   = 50
+	defaultReportLimit = 12
+	maxReportLimit     = 52
+)
+
+var (
+	ErrReportsUnavailable = errors.New("weekly reports are not configured")
+	ErrNotProjectMember   = errors.New("not a member of the project")
+)
+
+// ReportSource is implemented by the task service
+type ReportSource interface {
+	ProjectActivity(ctx context.Context, since, until time.Time) ([]task.ProjectActivity, error)
+	IsProjectMember(ctx context.Context, userID, project string) (bool, error)
+}
+
+// ReportNotifier is implemented by the notification service
+type ReportNotifier interface {
+	SendReport(ctx context.Context, report notification.Report)
+}
+
+// ReportStore keeps the weekly reports. There is at most one per project
+// and week, so replicas running the job at once do not post it twice.
+type ReportStore struct {
+	db *gorm.DB
+}
+
+func NewReportStore(db *gorm.DB) *ReportStore {
+	return &ReportStore{db: db}
+}
+
+// weekProjects returns the projects that have a report for the week
+func (st *ReportStore) weekProjects(ctx context.Context, weekStart time.Time) (map[string]bool, error) {
+	var projects []string
+	if err := st.db.WithContext(ctx).Model(&ProjectReport{}).
+		Where("week_start = ?", weekStart).
+		Pluck("project", &projects).Error; err != nil {
+		return nil, err
+	}
+	done := make(map[string]bool, len(projects))
+	for _, project := range projects {
+		done[project] = true
+	}
+	return done, nil
+}
+
+// create stores report unless the project's week already has one,
+// reporting whether it did
+func (st *ReportStore) create(ctx context.Context, report *ProjectReport) (bool, error) {
+	result := st.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(report)
+	return result.RowsAffected == 1, result.Error
+}
+
+// List returns the project's reports, newest week first
+func (st *ReportStore) List(ctx context.Context, project string, limit int) ([]ProjectReport, error) {
+	reports := []ProjectReport{}
+	err := st.db.WithContext(ctx).Where("project = ?", project).
+		Order("week_start DESC").Limit(limit).Find(&reports).Error
+	return reports, err
+}
+
+// SetReports enables weekly project reports, stored in store from the
+// activity source reports and posted with notifier, which may be nil
+func (s *Service) SetReports(store *ReportStore, source ReportSource, notifier ReportNotifier) {
+	s.reports = store
+	s.reportSource = source
+	s.reportNotifier = notifier
+}
+
+// weekStart is the Monday, at midnight UTC, of the week t is in
+func weekStart(t time.Time) time.Time {
+	t = t.UTC().Truncate(24 * time.Hour)
+	return t.AddDate(0, 0, -(int(t.Weekday())+6)%7)
+}
+
+// StartWeeklyReports writes the reports of the last full week once it
+// ends, checking every interval so a missed run is caught up. It stops
+// when ctx is done.
+func (s *Service) StartWeeklyReports(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if written, err := s.RunWeeklyReports(ctx, time.Now()); err != nil {
+				s.logger.Error("Weekly reports failed", zap.Error(err))
+			} else if written > 0 {
+				s.logger.Info("Wrote weekly reports", zap.Int("reports", written))
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// RunWeeklyReports writes and posts the reports of the week before the one
+// now is in, for every project with activity that has none yet, and
+// returns how many it wrote. A project whose summary fails is retried by
+// the next run.
+func (s *Service) RunWeeklyReports(ctx context.Context, now time.Time) (int, error) {
+	if s.reports == nil || s.reportSource == nil {
+		return 0, ErrReportsUnavailable
+	}
+	start := weekStart(now).Add(-week)
+	activity, err := s.reportSource.ProjectActivity(ctx, start, start.Add(week))
+	if err != nil {
+		return 0, err
+	}
+	done, err := s.reports.weekProjects(ctx, start)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load weekly reports: %w", err)
+	}
+
+	written := 0
+	for _, a := range activity {
+		if done[a.Project] {
+			continue
+		}
+		if written == maxReportsPerRun {
+			break
+		}
+		summary, err := s.summarizeWeek(ctx, a, start)
+		if err != nil {
+			if ctx.Err() != nil {
+				return written, ctx.Err()
+			}
+			s.logger.Warn("Failed to summarize project week", zap.String("project", a.Project), zap.Error(err))
+			continue
+		}
+
+		report := &ProjectReport{
+			Project:   a.Project,
+			WeekStart: start,
+			Summary:   summary,
+			Activity:  a,
+			Model:     s.generationOptions(ctx).model(s.provider.Model()),
+		}
+		created, err := s.reports.create(ctx, report)
+		if err != nil {
+			return written, fmt.Errorf("failed to store weekly report: %w", err)
+		}
+		if !created {
+			continue
+		}
+		written++
+		if s.reportNotifier != nil {
+			s.reportNotifier.SendReport(ctx, notification.Report{
+				Project: a.Project,
+				Title:   fmt.Sprintf("Weekly report for %s, week of %s", a.Project, start.Format("2 Jan 2006")),
+				Summary: summary,
+			})
+		}
+	}
+	return written, nil
+}
+
+// summarizeWeek asks the model for an executive summary of the week
+func (s *Service) summarizeWeek(ctx context.Context, activity task.ProjectActivity, start time.Time) (string, error) {
+	source, err := json.Marshal(activity)
+	if err != nil {
+		return "", err
+	}
+	prompt := fmt.Sprintf("Write an executive summary of the week starting %s for the project below, "+
+		"in at most 150 words of plain text. Cover progress, risks such as overdue tasks, and what "+
+		"needs attention next week. Do not invent facts that are not in the data.\n\n%s",
+		start.Format("2006-01-02"), source)
+
+	var summary string
+	err = s.withRetry(ctx, func() error {
+		if s.faults.ShouldFailAI() {
+			return fmt.Errorf("%w: %v", ErrAIProviderUnavailable, chaos.ErrInjectedFault)
+		}
+		ctx, span := s.startSpan(ctx, "GenerateReport", attribute.String("ai.project", activity.Project))
+		defer span.End()
+
+		completion, err := s.generate(ctx, Prompt{Text: prompt}, "")
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return err
+		}
+		summary = strings.TrimSpace(completion.Text)
+		if completion.Truncated || summary == "" {
+			return ErrInvalidResponse
+		}
+		return nil
+	})
+	return summary, err
+}
+
+// ListReports returns the newest weekly reports of a project userID is a
+// member of
+func (s *Service) ListReports(ctx context.Context, project, userID string, limit int) ([]ProjectReport, error) {
+	if s.reports == nil || s.reportSource == nil {
+		return nil, ErrReportsUnavailable
+	}
+	member, err := s.reportSource.IsProjectMember(ctx, userID, project)
+	if err != nil {
+		return nil, err
+	}
+	if !member {
+		return nil, ErrNotProjectMember
+	}
+	if limit <= 0 {
+		limit = defaultReportLimit
+	}
+	return s.reports.List(ctx, project, min(limit, maxReportLimit))
+}
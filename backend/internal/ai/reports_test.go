@@ -0,0 +1,121 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/iSparshP/real-time-task-management-system/internal/notification"
+	"github.com/iSparshP/real-time-task-management-system/internal/task"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type fakeReportSource struct {
+	activity     []task.ProjectActivity
+	since, until time.Time
+	member       bool
+}
+
+func (f *fakeReportSource) ProjectActivity(ctx context.Context, since, until time.Time) ([]task.ProjectActivity, error) {
+	f.since, f.until = since, until
+	return f.activity, nil
+}
+
+func (f *fakeReportSource) IsProjectMember(ctx context.Context, userID, project string) (bool, error) {
+	return f.member, nil
+}
+
+type fakeReportNotifier struct {
+	reports []notification.Report
+}
+
+func (f *fakeReportNotifier) SendReport(ctx context.Context, report notification.Report) {
+	f.reports = append(f.reports, report)
+}
+
+func newReportStore(t *testing.T) (*ReportStore, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewReportStore(db), mock
+}
+
+func TestWeekStart(t *testing.T) {
+	monday := time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC)
+	for _, at := range []time.Time{
+		monday,
+		time.Date(2024, 3, 13, 15, 4, 5, 0, time.UTC),
+		time.Date(2024, 3, 17, 23, 59, 0, 0, time.UTC),
+		// Monday morning east of UTC is still Sunday in UTC
+		time.Date(2024, 3, 18, 2, 0, 0, 0, time.FixedZone("PKT", 5*3600)),
+	} {
+		if got := weekStart(at); !got.Equal(monday) {
+			t.Errorf("weekStart(%v) = %v, want %v", at, got, monday)
+		}
+	}
+}
+
+func TestRunWeeklyReportsSkipsWrittenProjects(t *testing.T) {
+	provider := &fakeProvider{completion: Completion{Text: "  A steady week.  "}}
+	s := NewServiceWithProvider(provider, AIProviderConfig{}, zap.NewNop())
+	store, mock := newReportStore(t)
+	source := &fakeReportSource{activity: []task.ProjectActivity{
+		{Project: "api", Completed: 3},
+		{Project: "web", Created: 2},
+	}}
+	notifier := &fakeReportNotifier{}
+	s.SetReports(store, source, notifier)
+
+	lastWeek := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`SELECT "project" FROM "project_reports" WHERE week_start = \$1`).
+		WithArgs(lastWeek).
+		WillReturnRows(sqlmock.NewRows([]string{"project"}).AddRow("api"))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "project_reports" .* ON CONFLICT DO NOTHING`).
+		WithArgs("web", lastWeek, "A steady week.", sqlmock.AnyArg(), "fake-model").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("report-1", time.Now()))
+	mock.ExpectCommit()
+
+	written, err := s.RunWeeklyReports(context.Background(), time.Date(2024, 3, 13, 9, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written != 1 || len(provider.prompts) != 1 {
+		t.Fatalf("written = %d with %d prompts, want only the web report", written, len(provider.prompts))
+	}
+	if !source.since.Equal(lastWeek) || !source.until.Equal(lastWeek.AddDate(0, 0, 7)) {
+		t.Fatalf("activity window = %v to %v, want the week of %v", source.since, source.until, lastWeek)
+	}
+	if len(notifier.reports) != 1 || notifier.reports[0].Project != "web" || notifier.reports[0].Summary != "A steady week." {
+		t.Fatalf("posted = %+v, want the web report", notifier.reports)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestListReportsRequiresMembership(t *testing.T) {
+	s := NewServiceWithProvider(&fakeProvider{}, AIProviderConfig{}, zap.NewNop())
+	if _, err := s.ListReports(context.Background(), "web", "user-1", 0); !errors.Is(err, ErrReportsUnavailable) {
+		t.Fatalf("err = %v, want ErrReportsUnavailable", err)
+	}
+
+	store, _ := newReportStore(t)
+	s.SetReports(store, &fakeReportSource{}, nil)
+	if _, err := s.ListReports(context.Background(), "web", "user-1", 0); !errors.Is(err, ErrNotProjectMember) {
+		t.Fatalf("err = %v, want ErrNotProjectMember", err)
+	}
+}
@@ -2,17 +2,26 @@ package ai
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"math"
+	"net"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/google/generative-ai-go/genai"
+	"github.com/iSparshP/real-time-task-management-system/internal/breaker"
+	"github.com/iSparshP/real-time-task-management-system/internal/chaos"
+	"github.com/iSparshP/real-time-task-management-system/internal/moderation"
+	"github.com/iSparshP/real-time-task-management-system/internal/telemetry"
 	"github.com/patrickmn/go-cache"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"golang.org/x/time/rate"
-	"google.golang.org/api/option"
 )
 
 var (
@@ -21,64 +30,239 @@ var (
 	ErrRateLimitExceeded     = errors.New("rate limit exceeded")
 	ErrRateLimit             = errors.New("AI provider rate limit exceeded")
 	ErrQuota                 = errors.New("AI provider quota exceeded")
+
+	// errCallTimeout is a provider call that outlasted the call timeout
+	errCallTimeout = errors.New("call timeout")
 )
 
 type Service struct {
-	client      *genai.Client
-	model       *genai.GenerativeModel
-	config      AIProviderConfig
-	logger      *zap.Logger
-	cache       *cache.Cache
-	rateLimiter *rate.Limiter
-	maxRetries  int
-	retryDelay  time.Duration
+	provider AIProvider
+	config   AIProviderConfig
+	logger   *zap.Logger
+	// cache holds settings and plans this replica read
+	cache *cache.Cache
+	// responses caches the model's replies, for responseTTL
+	responses   ResponseCache
+	responseTTL time.Duration
+	// userLimiters holds the rate bucket of each user's requests
+	userLimiters *cache.Cache
+	limitersMu   sync.Mutex
+	// classifyLimiter budgets moderation separately so public intake traffic
+	// cannot starve members' suggestions
+	classifyLimiter *rate.Limiter
+	// ocrLimiter paces background OCR of attachments
+	ocrLimiter *rate.Limiter
+	// embedLimiter paces embeddings, requested for every task written
+	embedLimiter *rate.Limiter
+
+	maxRetries int
+	retryDelay time.Duration
+	faults     *chaos.Injector
+	tasks      TaskLoader
+	checklist  ChecklistWriter
+	workloads  WorkloadLoader
+	calendars  CalendarLoader
+	settings   *SettingsStore
+
+	reports        *ReportStore
+	reportSource   ReportSource
+	reportNotifier ReportNotifier
+
+	usage  *UsageStore
+	quotas map[string]PlanQuota
+	// prompts are the suggestion prompts of each type
+	prompts *PromptTemplates
+	// observeCall receives the outcome of every call to the provider
+	observeCall func(err error)
+	// breaker fails calls fast while the provider keeps failing
+	breaker *breaker.Set
+	// moderator checks text from users before it is put into a prompt
+	moderator moderation.Moderator
 }
 
+// NewService creates the service with the provider chosen by
+// config.Provider
 func NewService(config AIProviderConfig, logger *zap.Logger) (*Service, error) {
-	ctx := context.Background()
-	client, err := genai.NewClient(ctx, option.WithAPIKey(config.APIKey))
+	provider, err := NewProvider(config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create Gemini client: %w", err)
+		return nil, err
 	}
+	return NewServiceWithProvider(provider, config, logger), nil
+}
 
-	model := client.GenerativeModel(config.ModelName)
-	model.SetTemperature(config.Temperature)
-
+// NewServiceWithProvider creates the service with an existing provider
+func NewServiceWithProvider(provider AIProvider, config AIProviderConfig, logger *zap.Logger) *Service {
 	return &Service{
-		client:      client,
-		model:       model,
-		config:      config,
-		logger:      logger,
-		cache:       cache.New(5*time.Minute, 10*time.Minute),
-		rateLimiter: rate.NewLimiter(rate.Every(time.Second), 10),
-		maxRetries:  3,
-		retryDelay:  1 * time.Second,
-	}, nil
+		provider:        provider,
+		config:          config,
+		logger:          logger,
+		cache:           cache.New(5*time.Minute, 10*time.Minute),
+		responses:       NewMemoryResponseCache(),
+		responseTTL:     defaultResponseTTL,
+		userLimiters:    cache.New(userLimiterTTL, userLimiterTTL),
+		classifyLimiter: rate.NewLimiter(rate.Every(time.Second), 5),
+		ocrLimiter:      rate.NewLimiter(rate.Every(2*time.Second), 1),
+		embedLimiter:    rate.NewLimiter(rate.Every(100*time.Millisecond), 10),
+		maxRetries:      3,
+		retryDelay:      1 * time.Second,
+		prompts:         DefaultPromptTemplates(),
+	}
+}
+
+// SetFaultInjector enables fault injection for AI provider calls
+func (s *Service) SetFaultInjector(faults *chaos.Injector) {
+	s.faults = faults
+}
+
+// SetCallObserver reports the outcome of each call to the AI provider.
+// Calls abandoned because their context ended are not reported.
+func (s *Service) SetCallObserver(observe func(err error)) {
+	s.observeCall = observe
+}
+
+// SetBreaker fails calls with ErrAIProviderUnavailable, without calling
+// the provider, while its circuit in breakers is open
+func (s *Service) SetBreaker(breakers *breaker.Set) {
+	s.breaker = breakers
+}
+
+// Ping checks that the AI provider is reachable by fetching the configured
+// model's metadata, which does not consume generation quota
+func (s *Service) Ping(ctx context.Context) error {
+	err := s.provider.Ping(ctx)
+	s.recordCall(ctx, err)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrAIProviderUnavailable, err)
+	}
+	return nil
+}
+
+// generate calls the model, reports the outcome and counts a reply
+// towards userID's usage; background work passes no userID
+func (s *Service) generate(ctx context.Context, prompt Prompt, userID string) (Completion, error) {
+	s.applySettings(ctx, &prompt)
+	callCtx, cancel := s.callContext(ctx)
+	defer cancel()
+	var completion Completion
+	err := s.callProvider(ctx, func() error {
+		var err error
+		completion, err = s.provider.Generate(callCtx, prompt)
+		return s.callError(ctx, callCtx, err)
+	})
+	s.recordCall(ctx, err)
+	if err == nil {
+		s.recordUsage(ctx, userID, completion)
+	}
+	return completion, err
+}
+
+// callContext bounds one provider call by the configured call timeout
+func (s *Service) callContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.config.CallTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, s.config.CallTimeout)
+}
+
+// callError reports a call that failed because it ran out of its own time,
+// rather than the caller's, as a retryable outage
+func (s *Service) callError(ctx, callCtx context.Context, err error) error {
+	if err != nil && ctx.Err() == nil && errors.Is(callCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w after %s", ErrAIProviderUnavailable, errCallTimeout, s.config.CallTimeout)
+	}
+	return err
+}
+
+// callProvider makes call to the provider through its circuit breaker.
+// Outages open it; other errors, and calls the caller gave up on, do not.
+func (s *Service) callProvider(ctx context.Context, call func() error) error {
+	err := s.breaker.Do(s.provider.Name(), call, func(err error) bool {
+		return ctx.Err() == nil && (errors.Is(err, ErrAIProviderUnavailable) || isNetworkError(err))
+	})
+	if errors.Is(err, breaker.ErrOpen) {
+		return fmt.Errorf("%w: %w", ErrAIProviderUnavailable, err)
+	}
+	return err
+}
+
+// isNetworkError reports whether err is a failure to reach the provider,
+// which providers leave unmapped
+func isNetworkError(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// startSpan starts the client span of a provider call
+func (s *Service) startSpan(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return telemetry.Tracer().Start(ctx, s.provider.Name()+"."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(append([]attribute.KeyValue{
+			attribute.String("gen_ai.system", s.provider.Name()),
+			attribute.String("gen_ai.request.model", s.provider.Model()),
+		}, attrs...)...),
+	)
+}
+
+func (s *Service) recordCall(ctx context.Context, err error) {
+	if s.observeCall != nil && ctx.Err() == nil {
+		s.observeCall(err)
+	}
 }
 
-func (s *Service) GetSuggestions(req SuggestionRequest) (*SuggestionResponse, error) {
-	if !s.rateLimiter.Allow() {
-		return nil, ErrRateLimitExceeded
+// GetSuggestions honours the caller's deadline: the provider call and any
+// retry backoff stop once ctx is done. Requests count towards userID's rate
+// bucket and daily quota.
+func (s *Service) GetSuggestions(ctx context.Context, req SuggestionRequest, userID string) (*SuggestionResponse, error) {
+	if err := s.admit(ctx, userID); err != nil {
+		return nil, err
+	}
+	req, err := s.guardRequest(ctx, req, userID)
+	if err != nil {
+		return nil, err
+	}
+	req.calendar = s.describeCalendar(ctx, req, time.Now())
+
+	var cached SuggestionResponse
+	if s.cachedResponse(ctx, s.getCacheKey(req), &cached) {
+		return &cached, nil
 	}
 
-	// Check cache
-	if cached, found := s.cache.Get(s.getCacheKey(req)); found {
-		return cached.(*SuggestionResponse), nil
+	if req.SuggestFor == SuggestAssignee {
+		return s.suggestAssignees(ctx, req, userID)
 	}
 
+	var resp *SuggestionResponse
+	err = s.withRetry(ctx, func() error {
+		var err error
+		resp, err = s.makeAIRequest(ctx, req, userID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// withRetry calls the provider until it succeeds, fails permanently, runs
+// out of retries or ctx is done, backing off between attempts
+func (s *Service) withRetry(ctx context.Context, call func() error) error {
 	var lastErr error
 	for attempt := 0; attempt <= s.maxRetries; attempt++ {
 		if attempt > 0 {
-			time.Sleep(s.getRetryDelay(attempt))
+			select {
+			case <-time.After(s.getRetryDelay(attempt)):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
 		}
 
-		resp, err := s.makeAIRequest(req)
+		err := call()
 		if err == nil {
-			return resp, nil
+			return nil
 		}
 
 		lastErr = err
-		if !s.shouldRetry(err) {
+		if ctx.Err() != nil || !s.shouldRetry(err) {
 			break
 		}
 
@@ -89,38 +273,37 @@ func (s *Service) GetSuggestions(req SuggestionRequest) (*SuggestionResponse, er
 		)
 	}
 
-	return nil, fmt.Errorf("AI completion error after %d retries: %w", s.maxRetries, lastErr)
+	return fmt.Errorf("AI completion error after %d retries: %w", s.maxRetries, lastErr)
 }
 
-func (s *Service) makeAIRequest(req SuggestionRequest) (*SuggestionResponse, error) {
-	ctx := context.Background()
-	prompt := s.buildPrompt(req)
+func (s *Service) makeAIRequest(ctx context.Context, req SuggestionRequest, userID string) (*SuggestionResponse, error) {
+	if s.faults.ShouldFailAI() {
+		return nil, fmt.Errorf("%w: %v", ErrAIProviderUnavailable, chaos.ErrInjectedFault)
+	}
+
+	ctx, span := s.startSpan(ctx, "GenerateContent", attribute.String("ai.suggest_for", req.SuggestFor))
+	defer span.End()
 
-	resp, err := s.model.GenerateContent(ctx, genai.Text(prompt))
+	prompt, err := s.buildPrompt(req)
 	if err != nil {
-		if strings.Contains(err.Error(), "quota") {
-			return nil, ErrQuota
-		}
-		if strings.Contains(err.Error(), "rate") {
-			return nil, ErrRateLimit
-		}
 		return nil, err
 	}
 
-	if len(resp.Candidates) == 0 {
-		return nil, ErrInvalidResponse
+	completion, err := s.generate(ctx, Prompt{Text: prompt}, userID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
 	}
 
-	// Get text from the response
-	suggestion := ""
-	if textPart, ok := resp.Candidates[0].Content.Parts[0].(genai.Text); ok {
-		suggestion = string(textPart)
-	} else {
-		return nil, ErrInvalidResponse
-	}
+	return s.cacheSuggestion(ctx, req, completion), nil
+}
 
+// cacheSuggestion turns the model's reply into the response to req and
+// caches it
+func (s *Service) cacheSuggestion(ctx context.Context, req SuggestionRequest, completion Completion) *SuggestionResponse {
 	confidence := 1.0
-	if resp.Candidates[0].FinishReason == genai.FinishReasonMaxTokens {
+	if completion.Truncated {
 		confidence = 0.0
 	}
 
@@ -128,20 +311,55 @@ func (s *Service) makeAIRequest(req SuggestionRequest) (*SuggestionResponse, err
 		Suggestions: []Suggestion{
 			{
 				Type:       "primary",
-				Suggestion: suggestion,
+				Suggestion: completion.Text,
 				Confidence: math.Round(confidence*100) / 100,
 			},
 		},
 	}
 
-	// Cache the response
-	s.cache.Set(s.getCacheKey(req), response, cache.DefaultExpiration)
+	s.cacheResponse(ctx, s.getCacheKey(req), response, 0)
 
-	return response, nil
+	return response
+}
+
+// ClassifyContent asks the model whether user-submitted text is abusive.
+// It is used by the moderation layer for public intake.
+func (s *Service) ClassifyContent(ctx context.Context, text string) (bool, string, error) {
+	if !s.classifyLimiter.Allow() {
+		return false, "", ErrRateLimitExceeded
+	}
+	if s.faults.ShouldFailAI() {
+		return false, "", fmt.Errorf("%w: %v", ErrAIProviderUnavailable, chaos.ErrInjectedFault)
+	}
+
+	prompt := "You are a content moderator. Decide whether the following text submitted to a " +
+		"task tracker is abusive, harassing, hateful or obscene in any language.\n" +
+		"Reply with exactly one line: either OK, or ABUSIVE: <short reason>.\n\n" +
+		"Text:\n" + text
+
+	ctx, span := s.startSpan(ctx, "ClassifyContent")
+	defer span.End()
+
+	completion, err := s.generate(ctx, Prompt{Text: prompt}, "")
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return false, "", err
+	}
+
+	answer := strings.TrimSpace(completion.Text)
+	if strings.HasPrefix(strings.ToUpper(answer), "ABUSIVE") {
+		reason := strings.TrimSpace(strings.TrimPrefix(answer[len("ABUSIVE"):], ":"))
+		return true, reason, nil
+	}
+	return false, "", nil
 }
 
 func (s *Service) shouldRetry(err error) bool {
-	return err == ErrRateLimit || strings.Contains(err.Error(), "timeout") ||
+	if errors.Is(err, errStreamInterrupted) {
+		return false
+	}
+	return errors.Is(err, ErrRateLimit) || errors.Is(err, errCallTimeout) || strings.Contains(err.Error(), "timeout") ||
 		strings.Contains(err.Error(), "connection refused")
 }
 
@@ -149,39 +367,29 @@ func (s *Service) getRetryDelay(attempt int) time.Duration {
 	return s.retryDelay * time.Duration(math.Pow(2, float64(attempt-1)))
 }
 
-func (s *Service) buildPrompt(req SuggestionRequest) string {
-	var prompt string
-	switch req.SuggestFor {
-	case "priority":
-		prompt = fmt.Sprintf(
-			"Given the following task details:\nTitle: %s\nDescription: %s\nDue Date: %s\n"+
-				"Please suggest an appropriate priority level (low/medium/high) and provide reasoning.\n"+
-				"Consider task complexity, due date, and impact.",
-			req.Task.Title, req.Task.Description, req.Task.DueDate.Format("2006-01-02"),
-		)
-	case "deadline":
-		prompt = fmt.Sprintf(
-			"For the following task:\nTitle: %s\nDescription: %s\nPriority: %s\n"+
-				"Suggest an appropriate deadline considering the task complexity and priority.\n"+
-				"Provide reasoning for the suggested deadline.",
-			req.Task.Title, req.Task.Description, req.Task.Priority,
-		)
-	case "approach":
-		prompt = fmt.Sprintf(
-			"For the task:\nTitle: %s\nDescription: %s\n"+
-				"Suggest the best approach to complete this task efficiently.\n"+
-				"Consider breaking it down into smaller steps if appropriate.",
-			req.Task.Title, req.Task.Description,
-		)
+// buildPrompt renders the prompt of req's suggestion type, followed by the
+// user's context
+func (s *Service) buildPrompt(req SuggestionRequest) (string, error) {
+	prompt, err := s.prompts.render(req.SuggestFor, PromptData{Task: req.Task, Calendar: req.calendar})
+	if err != nil {
+		return "", err
 	}
 
 	if req.UserContext != "" {
 		prompt += fmt.Sprintf("\nAdditional context: %s", req.UserContext)
 	}
 
-	return prompt
+	return prompt, nil
 }
 
 func (s *Service) getCacheKey(req SuggestionRequest) string {
-	return fmt.Sprintf("%s:%s:%s", req.Task.ID, req.SuggestFor, req.UserContext)
+	if req.SuggestFor == SuggestAssignee {
+		return fmt.Sprintf("%s:%s:%s:%s", req.Task.ID, req.SuggestFor, candidateKey(req.CandidateIDs), req.UserContext)
+	}
+	if req.calendar != "" {
+		sum := sha256.Sum256([]byte(req.calendar))
+		return fmt.Sprintf("%s:%s:%s:%s:%s", req.Task.ID, req.SuggestFor, s.prompts.digests[req.SuggestFor],
+			hex.EncodeToString(sum[:6]), req.UserContext)
+	}
+	return fmt.Sprintf("%s:%s:%s:%s", req.Task.ID, req.SuggestFor, s.prompts.digests[req.SuggestFor], req.UserContext)
 }
@@ -0,0 +1,202 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Settings is the organization's choice of model and parameters
+type Settings = models.AISettings
+
+const (
+	// settingsID is the primary key of the single settings row
+	settingsID = 1
+	// settingsTTL is how long a replica reuses the settings it read, and so
+	// how long other replicas take to apply a change
+	settingsTTL      = 30 * time.Second
+	settingsCacheKey = "settings"
+
+	defaultMaxTemperature = 1
+	defaultMaxTokens      = 4096
+)
+
+var (
+	ErrInvalidSettings     = errors.New("invalid AI settings")
+	ErrSettingsUnavailable = errors.New("AI settings are not configured")
+)
+
+// SettingsStore keeps the organization's AI settings in the database
+type SettingsStore struct {
+	db *gorm.DB
+}
+
+func NewSettingsStore(db *gorm.DB) *SettingsStore {
+	return &SettingsStore{db: db}
+}
+
+// Load returns the stored settings, or nil if they were never changed
+func (st *SettingsStore) Load(ctx context.Context) (*Settings, error) {
+	var settings Settings
+	err := st.db.WithContext(ctx).First(&settings, "id = ?", settingsID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+func (st *SettingsStore) Save(ctx context.Context, settings *Settings) error {
+	settings.ID = settingsID
+	return st.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"model", "temperature", "max_tokens", "updated_by", "updated_at"}),
+	}).Create(settings).Error
+}
+
+// SetSettingsStore lets administrators choose the model and parameters
+// requests use, within the bounds of the server's configuration
+func (s *Service) SetSettingsStore(store *SettingsStore) {
+	s.settings = store
+}
+
+// Bounds are the choices the configuration leaves to the organization
+func (s *Service) Bounds() SettingsBounds {
+	allowed := []string{s.provider.Model()}
+	for _, model := range s.config.AllowedModels {
+		if !slices.Contains(allowed, model) {
+			allowed = append(allowed, model)
+		}
+	}
+	bounds := SettingsBounds{
+		Models:         allowed,
+		MaxTemperature: defaultMaxTemperature,
+		MaxTokens:      defaultMaxTokens,
+	}
+	if s.config.MaxTemperature > 0 {
+		bounds.MaxTemperature = s.config.MaxTemperature
+	}
+	if s.config.MaxOutputTokens > 0 {
+		bounds.MaxTokens = s.config.MaxOutputTokens
+	}
+	return bounds
+}
+
+// GetSettings returns the stored settings with the values requests use
+func (s *Service) GetSettings(ctx context.Context) (*SettingsResponse, error) {
+	if s.settings == nil {
+		return nil, ErrSettingsUnavailable
+	}
+	stored, err := s.settings.Load(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AI settings: %w", err)
+	}
+	return s.settingsResponse(stored), nil
+}
+
+// UpdateSettings replaces the organization's settings. Fields left out go
+// back to the server's configuration.
+func (s *Service) UpdateSettings(ctx context.Context, req UpdateSettingsRequest, userID string) (*SettingsResponse, error) {
+	if s.settings == nil {
+		return nil, ErrSettingsUnavailable
+	}
+	bounds := s.Bounds()
+	if req.Model != "" && !slices.Contains(bounds.Models, req.Model) {
+		return nil, fmt.Errorf("%w: model must be one of %v", ErrInvalidSettings, bounds.Models)
+	}
+	if req.Temperature != nil && (*req.Temperature < 0 || *req.Temperature > bounds.MaxTemperature) {
+		return nil, fmt.Errorf("%w: temperature must be between 0 and %g", ErrInvalidSettings, bounds.MaxTemperature)
+	}
+	if req.MaxTokens < 0 || req.MaxTokens > bounds.MaxTokens {
+		return nil, fmt.Errorf("%w: max_tokens must be at most %d", ErrInvalidSettings, bounds.MaxTokens)
+	}
+
+	settings := &Settings{
+		Model:       req.Model,
+		Temperature: req.Temperature,
+		MaxTokens:   req.MaxTokens,
+		UpdatedBy:   userID,
+		UpdatedAt:   time.Now(),
+	}
+	if err := s.settings.Save(ctx, settings); err != nil {
+		return nil, fmt.Errorf("failed to store AI settings: %w", err)
+	}
+	// Cached replies are keyed by the settings, so the new ones never
+	// match them
+	s.cache.Delete(settingsCacheKey)
+	return s.settingsResponse(settings), nil
+}
+
+func (s *Service) settingsResponse(stored *Settings) *SettingsResponse {
+	opts := s.boundedOptions(stored)
+	return &SettingsResponse{
+		Settings: stored,
+		Effective: EffectiveSettings{
+			Model:       opts.model(s.provider.Model()),
+			Temperature: opts.temperature(s.config.Temperature),
+			MaxTokens:   opts.MaxTokens,
+		},
+		Bounds: s.Bounds(),
+	}
+}
+
+// boundedOptions turns stored settings into generation options, dropping
+// or capping values the configuration no longer allows
+func (s *Service) boundedOptions(stored *Settings) GenerationOptions {
+	if stored == nil {
+		return GenerationOptions{}
+	}
+	bounds := s.Bounds()
+	var opts GenerationOptions
+	if slices.Contains(bounds.Models, stored.Model) {
+		opts.Model = stored.Model
+	}
+	if stored.Temperature != nil {
+		temperature := min(*stored.Temperature, bounds.MaxTemperature)
+		opts.Temperature = &temperature
+	}
+	if stored.MaxTokens > 0 {
+		opts.MaxTokens = min(stored.MaxTokens, bounds.MaxTokens)
+	}
+	return opts
+}
+
+// generationOptions are the options of the organization's settings for
+// the next provider call. If they cannot be loaded the configuration is
+// used.
+func (s *Service) generationOptions(ctx context.Context) GenerationOptions {
+	if s.settings == nil {
+		return GenerationOptions{}
+	}
+	if cached, found := s.cache.Get(settingsCacheKey); found {
+		return cached.(GenerationOptions)
+	}
+	stored, err := s.settings.Load(ctx)
+	if err != nil {
+		s.logger.Warn("Failed to load AI settings; using the configured model", zap.Error(err))
+		return GenerationOptions{}
+	}
+	opts := s.boundedOptions(stored)
+	s.cache.Set(settingsCacheKey, opts, settingsTTL)
+	return opts
+}
+
+// applySettings sets the organization's options on prompt, recording the
+// model on the call's span when it is not the configured one
+func (s *Service) applySettings(ctx context.Context, prompt *Prompt) {
+	prompt.Options = s.generationOptions(ctx)
+	if prompt.Options.Model != "" {
+		trace.SpanFromContext(ctx).SetAttributes(attribute.String("gen_ai.request.model", prompt.Options.Model))
+	}
+}
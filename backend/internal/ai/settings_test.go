@@ -0,0 +1,102 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newSettingsStore(t *testing.T) (*SettingsStore, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewSettingsStore(db), mock
+}
+
+func TestUpdateSettingsStaysWithinBounds(t *testing.T) {
+	s := NewServiceWithProvider(&fakeProvider{}, AIProviderConfig{
+		AllowedModels:   []string{"big-model"},
+		MaxTemperature:  0.8,
+		MaxOutputTokens: 2048,
+	}, zap.NewNop())
+	store, mock := newSettingsStore(t)
+	s.SetSettingsStore(store)
+
+	hot := float32(0.9)
+	for name, req := range map[string]UpdateSettingsRequest{
+		"unknown model":   {Model: "other-model"},
+		"temperature":     {Temperature: &hot},
+		"too many tokens": {MaxTokens: 4096},
+		"negative tokens": {MaxTokens: -1},
+	} {
+		if _, err := s.UpdateSettings(context.Background(), req, "admin-1"); !errors.Is(err, ErrInvalidSettings) {
+			t.Errorf("%s: err = %v, want ErrInvalidSettings", name, err)
+		}
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "ai_settings" .* ON CONFLICT \("id"\) DO UPDATE SET .*"updated_at"="excluded"."updated_at"`).
+		WithArgs(1, "big-model", sqlmock.AnyArg(), 0, "admin-1", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(time.Now()))
+	mock.ExpectCommit()
+	cool := float32(0.2)
+	resp, err := s.UpdateSettings(context.Background(), UpdateSettingsRequest{Model: "big-model", Temperature: &cool}, "admin-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Effective != (EffectiveSettings{Model: "big-model", Temperature: 0.2}) {
+		t.Fatalf("effective = %+v, want the chosen model and temperature", resp.Effective)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerateAppliesStoredSettings(t *testing.T) {
+	provider := &fakeProvider{completion: Completion{Text: "high"}}
+	s := NewServiceWithProvider(provider, AIProviderConfig{
+		AllowedModels:   []string{"big-model"},
+		MaxOutputTokens: 1000,
+	}, zap.NewNop())
+	store, mock := newSettingsStore(t)
+	s.SetSettingsStore(store)
+
+	// Stored before the bounds were tightened, so the tokens are capped
+	mock.ExpectQuery(`SELECT \* FROM "ai_settings"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "model", "temperature", "max_tokens"}).
+			AddRow(1, "big-model", 0.3, 4000))
+
+	for _, suggestFor := range []string{"priority", "deadline"} {
+		if _, err := s.GetSuggestions(context.Background(), SuggestionRequest{SuggestFor: suggestFor}, "user-1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(provider.prompts) != 2 {
+		t.Fatalf("prompts = %d, want 2", len(provider.prompts))
+	}
+	for _, prompt := range provider.prompts {
+		opts := prompt.Options
+		if opts.Model != "big-model" || opts.Temperature == nil || *opts.Temperature != 0.3 || opts.MaxTokens != 1000 {
+			t.Fatalf("options = %+v, want the stored settings within bounds", opts)
+		}
+	}
+	// The second call reuses the settings read by the first
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,98 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/chaos"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// errStreamInterrupted marks a streamed reply that failed after part of it
+// was passed on; retrying would repeat that part
+var errStreamInterrupted = errors.New("AI reply interrupted")
+
+// StreamSuggestions is GetSuggestions with the reply passed to onText as
+// the model writes it, for providers that can stream. Cached suggestions
+// are passed on whole. Assignee suggestions, and every suggestion of a
+// provider that cannot stream, are only returned, so callers must be ready
+// to get no text before the response.
+func (s *Service) StreamSuggestions(ctx context.Context, req SuggestionRequest, userID string, onText func(text string) error) (*SuggestionResponse, error) {
+	if err := s.admit(ctx, userID); err != nil {
+		return nil, err
+	}
+	req, err := s.guardRequest(ctx, req, userID)
+	if err != nil {
+		return nil, err
+	}
+	req.calendar = s.describeCalendar(ctx, req, time.Now())
+	var cached SuggestionResponse
+	if s.cachedResponse(ctx, s.getCacheKey(req), &cached) {
+		resp := &cached
+		if req.SuggestFor != SuggestAssignee {
+			if err := onText(resp.Suggestions[0].Suggestion); err != nil {
+				return nil, err
+			}
+		}
+		return resp, nil
+	}
+	if req.SuggestFor == SuggestAssignee {
+		return s.suggestAssignees(ctx, req, userID)
+	}
+
+	streamer, ok := s.provider.(StreamingProvider)
+	var resp *SuggestionResponse
+	err = s.withRetry(ctx, func() error {
+		var err error
+		if !ok {
+			resp, err = s.makeAIRequest(ctx, req, userID)
+			return err
+		}
+		resp, err = s.streamAIRequest(ctx, streamer, req, userID, onText)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (s *Service) streamAIRequest(ctx context.Context, streamer StreamingProvider, req SuggestionRequest, userID string, onText func(text string) error) (*SuggestionResponse, error) {
+	if s.faults.ShouldFailAI() {
+		return nil, fmt.Errorf("%w: %v", ErrAIProviderUnavailable, chaos.ErrInjectedFault)
+	}
+
+	ctx, span := s.startSpan(ctx, "StreamGenerateContent", attribute.String("ai.suggest_for", req.SuggestFor))
+	defer span.End()
+
+	text, err := s.buildPrompt(req)
+	if err != nil {
+		return nil, err
+	}
+	prompt := Prompt{Text: text}
+	s.applySettings(ctx, &prompt)
+	streamed := false
+	var completion Completion
+	err = s.callProvider(ctx, func() error {
+		var err error
+		completion, err = streamer.GenerateStream(ctx, prompt, func(text string) error {
+			streamed = true
+			return onText(text)
+		})
+		return err
+	})
+	s.recordCall(ctx, err)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		if streamed {
+			return nil, fmt.Errorf("%w: %w", errStreamInterrupted, err)
+		}
+		return nil, err
+	}
+	s.recordUsage(ctx, userID, completion)
+	return s.cacheSuggestion(ctx, req, completion), nil
+}
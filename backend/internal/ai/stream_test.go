@@ -0,0 +1,109 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"go.uber.org/zap"
+)
+
+type fakeStreamingProvider struct {
+	fakeProvider
+	chunks []string
+	calls  int
+}
+
+func (f *fakeStreamingProvider) GenerateStream(ctx context.Context, prompt Prompt, onText func(text string) error) (Completion, error) {
+	f.calls++
+	f.prompts = append(f.prompts, prompt)
+	for _, chunk := range f.chunks {
+		if err := onText(chunk); err != nil {
+			return Completion{}, err
+		}
+	}
+	return f.completion, f.err
+}
+
+func TestOpenAIProviderStreams(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, data := range []string{
+			`{"id":"1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"role":"assistant","content":"Split "}}]}`,
+			`{"id":"1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":"it up"},"finish_reason":"length"}]}`,
+			`[DONE]`,
+		} {
+			w.Write([]byte("data: " + data + "\n\n"))
+		}
+	}))
+	defer server.Close()
+
+	provider, err := NewProvider(AIProviderConfig{Provider: ProviderOpenAI, APIKey: "test-key", BaseURL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var chunks []string
+	completion, err := provider.(StreamingProvider).GenerateStream(context.Background(), Prompt{Text: "approach?"},
+		func(text string) error {
+			chunks = append(chunks, text)
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 2 || completion.Text != "Split it up" || !completion.Truncated {
+		t.Fatalf("chunks = %q, completion = %+v, want two chunks of the truncated reply", chunks, completion)
+	}
+}
+
+func TestStreamSuggestionsDoesNotRetryInterruptedReply(t *testing.T) {
+	provider := &fakeStreamingProvider{
+		fakeProvider: fakeProvider{err: ErrRateLimit},
+		chunks:       []string{"Split "},
+	}
+	s := NewServiceWithProvider(provider, AIProviderConfig{}, zap.NewNop())
+
+	var text string
+	_, err := s.StreamSuggestions(context.Background(), SuggestionRequest{SuggestFor: "approach"}, "user-1", func(chunk string) error {
+		text += chunk
+		return nil
+	})
+	if !errors.Is(err, ErrRateLimit) || provider.calls != 1 || text != "Split " {
+		t.Fatalf("err = %v after %d calls and text %q, want one call", err, provider.calls, text)
+	}
+}
+
+func TestGetSuggestionsStreamsServerSentEvents(t *testing.T) {
+	provider := &fakeStreamingProvider{
+		fakeProvider: fakeProvider{completion: Completion{Text: "Split it up"}},
+		chunks:       []string{"Split ", "it up"},
+	}
+	s := NewServiceWithProvider(provider, AIProviderConfig{}, zap.NewNop())
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(common.ErrorHandler(zap.NewNop()))
+	router.POST("/ai/suggest", NewHandler(s, zap.NewNop()).GetSuggestions)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/ai/suggest?stream=true",
+		strings.NewReader(`{"task":{"title":"Migrate the database"},"suggest_for":"approach"}`)))
+
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("status = %d with %q, want an event stream", w.Code, w.Header().Get("Content-Type"))
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		"event:chunk\ndata:{\"text\":\"Split \"}\n\n",
+		"event:chunk\ndata:{\"text\":\"it up\"}\n\n",
+		"event:done\ndata:{\"suggestions\":[{\"type\":\"primary\",\"suggestion\":\"Split it up\"",
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("body = %s, want %q", body, want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package ai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/chaos"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// translationTTL is longer than the suggestion cache because a translation
+// only changes when the task text does, and the text is part of the key
+const translationTTL = time.Hour
+
+type translation struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+// TranslateTask translates a task's title and description into lang.
+// Results are cached per language and task text, so editing a task
+// invalidates its translations. Translations count towards userID's rate
+// bucket and daily quota.
+func (s *Service) TranslateTask(ctx context.Context, lang, title, description, userID string) (string, string, error) {
+	key := translationCacheKey(lang, title, description)
+	var cached translation
+	if s.cachedResponse(ctx, key, &cached) {
+		return cached.Title, cached.Description, nil
+	}
+
+	if err := s.admit(ctx, userID); err != nil {
+		return "", "", err
+	}
+	if s.faults.ShouldFailAI() {
+		return "", "", fmt.Errorf("%w: %v", ErrAIProviderUnavailable, chaos.ErrInjectedFault)
+	}
+
+	source, err := json.Marshal(translation{Title: title, Description: description})
+	if err != nil {
+		return "", "", err
+	}
+	prompt := fmt.Sprintf("Translate the title and description of this task into the language with tag %q. "+
+		"Keep names, code and URLs unchanged. Reply with only a JSON object with the same keys.\n\n%s",
+		lang, source)
+
+	ctx, span := s.startSpan(ctx, "TranslateTask", attribute.String("ai.target_language", lang))
+	defer span.End()
+
+	completion, err := s.generate(ctx, Prompt{Text: prompt}, userID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return "", "", err
+	}
+
+	t, err := parseTranslation(completion.Text)
+	if err != nil {
+		return "", "", err
+	}
+	s.cacheResponse(ctx, key, t, translationTTL)
+	return t.Title, t.Description, nil
+}
+
+// parseTranslation reads the model's JSON reply, which may be wrapped in a
+// markdown code fence
+func parseTranslation(reply string) (translation, error) {
+	var t translation
+	if err := json.Unmarshal([]byte(trimCodeFence(reply)), &t); err != nil || t.Title == "" {
+		return translation{}, ErrInvalidResponse
+	}
+	return t, nil
+}
+
+// trimCodeFence strips the markdown code fence models often wrap JSON in
+func trimCodeFence(reply string) string {
+	reply = strings.TrimSpace(reply)
+	reply = strings.TrimPrefix(reply, "```json")
+	reply = strings.TrimPrefix(reply, "```")
+	reply = strings.TrimSuffix(reply, "```")
+	return strings.TrimSpace(reply)
+}
+
+func translationCacheKey(lang, title, description string) string {
+	sum := sha256.Sum256([]byte(title + "\x00" + description))
+	return "translate:" + strings.ToLower(lang) + ":" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,266 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+	"github.com/patrickmn/go-cache"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Usage is one user's AI calls and tokens on one day
+type Usage = models.AIUsage
+
+const (
+	// DefaultPlan is the plan of users no administrator moved to another
+	DefaultPlan = "free"
+	// planTTL is how long a replica reuses a user's plan, and so how long
+	// other replicas take to apply a change
+	planTTL = time.Minute
+	// userLimiterTTL is how long the rate bucket of an idle user is kept;
+	// it is full again long before
+	userLimiterTTL     = 10 * time.Minute
+	defaultUsageDays   = 7
+	maxUsageDays       = 90
+	planCacheKeyPrefix = "plan:"
+)
+
+var (
+	ErrDailyQuotaExceeded = errors.New("daily AI quota exceeded")
+	ErrUsageUnavailable   = errors.New("AI usage accounting is not configured")
+	ErrUnknownPlan        = errors.New("unknown AI plan")
+	ErrUserNotFound       = errors.New("user not found")
+)
+
+// PlanQuota is what a user of the plan may use per day (UTC): calls that
+// return a reply and the tokens they use. 0 is unlimited.
+type PlanQuota struct {
+	Calls  int64 `json:"calls"`
+	Tokens int64 `json:"tokens"`
+}
+
+// exceededBy reports whether usage used up the quota
+func (q PlanQuota) exceededBy(usage Usage) bool {
+	return (q.Calls > 0 && usage.Calls >= q.Calls) ||
+		(q.Tokens > 0 && usage.InputTokens+usage.OutputTokens >= q.Tokens)
+}
+
+// ParsePlanQuotas reads quotas written as plan:calls:tokens, such as
+// free:200:100000. The default plan is unlimited unless it is listed.
+func ParsePlanQuotas(specs []string) (map[string]PlanQuota, error) {
+	quotas := make(map[string]PlanQuota, len(specs))
+	for _, spec := range specs {
+		plan, calls, tokens, err := common.ParsePlanQuota(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid AI plan quota: %w", err)
+		}
+		quotas[plan] = PlanQuota{Calls: calls, Tokens: tokens}
+	}
+	return quotas, nil
+}
+
+// usageDay is the day, at midnight UTC, that usage at t counts towards
+func usageDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// UsageStore keeps each user's daily AI usage, and their plan on the
+// users table
+type UsageStore struct {
+	db *gorm.DB
+}
+
+func NewUsageStore(db *gorm.DB) *UsageStore {
+	return &UsageStore{db: db}
+}
+
+// record adds one call and its tokens to userID's usage of day
+func (st *UsageStore) record(ctx context.Context, userID string, day time.Time, completion Completion) error {
+	return st.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "day"}},
+		DoUpdates: clause.Set{
+			{Column: clause.Column{Name: "calls"}, Value: gorm.Expr("ai_usages.calls + 1")},
+			{Column: clause.Column{Name: "input_tokens"}, Value: gorm.Expr("ai_usages.input_tokens + EXCLUDED.input_tokens")},
+			{Column: clause.Column{Name: "output_tokens"}, Value: gorm.Expr("ai_usages.output_tokens + EXCLUDED.output_tokens")},
+		},
+	}).Create(&Usage{
+		UserID:       userID,
+		Day:          day,
+		Calls:        1,
+		InputTokens:  int64(completion.InputTokens),
+		OutputTokens: int64(completion.OutputTokens),
+	}).Error
+}
+
+// since returns userID's usage from day on, newest first. Days without
+// calls are left out.
+func (st *UsageStore) since(ctx context.Context, userID string, day time.Time) ([]Usage, error) {
+	usage := []Usage{}
+	err := st.db.WithContext(ctx).Where("user_id = ? AND day >= ?", userID, day).
+		Order("day DESC").Find(&usage).Error
+	return usage, err
+}
+
+// plan returns the plan of userID
+func (st *UsageStore) plan(ctx context.Context, userID string) (string, error) {
+	var plans []string
+	if err := st.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ?", userID).
+		Pluck("ai_plan", &plans).Error; err != nil {
+		return "", err
+	}
+	if len(plans) == 0 {
+		return "", ErrUserNotFound
+	}
+	return plans[0], nil
+}
+
+func (st *UsageStore) setPlan(ctx context.Context, userID, plan string) error {
+	result := st.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("ai_plan", plan)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// SetUsage enables accounting of each user's daily AI usage in store and
+// the daily quotas of their plans
+func (s *Service) SetUsage(store *UsageStore, quotas map[string]PlanQuota) {
+	s.usage = store
+	s.quotas = quotas
+}
+
+// userLimiter is the rate bucket of userID's AI requests, so one heavy
+// user cannot use up everyone's requests
+func (s *Service) userLimiter(userID string) *rate.Limiter {
+	s.limitersMu.Lock()
+	defer s.limitersMu.Unlock()
+	limiter, found := s.userLimiters.Get(userID)
+	if !found {
+		limiter = rate.NewLimiter(rate.Every(time.Second), 10)
+	}
+	s.userLimiters.Set(userID, limiter, cache.DefaultExpiration)
+	return limiter.(*rate.Limiter)
+}
+
+// admit takes one request of userID from its rate bucket and checks that
+// its daily quota is not used up
+func (s *Service) admit(ctx context.Context, userID string) error {
+	if !s.userLimiter(userID).Allow() {
+		return ErrRateLimitExceeded
+	}
+	return s.checkQuota(ctx, userID)
+}
+
+// checkQuota returns ErrDailyQuotaExceeded once userID used up the daily
+// quota of its plan. Should usage be unavailable, the call is let through.
+func (s *Service) checkQuota(ctx context.Context, userID string) error {
+	if s.usage == nil || userID == "" {
+		return nil
+	}
+	_, quota, err := s.userQuota(ctx, userID)
+	if err == nil && quota != (PlanQuota{}) {
+		var today []Usage
+		if today, err = s.usage.since(ctx, userID, usageDay(time.Now())); err == nil &&
+			len(today) > 0 && quota.exceededBy(today[0]) {
+			return ErrDailyQuotaExceeded
+		}
+	}
+	if err != nil && ctx.Err() == nil {
+		s.logger.Warn("Failed to check AI quota; allowing the call", zap.String("user_id", userID), zap.Error(err))
+	}
+	return nil
+}
+
+// userQuota returns the plan of userID and its quota. Users of a plan that
+// is no longer configured get the default plan's quota.
+func (s *Service) userQuota(ctx context.Context, userID string) (string, PlanQuota, error) {
+	var plan string
+	if cached, found := s.cache.Get(planCacheKeyPrefix + userID); found {
+		plan = cached.(string)
+	} else {
+		var err error
+		if plan, err = s.usage.plan(ctx, userID); err != nil {
+			return "", PlanQuota{}, err
+		}
+		s.cache.Set(planCacheKeyPrefix+userID, plan, planTTL)
+	}
+	quota, ok := s.quotas[plan]
+	if !ok {
+		quota = s.quotas[DefaultPlan]
+	}
+	return plan, quota, nil
+}
+
+// recordUsage counts a call of userID that returned completion, even if
+// the caller has gone. Failures are only logged.
+func (s *Service) recordUsage(ctx context.Context, userID string, completion Completion) {
+	if s.usage == nil || userID == "" {
+		return
+	}
+	if err := s.usage.record(context.WithoutCancel(ctx), userID, usageDay(time.Now()), completion); err != nil {
+		s.logger.Warn("Failed to record AI usage", zap.String("user_id", userID), zap.Error(err))
+	}
+}
+
+// GetUsage returns userID's plan and quota with its usage today and on
+// the last days days, today included
+func (s *Service) GetUsage(ctx context.Context, userID string, days int) (*UsageResponse, error) {
+	if s.usage == nil {
+		return nil, ErrUsageUnavailable
+	}
+	if days <= 0 {
+		days = defaultUsageDays
+	}
+	days = min(days, maxUsageDays)
+
+	plan, quota, err := s.userQuota(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	today := usageDay(time.Now())
+	history, err := s.usage.since(ctx, userID, today.AddDate(0, 0, 1-days))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AI usage: %w", err)
+	}
+
+	resp := &UsageResponse{
+		Plan:     plan,
+		Quota:    quota,
+		Today:    Usage{Day: today},
+		ResetsAt: today.AddDate(0, 0, 1),
+		Days:     history,
+	}
+	if len(history) > 0 && history[0].Day.Equal(today) {
+		resp.Today = history[0]
+	}
+	return resp, nil
+}
+
+// SetUserPlan moves userID to a configured plan. Other replicas apply it
+// within a minute.
+func (s *Service) SetUserPlan(ctx context.Context, userID, plan string) error {
+	if s.usage == nil {
+		return ErrUsageUnavailable
+	}
+	if _, ok := s.quotas[plan]; !ok && plan != DefaultPlan {
+		return fmt.Errorf("%w: %q", ErrUnknownPlan, plan)
+	}
+	if err := s.usage.setPlan(ctx, userID, plan); err != nil {
+		return err
+	}
+	s.cache.Delete(planCacheKeyPrefix + userID)
+	return nil
+}
@@ -0,0 +1,115 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newUsageStore(t *testing.T) (*UsageStore, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewUsageStore(db), mock
+}
+
+func TestParsePlanQuotas(t *testing.T) {
+	quotas, err := ParsePlanQuotas([]string{"free:200:100000", "pro:0:2000000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quotas["free"] != (PlanQuota{Calls: 200, Tokens: 100000}) || quotas["pro"] != (PlanQuota{Tokens: 2000000}) {
+		t.Fatalf("quotas = %+v", quotas)
+	}
+	for _, spec := range []string{"free", "free:200", ":1:1", "free:-1:0", "free:1:lots"} {
+		if _, err := ParsePlanQuotas([]string{spec}); err == nil {
+			t.Errorf("ParsePlanQuotas(%q) should fail", spec)
+		}
+	}
+}
+
+func TestGetSuggestionsCountsTowardsDailyQuota(t *testing.T) {
+	provider := &fakeProvider{completion: Completion{Text: "high", InputTokens: 40, OutputTokens: 12}}
+	s := NewServiceWithProvider(provider, AIProviderConfig{}, zap.NewNop())
+	store, mock := newUsageStore(t)
+	s.SetUsage(store, map[string]PlanQuota{DefaultPlan: {Calls: 1}})
+	today := usageDay(time.Now())
+
+	mock.ExpectQuery(`SELECT "ai_plan" FROM "users" WHERE id = \$1`).
+		WithArgs("user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"ai_plan"}).AddRow(DefaultPlan))
+	mock.ExpectQuery(`SELECT \* FROM "ai_usages" WHERE user_id = \$1 AND day >= \$2 ORDER BY day DESC`).
+		WithArgs("user-1", today).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "day", "calls"}))
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO "ai_usages" .* ON CONFLICT \("user_id","day"\) DO UPDATE SET "calls"=ai_usages.calls \+ 1`).
+		WithArgs("user-1", today, 1, 40, 12).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if _, err := s.GetSuggestions(context.Background(), SuggestionRequest{SuggestFor: "priority"}, "user-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	mock.ExpectQuery(`SELECT \* FROM "ai_usages"`).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "day", "calls", "input_tokens", "output_tokens"}).
+			AddRow("user-1", today, 1, 40, 12))
+	_, err := s.GetSuggestions(context.Background(), SuggestionRequest{SuggestFor: "approach"}, "user-1")
+	if !errors.Is(err, ErrDailyQuotaExceeded) || len(provider.prompts) != 1 {
+		t.Fatalf("err = %v after %d calls, want the quota of one call used up", err, len(provider.prompts))
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUserRateBucketsAreSeparate(t *testing.T) {
+	s := NewServiceWithProvider(&fakeProvider{completion: Completion{Text: "ok"}}, AIProviderConfig{}, zap.NewNop())
+	for range 10 {
+		s.userLimiter("user-1").Allow()
+	}
+
+	if err := s.admit(context.Background(), "user-1"); !errors.Is(err, ErrRateLimitExceeded) {
+		t.Fatalf("err = %v, want the heavy user limited", err)
+	}
+	if err := s.admit(context.Background(), "user-2"); err != nil {
+		t.Fatalf("err = %v, want other users unaffected", err)
+	}
+}
+
+func TestSetUserPlanRejectsUnknownPlans(t *testing.T) {
+	s := NewServiceWithProvider(&fakeProvider{}, AIProviderConfig{}, zap.NewNop())
+	store, mock := newUsageStore(t)
+	s.SetUsage(store, map[string]PlanQuota{"pro": {Calls: 2000}})
+
+	if err := s.SetUserPlan(context.Background(), "user-1", "enterprise"); !errors.Is(err, ErrUnknownPlan) {
+		t.Fatalf("err = %v, want ErrUnknownPlan", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "users" SET "ai_plan"=\$1,"updated_at"=\$2 WHERE id = \$3`).
+		WithArgs("pro", sqlmock.AnyArg(), "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	if err := s.SetUserPlan(context.Background(), "user-1", "pro"); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
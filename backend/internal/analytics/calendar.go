@@ -0,0 +1,118 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+)
+
+const (
+	maxCalendarDays      = 366
+	defaultDailyCapacity = 40
+)
+
+// SetDailyCapacity sets how many estimated hours of work the team can
+// finish in a day, against which calendar days are flagged as overloaded
+func (s *Service) SetDailyCapacity(hours float64) {
+	s.dailyCapacity = hours
+}
+
+// calendarQuery totals the tasks due on each day of the range per project.
+// Days are UTC calendar days.
+const calendarQuery = `
+SELECT (t.due_date AT TIME ZONE 'UTC')::date AS day,
+	t.project AS project,
+	COUNT(*) AS due,
+	COUNT(*) FILTER (WHERE t.status <> @completed) AS open,
+	COALESCE(SUM(t.estimated_effort) FILTER (WHERE t.status <> @completed), 0) AS estimated_hours
+FROM tasks t
+WHERE t.deleted_at IS NULL
+	AND t.due_date >= @from AND t.due_date < @until
+	AND (@project = '' OR t.project = @project)
+GROUP BY 1, 2
+ORDER BY 1, 2`
+
+// Calendar lists every day from params.From to params.To with the tasks
+// due on it across projects. from defaults to today and to to four weeks
+// after from.
+func (s *Service) Calendar(ctx context.Context, params CalendarParams) (*CalendarResponse, error) {
+	from := time.Now().UTC().Truncate(24 * time.Hour)
+	if params.From != nil {
+		from = *params.From
+	}
+	to := from.AddDate(0, 0, 27)
+	if params.To != nil {
+		to = *params.To
+	}
+
+	if from.After(to) || to.Sub(from) >= maxCalendarDays*24*time.Hour {
+		return nil, ErrInvalidRange
+	}
+	if params.RowLimit > 0 {
+		if latest := from.AddDate(0, 0, params.RowLimit-1); to.After(latest) {
+			to = latest
+		}
+	}
+
+	capacity := params.CapacityHours
+	if capacity <= 0 {
+		capacity = s.dailyCapacity
+	}
+	if capacity <= 0 {
+		capacity = defaultDailyCapacity
+	}
+
+	var rows []struct {
+		Day            time.Time
+		Project        string
+		Due            int64
+		Open           int64
+		EstimatedHours float64
+	}
+	if err := s.read(ctx).Raw(calendarQuery, map[string]interface{}{
+		"completed": models.StatusCompleted,
+		"from":      from,
+		"until":     to.AddDate(0, 0, 1),
+		"project":   params.Project,
+	}).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to compute calendar: %w", err)
+	}
+
+	resp := &CalendarResponse{
+		Project:       params.Project,
+		From:          from,
+		To:            to,
+		CapacityHours: capacity,
+		Days:          []CalendarDay{},
+	}
+	byDay := make(map[string]int)
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		byDay[day.Format(time.DateOnly)] = len(resp.Days)
+		resp.Days = append(resp.Days, CalendarDay{Day: day, Projects: []CalendarProject{}})
+	}
+	for _, row := range rows {
+		i, ok := byDay[row.Day.Format(time.DateOnly)]
+		if !ok {
+			continue
+		}
+		day := &resp.Days[i]
+		day.Due += row.Due
+		day.Open += row.Open
+		day.EstimatedHours += row.EstimatedHours
+		day.Projects = append(day.Projects, CalendarProject{
+			Project:        row.Project,
+			Due:            row.Due,
+			Open:           row.Open,
+			EstimatedHours: row.EstimatedHours,
+		})
+	}
+	for i := range resp.Days {
+		if resp.Days[i].EstimatedHours > capacity {
+			resp.Days[i].Overloaded = true
+			resp.OverloadedDays++
+		}
+	}
+	return resp, nil
+}
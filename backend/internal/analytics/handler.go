@@ -0,0 +1,86 @@
+package analytics
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"go.uber.org/zap"
+)
+
+type Handler struct {
+	service *Service
+	logger  *zap.Logger
+}
+
+func NewHandler(service *Service, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *Handler) Burndown(c *gin.Context) {
+	var params BurndownParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		_ = c.Error(common.NewValidationError(err))
+		return
+	}
+
+	params.RowLimit = c.GetInt("row_limit")
+
+	resp, err := h.service.Burndown(c.Request.Context(), params)
+	if err != nil {
+		if err == ErrInvalidRange {
+			_ = c.Error(common.NewInvalidInputError(err.Error()))
+			return
+		}
+		h.logger.Error("Failed to compute burndown", zap.Error(err))
+		_ = c.Error(common.NewInternalServerError("failed to compute burndown"))
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) Summary(c *gin.Context) {
+	var params SummaryParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		_ = c.Error(common.NewValidationError(err))
+		return
+	}
+
+	params.RowLimit = c.GetInt("row_limit")
+
+	resp, err := h.service.Summary(c.Request.Context(), params)
+	if err != nil {
+		h.logger.Error("Failed to compute analytics summary", zap.Error(err))
+		_ = c.Error(common.NewInternalServerError("failed to compute analytics summary"))
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) Calendar(c *gin.Context) {
+	var params CalendarParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		_ = c.Error(common.NewValidationError(err))
+		return
+	}
+
+	params.RowLimit = c.GetInt("row_limit")
+
+	resp, err := h.service.Calendar(c.Request.Context(), params)
+	if err != nil {
+		if err == ErrInvalidRange {
+			_ = c.Error(common.NewInvalidInputError(err.Error()))
+			return
+		}
+		h.logger.Error("Failed to compute calendar", zap.Error(err))
+		_ = c.Error(common.NewInternalServerError("failed to compute calendar"))
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
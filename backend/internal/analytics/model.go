@@ -0,0 +1,106 @@
+package analytics
+
+import "time"
+
+type BurndownParams struct {
+	Project string     `form:"project"`
+	From    *time.Time `form:"from" time_format:"2006-01-02"`
+	To      *time.Time `form:"to" time_format:"2006-01-02"`
+
+	// RowLimit caps the number of points, keeping the most recent days;
+	// set from the caller's token policy
+	RowLimit int `form:"-"`
+}
+
+type BurndownPoint struct {
+	Day       time.Time `json:"day"`
+	Completed float64   `json:"completed"`
+	Remaining float64   `json:"remaining"`
+}
+
+type BurndownResponse struct {
+	Project string          `json:"project,omitempty"`
+	From    time.Time       `json:"from"`
+	To      time.Time       `json:"to"`
+	Points  []BurndownPoint `json:"points"`
+}
+
+type SummaryParams struct {
+	Project string `form:"project"`
+	Weeks   int    `form:"weeks,default=8" binding:"min=1,max=52"`
+
+	// RowLimit caps every list in the response (weeks and workload rows);
+	// set from the caller's token policy
+	RowLimit int `form:"-"`
+}
+
+type CountBucket struct {
+	Key   string `json:"key"`
+	Count int64  `json:"count"`
+}
+
+type WeeklyThroughput struct {
+	Week      time.Time `json:"week"`
+	Created   int64     `json:"created"`
+	Completed int64     `json:"completed"`
+}
+
+type AssigneeWorkload struct {
+	AssignedTo string  `json:"assigned_to"`
+	Open       int64   `json:"open"`
+	InProgress int64   `json:"in_progress"`
+	Overdue    int64   `json:"overdue"`
+	OpenEffort float64 `json:"open_effort"`
+}
+
+type SummaryResponse struct {
+	Project                  string             `json:"project,omitempty"`
+	ByStatus                 []CountBucket      `json:"by_status"`
+	ByPriority               []CountBucket      `json:"by_priority"`
+	Overdue                  int64              `json:"overdue"`
+	OverdueByDeadlineType    []CountBucket      `json:"overdue_by_deadline_type"`
+	AvgCompletionTimeSeconds float64            `json:"avg_completion_time_seconds"`
+	Weekly                   []WeeklyThroughput `json:"weekly"`
+	Workload                 []AssigneeWorkload `json:"workload"`
+}
+
+type CalendarParams struct {
+	Project string     `form:"project"`
+	From    *time.Time `form:"from" time_format:"2006-01-02"`
+	To      *time.Time `form:"to" time_format:"2006-01-02"`
+
+	// CapacityHours overrides the configured daily team capacity
+	CapacityHours float64 `form:"capacity_hours" binding:"omitempty,gt=0"`
+
+	// RowLimit caps the number of days, keeping the earliest; set from the
+	// caller's token policy
+	RowLimit int `form:"-"`
+}
+
+// CalendarProject is the part of a day's load due in one project
+type CalendarProject struct {
+	Project        string  `json:"project"`
+	Due            int64   `json:"due"`
+	Open           int64   `json:"open"`
+	EstimatedHours float64 `json:"estimated_hours"`
+}
+
+// CalendarDay is the work due on one day. EstimatedHours counts only open
+// tasks, and the day is overloaded when it exceeds the capacity.
+type CalendarDay struct {
+	Day            time.Time         `json:"day"`
+	Due            int64             `json:"due"`
+	Open           int64             `json:"open"`
+	EstimatedHours float64           `json:"estimated_hours"`
+	Overloaded     bool              `json:"overloaded"`
+	Projects       []CalendarProject `json:"projects"`
+}
+
+type CalendarResponse struct {
+	Project        string        `json:"project,omitempty"`
+	From           time.Time     `json:"from"`
+	To             time.Time     `json:"to"`
+	CapacityHours  float64       `json:"capacity_hours"`
+	OverloadedDays int           `json:"overloaded_days"`
+	Days           []CalendarDay `json:"days"`
+}
@@ -0,0 +1,192 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/database"
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const maxBurndownDays = 366
+
+var ErrInvalidRange = errors.New("invalid date range")
+
+type Service struct {
+	db     *gorm.DB
+	logger *zap.Logger
+
+	// dailyCapacity is the team's estimated hours of work per day
+	dailyCapacity float64
+}
+
+func NewService(db *gorm.DB, logger *zap.Logger) *Service {
+	return &Service{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// burndownQuery buckets estimated effort per day into completed and
+// remaining. A task counts towards a day once it has been created, and is
+// completed on that day if its completed_at falls before the end of the day.
+const burndownQuery = `
+SELECT d.day::date AS day,
+	COALESCE(SUM(t.estimated_effort) FILTER (
+		WHERE t.completed_at IS NOT NULL AND t.completed_at < d.day + interval '1 day'
+	), 0) AS completed,
+	COALESCE(SUM(t.estimated_effort) FILTER (
+		WHERE t.completed_at IS NULL OR t.completed_at >= d.day + interval '1 day'
+	), 0) AS remaining
+FROM generate_series(?::date, ?::date, interval '1 day') AS d(day)
+LEFT JOIN tasks t
+	ON t.created_at < d.day + interval '1 day'
+	AND t.deleted_at IS NULL
+	AND (? = '' OR t.project = ?)
+GROUP BY d.day
+ORDER BY d.day`
+
+func (s *Service) Burndown(ctx context.Context, params BurndownParams) (*BurndownResponse, error) {
+	to := time.Now().UTC().Truncate(24 * time.Hour)
+	if params.To != nil {
+		to = *params.To
+	}
+	from := to.AddDate(0, 0, -13)
+	if params.From != nil {
+		from = *params.From
+	}
+
+	if from.After(to) || to.Sub(from) > maxBurndownDays*24*time.Hour {
+		return nil, ErrInvalidRange
+	}
+	if params.RowLimit > 0 {
+		if earliest := to.AddDate(0, 0, 1-params.RowLimit); from.Before(earliest) {
+			from = earliest
+		}
+	}
+
+	resp := &BurndownResponse{
+		Project: params.Project,
+		From:    from,
+		To:      to,
+		Points:  []BurndownPoint{},
+	}
+	if err := s.read(ctx).Raw(burndownQuery,
+		from.Format("2006-01-02"), to.Format("2006-01-02"),
+		params.Project, params.Project,
+	).Scan(&resp.Points).Error; err != nil {
+		return nil, fmt.Errorf("failed to compute burndown: %w", err)
+	}
+
+	return resp, nil
+}
+
+// weeklyThroughputQuery counts tasks created and completed in each of the
+// weeks from the given start week up to the current one
+const weeklyThroughputQuery = `
+SELECT w.week::date AS week,
+	COUNT(t.id) FILTER (WHERE date_trunc('week', t.created_at) = w.week) AS created,
+	COUNT(t.id) FILTER (WHERE date_trunc('week', t.completed_at) = w.week) AS completed
+FROM generate_series(date_trunc('week', ?::timestamp), date_trunc('week', now()), interval '1 week') AS w(week)
+LEFT JOIN tasks t
+	ON t.deleted_at IS NULL
+	AND (date_trunc('week', t.created_at) = w.week OR date_trunc('week', t.completed_at) = w.week)
+	AND (? = '' OR t.project = ?)
+GROUP BY w.week
+ORDER BY w.week`
+
+// read starts a query that may run on a read replica, as reports can
+// lag the latest writes by the replication delay
+func (s *Service) read(ctx context.Context) *gorm.DB {
+	return s.db.WithContext(database.ReadFromReplica(ctx))
+}
+
+func (s *Service) tasks(ctx context.Context, project string) *gorm.DB {
+	query := s.read(ctx).Model(&models.Task{})
+	if project != "" {
+		query = query.Where("tasks.project = ?", project)
+	}
+	return query
+}
+
+func (s *Service) Summary(ctx context.Context, params SummaryParams) (*SummaryResponse, error) {
+	if params.Weeks <= 0 {
+		params.Weeks = 8
+	}
+	if params.RowLimit > 0 && params.Weeks > params.RowLimit {
+		params.Weeks = params.RowLimit
+	}
+
+	now := time.Now()
+	resp := &SummaryResponse{
+		Project:               params.Project,
+		ByStatus:              []CountBucket{},
+		ByPriority:            []CountBucket{},
+		OverdueByDeadlineType: []CountBucket{},
+		Weekly:                []WeeklyThroughput{},
+		Workload:              []AssigneeWorkload{},
+	}
+
+	if err := s.tasks(ctx, params.Project).
+		Select("status AS key, COUNT(*) AS count").
+		Group("status").
+		Scan(&resp.ByStatus).Error; err != nil {
+		return nil, fmt.Errorf("failed to count tasks by status: %w", err)
+	}
+
+	if err := s.tasks(ctx, params.Project).
+		Select("priority AS key, COUNT(*) AS count").
+		Group("priority").
+		Scan(&resp.ByPriority).Error; err != nil {
+		return nil, fmt.Errorf("failed to count tasks by priority: %w", err)
+	}
+
+	if err := s.tasks(ctx, params.Project).
+		Select("deadline_type AS key, COUNT(*) AS count").
+		Where("due_date < ? AND status <> ?", now, models.StatusCompleted).
+		Group("deadline_type").
+		Scan(&resp.OverdueByDeadlineType).Error; err != nil {
+		return nil, fmt.Errorf("failed to count overdue tasks: %w", err)
+	}
+	for _, bucket := range resp.OverdueByDeadlineType {
+		resp.Overdue += bucket.Count
+	}
+
+	if err := s.tasks(ctx, params.Project).
+		Select("COALESCE(AVG(EXTRACT(EPOCH FROM completed_at - created_at)), 0)").
+		Where("completed_at IS NOT NULL").
+		Scan(&resp.AvgCompletionTimeSeconds).Error; err != nil {
+		return nil, fmt.Errorf("failed to compute average completion time: %w", err)
+	}
+
+	since := now.AddDate(0, 0, -7*(params.Weeks-1))
+	if err := s.read(ctx).Raw(weeklyThroughputQuery, since, params.Project, params.Project).
+		Scan(&resp.Weekly).Error; err != nil {
+		return nil, fmt.Errorf("failed to compute weekly throughput: %w", err)
+	}
+
+	// Workload counts a task once for each of its assignees
+	workload := s.tasks(ctx, params.Project)
+	if params.RowLimit > 0 {
+		workload = workload.Limit(params.RowLimit)
+	}
+	if err := workload.
+		Joins("JOIN task_assignees ta ON ta.task_id = tasks.id").
+		Select(`ta.user_id AS assigned_to,
+			COUNT(*) FILTER (WHERE tasks.status <> ?) AS open,
+			COUNT(*) FILTER (WHERE tasks.status = ?) AS in_progress,
+			COUNT(*) FILTER (WHERE tasks.status <> ? AND tasks.due_date < ?) AS overdue,
+			COALESCE(SUM(tasks.estimated_effort) FILTER (WHERE tasks.status <> ?), 0) AS open_effort`,
+			models.StatusCompleted, models.StatusInProgress, models.StatusCompleted, now, models.StatusCompleted).
+		Group("ta.user_id").
+		Order("open DESC").
+		Scan(&resp.Workload).Error; err != nil {
+		return nil, fmt.Errorf("failed to compute assignee workload: %w", err)
+	}
+
+	return resp, nil
+}
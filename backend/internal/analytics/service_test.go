@@ -0,0 +1,132 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestService(t *testing.T) (*Service, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewService(db, zap.NewNop()), mock
+}
+
+func TestBurndownRejectsInvertedRange(t *testing.T) {
+	s, _ := newTestService(t)
+	from := time.Date(2024, 3, 14, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	if _, err := s.Burndown(context.Background(), BurndownParams{From: &from, To: &to}); err != ErrInvalidRange {
+		t.Fatalf("err = %v, want ErrInvalidRange", err)
+	}
+}
+
+func TestBurndownScansDailyPoints(t *testing.T) {
+	s, mock := newTestService(t)
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`FROM generate_series`).
+		WithArgs("2024-03-01", "2024-03-02", "web", "web").
+		WillReturnRows(sqlmock.NewRows([]string{"day", "completed", "remaining"}).
+			AddRow(from, 1.0, 4.0).
+			AddRow(to, 3.0, 2.0))
+
+	resp, err := s.Burndown(context.Background(), BurndownParams{Project: "web", From: &from, To: &to})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Points) != 2 || resp.Points[1].Completed != 3 || resp.Points[1].Remaining != 2 {
+		t.Fatalf("points = %+v", resp.Points)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSummaryStopsWhenContextIsDone(t *testing.T) {
+	s, mock := newTestService(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := s.Summary(ctx, SummaryParams{Weeks: 8})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBurndownRowLimitKeepsMostRecentDays(t *testing.T) {
+	s, mock := newTestService(t)
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`FROM generate_series`).
+		WithArgs("2024-03-08", "2024-03-10", "", "").
+		WillReturnRows(sqlmock.NewRows([]string{"day", "completed", "remaining"}))
+
+	resp, err := s.Burndown(context.Background(), BurndownParams{From: &from, To: &to, RowLimit: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.From.Equal(time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("from = %s, want the range clipped to the last 3 days", resp.From)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCalendarFlagsOverloadedDays(t *testing.T) {
+	s, mock := newTestService(t)
+	s.SetDailyCapacity(8)
+	from := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 6, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`FROM tasks t\s+WHERE t.deleted_at IS NULL`).
+		WithArgs("completed", "completed", from, to.AddDate(0, 0, 1), "", "").
+		WillReturnRows(sqlmock.NewRows([]string{"day", "project", "due", "open", "estimated_hours"}).
+			AddRow(from, "web", 2, 2, 6.0).
+			AddRow(from, "ops", 1, 1, 3.0).
+			AddRow(to, "web", 1, 0, 0.0))
+
+	resp, err := s.Calendar(context.Background(), CalendarParams{From: &from, To: &to})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Days) != 3 {
+		t.Fatalf("got %d days, want every day in the range", len(resp.Days))
+	}
+	first := resp.Days[0]
+	if first.Due != 3 || first.EstimatedHours != 9 || !first.Overloaded || len(first.Projects) != 2 {
+		t.Fatalf("first day = %+v, want both projects totalled and overloaded", first)
+	}
+	if resp.Days[1].Due != 0 || resp.Days[2].Due != 1 || resp.Days[2].Overloaded {
+		t.Fatalf("days = %+v", resp.Days)
+	}
+	if resp.OverloadedDays != 1 || resp.CapacityHours != 8 {
+		t.Fatalf("resp = %+v, want one overloaded day at 8h capacity", resp)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
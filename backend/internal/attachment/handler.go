@@ -0,0 +1,100 @@
+package attachment
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"go.uber.org/zap"
+)
+
+type Handler struct {
+	service *Service
+	logger  *zap.Logger
+}
+
+func NewHandler(service *Service, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// Upload accepts a multipart form with the file in the "file" field
+func (h *Handler) Upload(c *gin.Context) {
+	// Leave room for the multipart envelope around the file itself
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.service.maxBytes+1<<20)
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			_ = c.Error(common.NewError(http.StatusRequestEntityTooLarge, ErrFileTooLarge.Error()))
+			return
+		}
+		_ = c.Error(common.NewInvalidInputError("a file is required in the \"file\" field"))
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		_ = c.Error(common.NewInvalidInputError("failed to read uploaded file"))
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, h.service.maxBytes+1))
+	if err != nil {
+		_ = c.Error(common.NewInvalidInputError("failed to read uploaded file"))
+		return
+	}
+
+	attachment, err := h.service.Upload(c.Request.Context(), c.Param("id"), c.GetString("user_id"),
+		header.Filename, header.Header.Get("Content-Type"), data)
+	if err != nil {
+		switch err {
+		case ErrTaskNotFound:
+			_ = c.Error(common.NewNotFoundError(err.Error()))
+		case ErrEmptyFile:
+			_ = c.Error(common.NewInvalidInputError(err.Error()))
+		case ErrFileTooLarge:
+			_ = c.Error(common.NewError(http.StatusRequestEntityTooLarge, err.Error()))
+		default:
+			h.logger.Error("Failed to upload attachment", zap.Error(err))
+			_ = c.Error(common.NewInternalServerError("failed to upload attachment"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+func (h *Handler) List(c *gin.Context) {
+	attachments, err := h.service.List(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.logger.Error("Failed to list attachments", zap.Error(err))
+		_ = c.Error(common.NewInternalServerError("failed to list attachments"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"attachments": attachments})
+}
+
+// Download returns the stored file as an attachment
+func (h *Handler) Download(c *gin.Context) {
+	attachment, err := h.service.Get(c.Request.Context(), c.Param("id"), c.Param("attachment_id"))
+	if err != nil {
+		if err == ErrAttachmentNotFound {
+			_ = c.Error(common.NewNotFoundError(err.Error()))
+			return
+		}
+		h.logger.Error("Failed to load attachment", zap.Error(err))
+		_ = c.Error(common.NewInternalServerError("failed to load attachment"))
+		return
+	}
+
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Data(http.StatusOK, attachment.ContentType, attachment.Data)
+}
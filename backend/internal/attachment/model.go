@@ -0,0 +1,14 @@
+package attachment
+
+import "github.com/iSparshP/real-time-task-management-system/internal/models"
+
+type Attachment = models.TaskAttachment
+
+// ocrContentTypes are the uploads that are run through OCR
+var ocrContentTypes = map[string]bool{
+	"image/png":       true,
+	"image/jpeg":      true,
+	"image/webp":      true,
+	"image/heic":      true,
+	"application/pdf": true,
+}
@@ -0,0 +1,110 @@
+package attachment
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// ocrBatchSize bounds how many attachments one pass works through
+	ocrBatchSize = 20
+	// ocrRetryDelay is the wait before a failed attachment is tried again
+	ocrRetryDelay = time.Minute
+)
+
+// errNothingPending ends an OCR pass
+var errNothingPending = errors.New("no attachments pending OCR")
+
+// Start runs pending attachments through OCR until ctx is cancelled. It is
+// a no-op without an extractor, leaving uploads pending.
+func (s *Service) Start(ctx context.Context) {
+	if s.extractor == nil {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			s.processPending(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-s.wake:
+			}
+		}
+	}()
+}
+
+// processPending works through up to ocrBatchSize pending attachments
+func (s *Service) processPending(ctx context.Context) {
+	s.processing.Lock()
+	defer s.processing.Unlock()
+
+	for i := 0; i < ocrBatchSize && ctx.Err() == nil; i++ {
+		err := s.processNext(ctx)
+		if errors.Is(err, errNothingPending) {
+			return
+		}
+		if err != nil {
+			s.logger.Error("Failed to run OCR on attachment", zap.Error(err))
+			return
+		}
+	}
+}
+
+// processNext claims one pending attachment with a skip-locked row lock,
+// so replicas never OCR the same file, and records the outcome. Extraction
+// failures are retried until maxAttempts, after which the attachment is
+// marked failed.
+func (s *Service) processNext(ctx context.Context) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var attachment Attachment
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("ocr_status = ?", models.OCRPending).
+			Where("ocr_attempts = 0 OR updated_at <= ?", time.Now().Add(-ocrRetryDelay)).
+			Order("created_at asc").
+			First(&attachment).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errNothingPending
+		}
+		if err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{
+			"ocr_attempts": attachment.OCRAttempts + 1,
+			"updated_at":   time.Now(),
+		}
+		text, err := s.extractor.ExtractText(ctx, attachment.ContentType, attachment.Data)
+		switch {
+		case err == nil:
+			updates["ocr_status"] = models.OCRDone
+			updates["ocr_text"] = text
+			updates["ocr_error"] = ""
+		case ctx.Err() != nil:
+			return ctx.Err()
+		default:
+			s.logger.Warn("OCR failed",
+				zap.String("attachment_id", attachment.ID),
+				zap.Int("attempt", attachment.OCRAttempts+1),
+				zap.Error(err),
+			)
+			updates["ocr_error"] = err.Error()
+			if attachment.OCRAttempts+1 >= s.maxAttempts {
+				updates["ocr_status"] = models.OCRFailed
+			}
+		}
+
+		return tx.Model(&Attachment{}).Where("id = ?", attachment.ID).Updates(updates).Error
+	})
+}
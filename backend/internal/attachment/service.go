@@ -0,0 +1,141 @@
+package attachment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTaskNotFound       = errors.New("task not found")
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	ErrEmptyFile          = errors.New("file is empty")
+	ErrFileTooLarge       = errors.New("file exceeds the maximum attachment size")
+)
+
+// Extractor is implemented by backends that can read the text in an image
+// or PDF
+type Extractor interface {
+	ExtractText(ctx context.Context, contentType string, data []byte) (string, error)
+}
+
+type Service struct {
+	db          *gorm.DB
+	extractor   Extractor
+	maxBytes    int64
+	interval    time.Duration
+	maxAttempts int
+	logger      *zap.Logger
+	wake        chan struct{}
+
+	// processing serializes OCR passes within this process; replicas are
+	// kept apart by row locks
+	processing sync.Mutex
+}
+
+func NewService(db *gorm.DB, extractor Extractor, maxBytes int64, interval time.Duration, maxAttempts int, logger *zap.Logger) *Service {
+	return &Service{
+		db:          db,
+		extractor:   extractor,
+		maxBytes:    maxBytes,
+		interval:    interval,
+		maxAttempts: maxAttempts,
+		logger:      logger,
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// Upload stores a file against a task. Images and PDFs are queued for OCR.
+func (s *Service) Upload(ctx context.Context, taskID, userID, fileName, contentType string, data []byte) (*Attachment, error) {
+	if len(data) == 0 {
+		return nil, ErrEmptyFile
+	}
+	if int64(len(data)) > s.maxBytes {
+		return nil, ErrFileTooLarge
+	}
+
+	var tasks int64
+	if err := s.db.WithContext(ctx).Model(&models.Task{}).Where("id = ?", taskID).Count(&tasks).Error; err != nil {
+		return nil, fmt.Errorf("failed to load task: %w", err)
+	}
+	if tasks == 0 {
+		return nil, ErrTaskNotFound
+	}
+
+	contentType = detectContentType(fileName, contentType, data)
+	status := models.OCRSkipped
+	if ocrContentTypes[contentType] {
+		status = models.OCRPending
+	}
+
+	attachment := &Attachment{
+		TaskID:      taskID,
+		UploadedBy:  userID,
+		FileName:    filepath.Base(fileName),
+		ContentType: contentType,
+		SizeBytes:   int64(len(data)),
+		Data:        data,
+		OCRStatus:   status,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	if err := s.db.WithContext(ctx).Create(attachment).Error; err != nil {
+		return nil, fmt.Errorf("failed to store attachment: %w", err)
+	}
+
+	if status == models.OCRPending {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+	return attachment, nil
+}
+
+// List returns a task's attachments without their contents
+func (s *Service) List(ctx context.Context, taskID string) ([]Attachment, error) {
+	attachments := []Attachment{}
+	if err := s.db.WithContext(ctx).Omit("data", "ocr_text").
+		Where("task_id = ?", taskID).
+		Order("created_at asc").
+		Find(&attachments).Error; err != nil {
+		return nil, fmt.Errorf("failed to list attachments: %w", err)
+	}
+	return attachments, nil
+}
+
+// Get returns an attachment including its contents
+func (s *Service) Get(ctx context.Context, taskID, id string) (*Attachment, error) {
+	var attachment Attachment
+	if err := s.db.WithContext(ctx).First(&attachment, "id = ? AND task_id = ?", id, taskID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAttachmentNotFound
+		}
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// detectContentType prefers the declared type, then the file extension,
+// then sniffing the contents
+func detectContentType(fileName, declared string, data []byte) string {
+	if mediaType, _, err := mime.ParseMediaType(declared); err == nil && mediaType != "application/octet-stream" {
+		return mediaType
+	}
+	if byExt := mime.TypeByExtension(filepath.Ext(fileName)); byExt != "" {
+		if mediaType, _, err := mime.ParseMediaType(byExt); err == nil {
+			return mediaType
+		}
+	}
+	mediaType, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+	return mediaType
+}
@@ -0,0 +1,164 @@
+package attachment
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n0000")
+
+type fakeExtractor struct {
+	text string
+	err  error
+}
+
+func (f fakeExtractor) ExtractText(ctx context.Context, contentType string, data []byte) (string, error) {
+	return f.text, f.err
+}
+
+func newTestService(t *testing.T, extractor Extractor) (*Service, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewService(db, extractor, 1024, time.Second, 3, zap.NewNop()), mock
+}
+
+func expectTaskCount(mock sqlmock.Sqlmock, count int) {
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "tasks"`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(count))
+}
+
+func TestUploadQueuesImagesForOCR(t *testing.T) {
+	tests := []struct {
+		name        string
+		fileName    string
+		contentType string
+		data        []byte
+		want        models.OCRStatus
+		wantType    string
+	}{
+		{name: "declared image", fileName: "shot.png", contentType: "image/png", data: pngHeader, want: models.OCRPending, wantType: "image/png"},
+		{name: "sniffed image", fileName: "shot", contentType: "application/octet-stream", data: pngHeader, want: models.OCRPending, wantType: "image/png"},
+		{name: "pdf by extension", fileName: "invoice.pdf", data: []byte("%PDF-1.7"), want: models.OCRPending, wantType: "application/pdf"},
+		{name: "text file", fileName: "notes.txt", contentType: "text/plain; charset=utf-8", data: []byte("hello"), want: models.OCRSkipped, wantType: "text/plain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock := newTestService(t, nil)
+			expectTaskCount(mock, 1)
+			mock.ExpectBegin()
+			mock.ExpectQuery(`INSERT INTO "task_attachments"`).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "ocr_text"}).AddRow("attachment-1", ""))
+			mock.ExpectCommit()
+
+			attachment, err := s.Upload(context.Background(), "task-1", "user-1", "../"+tt.fileName, tt.contentType, tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if attachment.OCRStatus != tt.want || attachment.ContentType != tt.wantType {
+				t.Fatalf("status %s, type %s; want %s, %s", attachment.OCRStatus, attachment.ContentType, tt.want, tt.wantType)
+			}
+			if attachment.FileName != tt.fileName {
+				t.Fatalf("file name = %q, want the path stripped", attachment.FileName)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestUploadRejects(t *testing.T) {
+	s, mock := newTestService(t, nil)
+
+	if _, err := s.Upload(context.Background(), "task-1", "user-1", "a.png", "image/png", nil); err != ErrEmptyFile {
+		t.Fatalf("empty: err = %v, want ErrEmptyFile", err)
+	}
+	if _, err := s.Upload(context.Background(), "task-1", "user-1", "a.png", "image/png", make([]byte, 1025)); err != ErrFileTooLarge {
+		t.Fatalf("large: err = %v, want ErrFileTooLarge", err)
+	}
+
+	expectTaskCount(mock, 0)
+	if _, err := s.Upload(context.Background(), "missing", "user-1", "a.png", "image/png", pngHeader); err != ErrTaskNotFound {
+		t.Fatalf("missing task: err = %v, want ErrTaskNotFound", err)
+	}
+}
+
+func expectClaim(mock sqlmock.Sqlmock, attempts int) {
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "task_attachments" WHERE ocr_status = \$1 .* FOR UPDATE SKIP LOCKED`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "content_type", "data", "ocr_status", "ocr_attempts"}).
+			AddRow("attachment-1", "image/png", pngHeader, models.OCRPending, attempts))
+}
+
+func TestProcessNextStoresExtractedText(t *testing.T) {
+	s, mock := newTestService(t, fakeExtractor{text: "INVOICE 4521"})
+	expectClaim(mock, 0)
+	mock.ExpectExec(`UPDATE "task_attachments" SET "ocr_attempts"=\$1,"ocr_error"=\$2,"ocr_status"=\$3,"ocr_text"=\$4`).
+		WithArgs(1, "", models.OCRDone, "INVOICE 4521", sqlmock.AnyArg(), "attachment-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := s.processNext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProcessNextRetriesThenFails(t *testing.T) {
+	s, mock := newTestService(t, fakeExtractor{err: errors.New("provider down")})
+
+	// An early failure stays pending for a retry
+	expectClaim(mock, 0)
+	mock.ExpectExec(`UPDATE "task_attachments" SET "ocr_attempts"=\$1,"ocr_error"=\$2,"updated_at"=\$3`).
+		WithArgs(1, "provider down", sqlmock.AnyArg(), "attachment-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// The last allowed attempt marks it failed
+	expectClaim(mock, 2)
+	mock.ExpectExec(`UPDATE "task_attachments" SET "ocr_attempts"=\$1,"ocr_error"=\$2,"ocr_status"=\$3`).
+		WithArgs(3, "provider down", models.OCRFailed, sqlmock.AnyArg(), "attachment-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	for i := 0; i < 2; i++ {
+		if err := s.processNext(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProcessNextWithNothingPending(t *testing.T) {
+	s, mock := newTestService(t, fakeExtractor{})
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "task_attachments"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	if err := s.processNext(context.Background()); !errors.Is(err, errNothingPending) {
+		t.Fatalf("err = %v, want errNothingPending", err)
+	}
+}
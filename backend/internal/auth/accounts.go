@@ -0,0 +1,220 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/security"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrAccountDisabled = errors.New("account is disabled")
+	ErrDisableSelf     = errors.New("administrators cannot disable their own account")
+	ErrInvalidPage     = errors.New("page must be at least 1 and page_size between 1 and 100")
+)
+
+const (
+	// defaultTokenLifetime is how long member tokens last unless configured
+	defaultTokenLifetime = 24 * time.Hour
+	// defaultRefreshLifetime is how long after it was issued a member token
+	// can be refreshed unless configured
+	defaultRefreshLifetime = 7 * 24 * time.Hour
+	// resetTokenLifetime is how long a password reset token lasts
+	resetTokenLifetime = 15 * time.Minute
+	// revocationWindow is how long revoked tokens are refused: reporting
+	// tokens, the longest lived, last up to 90 days
+	revocationWindow = 90 * 24 * time.Hour
+)
+
+// UserListParams pages through the users, optionally those whose email
+// starts with Email
+type UserListParams struct {
+	Email    string `form:"email"`
+	Page     int    `form:"page,default=1"`
+	PageSize int    `form:"page_size,default=50"`
+}
+
+type UserListResponse struct {
+	Users      []User `json:"users"`
+	Pagination struct {
+		CurrentPage int   `json:"current_page"`
+		PageSize    int   `json:"page_size"`
+		TotalItems  int64 `json:"total_items"`
+	} `json:"pagination"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	// bcrypt reads no more than 72 bytes
+	NewPassword string `json:"new_password" binding:"required,min=8,max=72"`
+}
+
+// restriction stops tokens of an account from being accepted: all of them
+// if it is disabled, or those issued before revokedAt
+type restriction struct {
+	disabled  bool
+	revokedAt time.Time
+}
+
+// ListUsers returns a page of users for administrators
+func (s *Service) ListUsers(ctx context.Context, params UserListParams) (*UserListResponse, error) {
+	if params.Page < 1 || params.PageSize < 1 || params.PageSize > 100 {
+		return nil, ErrInvalidPage
+	}
+	users, total, err := s.users.List(ctx, params.Email, (params.Page-1)*params.PageSize, params.PageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	resp := &UserListResponse{Users: users}
+	resp.Pagination.CurrentPage = params.Page
+	resp.Pagination.PageSize = params.PageSize
+	resp.Pagination.TotalItems = total
+	return resp, nil
+}
+
+// DisableUser stops the user logging in, and rejects the user's tokens on
+// this replica at once and on the others within the account sync interval
+func (s *Service) DisableUser(ctx context.Context, adminID, userID string) error {
+	if adminID == userID {
+		return ErrDisableSelf
+	}
+	now := time.Now()
+	if err := s.users.SetDisabled(ctx, userID, &now); err != nil {
+		return err
+	}
+	s.restrict(userID, func(r *restriction) { r.disabled = true })
+	s.events.Record(ctx, security.EventAccountDisabled, adminID, map[string]interface{}{"user_id": userID})
+	return nil
+}
+
+// EnableUser lets a disabled user log in again. Tokens issued while it was
+// disabled are accepted again too, unless they were revoked.
+func (s *Service) EnableUser(ctx context.Context, adminID, userID string) error {
+	if err := s.users.SetDisabled(ctx, userID, nil); err != nil {
+		return err
+	}
+	s.restrict(userID, func(r *restriction) { r.disabled = false })
+	s.events.Record(ctx, security.EventAccountEnabled, adminID, map[string]interface{}{"user_id": userID})
+	return nil
+}
+
+// ForcePasswordReset revokes the user's tokens and makes the user set a
+// new password: logging in then only grants a token for ChangePassword
+func (s *Service) ForcePasswordReset(ctx context.Context, adminID, userID string) error {
+	now := time.Now()
+	if err := s.users.RequirePasswordReset(ctx, userID, now); err != nil {
+		return err
+	}
+	s.restrict(userID, func(r *restriction) { r.revokedAt = now })
+	s.events.Record(ctx, security.EventPasswordResetForced, adminID, map[string]interface{}{"user_id": userID})
+	return nil
+}
+
+// ChangePassword sets a new password for the user, who proves the current
+// one, and clears a required reset. The user's other tokens are revoked;
+// the returned token, of a new session on device, replaces them.
+func (s *Service) ChangePassword(ctx context.Context, userID string, req ChangePasswordRequest, device Device) (*AuthResponse, error) {
+	user, err := s.users.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.CurrentPassword)) != nil {
+		return nil, ErrInvalidCredentials
+	}
+	if err := validatePassword(req.NewPassword); err != nil {
+		return nil, err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := s.users.SetPassword(ctx, userID, string(hash), now); err != nil {
+		return nil, err
+	}
+	s.restrict(userID, func(r *restriction) { r.revokedAt = now })
+	user.Password = string(hash)
+	user.PasswordResetRequired = false
+	user.SessionsRevokedAt = &now
+
+	return s.issue(ctx, user, device)
+}
+
+// StartAccountSync reloads the disabled accounts, revoked tokens and
+// revoked sessions of every replica every interval until ctx is
+// cancelled, so tokens are checked against them without a query per
+// request
+func (s *Service) StartAccountSync(ctx context.Context, interval time.Duration, logger *zap.Logger) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := s.SyncAccounts(ctx); err != nil && ctx.Err() == nil {
+				logger.Error("Failed to load restricted accounts", zap.Error(err))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// SyncAccounts loads the disabled accounts, the tokens revoked within the
+// revocation window and the revoked sessions that have not expired
+func (s *Service) SyncAccounts(ctx context.Context) error {
+	now := time.Now()
+	users, err := s.users.Restricted(ctx, now.Add(-revocationWindow))
+	if err != nil {
+		return err
+	}
+	sessions, err := s.users.RevokedSessions(ctx, now)
+	if err != nil {
+		return err
+	}
+	restricted := make(map[string]restriction, len(users))
+	for _, user := range users {
+		r := restriction{disabled: user.DisabledAt != nil}
+		if user.SessionsRevokedAt != nil {
+			r.revokedAt = *user.SessionsRevokedAt
+		}
+		restricted[user.ID] = r
+	}
+	revokedSessions := make(map[string]bool, len(sessions))
+	for _, session := range sessions {
+		revokedSessions[session.ID] = true
+	}
+
+	s.restrictedMu.Lock()
+	s.restricted = restricted
+	s.revokedSessions = revokedSessions
+	s.restrictedMu.Unlock()
+	return nil
+}
+
+// restrict changes the user's restriction on this replica at once
+func (s *Service) restrict(userID string, change func(r *restriction)) {
+	s.restrictedMu.Lock()
+	defer s.restrictedMu.Unlock()
+	r := s.restricted[userID]
+	change(&r)
+	s.restricted[userID] = r
+}
+
+// tokenRevoked reports whether a token of userID issued at issuedAt may no
+// longer be used. Token times are in whole seconds, so a token issued in
+// the second its user's tokens were revoked is still accepted.
+func (s *Service) tokenRevoked(userID string, issuedAt time.Time) bool {
+	s.restrictedMu.RLock()
+	r, ok := s.restricted[userID]
+	s.restrictedMu.RUnlock()
+	return ok && (r.disabled || issuedAt.Before(r.revokedAt.Truncate(time.Second)))
+}
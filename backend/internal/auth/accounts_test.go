@@ -0,0 +1,182 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func (f *fakeUsers) Get(_ context.Context, id string) (*User, error) {
+	for _, user := range f.users {
+		if user.ID == id {
+			return user, nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
+
+func (f *fakeUsers) SetDisabled(ctx context.Context, id string, disabledAt *time.Time) error {
+	user, err := f.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	user.DisabledAt = disabledAt
+	return nil
+}
+
+func (f *fakeUsers) RequirePasswordReset(ctx context.Context, id string, at time.Time) error {
+	user, err := f.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	user.PasswordResetRequired = true
+	user.SessionsRevokedAt = &at
+	return nil
+}
+
+func (f *fakeUsers) SetPassword(ctx context.Context, id, hash string, at time.Time) error {
+	user, err := f.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	user.Password = hash
+	user.PasswordResetRequired = false
+	user.SessionsRevokedAt = &at
+	return nil
+}
+
+// registerTestUser registers ana and returns the service and a member token
+// of hers issued a minute ago
+func registerTestUser(t *testing.T) (*Service, *User, string) {
+	t.Helper()
+	s := NewServiceWithRepository(&fakeUsers{users: map[string]*User{}, sessions: map[string]*Session{}}, Config{JWTSecret: "test-secret"})
+	resp, err := s.Register(context.Background(), RegisterRequest{Email: "ana@example.com", Password: "password1"}, Device{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := signTestToken(t, jwt.MapClaims{
+		"user_id": resp.User.ID,
+		"role":    string(RoleMember),
+		"iat":     time.Now().Add(-time.Minute).Unix(),
+		"exp":     time.Now().Add(time.Hour).Unix(),
+	})
+	return s, &resp.User, token
+}
+
+func TestDisableUserRefusesTokensAndLogin(t *testing.T) {
+	s, user, token := registerTestUser(t)
+	ctx := context.Background()
+
+	if err := s.DisableUser(ctx, "admin-1", user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ParseToken(ctx, token); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("token of disabled user: err = %v, want ErrInvalidCredentials", err)
+	}
+	if _, err := s.Login(ctx, LoginRequest{Email: "ana@example.com", Password: "password1"}, Device{IP: "203.0.113.1"}); !errors.Is(err, ErrAccountDisabled) {
+		t.Fatalf("login: err = %v, want ErrAccountDisabled", err)
+	}
+
+	if err := s.EnableUser(ctx, "admin-1", user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ParseToken(ctx, token); err != nil {
+		t.Fatalf("token of enabled user: %v", err)
+	}
+}
+
+func TestDisableUserRefusesOwnAccount(t *testing.T) {
+	s, user, _ := registerTestUser(t)
+	if err := s.DisableUser(context.Background(), user.ID, user.ID); !errors.Is(err, ErrDisableSelf) {
+		t.Fatalf("err = %v, want ErrDisableSelf", err)
+	}
+}
+
+func TestForcedPasswordResetOnlyAllowsChangingPassword(t *testing.T) {
+	s, user, token := registerTestUser(t)
+	ctx := context.Background()
+
+	if err := s.ForcePasswordReset(ctx, "admin-1", user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ParseToken(ctx, token); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("revoked token: err = %v, want ErrInvalidCredentials", err)
+	}
+
+	login, err := s.Login(ctx, LoginRequest{Email: "ana@example.com", Password: "password1"}, Device{IP: "203.0.113.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := s.ParseToken(ctx, login.Token)
+	if err != nil || claims.Role != RolePasswordReset || !login.User.PasswordResetRequired {
+		t.Fatalf("login claims = %+v, %v, want a password reset token", claims, err)
+	}
+	if _, err := s.RefreshToken(ctx, login.Token, Device{}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("refreshing reset token: err = %v, want ErrInvalidCredentials", err)
+	}
+
+	req := ChangePasswordRequest{CurrentPassword: "wrong-password1", NewPassword: "password2"}
+	if _, err := s.ChangePassword(ctx, user.ID, req, Device{}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("wrong current password: err = %v, want ErrInvalidCredentials", err)
+	}
+	req.CurrentPassword = "password1"
+	changed, err := s.ChangePassword(ctx, user.ID, req, Device{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err = s.ParseToken(ctx, changed.Token)
+	if err != nil || claims.Role != RoleMember || changed.User.PasswordResetRequired {
+		t.Fatalf("changed claims = %+v, %v, want a member token", claims, err)
+	}
+}
+
+func TestSyncAccountsLoadsRestrictions(t *testing.T) {
+	s, mock := newTestService(t)
+	s.restrict("user-2", func(r *restriction) { r.disabled = true })
+
+	mock.ExpectQuery(`SELECT "id","disabled_at","sessions_revoked_at" FROM "users" ` +
+		`WHERE \(disabled_at IS NOT NULL OR sessions_revoked_at > \$1\) AND "users"."deleted_at" IS NULL`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "disabled_at", "sessions_revoked_at"}).
+			AddRow("user-1", time.Now(), nil))
+	mock.ExpectQuery(`SELECT "id" FROM "user_sessions" WHERE revoked_at IS NOT NULL AND expires_at > \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("session-1"))
+	if err := s.SyncAccounts(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if !s.tokenRevoked("user-1", time.Now()) {
+		t.Fatal("token of disabled user-1 accepted")
+	}
+	if s.tokenRevoked("user-2", time.Now()) {
+		t.Fatal("user-2, enabled on another replica, still refused")
+	}
+	if !s.sessionRevoked("session-1") {
+		t.Fatal("token of revoked session-1 accepted")
+	}
+}
+
+func TestPasswordResetPolicy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for _, tt := range []struct {
+		route string
+		want  bool
+	}{
+		{"/api/auth/password", true},
+		{"/api/tasks", false},
+	} {
+		router := gin.New()
+		var allowed bool
+		router.PUT(tt.route, func(c *gin.Context) { allowed = authorize(c, RolePasswordReset) })
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, tt.route, nil))
+		if allowed != tt.want {
+			t.Errorf("%s allowed = %v, want %v", tt.route, allowed, tt.want)
+		}
+	}
+}
@@ -1,10 +1,13 @@
 package auth
 
 import (
+	"context"
+	"io"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
 	"go.uber.org/zap"
 )
 
@@ -23,17 +26,17 @@ func NewHandler(service *Service, logger *zap.Logger) *Handler {
 func (h *Handler) Register(c *gin.Context) {
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(common.NewValidationError(err))
 		return
 	}
 
-	resp, err := h.service.Register(req)
+	resp, err := h.service.Register(c.Request.Context(), req, device(c))
 	if err != nil {
 		if err == ErrUserExists {
-			c.JSON(http.StatusConflict, gin.H{"error": "user already exists"})
+			_ = c.Error(common.NewConflictError("user already exists"))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to register user"})
+		_ = c.Error(common.NewInternalServerError("failed to register user"))
 		return
 	}
 
@@ -43,17 +46,21 @@ func (h *Handler) Register(c *gin.Context) {
 func (h *Handler) Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(common.NewValidationError(err))
 		return
 	}
 
-	resp, err := h.service.Login(req)
+	resp, err := h.service.Login(c.Request.Context(), req, device(c))
 	if err != nil {
 		if err == ErrInvalidCredentials {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+			_ = c.Error(common.NewUnauthorizedError("invalid credentials"))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to login"})
+		if err == ErrAccountDisabled {
+			_ = c.Error(common.NewForbiddenError(err.Error()))
+			return
+		}
+		_ = c.Error(common.NewInternalServerError("failed to login"))
 		return
 	}
 
@@ -63,16 +70,236 @@ func (h *Handler) Login(c *gin.Context) {
 func (h *Handler) RefreshToken(c *gin.Context) {
 	authHeader := c.GetHeader("Authorization")
 	if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "valid refresh token required"})
+		_ = c.Error(common.NewUnauthorizedError("valid refresh token required"))
 		return
 	}
 
 	token := strings.TrimPrefix(authHeader, "Bearer ")
-	resp, err := h.service.RefreshToken(token)
+	resp, err := h.service.RefreshToken(c.Request.Context(), token, device(c))
+	if err != nil {
+		_ = c.Error(common.NewUnauthorizedError("invalid refresh token"))
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// CreateReportingToken mints a read-only reporting token for the calling
+// administrator
+func (h *Handler) CreateReportingToken(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		_ = c.Error(common.NewUnauthorizedError("unauthorized"))
+		return
+	}
+
+	var req ReportingTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		_ = c.Error(common.NewValidationError(err))
+		return
+	}
+
+	resp, err := h.service.CreateReportingToken(c.Request.Context(), userID, req)
+	if err != nil {
+		h.logger.Error("Failed to create reporting token", zap.Error(err))
+		_ = c.Error(common.NewInternalServerError("failed to create reporting token"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// ListReportingTokens lists reporting tokens that have not expired
+func (h *Handler) ListReportingTokens(c *gin.Context) {
+	tokens, err := h.service.ListReportingTokens(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to list reporting tokens", zap.Error(err))
+		_ = c.Error(common.NewInternalServerError("failed to list reporting tokens"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tokens": tokens})
+}
+
+// RevokeReportingToken revokes a reporting token by ID
+func (h *Handler) RevokeReportingToken(c *gin.Context) {
+	if err := h.service.RevokeReportingToken(c.Request.Context(), c.Param("id")); err != nil {
+		if err == ErrTokenNotFound {
+			_ = c.Error(common.NewNotFoundError(err.Error()))
+			return
+		}
+		h.logger.Error("Failed to revoke reporting token", zap.Error(err))
+		_ = c.Error(common.NewInternalServerError("failed to revoke reporting token"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// DeleteAccount deletes the caller's own account
+func (h *Handler) DeleteAccount(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		_ = c.Error(common.NewUnauthorizedError("unauthorized"))
+		return
+	}
+
+	if err := h.service.DeleteAccount(c.Request.Context(), userID); err != nil {
+		if err == ErrUserNotFound {
+			_ = c.Error(common.NewNotFoundError(err.Error()))
+			return
+		}
+		h.logger.Error("Failed to delete account", zap.Error(err))
+		_ = c.Error(common.NewInternalServerError("failed to delete account"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ChangePassword sets a new password for the caller, and is the only route
+// open to users who must reset theirs
+func (h *Handler) ChangePassword(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		_ = c.Error(common.NewUnauthorizedError("unauthorized"))
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(common.NewValidationError(err))
+		return
+	}
+
+	resp, err := h.service.ChangePassword(c.Request.Context(), userID, req, device(c))
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+		switch err {
+		case ErrInvalidCredentials:
+			_ = c.Error(common.NewUnauthorizedError("current password is incorrect"))
+		case ErrPasswordTooShort, ErrPasswordNoNumber:
+			_ = c.Error(common.NewInvalidInputError(err.Error()))
+		case ErrUserNotFound:
+			_ = c.Error(common.NewNotFoundError(err.Error()))
+		default:
+			h.logger.Error("Failed to change password", zap.Error(err))
+			_ = c.Error(common.NewInternalServerError("failed to change password"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ListUsers lists the accounts for administrators
+func (h *Handler) ListUsers(c *gin.Context) {
+	var params UserListParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		_ = c.Error(common.NewValidationError(err))
+		return
+	}
+
+	resp, err := h.service.ListUsers(c.Request.Context(), params)
+	if err != nil {
+		if err == ErrInvalidPage {
+			_ = c.Error(common.NewInvalidInputError(err.Error()))
+			return
+		}
+		h.logger.Error("Failed to list users", zap.Error(err))
+		_ = c.Error(common.NewInternalServerError("failed to list users"))
 		return
 	}
 
 	c.JSON(http.StatusOK, resp)
 }
+
+// DisableUser disables an account and refuses its tokens
+func (h *Handler) DisableUser(c *gin.Context) {
+	h.changeAccount(c, "disable user", h.service.DisableUser)
+}
+
+// EnableUser enables a disabled account
+func (h *Handler) EnableUser(c *gin.Context) {
+	h.changeAccount(c, "enable user", h.service.EnableUser)
+}
+
+// ForcePasswordReset revokes an account's tokens and makes its user set a
+// new password
+func (h *Handler) ForcePasswordReset(c *gin.Context) {
+	h.changeAccount(c, "force password reset", h.service.ForcePasswordReset)
+}
+
+// changeAccount runs an administrator's change to the account in the path
+func (h *Handler) changeAccount(c *gin.Context, action string, change func(ctx context.Context, adminID, userID string) error) {
+	if err := change(c.Request.Context(), c.GetString("user_id"), c.Param("id")); err != nil {
+		switch err {
+		case ErrUserNotFound:
+			_ = c.Error(common.NewNotFoundError(err.Error()))
+		case ErrDisableSelf:
+			_ = c.Error(common.NewInvalidInputError(err.Error()))
+		default:
+			h.logger.Error("Failed to "+action, zap.Error(err))
+			_ = c.Error(common.NewInternalServerError("failed to " + action))
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Impersonate issues the calling administrator a token acting as a user
+func (h *Handler) Impersonate(c *gin.Context) {
+	resp, err := h.service.Impersonate(c.Request.Context(), c.GetString("user_id"), c.Param("user_id"))
+	if err != nil {
+		switch err {
+		case ErrUserNotFound:
+			_ = c.Error(common.NewNotFoundError(err.Error()))
+		case ErrImpersonateSelf:
+			_ = c.Error(common.NewInvalidInputError(err.Error()))
+		case ErrAccountDisabled:
+			_ = c.Error(common.NewConflictError(err.Error()))
+		default:
+			h.logger.Error("Failed to impersonate user", zap.Error(err))
+			_ = c.Error(common.NewInternalServerError("failed to impersonate user"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// ListSessions lists the caller's signed in devices
+func (h *Handler) ListSessions(c *gin.Context) {
+	sessions, err := h.service.ListSessions(c.Request.Context(), c.GetString("user_id"), c.GetString("session_id"))
+	if err != nil {
+		if err == ErrUserNotFound {
+			_ = c.Error(common.NewNotFoundError(err.Error()))
+			return
+		}
+		h.logger.Error("Failed to list sessions", zap.Error(err))
+		_ = c.Error(common.NewInternalServerError("failed to list sessions"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// RevokeSession signs one of the caller's devices out
+func (h *Handler) RevokeSession(c *gin.Context) {
+	if err := h.service.RevokeSession(c.Request.Context(), c.GetString("user_id"), c.Param("id")); err != nil {
+		if err == ErrSessionNotFound {
+			_ = c.Error(common.NewNotFoundError(err.Error()))
+			return
+		}
+		h.logger.Error("Failed to revoke session", zap.Error(err))
+		_ = c.Error(common.NewInternalServerError("failed to revoke session"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// device describes the client making the request
+func device(c *gin.Context) Device {
+	return Device{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+}
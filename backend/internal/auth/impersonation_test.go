@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"github.com/iSparshP/real-time-task-management-system/internal/security"
+	"go.uber.org/zap"
+)
+
+func TestImpersonationTokenActsAsUser(t *testing.T) {
+	s, user, _ := registerTestUser(t)
+	ctx := context.Background()
+
+	if _, err := s.Impersonate(ctx, user.ID, user.ID); !errors.Is(err, ErrImpersonateSelf) {
+		t.Fatalf("self: err = %v, want ErrImpersonateSelf", err)
+	}
+	resp, err := s.Impersonate(ctx, "admin-1", user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := s.ParseToken(ctx, resp.Token)
+	if err != nil || claims.UserID != user.ID || claims.ImpersonatorID != "admin-1" {
+		t.Fatalf("claims = %+v, %v, want admin-1 acting as %s", claims, err, user.ID)
+	}
+	if _, err := s.RefreshToken(ctx, resp.Token, Device{}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("refresh: err = %v, want ErrInvalidCredentials", err)
+	}
+
+	s.restrict("admin-1", func(r *restriction) { r.disabled = true })
+	if _, err := s.ParseToken(ctx, resp.Token); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("token of disabled administrator: err = %v, want ErrInvalidCredentials", err)
+	}
+}
+
+func TestImpersonatedRequestsAreRecordedAndKeptFromAccountRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s, mock := newTestService(t)
+	s.SetEventRecorder(security.NewService(s.users.(*gormUserRepository).db, zap.NewNop()))
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE id = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow("user-1", "ana@example.com"))
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`INSERT INTO "security_events"`).
+		WithArgs(string(security.EventImpersonationStarted), "admin-1", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(1))
+	mock.ExpectCommit()
+
+	resp, err := s.Impersonate(context.Background(), "admin-1", "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	router.Use(common.ErrorHandler(zap.NewNop()))
+	api := router.Group("/api", AuthMiddleware(s))
+	api.GET("/tasks", func(c *gin.Context) { c.Status(http.StatusOK) })
+	api.PUT("/auth/password", func(c *gin.Context) { c.Status(http.StatusOK) })
+	api.GET("/admin/users", RequireAdmin([]string{"user-1"}), func(c *gin.Context) { c.Status(http.StatusOK) })
+	serve := func(method, path string) int {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+resp.Token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`INSERT INTO "security_events"`).
+		WithArgs(string(security.EventImpersonatedRequest), "admin-1",
+			`{"method":"GET","path":"/api/tasks","route":"/api/tasks","status":200,"user_id":"user-1"}`, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(2))
+	mock.ExpectCommit()
+	if code := serve(http.MethodGet, "/api/tasks"); code != http.StatusOK {
+		t.Fatalf("tasks: status = %d, want 200", code)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+
+	if code := serve(http.MethodPut, "/api/auth/password"); code != http.StatusForbidden {
+		t.Fatalf("password: status = %d, want 403", code)
+	}
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`INSERT INTO "security_events"`).WillReturnRows(sqlmock.NewRows([]string{"sequence"}).AddRow(3))
+	mock.ExpectCommit()
+	if code := serve(http.MethodGet, "/api/admin/users"); code != http.StatusForbidden {
+		t.Fatalf("administrator route: status = %d, want 403", code)
+	}
+}
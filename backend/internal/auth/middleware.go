@@ -5,33 +5,69 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
 )
 
 func AuthMiddleware(service *Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "authorization header required"})
-			c.Abort()
+			authHeader = webSocketToken(c.Request)
+		}
+		if authHeader == "" {
+			common.Abort(c, common.NewUnauthorizedError("authorization header required"))
 			return
 		}
 
 		// Extract token from "Bearer <token>"
 		tokenParts := strings.Split(authHeader, " ")
 		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization header"})
-			c.Abort()
+			common.Abort(c, common.NewUnauthorizedError("invalid authorization header"))
 			return
 		}
 
-		userID, err := service.ValidateToken(tokenParts[1])
+		claims, err := service.ParseToken(c.Request.Context(), tokenParts[1])
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
-			c.Abort()
+			common.Abort(c, common.NewUnauthorizedError("invalid token"))
 			return
 		}
 
-		c.Set("user_id", userID)
+		if !authorize(c, claims.Role) || (claims.ImpersonatorID != "" && !impersonationAllowed(c)) {
+			common.Abort(c, common.NewForbiddenError("token not permitted for this endpoint"))
+			return
+		}
+
+		c.Set("user_id", claims.UserID)
+		c.Set("role", string(claims.Role))
+		c.Set("row_limit", policies[claims.Role].RowLimit)
+		if claims.SessionID != "" {
+			c.Set("session_id", claims.SessionID)
+		}
+		if claims.ImpersonatorID != "" {
+			c.Set("impersonator_id", claims.ImpersonatorID)
+		}
 		c.Next()
+
+		if claims.ImpersonatorID != "" {
+			service.recordImpersonatedRequest(c, claims)
+		}
+	}
+}
+
+// WebSocketSubprotocol is offered by browsers, which cannot set headers on
+// WebSocket connections, to pass the token as the next subprotocol:
+// new WebSocket(url, ["bearer", token])
+const WebSocketSubprotocol = "bearer"
+
+// webSocketToken returns the token of a WebSocket upgrade offering it as a
+// subprotocol, as an Authorization header value
+func webSocketToken(r *http.Request) string {
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		return ""
+	}
+	protocols := strings.Split(r.Header.Get("Sec-WebSocket-Protocol"), ",")
+	if len(protocols) != 2 || strings.TrimSpace(protocols[0]) != WebSocketSubprotocol {
+		return ""
 	}
+	return "Bearer " + strings.TrimSpace(protocols[1])
 }
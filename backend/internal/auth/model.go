@@ -18,8 +18,9 @@ type LoginRequest struct {
 }
 
 type RegisterRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=8"`
+	Email string `json:"email" binding:"required,email"`
+	// bcrypt reads no more than 72 bytes
+	Password string `json:"password" binding:"required,min=8,max=72"`
 }
 
 type AuthResponse struct {
@@ -27,8 +28,44 @@ type AuthResponse struct {
 	User  User   `json:"user"`
 }
 
+type ReportingToken = models.ReportingToken
+
+type Session = models.UserSession
+
+// Device describes where a request came from, for the session it starts
+type Device struct {
+	IP        string
+	UserAgent string
+}
+
+// Claims are the identity and role carried by a validated token. TokenID is
+// set for reporting tokens only, SessionID for member tokens issued since
+// sessions were tracked, and ImpersonatorID for tokens an administrator was
+// issued to act as the user.
+type Claims struct {
+	UserID         string
+	Role           Role
+	TokenID        string
+	SessionID      string
+	ImpersonatorID string
+}
+
+type ReportingTokenRequest struct {
+	ExpiresInDays int `json:"expires_in_days" binding:"omitempty,min=1,max=90"`
+}
+
+type ReportingTokenResponse struct {
+	ID        string    `json:"id"`
+	Token     string    `json:"token"`
+	Role      Role      `json:"role"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
 type Config struct {
 	JWTSecret              string
 	TokenExpiration        time.Duration
 	RefreshTokenExpiration time.Duration
+	// FoldGmail treats Gmail addresses that differ only in dots or a +tag
+	// as the same account
+	FoldGmail bool
 }
@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/openapi"
+)
+
+// Describe annotates the auth routes for the OpenAPI document
+func (h *Handler) Describe(spec *openapi.Spec) {
+	spec.Enum(Role(""), string(RoleMember), string(RoleReporting), string(RolePasswordReset))
+
+	spec.Describe(h.Register, openapi.Operation{
+		Summary:  "Register an account",
+		Public:   true,
+		Request:  RegisterRequest{},
+		Status:   http.StatusCreated,
+		Response: AuthResponse{},
+		Errors:   []int{http.StatusBadRequest, http.StatusConflict},
+	})
+	spec.Describe(h.Login, openapi.Operation{
+		Summary: "Sign in",
+		Public:  true,
+		Request: LoginRequest{},
+		Description: "Disabled accounts are refused with 403. Users who must reset their password get a " +
+			"short-lived password_reset token that only changes the password.",
+		Response: AuthResponse{},
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden},
+	})
+	spec.Describe(h.RefreshToken, openapi.Operation{
+		Summary:     "Refresh a token",
+		Description: "Send the refresh token as the bearer token.",
+		Response:    AuthResponse{},
+		Errors:      []int{http.StatusUnauthorized},
+	})
+	spec.Describe(h.CreateReportingToken, openapi.Operation{
+		Summary:  "Create a read-only reporting token",
+		Request:  ReportingTokenRequest{},
+		Status:   http.StatusCreated,
+		Response: ReportingTokenResponse{},
+		Errors:   []int{http.StatusBadRequest, http.StatusForbidden},
+	})
+	spec.Describe(h.ListReportingTokens, openapi.Operation{
+		Summary:  "List reporting tokens that have not expired",
+		Response: openapi.Fields{"tokens": []ReportingToken{}},
+		Errors:   []int{http.StatusForbidden},
+	})
+	spec.Describe(h.RevokeReportingToken, openapi.Operation{
+		Summary: "Revoke a reporting token",
+		Status:  http.StatusNoContent,
+		Errors:  []int{http.StatusForbidden, http.StatusNotFound},
+	})
+	spec.Describe(h.ChangePassword, openapi.Operation{
+		Summary:     "Change the caller's password",
+		Description: "Clears a required password reset. The caller's other tokens are revoked; use the returned token.",
+		Request:     ChangePasswordRequest{},
+		Response:    AuthResponse{},
+		Errors:      []int{http.StatusBadRequest, http.StatusUnauthorized},
+	})
+	spec.Describe(h.ListUsers, openapi.Operation{
+		Summary:  "List accounts, for administrators",
+		Query:    []any{UserListParams{}},
+		Response: UserListResponse{},
+		Errors:   []int{http.StatusBadRequest, http.StatusForbidden},
+	})
+	spec.Describe(h.DisableUser, openapi.Operation{
+		Summary:     "Disable an account",
+		Description: "Login is refused and the account's tokens stop being accepted.",
+		Status:      http.StatusNoContent,
+		Errors:      []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound},
+	})
+	spec.Describe(h.EnableUser, openapi.Operation{
+		Summary: "Enable a disabled account",
+		Status:  http.StatusNoContent,
+		Errors:  []int{http.StatusForbidden, http.StatusNotFound},
+	})
+	spec.Describe(h.ForcePasswordReset, openapi.Operation{
+		Summary:     "Force a password reset",
+		Description: "Revokes the account's tokens; the user must set a new password after signing in.",
+		Status:      http.StatusNoContent,
+		Errors:      []int{http.StatusForbidden, http.StatusNotFound},
+	})
+	spec.Describe(h.Impersonate, openapi.Operation{
+		Summary: "Act as a user, for administrators",
+		Description: "The token lasts 15 minutes, cannot be refreshed and cannot reach /auth or administrator routes. " +
+			"Every request made with it is recorded as an impersonation.request security event.",
+		Status:   http.StatusCreated,
+		Response: ImpersonationResponse{},
+		Errors:   []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusConflict},
+	})
+	spec.Describe(h.ListSessions, openapi.Operation{
+		Summary:     "List the caller's signed in devices",
+		Description: "current marks the session of the token making the request.",
+		Response:    openapi.Fields{"sessions": []Session{}},
+	})
+	spec.Describe(h.RevokeSession, openapi.Operation{
+		Summary:     "Sign out one of the caller's devices",
+		Description: "Tokens of the session stop working; every replica refuses them within ACCOUNT_SYNC_INTERVAL_SECONDS.",
+		Status:      http.StatusNoContent,
+		Errors:      []int{http.StatusNotFound},
+	})
+	spec.Describe(h.DeleteAccount, openapi.Operation{
+		Summary: "Delete the caller's account",
+		Status:  http.StatusNoContent,
+	})
+}
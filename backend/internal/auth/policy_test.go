@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func signTestToken(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("test-secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestCreateReportingTokenIsStored(t *testing.T) {
+	s, mock := newTestService(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "reporting_tokens"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("token-1"))
+	mock.ExpectCommit()
+
+	resp, err := s.CreateReportingToken(context.Background(), "admin-1", ReportingTokenRequest{ExpiresInDays: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ID == "" || resp.Token == "" {
+		t.Fatalf("response = %+v, want a token and its ID", resp)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseTokenRejectsRevokedReportingToken(t *testing.T) {
+	s, mock := newTestService(t)
+	token := signTestToken(t, jwt.MapClaims{
+		"user_id": "admin-1",
+		"role":    string(RoleReporting),
+		"jti":     "token-1",
+		"exp":     time.Now().Add(time.Hour).Unix(),
+	})
+
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "reporting_tokens" WHERE id = \$1 AND user_id = \$2 AND revoked_at IS NULL`).
+		WithArgs("token-1", "admin-1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	if _, err := s.ParseToken(context.Background(), token); err != ErrInvalidCredentials {
+		t.Fatalf("err = %v, want ErrInvalidCredentials for a revoked token", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseTokenRejectsUnrecordedReportingToken(t *testing.T) {
+	s, _ := newTestService(t)
+	token := signTestToken(t, jwt.MapClaims{
+		"user_id": "admin-1",
+		"role":    string(RoleReporting),
+		"exp":     time.Now().Add(time.Hour).Unix(),
+	})
+
+	if _, err := s.ParseToken(context.Background(), token); err != ErrInvalidCredentials {
+		t.Fatalf("err = %v, want ErrInvalidCredentials for a token without an ID", err)
+	}
+}
+
+func TestRevokeReportingTokenUnknownID(t *testing.T) {
+	s, mock := newTestService(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "reporting_tokens" SET "revoked_at"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	if err := s.RevokeReportingToken(context.Background(), "missing"); err != ErrTokenNotFound {
+		t.Fatalf("err = %v, want ErrTokenNotFound", err)
+	}
+}
+
+func TestReportingTokensRequireAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("user_id", "member-1") })
+	router.POST("/auth/reporting-tokens", RequireAdmin([]string{"admin-1"}), func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/auth/reporting-tokens", nil))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want 403 for a non-admin", w.Code)
+	}
+}
+
+func TestReportingPolicyBlocksWrites(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		method, route string
+		want          bool
+	}{
+		{http.MethodGet, "/api/analytics/summary", true},
+		{http.MethodPost, "/api/exports/run", false},
+		{http.MethodGet, "/api/tasks", false},
+	}
+
+	for _, tt := range tests {
+		var allowed bool
+		router := gin.New()
+		router.Handle(tt.method, tt.route, func(c *gin.Context) { allowed = authorize(c, RoleReporting) })
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.route, nil))
+		if allowed != tt.want {
+			t.Errorf("%s %s allowed = %v, want %v", tt.method, tt.route, allowed, tt.want)
+		}
+	}
+}
+
+func TestAuthMiddlewareAcceptsWebSocketSubprotocolToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s, _ := newTestService(t)
+	token := signTestToken(t, jwt.MapClaims{"user_id": "user-1", "exp": time.Now().Add(time.Hour).Unix()})
+
+	router := gin.New()
+	router.GET("/api/tasks/ws", AuthMiddleware(s), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("user_id"))
+	})
+
+	serve := func(upgrade string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/tasks/ws", nil)
+		req.Header.Set("Upgrade", upgrade)
+		req.Header.Set("Sec-WebSocket-Protocol", WebSocketSubprotocol+", "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	if w := serve("websocket"); w.Code != http.StatusOK || w.Body.String() != "user-1" {
+		t.Fatalf("upgrade: status = %d, body = %q", w.Code, w.Body.String())
+	}
+	if w := serve(""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("plain request: status = %d, want 401", w.Code)
+	}
+}
@@ -0,0 +1,314 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"github.com/iSparshP/real-time-task-management-system/internal/readonly"
+	"gorm.io/gorm"
+)
+
+// UserRepository stores user accounts and their reporting tokens
+type UserRepository interface {
+	// EmailTaken reports whether an account has the normalized email, or
+	// the address for older accounts without one
+	EmailTaken(ctx context.Context, normalized, email string) (bool, error)
+	// Create stores a new user, or returns ErrUserExists
+	Create(ctx context.Context, user *User) error
+	// GetByEmail returns the account with the normalized email, or the
+	// older account without one at the exact address, or ErrUserNotFound
+	GetByEmail(ctx context.Context, normalized, email string) (*User, error)
+	// Get returns the user, or ErrUserNotFound
+	Get(ctx context.Context, id string) (*User, error)
+	// Delete soft-deletes the user, releasing its email, or returns
+	// ErrUserNotFound
+	Delete(ctx context.Context, id string) error
+	// List returns the page of users whose email starts with prefix,
+	// oldest first, and how many there are in all
+	List(ctx context.Context, prefix string, offset, limit int) ([]User, int64, error)
+	// SetDisabled disables the user at disabledAt, or enables it when nil,
+	// or returns ErrUserNotFound
+	SetDisabled(ctx context.Context, id string, disabledAt *time.Time) error
+	// RequirePasswordReset makes the user set a new password and revokes
+	// the tokens issued before at, or returns ErrUserNotFound
+	RequirePasswordReset(ctx context.Context, id string, at time.Time) error
+	// SetPassword stores a new password hash, clears a required reset and
+	// revokes the tokens issued before at
+	SetPassword(ctx context.Context, id, hash string, at time.Time) error
+	// Restricted returns the users who are disabled or whose tokens were
+	// revoked after since
+	Restricted(ctx context.Context, since time.Time) ([]User, error)
+
+	// CreateSession stores a new session, or returns ErrSessionsReadOnly
+	// while the database is a standby
+	CreateSession(ctx context.Context, session *Session) error
+	// ListSessions returns the user's sessions neither revoked nor expired
+	// at now, most recently seen first
+	ListSessions(ctx context.Context, userID string, now time.Time) ([]Session, error)
+	// RenewSession stores the session's last sighting, device and expiry,
+	// or returns ErrSessionNotFound unless it is the user's and active, and
+	// ErrSessionsReadOnly while the database is a standby
+	RenewSession(ctx context.Context, session *Session) error
+	// TouchSession records that the session was seen at
+	TouchSession(ctx context.Context, id string, at time.Time) error
+	// RevokeSession revokes the user's session, or returns
+	// ErrSessionNotFound if it is not the user's or already revoked
+	RevokeSession(ctx context.Context, id, userID string, at time.Time) error
+	// RevokedSessions returns the revoked sessions expiring after now
+	RevokedSessions(ctx context.Context, now time.Time) ([]Session, error)
+
+	CreateReportingToken(ctx context.Context, token *ReportingToken) error
+	// ListReportingTokens returns the tokens expiring after now, newest
+	// first
+	ListReportingTokens(ctx context.Context, now time.Time) ([]ReportingToken, error)
+	// RevokeReportingToken revokes the token, or returns ErrTokenNotFound
+	// if it does not exist or is already revoked
+	RevokeReportingToken(ctx context.Context, id string) error
+	// ReportingTokenActive reports whether userID's token is on record and
+	// not revoked
+	ReportingTokenActive(ctx context.Context, id, userID string) (bool, error)
+}
+
+// gormUserRepository is the UserRepository of the Postgres database
+type gormUserRepository struct {
+	db *gorm.DB
+}
+
+func NewUserRepository(db *gorm.DB) UserRepository {
+	return &gormUserRepository{db: db}
+}
+
+func (r *gormUserRepository) EmailTaken(ctx context.Context, normalized, email string) (bool, error) {
+	var existing int64
+	if err := r.db.WithContext(ctx).Model(&User{}).
+		Where("normalized_email = ? OR lower(email) = ?", normalized, email).
+		Count(&existing).Error; err != nil {
+		return false, err
+	}
+	return existing > 0, nil
+}
+
+func (r *gormUserRepository) Create(ctx context.Context, user *User) error {
+	// The unique normalized email catches concurrent registrations
+	if err := r.db.WithContext(ctx).Create(user).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrUserExists
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *gormUserRepository) GetByEmail(ctx context.Context, normalized, email string) (*User, error) {
+	var user User
+	err := r.db.WithContext(ctx).
+		Where("normalized_email = ?", normalized).
+		First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = r.db.WithContext(ctx).
+			Where("normalized_email IS NULL AND email = ?", email).
+			First(&user).Error
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *gormUserRepository) Get(ctx context.Context, id string) (*User, error) {
+	var user User
+	if err := r.db.WithContext(ctx).First(&user, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *gormUserRepository) Delete(ctx context.Context, id string) error {
+	result := r.db.WithContext(ctx).Model(&User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"email":            fmt.Sprintf("deleted-%s@deleted.invalid", id),
+			"normalized_email": nil,
+			"deleted_at":       time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *gormUserRepository) List(ctx context.Context, prefix string, offset, limit int) ([]User, int64, error) {
+	query := r.db.WithContext(ctx).Model(&User{})
+	if prefix != "" {
+		query = query.Where("email LIKE ?", common.EscapeLike(strings.ToLower(prefix))+"%")
+	}
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	users := []User{}
+	if err := query.Order("created_at ASC, id ASC").Offset(offset).Limit(limit).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+func (r *gormUserRepository) SetDisabled(ctx context.Context, id string, disabledAt *time.Time) error {
+	return r.update(ctx, id, map[string]interface{}{"disabled_at": disabledAt})
+}
+
+func (r *gormUserRepository) RequirePasswordReset(ctx context.Context, id string, at time.Time) error {
+	return r.update(ctx, id, map[string]interface{}{"password_reset_required": true, "sessions_revoked_at": at})
+}
+
+func (r *gormUserRepository) SetPassword(ctx context.Context, id, hash string, at time.Time) error {
+	return r.update(ctx, id, map[string]interface{}{
+		"password":                hash,
+		"password_reset_required": false,
+		"sessions_revoked_at":     at,
+	})
+}
+
+// update sets columns of the user, or returns ErrUserNotFound
+func (r *gormUserRepository) update(ctx context.Context, id string, columns map[string]interface{}) error {
+	result := r.db.WithContext(ctx).Model(&User{}).Where("id = ?", id).Updates(columns)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *gormUserRepository) Restricted(ctx context.Context, since time.Time) ([]User, error) {
+	var users []User
+	if err := r.db.WithContext(ctx).
+		Select("id", "disabled_at", "sessions_revoked_at").
+		Where("disabled_at IS NOT NULL OR sessions_revoked_at > ?", since).
+		Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (r *gormUserRepository) CreateSession(ctx context.Context, session *Session) error {
+	err := r.db.WithContext(ctx).Create(session).Error
+	if readonly.IsStandbyError(err) {
+		return fmt.Errorf("%w: %v", ErrSessionsReadOnly, err)
+	}
+	return err
+}
+
+func (r *gormUserRepository) ListSessions(ctx context.Context, userID string, now time.Time) ([]Session, error) {
+	sessions := []Session{}
+	if err := r.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (r *gormUserRepository) RenewSession(ctx context.Context, session *Session) error {
+	result := r.db.WithContext(ctx).Model(&Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL AND expires_at > ?", session.ID, session.UserID, session.LastSeenAt).
+		Updates(map[string]interface{}{
+			"last_seen_at": session.LastSeenAt,
+			"expires_at":   session.ExpiresAt,
+			"ip_address":   session.IPAddress,
+			"user_agent":   session.UserAgent,
+		})
+	if readonly.IsStandbyError(result.Error) {
+		return fmt.Errorf("%w: %v", ErrSessionsReadOnly, result.Error)
+	}
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+func (r *gormUserRepository) TouchSession(ctx context.Context, id string, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&Session{}).Where("id = ?", id).Update("last_seen_at", at).Error
+}
+
+func (r *gormUserRepository) RevokeSession(ctx context.Context, id, userID string, at time.Time) error {
+	result := r.db.WithContext(ctx).Model(&Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", at)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+func (r *gormUserRepository) RevokedSessions(ctx context.Context, now time.Time) ([]Session, error) {
+	var sessions []Session
+	if err := r.db.WithContext(ctx).
+		Select("id").
+		Where("revoked_at IS NOT NULL AND expires_at > ?", now).
+		Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (r *gormUserRepository) CreateReportingToken(ctx context.Context, token *ReportingToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *gormUserRepository) ListReportingTokens(ctx context.Context, now time.Time) ([]ReportingToken, error) {
+	tokens := []ReportingToken{}
+	if err := r.db.WithContext(ctx).
+		Where("expires_at > ?", now).
+		Order("created_at DESC").
+		Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func (r *gormUserRepository) RevokeReportingToken(ctx context.Context, id string) error {
+	result := r.db.WithContext(ctx).Model(&ReportingToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTokenNotFound
+	}
+	return nil
+}
+
+func (r *gormUserRepository) ReportingTokenActive(ctx context.Context, id, userID string) (bool, error) {
+	var active int64
+	if err := r.db.WithContext(ctx).Model(&ReportingToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Count(&active).Error; err != nil {
+		return false, err
+	}
+	return active > 0, nil
+}
@@ -1,12 +1,20 @@
 package auth
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 	"unicode"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+	"github.com/iSparshP/real-time-task-management-system/internal/security"
+	"github.com/patrickmn/go-cache"
+	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
@@ -17,31 +25,99 @@ var (
 	ErrTokenExpired       = errors.New("token has expired")
 	ErrInvalidToken       = errors.New("invalid token")
 	ErrUserNotFound       = errors.New("user not found")
+	ErrTokenNotFound      = errors.New("reporting token not found")
+	ErrPasswordTooShort   = errors.New("password must be at least 8 characters")
+	ErrPasswordNoNumber   = errors.New("password must contain at least one number")
+)
+
+// Failed logins for the same email within failedLoginWindow are counted;
+// reaching failedLoginThreshold is reported as a login anomaly
+const (
+	failedLoginThreshold = 5
+	failedLoginWindow    = 15 * time.Minute
 )
 
 type Service struct {
-	db        *gorm.DB
+	users     UserRepository
 	jwtSecret []byte
 	config    Config
+	events    *security.Service
+	failures  *cache.Cache
+	logger    *zap.Logger
+
+	// restricted holds the accounts whose tokens are refused, and
+	// revokedSessions the revoked sessions until they expire, loaded by
+	// StartAccountSync
+	restrictedMu    sync.RWMutex
+	restricted      map[string]restriction
+	revokedSessions map[string]bool
+	// touched holds the sessions whose last sighting was stored lately
+	touched *cache.Cache
 }
 
 func NewService(db *gorm.DB, config Config) *Service {
+	return NewServiceWithRepository(NewUserRepository(db), config)
+}
+
+// NewServiceWithRepository is NewService with accounts stored in users
+func NewServiceWithRepository(users UserRepository, config Config) *Service {
 	return &Service{
-		db:        db,
-		jwtSecret: []byte(config.JWTSecret),
-		config:    config,
+		users:           users,
+		jwtSecret:       []byte(config.JWTSecret),
+		config:          config,
+		failures:        cache.New(failedLoginWindow, 2*failedLoginWindow),
+		logger:          zap.NewNop(),
+		restricted:      make(map[string]restriction),
+		revokedSessions: make(map[string]bool),
+		touched:         cache.New(sessionTouchInterval, 2*sessionTouchInterval),
+	}
+}
+
+// tokenLifetime is how long member tokens last
+func (s *Service) tokenLifetime() time.Duration {
+	if s.config.TokenExpiration > 0 {
+		return s.config.TokenExpiration
 	}
+	return defaultTokenLifetime
 }
 
-func (s *Service) Register(req RegisterRequest) (*AuthResponse, error) {
+// refreshLifetime is how long after it was issued a member token can be
+// refreshed, and so how long a session lasts without a refresh
+func (s *Service) refreshLifetime() time.Duration {
+	if s.config.RefreshTokenExpiration > 0 {
+		return max(s.config.RefreshTokenExpiration, s.tokenLifetime())
+	}
+	return max(defaultRefreshLifetime, s.tokenLifetime())
+}
+
+// SetLogger has warnings, such as tokens issued without a session, logged
+// to logger
+func (s *Service) SetLogger(logger *zap.Logger) {
+	s.logger = logger
+}
+
+// SetEventRecorder enables security events for login anomalies and role
+// grants
+func (s *Service) SetEventRecorder(events *security.Service) {
+	s.events = events
+}
+
+// Register creates an account and signs it in on device
+func (s *Service) Register(ctx context.Context, req RegisterRequest, device Device) (*AuthResponse, error) {
 	// Validate password strength
 	if err := validatePassword(req.Password); err != nil {
 		return nil, err
 	}
 
-	// Check if user exists
-	var existingUser User
-	if result := s.db.Where("email = ?", req.Email).First(&existingUser); result.Error == nil {
+	// Emails are matched case-insensitively, and older accounts without a
+	// normalized email by their address
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	normalized := models.NormalizeEmail(email, s.config.FoldGmail)
+	taken, err := s.users.EmailTaken(ctx, normalized, email)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
 		return nil, ErrUserExists
 	}
 
@@ -51,124 +127,304 @@ func (s *Service) Register(req RegisterRequest) (*AuthResponse, error) {
 	}
 
 	user := &User{
-		Email:     req.Email,
-		Password:  string(hashedPassword),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-	}
-
-	// Save user to DB
-	if err := s.db.Create(user).Error; err != nil {
-		return nil, err
+		Email:           email,
+		NormalizedEmail: &normalized,
+		Password:        string(hashedPassword),
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
 	}
 
-	token, err := s.generateToken(user)
-	if err != nil {
+	// Concurrent registrations of the email get ErrUserExists
+	if err := s.users.Create(ctx, user); err != nil {
 		return nil, err
 	}
 
-	return &AuthResponse{
-		Token: token,
-		User:  *user,
-	}, nil
+	return s.issue(ctx, user, device)
 }
 
-func (s *Service) Login(req LoginRequest) (*AuthResponse, error) {
-	var user User
-	if err := s.db.Where("email = ?", req.Email).First(&user).Error; err != nil {
+// Login signs the user in on device, starting a session
+func (s *Service) Login(ctx context.Context, req LoginRequest, device Device) (*AuthResponse, error) {
+	clientIP := device.IP
+	email := models.NormalizeEmail(req.Email, s.config.FoldGmail)
+	user, err := s.findByEmail(ctx, req.Email)
+	if err != nil {
+		s.recordFailedLogin(ctx, email, "", clientIP)
 		return nil, ErrInvalidCredentials
 	}
 
 	// Compare passwords
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		s.recordFailedLogin(ctx, email, user.ID, clientIP)
 		return nil, ErrInvalidCredentials
 	}
 
-	token, err := s.generateToken(&user)
-	if err != nil {
-		return nil, err
+	if failures, found := s.failures.Get(email); found && failures.(int) >= failedLoginThreshold {
+		s.events.Record(ctx, security.EventLoginAfterFailures, user.ID, map[string]interface{}{
+			"email":           email,
+			"failed_attempts": failures,
+			"client_ip":       clientIP,
+		})
 	}
+	s.failures.Delete(email)
 
-	return &AuthResponse{
-		Token: token,
-		User:  user,
-	}, nil
+	// Checked after the password so that only the account's owner learns
+	// it is disabled
+	if user.DisabledAt != nil {
+		return nil, ErrAccountDisabled
+	}
+
+	return s.issue(ctx, user, device)
+}
+
+// findByEmail finds the account by its normalized email, or by its exact
+// address for older accounts left without one by the backfill
+func (s *Service) findByEmail(ctx context.Context, email string) (*User, error) {
+	return s.users.GetByEmail(ctx, models.NormalizeEmail(email, s.config.FoldGmail), strings.TrimSpace(email))
 }
 
-func (s *Service) generateToken(user *User) (string, error) {
+// generateToken issues a member token of the session, or a short-lived
+// password reset token while the user must set a new password
+func (s *Service) generateToken(user *User, sessionID string) (string, error) {
+	role, lifetime := RoleMember, s.tokenLifetime()
+	if user.PasswordResetRequired {
+		role, lifetime = RolePasswordReset, resetTokenLifetime
+	}
+	now := time.Now()
 	claims := jwt.MapClaims{
 		"user_id": user.ID,
 		"email":   user.Email,
-		"exp":     time.Now().Add(time.Hour * 24).Unix(), // 24 hour expiry
+		"role":    string(role),
+		"iat":     now.Unix(),
+		"exp":     now.Add(lifetime).Unix(),
+	}
+	if sessionID != "" {
+		claims["sid"] = sessionID
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString(s.jwtSecret)
 }
 
-func (s *Service) ValidateToken(tokenString string) (string, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+// CreateReportingToken issues a long-lived read-only token for BI tools on
+// behalf of the user. The token carries no email claim; its ID is stored so
+// it can be revoked before it expires.
+func (s *Service) CreateReportingToken(ctx context.Context, userID string, req ReportingTokenRequest) (*ReportingTokenResponse, error) {
+	days := req.ExpiresInDays
+	if days == 0 {
+		days = 30
+	}
+	record := &ReportingToken{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(time.Duration(days) * 24 * time.Hour),
+		CreatedAt: time.Now(),
+	}
+
+	claims := jwt.MapClaims{
+		"user_id": userID,
+		"role":    string(RoleReporting),
+		"jti":     record.ID,
+		"iat":     record.CreatedAt.Unix(),
+		"exp":     record.ExpiresAt.Unix(),
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.jwtSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.users.CreateReportingToken(ctx, record); err != nil {
+		return nil, fmt.Errorf("failed to store reporting token: %w", err)
+	}
+	expiresAt := record.ExpiresAt
+
+	s.events.Record(ctx, security.EventRoleGranted, userID, map[string]interface{}{
+		"role":       RoleReporting,
+		"expires_at": expiresAt,
+	})
+
+	return &ReportingTokenResponse{
+		ID:        record.ID,
+		Token:     token,
+		Role:      RoleReporting,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// ListReportingTokens returns the reporting tokens that have not expired,
+// newest first
+func (s *Service) ListReportingTokens(ctx context.Context) ([]ReportingToken, error) {
+	return s.users.ListReportingTokens(ctx, time.Now())
+}
+
+// RevokeReportingToken stops a reporting token from being accepted
+func (s *Service) RevokeReportingToken(ctx context.Context, tokenID string) error {
+	return s.users.RevokeReportingToken(ctx, tokenID)
+}
+
+func (s *Service) ValidateToken(ctx context.Context, tokenString string) (string, error) {
+	claims, err := s.ParseToken(ctx, tokenString)
+	if err != nil {
+		return "", err
+	}
+	return claims.UserID, nil
+}
+
+// ParseToken validates the token and returns its claims. Tokens issued
+// before roles existed are treated as member tokens. Tokens of disabled
+// accounts, and those issued before the account's tokens were revoked, are
+// refused. Reporting tokens must also be on record and not revoked.
+func (s *Service) ParseToken(ctx context.Context, tokenString string) (*Claims, error) {
+	return s.parseToken(ctx, tokenString, false)
+}
+
+// parseToken is ParseToken. Tokens being refreshed may be past their
+// expiry, as long as they were issued within the refresh lifetime.
+func (s *Service) parseToken(ctx context.Context, tokenString string, refreshing bool) (*Claims, error) {
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
 		// Verify signing method
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 		return s.jwtSecret, nil
-	})
+	}
+	var options []jwt.ParserOption
+	if refreshing {
+		options = append(options, jwt.WithoutClaimsValidation())
+	}
+	token, err := jwt.Parse(tokenString, keyFunc, options...)
 
 	if err != nil {
-		return "", ErrInvalidCredentials
+		return nil, ErrInvalidCredentials
 	}
 
 	if !token.Valid {
-		return "", ErrInvalidCredentials
+		return nil, ErrInvalidCredentials
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return "", ErrInvalidCredentials
+		return nil, ErrInvalidCredentials
 	}
 
 	// Check token expiration
-	if exp, ok := claims["exp"].(float64); ok {
+	iat, hasIssuedAt := claims["iat"].(float64)
+	if refreshing && hasIssuedAt {
+		if time.Now().After(time.Unix(int64(iat), 0).Add(s.refreshLifetime())) {
+			return nil, ErrInvalidCredentials
+		}
+	} else if exp, ok := claims["exp"].(float64); ok {
 		if time.Now().Unix() > int64(exp) {
-			return "", ErrInvalidCredentials
+			return nil, ErrInvalidCredentials
 		}
 	}
 
 	userID, ok := claims["user_id"].(string)
 	if !ok {
-		return "", ErrInvalidCredentials
+		return nil, ErrInvalidCredentials
+	}
+
+	// Tokens issued before they carried iat count as the oldest
+	var issuedAt time.Time
+	if hasIssuedAt {
+		issuedAt = time.Unix(int64(iat), 0)
+	}
+	if s.tokenRevoked(userID, issuedAt) {
+		return nil, ErrInvalidCredentials
+	}
+	// Impersonation tokens also stop with their administrator's tokens
+	impersonatorID, _ := claims["imp"].(string)
+	if impersonatorID != "" && s.tokenRevoked(impersonatorID, issuedAt) {
+		return nil, ErrInvalidCredentials
+	}
+
+	role := RoleMember
+	if r, ok := claims["role"].(string); ok && r != "" {
+		role = Role(r)
+	}
+
+	// Tokens of a revoked session are refused; others are used to record
+	// when the session was last seen
+	sessionID, _ := claims["sid"].(string)
+	if sessionID != "" {
+		if s.sessionRevoked(sessionID) {
+			return nil, ErrInvalidCredentials
+		}
+		s.touchSession(ctx, sessionID)
+	}
+
+	result := &Claims{UserID: userID, Role: role, SessionID: sessionID, ImpersonatorID: impersonatorID}
+	if role == RoleReporting {
+		tokenID, _ := claims["jti"].(string)
+		if tokenID == "" {
+			return nil, ErrInvalidCredentials
+		}
+		active, err := s.users.ReportingTokenActive(ctx, tokenID, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check reporting token: %w", err)
+		}
+		if !active {
+			return nil, ErrInvalidCredentials
+		}
+		result.TokenID = tokenID
 	}
 
-	return userID, nil
+	return result, nil
 }
 
-func (s *Service) RefreshToken(refreshToken string) (*AuthResponse, error) {
-	userID, err := s.ValidateToken(refreshToken)
+// RefreshToken issues a new token of the refreshed token's session, which
+// is renewed from device. The refreshed token may have expired if it was
+// issued within the refresh lifetime.
+func (s *Service) RefreshToken(ctx context.Context, refreshToken string, device Device) (*AuthResponse, error) {
+	claims, err := s.parseToken(ctx, refreshToken, true)
 	if err != nil {
 		return nil, err
 	}
 
-	var user User
-	if err := s.db.First(&user, "id = ?", userID).Error; err != nil {
+	// Reporting tokens are rotated by minting a new one, never refreshed
+	// into a member token; impersonation tokens are never extended
+	if claims.Role != RoleMember || claims.ImpersonatorID != "" {
 		return nil, ErrInvalidCredentials
 	}
 
-	token, err := s.generateToken(&user)
-	if err != nil {
-		return nil, err
+	user, err := s.users.Get(ctx, claims.UserID)
+	if err != nil || user.DisabledAt != nil {
+		return nil, ErrInvalidCredentials
 	}
 
-	return &AuthResponse{
-		Token: token,
-		User:  user,
-	}, nil
+	return s.renew(ctx, user, claims.SessionID, device)
+}
+
+// DeleteAccount soft-deletes the user. The email is rewritten so it can be
+// registered again; tokens already issued stay valid until they expire but
+// can no longer be refreshed.
+func (s *Service) DeleteAccount(ctx context.Context, userID string) error {
+	return s.users.Delete(ctx, userID)
+}
+
+// recordFailedLogin counts a failed attempt and reports a login anomaly the
+// first time the threshold is reached within the window
+func (s *Service) recordFailedLogin(ctx context.Context, email, userID, clientIP string) {
+	if err := s.failures.Add(email, 1, cache.DefaultExpiration); err == nil {
+		return
+	}
+	failures, err := s.failures.IncrementInt(email, 1)
+	if err != nil || failures != failedLoginThreshold {
+		return
+	}
+
+	s.events.Record(ctx, security.EventLoginFailedBurst, userID, map[string]interface{}{
+		"email":           email,
+		"failed_attempts": failures,
+		"window":          failedLoginWindow.String(),
+		"client_ip":       clientIP,
+	})
 }
 
 func validatePassword(password string) error {
 	// Minimum length
 	if len(password) < 8 {
-		return errors.New("password must be at least 8 characters")
+		return ErrPasswordTooShort
 	}
 
 	// Check for number
@@ -180,7 +436,7 @@ func validatePassword(password string) error {
 		}
 	}
 	if !hasNumber {
-		return errors.New("password must contain at least one number")
+		return ErrPasswordNoNumber
 	}
 
 	// Add more validation as needed
@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestService(t *testing.T) (*Service, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewService(db, Config{JWTSecret: "test-secret"}), mock
+}
+
+func TestDeleteAccountReleasesEmail(t *testing.T) {
+	s, mock := newTestService(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "users" SET .*"email"=.*WHERE id = .* AND "users"."deleted_at" IS NULL`).
+		WithArgs(sqlmock.AnyArg(), "deleted-user-1@deleted.invalid", nil, sqlmock.AnyArg(), "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := s.DeleteAccount(context.Background(), "user-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeleteAccountUnknownUser(t *testing.T) {
+	s, mock := newTestService(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "users"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	if err := s.DeleteAccount(context.Background(), "missing"); err != ErrUserNotFound {
+		t.Fatalf("err = %v, want ErrUserNotFound", err)
+	}
+}
+
+func TestRegisterStopsWithRequestContext(t *testing.T) {
+	s, mock := newTestService(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := s.Register(ctx, RegisterRequest{Email: "new@example.com", Password: "password1"}, Device{})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRegisterRejectsEmailDifferingOnlyByCase(t *testing.T) {
+	s, mock := newTestService(t)
+
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "users" WHERE \(normalized_email = \$1 OR lower\(email\) = \$2\)`).
+		WithArgs("ana@example.com", "ana@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	_, err := s.Register(context.Background(), RegisterRequest{Email: " Ana@Example.com ", Password: "password1"}, Device{})
+	if !errors.Is(err, ErrUserExists) {
+		t.Fatalf("err = %v, want ErrUserExists", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// fakeUsers is a UserRepository in memory, keyed by normalized email,
+// with sessions by ID
+type fakeUsers struct {
+	UserRepository
+	users    map[string]*User
+	sessions map[string]*Session
+	// sessionErr fails session writes
+	sessionErr error
+}
+
+func (f *fakeUsers) EmailTaken(_ context.Context, normalized, _ string) (bool, error) {
+	_, ok := f.users[normalized]
+	return ok, nil
+}
+
+func (f *fakeUsers) Create(_ context.Context, user *User) error {
+	user.ID = fmt.Sprintf("user-%d", len(f.users)+1)
+	f.users[*user.NormalizedEmail] = user
+	return nil
+}
+
+func (f *fakeUsers) GetByEmail(_ context.Context, normalized, _ string) (*User, error) {
+	if user, ok := f.users[normalized]; ok {
+		return user, nil
+	}
+	return nil, ErrUserNotFound
+}
+
+func TestRegisterAndLoginWithRepository(t *testing.T) {
+	s := NewServiceWithRepository(&fakeUsers{users: map[string]*User{}, sessions: map[string]*Session{}}, Config{JWTSecret: "test-secret"})
+
+	registered, err := s.Register(context.Background(), RegisterRequest{Email: "Ana@Example.com", Password: "password1"}, Device{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Register(context.Background(), RegisterRequest{Email: "ana@example.com", Password: "password2"}, Device{}); !errors.Is(err, ErrUserExists) {
+		t.Fatalf("second registration: err = %v, want ErrUserExists", err)
+	}
+
+	if _, err := s.Login(context.Background(), LoginRequest{Email: "ana@example.com", Password: "wrong-password1"}, Device{IP: "203.0.113.1"}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("wrong password: err = %v, want ErrInvalidCredentials", err)
+	}
+	resp, err := s.Login(context.Background(), LoginRequest{Email: "ANA@example.com", Password: "password1"}, Device{IP: "203.0.113.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	userID, err := s.ValidateToken(context.Background(), resp.Token)
+	if err != nil || userID != registered.User.ID {
+		t.Fatalf("token user = %q, %v, want %q", userID, err, registered.User.ID)
+	}
+}
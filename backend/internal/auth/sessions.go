@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrSessionsReadOnly is returned for session writes while the
+	// database is a standby
+	ErrSessionsReadOnly = errors.New("sessions cannot be stored while the database is read-only")
+)
+
+// sessionTouchInterval is how often a session's last sighting is stored
+// while its tokens are used
+const sessionTouchInterval = 5 * time.Minute
+
+// issue starts a session on device and returns a token of it. Users who
+// must reset their password get a reset token, which starts no session.
+// Signing in stays available while the database is a standby: the token
+// is then issued without a session. Other failures to store the session
+// fail the sign-in.
+func (s *Service) issue(ctx context.Context, user *User, device Device) (*AuthResponse, error) {
+	var sessionID string
+	if !user.PasswordResetRequired {
+		now := time.Now()
+		session := &Session{
+			UserID:     user.ID,
+			UserAgent:  truncate(device.UserAgent, 512),
+			IPAddress:  device.IP,
+			CreatedAt:  now,
+			LastSeenAt: now,
+			ExpiresAt:  now.Add(s.refreshLifetime()),
+		}
+		err := s.users.CreateSession(ctx, session)
+		switch {
+		case errors.Is(err, ErrSessionsReadOnly):
+			s.logger.Warn("Issuing token without a session", zap.String("user_id", user.ID), zap.Error(err))
+		case err != nil:
+			return nil, fmt.Errorf("failed to start session: %w", err)
+		default:
+			sessionID = session.ID
+		}
+	}
+
+	token, err := s.generateToken(user, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return &AuthResponse{Token: token, User: *user}, nil
+}
+
+// renew extends the session of a refreshed token by the refresh lifetime,
+// or starts one for tokens issued before sessions were tracked.
+// While the database is a standby the session is kept as it was, as issue
+// does.
+func (s *Service) renew(ctx context.Context, user *User, sessionID string, device Device) (*AuthResponse, error) {
+	if sessionID == "" {
+		return s.issue(ctx, user, device)
+	}
+	now := time.Now()
+	err := s.users.RenewSession(ctx, &Session{
+		ID:         sessionID,
+		UserID:     user.ID,
+		UserAgent:  truncate(device.UserAgent, 512),
+		IPAddress:  device.IP,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(s.refreshLifetime()),
+	})
+	switch {
+	case errors.Is(err, ErrSessionNotFound):
+		return nil, ErrInvalidCredentials
+	case errors.Is(err, ErrSessionsReadOnly):
+		s.logger.Warn("Refreshing token without renewing its session", zap.String("session_id", sessionID), zap.Error(err))
+	case err != nil:
+		return nil, fmt.Errorf("failed to renew session: %w", err)
+	}
+
+	token, err := s.generateToken(user, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return &AuthResponse{Token: token, User: *user}, nil
+}
+
+// ListSessions returns the user's active sessions, marking currentID as
+// the caller's. Sessions from before the user's tokens were last revoked
+// are left out; their tokens are no longer accepted.
+func (s *Service) ListSessions(ctx context.Context, userID, currentID string) ([]Session, error) {
+	user, err := s.users.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := s.users.ListSessions(ctx, userID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	active := sessions[:0]
+	for _, session := range sessions {
+		if user.SessionsRevokedAt != nil && session.CreatedAt.Before(user.SessionsRevokedAt.Truncate(time.Second)) {
+			continue
+		}
+		session.Current = session.ID == currentID
+		active = append(active, session)
+	}
+	return active, nil
+}
+
+// RevokeSession signs the user's session out: its tokens are refused on
+// this replica at once and on the others within the account sync interval
+func (s *Service) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	now := time.Now()
+	if err := s.users.RevokeSession(ctx, sessionID, userID, now); err != nil {
+		return err
+	}
+	s.restrictedMu.Lock()
+	s.revokedSessions[sessionID] = true
+	s.restrictedMu.Unlock()
+	return nil
+}
+
+// sessionRevoked reports whether the session's tokens may no longer be
+// used
+func (s *Service) sessionRevoked(sessionID string) bool {
+	s.restrictedMu.RLock()
+	defer s.restrictedMu.RUnlock()
+	return s.revokedSessions[sessionID]
+}
+
+// touchSession stores that the session was seen, at most once per
+// sessionTouchInterval. Last sightings are informational, so a failure
+// to store one does not fail the request.
+func (s *Service) touchSession(ctx context.Context, sessionID string) {
+	if s.touched.Add(sessionID, true, cache.DefaultExpiration) != nil {
+		return
+	}
+	_ = s.users.TouchSession(ctx, sessionID, time.Now())
+}
+
+// truncate cuts s to at most n bytes
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,208 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func (f *fakeUsers) CreateSession(_ context.Context, session *Session) error {
+	if f.sessionErr != nil {
+		return f.sessionErr
+	}
+	session.ID = fmt.Sprintf("session-%d", len(f.sessions)+1)
+	f.sessions[session.ID] = session
+	return nil
+}
+
+func (f *fakeUsers) ListSessions(_ context.Context, userID string, now time.Time) ([]Session, error) {
+	var sessions []Session
+	for _, session := range f.sessions {
+		if session.UserID == userID && session.RevokedAt == nil && session.ExpiresAt.After(now) {
+			sessions = append(sessions, *session)
+		}
+	}
+	return sessions, nil
+}
+
+func (f *fakeUsers) RenewSession(_ context.Context, renewed *Session) error {
+	if f.sessionErr != nil {
+		return f.sessionErr
+	}
+	session, ok := f.sessions[renewed.ID]
+	if !ok || session.UserID != renewed.UserID || session.RevokedAt != nil {
+		return ErrSessionNotFound
+	}
+	session.LastSeenAt, session.ExpiresAt = renewed.LastSeenAt, renewed.ExpiresAt
+	session.IPAddress, session.UserAgent = renewed.IPAddress, renewed.UserAgent
+	return nil
+}
+
+func (f *fakeUsers) TouchSession(_ context.Context, id string, at time.Time) error {
+	if session, ok := f.sessions[id]; ok {
+		session.LastSeenAt = at
+	}
+	return nil
+}
+
+func (f *fakeUsers) RevokeSession(_ context.Context, id, userID string, at time.Time) error {
+	session, ok := f.sessions[id]
+	if !ok || session.UserID != userID || session.RevokedAt != nil {
+		return ErrSessionNotFound
+	}
+	session.RevokedAt = &at
+	return nil
+}
+
+func TestSessionsTrackDevicesAndRevokeTheirTokens(t *testing.T) {
+	s, user, _ := registerTestUser(t)
+	ctx := context.Background()
+	login := LoginRequest{Email: "ana@example.com", Password: "password1"}
+
+	laptop, err := s.Login(ctx, login, Device{IP: "203.0.113.1", UserAgent: "Firefox"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	phone, err := s.Login(ctx, login, Device{IP: "203.0.113.2", UserAgent: "Safari"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := s.ParseToken(ctx, phone.Token)
+	if err != nil || claims.SessionID == "" {
+		t.Fatalf("claims = %+v, %v, want a session", claims, err)
+	}
+
+	sessions, err := s.ListSessions(ctx, user.ID, claims.SessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Registering signed in a third device
+	if len(sessions) != 3 {
+		t.Fatalf("sessions = %+v, want 3", sessions)
+	}
+	for _, session := range sessions {
+		if session.Current != (session.ID == claims.SessionID) {
+			t.Fatalf("session %+v, want only %s current", session, claims.SessionID)
+		}
+	}
+
+	if err := s.RevokeSession(ctx, "user-2", claims.SessionID); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("another user's session: err = %v, want ErrSessionNotFound", err)
+	}
+	if err := s.RevokeSession(ctx, user.ID, claims.SessionID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ParseToken(ctx, phone.Token); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("token of revoked session: err = %v, want ErrInvalidCredentials", err)
+	}
+	if _, err := s.ParseToken(ctx, laptop.Token); err != nil {
+		t.Fatalf("token of other session: %v", err)
+	}
+}
+
+func TestRefreshKeepsSession(t *testing.T) {
+	s, _, legacy := registerTestUser(t)
+	ctx := context.Background()
+
+	// Tokens from before sessions were tracked start one when refreshed
+	refreshed, err := s.RefreshToken(ctx, legacy, Device{IP: "203.0.113.1", UserAgent: "Firefox"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := s.ParseToken(ctx, refreshed.Token)
+	if err != nil || first.SessionID == "" {
+		t.Fatalf("claims = %+v, %v, want a session", first, err)
+	}
+
+	again, err := s.RefreshToken(ctx, refreshed.Token, Device{IP: "198.51.100.7", UserAgent: "Firefox"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := s.ParseToken(ctx, again.Token)
+	if err != nil || second.SessionID != first.SessionID {
+		t.Fatalf("claims = %+v, %v, want session %s kept", second, err, first.SessionID)
+	}
+	if ip := s.users.(*fakeUsers).sessions[first.SessionID].IPAddress; ip != "198.51.100.7" {
+		t.Fatalf("session IP = %q, want the refreshing device's", ip)
+	}
+}
+
+func TestConfiguredLifetimesSetTokenAndSessionExpiry(t *testing.T) {
+	users := &fakeUsers{users: map[string]*User{}, sessions: map[string]*Session{}}
+	s := NewServiceWithRepository(users, Config{JWTSecret: "test-secret", TokenExpiration: time.Hour, RefreshTokenExpiration: 48 * time.Hour})
+	resp, err := s.Register(context.Background(), RegisterRequest{Email: "ana@example.com", Password: "password1"}, Device{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, _, err := jwt.NewParser().ParseUnverified(resp.Token, jwt.MapClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp, err := token.Claims.GetExpirationTime()
+	if err != nil || exp == nil {
+		t.Fatalf("exp = %v, %v, want one", exp, err)
+	}
+	if lifetime := time.Until(exp.Time); lifetime > time.Hour || lifetime < 59*time.Minute {
+		t.Fatalf("token lasts %s, want the configured hour", lifetime)
+	}
+	for _, session := range users.sessions {
+		if lifetime := time.Until(session.ExpiresAt); lifetime > 48*time.Hour || lifetime < 47*time.Hour {
+			t.Fatalf("session lasts %s, want the configured refresh lifetime", lifetime)
+		}
+	}
+}
+
+func TestRefreshAcceptsExpiredTokenWithinRefreshLifetime(t *testing.T) {
+	s, user, _ := registerTestUser(t)
+	ctx := context.Background()
+
+	expired := signTestToken(t, jwt.MapClaims{
+		"user_id": user.ID,
+		"role":    string(RoleMember),
+		"iat":     time.Now().Add(-48 * time.Hour).Unix(),
+		"exp":     time.Now().Add(-24 * time.Hour).Unix(),
+	})
+	if _, err := s.ParseToken(ctx, expired); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("expired token: err = %v, want ErrInvalidCredentials", err)
+	}
+	if _, err := s.RefreshToken(ctx, expired, Device{}); err != nil {
+		t.Fatalf("refresh within the refresh lifetime: %v", err)
+	}
+
+	stale := signTestToken(t, jwt.MapClaims{
+		"user_id": user.ID,
+		"role":    string(RoleMember),
+		"iat":     time.Now().Add(-8 * 24 * time.Hour).Unix(),
+		"exp":     time.Now().Add(-7 * 24 * time.Hour).Unix(),
+	})
+	if _, err := s.RefreshToken(ctx, stale, Device{}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("refresh past the refresh lifetime: err = %v, want ErrInvalidCredentials", err)
+	}
+}
+
+func TestLoginFailsWhenSessionCannotBeStored(t *testing.T) {
+	s, _, _ := registerTestUser(t)
+	ctx := context.Background()
+	users := s.users.(*fakeUsers)
+
+	users.sessionErr = errors.New("connection reset")
+	if _, err := s.Login(ctx, LoginRequest{Email: "ana@example.com", Password: "password1"}, Device{}); err == nil {
+		t.Fatal("signed in without a session")
+	}
+
+	// While the database is a standby, sign-in carries on without one
+	users.sessionErr = fmt.Errorf("%w: read-only transaction", ErrSessionsReadOnly)
+	resp, err := s.Login(ctx, LoginRequest{Email: "ana@example.com", Password: "password1"}, Device{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := s.ParseToken(ctx, resp.Token)
+	if err != nil || claims.SessionID != "" {
+		t.Fatalf("claims = %+v, %v, want a token without a session", claims, err)
+	}
+}
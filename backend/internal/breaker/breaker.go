@@ -0,0 +1,167 @@
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrOpen = errors.New("circuit breaker open")
+
+type State string
+
+const (
+	Closed   State = "closed"
+	Open     State = "open"
+	HalfOpen State = "half_open"
+)
+
+// circuit is the breaker of one destination
+type circuit struct {
+	state    State
+	failures int
+	openedAt time.Time
+	// probing is set while the one call allowed through a half-open
+	// circuit is in flight
+	probing bool
+}
+
+// Set keeps a circuit breaker per destination, so calls to a destination
+// that keeps failing fail fast instead of each waiting for a timeout. A nil
+// *Set lets every call through.
+type Set struct {
+	failures int
+	cooldown time.Duration
+	observe  func(dest string, state State)
+	now      func() time.Time
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+// New returns breakers that open after failures consecutive failures and
+// let a probe through cooldown after opening
+func New(failures int, cooldown time.Duration) *Set {
+	return &Set{
+		failures: max(failures, 1),
+		cooldown: cooldown,
+		now:      time.Now,
+		circuits: make(map[string]*circuit),
+	}
+}
+
+// SetObserver reports each change of a destination's state
+func (s *Set) SetObserver(observe func(dest string, state State)) {
+	s.observe = observe
+}
+
+// Do calls call unless dest's circuit is open, when it returns ErrOpen.
+// failed tells which of call's errors are failures of dest; other errors,
+// such as the caller giving up, leave the circuit as it was.
+func (s *Set) Do(dest string, call func() error, failed func(err error) bool) error {
+	if s == nil {
+		return call()
+	}
+	if err := s.allow(dest); err != nil {
+		return err
+	}
+	err := call()
+	switch {
+	case err == nil:
+		s.succeed(dest)
+	case failed(err):
+		s.fail(dest)
+	default:
+		s.release(dest)
+	}
+	return err
+}
+
+// State returns dest's state
+func (s *Set) State(dest string) State {
+	if s == nil {
+		return Closed
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.circuits[dest]; ok {
+		return c.state
+	}
+	return Closed
+}
+
+// allow admits a call to dest. Once an open circuit cooled down, it turns
+// half-open and admits a single probe.
+func (s *Set) allow(dest string) error {
+	s.mu.Lock()
+	c, ok := s.circuits[dest]
+	if !ok || c.state == Closed {
+		s.mu.Unlock()
+		return nil
+	}
+	if c.probing || (c.state == Open && s.now().Sub(c.openedAt) < s.cooldown) {
+		s.mu.Unlock()
+		return ErrOpen
+	}
+	changed := c.state != HalfOpen
+	c.state = HalfOpen
+	c.probing = true
+	s.mu.Unlock()
+
+	if changed {
+		s.notify(dest, HalfOpen)
+	}
+	return nil
+}
+
+// succeed closes dest's circuit. Closed circuits without failures are
+// dropped, so destinations that work take no memory.
+func (s *Set) succeed(dest string) {
+	s.mu.Lock()
+	c, ok := s.circuits[dest]
+	delete(s.circuits, dest)
+	s.mu.Unlock()
+
+	if ok && c.state != Closed {
+		s.notify(dest, Closed)
+	}
+}
+
+// fail counts a failure of dest, opening its circuit on the last one
+// allowed or on a failed probe
+func (s *Set) fail(dest string) {
+	s.mu.Lock()
+	c, ok := s.circuits[dest]
+	if !ok {
+		c = &circuit{state: Closed}
+		s.circuits[dest] = c
+	}
+	c.failures++
+	c.probing = false
+	opened := c.state == HalfOpen || (c.state == Closed && c.failures >= s.failures)
+	if opened {
+		c.state = Open
+		c.openedAt = s.now()
+	}
+	s.mu.Unlock()
+
+	if opened {
+		s.notify(dest, Open)
+	}
+}
+
+// release ends a call that neither succeeded nor failed, letting another
+// probe through a half-open circuit
+func (s *Set) release(dest string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.circuits[dest]; ok {
+		c.probing = false
+	}
+}
+
+func (s *Set) notify(dest string, state State) {
+	if s.observe != nil {
+		s.observe(dest, state)
+	}
+}
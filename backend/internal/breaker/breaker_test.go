@@ -0,0 +1,81 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var errDown = errors.New("down")
+
+func isDown(err error) bool { return errors.Is(err, errDown) }
+
+func TestBreakerOpensAndRecoversThroughProbe(t *testing.T) {
+	s := New(3, time.Minute)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+	var states []State
+	s.SetObserver(func(dest string, state State) { states = append(states, state) })
+
+	calls := 0
+	fail := func() error { calls++; return errDown }
+	for range 3 {
+		if err := s.Do("gemini", fail, isDown); !errors.Is(err, errDown) {
+			t.Fatalf("err = %v, want the call's error while closed", err)
+		}
+	}
+	if err := s.Do("gemini", fail, isDown); !errors.Is(err, ErrOpen) || calls != 3 {
+		t.Fatalf("err = %v after %d calls, want ErrOpen without a fourth call", err, calls)
+	}
+	if err := s.Do("discord", func() error { return nil }, isDown); err != nil {
+		t.Fatalf("other destination: err = %v, want its own circuit", err)
+	}
+
+	// A failed probe opens the circuit for another cooldown
+	now = now.Add(time.Minute)
+	if err := s.Do("gemini", fail, isDown); !errors.Is(err, errDown) || calls != 4 {
+		t.Fatalf("probe: err = %v after %d calls, want the probe to reach the destination", err, calls)
+	}
+	if err := s.Do("gemini", fail, isDown); !errors.Is(err, ErrOpen) {
+		t.Fatalf("err = %v, want ErrOpen after a failed probe", err)
+	}
+
+	// Errors that are not failures, like a caller giving up, leave the
+	// circuit half-open for the next probe
+	now = now.Add(time.Minute)
+	if err := s.Do("gemini", func() error { return context.Canceled }, isDown); !errors.Is(err, context.Canceled) {
+		t.Fatal(err)
+	}
+	if err := s.Do("gemini", func() error { return nil }, isDown); err != nil {
+		t.Fatalf("err = %v, want the next probe through", err)
+	}
+	if s.State("gemini") != Closed {
+		t.Fatalf("state = %s, want closed after a successful probe", s.State("gemini"))
+	}
+
+	want := []State{Open, HalfOpen, Open, HalfOpen, Closed}
+	if len(states) != len(want) {
+		t.Fatalf("states = %v, want %v", states, want)
+	}
+	for i := range want {
+		if states[i] != want[i] {
+			t.Fatalf("states = %v, want %v", states, want)
+		}
+	}
+}
+
+func TestBreakerAdmitsOneProbeAtATime(t *testing.T) {
+	s := New(1, 0)
+	s.Do("slack", func() error { return errDown }, isDown)
+
+	err := s.Do("slack", func() error {
+		if err := s.Do("slack", func() error { return nil }, isDown); !errors.Is(err, ErrOpen) {
+			t.Errorf("concurrent call: err = %v, want ErrOpen while probing", err)
+		}
+		return nil
+	}, isDown)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,41 @@
+package chaos
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"go.uber.org/zap"
+)
+
+type Handler struct {
+	injector *Injector
+	logger   *zap.Logger
+}
+
+func NewHandler(injector *Injector, logger *zap.Logger) *Handler {
+	return &Handler{
+		injector: injector,
+		logger:   logger,
+	}
+}
+
+func (h *Handler) GetConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, h.injector.Config())
+}
+
+func (h *Handler) UpdateConfig(c *gin.Context) {
+	var config Config
+	if err := c.ShouldBindJSON(&config); err != nil {
+		_ = c.Error(common.NewValidationError(err))
+		return
+	}
+
+	if err := h.injector.Update(config); err != nil {
+		_ = c.Error(common.NewInvalidInputError(err.Error()))
+		return
+	}
+
+	h.logger.Info("Fault injection updated", zap.String("user_id", c.GetString("user_id")))
+	c.JSON(http.StatusOK, h.injector.Config())
+}
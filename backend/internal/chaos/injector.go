@@ -0,0 +1,160 @@
+package chaos
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInjectedFault = errors.New("injected fault")
+	ErrInvalidRate   = errors.New("rates must be between 0 and 1")
+)
+
+// Config controls which faults are injected and how often. Rates are
+// probabilities in the range [0, 1].
+type Config struct {
+	Enabled       bool    `json:"enabled"`
+	LatencyRate   float64 `json:"latency_rate"`
+	MaxLatencyMs  int     `json:"max_latency_ms"`
+	DBErrorRate   float64 `json:"db_error_rate"`
+	WSDropRate    float64 `json:"ws_drop_rate"`
+	AIFailureRate float64 `json:"ai_failure_rate"`
+}
+
+func (c Config) validate() error {
+	for _, r := range []float64{c.LatencyRate, c.DBErrorRate, c.WSDropRate, c.AIFailureRate} {
+		if r < 0 || r > 1 {
+			return ErrInvalidRate
+		}
+	}
+	if c.MaxLatencyMs < 0 {
+		return errors.New("max_latency_ms must not be negative")
+	}
+	return nil
+}
+
+// Injector decides when to inject faults. A nil Injector never injects,
+// so services can call its methods unconditionally.
+type Injector struct {
+	mu     sync.RWMutex
+	config Config
+	rand   *rand.Rand
+	logger *zap.Logger
+}
+
+func NewInjector(logger *zap.Logger) *Injector {
+	return &Injector{
+		config: Config{MaxLatencyMs: 2000},
+		rand:   rand.New(rand.NewSource(time.Now().UnixNano())),
+		logger: logger,
+	}
+}
+
+func (i *Injector) Config() Config {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.config
+}
+
+func (i *Injector) Update(config Config) error {
+	if err := config.validate(); err != nil {
+		return err
+	}
+
+	i.mu.Lock()
+	i.config = config
+	i.mu.Unlock()
+
+	i.logger.Warn("Fault injection config updated",
+		zap.Bool("enabled", config.Enabled),
+		zap.Float64("latency_rate", config.LatencyRate),
+		zap.Float64("db_error_rate", config.DBErrorRate),
+		zap.Float64("ws_drop_rate", config.WSDropRate),
+		zap.Float64("ai_failure_rate", config.AIFailureRate),
+	)
+	return nil
+}
+
+func (i *Injector) roll(rate func(Config) float64) bool {
+	if i == nil {
+		return false
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if !i.config.Enabled {
+		return false
+	}
+	return i.rand.Float64() < rate(i.config)
+}
+
+// ShouldFailDB reports whether the current database operation should fail
+func (i *Injector) ShouldFailDB() bool {
+	return i.roll(func(c Config) float64 { return c.DBErrorRate })
+}
+
+// ShouldDropFrame reports whether the current WebSocket frame should be dropped
+func (i *Injector) ShouldDropFrame() bool {
+	return i.roll(func(c Config) float64 { return c.WSDropRate })
+}
+
+// ShouldFailAI reports whether the current AI provider call should fail
+func (i *Injector) ShouldFailAI() bool {
+	return i.roll(func(c Config) float64 { return c.AIFailureRate })
+}
+
+// Latency returns a random delay to inject, or zero
+func (i *Injector) Latency() time.Duration {
+	if !i.roll(func(c Config) float64 { return c.LatencyRate }) {
+		return 0
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.config.MaxLatencyMs <= 0 {
+		return 0
+	}
+	return time.Duration(i.rand.Intn(i.config.MaxLatencyMs)) * time.Millisecond
+}
+
+// Middleware injects random latency before the request is handled
+func (i *Injector) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if delay := i.Latency(); delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-c.Request.Context().Done():
+			}
+		}
+		c.Next()
+	}
+}
+
+// RegisterGormCallbacks makes database operations fail according to the
+// configured DB error rate
+func (i *Injector) RegisterGormCallbacks(db *gorm.DB) error {
+	inject := func(tx *gorm.DB) {
+		if i.ShouldFailDB() {
+			tx.AddError(ErrInjectedFault)
+		}
+	}
+
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").Register("chaos:create", inject); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("gorm:query").Register("chaos:query", inject); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("chaos:update", inject); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("chaos:delete", inject); err != nil {
+		return err
+	}
+	return cb.Row().Before("gorm:row").Register("chaos:row", inject)
+}
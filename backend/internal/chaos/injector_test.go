@@ -0,0 +1,61 @@
+package chaos
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func TestNilInjectorNeverInjects(t *testing.T) {
+	var i *Injector
+	if i.ShouldFailDB() || i.ShouldFailAI() || i.ShouldDropFrame() || i.Latency() != 0 {
+		t.Fatal("nil injector injected a fault")
+	}
+}
+
+func TestUpdateRejectsOutOfRangeRates(t *testing.T) {
+	i := NewInjector(zap.NewNop())
+	if err := i.Update(Config{Enabled: true, DBErrorRate: 1.5}); err != ErrInvalidRate {
+		t.Fatalf("err = %v, want ErrInvalidRate", err)
+	}
+	if i.Config().Enabled {
+		t.Fatal("invalid config was applied")
+	}
+}
+
+func TestAlwaysFailingRates(t *testing.T) {
+	i := NewInjector(zap.NewNop())
+	if err := i.Update(Config{Enabled: true, DBErrorRate: 1, AIFailureRate: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if !i.ShouldFailDB() || !i.ShouldFailAI() {
+		t.Fatal("rate 1 did not inject")
+	}
+	if i.ShouldDropFrame() {
+		t.Fatal("rate 0 injected")
+	}
+}
+
+func TestMiddlewareStopsWaitingWhenRequestIsCancelled(t *testing.T) {
+	i := NewInjector(zap.NewNop())
+	if err := i.Update(Config{Enabled: true, LatencyRate: 1, MaxLatencyMs: 60000}); err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/", i.Middleware(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("middleware waited %s after the request was cancelled", elapsed)
+	}
+}
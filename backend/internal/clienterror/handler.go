@@ -0,0 +1,79 @@
+package clienterror
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultSummaryHours = 24
+	maxSummaryHours     = 24 * 7
+)
+
+type Handler struct {
+	service *Service
+	logger  *zap.Logger
+}
+
+func NewHandler(service *Service, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// Report accepts an error report from the frontend. Unsampled reports are
+// accepted too, so clients never retry them.
+func (h *Handler) Report(c *gin.Context) {
+	var req ReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(common.NewValidationError(err))
+		return
+	}
+
+	stored, err := h.service.Report(c.Request.Context(), req, c.Request.UserAgent())
+	if err != nil {
+		h.logger.Error("Failed to store client error", zap.Error(err))
+		_ = c.Error(common.NewInternalServerError("failed to store client error"))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"stored": stored})
+}
+
+func (h *Handler) Summary(c *gin.Context) {
+	hours := defaultSummaryHours
+	if raw := c.Query("hours"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxSummaryHours {
+			_ = c.Error(common.NewInvalidInputError("hours must be between 1 and 168"))
+			return
+		}
+		hours = parsed
+	}
+
+	summary, err := h.service.Summarize(c.Request.Context(), time.Now().Add(-time.Duration(hours)*time.Hour))
+	if err != nil {
+		h.logger.Error("Failed to summarize client errors", zap.Error(err))
+		_ = c.Error(common.NewInternalServerError("failed to summarize client errors"))
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+func (h *Handler) List(c *gin.Context) {
+	reports, err := h.service.List(c.Request.Context(), c.Query("fingerprint"))
+	if err != nil {
+		h.logger.Error("Failed to list client errors", zap.Error(err))
+		_ = c.Error(common.NewInternalServerError("failed to list client errors"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reports": reports})
+}
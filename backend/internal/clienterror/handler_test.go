@@ -0,0 +1,111 @@
+package clienterror
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestRouter(t *testing.T, sample float64) (*gin.Engine, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service := NewService(db, 0.25, zap.NewNop())
+	service.sample = func() float64 { return sample }
+	handler := NewHandler(service, zap.NewNop())
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(common.ErrorHandler(zap.NewNop()))
+	router.POST("/client-errors", handler.Report)
+	router.GET("/admin/client-errors/summary", handler.Summary)
+	return router, mock
+}
+
+func post(router *gin.Engine, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/client-errors", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestReportSamplesScriptErrors(t *testing.T) {
+	// 0.5 is above the 0.25 sample rate, so the report is dropped
+	router, mock := newTestRouter(t, 0.5)
+
+	w := post(router, `{"kind": "js_error", "message": "x is undefined", "source": "app.js"}`)
+	if w.Code != http.StatusAccepted || !strings.Contains(w.Body.String(), `"stored":false`) {
+		t.Fatalf("status = %d, body %s, want an accepted unsampled report", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReportKeepsEveryReconnectLoop(t *testing.T) {
+	router, mock := newTestRouter(t, 0.5)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "client_errors"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("report-1"))
+	mock.ExpectCommit()
+
+	w := post(router, `{"kind": "ws_reconnect_loop", "message": "socket closed 1006", "attempts": 12, "request_id": "req-1"}`)
+	if w.Code != http.StatusAccepted || !strings.Contains(w.Body.String(), `"stored":true`) {
+		t.Fatalf("status = %d, body %s, want a stored report", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReportValidatesInput(t *testing.T) {
+	router, _ := newTestRouter(t, 0)
+
+	for _, body := range []string{
+		`{"kind": "console_log", "message": "hi"}`,
+		`{"kind": "js_error"}`,
+		`{"kind": "js_error", "message": "` + strings.Repeat("x", 1001) + `"}`,
+	} {
+		if w := post(router, body); w.Code != http.StatusBadRequest {
+			t.Errorf("body %.60s: status = %d, want 400", body, w.Code)
+		}
+	}
+}
+
+func TestSummaryRejectsLongWindows(t *testing.T) {
+	router, _ := newTestRouter(t, 0)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/client-errors/summary?hours=500", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", w.Code)
+	}
+}
+
+func TestFingerprintGroupsBySource(t *testing.T) {
+	a := fingerprint(KindJSError, "x is undefined", "app.js")
+	if a != fingerprint(KindJSError, "x is undefined", "app.js") || len(a) != 16 {
+		t.Fatalf("fingerprint %q is not a stable 16 character hash", a)
+	}
+	if a == fingerprint(KindJSError, "x is undefined", "vendor.js") {
+		t.Fatal("errors from different scripts share a fingerprint")
+	}
+}
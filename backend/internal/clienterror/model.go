@@ -0,0 +1,58 @@
+package clienterror
+
+import (
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+)
+
+type ClientError = models.ClientError
+
+const (
+	// KindJSError is an uncaught script error or unhandled rejection
+	KindJSError = "js_error"
+	// KindReconnectLoop is a WebSocket that keeps failing to reconnect
+	KindReconnectLoop = "ws_reconnect_loop"
+)
+
+// ReportRequest is what the frontend posts for one error. RequestID is the
+// X-Request-ID of the API response that failed, if any, which matches the
+// server's logs.
+type ReportRequest struct {
+	Kind      string            `json:"kind" binding:"required,oneof=js_error ws_reconnect_loop"`
+	Message   string            `json:"message" binding:"required,max=1000"`
+	Stack     string            `json:"stack" binding:"max=8000"`
+	Source    string            `json:"source" binding:"max=500"`
+	Page      string            `json:"page" binding:"max=500"`
+	Release   string            `json:"release" binding:"max=100"`
+	RequestID string            `json:"request_id" binding:"max=100"`
+	Attempts  int               `json:"attempts" binding:"min=0"`
+	Context   map[string]string `json:"context" binding:"max=20,dive,keys,max=50,endkeys,max=500"`
+}
+
+// Group is every report with the same fingerprint in the summary window.
+// Estimated scales the stored reports up by their sample rate.
+type Group struct {
+	Kind        string    `json:"kind"`
+	Fingerprint string    `json:"fingerprint"`
+	Message     string    `json:"message"`
+	Source      string    `json:"source,omitempty"`
+	Stored      int64     `json:"stored"`
+	Estimated   float64   `json:"estimated"`
+	Releases    int64     `json:"releases"`
+	FirstSeen   time.Time `json:"first_seen"`
+	LastSeen    time.Time `json:"last_seen"`
+}
+
+// KindTotal is the number of reports of one kind in the summary window
+type KindTotal struct {
+	Kind      string  `json:"kind"`
+	Stored    int64   `json:"stored"`
+	Estimated float64 `json:"estimated"`
+}
+
+type Summary struct {
+	Since  time.Time   `json:"since"`
+	Totals []KindTotal `json:"totals"`
+	Groups []Group     `json:"groups"`
+}
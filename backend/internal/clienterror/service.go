@@ -0,0 +1,145 @@
+package clienterror
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	maxGroups     = 50
+	maxListed     = 100
+	maxUserAgent  = 500
+	pruneInterval = time.Hour
+)
+
+// Service stores a sample of client error reports and aggregates them for
+// administrators
+type Service struct {
+	db         *gorm.DB
+	sampleRate float64
+	// sample returns a number in [0, 1) for sampling decisions
+	sample func() float64
+	logger *zap.Logger
+}
+
+// NewService keeps sampleRate of script errors. Reconnect loops are rarer
+// and say more about the server, so all of them are kept.
+func NewService(db *gorm.DB, sampleRate float64, logger *zap.Logger) *Service {
+	return &Service{
+		db:         db,
+		sampleRate: min(max(sampleRate, 0), 1),
+		sample:     rand.Float64,
+		logger:     logger,
+	}
+}
+
+// Report stores the report if it is sampled, reporting whether it was
+func (s *Service) Report(ctx context.Context, req ReportRequest, userAgent string) (bool, error) {
+	rate := 1.0
+	if req.Kind == KindJSError {
+		rate = s.sampleRate
+	}
+	if rate == 0 || s.sample() >= rate {
+		return false, nil
+	}
+
+	if len(userAgent) > maxUserAgent {
+		userAgent = userAgent[:maxUserAgent]
+	}
+	report := &ClientError{
+		Kind:        req.Kind,
+		Fingerprint: fingerprint(req.Kind, req.Message, req.Source),
+		Message:     req.Message,
+		Stack:       req.Stack,
+		Source:      req.Source,
+		Page:        req.Page,
+		Release:     req.Release,
+		RequestID:   req.RequestID,
+		Attempts:    req.Attempts,
+		Context:     req.Context,
+		UserAgent:   userAgent,
+		SampleRate:  rate,
+		CreatedAt:   time.Now(),
+	}
+	if err := s.db.WithContext(ctx).Create(report).Error; err != nil {
+		return false, fmt.Errorf("failed to store client error: %w", err)
+	}
+	return true, nil
+}
+
+// Summarize aggregates the reports since the given time: totals per kind
+// and the 50 most frequent fingerprints
+func (s *Service) Summarize(ctx context.Context, since time.Time) (*Summary, error) {
+	summary := &Summary{Since: since, Totals: []KindTotal{}, Groups: []Group{}}
+	if err := s.db.WithContext(ctx).Model(&ClientError{}).
+		Select("kind, COUNT(*) AS stored, SUM(1 / sample_rate) AS estimated").
+		Where("created_at >= ?", since).
+		Group("kind").
+		Order("kind ASC").
+		Scan(&summary.Totals).Error; err != nil {
+		return nil, fmt.Errorf("failed to total client errors: %w", err)
+	}
+
+	if err := s.db.WithContext(ctx).Model(&ClientError{}).
+		Select(`kind, fingerprint, MAX(message) AS message, MAX(source) AS source,
+			COUNT(*) AS stored, SUM(1 / sample_rate) AS estimated,
+			COUNT(DISTINCT NULLIF(release, '')) AS releases,
+			MIN(created_at) AS first_seen, MAX(created_at) AS last_seen`).
+		Where("created_at >= ?", since).
+		Group("kind, fingerprint").
+		Order("estimated DESC, last_seen DESC").
+		Limit(maxGroups).
+		Scan(&summary.Groups).Error; err != nil {
+		return nil, fmt.Errorf("failed to group client errors: %w", err)
+	}
+	return summary, nil
+}
+
+// List returns the 100 most recent reports, of one fingerprint if it is
+// given
+func (s *Service) List(ctx context.Context, fingerprint string) ([]ClientError, error) {
+	reports := []ClientError{}
+	query := s.db.WithContext(ctx).Order("created_at DESC").Limit(maxListed)
+	if fingerprint != "" {
+		query = query.Where("fingerprint = ?", fingerprint)
+	}
+	if err := query.Find(&reports).Error; err != nil {
+		return nil, fmt.Errorf("failed to list client errors: %w", err)
+	}
+	return reports, nil
+}
+
+// Start deletes reports older than retention every hour until ctx is
+// cancelled
+func (s *Service) Start(ctx context.Context, retention time.Duration) {
+	go func() {
+		ticker := time.NewTicker(pruneInterval)
+		defer ticker.Stop()
+
+		for {
+			result := s.db.WithContext(ctx).Where("created_at < ?", time.Now().Add(-retention)).Delete(&ClientError{})
+			if result.Error != nil && ctx.Err() == nil {
+				s.logger.Error("Failed to prune client errors", zap.Error(result.Error))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// fingerprint groups reports of the same error from the same script
+func fingerprint(kind, message, source string) string {
+	sum := sha256.Sum256([]byte(kind + "\n" + message + "\n" + source))
+	return hex.EncodeToString(sum[:8])
+}
@@ -2,8 +2,12 @@ package common
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -15,6 +19,15 @@ type Config struct {
 	DBUser     string
 	DBPassword string
 	DBName     string
+	DBSSLMode  string
+	// DBReplicas are read replicas, as host or host:port, for reads that
+	// tolerate replication lag
+	DBReplicas             []string
+	DBReplicaCheckInterval time.Duration
+	// DBSlowQueryThreshold is how long a query runs before it is logged,
+	// with its plan when DBExplainSlowQueries is set
+	DBSlowQueryThreshold time.Duration
+	DBExplainSlowQueries bool
 
 	// Redis settings
 	RedisHost     string
@@ -25,21 +38,305 @@ type Config struct {
 	// Server settings
 	ServerPort  int
 	Environment string
+	// PublicBaseURL is the server's address as users reach it, used in
+	// the links it hands out
+	PublicBaseURL string
+
+	// APIDocsEnabled serves the OpenAPI document at /api/openapi.json and
+	// Swagger UI at /docs, which loads its scripts from SwaggerUIAssets
+	APIDocsEnabled  bool
+	SwaggerUIAssets string
+
+	// WebAppEnabled serves the embedded web UI at /app
+	WebAppEnabled bool
+	// CORS admits browsers on other origins; none are allowed by default
+	CORS CORS
+
+	// JWTSecret signs access and refresh tokens, which last JWTExpiration
+	// and JWTRefreshExpiration
+	JWTSecret            string
+	JWTExpiration        time.Duration
+	JWTRefreshExpiration time.Duration
+
+	// AdminUserIDs may manage deployment-wide settings such as security
+	// webhooks. Each deployment serves a single organization.
+	AdminUserIDs []string
+	// EmailFoldGmail makes Gmail addresses that differ only in dots or a
+	// +tag the same account. Set it before the first migration: existing
+	// normalized emails are not recomputed when it changes.
+	EmailFoldGmail bool
+	// Disabled accounts, revoked tokens and signed out sessions are
+	// reloaded every AccountSyncInterval, so other replicas refuse them
+	// within it
+	AccountSyncInterval time.Duration
+
+	// StartupWaitTimeout bounds how long the server waits for Postgres,
+	// Redis and migrations before giving up
+	StartupWaitTimeout time.Duration
+	// MigrateOnStart runs migrations at startup; when false the server
+	// waits for a separate migration job instead
+	MigrateOnStart bool
+	// Migrations that would block writes to a table of at least
+	// MigrationLargeTableRows rows fail when MigrationGuardEnforce is set,
+	// as it is in production, and are only logged otherwise
+	MigrationGuardEnforce   bool
+	MigrationLargeTableRows int
+
+	// MetricsToken, when set, is required as a bearer token on the
+	// internal scaling endpoints
+	MetricsToken string
+	// MetricsRoutes are the route templates labelled individually in
+	// request metrics; the rest are labelled "other". Empty means every
+	// registered route.
+	MetricsRoutes []string
+
+	// Region and InstanceID label latency metrics so they can be compared
+	// across replicas; InstanceID defaults to the hostname
+	Region     string
+	InstanceID string
+	// DeliveryProbeEnabled runs a built-in WebSocket client that times
+	// every task event
+	DeliveryProbeEnabled bool
+
+	// Route timeouts
+	TaskRouteTimeout   time.Duration
+	AIRouteTimeout     time.Duration
+	ExportRouteTimeout time.Duration
+
+	// AIEnabled turns the AI features on; it defaults to whether an API
+	// key is set, and requires one
+	AIEnabled bool
+	// AI provider settings; empty model names take the provider's default
+	AIProvider       string
+	AIAPIKey         string
+	AIModelName      string
+	AIBaseURL        string
+	AIEmbeddingModel string
+	// AIHealthCheckTTL is how long readiness probes reuse the last AI
+	// provider check instead of calling Gemini again
+	AIHealthCheckTTL time.Duration
+	// AICallTimeout bounds each call to the AI provider, so a stalled call
+	// is retried within AIRouteTimeout; 0 leaves only the route timeout
+	AICallTimeout time.Duration
+	// Calls to the AI provider and to each notification webhook are
+	// skipped for BreakerCooldown after BreakerFailures consecutive
+	// failures, until a probe call succeeds
+	BreakerFailures int
+	BreakerCooldown time.Duration
+	// AI suggestions are cached in AICacheStore for AICacheTTL; a TTL of
+	// 0 disables the cache
+	AICacheStore string
+	AICacheTTL   time.Duration
+	// AIBatchMaxTasks caps the tasks of one batch suggestion request and
+	// AIBatchWorkers how many prompts it sends at once
+	AIBatchMaxTasks int
+	AIBatchWorkers  int
+	// AIAllowedModels, AIMaxTemperature and AIMaxOutputTokens bound the
+	// model and parameters administrators may pick for the organization
+	AIAllowedModels   []string
+	AIMaxTemperature  float64
+	AIMaxOutputTokens int
+	// AIWeeklyReports writes an AI summary of each active project's week
+	AIWeeklyReports bool
+	// New tasks get a duplicate warning when an open task's embedding is
+	// at least AIDuplicateThreshold similar. Tasks still missing an
+	// embedding are embedded every AIEmbeddingInterval.
+	AIDuplicateThreshold float64
+	AIEmbeddingInterval  time.Duration
+	// AIPlanQuotas are the daily AI quotas of user plans, each written as
+	// plan:calls:tokens
+	AIPlanQuotas []string
+	// AIPromptDir holds <type>.tmpl prompt templates that replace the
+	// built-in suggestion prompts or add suggestion types
+	AIPromptDir string
+	// AIModerationURL is a moderation API checking text from users before
+	// it is put into a prompt
+	AIModerationURL    string
+	AIModerationAPIKey string
+
+	// Rate limiting (requests per minute per user, or per IP when anonymous)
+	RateLimitStore string
+	RateLimitAuth  int
+	RateLimitTasks int
+	RateLimitAI    int
+	// RateLimitIntake guards the unauthenticated public form, per client IP
+	RateLimitIntake int
+	// RateLimitClientErrors guards the unauthenticated error reports, per
+	// client IP
+	RateLimitClientErrors int
+	// RateLimitTaskLinks guards the task link forms, per client IP
+	RateLimitTaskLinks int
+
+	// Global notification webhooks; Teams is only a default channel when
+	// its webhook is set
+	SlackWebhookURL   string
+	DiscordWebhookURL string
+	TeamsWebhookURL   string
+	// Notification event deduplication; a TTL of 0 disables it
+	NotificationDedupeStore string
+	NotificationDedupeTTL   time.Duration
+	// Failed notification sends are retried until NotificationRetryMaxAttempts
+	// attempts have failed; 0 drops them as before
+	NotificationRetryMaxAttempts int
+	// NotificationWorkers messages are sent at once; urgent, normal and
+	// low priority messages may use at most their concurrency of them
+	NotificationWorkers           int
+	NotificationUrgentConcurrency int
+	NotificationNormalConcurrency int
+	NotificationLowConcurrency    int
+
+	// Task typeahead answers within TypeaheadTimeout and caches results
+	// in TypeaheadCacheStore; a TTL of 0 disables the cache
+	TypeaheadTimeout    time.Duration
+	TypeaheadCacheStore string
+	TypeaheadCacheTTL   time.Duration
+
+	// Per-user task counters are cached in TaskCountersCacheStore; a TTL
+	// of 0 disables the cache
+	TaskCountersCacheStore string
+	TaskCountersCacheTTL   time.Duration
+
+	// OrgDailyCapacityHours is how many estimated hours of work the team
+	// finishes in a day; calendar days with more due are overloaded
+	OrgDailyCapacityHours float64
+
+	// Service level objectives, evaluated over a rolling window
+	SLOWindow                     time.Duration
+	SLOTaskCreateThreshold        time.Duration
+	SLOTaskCreateTarget           float64
+	SLOWebSocketDeliveryThreshold time.Duration
+	SLOWebSocketDeliveryTarget    float64
+	SLONotificationTarget         float64
+
+	// Fault injection (never enabled in production)
+	ChaosEnabled bool
+
+	// Writes are refused while ReadOnly is set, while an admin has turned
+	// read-only mode on, or while the database is a standby
+	ReadOnly              bool
+	ReadOnlyReason        string
+	ReadOnlyCheckInterval time.Duration
+
+	// Runtime settings changed by administrators reach every replica within
+	// RuntimeConfigInterval
+	RuntimeConfigInterval time.Duration
+
+	// DevDataEnabled exposes the test data generator (never in production)
+	DevDataEnabled bool
+
+	// Tracing settings
+	OTelEnabled      bool
+	OTelEndpoint     string
+	OTelServiceName  string
+	OTelSampleRatio  float64
+	OTelExporterAuth string
 
 	// Task settings
 	TaskDefaultStatus string
 	TaskPageSize      int
 	TaskMaxDescLength int
+	// Task imports accept files of up to ImportMaxBytes with at most
+	// ImportMaxRows tasks
+	ImportMaxBytes int64
+	ImportMaxRows  int
+	// Task transfers wait TransferAcceptWindow for the new assignee by
+	// default; expired ones are reverted every TransferExpiryInterval
+	TransferAcceptWindow   time.Duration
+	TransferExpiryInterval time.Duration
+	// Task edit locks last TaskEditLockTTL unless renewed; updates of a
+	// task locked by another user are warned or rejected per
+	// TaskEditLockMode
+	TaskEditLockTTL  time.Duration
+	TaskEditLockMode string
+	// Tasks completed more than TaskArchiveAfter ago are archived every
+	// TaskArchiveInterval; zero disables archival
+	TaskArchiveAfter    time.Duration
+	TaskArchiveInterval time.Duration
+	// Overdue events are emitted nightly at OverdueScanHour o'clock UTC;
+	// a negative hour disables them
+	OverdueScanHour int
+	// Pending tasks due within TaskEscalationWithinHours get a higher
+	// priority, checked every TaskEscalationInterval; project rules
+	// override the window, and zero escalates only projects with a rule
+	TaskEscalationWithinHours int
+	TaskEscalationInterval    time.Duration
+	// WebSocket project subscriptions are re-checked after membership
+	// changes and every SubscriptionReconcileInterval
+	SubscriptionReconcileInterval time.Duration
+	// In-process stores are swept of stale entries every JanitorInterval
+	JanitorInterval time.Duration
+	// WebSocket protocol versions older than WSMinProtocolVersion are
+	// refused
+	WSMinProtocolVersion int
+	// Each WebSocket client queues up to WSClientBuffer messages; a client
+	// falling further behind is handled by WSSlowClientPolicy
+	WSClientBuffer     int
+	WSSlowClientPolicy string
+	// Up to WSBroadcastBuffer task events wait for the broadcast loop;
+	// beyond that the oldest is dropped
+	WSBroadcastBuffer int
+
+	// Attachment settings
+	AttachmentMaxBytes int64
+	OCRInterval        time.Duration
+	OCRMaxAttempts     int
+
+	// Frontend error reports: the share of script errors kept, and for how
+	// long
+	ClientErrorSampleRate float64
+	ClientErrorRetention  time.Duration
+
+	// Public intake settings
+	IntakeOwnerID        string
+	IntakeEmailToken     string
+	ModerationAIEnabled  bool
+	ModerationExtraTerms []string
+
+	// Slack app settings; slash commands are enabled when both are set
+	SlackSigningSecret string
+	SlackBotToken      string
+
+	// ComplianceSigningKey signs compliance reports; the reports are only
+	// available when it is set
+	ComplianceSigningKey string
+
+	// Warehouse export settings
+	ExportDestination   string
+	ExportInterval      time.Duration
+	BigQueryProject     string
+	BigQueryDataset     string
+	SnowflakeAccountURL string
+	SnowflakeToken      string
+	SnowflakeTokenType  string
+	SnowflakeDatabase   string
+	SnowflakeSchema     string
+	SnowflakeWarehouse  string
+	SnowflakeRole       string
 }
 
 var AppConfig Config
 
-// LoadConfig loads all environment variables into the Config struct
+// configErrors collects the variables LoadConfig could not parse
+var configErrors []string
+
+// ConfigError lists every problem of the configuration, so that all of them
+// can be fixed before the next start
+type ConfigError struct {
+	Problems []string
+}
+
+func (e *ConfigError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// LoadConfig loads all environment variables into the Config struct and
+// validates them, returning a *ConfigError when any is invalid
 func LoadConfig() error {
 	if err := godotenv.Load(); err != nil {
 		// Only log warning as .env file is optional
 		fmt.Println("Warning: .env file not found")
 	}
+	configErrors = nil
 
 	// Database configuration
 	AppConfig.DBHost = getEnvString("DB_HOST", "localhost")
@@ -47,6 +344,11 @@ func LoadConfig() error {
 	AppConfig.DBUser = getEnvString("DB_USER", "postgres")
 	AppConfig.DBPassword = getEnvString("DB_PASSWORD", "")
 	AppConfig.DBName = getEnvString("DB_NAME", "app_db")
+	AppConfig.DBSSLMode = getEnvString("DB_SSLMODE", "require")
+	AppConfig.DBReplicas = getEnvList("DB_REPLICAS")
+	AppConfig.DBReplicaCheckInterval = time.Duration(GetEnvInt("DB_REPLICA_CHECK_SECONDS", 10)) * time.Second
+	AppConfig.DBSlowQueryThreshold = time.Duration(GetEnvInt("DB_SLOW_QUERY_MS", 1000)) * time.Millisecond
+	AppConfig.DBExplainSlowQueries = getEnvBool("DB_EXPLAIN_SLOW_QUERIES", false)
 
 	// Redis configuration
 	AppConfig.RedisHost = getEnvString("REDIS_HOST", "localhost")
@@ -55,8 +357,125 @@ func LoadConfig() error {
 	AppConfig.RedisDB = GetEnvInt("REDIS_DB", 0)
 
 	// Server configuration
-	AppConfig.ServerPort = GetEnvInt("SERVER_PORT", 8080)
+	// SERVER_PORT is the older name of PORT
+	AppConfig.ServerPort = GetEnvInt("PORT", GetEnvInt("SERVER_PORT", 8080))
 	AppConfig.Environment = getEnvString("ENVIRONMENT", "development")
+	AppConfig.PublicBaseURL = getEnvString("PUBLIC_BASE_URL", "")
+	AppConfig.APIDocsEnabled = getEnvBool("API_DOCS_ENABLED", true)
+	AppConfig.SwaggerUIAssets = strings.TrimSuffix(getEnvString("SWAGGER_UI_ASSETS_URL", "https://unpkg.com/swagger-ui-dist@5"), "/")
+	AppConfig.WebAppEnabled = getEnvBool("WEB_APP_ENABLED", true)
+	AppConfig.CORS = CORS{
+		AllowedOrigins: getEnvList("CORS_ALLOWED_ORIGINS"),
+		AllowedMethods: getEnvListDefault("CORS_ALLOWED_METHODS", "GET,POST,PUT,DELETE,OPTIONS"),
+		AllowedHeaders: getEnvListDefault("CORS_ALLOWED_HEADERS", "Origin,Authorization,Content-Type,X-Request-ID"),
+		ExposedHeaders: getEnvListDefault("CORS_EXPOSED_HEADERS", "X-Request-ID,Retry-After,Content-Disposition"),
+		MaxAge:         time.Duration(GetEnvInt("CORS_MAX_AGE", 600)) * time.Second,
+	}
+	AppConfig.JWTSecret = getEnvString("JWT_SECRET", "")
+	AppConfig.JWTExpiration = getEnvDuration("JWT_EXPIRATION", 24*time.Hour)
+	AppConfig.JWTRefreshExpiration = getEnvDuration("JWT_REFRESH_EXPIRATION", 7*24*time.Hour)
+	AppConfig.AdminUserIDs = getEnvList("ADMIN_USER_IDS")
+	AppConfig.EmailFoldGmail = getEnvBool("EMAIL_FOLD_GMAIL", false)
+	AppConfig.AccountSyncInterval = time.Duration(GetEnvInt("ACCOUNT_SYNC_INTERVAL_SECONDS", 30)) * time.Second
+
+	// Startup configuration
+	AppConfig.StartupWaitTimeout = time.Duration(GetEnvInt("STARTUP_WAIT_TIMEOUT_SECONDS", 60)) * time.Second
+	AppConfig.MigrateOnStart = getEnvBool("MIGRATE_ON_START", true)
+	AppConfig.MigrationGuardEnforce = getEnvBool("MIGRATION_GUARD_ENFORCE", AppConfig.Environment == "production")
+	AppConfig.MigrationLargeTableRows = GetEnvInt("MIGRATION_LARGE_TABLE_ROWS", 100000)
+
+	AppConfig.MetricsToken = getEnvString("METRICS_TOKEN", "")
+	AppConfig.MetricsRoutes = getEnvList("METRICS_ROUTES")
+	hostname, _ := os.Hostname()
+	AppConfig.Region = getEnvString("REGION", "")
+	AppConfig.InstanceID = getEnvString("INSTANCE_ID", hostname)
+	AppConfig.DeliveryProbeEnabled = getEnvBool("DELIVERY_PROBE_ENABLED", false)
+
+	// Route timeout configuration (seconds)
+	AppConfig.TaskRouteTimeout = time.Duration(GetEnvInt("TASK_ROUTE_TIMEOUT", 2)) * time.Second
+	AppConfig.AIRouteTimeout = time.Duration(GetEnvInt("AI_ROUTE_TIMEOUT", 30)) * time.Second
+	AppConfig.ExportRouteTimeout = time.Duration(GetEnvInt("EXPORT_ROUTE_TIMEOUT", 60)) * time.Second
+	AppConfig.AIProvider = strings.ToLower(getEnvString("AI_PROVIDER", "gemini"))
+	AppConfig.AIAPIKey = getEnvString("AI_API_KEY", "")
+	AppConfig.AIModelName = getEnvString("AI_MODEL_NAME", "")
+	AppConfig.AIBaseURL = getEnvString("AI_BASE_URL", "")
+	AppConfig.AIEmbeddingModel = getEnvString("AI_EMBEDDING_MODEL", "")
+	AppConfig.AIEnabled = getEnvBool("AI_ENABLED", AppConfig.AIAPIKey != "")
+	AppConfig.AIHealthCheckTTL = time.Duration(GetEnvInt("AI_HEALTH_CHECK_TTL_MINUTES", 5)) * time.Minute
+	AppConfig.AICallTimeout = time.Duration(GetEnvInt("AI_CALL_TIMEOUT", 10)) * time.Second
+	AppConfig.BreakerFailures = GetEnvInt("BREAKER_FAILURES", 5)
+	AppConfig.BreakerCooldown = time.Duration(GetEnvInt("BREAKER_COOLDOWN_SECONDS", 30)) * time.Second
+	AppConfig.AICacheStore = strings.ToLower(getEnvString("AI_CACHE_STORE", "memory"))
+	AppConfig.AICacheTTL = time.Duration(GetEnvInt("AI_CACHE_TTL_SECONDS", 300)) * time.Second
+	AppConfig.AIBatchMaxTasks = GetEnvInt("AI_BATCH_MAX_TASKS", 20)
+	AppConfig.AIBatchWorkers = GetEnvInt("AI_BATCH_WORKERS", 4)
+	AppConfig.AIAllowedModels = getEnvList("AI_ALLOWED_MODELS")
+	AppConfig.AIMaxTemperature = getEnvFloat("AI_MAX_TEMPERATURE", 1)
+	AppConfig.AIMaxOutputTokens = GetEnvInt("AI_MAX_OUTPUT_TOKENS", 4096)
+	AppConfig.AIWeeklyReports = getEnvBool("AI_WEEKLY_REPORTS", true)
+	AppConfig.AIDuplicateThreshold = getEnvFloat("AI_DUPLICATE_THRESHOLD", 0.9)
+	AppConfig.AIEmbeddingInterval = time.Duration(GetEnvInt("AI_EMBEDDING_INTERVAL_SECONDS", 30)) * time.Second
+	AppConfig.AIPlanQuotas = getEnvList("AI_PLAN_QUOTAS")
+	AppConfig.AIPromptDir = getEnvString("AI_PROMPT_DIR", "")
+	AppConfig.AIModerationURL = getEnvString("AI_MODERATION_URL", "")
+	AppConfig.AIModerationAPIKey = getEnvString("AI_MODERATION_API_KEY", "")
+
+	// Rate limit configuration; a budget of 0 disables that limit
+	AppConfig.RateLimitStore = strings.ToLower(getEnvString("RATE_LIMIT_STORE", "memory"))
+	AppConfig.RateLimitAuth = GetEnvInt("RATE_LIMIT_AUTH_PER_MINUTE", 10)
+	AppConfig.RateLimitTasks = GetEnvInt("RATE_LIMIT_TASKS_PER_MINUTE", 120)
+	AppConfig.RateLimitAI = GetEnvInt("RATE_LIMIT_AI_PER_MINUTE", 10)
+	AppConfig.RateLimitIntake = GetEnvInt("RATE_LIMIT_INTAKE_PER_MINUTE", 5)
+	AppConfig.RateLimitClientErrors = GetEnvInt("RATE_LIMIT_CLIENT_ERRORS_PER_MINUTE", 30)
+	AppConfig.RateLimitTaskLinks = GetEnvInt("RATE_LIMIT_TASK_LINKS_PER_MINUTE", 10)
+
+	// Notification configuration
+	AppConfig.SlackWebhookURL = getEnvString("SLACK_WEBHOOK_URL", "")
+	AppConfig.DiscordWebhookURL = getEnvString("DISCORD_WEBHOOK_URL", "")
+	AppConfig.TeamsWebhookURL = getEnvString("TEAMS_WEBHOOK_URL", "")
+	AppConfig.NotificationDedupeStore = strings.ToLower(getEnvString("NOTIFICATION_DEDUPE_STORE", "memory"))
+	AppConfig.NotificationDedupeTTL = time.Duration(GetEnvInt("NOTIFICATION_DEDUPE_TTL_MINUTES", 60)) * time.Minute
+	AppConfig.NotificationRetryMaxAttempts = GetEnvInt("NOTIFICATION_RETRY_MAX_ATTEMPTS", 8)
+	AppConfig.NotificationWorkers = GetEnvInt("NOTIFICATION_WORKERS", 8)
+	AppConfig.NotificationUrgentConcurrency = GetEnvInt("NOTIFICATION_URGENT_CONCURRENCY", 8)
+	AppConfig.NotificationNormalConcurrency = GetEnvInt("NOTIFICATION_NORMAL_CONCURRENCY", 4)
+	AppConfig.NotificationLowConcurrency = GetEnvInt("NOTIFICATION_LOW_CONCURRENCY", 2)
+
+	// Typeahead configuration
+	AppConfig.TypeaheadTimeout = time.Duration(GetEnvInt("TYPEAHEAD_TIMEOUT_MS", 300)) * time.Millisecond
+	AppConfig.TypeaheadCacheStore = strings.ToLower(getEnvString("TYPEAHEAD_CACHE_STORE", "memory"))
+	AppConfig.TypeaheadCacheTTL = time.Duration(GetEnvInt("TYPEAHEAD_CACHE_TTL_SECONDS", 30)) * time.Second
+	AppConfig.TaskCountersCacheStore = strings.ToLower(getEnvString("TASK_COUNTERS_CACHE_STORE", "memory"))
+	AppConfig.TaskCountersCacheTTL = time.Duration(GetEnvInt("TASK_COUNTERS_CACHE_TTL_SECONDS", 60)) * time.Second
+
+	// Planning configuration
+	AppConfig.OrgDailyCapacityHours = getEnvFloat("ORG_DAILY_CAPACITY_HOURS", 40)
+
+	// SLO configuration; targets are the fraction of events that must be good
+	AppConfig.SLOWindow = time.Duration(GetEnvInt("SLO_WINDOW_MINUTES", 60)) * time.Minute
+	AppConfig.SLOTaskCreateThreshold = time.Duration(GetEnvInt("SLO_TASK_CREATE_LATENCY_MS", 500)) * time.Millisecond
+	AppConfig.SLOTaskCreateTarget = getEnvFloat("SLO_TASK_CREATE_TARGET", 0.99)
+	AppConfig.SLOWebSocketDeliveryThreshold = time.Duration(GetEnvInt("SLO_WS_DELIVERY_LATENCY_MS", 1000)) * time.Millisecond
+	AppConfig.SLOWebSocketDeliveryTarget = getEnvFloat("SLO_WS_DELIVERY_TARGET", 0.99)
+	AppConfig.SLONotificationTarget = getEnvFloat("SLO_NOTIFICATION_SUCCESS_TARGET", 0.995)
+
+	// Read-only mode configuration
+	AppConfig.ReadOnly = getEnvBool("READ_ONLY", false)
+	AppConfig.ReadOnlyReason = getEnvString("READ_ONLY_REASON", "maintenance in progress; changes are paused")
+	AppConfig.ReadOnlyCheckInterval = time.Duration(GetEnvInt("READ_ONLY_CHECK_SECONDS", 5)) * time.Second
+
+	AppConfig.RuntimeConfigInterval = time.Duration(GetEnvInt("RUNTIME_CONFIG_INTERVAL_SECONDS", 10)) * time.Second
+
+	// Fault injection configuration
+	AppConfig.ChaosEnabled = getEnvBool("CHAOS_ENABLED", false) && AppConfig.Environment != "production"
+	AppConfig.DevDataEnabled = getEnvBool("DEV_DATA_ENABLED", false) && AppConfig.Environment != "production"
+
+	// Tracing configuration (standard OTel variable names where they exist)
+	AppConfig.OTelEndpoint = getEnvString("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	AppConfig.OTelEnabled = getEnvBool("OTEL_ENABLED", AppConfig.OTelEndpoint != "")
+	AppConfig.OTelServiceName = getEnvString("OTEL_SERVICE_NAME", "task-management-api")
+	AppConfig.OTelSampleRatio = getEnvFloat("OTEL_TRACES_SAMPLER_ARG", 1.0)
+	AppConfig.OTelExporterAuth = getEnvString("OTEL_EXPORTER_OTLP_AUTHORIZATION", "")
 
 	// Task configuration
 	AppConfig.TaskDefaultStatus = getEnvString("TASK_DEFAULT_STATUS", "pending")
@@ -66,10 +485,168 @@ func LoadConfig() error {
 	if AppConfig.TaskMaxDescLength <= 0 {
 		AppConfig.TaskMaxDescLength = 1000 // Fallback default if environment variable is invalid
 	}
+	AppConfig.ImportMaxBytes = int64(GetEnvInt("IMPORT_MAX_MB", 5)) << 20
+	AppConfig.ImportMaxRows = GetEnvInt("IMPORT_MAX_ROWS", 1000)
+	AppConfig.TransferAcceptWindow = time.Duration(GetEnvInt("TRANSFER_ACCEPT_WINDOW_HOURS", 24)) * time.Hour
+	AppConfig.TransferExpiryInterval = time.Duration(GetEnvInt("TRANSFER_EXPIRY_INTERVAL_SECONDS", 60)) * time.Second
+	AppConfig.TaskEditLockTTL = time.Duration(GetEnvInt("TASK_EDIT_LOCK_TTL_SECONDS", 120)) * time.Second
+	AppConfig.TaskEditLockMode = strings.ToLower(getEnvString("TASK_EDIT_LOCK_MODE", "warn"))
+	AppConfig.TaskArchiveAfter = time.Duration(GetEnvInt("TASK_ARCHIVE_AFTER_DAYS", 90)) * 24 * time.Hour
+	AppConfig.TaskArchiveInterval = time.Duration(GetEnvInt("TASK_ARCHIVE_INTERVAL_MINUTES", 60)) * time.Minute
+	AppConfig.OverdueScanHour = GetEnvInt("OVERDUE_SCAN_HOUR", 0)
+	AppConfig.TaskEscalationWithinHours = GetEnvInt("TASK_ESCALATION_WITHIN_HOURS", 24)
+	AppConfig.TaskEscalationInterval = time.Duration(GetEnvInt("TASK_ESCALATION_INTERVAL_MINUTES", 15)) * time.Minute
+	AppConfig.SubscriptionReconcileInterval = time.Duration(GetEnvInt("WS_SUBSCRIPTION_RECONCILE_SECONDS", 60)) * time.Second
+	AppConfig.JanitorInterval = time.Duration(GetEnvInt("JANITOR_INTERVAL_SECONDS", 60)) * time.Second
+	AppConfig.WSMinProtocolVersion = GetEnvInt("WS_MIN_PROTOCOL_VERSION", 1)
+	AppConfig.WSClientBuffer = GetEnvInt("WS_CLIENT_BUFFER", 256)
+	AppConfig.WSSlowClientPolicy = strings.ToLower(getEnvString("WS_SLOW_CLIENT_POLICY", "disconnect"))
+	AppConfig.WSBroadcastBuffer = GetEnvInt("WS_BROADCAST_BUFFER", 1024)
+
+	// Attachment configuration
+	AppConfig.AttachmentMaxBytes = int64(GetEnvInt("ATTACHMENT_MAX_MB", 10)) << 20
+	AppConfig.OCRInterval = time.Duration(GetEnvInt("OCR_INTERVAL_SECONDS", 10)) * time.Second
+	AppConfig.OCRMaxAttempts = GetEnvInt("OCR_MAX_ATTEMPTS", 3)
 
+	// Client error report configuration
+	AppConfig.ClientErrorSampleRate = getEnvFloat("CLIENT_ERROR_SAMPLE_RATE", 0.25)
+	AppConfig.ClientErrorRetention = time.Duration(GetEnvInt("CLIENT_ERROR_RETENTION_DAYS", 14)) * 24 * time.Hour
+
+	// Public intake configuration
+	AppConfig.IntakeOwnerID = getEnvString("INTAKE_OWNER_ID", "")
+	AppConfig.IntakeEmailToken = getEnvString("INTAKE_EMAIL_TOKEN", "")
+	AppConfig.ModerationAIEnabled = getEnvBool("MODERATION_AI_ENABLED", false)
+	AppConfig.ModerationExtraTerms = getEnvList("MODERATION_EXTRA_TERMS")
+
+	// Slack app configuration
+	AppConfig.SlackSigningSecret = getEnvString("SLACK_SIGNING_SECRET", "")
+	AppConfig.SlackBotToken = getEnvString("SLACK_BOT_TOKEN", "")
+
+	AppConfig.ComplianceSigningKey = getEnvString("COMPLIANCE_SIGNING_KEY", "")
+
+	// Warehouse export configuration (disabled when no destination is set)
+	AppConfig.ExportDestination = strings.ToLower(getEnvString("EXPORT_DESTINATION", ""))
+	AppConfig.ExportInterval = time.Duration(GetEnvInt("EXPORT_INTERVAL_MINUTES", 60)) * time.Minute
+	AppConfig.BigQueryProject = getEnvString("BIGQUERY_PROJECT", "")
+	AppConfig.BigQueryDataset = getEnvString("BIGQUERY_DATASET", "task_management")
+	AppConfig.SnowflakeAccountURL = getEnvString("SNOWFLAKE_ACCOUNT_URL", "")
+	AppConfig.SnowflakeToken = getEnvString("SNOWFLAKE_TOKEN", "")
+	AppConfig.SnowflakeTokenType = getEnvString("SNOWFLAKE_TOKEN_TYPE", "PROGRAMMATIC_ACCESS_TOKEN")
+	AppConfig.SnowflakeDatabase = getEnvString("SNOWFLAKE_DATABASE", "")
+	AppConfig.SnowflakeSchema = getEnvString("SNOWFLAKE_SCHEMA", "PUBLIC")
+	AppConfig.SnowflakeWarehouse = getEnvString("SNOWFLAKE_WAREHOUSE", "")
+	AppConfig.SnowflakeRole = getEnvString("SNOWFLAKE_ROLE", "")
+
+	problems := append(configErrors, AppConfig.Validate()...)
+	if len(problems) > 0 {
+		return &ConfigError{Problems: problems}
+	}
 	return nil
 }
 
+// Validate returns what is wrong with the configuration, each problem
+// naming the variable to fix
+func (c *Config) Validate() []string {
+	var problems []string
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+	oneOf := func(key, value string, allowed ...string) {
+		check(slices.Contains(allowed, value), "%s must be one of %s, not %q", key, strings.Join(allowed, ", "), value)
+	}
+	httpURL := func(key, value string) {
+		if value == "" {
+			return
+		}
+		u, err := url.Parse(value)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+			"%s must be an http or https URL, not %q", key, value)
+	}
+	fraction := func(key string, value float64) {
+		check(value >= 0 && value <= 1, "%s must be between 0 and 1, not %g", key, value)
+	}
+
+	// Server and database
+	check(c.ServerPort > 0 && c.ServerPort < 65536, "PORT must be between 1 and 65535, not %d", c.ServerPort)
+	check(c.DBPort > 0 && c.DBPort < 65536, "DB_PORT must be between 1 and 65535, not %d", c.DBPort)
+	oneOf("DB_SSLMODE", c.DBSSLMode, "disable", "allow", "prefer", "require", "verify-ca", "verify-full")
+	check(c.DBReplicaCheckInterval > 0, "DB_REPLICA_CHECK_SECONDS must be at least 1")
+	check(c.DBSlowQueryThreshold > 0, "DB_SLOW_QUERY_MS must be at least 1")
+	httpURL("PUBLIC_BASE_URL", c.PublicBaseURL)
+
+	// Auth: tokens signed with an empty or guessable secret can be forged
+	check(c.JWTSecret != "", "JWT_SECRET is required")
+	check(c.JWTSecret == "" || c.Environment != "production" || len(c.JWTSecret) >= 32,
+		"JWT_SECRET must be at least 32 characters in production")
+	check(c.JWTExpiration > 0, "JWT_EXPIRATION must be positive")
+	check(c.JWTRefreshExpiration >= c.JWTExpiration, "JWT_REFRESH_EXPIRATION must be at least JWT_EXPIRATION")
+	check(c.AccountSyncInterval > 0, "ACCOUNT_SYNC_INTERVAL_SECONDS must be at least 1")
+
+	// AI
+	oneOf("AI_PROVIDER", c.AIProvider, "gemini", "openai", "anthropic")
+	check(!c.AIEnabled || c.AIAPIKey != "",
+		"AI_API_KEY is required while AI_ENABLED is true; set AI_ENABLED=false to run without the AI features")
+	httpURL("AI_BASE_URL", c.AIBaseURL)
+	httpURL("AI_MODERATION_URL", c.AIModerationURL)
+	fraction("AI_DUPLICATE_THRESHOLD", c.AIDuplicateThreshold)
+	for _, spec := range c.AIPlanQuotas {
+		_, _, _, err := ParsePlanQuota(spec)
+		check(err == nil, "AI_PLAN_QUOTAS entry %v", err)
+	}
+
+	// Notifications
+	httpURL("SLACK_WEBHOOK_URL", c.SlackWebhookURL)
+	httpURL("DISCORD_WEBHOOK_URL", c.DiscordWebhookURL)
+	httpURL("TEAMS_WEBHOOK_URL", c.TeamsWebhookURL)
+	check(c.NotificationWorkers > 0, "NOTIFICATION_WORKERS must be at least 1")
+	check((c.SlackSigningSecret == "") == (c.SlackBotToken == ""),
+		"SLACK_SIGNING_SECRET and SLACK_BOT_TOKEN must be set together")
+
+	// Stores shared across replicas
+	for key, store := range map[string]string{
+		"RATE_LIMIT_STORE":          c.RateLimitStore,
+		"NOTIFICATION_DEDUPE_STORE": c.NotificationDedupeStore,
+		"TYPEAHEAD_CACHE_STORE":     c.TypeaheadCacheStore,
+		"TASK_COUNTERS_CACHE_STORE": c.TaskCountersCacheStore,
+		"AI_CACHE_STORE":            c.AICacheStore,
+	} {
+		oneOf(key, store, "memory", "redis")
+	}
+
+	oneOf("EXPORT_DESTINATION", c.ExportDestination, "", "bigquery", "snowflake")
+	oneOf("WS_SLOW_CLIENT_POLICY", c.WSSlowClientPolicy, "disconnect", "drop_oldest")
+	check(c.WSClientBuffer > 0, "WS_CLIENT_BUFFER must be at least 1")
+	oneOf("TASK_EDIT_LOCK_MODE", c.TaskEditLockMode, "warn", "reject")
+	check(c.TaskEditLockTTL > 0, "TASK_EDIT_LOCK_TTL_SECONDS must be at least 1")
+	check(c.TaskArchiveAfter >= 0, "TASK_ARCHIVE_AFTER_DAYS must not be negative")
+	check(c.TaskArchiveInterval > 0, "TASK_ARCHIVE_INTERVAL_MINUTES must be at least 1")
+	check(c.WSBroadcastBuffer > 0, "WS_BROADCAST_BUFFER must be at least 1")
+	fraction("OTEL_TRACES_SAMPLER_ARG", c.OTelSampleRatio)
+	fraction("CLIENT_ERROR_SAMPLE_RATE", c.ClientErrorSampleRate)
+	check(c.RuntimeConfigInterval > 0, "RUNTIME_CONFIG_INTERVAL_SECONDS must be at least 1")
+	check(c.OverdueScanHour < 24, "OVERDUE_SCAN_HOUR must be below 24, or negative to disable the scan")
+	check(c.TaskEscalationWithinHours >= 0 && c.TaskEscalationWithinHours <= 720, "TASK_ESCALATION_WITHIN_HOURS must be between 0 and 720")
+	check(c.TaskEscalationInterval > 0, "TASK_ESCALATION_INTERVAL_MINUTES must be at least 1")
+
+	slices.Sort(problems)
+	return problems
+}
+
+// WriteTimeout is the http.Server write timeout. It outlasts the longest
+// route budget so the Timeout middleware's 504 reaches the client instead of
+// the connection being cut mid-request.
+func (c *Config) WriteTimeout() time.Duration {
+	timeout := 15 * time.Second
+	for _, budget := range []time.Duration{c.TaskRouteTimeout, c.AIRouteTimeout, c.ExportRouteTimeout} {
+		if budget+5*time.Second > timeout {
+			timeout = budget + 5*time.Second
+		}
+	}
+	return timeout
+}
+
 // Helper functions to get environment variables with default values
 func getEnvString(key, defaultValue string) string {
 	if value, exists := os.LookupEnv(key); exists {
@@ -78,11 +655,87 @@ func getEnvString(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getEnvList splits a comma-separated variable, dropping empty entries
+func getEnvList(key string) []string {
+	return splitList(os.Getenv(key))
+}
+
+// getEnvListDefault is getEnvList, falling back to defaultValue when the
+// variable is unset
+func getEnvListDefault(key, defaultValue string) []string {
+	return splitList(getEnvString(key, defaultValue))
+}
+
+// ParsePlanQuota reads an AI plan quota written as plan:calls:tokens, such
+// as free:200:100000
+func ParsePlanQuota(spec string) (plan string, calls, tokens int64, err error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) != 3 || parts[0] == "" {
+		return "", 0, 0, fmt.Errorf("%q must be written as plan:calls:tokens", spec)
+	}
+	calls, err = strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || calls < 0 {
+		return "", 0, 0, fmt.Errorf("%q must have a non-negative number of calls", spec)
+	}
+	tokens, err = strconv.ParseInt(parts[2], 10, 64)
+	if err != nil || tokens < 0 {
+		return "", 0, 0, fmt.Errorf("%q must have a non-negative number of tokens", spec)
+	}
+	return parts[0], calls, tokens, nil
+}
+
+func splitList(list string) []string {
+	var values []string
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvBool, getEnvFloat, getEnvDuration and GetEnvInt take an empty
+// variable as unset, and record a value they cannot parse in configErrors
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		boolValue, err := strconv.ParseBool(value)
+		if err == nil {
+			return boolValue
+		}
+		configErrors = append(configErrors, fmt.Sprintf("%s must be true or false, not %q", key, value))
+	}
+	return defaultValue
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		floatValue, err := strconv.ParseFloat(value, 64)
+		if err == nil {
+			return floatValue
+		}
+		configErrors = append(configErrors, fmt.Sprintf("%s must be a number, not %q", key, value))
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		duration, err := time.ParseDuration(value)
+		if err == nil {
+			return duration
+		}
+		configErrors = append(configErrors, fmt.Sprintf("%s must be a duration such as 24h, not %q", key, value))
+	}
+	return defaultValue
+}
+
 func GetEnvInt(key string, defaultValue int) int {
-	if value, exists := os.LookupEnv(key); exists {
-		if intValue, err := strconv.Atoi(value); err == nil {
+	if value := os.Getenv(key); value != "" {
+		intValue, err := strconv.Atoi(value)
+		if err == nil {
 			return intValue
 		}
+		configErrors = append(configErrors, fmt.Sprintf("%s must be a whole number, not %q", key, value))
 	}
 	return defaultValue
 }
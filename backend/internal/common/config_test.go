@@ -0,0 +1,70 @@
+package common
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func loadTestConfig(t *testing.T, env map[string]string) error {
+	t.Helper()
+	saved := AppConfig
+	t.Cleanup(func() { AppConfig = saved })
+	for _, key := range []string{"JWT_SECRET", "AI_ENABLED", "AI_API_KEY", "PORT", "SERVER_PORT", "ENVIRONMENT"} {
+		t.Setenv(key, "")
+	}
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+	return LoadConfig()
+}
+
+func TestLoadConfigListsEveryProblem(t *testing.T) {
+	err := loadTestConfig(t, map[string]string{
+		"AI_ENABLED":       "true",
+		"PORT":             "eighty",
+		"RATE_LIMIT_STORE": "disk",
+		"JWT_EXPIRATION":   "1 day",
+		"AI_PLAN_QUOTAS":   "free:200:100000,pro:lots:0",
+	})
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) {
+		t.Fatalf("err = %v, want a ConfigError", err)
+	}
+	for _, want := range []string{
+		`PORT must be a whole number, not "eighty"`,
+		`JWT_EXPIRATION must be a duration such as 24h, not "1 day"`,
+		"JWT_SECRET is required",
+		"AI_API_KEY is required while AI_ENABLED is true; set AI_ENABLED=false to run without the AI features",
+		`RATE_LIMIT_STORE must be one of memory, redis, not "disk"`,
+		`AI_PLAN_QUOTAS entry "pro:lots:0" must have a non-negative number of calls`,
+	} {
+		if !slices.Contains(configErr.Problems, want) {
+			t.Errorf("problems lack %q: %q", want, configErr.Problems)
+		}
+	}
+}
+
+func TestLoadConfigEnablesAIWithAKey(t *testing.T) {
+	if err := loadTestConfig(t, map[string]string{"JWT_SECRET": "secret"}); err != nil {
+		t.Fatalf("defaults rejected: %v", err)
+	}
+	if AppConfig.AIEnabled || AppConfig.ServerPort != 8080 {
+		t.Fatalf("AIEnabled = %v, ServerPort = %d", AppConfig.AIEnabled, AppConfig.ServerPort)
+	}
+
+	if err := loadTestConfig(t, map[string]string{"JWT_SECRET": "secret", "AI_API_KEY": "key"}); err != nil {
+		t.Fatalf("err = %v", err)
+	}
+	if !AppConfig.AIEnabled {
+		t.Fatal("AI stays off with an API key set")
+	}
+}
+
+func TestValidateRequiresLongSecretInProduction(t *testing.T) {
+	err := loadTestConfig(t, map[string]string{"JWT_SECRET": "short", "ENVIRONMENT": "production"})
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) || !slices.Contains(configErr.Problems, "JWT_SECRET must be at least 32 characters in production") {
+		t.Fatalf("err = %v", err)
+	}
+}
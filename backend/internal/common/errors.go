@@ -1,43 +1,158 @@
 package common
 
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// AppError is the body of every error response, under the "error" key
 type AppError struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
-	Details string `json:"details,omitempty"`
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	Details   string `json:"details,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+	// Fields lists the rejected fields of an invalid request
+	Fields []FieldError `json:"fields,omitempty"`
+
+	// Status is the HTTP status the error is answered with
+	Status int `json:"-"`
+	// Extra is added to the response body next to the error, for data
+	// clients act on such as the unmet items of a checklist
+	Extra map[string]any `json:"-"`
 }
 
 func (e AppError) Error() string {
+	if e.Details != "" {
+		return e.Details
+	}
 	return e.Message
 }
 
-func NewNotFoundError(details string) AppError {
-	return AppError{
-		Code:    "NOT_FOUND",
-		Message: "Resource not found",
-		Details: details,
+// With returns a copy of the error whose response also carries key
+func (e AppError) With(key string, value any) AppError {
+	extra := make(map[string]any, len(e.Extra)+1)
+	for k, v := range e.Extra {
+		extra[k] = v
 	}
+	extra[key] = value
+	e.Extra = extra
+	return e
 }
 
-func NewUnauthorizedError(details string) AppError {
-	return AppError{
-		Code:    "UNAUTHORIZED",
-		Message: "Unauthorized access",
-		Details: details,
+// statusErrors are the code and message of each status, for errors that
+// carry only a status
+var statusErrors = map[int]AppError{
+	http.StatusBadRequest:            {Code: "INVALID_INPUT", Message: "Invalid input provided"},
+	http.StatusUnauthorized:          {Code: "UNAUTHORIZED", Message: "Unauthorized access"},
+	http.StatusForbidden:             {Code: "FORBIDDEN", Message: "Access denied"},
+	http.StatusNotFound:              {Code: "NOT_FOUND", Message: "Resource not found"},
+	http.StatusConflict:              {Code: "CONFLICT", Message: "Request conflicts with the current state"},
+	http.StatusRequestEntityTooLarge: {Code: "PAYLOAD_TOO_LARGE", Message: "Request is too large"},
+	http.StatusUpgradeRequired:       {Code: "UPGRADE_REQUIRED", Message: "Client upgrade required"},
+	http.StatusTooManyRequests:       {Code: "RATE_LIMITED", Message: "Too many requests"},
+	http.StatusInternalServerError:   {Code: "INTERNAL_ERROR", Message: "Internal server error"},
+	http.StatusNotImplemented:        {Code: "FEATURE_DISABLED", Message: "Feature disabled"},
+	http.StatusServiceUnavailable:    {Code: "UNAVAILABLE", Message: "Service unavailable"},
+	http.StatusGatewayTimeout:        {Code: "TIMEOUT", Message: "Request timed out"},
+}
+
+// NewError returns the error answered with status, explained by details
+func NewError(status int, details string) AppError {
+	e, ok := statusErrors[status]
+	if !ok {
+		text := http.StatusText(status)
+		e = AppError{Code: strings.ToUpper(strings.ReplaceAll(text, " ", "_")), Message: text}
 	}
+	e.Status = status
+	e.Details = details
+	return e
+}
+
+func NewNotFoundError(details string) AppError {
+	return NewError(http.StatusNotFound, details)
+}
+
+func NewUnauthorizedError(details string) AppError {
+	return NewError(http.StatusUnauthorized, details)
+}
+
+func NewForbiddenError(details string) AppError {
+	return NewError(http.StatusForbidden, details)
 }
 
 func NewInvalidInputError(details string) AppError {
-	return AppError{
-		Code:    "INVALID_INPUT",
-		Message: "Invalid input provided",
-		Details: details,
-	}
+	return NewError(http.StatusBadRequest, details)
+}
+
+func NewConflictError(details string) AppError {
+	return NewError(http.StatusConflict, details)
 }
 
 func NewInternalServerError(details string) AppError {
-	return AppError{
-		Code:    "INTERNAL_ERROR",
-		Message: "Internal server error",
-		Details: details,
+	return NewError(http.StatusInternalServerError, details)
+}
+
+func NewUnavailableError(details string) AppError {
+	return NewError(http.StatusServiceUnavailable, details)
+}
+
+func NewTimeoutError(details string) AppError {
+	return NewError(http.StatusGatewayTimeout, details)
+}
+
+func NewRateLimitError(details string) AppError {
+	return NewError(http.StatusTooManyRequests, details)
+}
+
+func NewFeatureDisabledError(details string) AppError {
+	return NewError(http.StatusNotImplemented, details)
+}
+
+func NewReadOnlyError(details string) AppError {
+	e := NewError(http.StatusServiceUnavailable, details)
+	e.Code = "READ_ONLY"
+	e.Message = "Service is read-only"
+	return e
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   []registeredError
+)
+
+type registeredError struct {
+	target error
+	status int
+}
+
+// RegisterError answers errors matching target, as errors.Is reports, with
+// status. Packages register their sentinel errors when they are loaded.
+func RegisterError(target error, status int) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry = append(registry, registeredError{target: target, status: status})
+}
+
+// ToAppError returns the response for err: itself when it is an AppError,
+// its registered status with its message as details, or else an internal
+// error that reveals nothing of it. The second result reports whether err
+// was expected.
+func ToAppError(err error) (AppError, bool) {
+	var appErr AppError
+	if errors.As(err, &appErr) {
+		if appErr.Status == 0 {
+			appErr.Status = http.StatusInternalServerError
+		}
+		return appErr, true
+	}
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	for _, r := range registry {
+		if errors.Is(err, r.target) {
+			return NewError(r.status, err.Error()), true
+		}
 	}
+	return NewInternalServerError(""), false
 }
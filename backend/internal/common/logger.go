@@ -9,6 +9,9 @@ import (
 
 var Logger *zap.Logger
 
+// LogLevel is the level of Logger, which can change while the server runs
+var LogLevel = zap.NewAtomicLevel()
+
 // InitLogger initializes the global logger
 func InitLogger() error {
 	config := zap.NewProductionConfig()
@@ -19,13 +22,14 @@ func InitLogger() error {
 
 	// Set log level based on environment
 	if AppConfig.Environment == "development" {
-		config.Level = zap.NewAtomicLevelAt(zap.DebugLevel)
+		LogLevel.SetLevel(zap.DebugLevel)
 		config.Development = true
 		config.Encoding = "console"
 	} else {
-		config.Level = zap.NewAtomicLevelAt(zap.InfoLevel)
+		LogLevel.SetLevel(zap.InfoLevel)
 		config.Encoding = "json"
 	}
+	config.Level = LogLevel
 
 	// Create the logger
 	var err error
@@ -1,6 +1,12 @@
 package common
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -11,32 +17,155 @@ import (
 // RequestIDKey is the key used to store request ID in context
 const RequestIDKey = "RequestID"
 
-// CORS middleware
-func CORSMiddleware() gin.HandlerFunc {
+// CORS configures cross-origin access. Origins are matched exactly, apart
+// from case and a trailing slash; "*" admits every origin, but without
+// credentials, which browsers refuse alongside a wildcard.
+type CORS struct {
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	ExposedHeaders []string
+	// MaxAge is how long browsers may cache a preflight answer
+	MaxAge time.Duration
+}
+
+// CORSMiddleware answers cross-origin requests from allowed origins, echoing
+// the origin so that credentialed requests work. Preflight requests are
+// answered here; those of other origins get no CORS headers, which browsers
+// treat as a refusal.
+func CORSMiddleware(cors CORS) gin.HandlerFunc {
+	origins := make(map[string]bool, len(cors.AllowedOrigins))
+	anyOrigin := false
+	for _, origin := range cors.AllowedOrigins {
+		if origin == "*" {
+			anyOrigin = true
+		}
+		origins[normalizeOrigin(origin)] = true
+	}
+	methods := strings.Join(cors.AllowedMethods, ", ")
+	headers := strings.Join(cors.AllowedHeaders, ", ")
+	exposed := strings.Join(cors.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(cors.MaxAge.Seconds()))
+
 	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Origin, Authorization, Content-Type")
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+		header := c.Writer.Header()
+		header.Add("Vary", "Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
+		switch {
+		case origins[normalizeOrigin(origin)]:
+			header.Set("Access-Control-Allow-Origin", origin)
+			header.Set("Access-Control-Allow-Credentials", "true")
+		case anyOrigin:
+			header.Set("Access-Control-Allow-Origin", "*")
+		default:
+			if preflight {
+				c.AbortWithStatus(http.StatusNoContent)
+				return
+			}
+			c.Next()
 			return
 		}
 
-		c.Next()
+		if !preflight {
+			if exposed != "" {
+				header.Set("Access-Control-Expose-Headers", exposed)
+			}
+			c.Next()
+			return
+		}
+		header.Add("Vary", "Access-Control-Request-Method")
+		header.Add("Vary", "Access-Control-Request-Headers")
+		header.Set("Access-Control-Allow-Methods", methods)
+		if headers != "" {
+			header.Set("Access-Control-Allow-Headers", headers)
+		}
+		if cors.MaxAge > 0 {
+			header.Set("Access-Control-Max-Age", maxAge)
+		}
+		c.AbortWithStatus(http.StatusNoContent)
 	}
 }
 
-// RequestID middleware adds a unique ID to each request
+func normalizeOrigin(origin string) string {
+	return strings.ToLower(strings.TrimSuffix(origin, "/"))
+}
+
+// RequestID middleware adds a unique ID to each request, keeping the one a
+// proxy in front set in X-Request-ID
 func RequestID() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		requestID := uuid.New().String()
+		requestID := c.GetHeader("X-Request-ID")
+		if requestID == "" || len(requestID) > 128 {
+			requestID = uuid.New().String()
+		}
 		c.Set(RequestIDKey, requestID)
 		c.Header("X-Request-ID", requestID)
 		c.Next()
 	}
 }
 
+// ErrorHandler answers the last error a handler pushed with c.Error, when
+// the handler wrote no response: as {"error": AppError} with the error's
+// status. Unexpected errors are logged and answered with a bare 500.
+func ErrorHandler(logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		err := c.Errors.Last().Err
+		appErr, expected := ToAppError(err)
+		if !expected {
+			logger.Error("Unhandled request error",
+				zap.String("path", c.FullPath()),
+				zap.String("request_id", c.GetString(RequestIDKey)),
+				zap.Error(err),
+			)
+		}
+		writeError(c, appErr)
+	}
+}
+
+// ResponseStatus is the status of the response, including that of an error
+// ErrorHandler is yet to write, for middleware inside it
+func ResponseStatus(c *gin.Context) int {
+	if len(c.Errors) > 0 && !c.Writer.Written() {
+		appErr, _ := ToAppError(c.Errors.Last().Err)
+		return appErr.Status
+	}
+	return c.Writer.Status()
+}
+
+// Abort stops the chain and answers err at once, for middleware that may
+// run without ErrorHandler
+func Abort(c *gin.Context, err error) {
+	appErr, _ := ToAppError(err)
+	writeError(c, appErr)
+	c.Abort()
+}
+
+func writeError(c *gin.Context, appErr AppError) {
+	c.JSON(appErr.Status, ErrorBody(c, appErr))
+}
+
+// ErrorBody is the response body of appErr, for handlers that report
+// errors other than as a response, such as in an event stream
+func ErrorBody(c *gin.Context, appErr AppError) gin.H {
+	appErr.RequestID = c.GetString(RequestIDKey)
+	body := gin.H{"error": appErr}
+	for k, v := range appErr.Extra {
+		body[k] = v
+	}
+	return body
+}
+
 func RequestLogger(logger *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
@@ -52,3 +181,77 @@ func RequestLogger(logger *zap.Logger) gin.HandlerFunc {
 		)
 	}
 }
+
+// Timeout middleware derives a deadline for the route and propagates it to
+// downstream services through the request context. If the budget expires
+// before the handler has written a response, a 504 is returned instead.
+// Responses the handler writes after the deadline (typically a 500 for the
+// aborted query) are discarded so the 504 wins.
+func Timeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		writer := c.Writer
+		c.Writer = &deadlineWriter{ResponseWriter: writer, ctx: ctx}
+
+		c.Next()
+
+		c.Writer = writer
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+			Abort(c, NewTimeoutError(fmt.Sprintf("request exceeded %s budget", timeout)))
+		}
+	}
+}
+
+// FeatureDisabled answers 501 in place of the routes of a feature this
+// deployment runs without
+func FeatureDisabled(details string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		Abort(c, NewFeatureDisabledError(details))
+	}
+}
+
+// deadlineWriter drops responses started after the request deadline
+type deadlineWriter struct {
+	gin.ResponseWriter
+	ctx context.Context
+}
+
+func (w *deadlineWriter) expired() bool {
+	return !w.ResponseWriter.Written() && errors.Is(w.ctx.Err(), context.DeadlineExceeded)
+}
+
+func (w *deadlineWriter) WriteHeader(code int) {
+	if w.expired() {
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *deadlineWriter) WriteHeaderNow() {
+	if w.expired() {
+		return
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *deadlineWriter) Write(data []byte) (int, error) {
+	if w.expired() {
+		return len(data), nil
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *deadlineWriter) WriteString(s string) (int, error) {
+	if w.expired() {
+		return len(s), nil
+	}
+	return w.ResponseWriter.WriteString(s)
+}
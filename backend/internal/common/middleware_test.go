@@ -0,0 +1,238 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func newTimeoutRouter(timeout time.Duration, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/", Timeout(timeout), handler)
+	return router
+}
+
+func TestTimeoutReturns504WhenBudgetExpires(t *testing.T) {
+	router := newTimeoutRouter(10*time.Millisecond, func(c *gin.Context) {
+		<-c.Request.Context().Done()
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusGatewayTimeout {
+		t.Fatalf("status = %d, want 504", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "TIMEOUT") {
+		t.Fatalf("body = %s, want timeout error envelope", w.Body.String())
+	}
+}
+
+func TestTimeoutDiscardsLateHandlerError(t *testing.T) {
+	router := newTimeoutRouter(10*time.Millisecond, func(c *gin.Context) {
+		<-c.Request.Context().Done()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query cancelled"})
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusGatewayTimeout {
+		t.Fatalf("status = %d, want 504", w.Code)
+	}
+	if strings.Contains(w.Body.String(), "query cancelled") {
+		t.Fatalf("late handler body leaked into response: %s", w.Body.String())
+	}
+}
+
+func TestTimeoutPassesThroughFastResponses(t *testing.T) {
+	router := newTimeoutRouter(time.Second, func(c *gin.Context) {
+		if _, ok := c.Request.Context().Deadline(); !ok {
+			t.Error("request context has no deadline")
+		}
+		c.JSON(http.StatusCreated, gin.H{"ok": true})
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201", w.Code)
+	}
+}
+
+func TestWriteTimeoutOutlastsRouteBudgets(t *testing.T) {
+	cfg := Config{
+		TaskRouteTimeout:   2 * time.Second,
+		AIRouteTimeout:     30 * time.Second,
+		ExportRouteTimeout: 60 * time.Second,
+	}
+	if got := cfg.WriteTimeout(); got <= cfg.ExportRouteTimeout {
+		t.Fatalf("WriteTimeout = %s, want more than the %s export budget", got, cfg.ExportRouteTimeout)
+	}
+	if got := (&Config{}).WriteTimeout(); got != 15*time.Second {
+		t.Fatalf("default WriteTimeout = %s, want 15s", got)
+	}
+}
+
+func TestFeatureDisabledReturns501Envelope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/ai/suggest", FeatureDisabled("AI features are disabled on this deployment"))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/ai/suggest", nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Fatalf("status = %d, want 501", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `"code":"FEATURE_DISABLED"`) {
+		t.Fatalf("body = %s, want feature disabled error envelope", w.Body.String())
+	}
+}
+
+var errWidgetNotFound = errors.New("widget not found")
+
+func init() {
+	RegisterError(errWidgetNotFound, http.StatusNotFound)
+}
+
+func TestErrorHandlerWritesEnvelope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestID(), ErrorHandler(zap.NewNop()))
+	router.GET("/app-error", func(c *gin.Context) {
+		_ = c.Error(NewConflictError("version is stale").With("current", 3))
+	})
+	router.GET("/sentinel", func(c *gin.Context) {
+		_ = c.Error(fmt.Errorf("loading widget 7: %w", errWidgetNotFound))
+	})
+	router.GET("/unexpected", func(c *gin.Context) {
+		_ = c.Error(errors.New("connection refused"))
+	})
+
+	for path, want := range map[string]struct {
+		status int
+		code   string
+	}{
+		"/app-error":  {http.StatusConflict, "CONFLICT"},
+		"/sentinel":   {http.StatusNotFound, "NOT_FOUND"},
+		"/unexpected": {http.StatusInternalServerError, "INTERNAL_ERROR"},
+	} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-Request-ID", "req-1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var body struct {
+			Error   AppError `json:"error"`
+			Current int      `json:"current"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s: body = %s: %v", path, w.Body.String(), err)
+		}
+		if w.Code != want.status || body.Error.Code != want.code || body.Error.RequestID != "req-1" {
+			t.Fatalf("%s: status = %d, body = %s", path, w.Code, w.Body.String())
+		}
+		switch path {
+		case "/app-error":
+			if body.Current != 3 {
+				t.Fatalf("extra fields missing: %s", w.Body.String())
+			}
+		case "/sentinel":
+			if body.Error.Details != "loading widget 7: widget not found" {
+				t.Fatalf("sentinel details = %q", body.Error.Details)
+			}
+		case "/unexpected":
+			if strings.Contains(w.Body.String(), "connection refused") {
+				t.Fatalf("unexpected error leaked: %s", w.Body.String())
+			}
+		}
+	}
+}
+
+func TestErrorHandlerKeepsWrittenResponses(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ErrorHandler(zap.NewNop()))
+	router.GET("/", func(c *gin.Context) {
+		_ = c.Error(errWidgetNotFound)
+		c.JSON(http.StatusAccepted, gin.H{"ok": true})
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusAccepted || strings.Contains(w.Body.String(), "error") {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+}
+
+func newCORSRouter(origins ...string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(CORSMiddleware(CORS{
+		AllowedOrigins: origins,
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"Authorization", "Content-Type"},
+		ExposedHeaders: []string{"X-Request-ID"},
+		MaxAge:         10 * time.Minute,
+	}))
+	router.GET("/tasks", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return router
+}
+
+func corsRequest(router *gin.Engine, method, origin string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/tasks", nil)
+	req.Header.Set("Origin", origin)
+	if method == http.MethodOptions {
+		req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORSEchoesAllowedOrigins(t *testing.T) {
+	router := newCORSRouter("https://tasks.example.com/")
+
+	w := corsRequest(router, http.MethodGet, "https://Tasks.example.com")
+	if w.Header().Get("Access-Control-Allow-Origin") != "https://Tasks.example.com" ||
+		w.Header().Get("Access-Control-Allow-Credentials") != "true" ||
+		w.Header().Get("Access-Control-Expose-Headers") != "X-Request-ID" {
+		t.Fatalf("headers = %v", w.Header())
+	}
+
+	w = corsRequest(router, http.MethodOptions, "https://tasks.example.com")
+	if w.Code != http.StatusNoContent ||
+		w.Header().Get("Access-Control-Allow-Methods") != "GET, POST" ||
+		w.Header().Get("Access-Control-Allow-Headers") != "Authorization, Content-Type" ||
+		w.Header().Get("Access-Control-Max-Age") != "600" {
+		t.Fatalf("preflight = %d %v", w.Code, w.Header())
+	}
+}
+
+func TestCORSIgnoresOtherOrigins(t *testing.T) {
+	router := newCORSRouter("https://tasks.example.com")
+
+	for _, method := range []string{http.MethodGet, http.MethodOptions} {
+		w := corsRequest(router, method, "https://evil.example.com")
+		if w.Header().Get("Access-Control-Allow-Origin") != "" {
+			t.Fatalf("%s allowed another origin: %v", method, w.Header())
+		}
+	}
+	if w := corsRequest(router, http.MethodGet, "https://evil.example.com"); w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want the request served without CORS headers", w.Code)
+	}
+}
+
+func TestCORSWildcardOmitsCredentials(t *testing.T) {
+	w := corsRequest(newCORSRouter("*"), http.MethodGet, "https://any.example.com")
+	if w.Header().Get("Access-Control-Allow-Origin") != "*" || w.Header().Get("Access-Control-Allow-Credentials") != "" {
+		t.Fatalf("headers = %v", w.Header())
+	}
+}
@@ -0,0 +1,133 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// RateLimit is a token bucket: Burst requests at once, refilled at
+// PerMinute requests per minute
+type RateLimit struct {
+	PerMinute int
+	Burst     int
+}
+
+func (l RateLimit) perSecond() float64 {
+	return float64(l.PerMinute) / 60
+}
+
+// RateLimitStore takes a token from the bucket identified by key. When no
+// token is available it returns how long until one will be.
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, limit RateLimit) (allowed bool, retryAfter time.Duration, err error)
+}
+
+// RateLimiter limits requests per authenticated user, or per client IP for
+// anonymous requests. Buckets are scoped by name so each route group has
+// its own budget. If the store fails, requests are let through.
+func RateLimiter(name string, limit RateLimit, store RateLimitStore, logger *zap.Logger) gin.HandlerFunc {
+	return DynamicRateLimiter(name, func() RateLimit { return limit }, store, logger)
+}
+
+// DynamicRateLimiter is RateLimiter with the limit read on every request,
+// so that it can change while the server runs
+func DynamicRateLimiter(name string, current func() RateLimit, store RateLimitStore, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := current()
+		if limit.PerMinute <= 0 {
+			c.Next()
+			return
+		}
+
+		subject := "ip:" + c.ClientIP()
+		if userID := c.GetString("user_id"); userID != "" {
+			subject = "user:" + userID
+		}
+
+		allowed, retryAfter, err := store.Take(c.Request.Context(), "ratelimit:"+name+":"+subject, limit)
+		if err != nil {
+			logger.Warn("Rate limit store unavailable, allowing request", zap.String("limit", name), zap.Error(err))
+			c.Next()
+			return
+		}
+
+		if !allowed {
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			if seconds < 1 {
+				seconds = 1
+			}
+			c.Header("Retry-After", strconv.Itoa(seconds))
+			Abort(c, NewRateLimitError(fmt.Sprintf("%s limit of %d requests per minute exceeded", name, limit.PerMinute)))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+type memoryBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// MemoryRateLimitStore keeps buckets in process memory. Limits are per
+// instance, so use the Redis store when running more than one replica.
+type MemoryRateLimitStore struct {
+	mu      sync.Mutex
+	buckets map[string]*memoryBucket
+}
+
+// NewMemoryRateLimitStore returns an empty store. Idle buckets are only
+// dropped by Sweep.
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: make(map[string]*memoryBucket)}
+}
+
+func (s *MemoryRateLimitStore) Take(_ context.Context, key string, limit RateLimit) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	burst := float64(limit.Burst)
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &memoryBucket{tokens: burst, last: now}
+		s.buckets[key] = b
+	}
+
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*limit.perSecond())
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0, nil
+	}
+	wait := time.Duration((1 - b.tokens) / limit.perSecond() * float64(time.Second))
+	return false, wait, nil
+}
+
+// idleBucketAge is how long a bucket is kept untouched; any realistic
+// bucket has refilled completely by then
+const idleBucketAge = 10 * time.Minute
+
+// Sweep drops the buckets that have not been touched for idleBucketAge
+func (s *MemoryRateLimitStore) Sweep(context.Context) (int, error) {
+	cutoff := time.Now().Add(-idleBucketAge)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	swept := 0
+	for key, b := range s.buckets {
+		if b.last.Before(cutoff) {
+			delete(s.buckets, key)
+			swept++
+		}
+	}
+	return swept, nil
+}
@@ -0,0 +1,136 @@
+package common
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func TestMemoryRateLimitStoreExhaustsBurst(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	limit := RateLimit{PerMinute: 60, Burst: 2}
+
+	for i := 0; i < 2; i++ {
+		allowed, _, err := store.Take(context.Background(), "k", limit)
+		if err != nil || !allowed {
+			t.Fatalf("request %d: allowed=%v err=%v, want allowed", i, allowed, err)
+		}
+	}
+
+	allowed, retryAfter, err := store.Take(context.Background(), "k", limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if allowed {
+		t.Fatal("third request allowed, want rejected")
+	}
+	if retryAfter <= 0 || retryAfter > time.Second {
+		t.Fatalf("retryAfter = %s, want (0, 1s]", retryAfter)
+	}
+
+	if allowed, _, _ := store.Take(context.Background(), "other", limit); !allowed {
+		t.Fatal("separate key shares a bucket")
+	}
+}
+
+func newRateLimitedRouter(store RateLimitStore, userID string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if userID != "" {
+			c.Set("user_id", userID)
+		}
+	})
+	router.GET("/", RateLimiter("tasks", RateLimit{PerMinute: 1, Burst: 1}, store, zap.NewNop()), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func TestRateLimiterReturns429WithRetryAfter(t *testing.T) {
+	router := newRateLimitedRouter(NewMemoryRateLimitStore(), "user-1")
+
+	first := httptest.NewRecorder()
+	router.ServeHTTP(first, httptest.NewRequest(http.MethodGet, "/", nil))
+	if first.Code != http.StatusOK {
+		t.Fatalf("first request status = %d, want 200", first.Code)
+	}
+
+	second := httptest.NewRecorder()
+	router.ServeHTTP(second, httptest.NewRequest(http.MethodGet, "/", nil))
+	if second.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request status = %d, want 429", second.Code)
+	}
+	if got := second.Header().Get("Retry-After"); got != "60" {
+		t.Fatalf("Retry-After = %q, want 60", got)
+	}
+}
+
+func TestRateLimiterBucketsPerUser(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+
+	for _, user := range []string{"user-1", "user-2"} {
+		w := httptest.NewRecorder()
+		newRateLimitedRouter(store, user).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s status = %d, want 200", user, w.Code)
+		}
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, RateLimit) (bool, time.Duration, error) {
+	return false, 0, context.DeadlineExceeded
+}
+
+func TestRateLimiterFailsOpen(t *testing.T) {
+	router := newRateLimitedRouter(failingStore{}, "user-1")
+
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d status = %d, want 200 when the store fails", i, w.Code)
+		}
+	}
+}
+
+func TestRedisRateLimitStore(t *testing.T) {
+	server := miniredis.RunT(t)
+	port, err := strconv.Atoi(server.Port())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewRedisClient(server.Host(), port, "", 0)
+	defer client.Close()
+	store := NewRedisRateLimitStore(client)
+	limit := RateLimit{PerMinute: 60, Burst: 1}
+
+	if err := store.Ping(context.Background()); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+
+	allowed, _, err := store.Take(context.Background(), "ratelimit:test", limit)
+	if err != nil || !allowed {
+		t.Fatalf("first take: allowed=%v err=%v, want allowed", allowed, err)
+	}
+
+	allowed, retryAfter, err := store.Take(context.Background(), "ratelimit:test", limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if allowed {
+		t.Fatal("second take allowed, want rejected")
+	}
+	if retryAfter <= 0 || retryAfter > time.Second {
+		t.Fatalf("retryAfter = %s, want (0, 1s]", retryAfter)
+	}
+}
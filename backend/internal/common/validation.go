@@ -1,8 +1,15 @@
 package common
 
 import (
+	"encoding/json"
+	"errors"
+	"reflect"
 	"regexp"
 	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
 )
 
 var emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
@@ -27,3 +34,132 @@ func ValidateLength(str string, min, max int) bool {
 func ValidateRange(num, min, max int) bool {
 	return num >= min && num <= max
 }
+
+// EscapeLike makes LIKE wildcards in s match literally
+func EscapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// FieldError says why one field of a request was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func init() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	// Fields are reported by the names clients send
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "form"} {
+			name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return field.Name
+	})
+	_ = v.RegisterValidation("future", func(fl validator.FieldLevel) bool {
+		t, ok := fl.Field().Interface().(time.Time)
+		return ok && t.After(time.Now())
+	})
+}
+
+// NewValidationError explains why a request could not be bound, field by
+// field where the error says which
+func NewValidationError(err error) AppError {
+	var fields []FieldError
+	var invalid validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	var timeErr *time.ParseError
+	switch {
+	case errors.As(err, &invalid):
+		for _, fe := range invalid {
+			fields = append(fields, translateFieldError(fe))
+		}
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		fields = append(fields, FieldError{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Message: typeErr.Field + " must be " + jsonKind(typeErr.Type),
+		})
+	case errors.As(err, &timeErr):
+		return NewInvalidInputError("times must be RFC 3339, such as 2024-03-10T15:04:05Z")
+	default:
+		return NewInvalidInputError(err.Error())
+	}
+
+	messages := make([]string, len(fields))
+	for i, f := range fields {
+		messages[i] = f.Message
+	}
+	appErr := NewInvalidInputError(strings.Join(messages, "; "))
+	appErr.Fields = fields
+	return appErr
+}
+
+func translateFieldError(fe validator.FieldError) FieldError {
+	// The namespace starts with the request's type name
+	field := fe.Namespace()
+	if _, rest, ok := strings.Cut(field, "."); ok {
+		field = rest
+	}
+	return FieldError{Field: field, Rule: fe.Tag(), Message: field + " " + ruleMessage(fe)}
+}
+
+func ruleMessage(fe validator.FieldError) string {
+	param := fe.Param()
+	unit := ""
+	switch fe.Kind() {
+	case reflect.String:
+		unit = " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		unit = " items"
+	}
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "min":
+		return "must be at least " + param + unit
+	case "max":
+		return "must be at most " + param + unit
+	case "len":
+		return "must be exactly " + param + unit
+	case "oneof":
+		return "must be one of " + strings.Join(strings.Fields(param), ", ")
+	case "future":
+		return "must be in the future"
+	case "gtfield", "gtefield":
+		return "must be after " + param
+	case "url", "http_url":
+		return "must be a URL"
+	case "uuid", "uuid4":
+		return "must be a UUID"
+	}
+	return "fails the " + fe.Tag() + " rule"
+}
+
+func jsonKind(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	}
+	return "an object"
+}
@@ -0,0 +1,82 @@
+package common
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type widgetRequest struct {
+	Name  string    `json:"name" binding:"required,max=5"`
+	Kind  string    `json:"kind" binding:"omitempty,oneof=bolt nut"`
+	Due   time.Time `json:"due_date" binding:"required,future"`
+	Tags  []string  `json:"tags" binding:"max=2,dive,max=3"`
+	Count int       `json:"count" binding:"min=0"`
+}
+
+func bindWidget(t *testing.T, body string) AppError {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	var req widgetRequest
+	err := c.ShouldBindJSON(&req)
+	if err == nil {
+		t.Fatalf("body %s bound without error", body)
+	}
+	return NewValidationError(err)
+}
+
+func TestValidationErrorListsFields(t *testing.T) {
+	past := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	appErr := bindWidget(t, `{"name": "toolong", "kind": "gear", "due_date": "`+past+`", "tags": ["a", "long"]}`)
+
+	if appErr.Status != http.StatusBadRequest || appErr.Code != "INVALID_INPUT" {
+		t.Fatalf("error = %+v", appErr)
+	}
+	want := map[string]FieldError{
+		"name":     {Field: "name", Rule: "max", Message: "name must be at most 5 characters"},
+		"kind":     {Field: "kind", Rule: "oneof", Message: "kind must be one of bolt, nut"},
+		"due_date": {Field: "due_date", Rule: "future", Message: "due_date must be in the future"},
+		"tags[1]":  {Field: "tags[1]", Rule: "max", Message: "tags[1] must be at most 3 characters"},
+	}
+	if len(appErr.Fields) != len(want) {
+		t.Fatalf("fields = %+v", appErr.Fields)
+	}
+	for _, f := range appErr.Fields {
+		if want[f.Field] != f {
+			t.Fatalf("field %s = %+v, want %+v", f.Field, f, want[f.Field])
+		}
+	}
+	if !strings.Contains(appErr.Details, "name must be at most 5 characters") {
+		t.Fatalf("details = %q", appErr.Details)
+	}
+}
+
+func TestValidationErrorExplainsMalformedBodies(t *testing.T) {
+	appErr := bindWidget(t, `{"name": "bolt", "due_date": "2030-01-01T00:00:00Z", "count": "three"}`)
+	if len(appErr.Fields) != 1 || appErr.Fields[0] != (FieldError{Field: "count", Rule: "type", Message: "count must be an integer"}) {
+		t.Fatalf("fields = %+v", appErr.Fields)
+	}
+
+	appErr = bindWidget(t, `{"name": "bolt", "due_date": "tomorrow"}`)
+	if appErr.Fields != nil || !strings.Contains(appErr.Details, "RFC 3339") {
+		t.Fatalf("error = %+v", appErr)
+	}
+
+	appErr = bindWidget(t, `{"name": `)
+	if appErr.Code != "INVALID_INPUT" || appErr.Fields != nil {
+		t.Fatalf("error = %+v", appErr)
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	if got, want := EscapeLike(`50%_off\`), `50\%\_off\\`; got != want {
+		t.Fatalf("EscapeLike = %q, want %q", got, want)
+	}
+}
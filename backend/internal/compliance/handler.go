@@ -0,0 +1,92 @@
+package compliance
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+	"go.uber.org/zap"
+)
+
+// pendingRetryAfter is how long, in seconds, clients are told to wait
+// before asking again for a report being generated
+const pendingRetryAfter = "10"
+
+type Handler struct {
+	service *Service
+	logger  *zap.Logger
+}
+
+func NewHandler(service *Service, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// Report answers 202 while the report is generated; ask again for it. Once
+// completed it is returned as JSON, or as a PDF with format=pdf, with its
+// signature in X-Report-Signature.
+func (h *Handler) Report(c *gin.Context) {
+	var params ReportParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		_ = c.Error(common.NewValidationError(err))
+		return
+	}
+	if params.Format != "" && params.Format != "json" && params.Format != "pdf" {
+		_ = c.Error(common.NewInvalidInputError("format must be json or pdf"))
+		return
+	}
+
+	report, err := h.service.Report(c.Request.Context(), params, c.GetString("user_id"))
+	if err != nil {
+		if errors.Is(err, ErrInvalidPeriod) {
+			_ = c.Error(common.NewInvalidInputError(err.Error()))
+			return
+		}
+		h.logger.Error("Failed to load compliance report", zap.Error(err))
+		_ = c.Error(common.NewInternalServerError("failed to load compliance report"))
+		return
+	}
+
+	if report.Status != models.ComplianceReportCompleted {
+		c.Header("Retry-After", pendingRetryAfter)
+		c.JSON(http.StatusAccepted, PendingResponse{
+			ID:        report.ID,
+			Status:    report.Status,
+			From:      report.PeriodFrom,
+			To:        report.PeriodTo,
+			CreatedAt: report.CreatedAt,
+		})
+		return
+	}
+
+	c.Header("X-Report-Signature", report.Signature)
+	if params.Format != "pdf" {
+		c.JSON(http.StatusOK, ReportResponse{
+			ID:          report.ID,
+			Report:      json.RawMessage(report.Content),
+			Algorithm:   signatureAlgorithm,
+			Signature:   report.Signature,
+			CompletedAt: report.CompletedAt,
+		})
+		return
+	}
+
+	var content Content
+	if err := json.Unmarshal([]byte(report.Content), &content); err != nil {
+		h.logger.Error("Failed to decode compliance report", zap.String("report_id", report.ID), zap.Error(err))
+		_ = c.Error(common.NewInternalServerError("failed to render compliance report"))
+		return
+	}
+	name := fmt.Sprintf("compliance-report-%s-%s.pdf",
+		report.PeriodFrom.Format(time.DateOnly), report.PeriodTo.AddDate(0, 0, -1).Format(time.DateOnly))
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	c.Data(http.StatusOK, "application/pdf", renderPDF(reportLines(report, &content)))
+}
@@ -0,0 +1,155 @@
+package compliance
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestRouter(t *testing.T) (*gin.Engine, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := NewHandler(NewService(db, "test-key", zap.NewNop()), zap.NewNop())
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(common.ErrorHandler(zap.NewNop()))
+	router.GET("/report", func(c *gin.Context) { c.Set("user_id", "admin-1") }, handler.Report)
+	return router, mock
+}
+
+func TestReportReturnsCompletedReportWithSignature(t *testing.T) {
+	router, mock := newTestRouter(t)
+	content := `{"from":"2026-01-01T00:00:00Z","to":"2026-02-01T00:00:00Z","generated_at":"2026-02-01T00:05:00Z",` +
+		`"access":{"entries":[],"truncated":false},"permission_changes":{"entries":[{"at":"2026-01-05T10:00:00Z",` +
+		`"type":"role.granted","actor_id":"user-1","data":{"role":"reporting"}}],"truncated":false},` +
+		`"data_exports":{"entries":[],"truncated":false},"deletions":{"entries":[],"truncated":false}}`
+	mac := hmac.New(sha256.New, []byte("test-key"))
+	mac.Write([]byte(content))
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	for _, format := range []string{"json", "pdf"} {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO "compliance_reports" .* ON CONFLICT \("period_from","period_to"\) DO NOTHING`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectCommit()
+		mock.ExpectQuery(`SELECT \* FROM "compliance_reports" WHERE period_from = \$1 AND period_to = \$2`).
+			WithArgs(from, to, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "period_from", "period_to", "status", "content", "signature"}).
+				AddRow("report-1", from, to, "completed", content, signature))
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/report?from=2026-01-01&to=2026-01-31&format="+format, nil))
+		if w.Code != http.StatusOK || w.Header().Get("X-Report-Signature") != signature {
+			t.Fatalf("%s: status = %d, signature %q, want 200 and %s", format, w.Code, w.Header().Get("X-Report-Signature"), signature)
+		}
+
+		if format == "pdf" {
+			if !bytes.HasPrefix(w.Body.Bytes(), []byte("%PDF-")) || !bytes.Contains(w.Body.Bytes(), []byte("role.granted  actor=user-1")) {
+				t.Fatalf("pdf = %q, want the report's entries", w.Body.String())
+			}
+			continue
+		}
+		var resp struct {
+			Report    json.RawMessage `json:"report"`
+			Signature string          `json:"signature"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		mac.Reset()
+		mac.Write(resp.Report)
+		if got := hex.EncodeToString(mac.Sum(nil)); got != resp.Signature {
+			t.Fatalf("signature = %s, want the report as returned to verify as %s", resp.Signature, got)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReportRejectsPeriodsNotOver(t *testing.T) {
+	router, _ := newTestRouter(t)
+	today := time.Now().UTC().Format(time.DateOnly)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/report?from=2026-01-01&to="+today, nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400 for a period ending today", w.Code)
+	}
+}
+
+func TestCollectSectionKeepsOldestEntries(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	every := func(offset, step int) loader {
+		return func(ctx context.Context, from, to time.Time, limit int) ([]Entry, error) {
+			entries := make([]Entry, limit)
+			for i := range entries {
+				entries[i] = Entry{At: start.Add(time.Duration(offset+i*step) * time.Second), Type: strconv.Itoa(offset)}
+			}
+			return entries, nil
+		}
+	}
+
+	section, err := collectSection(context.Background(), start, start.AddDate(0, 0, 1), []loader{every(1, 2), every(0, 2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !section.Truncated || len(section.Entries) != maxSectionEntries {
+		t.Fatalf("got %d entries, truncated %v, want %d truncated", len(section.Entries), section.Truncated, maxSectionEntries)
+	}
+	for i, entry := range section.Entries {
+		if want := start.Add(time.Duration(i) * time.Second); !entry.At.Equal(want) {
+			t.Fatalf("entry %d at %v, want %v", i, entry.At, want)
+		}
+	}
+}
+
+func TestRenderPDFPointsXrefAtObjects(t *testing.T) {
+	lines := make([]string, 2*pdfLinesOnPage+1)
+	for i := range lines {
+		lines[i] = "line (" + strconv.Itoa(i) + ") \\ é"
+	}
+	pdf := renderPDF(lines)
+
+	if !bytes.Contains(pdf, []byte(`(line \(0\) \\ ?) '`)) || !bytes.Contains(pdf, []byte("/Count 3")) {
+		t.Fatalf("pdf lacks the escaped lines on 3 pages:\n%s", pdf)
+	}
+	match := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(pdf)
+	xref, _ := strconv.Atoi(string(match[1]))
+	if !bytes.HasPrefix(pdf[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %d does not point at the xref table", xref)
+	}
+	for i, offset := range regexp.MustCompile(`(\d{10}) 00000 n`).FindAllSubmatch(pdf, -1) {
+		at, _ := strconv.Atoi(string(offset[1]))
+		if want := strconv.Itoa(i+1) + " 0 obj"; !bytes.HasPrefix(pdf[at:], []byte(want)) {
+			t.Fatalf("xref entry %d points at %q, want %q", i+1, pdf[at:at+10], want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package compliance
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+)
+
+type Report = models.ComplianceReport
+
+var ErrInvalidPeriod = errors.New("invalid report period")
+
+// ReportParams are the first and last day, in UTC, of the audited period
+type ReportParams struct {
+	From   time.Time `form:"from" time_format:"2006-01-02" binding:"required"`
+	To     time.Time `form:"to" time_format:"2006-01-02" binding:"required"`
+	Format string    `form:"format"`
+}
+
+// Entry is one audited action. Data holds what the action recorded, such
+// as a granted role or an exported table.
+type Entry struct {
+	At      time.Time       `json:"at"`
+	Type    string          `json:"type"`
+	ActorID string          `json:"actor_id,omitempty"`
+	Subject string          `json:"subject,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// Section lists a kind of action, oldest first. Truncated is set when the
+// period held more than the report lists.
+type Section struct {
+	Entries   []Entry `json:"entries"`
+	Truncated bool    `json:"truncated"`
+}
+
+// Content is what a report attests to, and what its signature covers
+type Content struct {
+	From              time.Time `json:"from"`
+	To                time.Time `json:"to"`
+	GeneratedAt       time.Time `json:"generated_at"`
+	Access            Section   `json:"access"`
+	PermissionChanges Section   `json:"permission_changes"`
+	DataExports       Section   `json:"data_exports"`
+	Deletions         Section   `json:"deletions"`
+}
+
+// PendingResponse is returned while a report is being generated
+type PendingResponse struct {
+	ID        string                        `json:"id"`
+	Status    models.ComplianceReportStatus `json:"status"`
+	From      time.Time                     `json:"from"`
+	To        time.Time                     `json:"to"`
+	CreatedAt time.Time                     `json:"created_at"`
+}
+
+// ReportResponse is a completed report. Signature is the hex HMAC-SHA256
+// of Report exactly as returned, keyed with the signing key.
+type ReportResponse struct {
+	ID          string          `json:"id"`
+	Report      json.RawMessage `json:"report"`
+	Algorithm   string          `json:"algorithm"`
+	Signature   string          `json:"signature"`
+	CompletedAt *time.Time      `json:"completed_at"`
+}
@@ -0,0 +1,140 @@
+package compliance
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	pdfPageWidth   = 612 // US Letter, in points
+	pdfPageHeight  = 792
+	pdfMargin      = 50
+	pdfFontSize    = 8
+	pdfLeading     = 11
+	pdfLineLength  = 120
+	pdfLinesOnPage = (pdfPageHeight - 2*pdfMargin) / pdfLeading
+)
+
+// reportLines is the text of report's PDF
+func reportLines(report *Report, content *Content) []string {
+	lines := []string{
+		"Compliance report",
+		"",
+		fmt.Sprintf("Report ID:     %s", report.ID),
+		fmt.Sprintf("Period:        %s to %s (UTC, end exclusive)", content.From.Format(time.DateOnly), content.To.Format(time.DateOnly)),
+		fmt.Sprintf("Generated at:  %s", content.GeneratedAt.Format(time.RFC3339)),
+		fmt.Sprintf("Signature:     %s %s", signatureAlgorithm, report.Signature),
+		"The signature covers the JSON form of this report.",
+	}
+	for _, section := range []struct {
+		title string
+		Section
+	}{
+		{"Access", content.Access},
+		{"Permission changes", content.PermissionChanges},
+		{"Data exports", content.DataExports},
+		{"Deletions", content.Deletions},
+	} {
+		lines = append(lines, "", fmt.Sprintf("%s (%d)", section.title, len(section.Entries)))
+		if len(section.Entries) == 0 {
+			lines = append(lines, "  None")
+		}
+		for _, entry := range section.Entries {
+			line := entry.At.Format(time.RFC3339) + "  " + entry.Type
+			if entry.ActorID != "" {
+				line += "  actor=" + entry.ActorID
+			}
+			if entry.Subject != "" {
+				line += "  subject=" + entry.Subject
+			}
+			if len(entry.Data) > 0 && string(entry.Data) != "{}" {
+				line += "  " + string(entry.Data)
+			}
+			lines = append(lines, wrap("  "+line, pdfLineLength)...)
+		}
+		if section.Truncated {
+			lines = append(lines, fmt.Sprintf("  Only the first %d entries are listed.", maxSectionEntries))
+		}
+	}
+	return lines
+}
+
+// wrap splits line into lines of at most width characters, indenting
+// continuations
+func wrap(line string, width int) []string {
+	var lines []string
+	for len(line) > width {
+		lines = append(lines, line[:width])
+		line = "      " + line[width:]
+	}
+	return append(lines, line)
+}
+
+// renderPDF lays lines out as a plain text PDF in Helvetica. Characters
+// outside printable ASCII are replaced, as the standard fonts lack them.
+func renderPDF(lines []string) []byte {
+	var pages [][]string
+	for len(lines) > pdfLinesOnPage {
+		pages = append(pages, lines[:pdfLinesOnPage])
+		lines = lines[pdfLinesOnPage:]
+	}
+	pages = append(pages, lines)
+
+	// Objects 1 to 3 are the catalog, the page tree and the font; each page
+	// then takes two, itself and its content stream
+	objects := make([]string, 3, 3+2*len(pages))
+	kids := make([]string, len(pages))
+	for i, page := range pages {
+		pageID := 4 + 2*i
+		kids[i] = fmt.Sprintf("%d 0 R", pageID)
+
+		var stream strings.Builder
+		fmt.Fprintf(&stream, "BT /F1 %d Tf %d TL %d %d Td\n", pdfFontSize, pdfLeading, pdfMargin, pdfPageHeight-pdfMargin)
+		for _, line := range page {
+			fmt.Fprintf(&stream, "(%s) '\n", pdfString(line))
+		}
+		stream.WriteString("ET")
+
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+				pdfPageWidth, pdfPageHeight, pageID+1),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", stream.Len(), stream.String()))
+	}
+	objects[0] = "<< /Type /Catalog /Pages 2 0 R >>"
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages))
+	objects[2] = "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>"
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+// pdfString escapes s for a PDF literal string
+func pdfString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < ' ' || r > '~':
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,412 @@
+package compliance
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/auth"
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+	"github.com/iSparshP/real-time-task-management-system/internal/security"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// maxPeriodDays bounds the period of one report
+	maxPeriodDays = 366
+	// maxSectionEntries caps each section of a report, keeping the oldest
+	maxSectionEntries = 5000
+	// generateTimeout bounds generating one report. A report generating for
+	// longer was interrupted, so the next request for it starts it again.
+	generateTimeout    = 5 * time.Minute
+	signatureAlgorithm = "HMAC-SHA256"
+)
+
+// deletedTables are the soft-deleted tables whose deletions are reported,
+// with the kind of record each holds
+var deletedTables = []struct{ table, kind string }{
+	{"users", "user"},
+	{"tasks", "task"},
+	{"checklist_items", "checklist_item"},
+	{"time_entries", "time_entry"},
+	{"task_templates", "task_template"},
+	{"task_attachments", "task_attachment"},
+	{"intake_submissions", "intake_submission"},
+	{"security_webhooks", "security_webhook"},
+}
+
+// loader returns up to limit entries of the period, oldest first
+type loader func(ctx context.Context, from, to time.Time, limit int) ([]Entry, error)
+
+// Service compiles signed audit reports from the security events and the
+// records of grants, exports and deletions. Each period's report is
+// generated once, in the background, by the first request for it.
+type Service struct {
+	db     *gorm.DB
+	key    []byte
+	events *security.Service
+	logger *zap.Logger
+
+	mu         sync.Mutex
+	background context.Context
+}
+
+func NewService(db *gorm.DB, signingKey string, logger *zap.Logger) *Service {
+	return &Service{
+		db:     db,
+		key:    []byte(signingKey),
+		logger: logger,
+
+		background: context.Background(),
+	}
+}
+
+// SetEventRecorder records each report generation as a security event
+func (s *Service) SetEventRecorder(events *security.Service) {
+	s.events = events
+}
+
+// Start generates reports under ctx, so generation stops on shutdown
+func (s *Service) Start(ctx context.Context) {
+	s.mu.Lock()
+	s.background = ctx
+	s.mu.Unlock()
+}
+
+// Report returns the report of the days from params.From through params.To,
+// and starts generating it unless that already completed or is under way.
+// Only days that are over can be reported, so a completed report never
+// goes stale.
+func (s *Service) Report(ctx context.Context, params ReportParams, userID string) (*Report, error) {
+	from := utcDay(params.From)
+	to := utcDay(params.To).AddDate(0, 0, 1)
+	switch {
+	case !to.After(from):
+		return nil, fmt.Errorf("%w: from must not be after to", ErrInvalidPeriod)
+	case to.Sub(from) > maxPeriodDays*24*time.Hour:
+		return nil, fmt.Errorf("%w: a report covers at most %d days", ErrInvalidPeriod, maxPeriodDays)
+	case to.After(time.Now()):
+		return nil, fmt.Errorf("%w: to must be a day before today (UTC)", ErrInvalidPeriod)
+	}
+
+	now := time.Now()
+	report := &Report{
+		PeriodFrom:  from,
+		PeriodTo:    to,
+		Status:      models.ComplianceReportRunning,
+		RequestedBy: userID,
+		CreatedAt:   now,
+	}
+	result := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "period_from"}, {Name: "period_to"}},
+		DoNothing: true,
+	}).Create(report)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to create compliance report: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		report = &Report{}
+		if err := s.db.WithContext(ctx).
+			Where("period_from = ? AND period_to = ?", from, to).
+			First(report).Error; err != nil {
+			return nil, fmt.Errorf("failed to load compliance report: %w", err)
+		}
+		if report.Status == models.ComplianceReportCompleted {
+			return report, nil
+		}
+
+		// A failed or interrupted report is generated again by whichever
+		// request claims it first
+		claimed := s.db.WithContext(ctx).Model(&Report{}).
+			Where("id = ? AND (status = ? OR (status = ? AND created_at < ?))", report.ID,
+				models.ComplianceReportFailed, models.ComplianceReportRunning, now.Add(-generateTimeout)).
+			Updates(map[string]interface{}{
+				"status":       models.ComplianceReportRunning,
+				"requested_by": userID,
+				"error":        "",
+				"created_at":   now,
+			})
+		if claimed.Error != nil {
+			return nil, fmt.Errorf("failed to restart compliance report: %w", claimed.Error)
+		}
+		report.Status = models.ComplianceReportRunning
+		report.Error = ""
+		if claimed.RowsAffected == 0 {
+			return report, nil
+		}
+		report.RequestedBy = userID
+		report.CreatedAt = now
+	}
+
+	s.events.Record(ctx, security.EventComplianceReportRequested, userID, map[string]interface{}{
+		"report_id": report.ID,
+		"from":      from,
+		"to":        to,
+	})
+	s.start(*report)
+	return report, nil
+}
+
+// start generates report in the background, recording a failure on it
+func (s *Service) start(report Report) {
+	s.mu.Lock()
+	background := s.background
+	s.mu.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(background, generateTimeout)
+		defer cancel()
+		err := s.generate(ctx, report)
+		if err == nil {
+			return
+		}
+		s.logger.Error("Failed to generate compliance report", zap.String("report_id", report.ID), zap.Error(err))
+		if err := s.db.WithContext(context.WithoutCancel(ctx)).Model(&Report{}).
+			Where("id = ?", report.ID).
+			Updates(map[string]interface{}{
+				"status": models.ComplianceReportFailed,
+				"error":  err.Error(),
+			}).Error; err != nil {
+			s.logger.Error("Failed to record compliance report failure", zap.String("report_id", report.ID), zap.Error(err))
+		}
+	}()
+}
+
+func (s *Service) generate(ctx context.Context, report Report) error {
+	content, err := s.collect(ctx, report.PeriodFrom, report.PeriodTo)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(content)
+	if err != nil {
+		return err
+	}
+	return s.db.WithContext(ctx).Model(&Report{}).
+		Where("id = ?", report.ID).
+		Updates(map[string]interface{}{
+			"status":       models.ComplianceReportCompleted,
+			"content":      string(data),
+			"signature":    s.sign(data),
+			"completed_at": time.Now(),
+		}).Error
+}
+
+// sign returns the hex HMAC-SHA256 of data
+func (s *Service) sign(data []byte) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// collect compiles the report of [from, to)
+func (s *Service) collect(ctx context.Context, from, to time.Time) (*Content, error) {
+	deletions := make([]loader, 0, len(deletedTables))
+	for _, t := range deletedTables {
+		deletions = append(deletions, s.deleted(t.table, t.kind))
+	}
+
+	content := &Content{From: from, To: to, GeneratedAt: time.Now().UTC()}
+	for _, section := range []struct {
+		name    string
+		into    *Section
+		loaders []loader
+	}{
+		{"access", &content.Access, []loader{
+			s.securityEvents(security.EventLoginFailedBurst, security.EventLoginAfterFailures, security.EventImpersonatedRequest),
+		}},
+		{"permission changes", &content.PermissionChanges, []loader{
+			s.securityEvents(security.EventRoleGranted, security.EventSecurityWebhookCreated, security.EventSecurityWebhookDeleted,
+				security.EventAccountDisabled, security.EventAccountEnabled, security.EventPasswordResetForced,
+				security.EventImpersonationStarted),
+			s.revokedReportingTokens,
+			s.taskLinks,
+		}},
+		{"data exports", &content.DataExports, []loader{
+			s.securityEvents(security.EventDataExportRequested, security.EventDataExportCompleted, security.EventComplianceReportRequested),
+			s.exportRuns,
+		}},
+		{"deletions", &content.Deletions, deletions},
+	} {
+		var err error
+		if *section.into, err = collectSection(ctx, from, to, section.loaders); err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", section.name, err)
+		}
+	}
+	return content, nil
+}
+
+// collectSection merges the entries of loaders, oldest first. Each loader
+// returns its oldest entries, so the oldest of them all are kept.
+func collectSection(ctx context.Context, from, to time.Time, loaders []loader) (Section, error) {
+	entries := []Entry{}
+	for _, load := range loaders {
+		loaded, err := load(ctx, from, to, maxSectionEntries+1)
+		if err != nil {
+			return Section{}, err
+		}
+		entries = append(entries, loaded...)
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].At.Before(entries[j].At) })
+
+	section := Section{Entries: entries}
+	if len(entries) > maxSectionEntries {
+		section.Entries = entries[:maxSectionEntries]
+		section.Truncated = true
+	}
+	return section, nil
+}
+
+func (s *Service) securityEvents(types ...security.EventType) loader {
+	return func(ctx context.Context, from, to time.Time, limit int) ([]Entry, error) {
+		var events []security.Event
+		if err := s.db.WithContext(ctx).
+			Where("type IN ? AND created_at >= ? AND created_at < ?", types, from, to).
+			Order("sequence").
+			Limit(limit).
+			Find(&events).Error; err != nil {
+			return nil, err
+		}
+		entries := make([]Entry, 0, len(events))
+		for _, event := range events {
+			entries = append(entries, Entry{
+				At:      event.CreatedAt.UTC(),
+				Type:    event.Type,
+				ActorID: event.ActorID,
+				Data:    json.RawMessage(event.Data),
+			})
+		}
+		return entries, nil
+	}
+}
+
+// revokedReportingTokens reports revocations, which unlike grants are not
+// recorded as security events
+func (s *Service) revokedReportingTokens(ctx context.Context, from, to time.Time, limit int) ([]Entry, error) {
+	var tokens []models.ReportingToken
+	if err := s.db.WithContext(ctx).
+		Where("revoked_at >= ? AND revoked_at < ?", from, to).
+		Order("revoked_at").
+		Limit(limit).
+		Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, 0, len(tokens))
+	for _, token := range tokens {
+		entries = append(entries, Entry{
+			At:      token.RevokedAt.UTC(),
+			Type:    "role.revoked",
+			Subject: token.UserID,
+			Data:    encode(map[string]interface{}{"role": auth.RoleReporting, "token_id": token.ID}),
+		})
+	}
+	return entries, nil
+}
+
+// taskLinks reports task links created and revoked, since each grants
+// anyone holding it the right to create tasks
+func (s *Service) taskLinks(ctx context.Context, from, to time.Time, limit int) ([]Entry, error) {
+	var created, revoked []models.TaskLink
+	if err := s.db.WithContext(ctx).
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Order("created_at").
+		Limit(limit).
+		Find(&created).Error; err != nil {
+		return nil, err
+	}
+	if err := s.db.WithContext(ctx).
+		Where("revoked_at >= ? AND revoked_at < ?", from, to).
+		Order("revoked_at").
+		Limit(limit).
+		Find(&revoked).Error; err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(created)+len(revoked))
+	for _, link := range created {
+		entries = append(entries, Entry{
+			At:      link.CreatedAt.UTC(),
+			Type:    "task_link.created",
+			ActorID: link.CreatedBy,
+			Subject: link.ID,
+			Data:    encode(map[string]interface{}{"project": link.Project, "expires_at": link.ExpiresAt.UTC()}),
+		})
+	}
+	for _, link := range revoked {
+		// Only its creator can revoke a link
+		entries = append(entries, Entry{
+			At:      link.RevokedAt.UTC(),
+			Type:    "task_link.revoked",
+			ActorID: link.CreatedBy,
+			Subject: link.ID,
+		})
+	}
+	return entries, nil
+}
+
+func (s *Service) exportRuns(ctx context.Context, from, to time.Time, limit int) ([]Entry, error) {
+	var runs []models.ExportRun
+	if err := s.db.WithContext(ctx).
+		Where("started_at >= ? AND started_at < ?", from, to).
+		Order("started_at").
+		Limit(limit).
+		Find(&runs).Error; err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, 0, len(runs))
+	for _, run := range runs {
+		entries = append(entries, Entry{
+			At:      run.StartedAt.UTC(),
+			Type:    "warehouse_export." + string(run.Status),
+			Subject: run.Source,
+			Data:    encode(map[string]interface{}{"destination": run.Destination, "rows": run.Rows}),
+		})
+	}
+	return entries, nil
+}
+
+// deleted reports the soft deletions of table. Who deleted a record is not
+// kept, so entries have no actor.
+func (s *Service) deleted(table, kind string) loader {
+	return func(ctx context.Context, from, to time.Time, limit int) ([]Entry, error) {
+		var rows []struct {
+			ID        string
+			DeletedAt time.Time
+		}
+		if err := s.db.WithContext(ctx).Table(table).
+			Select("id", "deleted_at").
+			Where("deleted_at >= ? AND deleted_at < ?", from, to).
+			Order("deleted_at").
+			Limit(limit).
+			Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		entries := make([]Entry, 0, len(rows))
+		for _, row := range rows {
+			entries = append(entries, Entry{
+				At:      row.DeletedAt.UTC(),
+				Type:    kind + ".deleted",
+				Subject: row.ID,
+			})
+		}
+		return entries, nil
+	}
+}
+
+func encode(data map[string]interface{}) json.RawMessage {
+	encoded, _ := json.Marshal(data)
+	return encoded
+}
+
+// utcDay is the UTC midnight of t's date, whatever t's location
+func utcDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
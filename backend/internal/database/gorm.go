@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"math"
@@ -21,6 +22,14 @@ type Config struct {
 	SSLMode     string
 	ConnTimeout time.Duration // Add connection timeout
 	MaxRetries  int
+	// Replicas are the read replicas, as host or host:port, sharing the
+	// primary's credentials and database
+	Replicas             []string
+	ReplicaCheckInterval time.Duration
+	// SlowQueryThreshold is how long a query runs before it is logged as
+	// slow, with its plan when ExplainSlowQueries is set
+	SlowQueryThreshold time.Duration
+	ExplainSlowQueries bool
 }
 
 func CheckConnection(db *gorm.DB) error {
@@ -36,6 +45,20 @@ func CheckConnection(db *gorm.DB) error {
 	return nil
 }
 
+// PingContext verifies the database is reachable within the context deadline
+func PingContext(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get database instance: %w", err)
+	}
+
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	return nil
+}
+
 func NewGormDB(config Config) (*gorm.DB, error) {
 	if config.ConnTimeout == 0 {
 		config.ConnTimeout = 10 * time.Second
@@ -47,34 +70,32 @@ func NewGormDB(config Config) (*gorm.DB, error) {
 		config.SSLMode = "require" // Default to require SSL for cloud databases
 	}
 
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=%s connect_timeout=%d",
-		config.Host,
-		config.User,
-		config.Password,
-		config.DBName,
-		config.Port,
-		config.SSLMode,
-		int(config.ConnTimeout.Seconds()),
-	)
+	if config.ReplicaCheckInterval == 0 {
+		config.ReplicaCheckInterval = 10 * time.Second
+	}
+	if config.SlowQueryThreshold == 0 {
+		config.SlowQueryThreshold = time.Second
+	}
 
 	gormConfig := &gorm.Config{
 		Logger: logger.New(
 			log.New(log.Writer(), "\r\n", log.LstdFlags),
 			logger.Config{
-				SlowThreshold:             time.Second,
+				SlowThreshold:             config.SlowQueryThreshold,
 				LogLevel:                  logger.Info,
 				IgnoreRecordNotFoundError: true,
 				Colorful:                  true,
 			},
 		),
-		PrepareStmt: true, // Enable prepared statement cache
+		PrepareStmt:    true, // Enable prepared statement cache
+		TranslateError: true, // Surface unique violations as gorm.ErrDuplicatedKey
 	}
 
 	// Enhanced retry logic with exponential backoff
 	var db *gorm.DB
 	var err error
 	for i := 0; i < config.MaxRetries; i++ {
-		db, err = gorm.Open(postgres.Open(dsn), gormConfig)
+		db, err = gorm.Open(postgres.Open(dsn(config)), gormConfig)
 		if err == nil {
 			if err := CheckConnection(db); err == nil {
 				break
@@ -99,12 +120,35 @@ func NewGormDB(config Config) (*gorm.DB, error) {
 	sqlDB.SetMaxOpenConns(100)
 	sqlDB.SetConnMaxLifetime(time.Hour)
 
+	if len(config.Replicas) > 0 {
+		if err := useReplicas(db, config); err != nil {
+			return nil, err
+		}
+	}
+	if config.ExplainSlowQueries {
+		if err := db.Use(newSlowQueryLogger(config.SlowQueryThreshold)); err != nil {
+			return nil, fmt.Errorf("failed to register slow query logging: %w", err)
+		}
+	}
+
 	// Monitor connection health
 	go monitorDBConnection(db)
 
 	return db, nil
 }
 
+func dsn(config Config) string {
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=%s connect_timeout=%d",
+		config.Host,
+		config.User,
+		config.Password,
+		config.DBName,
+		config.Port,
+		config.SSLMode,
+		int(config.ConnTimeout.Seconds()),
+	)
+}
+
 func monitorDBConnection(db *gorm.DB) {
 	ticker := time.NewTicker(30 * time.Second)
 	defer ticker.Stop()
@@ -117,6 +161,10 @@ func monitorDBConnection(db *gorm.DB) {
 }
 
 func CloseDB(db *gorm.DB) error {
+	if router, ok := db.Config.Plugins[(&replicaRouter{}).Name()].(*replicaRouter); ok {
+		router.close()
+	}
+
 	sqlDB, err := db.DB()
 	if err != nil {
 		return fmt.Errorf("failed to get database instance: %w", err)
@@ -129,10 +177,63 @@ func CloseDB(db *gorm.DB) error {
 	return nil
 }
 
-// AutoMigrate runs database migrations for all models
-func AutoMigrate(db *gorm.DB) error {
-	return db.AutoMigrate(
+// AutoMigrate runs the schema migration and the expand data migrations,
+// with their statements checked by guard. It refuses to run against a
+// schema contracted past this build.
+func AutoMigrate(db *gorm.DB, guard *MigrationGuard) error {
+	if err := CheckSchemaVersion(context.Background(), db); err != nil {
+		return err
+	}
+
+	db = withGuard(db, guard)
+	if err := db.AutoMigrate(
 		&models.User{},
 		&models.Task{},
-	)
+		&models.TaskAssignee{},
+		&models.TaskWatcher{},
+		&models.TaskEditLock{},
+		&models.TaskTransfer{},
+		&models.AssignmentEvent{},
+		&models.ChecklistItem{},
+		&models.TimeEntry{},
+		&models.TaskTemplate{},
+		&models.ProjectFieldSchema{},
+		&models.ProjectEscalationRule{},
+		&models.TaskEscalation{},
+		&models.ProjectCalendar{},
+		&models.AISettings{},
+		&models.RuntimeSetting{},
+		&models.ProjectReport{},
+		&models.AIUsage{},
+		&models.ProjectWebhook{},
+		&models.ProjectNotificationRule{},
+		&models.NotificationDelivery{},
+		&models.ClientError{},
+		&models.Preference{},
+		&models.TaskAttachment{},
+		&models.IntakeSubmission{},
+		&models.ExportRun{},
+		&models.SecurityEvent{},
+		&models.SecurityWebhook{},
+		&models.ReportingToken{},
+		&models.UserSession{},
+		&models.StatusIncident{},
+		&models.TaskLink{},
+		&models.ComplianceReport{},
+		&appliedMigration{},
+	); err != nil {
+		return err
+	}
+
+	return runDataMigrations(db, dataMigrations, phaseExpand)
+}
+
+// Contract runs the contract data migrations, with their statements
+// checked by guard. Run it only once every replica runs this build, since
+// older builds may still use what they remove.
+func Contract(db *gorm.DB, guard *MigrationGuard) error {
+	if err := CheckMigrations(context.Background(), db); err != nil {
+		return err
+	}
+	return runDataMigrations(withGuard(db, guard), dataMigrations, phaseContract)
 }
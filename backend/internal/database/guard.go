@@ -0,0 +1,146 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// defaultLargeTableRows is the estimated row count from which a table is
+// too large to lock for a rewrite or a scan
+const defaultLargeTableRows = 100_000
+
+var ErrUnsafeMigration = errors.New("unsafe migration")
+
+var (
+	createIndexPattern = regexp.MustCompile(`(?is)^\s*CREATE\s+(UNIQUE\s+)?INDEX\s+(CONCURRENTLY\s+)?(IF\s+NOT\s+EXISTS\s+)?\S*\s*ON\s+(ONLY\s+)?"?(\w+)"?`)
+	alterTablePattern  = regexp.MustCompile(`(?is)^\s*ALTER\s+TABLE\s+(IF\s+EXISTS\s+)?(ONLY\s+)?"?(\w+)"?\s+(.*)$`)
+	// clauseSplit splits the actions of an ALTER TABLE. Commas inside
+	// parentheses, as in numeric(10,2), are not action separators.
+	clauseSplit = regexp.MustCompile(`,\s*(?:ADD|ALTER|DROP|RENAME|SET|VALIDATE)\b`)
+)
+
+// unsafeOperation is a statement that locks a table against writes for as
+// long as it takes to scan or rewrite it
+type unsafeOperation struct {
+	table  string
+	reason string
+}
+
+// lintStatement returns the operations of sql that block writes to their
+// table while it is scanned or rebuilt: index builds without CONCURRENTLY
+// and NOT NULL columns or constraints without a default to fill them.
+// Whether they are unsafe depends on the table's size.
+func lintStatement(sql string) []unsafeOperation {
+	if m := createIndexPattern.FindStringSubmatch(sql); m != nil {
+		if m[2] == "" {
+			return []unsafeOperation{{table: m[5], reason: "CREATE INDEX without CONCURRENTLY blocks writes while the index is built"}}
+		}
+		return nil
+	}
+
+	m := alterTablePattern.FindStringSubmatch(sql)
+	if m == nil {
+		return nil
+	}
+	table, actions := m[3], m[4]
+	var ops []unsafeOperation
+	for _, action := range splitActions(actions) {
+		upper := strings.ToUpper(strings.Join(strings.Fields(action), " "))
+		switch {
+		case strings.HasPrefix(upper, "ADD") && !strings.HasPrefix(upper, "ADD CONSTRAINT") &&
+			strings.Contains(upper, "NOT NULL") && !strings.Contains(upper, "DEFAULT"):
+			ops = append(ops, unsafeOperation{table: table, reason: "ADD COLUMN ... NOT NULL without a DEFAULT fails or rewrites the table"})
+		case strings.HasPrefix(upper, "ALTER") && strings.Contains(upper, "SET NOT NULL"):
+			ops = append(ops, unsafeOperation{table: table, reason: "SET NOT NULL scans the table while blocking writes"})
+		}
+	}
+	return ops
+}
+
+// splitActions splits the actions of an ALTER TABLE statement
+func splitActions(actions string) []string {
+	var parts []string
+	for {
+		loc := clauseSplit.FindStringIndex(actions)
+		if loc == nil {
+			return append(parts, actions)
+		}
+		parts = append(parts, actions[:loc[0]])
+		// Keep the keyword with the next action
+		actions = strings.TrimLeft(actions[loc[0]+1:], " \t\n")
+	}
+}
+
+// MigrationGuard checks the statements migrations execute. Operations
+// lintStatement reports on tables of at least largeTableRows estimated
+// rows are rejected when enforcing, and only logged otherwise.
+type MigrationGuard struct {
+	enforce        bool
+	largeTableRows int64
+}
+
+// NewMigrationGuard returns a guard. Production servers should enforce it.
+func NewMigrationGuard(enforce bool, largeTableRows int64) *MigrationGuard {
+	if largeTableRows <= 0 {
+		largeTableRows = defaultLargeTableRows
+	}
+	return &MigrationGuard{enforce: enforce, largeTableRows: largeTableRows}
+}
+
+type guardKey struct{}
+
+// withGuard has the statements executed through the returned session
+// checked by guard
+func withGuard(db *gorm.DB, guard *MigrationGuard) *gorm.DB {
+	if guard == nil {
+		return db
+	}
+	raw := db.Callback().Raw()
+	if raw.Get("migration_guard") == nil {
+		if err := raw.Before("gorm:raw").Register("migration_guard", checkStatement); err != nil {
+			log.Printf("Failed to register migration guard: %v", err)
+		}
+	}
+	ctx := db.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return db.WithContext(context.WithValue(ctx, guardKey{}, guard))
+}
+
+// checkStatement is the gorm callback running the guard of the statement's
+// session, if it has one
+func checkStatement(tx *gorm.DB) {
+	guard, ok := tx.Statement.Context.Value(guardKey{}).(*MigrationGuard)
+	if !ok || tx.Error != nil {
+		return
+	}
+	if err := guard.check(tx, tx.Statement.SQL.String()); err != nil {
+		tx.AddError(err)
+	}
+}
+
+func (g *MigrationGuard) check(tx *gorm.DB, sql string) error {
+	for _, op := range lintStatement(sql) {
+		var rows int64
+		if err := tx.Session(&gorm.Session{NewDB: true}).
+			Raw("SELECT COALESCE(MAX(GREATEST(reltuples, 0)), 0)::bigint FROM pg_class WHERE relname = ? AND relkind IN ('r', 'p')", op.table).
+			Scan(&rows).Error; err != nil {
+			return fmt.Errorf("failed to size table %s: %w", op.table, err)
+		}
+		if rows < g.largeTableRows {
+			continue
+		}
+		if g.enforce {
+			return fmt.Errorf("%w on %s (about %d rows): %s", ErrUnsafeMigration, op.table, rows, op.reason)
+		}
+		log.Printf("Warning: unsafe migration on %s (about %d rows): %s", op.table, rows, op.reason)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package database
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestLintStatementFlagsBlockingOperations(t *testing.T) {
+	tests := []struct {
+		sql   string
+		table string
+	}{
+		{`CREATE INDEX IF NOT EXISTS idx_tasks_due ON tasks (due_date)`, "tasks"},
+		{`CREATE UNIQUE INDEX "idx_users_email" ON "users" ("email")`, "users"},
+		{`CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_tasks_due ON tasks (due_date)`, ""},
+		{`ALTER TABLE "tasks" ADD "effort" decimal(10,2) NOT NULL`, "tasks"},
+		{`ALTER TABLE "tasks" ADD "ai_plan" varchar(50) NOT NULL DEFAULT 'free'`, ""},
+		{"ALTER TABLE tasks\n\t\tADD COLUMN IF NOT EXISTS embedding vector,\n\t\tADD COLUMN IF NOT EXISTS rank int NOT NULL", "tasks"},
+		{`ALTER TABLE tasks ALTER COLUMN project SET NOT NULL`, "tasks"},
+		{`ALTER TABLE tasks ADD CONSTRAINT chk_title CHECK (title IS NOT NULL)`, ""},
+		{`UPDATE tasks SET completed_at = updated_at WHERE status = 'completed'`, ""},
+	}
+	for _, tt := range tests {
+		ops := lintStatement(tt.sql)
+		if tt.table == "" && len(ops) != 0 || tt.table != "" && (len(ops) != 1 || ops[0].table != tt.table) {
+			t.Errorf("lintStatement(%q) = %+v, want one operation on %q", tt.sql, ops, tt.table)
+		}
+	}
+}
+
+func TestMigrationGuardRejectsBlockingOperationsOnLargeTables(t *testing.T) {
+	db, mock := newMockDB(t)
+	db = withGuard(db, NewMigrationGuard(true, 1000))
+
+	mock.ExpectQuery(`SELECT COALESCE\(MAX\(GREATEST\(reltuples, 0\)\), 0\)::bigint FROM pg_class`).WithArgs("tasks").
+		WillReturnRows(sqlmock.NewRows([]string{"rows"}).AddRow(5000))
+	err := db.Exec(`CREATE INDEX idx_tasks_project ON tasks (project)`).Error
+	if !errors.Is(err, ErrUnsafeMigration) {
+		t.Fatalf("err = %v, want ErrUnsafeMigration for a large table", err)
+	}
+
+	mock.ExpectQuery(`FROM pg_class`).WithArgs("task_links").
+		WillReturnRows(sqlmock.NewRows([]string{"rows"}).AddRow(10))
+	mock.ExpectExec(`CREATE INDEX idx_task_links_project`).WillReturnResult(sqlmock.NewResult(0, 0))
+	if err := db.Exec(`CREATE INDEX idx_task_links_project ON task_links (project)`).Error; err != nil {
+		t.Fatalf("err = %v, want small tables let through", err)
+	}
+
+	mock.ExpectExec(`CREATE INDEX CONCURRENTLY`).WillReturnResult(sqlmock.NewResult(0, 0))
+	if err := db.Exec(`CREATE INDEX CONCURRENTLY idx_tasks_project ON tasks (project)`).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
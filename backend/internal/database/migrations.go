@@ -0,0 +1,379 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+	"gorm.io/gorm"
+)
+
+// migrationLockKey serializes data migrations across replicas starting at
+// the same time
+const migrationLockKey = 72_410_001
+
+// appliedMigration records a data migration that has already run
+type appliedMigration struct {
+	Name      string    `gorm:"primaryKey;size:255"`
+	Phase     string    `gorm:"size:10;not null;default:'expand'"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (appliedMigration) TableName() string {
+	return "data_migrations"
+}
+
+// Migrations are deployed in two phases. Expand migrations only add to
+// the schema, so the builds before them keep working, and run before the
+// build that needs them serves. Contract migrations remove what older
+// builds still use, and only run, with Contract, once every replica runs a
+// build that no longer needs it.
+const (
+	phaseExpand   = "expand"
+	phaseContract = "contract"
+)
+
+// dataMigration is a one-time data fix that AutoMigrate cannot express.
+// Names must never change once released.
+type dataMigration struct {
+	name string
+	run  func(tx *gorm.DB) error
+	// contract marks a contract migration
+	contract bool
+	// noTransaction runs the migration outside a transaction, as CREATE
+	// INDEX CONCURRENTLY must be. It must be safe to run again after
+	// failing halfway, e.g. by dropping an invalid index first.
+	noTransaction bool
+}
+
+func (m dataMigration) phase() string {
+	if m.contract {
+		return phaseContract
+	}
+	return phaseExpand
+}
+
+var dataMigrations = []dataMigration{
+	{name: "backfill_task_assignees", run: backfillTaskAssignees},
+	{name: "backfill_task_completed_at", run: backfillTaskCompletedAt},
+	{name: "create_task_search_indexes", run: createTaskSearchIndexes},
+	{name: "create_task_typeahead_index", run: createTaskTypeaheadIndex},
+	{name: "backfill_normalized_emails", run: backfillNormalizedEmails},
+	{name: "add_task_embeddings", run: addTaskEmbeddings},
+	{name: "add_task_overdue_tracking", run: addTaskOverdueTracking},
+	{name: "create_task_filter_indexes", run: createTaskFilterIndexes, noTransaction: true},
+	{name: "create_task_archive_index", run: createTaskArchiveIndex, noTransaction: true},
+	{name: "add_task_escalation_tracking", run: addTaskEscalationTracking},
+}
+
+// runDataMigrations applies each pending data migration of phase exactly
+// once, in order, recording it in the same transaction
+func runDataMigrations(db *gorm.DB, migrations []dataMigration, phase string) error {
+	for _, m := range migrations {
+		if m.phase() != phase {
+			continue
+		}
+		var err error
+		if m.noTransaction {
+			err = runOutsideTransaction(db, m)
+		} else {
+			err = db.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationLockKey).Error; err != nil {
+					return err
+				}
+				return applyOnce(tx, m)
+			})
+		}
+		if err != nil {
+			return fmt.Errorf("data migration %s: %w", m.name, err)
+		}
+	}
+	return nil
+}
+
+// runOutsideTransaction applies m on a single connection holding the
+// session advisory lock
+func runOutsideTransaction(db *gorm.DB, m dataMigration) error {
+	return db.Connection(func(conn *gorm.DB) error {
+		if err := conn.Exec("SELECT pg_advisory_lock(?)", migrationLockKey).Error; err != nil {
+			return err
+		}
+		defer conn.Exec("SELECT pg_advisory_unlock(?)", migrationLockKey)
+		return applyOnce(conn, m)
+	})
+}
+
+// applyOnce runs m and records it, unless it was already recorded
+func applyOnce(tx *gorm.DB, m dataMigration) error {
+	var applied int64
+	if err := tx.Model(&appliedMigration{}).Where("name = ?", m.name).Count(&applied).Error; err != nil {
+		return err
+	}
+	if applied > 0 {
+		return nil
+	}
+
+	if err := m.run(tx); err != nil {
+		return err
+	}
+	return tx.Create(&appliedMigration{Name: m.name, Phase: m.phase(), AppliedAt: time.Now()}).Error
+}
+
+var (
+	// ErrMigrationsPending means the schema has not been migrated to this
+	// build's version yet
+	ErrMigrationsPending = errors.New("database migrations have not been applied")
+	// ErrSchemaTooNew means a contract migration this build does not know
+	// has run, so the schema may lack what this build uses
+	ErrSchemaTooNew = errors.New("database schema was contracted past this build")
+)
+
+// CheckMigrations reports whether every expand migration of this build has
+// been recorded. Data migrations run after the schema migration, so this
+// also means the schema is current. Servers that leave migrating to a
+// separate job wait on it before serving.
+func CheckMigrations(ctx context.Context, db *gorm.DB) error {
+	if !db.WithContext(ctx).Migrator().HasTable(&appliedMigration{}) {
+		return ErrMigrationsPending
+	}
+
+	var names []string
+	for _, m := range dataMigrations {
+		if !m.contract {
+			names = append(names, m.name)
+		}
+	}
+	var applied int64
+	if err := db.WithContext(ctx).Model(&appliedMigration{}).Where("name IN ?", names).Count(&applied).Error; err != nil {
+		return err
+	}
+	if applied < int64(len(names)) {
+		return fmt.Errorf("%w: %d of %d data migrations recorded", ErrMigrationsPending, applied, len(names))
+	}
+	return nil
+}
+
+// CheckSchemaVersion returns ErrSchemaTooNew if the database ran contract
+// migrations this build does not know, as happens when an older build is
+// rolled back to after them. Such a build must not serve or migrate.
+func CheckSchemaVersion(ctx context.Context, db *gorm.DB) error {
+	return checkSchemaVersion(ctx, db, dataMigrations)
+}
+
+func checkSchemaVersion(ctx context.Context, db *gorm.DB, migrations []dataMigration) error {
+	db = db.WithContext(ctx)
+	if !db.Migrator().HasTable(&appliedMigration{}) || !db.Migrator().HasColumn(&appliedMigration{}, "phase") {
+		return nil
+	}
+
+	known := make([]string, len(migrations))
+	for i, m := range migrations {
+		known[i] = m.name
+	}
+	var unknown []string
+	if err := db.Model(&appliedMigration{}).Where("phase = ? AND name NOT IN ?", phaseContract, known).
+		Order("applied_at ASC").
+		Pluck("name", &unknown).Error; err != nil {
+		return err
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("%w: unknown contract migrations %s", ErrSchemaTooNew, strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// backfillTaskAssignees copies single-assignee tasks into the join table so
+// tasks created before multi-assignee support keep their assignee. It reads
+// the deprecated tasks.assigned_to column, which nothing writes any more and
+// which fresh databases never get.
+func backfillTaskAssignees(tx *gorm.DB) error {
+	if !tx.Migrator().HasColumn("tasks", "assigned_to") {
+		return nil
+	}
+	return tx.Exec(`
+		INSERT INTO task_assignees (task_id, user_id, is_primary, created_at)
+		SELECT id, assigned_to, true, CURRENT_TIMESTAMP
+		FROM tasks
+		WHERE assigned_to IS NOT NULL AND deleted_at IS NULL
+		ON CONFLICT DO NOTHING`).Error
+}
+
+// backfillTaskCompletedAt gives tasks completed before completion tracking
+// a best-effort completion time so burndown charts include them
+func backfillTaskCompletedAt(tx *gorm.DB) error {
+	return tx.Exec(`
+		UPDATE tasks SET completed_at = updated_at
+		WHERE status = 'completed' AND completed_at IS NULL`).Error
+}
+
+// createTaskSearchIndexes adds the GIN indexes behind task search. The
+// expressions must match the ones in task.SearchTasks to be used.
+func createTaskSearchIndexes(tx *gorm.DB) error {
+	if err := tx.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_tasks_search ON tasks
+		USING GIN (to_tsvector('simple', title || ' ' || COALESCE(description, '')))`).Error; err != nil {
+		return err
+	}
+	return tx.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_task_attachments_search ON task_attachments
+		USING GIN (to_tsvector('simple', ocr_text))`).Error
+}
+
+// createTaskTypeaheadIndex adds the trigram index behind task typeahead.
+// It only covers open tasks, and its expression and predicate must match
+// task.Typeahead to be used.
+func createTaskTypeaheadIndex(tx *gorm.DB) error {
+	if err := tx.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm").Error; err != nil {
+		return err
+	}
+	return tx.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_tasks_typeahead ON tasks
+		USING GIN (lower(title) gin_trgm_ops)
+		WHERE deleted_at IS NULL AND status <> 'completed'`).Error
+}
+
+// backfillNormalizedEmails gives existing accounts their normalized email,
+// oldest first. An account whose normalized email an older account already
+// has is left without one: it can still sign in with its exact address,
+// and the duplicate is left for an administrator to resolve.
+func backfillNormalizedEmails(tx *gorm.DB) error {
+	var taken []string
+	if err := tx.Model(&models.User{}).Where("normalized_email IS NOT NULL").
+		Pluck("normalized_email", &taken).Error; err != nil {
+		return err
+	}
+	seen := make(map[string]bool, len(taken))
+	for _, email := range taken {
+		seen[email] = true
+	}
+
+	var users []models.User
+	if err := tx.Select("id", "email").Where("normalized_email IS NULL").
+		Order("created_at ASC, id ASC").
+		Find(&users).Error; err != nil {
+		return err
+	}
+	for _, user := range users {
+		normalized := models.NormalizeEmail(user.Email, common.AppConfig.EmailFoldGmail)
+		if seen[normalized] {
+			continue
+		}
+		seen[normalized] = true
+		if err := tx.Model(&models.User{}).Where("id = ?", user.ID).
+			UpdateColumn("normalized_email", normalized).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addTaskEmbeddings adds the columns duplicate detection keeps task
+// embeddings in. The vector has no fixed dimension, since that depends on
+// the embedding model, so it cannot be indexed: similarity searches scan
+// the embedded tasks. embedding_model keeps vectors of different models
+// from being compared.
+func addTaskEmbeddings(tx *gorm.DB) error {
+	if err := tx.Exec("CREATE EXTENSION IF NOT EXISTS vector").Error; err != nil {
+		return err
+	}
+	return tx.Exec(`
+		ALTER TABLE tasks
+		ADD COLUMN IF NOT EXISTS embedding vector,
+		ADD COLUMN IF NOT EXISTS embedding_model text`).Error
+}
+
+// addTaskOverdueTracking adds the index behind the overdue filter and the
+// column the overdue scan records its events in. Tasks already overdue are
+// marked as notified, so the first scan only emits newly overdue ones. The
+// index only covers open tasks, and its predicate must match
+// the task list filter and task.EmitOverdue to be used.
+func addTaskOverdueTracking(tx *gorm.DB) error {
+	if err := tx.Exec(`
+		ALTER TABLE tasks
+		ADD COLUMN IF NOT EXISTS overdue_notified_at timestamptz`).Error; err != nil {
+		return err
+	}
+	if err := tx.Exec(`
+		UPDATE tasks SET overdue_notified_at = now()
+		WHERE status <> 'completed' AND due_date < now()`).Error; err != nil {
+		return err
+	}
+	return tx.Exec(`
+		CREATE INDEX IF NOT EXISTS idx_tasks_open_due_date ON tasks (due_date)
+		WHERE deleted_at IS NULL AND status <> 'completed'`).Error
+}
+
+// addTaskEscalationTracking records when each task's priority was last
+// escalated
+func addTaskEscalationTracking(tx *gorm.DB) error {
+	return tx.Exec(`
+		ALTER TABLE tasks
+		ADD COLUMN IF NOT EXISTS escalated_at timestamptz`).Error
+}
+
+// migrationIndex is an index built by a data migration
+type migrationIndex struct {
+	name       string
+	definition string
+}
+
+// taskFilterIndexes back the filter combinations of task lists that scan
+// the most rows on large databases. The tasks indexes only cover live
+// tasks, as every list does, and end in the default sort column.
+var taskFilterIndexes = []migrationIndex{
+	// assigned_to: the EXISTS on task_assignees runs from the index alone
+	{"idx_task_assignees_user_task", "task_assignees (user_id, task_id)"},
+	// status alone and with assigned_to, newest first
+	{"idx_tasks_status_created_at", "tasks (status, created_at, id) WHERE deleted_at IS NULL"},
+	// created_by, the creator's own lists, with or without status
+	{"idx_tasks_created_by_status", "tasks (created_by, status, created_at) WHERE deleted_at IS NULL"},
+	// due_before and due_after, with or without status
+	{"idx_tasks_due_date_status", "tasks (due_date, status) WHERE deleted_at IS NULL"},
+}
+
+// createTaskFilterIndexes builds taskFilterIndexes without blocking writes
+func createTaskFilterIndexes(tx *gorm.DB) error {
+	return createIndexesConcurrently(tx, taskFilterIndexes)
+}
+
+// createTaskArchiveIndex indexes the archived tasks, which task lists only
+// read when asked for the archive. Lists of the other tasks keep using the
+// filter indexes.
+func createTaskArchiveIndex(tx *gorm.DB) error {
+	return createIndexesConcurrently(tx, []migrationIndex{
+		{"idx_tasks_archived_at", "tasks (archived_at, id) WHERE deleted_at IS NULL AND archived_at IS NOT NULL"},
+	})
+}
+
+// createIndexesConcurrently builds indexes without blocking writes. A
+// concurrent build that failed leaves an invalid index behind, which IF
+// NOT EXISTS would keep, so those are dropped and built again.
+func createIndexesConcurrently(tx *gorm.DB, indexes []migrationIndex) error {
+	names := make([]string, len(indexes))
+	for i, index := range indexes {
+		names[i] = index.name
+	}
+	var invalid []string
+	if err := tx.Raw(`
+		SELECT c.relname FROM pg_index i
+		JOIN pg_class c ON c.oid = i.indexrelid
+		WHERE NOT i.indisvalid AND c.relname IN ?`, names).Scan(&invalid).Error; err != nil {
+		return err
+	}
+	for _, name := range invalid {
+		if err := tx.Exec("DROP INDEX CONCURRENTLY IF EXISTS " + name).Error; err != nil {
+			return err
+		}
+	}
+
+	for _, index := range indexes {
+		if err := tx.Exec("CREATE INDEX CONCURRENTLY IF NOT EXISTS " + index.name + " ON " + index.definition).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
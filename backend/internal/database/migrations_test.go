@@ -0,0 +1,234 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, mock
+}
+
+func TestRunDataMigrationsSkipsAppliedAndRecordsNew(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	var ran []string
+	migrations := []dataMigration{
+		{name: "old", run: func(*gorm.DB) error { ran = append(ran, "old"); return nil }},
+		{name: "new", run: func(*gorm.DB) error { ran = append(ran, "new"); return nil }},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "data_migrations"`).WithArgs("old").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectCommit()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "data_migrations"`).WithArgs("new").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectExec(`INSERT INTO "data_migrations"`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := runDataMigrations(db, migrations, phaseExpand); err != nil {
+		t.Fatal(err)
+	}
+	if len(ran) != 1 || ran[0] != "new" {
+		t.Fatalf("ran = %v, want [new]", ran)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBackfillTaskCompletedAtOnlyTouchesCompletedTasks(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectExec(`UPDATE tasks SET completed_at = updated_at\s+WHERE status = 'completed' AND completed_at IS NULL`).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	if err := backfillTaskCompletedAt(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBackfillTaskAssigneesSkipsFreshDatabases(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectQuery(`SELECT count\(\*\) FROM INFORMATION_SCHEMA.columns`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	if err := backfillTaskAssignees(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBackfillTaskAssigneesCopiesLegacyColumn(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectQuery(`SELECT count\(\*\) FROM INFORMATION_SCHEMA.columns`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectExec(`INSERT INTO task_assignees .*SELECT id, assigned_to, true`).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	if err := backfillTaskAssignees(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBackfillNormalizedEmailsKeepsOldestOfDuplicates(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	mock.ExpectQuery(`SELECT "normalized_email" FROM "users" WHERE normalized_email IS NOT NULL`).
+		WillReturnRows(sqlmock.NewRows([]string{"normalized_email"}).AddRow("bo@example.com"))
+	mock.ExpectQuery(`SELECT "id","email" FROM "users" WHERE normalized_email IS NULL .* ORDER BY created_at ASC, id ASC`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).
+			AddRow("user-1", "Ana@Example.com").
+			AddRow("user-2", "ana@example.com").
+			AddRow("user-3", "BO@example.com"))
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "users" SET "normalized_email"=\$1 WHERE id = \$2`).
+		WithArgs("ana@example.com", "user-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := backfillNormalizedEmails(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckMigrationsWaitsForEveryDataMigration(t *testing.T) {
+	db, mock := newMockDB(t)
+	expectHasTable := func() {
+		mock.ExpectQuery(`SELECT count\(\*\) FROM information_schema.tables`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	}
+
+	expectHasTable()
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "data_migrations" WHERE name IN`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(len(dataMigrations) - 1))
+	if err := CheckMigrations(context.Background(), db); !errors.Is(err, ErrMigrationsPending) {
+		t.Fatalf("err = %v, want ErrMigrationsPending", err)
+	}
+
+	expectHasTable()
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "data_migrations" WHERE name IN`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(len(dataMigrations)))
+	if err := CheckMigrations(context.Background(), db); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckMigrationsBeforeFirstMigration(t *testing.T) {
+	db, mock := newMockDB(t)
+	mock.ExpectQuery(`SELECT count\(\*\) FROM information_schema.tables`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	if err := CheckMigrations(context.Background(), db); !errors.Is(err, ErrMigrationsPending) {
+		t.Fatalf("err = %v, want ErrMigrationsPending", err)
+	}
+}
+
+func TestCheckSchemaVersionRejectsUnknownContractMigrations(t *testing.T) {
+	db, mock := newMockDB(t)
+	migrations := []dataMigration{{name: "add_status_v2"}, {name: "drop_status_v1", contract: true}}
+	expectPhaseColumn := func() {
+		mock.ExpectQuery(`SELECT count\(\*\) FROM information_schema.tables`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectQuery(`SELECT count\(\*\) FROM INFORMATION_SCHEMA.columns`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	}
+
+	expectPhaseColumn()
+	mock.ExpectQuery(`SELECT "name" FROM "data_migrations" WHERE phase = \$1 AND name NOT IN \(\$2,\$3\)`).
+		WithArgs(phaseContract, "add_status_v2", "drop_status_v1").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}))
+	if err := checkSchemaVersion(context.Background(), db, migrations); err != nil {
+		t.Fatal(err)
+	}
+
+	// A build from before drop_status_v1 must not run after it
+	expectPhaseColumn()
+	mock.ExpectQuery(`SELECT "name" FROM "data_migrations" WHERE phase = \$1 AND name NOT IN \(\$2\)`).
+		WithArgs(phaseContract, "add_status_v2").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("drop_status_v1"))
+	if err := checkSchemaVersion(context.Background(), db, migrations[:1]); !errors.Is(err, ErrSchemaTooNew) {
+		t.Fatalf("err = %v, want ErrSchemaTooNew", err)
+	}
+}
+
+func TestRunDataMigrationsRunsOnlyThePhase(t *testing.T) {
+	db, mock := newMockDB(t)
+	var ran []string
+	migrations := []dataMigration{
+		{name: "drop_status_v1", contract: true, run: func(*gorm.DB) error { ran = append(ran, "drop_status_v1"); return nil }},
+	}
+
+	if err := runDataMigrations(db, migrations, phaseExpand); err != nil || len(ran) != 0 {
+		t.Fatalf("ran = %v, err = %v, want contract migrations skipped while expanding", ran, err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "data_migrations"`).WithArgs("drop_status_v1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectExec(`INSERT INTO "data_migrations" \("name","phase","applied_at"\)`).
+		WithArgs("drop_status_v1", phaseContract, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	if err := runDataMigrations(db, migrations, phaseContract); err != nil {
+		t.Fatal(err)
+	}
+	if len(ran) != 1 {
+		t.Fatalf("ran = %v, want the contract migration", ran)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCreateTaskFilterIndexesRebuildsInvalidIndexes(t *testing.T) {
+	db, mock := newMockDB(t)
+	mock.ExpectQuery(`SELECT c.relname FROM pg_index i .* WHERE NOT i.indisvalid AND c.relname IN`).
+		WillReturnRows(sqlmock.NewRows([]string{"relname"}).AddRow("idx_tasks_status_created_at"))
+	mock.ExpectExec(`DROP INDEX CONCURRENTLY IF EXISTS idx_tasks_status_created_at`).WillReturnResult(sqlmock.NewResult(0, 0))
+	for _, index := range taskFilterIndexes {
+		mock.ExpectExec(`CREATE INDEX CONCURRENTLY IF NOT EXISTS ` + index.name + ` ON`).WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+
+	if err := createTaskFilterIndexes(db); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,223 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// replicaResolver names the dbresolver resolver of the read replicas. It
+// is not a table, so only queries that ask for it use the replicas.
+const replicaResolver = "read_replicas"
+
+// replicaPingTimeout bounds each health check of a replica
+const replicaPingTimeout = 2 * time.Second
+
+// ErrNoHealthyReplica means every read replica failed its last health
+// check, so replica reads go to the primary
+var ErrNoHealthyReplica = errors.New("no read replica is healthy")
+
+type replicaReadsKey struct{}
+
+// ReadFromReplica lets the reads made with ctx go to a read replica. Use it
+// only for reads that may lag the latest writes by the replication delay,
+// never to reload what the same request wrote. Without replicas, or inside
+// a transaction, reads stay on the primary.
+func ReadFromReplica(ctx context.Context) context.Context {
+	return context.WithValue(ctx, replicaReadsKey{}, true)
+}
+
+// replica is one read replica and the outcome of its last health check
+type replica struct {
+	host    string
+	db      *sql.DB
+	healthy atomic.Bool
+}
+
+// replicaRouter sends the reads of ReadFromReplica contexts to the healthy
+// replicas in turn, and to the primary while none is healthy. Replicas are
+// pinged every interval; one that fails is skipped until it answers again.
+type replicaRouter struct {
+	replicas []*replica
+	primary  *sql.DB
+	next     atomic.Uint64
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// Name implements gorm.Plugin, so CheckReplicas can find the router
+func (r *replicaRouter) Name() string {
+	return "replica_router"
+}
+
+// Initialize routes the reads of ReadFromReplica contexts once dbresolver
+// has left the others on the primary. Using the resolver switches the
+// connection, unless in a transaction, so the policy is consulted once.
+func (r *replicaRouter) Initialize(db *gorm.DB) error {
+	use := dbresolver.Use(replicaResolver).(gorm.StatementModifier)
+	route := func(db *gorm.DB) {
+		if ctx := db.Statement.Context; ctx != nil && ctx.Value(replicaReadsKey{}) != nil {
+			use.ModifyStatement(db.Statement)
+		}
+	}
+	for _, err := range []error{
+		db.Callback().Query().After("gorm:db_resolver").Before("gorm:query").Register("replica_router:route", route),
+		db.Callback().Row().After("gorm:db_resolver").Before("gorm:row").Register("replica_router:route", route),
+		db.Callback().Raw().After("gorm:db_resolver").Before("gorm:raw").Register("replica_router:route", route),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Resolve implements dbresolver.Policy. It picks among the router's own
+// pools, which dbresolver was given as the resolver's replicas.
+func (r *replicaRouter) Resolve([]gorm.ConnPool) gorm.ConnPool {
+	start := r.next.Add(1)
+	for i := range r.replicas {
+		if replica := r.replicas[(start+uint64(i))%uint64(len(r.replicas))]; replica.healthy.Load() {
+			return replica.db
+		}
+	}
+	return r.primary
+}
+
+// useReplicas opens a pool to each of config's replicas and routes the
+// reads of ReadFromReplica contexts to them
+func useReplicas(db *gorm.DB, config Config) error {
+	primary, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get database instance: %w", err)
+	}
+	router := &replicaRouter{primary: primary, stop: make(chan struct{})}
+	for _, host := range config.Replicas {
+		replicaConfig := config
+		replicaConfig.Host, replicaConfig.Port = replicaAddress(host, config.Port)
+		pool, err := sql.Open("pgx", dsn(replicaConfig))
+		if err != nil {
+			return fmt.Errorf("failed to open read replica %s: %w", host, err)
+		}
+		pool.SetMaxIdleConns(10)
+		pool.SetMaxOpenConns(100)
+		pool.SetConnMaxLifetime(time.Hour)
+		router.add(host, pool)
+	}
+	if err := router.register(db); err != nil {
+		return err
+	}
+
+	router.check(context.Background())
+	go router.monitor(config.ReplicaCheckInterval)
+	return nil
+}
+
+// add takes pool as a replica, healthy until checked
+func (r *replicaRouter) add(host string, pool *sql.DB) {
+	replica := &replica{host: host, db: pool}
+	replica.healthy.Store(true)
+	r.replicas = append(r.replicas, replica)
+}
+
+// register hands the pools to dbresolver, under a resolver only the reads
+// of ReadFromReplica contexts use
+func (r *replicaRouter) register(db *gorm.DB) error {
+	// The primary is a replica too, as the fallback, so dbresolver
+	// consults the router even with a single replica
+	dialectors := []gorm.Dialector{postgres.New(postgres.Config{Conn: r.primary})}
+	for _, replica := range r.replicas {
+		dialectors = append(dialectors, postgres.New(postgres.Config{Conn: replica.db}))
+	}
+	if err := db.Use(dbresolver.Register(dbresolver.Config{
+		Replicas: dialectors,
+		Policy:   r,
+	}, replicaResolver)); err != nil {
+		return fmt.Errorf("failed to register read replicas: %w", err)
+	}
+	if err := db.Use(r); err != nil {
+		return fmt.Errorf("failed to register read replica routing: %w", err)
+	}
+	return nil
+}
+
+// replicaAddress splits host:port, taking defaultPort when host has none
+func replicaAddress(address string, defaultPort int) (string, int) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return address, defaultPort
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil {
+		return address, defaultPort
+	}
+	return host, n
+}
+
+func (r *replicaRouter) monitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			r.check(context.Background())
+		}
+	}
+}
+
+// check pings every replica, logging those that fail or recover, and
+// returns ErrNoHealthyReplica if none answered
+func (r *replicaRouter) check(ctx context.Context) error {
+	healthy := 0
+	for _, replica := range r.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, replicaPingTimeout)
+		err := replica.db.PingContext(pingCtx)
+		cancel()
+		if was := replica.healthy.Swap(err == nil); was != (err == nil) {
+			if err != nil {
+				log.Printf("Read replica %s is unhealthy, skipping it: %v", replica.host, err)
+			} else {
+				log.Printf("Read replica %s is healthy again", replica.host)
+			}
+		}
+		if err == nil {
+			healthy++
+		}
+	}
+	if healthy == 0 {
+		return ErrNoHealthyReplica
+	}
+	return nil
+}
+
+func (r *replicaRouter) close() {
+	r.stopOnce.Do(func() {
+		close(r.stop)
+		for _, replica := range r.replicas {
+			replica.db.Close()
+		}
+	})
+}
+
+// CheckReplicas pings the read replicas of db now, for readiness probes.
+// It returns ErrNoHealthyReplica if none answers, and nil without replicas.
+func CheckReplicas(ctx context.Context, db *gorm.DB) error {
+	router, ok := db.Config.Plugins[(&replicaRouter{}).Name()].(*replicaRouter)
+	if !ok {
+		return nil
+	}
+	return router.check(ctx)
+}
@@ -0,0 +1,88 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestReplicaRoutingSkipsUnhealthyReplicas(t *testing.T) {
+	db, primary := newMockDB(t)
+	primaryDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := &replicaRouter{primary: primaryDB, stop: make(chan struct{})}
+	replicas := make([]sqlmock.Sqlmock, 2)
+	for i := range replicas {
+		pool, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { pool.Close() })
+		router.add("replica", pool)
+		replicas[i] = mock
+		// dbresolver pings each pool as it opens it
+		mock.ExpectPing()
+	}
+	if err := router.register(db); err != nil {
+		t.Fatal(err)
+	}
+
+	count := func(mock sqlmock.Sqlmock, ctx context.Context) {
+		t.Helper()
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "tasks"`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		var n int64
+		if err := db.WithContext(ctx).Table("tasks").Count(&n).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	reads := ReadFromReplica(context.Background())
+
+	// Reads not marked for replicas stay on the primary
+	count(primary, context.Background())
+	// Marked reads take the replicas in turn
+	count(replicas[1], reads)
+	count(replicas[0], reads)
+
+	// A replica failing its check is skipped
+	replicas[0].ExpectPing().WillReturnError(errors.New("connection refused"))
+	replicas[1].ExpectPing()
+	if err := router.check(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	count(replicas[1], reads)
+	count(replicas[1], reads)
+
+	// With none healthy, marked reads fall back to the primary
+	for _, mock := range replicas {
+		mock.ExpectPing().WillReturnError(errors.New("connection refused"))
+	}
+	if err := router.check(context.Background()); !errors.Is(err, ErrNoHealthyReplica) {
+		t.Fatalf("check = %v, want ErrNoHealthyReplica", err)
+	}
+	count(primary, reads)
+
+	for _, mock := range append(replicas, primary) {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReplicaAddress(t *testing.T) {
+	for address, want := range map[string]struct {
+		host string
+		port int
+	}{
+		"replica-1":           {"replica-1", 5432},
+		"replica-2:6432":      {"replica-2", 6432},
+		"[2001:db8::1]:15432": {"2001:db8::1", 15432},
+	} {
+		if host, port := replicaAddress(address, 5432); host != want.host || port != want.port {
+			t.Errorf("replicaAddress(%q) = %s, %d, want %s, %d", address, host, port, want.host, want.port)
+		}
+	}
+}
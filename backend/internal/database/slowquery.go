@@ -0,0 +1,107 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// explainInterval is how often the plan of the same slow statement is
+	// logged, so a hot slow query does not double its own load
+	explainInterval = time.Minute
+	// explainTimeout bounds each EXPLAIN
+	explainTimeout = 5 * time.Second
+
+	slowQueryStartKey = "slow_query:start"
+)
+
+// slowQueryLogger logs the plans of queries slower than threshold. Plans
+// come from a plain EXPLAIN, which does not run the query again, made in
+// the background on the pool the query ran on. Queries in transactions
+// are skipped, as a failed EXPLAIN would abort them.
+type slowQueryLogger struct {
+	threshold time.Duration
+	// explained holds when each statement's plan was last logged
+	explained sync.Map
+	logf      func(format string, args ...interface{})
+}
+
+func newSlowQueryLogger(threshold time.Duration) *slowQueryLogger {
+	return &slowQueryLogger{threshold: threshold, logf: log.Printf}
+}
+
+func (l *slowQueryLogger) Name() string {
+	return "slow_query_logger"
+}
+
+func (l *slowQueryLogger) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Query().Before("gorm:query").Register("slow_query:start", func(db *gorm.DB) {
+		db.InstanceSet(slowQueryStartKey, time.Now())
+	}); err != nil {
+		return err
+	}
+	return db.Callback().Query().After("gorm:query").Register("slow_query:explain", l.check)
+}
+
+func (l *slowQueryLogger) check(db *gorm.DB) {
+	start, ok := db.InstanceGet(slowQueryStartKey)
+	if !ok || db.Error != nil {
+		return
+	}
+	elapsed := time.Since(start.(time.Time))
+	if elapsed < l.threshold {
+		return
+	}
+	pool := db.Statement.ConnPool
+	if _, ok := pool.(gorm.TxCommitter); ok {
+		return
+	}
+	// The prepared statement cache would keep every EXPLAIN
+	if prepared, ok := pool.(*gorm.PreparedStmtDB); ok {
+		pool = prepared.ConnPool
+	}
+
+	sql := db.Statement.SQL.String()
+	now := time.Now()
+	if last, ok := l.explained.Load(sql); ok && now.Sub(last.(time.Time)) < explainInterval {
+		return
+	}
+	l.explained.Store(sql, now)
+
+	vars := append([]interface{}(nil), db.Statement.Vars...)
+	go func() {
+		plan, err := explain(pool, sql, vars)
+		if err != nil {
+			l.logf("Slow query (%s), EXPLAIN failed: %v\n%s", elapsed, err, sql)
+			return
+		}
+		l.logf("Slow query (%s):\n%s\n%s", elapsed, sql, plan)
+	}()
+}
+
+// explain returns the plan of sql with vars, one line per plan row
+func explain(pool gorm.ConnPool, sql string, vars []interface{}) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), explainTimeout)
+	defer cancel()
+	rows, err := pool.QueryContext(ctx, "EXPLAIN "+sql, vars...)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var plan strings.Builder
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return "", err
+		}
+		fmt.Fprintln(&plan, line)
+	}
+	return plan.String(), rows.Err()
+}
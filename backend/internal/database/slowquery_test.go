@@ -0,0 +1,53 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestSlowQueryLoggerExplainsSlowQueriesOnce(t *testing.T) {
+	db, mock := newMockDB(t)
+	logged := make(chan string, 2)
+	logger := newSlowQueryLogger(0)
+	logger.logf = func(format string, args ...interface{}) { logged <- fmt.Sprintf(format, args...) }
+	if err := db.Use(logger); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "tasks" WHERE status = \$1`).WithArgs("pending").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+		if i == 0 {
+			mock.ExpectQuery(`EXPLAIN SELECT count\(\*\) FROM "tasks" WHERE status = \$1`).WithArgs("pending").
+				WillReturnRows(sqlmock.NewRows([]string{"QUERY PLAN"}).AddRow("Seq Scan on tasks"))
+		}
+		var n int64
+		if err := db.Table("tasks").Where("status = ?", "pending").Count(&n).Error; err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			select {
+			case line := <-logged:
+				if !strings.Contains(line, "Seq Scan on tasks") {
+					t.Fatalf("logged %q, want the plan", line)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("slow query not logged")
+			}
+		}
+	}
+
+	// The same statement again within the interval is not explained
+	select {
+	case line := <-logged:
+		t.Fatalf("logged %q again", line)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,86 @@
+package devdata
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+)
+
+// namespace makes generated task IDs distinct from random UUIDs
+var namespace = uuid.MustParse("5d3c1b8e-4a0f-4c36-9f5e-2b7f0a6d9e41")
+
+type weighted[T any] struct {
+	value  T
+	weight int
+}
+
+func pick[T any](rng *rand.Rand, choices []weighted[T]) T {
+	total := 0
+	for _, c := range choices {
+		total += c.weight
+	}
+	n := rng.Intn(total)
+	for _, c := range choices {
+		if n < c.weight {
+			return c.value
+		}
+		n -= c.weight
+	}
+	return choices[len(choices)-1].value
+}
+
+var (
+	statusWeights = []weighted[models.TaskStatus]{
+		{models.StatusPending, 45}, {models.StatusInProgress, 30}, {models.StatusCompleted, 25},
+	}
+	priorityWeights = []weighted[models.TaskPriority]{
+		{models.PriorityLow, 30}, {models.PriorityMedium, 50}, {models.PriorityHigh, 20},
+	}
+)
+
+// generateTask builds task i of the dataset for seed. Each task has its
+// own random source, so a smaller dataset is a prefix of a larger one.
+// Times are offsets from anchor; IDs are derived from the creator, seed
+// and index, so generating again inserts nothing new.
+func generateTask(creatorID string, seed int64, i int, anchor time.Time) (models.Task, bool) {
+	rng := rand.New(rand.NewSource(seed*1_000_003 + int64(i)))
+	day := 24 * time.Hour
+
+	task := models.Task{
+		ID:              uuid.NewSHA1(namespace, []byte(fmt.Sprintf("%s/%d/%d", creatorID, seed, i))).String(),
+		Title:           verbs[rng.Intn(len(verbs))] + " " + subjects[rng.Intn(len(subjects))],
+		Status:          pick(rng, statusWeights),
+		Priority:        pick(rng, priorityWeights),
+		CreatedBy:       creatorID,
+		Project:         projects[rng.Intn(len(projects))],
+		EstimatedEffort: efforts[rng.Intn(len(efforts))],
+	}
+	task.Description = fmt.Sprintf("Generated task %d for seed %d in %s.", i+1, seed, task.Project)
+	task.CreatedAt = anchor.Add(-time.Duration(1+rng.Intn(60)) * day)
+
+	switch task.Status {
+	case models.StatusCompleted:
+		// Finished some time after creation, mostly before the due date
+		hours := int(anchor.Sub(task.CreatedAt) / time.Hour)
+		completedAt := task.CreatedAt.Add(time.Duration(1+rng.Intn(hours-1)) * time.Hour)
+		task.CompletedAt = &completedAt
+		task.DueDate = completedAt.Add(time.Duration(rng.Intn(6)-1) * day)
+	default:
+		// About one open task in six is overdue
+		task.DueDate = anchor.Add(time.Duration(rng.Intn(37)-6) * day)
+	}
+	task.UpdatedAt = task.CreatedAt
+	if task.CompletedAt != nil {
+		task.UpdatedAt = *task.CompletedAt
+	}
+
+	// Three in five tasks are assigned to the creator, the rest unassigned
+	assigned := rng.Intn(5) < 3
+	if assigned {
+		task.AssignedTo = creatorID
+	}
+	return task, assigned
+}
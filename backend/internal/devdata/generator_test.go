@@ -0,0 +1,171 @@
+package devdata
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var anchor = time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+
+func TestGenerateTaskIsDeterministic(t *testing.T) {
+	a, _ := generateTask("user-1", 7, 3, anchor)
+	b, _ := generateTask("user-1", 7, 3, anchor)
+	if !reflect.DeepEqual(a, b) {
+		t.Fatalf("same inputs gave different tasks:\n%+v\n%+v", a, b)
+	}
+
+	other, _ := generateTask("user-1", 8, 3, anchor)
+	if other.ID == a.ID {
+		t.Fatal("different seeds gave the same task ID")
+	}
+	otherUser, _ := generateTask("user-2", 7, 3, anchor)
+	if otherUser.ID == a.ID {
+		t.Fatal("different creators gave the same task ID")
+	}
+}
+
+func TestGeneratedDistributions(t *testing.T) {
+	const n = 2000
+	statuses := map[models.TaskStatus]int{}
+	priorities := map[models.TaskPriority]int{}
+	overdue := 0
+	for i := 0; i < n; i++ {
+		task, _ := generateTask("user-1", 1, i, anchor)
+		statuses[task.Status]++
+		priorities[task.Priority]++
+
+		if task.Title == "" || task.Project == "" {
+			t.Fatalf("task %d has no title or project: %+v", i, task)
+		}
+		if (task.Status == models.StatusCompleted) != (task.CompletedAt != nil) {
+			t.Fatalf("task %d: status %s with completed_at %v", i, task.Status, task.CompletedAt)
+		}
+		if task.CompletedAt != nil && (task.CompletedAt.After(anchor) || task.CompletedAt.Before(task.CreatedAt)) {
+			t.Fatalf("task %d completed at %s, outside its lifetime", i, task.CompletedAt)
+		}
+		if task.Status != models.StatusCompleted && task.DueDate.Before(anchor) {
+			overdue++
+		}
+	}
+
+	within := func(got, wantPercent int) bool {
+		return got > n*(wantPercent-5)/100 && got < n*(wantPercent+5)/100
+	}
+	if !within(statuses[models.StatusPending], 45) || !within(statuses[models.StatusCompleted], 25) {
+		t.Fatalf("statuses = %v, want about 45%% pending and 25%% completed", statuses)
+	}
+	if !within(priorities[models.PriorityMedium], 50) || !within(priorities[models.PriorityHigh], 20) {
+		t.Fatalf("priorities = %v, want about 50%% medium and 20%% high", priorities)
+	}
+	if open := n - statuses[models.StatusCompleted]; overdue == 0 || overdue > open/3 {
+		t.Fatalf("%d of %d open tasks overdue, want some but not most", overdue, open)
+	}
+}
+
+func newTestService(t *testing.T) (*Service, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewService(db)
+	s.now = func() time.Time { return anchor.Add(15 * time.Hour) }
+	return s, mock
+}
+
+func TestGenerateSkipsExistingTasks(t *testing.T) {
+	s, mock := newTestService(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "tasks" WHERE id IN`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(6))
+	mock.ExpectQuery(`INSERT INTO "tasks" .* ON CONFLICT DO NOTHING`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}))
+	mock.ExpectQuery(`INSERT INTO "task_assignees" .* ON CONFLICT DO NOTHING`).WillReturnRows(sqlmock.NewRows([]string{"is_primary"}))
+	mock.ExpectCommit()
+
+	result, err := s.Generate(context.Background(), "user-1", GenerateParams{Tasks: 10, Seed: 42})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Created != 4 || result.Existing != 6 || result.Anchor != "2026-03-10T00:00:00Z" {
+		t.Fatalf("result = %+v, want 4 created and 6 existing as of the start of the day", result)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerateRejectsInvalidCounts(t *testing.T) {
+	s, _ := newTestService(t)
+	for _, n := range []int{-1, maxTasks + 1} {
+		if _, err := s.Generate(context.Background(), "user-1", GenerateParams{Tasks: n}); err != ErrInvalidCount {
+			t.Errorf("tasks=%d: err = %v, want ErrInvalidCount", n, err)
+		}
+	}
+}
+
+func TestSeedTaskSpreadsWorkAcrossTheTeam(t *testing.T) {
+	users := []string{"user-1", "user-2", "user-3", "user-4"}
+	creators := map[string]int{}
+	assignedToOthers := 0
+	for i := 0; i < 400; i++ {
+		task, assignee := seedTask(users, 1, i, anchor)
+		creators[task.CreatedBy]++
+		if (assignee != nil) != (task.AssignedTo != "") {
+			t.Fatalf("task %d: assignee %+v but assigned to %q", i, assignee, task.AssignedTo)
+		}
+		if assignee != nil && assignee.UserID != task.CreatedBy {
+			assignedToOthers++
+		}
+	}
+	if len(creators) != len(users) || assignedToOthers == 0 {
+		t.Fatalf("creators = %v, %d tasks assigned by others, want both spread across the team", creators, assignedToOthers)
+	}
+}
+
+func TestSeedCreatesUsersAndTasks(t *testing.T) {
+	Profiles["test"] = Profile{Users: 2, Tasks: 5}
+	t.Cleanup(func() { delete(Profiles, "test") })
+
+	s, mock := newTestService(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "users" .* ON CONFLICT DO NOTHING`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "tasks" WHERE id IN`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(`INSERT INTO "tasks" .* ON CONFLICT DO NOTHING`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}))
+	mock.ExpectQuery(`INSERT INTO "task_assignees" .* ON CONFLICT DO NOTHING`).WillReturnRows(sqlmock.NewRows([]string{"is_primary"}))
+	mock.ExpectCommit()
+
+	result, err := s.Seed(context.Background(), "test", 1, "seed-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"test.user01@seed.example.com", "test.user02@seed.example.com"}
+	if result.Created != 5 || !reflect.DeepEqual(result.Emails, want) {
+		t.Fatalf("result = %+v, want 5 tasks created for %v", result, want)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Seed(context.Background(), "huge", 1, "seed-password"); !errors.Is(err, ErrUnknownProfile) {
+		t.Fatalf("err = %v, want ErrUnknownProfile", err)
+	}
+}
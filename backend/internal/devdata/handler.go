@@ -0,0 +1,42 @@
+package devdata
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"go.uber.org/zap"
+)
+
+type Handler struct {
+	service *Service
+	logger  *zap.Logger
+}
+
+func NewHandler(service *Service, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *Handler) Generate(c *gin.Context) {
+	var params GenerateParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		_ = c.Error(common.NewValidationError(err))
+		return
+	}
+
+	result, err := h.service.Generate(c.Request.Context(), c.GetString("user_id"), params)
+	if err != nil {
+		if err == ErrInvalidCount {
+			_ = c.Error(common.NewInvalidInputError(err.Error()))
+			return
+		}
+		h.logger.Error("Failed to generate test data", zap.Error(err))
+		_ = c.Error(common.NewInternalServerError("failed to generate test data"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
@@ -0,0 +1,40 @@
+package devdata
+
+import "errors"
+
+const (
+	defaultTasks = 100
+	maxTasks     = 10000
+)
+
+var ErrInvalidCount = errors.New("tasks must be between 1 and 10000")
+
+type GenerateParams struct {
+	Tasks int   `form:"tasks"`
+	Seed  int64 `form:"seed"`
+}
+
+// GenerateResult counts the tasks of the dataset; Existing ones were
+// already there from an earlier run with the same seed
+type GenerateResult struct {
+	Seed     int64  `json:"seed"`
+	Tasks    int    `json:"tasks"`
+	Created  int64  `json:"created"`
+	Existing int64  `json:"existing"`
+	Anchor   string `json:"anchor"`
+}
+
+// Word lists are curated so generated titles are always presentable
+var (
+	verbs = []string{
+		"Update", "Review", "Fix", "Document", "Test", "Refactor", "Design", "Deploy",
+		"Migrate", "Measure", "Plan", "Prototype",
+	}
+	subjects = []string{
+		"login form", "invoice export", "search index", "onboarding emails", "billing page",
+		"release checklist", "API rate limits", "mobile navigation", "audit log", "dashboard charts",
+		"password reset flow", "team settings", "notification preferences", "CSV import",
+	}
+	projects = []string{"website-redesign", "mobile-app", "billing", "onboarding", "infrastructure"}
+	efforts  = []float64{1, 2, 3, 5, 8}
+)
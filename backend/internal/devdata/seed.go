@@ -0,0 +1,148 @@
+package devdata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrUnknownProfile = errors.New("unknown seed profile")
+
+// Profile sizes a seeded dataset: a team of users sharing tasks across the
+// generator's projects
+type Profile struct {
+	Users int
+	Tasks int
+}
+
+// Profiles are the datasets the seed command can create. demo fills every
+// screen; load has enough tasks to page and filter through in load tests.
+var Profiles = map[string]Profile{
+	"demo": {Users: 12, Tasks: 2500},
+	"load": {Users: 200, Tasks: 100000},
+}
+
+// ProfileNames lists the profiles in order, for help and error messages
+func ProfileNames() []string {
+	names := make([]string, 0, len(Profiles))
+	for name := range Profiles {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// SeedResult counts the tasks of the dataset like GenerateResult, and
+// gives the accounts to sign in with
+type SeedResult struct {
+	Profile  string
+	Seed     int64
+	Emails   []string
+	Tasks    int
+	Created  int64
+	Existing int64
+	Anchor   time.Time
+}
+
+// seedEmail is the address of user i of profile
+func seedEmail(profile string, i int) string {
+	return fmt.Sprintf("%s.user%02d@seed.example.com", profile, i+1)
+}
+
+// seedTask builds task i of the dataset among users. The creator and the
+// assignee are drawn from the team, so every user has tasks of their own
+// and tasks assigned by others.
+func seedTask(users []string, seed int64, i int, anchor time.Time) (models.Task, *models.TaskAssignee) {
+	rng := rand.New(rand.NewSource(-(seed*1_000_003 + int64(i))))
+	task, assigned := generateTask(users[rng.Intn(len(users))], seed, i, anchor)
+	if !assigned {
+		return task, nil
+	}
+	task.AssignedTo = users[rng.Intn(len(users))]
+	return task, &models.TaskAssignee{
+		TaskID:    task.ID,
+		UserID:    task.AssignedTo,
+		IsPrimary: true,
+		CreatedAt: task.CreatedAt,
+	}
+}
+
+// Seed inserts the users and tasks of profile for seed, all signing in with
+// password. Like Generate it is idempotent: users and tasks that already
+// exist, as from an earlier run, are left untouched, passwords included.
+func (s *Service) Seed(ctx context.Context, profileName string, seed int64, password string) (*SeedResult, error) {
+	profile, ok := Profiles[profileName]
+	if !ok {
+		return nil, fmt.Errorf("%w %q, want one of %s", ErrUnknownProfile, profileName, strings.Join(ProfileNames(), ", "))
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	anchor := s.now().UTC().Truncate(24 * time.Hour)
+	users := make([]models.User, 0, profile.Users)
+	ids := make([]string, 0, profile.Users)
+	emails := make([]string, 0, profile.Users)
+	for i := 0; i < profile.Users; i++ {
+		email := seedEmail(profileName, i)
+		normalized := models.NormalizeEmail(email, false)
+		user := models.User{
+			ID:              uuid.NewSHA1(namespace, []byte(fmt.Sprintf("user/%s/%d", profileName, i))).String(),
+			Email:           email,
+			NormalizedEmail: &normalized,
+			Password:        string(hashed),
+			AIPlan:          "free",
+			CreatedAt:       anchor.Add(-90 * 24 * time.Hour),
+			UpdatedAt:       anchor.Add(-90 * 24 * time.Hour),
+		}
+		users = append(users, user)
+		ids = append(ids, user.ID)
+		emails = append(emails, email)
+	}
+
+	tasks := make([]models.Task, 0, profile.Tasks)
+	var assignees []models.TaskAssignee
+	for i := 0; i < profile.Tasks; i++ {
+		task, assignee := seedTask(ids, seed, i, anchor)
+		tasks = append(tasks, task)
+		if assignee != nil {
+			assignees = append(assignees, *assignee)
+		}
+	}
+
+	var existing int64
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Omit(clause.Associations).
+			Create(&users).Error; err != nil {
+			return err
+		}
+		var err error
+		existing, err = insertTasks(tx, tasks, assignees)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to seed %s: %w", profileName, err)
+	}
+
+	return &SeedResult{
+		Profile:  profileName,
+		Seed:     seed,
+		Emails:   emails,
+		Tasks:    profile.Tasks,
+		Created:  int64(profile.Tasks) - existing,
+		Existing: existing,
+		Anchor:   anchor,
+	}, nil
+}
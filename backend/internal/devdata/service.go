@@ -0,0 +1,100 @@
+package devdata
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const insertBatchSize = 500
+
+type Service struct {
+	db  *gorm.DB
+	now func() time.Time
+}
+
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db, now: time.Now}
+}
+
+// Generate inserts the seeded dataset of creatorID. Dates are relative to
+// the start of the current UTC day, so the same seed gives the same data
+// all day. Tasks that already exist are left untouched.
+func (s *Service) Generate(ctx context.Context, creatorID string, params GenerateParams) (*GenerateResult, error) {
+	if params.Tasks == 0 {
+		params.Tasks = defaultTasks
+	}
+	if params.Tasks < 1 || params.Tasks > maxTasks {
+		return nil, ErrInvalidCount
+	}
+
+	anchor := s.now().UTC().Truncate(24 * time.Hour)
+	tasks := make([]models.Task, 0, params.Tasks)
+	var assignees []models.TaskAssignee
+	for i := 0; i < params.Tasks; i++ {
+		task, assigned := generateTask(creatorID, params.Seed, i, anchor)
+		tasks = append(tasks, task)
+		if assigned {
+			assignees = append(assignees, models.TaskAssignee{
+				TaskID:    task.ID,
+				UserID:    creatorID,
+				IsPrimary: true,
+				CreatedAt: task.CreatedAt,
+			})
+		}
+	}
+
+	var existing int64
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		existing, err = insertTasks(tx, tasks, assignees)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate tasks: %w", err)
+	}
+
+	return &GenerateResult{
+		Seed:     params.Seed,
+		Tasks:    params.Tasks,
+		Created:  int64(params.Tasks) - existing,
+		Existing: existing,
+		Anchor:   anchor.Format(time.RFC3339),
+	}, nil
+}
+
+// insertTasks inserts the tasks and assignees that do not exist yet and
+// returns how many of the tasks did
+func insertTasks(tx *gorm.DB, tasks []models.Task, assignees []models.TaskAssignee) (int64, error) {
+	// Count first: RowsAffected is unreliable for batched inserts that
+	// skip conflicts. Deleted tasks still hold their IDs.
+	var existing int64
+	for start := 0; start < len(tasks); start += insertBatchSize {
+		end := min(start+insertBatchSize, len(tasks))
+		ids := make([]string, 0, end-start)
+		for _, task := range tasks[start:end] {
+			ids = append(ids, task.ID)
+		}
+		var n int64
+		if err := tx.Unscoped().Model(&models.Task{}).Where("id IN ?", ids).Count(&n).Error; err != nil {
+			return 0, err
+		}
+		existing += n
+	}
+
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Omit(clause.Associations).
+		CreateInBatches(&tasks, insertBatchSize).Error; err != nil {
+		return 0, err
+	}
+
+	if len(assignees) == 0 {
+		return existing, nil
+	}
+	return existing, tx.Clauses(clause.OnConflict{DoNothing: true}).
+		CreateInBatches(&assignees, insertBatchSize).Error
+}
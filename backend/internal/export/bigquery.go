@@ -0,0 +1,244 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	bigQueryBaseURL = "https://bigquery.googleapis.com/bigquery/v2"
+	bigQueryScope   = "https://www.googleapis.com/auth/bigquery"
+)
+
+type BigQueryConfig struct {
+	Project string
+	Dataset string
+}
+
+// BigQuery appends rows through the streaming insert API. Credentials come
+// from Application Default Credentials.
+type BigQuery struct {
+	config  BigQueryConfig
+	client  *http.Client
+	baseURL string
+}
+
+type bigQueryField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Mode string `json:"mode,omitempty"`
+}
+
+type bigQuerySchema struct {
+	Fields []bigQueryField `json:"fields"`
+}
+
+type bigQueryError struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func NewBigQuery(ctx context.Context, config BigQueryConfig) (*BigQuery, error) {
+	if config.Project == "" || config.Dataset == "" {
+		return nil, fmt.Errorf("bigquery export requires a project and dataset")
+	}
+
+	client, err := google.DefaultClient(ctx, bigQueryScope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Google credentials: %w", err)
+	}
+
+	return &BigQuery{
+		config: config,
+		client: client,
+		baseURL: fmt.Sprintf("%s/projects/%s", bigQueryBaseURL,
+			url.PathEscape(config.Project)),
+	}, nil
+}
+
+func (b *BigQuery) Name() string {
+	return "bigquery"
+}
+
+func (b *BigQuery) EnsureTable(ctx context.Context, table Table) error {
+	if err := b.ensureDataset(ctx); err != nil {
+		return err
+	}
+
+	tablePath := b.datasetPath() + "/tables/" + url.PathEscape(table.Name)
+
+	var existing struct {
+		Schema bigQuerySchema `json:"schema"`
+	}
+	status, err := b.do(ctx, http.MethodGet, tablePath, nil, &existing)
+	if err != nil && status != http.StatusNotFound {
+		return fmt.Errorf("failed to get table %s: %w", table.Name, err)
+	}
+
+	if status == http.StatusNotFound {
+		body := map[string]interface{}{
+			"tableReference": map[string]string{
+				"projectId": b.config.Project,
+				"datasetId": b.config.Dataset,
+				"tableId":   table.Name,
+			},
+			"schema":           bigQuerySchema{Fields: bigQueryFields(table.Columns)},
+			"timePartitioning": map[string]string{"type": "DAY", "field": "changed_at"},
+		}
+		if _, err := b.do(ctx, http.MethodPost, b.datasetPath()+"/tables", body, nil); err != nil {
+			return fmt.Errorf("failed to create table %s: %w", table.Name, err)
+		}
+		return nil
+	}
+
+	// Append columns the warehouse table does not have yet
+	known := make(map[string]bool, len(existing.Schema.Fields))
+	for _, f := range existing.Schema.Fields {
+		known[strings.ToLower(f.Name)] = true
+	}
+	fields := existing.Schema.Fields
+	for _, f := range bigQueryFields(table.Columns) {
+		if !known[f.Name] {
+			fields = append(fields, f)
+		}
+	}
+	if len(fields) == len(existing.Schema.Fields) {
+		return nil
+	}
+
+	body := map[string]interface{}{"schema": bigQuerySchema{Fields: fields}}
+	if _, err := b.do(ctx, http.MethodPatch, tablePath, body, nil); err != nil {
+		return fmt.Errorf("failed to update schema of %s: %w", table.Name, err)
+	}
+	return nil
+}
+
+func (b *BigQuery) Load(ctx context.Context, table Table, rows []Row) error {
+	type insertRow struct {
+		InsertID string `json:"insertId"`
+		JSON     Row    `json:"json"`
+	}
+
+	body := struct {
+		Rows []insertRow `json:"rows"`
+	}{Rows: make([]insertRow, 0, len(rows))}
+	for _, row := range rows {
+		// insertId lets BigQuery drop duplicates when a failed run is retried
+		body.Rows = append(body.Rows, insertRow{
+			InsertID: fmt.Sprintf("%v@%v", row["id"], row["changed_at"]),
+			JSON:     row,
+		})
+	}
+
+	var resp struct {
+		InsertErrors []struct {
+			Index  int `json:"index"`
+			Errors []struct {
+				Reason  string `json:"reason"`
+				Message string `json:"message"`
+			} `json:"errors"`
+		} `json:"insertErrors"`
+	}
+	path := b.datasetPath() + "/tables/" + url.PathEscape(table.Name) + "/insertAll"
+	if _, err := b.do(ctx, http.MethodPost, path, body, &resp); err != nil {
+		return fmt.Errorf("failed to insert rows into %s: %w", table.Name, err)
+	}
+
+	if len(resp.InsertErrors) > 0 {
+		first := resp.InsertErrors[0]
+		msg := "unknown error"
+		if len(first.Errors) > 0 {
+			msg = first.Errors[0].Reason + ": " + first.Errors[0].Message
+		}
+		return fmt.Errorf("%d rows rejected by %s (row %d: %s)", len(resp.InsertErrors), table.Name, first.Index, msg)
+	}
+	return nil
+}
+
+func (b *BigQuery) datasetPath() string {
+	return b.baseURL + "/datasets/" + url.PathEscape(b.config.Dataset)
+}
+
+func (b *BigQuery) ensureDataset(ctx context.Context) error {
+	status, err := b.do(ctx, http.MethodGet, b.datasetPath(), nil, nil)
+	if err == nil {
+		return nil
+	}
+	if status != http.StatusNotFound {
+		return fmt.Errorf("failed to get dataset: %w", err)
+	}
+
+	body := map[string]interface{}{
+		"datasetReference": map[string]string{
+			"projectId": b.config.Project,
+			"datasetId": b.config.Dataset,
+		},
+	}
+	if _, err := b.do(ctx, http.MethodPost, b.baseURL+"/datasets", body, nil); err != nil {
+		return fmt.Errorf("failed to create dataset: %w", err)
+	}
+	return nil
+}
+
+// do sends a JSON request and decodes the response into out. The status code
+// is returned alongside errors so callers can treat 404 specially.
+func (b *BigQuery) do(ctx context.Context, method, endpoint string, body, out interface{}) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+
+	if resp.StatusCode >= 300 {
+		var apiErr bigQueryError
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Error.Message != "" {
+			return resp.StatusCode, fmt.Errorf("bigquery: %s", apiErr.Error.Message)
+		}
+		return resp.StatusCode, fmt.Errorf("bigquery responded with status %d", resp.StatusCode)
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			return resp.StatusCode, fmt.Errorf("failed to decode bigquery response: %w", err)
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+func bigQueryFields(columns []Column) []bigQueryField {
+	fields := make([]bigQueryField, 0, len(columns))
+	for _, c := range columns {
+		fields = append(fields, bigQueryField{Name: c.Name, Type: string(c.Type), Mode: "NULLABLE"})
+	}
+	return fields
+}
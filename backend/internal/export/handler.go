@@ -0,0 +1,43 @@
+package export
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"go.uber.org/zap"
+)
+
+type Handler struct {
+	service *Service
+	logger  *zap.Logger
+}
+
+func NewHandler(service *Service, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *Handler) Status(c *gin.Context) {
+	resp, err := h.service.Status(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to load export status", zap.Error(err))
+		_ = c.Error(common.NewInternalServerError("failed to load export status"))
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// TriggerRun starts an export outside the schedule. The export runs in the
+// background; poll the status endpoint for the result.
+func (h *Handler) TriggerRun(c *gin.Context) {
+	if err := h.service.Trigger(c.Request.Context(), c.GetString("user_id")); err != nil {
+		_ = c.Error(common.NewConflictError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "export started"})
+}
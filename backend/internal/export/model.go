@@ -0,0 +1,51 @@
+package export
+
+import (
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+)
+
+type ExportRun = models.ExportRun
+
+type ColumnType string
+
+const (
+	TypeString    ColumnType = "STRING"
+	TypeInteger   ColumnType = "INTEGER"
+	TypeFloat     ColumnType = "FLOAT"
+	TypeBoolean   ColumnType = "BOOLEAN"
+	TypeTimestamp ColumnType = "TIMESTAMP"
+)
+
+type Column struct {
+	Name string
+	Type ColumnType
+}
+
+// Table describes a source table and the warehouse table it is exported to.
+// Columns are only ever added, so warehouse schemas evolve without rewrites.
+type Table struct {
+	Name    string
+	Columns []Column
+	// query selects Columns for rows whose changed_at falls in (from, to]
+	query string
+}
+
+// Row is one exported record keyed by column name
+type Row map[string]interface{}
+
+type TableStatus struct {
+	Source        string     `json:"source"`
+	LastRun       *ExportRun `json:"last_run,omitempty"`
+	Watermark     *time.Time `json:"watermark,omitempty"`
+	TotalExported int64      `json:"total_exported"`
+}
+
+type StatusResponse struct {
+	Destination string        `json:"destination"`
+	Interval    string        `json:"interval"`
+	Running     bool          `json:"running"`
+	NextRunAt   *time.Time    `json:"next_run_at,omitempty"`
+	Tables      []TableStatus `json:"tables"`
+}
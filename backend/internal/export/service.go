@@ -0,0 +1,357 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+	"github.com/iSparshP/real-time-task-management-system/internal/security"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	exportBatchSize = 500
+	// commitLag keeps the watermark behind transactions that may still commit
+	// with an earlier updated_at
+	commitLag = 30 * time.Second
+)
+
+var ErrExportRunning = errors.New("an export is already running")
+
+type Service struct {
+	db        *gorm.DB
+	warehouse Warehouse
+	tables    []Table
+	interval  time.Duration
+	logger    *zap.Logger
+	events    *security.Service
+
+	running   sync.Mutex
+	mu        sync.Mutex
+	ensured   map[string]bool
+	nextRunAt *time.Time
+
+	// background is the lifetime of the scheduler; manual runs use it so
+	// they stop on shutdown rather than outliving the request
+	background context.Context
+}
+
+func NewService(db *gorm.DB, warehouse Warehouse, interval time.Duration, logger *zap.Logger) *Service {
+	return &Service{
+		db:        db,
+		warehouse: warehouse,
+		tables:    Tables,
+		interval:  interval,
+		logger:    logger,
+		ensured:   make(map[string]bool),
+
+		background: context.Background(),
+	}
+}
+
+// SetEventRecorder reports each completed export run as a security event
+func (s *Service) SetEventRecorder(events *security.Service) {
+	s.events = events
+}
+
+// Start runs an export immediately and then every interval until ctx is
+// cancelled. Runs left marked running by a previous process are failed
+// first, since that process can no longer finish them.
+func (s *Service) Start(ctx context.Context) {
+	s.mu.Lock()
+	s.background = ctx
+	s.mu.Unlock()
+
+	if err := s.failInterruptedRuns(ctx); err != nil {
+		s.logger.Error("Failed to clean up interrupted export runs", zap.Error(err))
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			s.setNextRun(time.Now().Add(s.interval))
+			if _, err := s.Run(ctx); err != nil && err != ErrExportRunning {
+				s.logger.Error("Scheduled export failed", zap.Error(err))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Run exports every table once. Tables are independent, so a failure in one
+// does not stop the others; the first error is returned.
+func (s *Service) Run(ctx context.Context) ([]ExportRun, error) {
+	if !s.running.TryLock() {
+		return nil, ErrExportRunning
+	}
+	defer s.running.Unlock()
+
+	var firstErr error
+	runs := make([]ExportRun, 0, len(s.tables))
+	for _, table := range s.tables {
+		run, err := s.exportTable(ctx, table)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if run != nil {
+			runs = append(runs, *run)
+		}
+	}
+	return runs, firstErr
+}
+
+// Trigger starts an export in the background on behalf of a user
+func (s *Service) Trigger(ctx context.Context, userID string) error {
+	if s.IsRunning() {
+		return ErrExportRunning
+	}
+
+	s.events.Record(ctx, security.EventDataExportRequested, userID, map[string]interface{}{
+		"destination": s.warehouse.Name(),
+	})
+
+	s.mu.Lock()
+	background := s.background
+	s.mu.Unlock()
+
+	go func() {
+		if _, err := s.Run(background); err != nil && err != ErrExportRunning {
+			s.logger.Error("Manual export failed", zap.Error(err))
+		}
+	}()
+	return nil
+}
+
+// failInterruptedRuns marks this destination's runs still recorded as
+// running as failed. It assumes a single exporting process per destination,
+// which the running lock already requires.
+func (s *Service) failInterruptedRuns(ctx context.Context) error {
+	result := s.db.WithContext(ctx).Model(&ExportRun{}).
+		Where("destination = ? AND status = ?", s.warehouse.Name(), models.ExportRunning).
+		Updates(map[string]interface{}{
+			"status":      models.ExportFailed,
+			"error":       "interrupted before finishing",
+			"finished_at": time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		s.logger.Warn("Marked interrupted export runs as failed", zap.Int64("runs", result.RowsAffected))
+	}
+	return nil
+}
+
+// IsRunning reports whether an export is in progress
+func (s *Service) IsRunning() bool {
+	if s.running.TryLock() {
+		s.running.Unlock()
+		return false
+	}
+	return true
+}
+
+func (s *Service) exportTable(ctx context.Context, table Table) (*ExportRun, error) {
+	if err := s.ensureTable(ctx, table); err != nil {
+		return nil, err
+	}
+
+	from, err := s.watermark(ctx, table.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	run := &ExportRun{
+		Destination:   s.warehouse.Name(),
+		Source:        table.Name,
+		WatermarkFrom: from,
+		WatermarkTo:   time.Now().Add(-commitLag),
+		Status:        models.ExportRunning,
+		StartedAt:     time.Now(),
+	}
+	if !run.WatermarkTo.After(from) {
+		return nil, nil
+	}
+	if err := s.db.WithContext(ctx).Create(run).Error; err != nil {
+		return nil, fmt.Errorf("failed to record export run: %w", err)
+	}
+
+	rows, exportErr := s.copyRows(ctx, table, run.WatermarkFrom, run.WatermarkTo)
+
+	now := time.Now()
+	run.Rows = rows
+	run.FinishedAt = &now
+	run.Status = models.ExportSucceeded
+	if exportErr != nil {
+		run.Status = models.ExportFailed
+		run.Error = exportErr.Error()
+	}
+	// Record the outcome even if ctx was cancelled mid-copy, so the run is
+	// not left marked as running
+	if err := s.db.WithContext(context.WithoutCancel(ctx)).Save(run).Error; err != nil {
+		return run, fmt.Errorf("failed to record export run: %w", err)
+	}
+
+	s.events.Record(ctx, security.EventDataExportCompleted, "", map[string]interface{}{
+		"run_id":      run.ID,
+		"destination": run.Destination,
+		"source":      run.Source,
+		"rows":        run.Rows,
+		"status":      run.Status,
+	})
+
+	if exportErr != nil {
+		return run, fmt.Errorf("failed to export %s: %w", table.Name, exportErr)
+	}
+	s.logger.Info("Exported table",
+		zap.String("destination", run.Destination),
+		zap.String("table", table.Name),
+		zap.Int64("rows", rows),
+	)
+	return run, nil
+}
+
+// copyRows streams rows changed in (from, to] to the warehouse in batches
+func (s *Service) copyRows(ctx context.Context, table Table, from, to time.Time) (int64, error) {
+	cursor, err := s.db.WithContext(ctx).Raw(table.query, from, to).Rows()
+	if err != nil {
+		return 0, fmt.Errorf("failed to query rows: %w", err)
+	}
+	defer cursor.Close()
+
+	var exported int64
+	batch := make([]Row, 0, exportBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := s.warehouse.Load(ctx, table, batch); err != nil {
+			return err
+		}
+		exported += int64(len(batch))
+		batch = batch[:0]
+		return nil
+	}
+
+	for cursor.Next() {
+		values := make(map[string]interface{})
+		if err := s.db.ScanRows(cursor, &values); err != nil {
+			return exported, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		row := make(Row, len(table.Columns))
+		for _, c := range table.Columns {
+			row[c.Name] = formatValue(values[c.Name])
+		}
+		batch = append(batch, row)
+
+		if len(batch) >= exportBatchSize {
+			if err := flush(); err != nil {
+				return exported, err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return exported, fmt.Errorf("failed to read rows: %w", err)
+	}
+	return exported, flush()
+}
+
+func (s *Service) ensureTable(ctx context.Context, table Table) error {
+	s.mu.Lock()
+	done := s.ensured[table.Name]
+	s.mu.Unlock()
+	if done {
+		return nil
+	}
+
+	if err := s.warehouse.EnsureTable(ctx, table); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.ensured[table.Name] = true
+	s.mu.Unlock()
+	return nil
+}
+
+// watermark returns where the next export of the table resumes; the zero
+// time triggers a full backfill
+func (s *Service) watermark(ctx context.Context, source string) (time.Time, error) {
+	var last ExportRun
+	err := s.db.WithContext(ctx).Where("destination = ? AND source = ? AND status = ?", s.warehouse.Name(), source, models.ExportSucceeded).
+		Order("watermark_to DESC").
+		First(&last).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to load export watermark: %w", err)
+	}
+	return last.WatermarkTo, nil
+}
+
+func (s *Service) setNextRun(t time.Time) {
+	s.mu.Lock()
+	s.nextRunAt = &t
+	s.mu.Unlock()
+}
+
+// Status reports the latest run, watermark and exported row count per table
+func (s *Service) Status(ctx context.Context) (*StatusResponse, error) {
+	s.mu.Lock()
+	nextRunAt := s.nextRunAt
+	s.mu.Unlock()
+
+	resp := &StatusResponse{
+		Destination: s.warehouse.Name(),
+		Interval:    s.interval.String(),
+		Running:     s.IsRunning(),
+		NextRunAt:   nextRunAt,
+		Tables:      make([]TableStatus, 0, len(s.tables)),
+	}
+
+	for _, table := range s.tables {
+		status := TableStatus{Source: table.Name}
+
+		var last ExportRun
+		err := s.db.WithContext(ctx).Where("destination = ? AND source = ?", resp.Destination, table.Name).
+			Order("started_at DESC").
+			First(&last).Error
+		if err == nil {
+			status.LastRun = &last
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("failed to load last export run: %w", err)
+		}
+
+		watermark, err := s.watermark(ctx, table.Name)
+		if err != nil {
+			return nil, err
+		}
+		if !watermark.IsZero() {
+			status.Watermark = &watermark
+		}
+
+		if err := s.db.WithContext(ctx).Model(&ExportRun{}).
+			Select(`COALESCE(SUM("rows"), 0)`).
+			Where("destination = ? AND source = ? AND status = ?", resp.Destination, table.Name, models.ExportSucceeded).
+			Scan(&status.TotalExported).Error; err != nil {
+			return nil, fmt.Errorf("failed to count exported rows: %w", err)
+		}
+
+		resp.Tables = append(resp.Tables, status)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,135 @@
+package export
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// fakeWarehouse records loaded rows per table
+type fakeWarehouse struct {
+	loaded map[string][]Row
+}
+
+func (w *fakeWarehouse) Name() string { return "fake" }
+
+func (w *fakeWarehouse) EnsureTable(context.Context, Table) error { return nil }
+
+func (w *fakeWarehouse) Load(_ context.Context, table Table, rows []Row) error {
+	w.loaded[table.Name] = append(w.loaded[table.Name], rows...)
+	return nil
+}
+
+var testTable = Table{
+	Name:    "widgets",
+	Columns: []Column{{Name: "id", Type: TypeString}, {Name: "changed_at", Type: TypeTimestamp}},
+	query:   `SELECT id, changed_at FROM widgets WHERE changed_at > ? AND changed_at <= ?`,
+}
+
+func newTestService(t *testing.T) (*Service, *fakeWarehouse, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	warehouse := &fakeWarehouse{loaded: make(map[string][]Row)}
+	s := NewService(db, warehouse, time.Hour, zap.NewNop())
+	s.tables = []Table{testTable}
+	return s, warehouse, mock
+}
+
+func TestWatermarkResumesFromLastSucceededRun(t *testing.T) {
+	s, _, mock := newTestService(t)
+	last := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT \* FROM "export_runs" WHERE destination = \$1 AND source = \$2 AND status = \$3 ORDER BY watermark_to DESC`).
+		WithArgs("fake", "widgets", "succeeded", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"watermark_to"}).AddRow(last))
+
+	got, err := s.watermark(context.Background(), "widgets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(last) {
+		t.Fatalf("watermark = %s, want %s", got, last)
+	}
+}
+
+func TestWatermarkIsZeroBeforeFirstExport(t *testing.T) {
+	s, _, mock := newTestService(t)
+
+	mock.ExpectQuery(`SELECT \* FROM "export_runs"`).
+		WillReturnRows(sqlmock.NewRows([]string{"watermark_to"}))
+
+	got, err := s.watermark(context.Background(), "widgets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.IsZero() {
+		t.Fatalf("watermark = %s, want zero for a full backfill", got)
+	}
+}
+
+func TestRunExportsRowsAfterWatermark(t *testing.T) {
+	s, warehouse, mock := newTestService(t)
+	last := time.Now().Add(-time.Hour)
+
+	mock.ExpectQuery(`SELECT \* FROM "export_runs"`).
+		WillReturnRows(sqlmock.NewRows([]string{"watermark_to"}).AddRow(last))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "export_runs"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("run-1"))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`SELECT id, changed_at FROM widgets`).
+		WithArgs(last, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "changed_at"}).
+			AddRow("w-1", last.Add(time.Minute)).
+			AddRow("w-2", last.Add(2*time.Minute)))
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "export_runs" SET .*"rows"=\$5,"status"=\$6`).
+		WithArgs("fake", "widgets", last, sqlmock.AnyArg(), 2, "succeeded", "", sqlmock.AnyArg(), sqlmock.AnyArg(), "run-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	runs, err := s.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 || runs[0].Rows != 2 {
+		t.Fatalf("runs = %+v, want one run with 2 rows", runs)
+	}
+	if got := len(warehouse.loaded["widgets"]); got != 2 {
+		t.Fatalf("loaded %d rows, want 2", got)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStartFailsInterruptedRuns(t *testing.T) {
+	s, _, mock := newTestService(t)
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "export_runs" SET "error"=\$1,"finished_at"=\$2,"status"=\$3 WHERE destination = \$4 AND status = \$5`).
+		WithArgs("interrupted before finishing", sqlmock.AnyArg(), "failed", "fake", "running").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := s.failInterruptedRuns(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
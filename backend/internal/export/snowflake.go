@@ -0,0 +1,260 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const snowflakePollInterval = time.Second
+
+type SnowflakeConfig struct {
+	AccountURL string // e.g. https://myorg-myaccount.snowflakecomputing.com
+	Token      string
+	TokenType  string // PROGRAMMATIC_ACCESS_TOKEN, OAUTH or KEYPAIR_JWT
+	Database   string
+	Schema     string
+	Warehouse  string
+	Role       string
+}
+
+// Snowflake appends rows through the SQL API using bound INSERT statements
+type Snowflake struct {
+	config SnowflakeConfig
+	client *http.Client
+}
+
+type snowflakeBinding struct {
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+type snowflakeResponse struct {
+	Code               string `json:"code"`
+	Message            string `json:"message"`
+	StatementHandle    string `json:"statementHandle"`
+	StatementStatusURL string `json:"statementStatusUrl"`
+	// Data holds result rows, each value as a string or null
+	Data [][]*string `json:"data"`
+}
+
+func NewSnowflake(config SnowflakeConfig) (*Snowflake, error) {
+	if config.AccountURL == "" || config.Token == "" || config.Database == "" {
+		return nil, fmt.Errorf("snowflake export requires an account URL, token and database")
+	}
+	config.AccountURL = strings.TrimRight(config.AccountURL, "/")
+
+	return &Snowflake{
+		config: config,
+		client: &http.Client{Timeout: 2 * time.Minute},
+	}, nil
+}
+
+func (s *Snowflake) Name() string {
+	return "snowflake"
+}
+
+// EnsureTable reads the table's current columns from INFORMATION_SCHEMA and
+// issues at most one CREATE or ALTER statement
+func (s *Snowflake) EnsureTable(ctx context.Context, table Table) error {
+	existing, err := s.columns(ctx, table.Name)
+	if err != nil {
+		return fmt.Errorf("failed to read columns of %s: %w", table.Name, err)
+	}
+
+	var missing []string
+	for _, c := range table.Columns {
+		if !existing[strings.ToUpper(c.Name)] {
+			missing = append(missing, c.Name+" "+snowflakeType(c.Type))
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	if len(existing) == 0 {
+		create := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", table.Name, strings.Join(missing, ", "))
+		if err := s.exec(ctx, create, nil); err != nil {
+			return fmt.Errorf("failed to create table %s: %w", table.Name, err)
+		}
+		return nil
+	}
+
+	// Tables created by an older release may be missing newer columns
+	alter := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", table.Name, strings.Join(missing, ", "))
+	if err := s.exec(ctx, alter, nil); err != nil {
+		return fmt.Errorf("failed to add columns to %s: %w", table.Name, err)
+	}
+	return nil
+}
+
+// columns returns the upper-cased column names of the table in the
+// configured schema, or none if the table does not exist
+func (s *Snowflake) columns(ctx context.Context, table string) (map[string]bool, error) {
+	resp, err := s.query(ctx,
+		"SELECT COLUMN_NAME FROM INFORMATION_SCHEMA.COLUMNS WHERE TABLE_SCHEMA = CURRENT_SCHEMA() AND TABLE_NAME = ?",
+		map[string]snowflakeBinding{"1": {Type: "TEXT", Value: strings.ToUpper(table)}})
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]bool, len(resp.Data))
+	for _, row := range resp.Data {
+		if len(row) > 0 && row[0] != nil {
+			columns[strings.ToUpper(*row[0])] = true
+		}
+	}
+	return columns, nil
+}
+
+func (s *Snowflake) Load(ctx context.Context, table Table, rows []Row) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(table.Columns))
+	for _, c := range table.Columns {
+		names = append(names, c.Name)
+	}
+
+	bindings := make(map[string]snowflakeBinding, len(rows)*len(table.Columns))
+	values := make([]string, 0, len(rows))
+	n := 0
+	for _, row := range rows {
+		placeholders := make([]string, 0, len(table.Columns))
+		for _, c := range table.Columns {
+			n++
+			bindings[strconv.Itoa(n)] = snowflakeBinding{Type: snowflakeBindType(c.Type), Value: snowflakeValue(row[c.Name])}
+			placeholders = append(placeholders, "?")
+		}
+		values = append(values, "("+strings.Join(placeholders, ", ")+")")
+	}
+
+	insert := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", table.Name, strings.Join(names, ", "), strings.Join(values, ", "))
+	if err := s.exec(ctx, insert, bindings); err != nil {
+		return fmt.Errorf("failed to insert rows into %s: %w", table.Name, err)
+	}
+	return nil
+}
+
+// exec runs a single statement and waits for it to finish
+func (s *Snowflake) exec(ctx context.Context, statement string, bindings map[string]snowflakeBinding) error {
+	_, err := s.query(ctx, statement, bindings)
+	return err
+}
+
+// query runs a single statement, waits for it to finish and returns the
+// first partition of its result
+func (s *Snowflake) query(ctx context.Context, statement string, bindings map[string]snowflakeBinding) (*snowflakeResponse, error) {
+	body := map[string]interface{}{
+		"statement": statement,
+		"timeout":   120,
+		"database":  s.config.Database,
+		"schema":    s.config.Schema,
+	}
+	if s.config.Warehouse != "" {
+		body["warehouse"] = s.config.Warehouse
+	}
+	if s.config.Role != "" {
+		body["role"] = s.config.Role
+	}
+	if len(bindings) > 0 {
+		body["bindings"] = bindings
+	}
+
+	resp, status, err := s.do(ctx, http.MethodPost, s.config.AccountURL+"/api/v2/statements", body)
+	for err == nil && status == http.StatusAccepted {
+		// Long statements finish asynchronously; poll until done
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(snowflakePollInterval):
+		}
+		resp, status, err = s.do(ctx, http.MethodGet, s.config.AccountURL+resp.StatementStatusURL, nil)
+	}
+	return resp, err
+}
+
+func (s *Snowflake) do(ctx context.Context, method, endpoint string, body interface{}) (*snowflakeResponse, int, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, 0, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.config.Token)
+	req.Header.Set("X-Snowflake-Authorization-Token-Type", s.config.TokenType)
+
+	httpResp, err := s.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer httpResp.Body.Close()
+
+	var resp snowflakeResponse
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, httpResp.StatusCode, err
+	}
+	_ = json.Unmarshal(data, &resp)
+
+	if httpResp.StatusCode != http.StatusOK && httpResp.StatusCode != http.StatusAccepted {
+		if resp.Message != "" {
+			return nil, httpResp.StatusCode, fmt.Errorf("snowflake: %s (%s)", resp.Message, resp.Code)
+		}
+		return nil, httpResp.StatusCode, fmt.Errorf("snowflake responded with status %d", httpResp.StatusCode)
+	}
+	return &resp, httpResp.StatusCode, nil
+}
+
+func snowflakeType(t ColumnType) string {
+	switch t {
+	case TypeInteger:
+		return "NUMBER(38,0)"
+	case TypeFloat:
+		return "FLOAT"
+	case TypeBoolean:
+		return "BOOLEAN"
+	case TypeTimestamp:
+		return "TIMESTAMP_TZ"
+	default:
+		return "VARCHAR"
+	}
+}
+
+func snowflakeBindType(t ColumnType) string {
+	switch t {
+	case TypeInteger:
+		return "FIXED"
+	case TypeFloat:
+		return "REAL"
+	case TypeBoolean:
+		return "BOOLEAN"
+	default:
+		// Timestamps are bound as RFC 3339 text and cast on insert
+		return "TEXT"
+	}
+}
+
+// snowflakeValue renders a value as the string form the SQL API expects
+func snowflakeValue(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,76 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// snowflakeStub answers the column lookup with the given columns and
+// records every other statement
+func snowflakeStub(t *testing.T, columns ...string) (*Snowflake, *[]string) {
+	t.Helper()
+	var statements []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Statement string `json:"statement"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode statement: %v", err)
+		}
+
+		resp := snowflakeResponse{Data: [][]*string{}}
+		if strings.Contains(body.Statement, "INFORMATION_SCHEMA.COLUMNS") {
+			for i := range columns {
+				resp.Data = append(resp.Data, []*string{&columns[i]})
+			}
+		} else {
+			statements = append(statements, body.Statement)
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+
+	s, err := NewSnowflake(SnowflakeConfig{AccountURL: server.URL, Token: "token", Database: "db"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, &statements
+}
+
+func TestSnowflakeEnsureTableAddsOnlyMissingColumns(t *testing.T) {
+	s, statements := snowflakeStub(t, "ID")
+
+	if err := s.EnsureTable(context.Background(), testTable); err != nil {
+		t.Fatal(err)
+	}
+	want := "ALTER TABLE widgets ADD COLUMN changed_at TIMESTAMP_TZ"
+	if len(*statements) != 1 || (*statements)[0] != want {
+		t.Fatalf("statements = %q, want [%q]", *statements, want)
+	}
+}
+
+func TestSnowflakeEnsureTableCreatesMissingTable(t *testing.T) {
+	s, statements := snowflakeStub(t)
+
+	if err := s.EnsureTable(context.Background(), testTable); err != nil {
+		t.Fatal(err)
+	}
+	if len(*statements) != 1 || !strings.HasPrefix((*statements)[0], "CREATE TABLE IF NOT EXISTS widgets") {
+		t.Fatalf("statements = %q, want a single CREATE TABLE", *statements)
+	}
+}
+
+func TestSnowflakeEnsureTableUpToDate(t *testing.T) {
+	s, statements := snowflakeStub(t, "ID", "CHANGED_AT")
+
+	if err := s.EnsureTable(context.Background(), testTable); err != nil {
+		t.Fatal(err)
+	}
+	if len(*statements) != 0 {
+		t.Fatalf("statements = %q, want none when every column exists", *statements)
+	}
+}
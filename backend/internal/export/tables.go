@@ -0,0 +1,90 @@
+package export
+
+// changed_at is the later of updated_at and deleted_at so soft deletes are
+// exported as a final snapshot with deleted_at set. Every snapshot is
+// appended; analysts take the latest changed_at per id.
+
+var taskTable = Table{
+	Name: "tasks",
+	Columns: []Column{
+		{Name: "id", Type: TypeString},
+		{Name: "title", Type: TypeString},
+		{Name: "status", Type: TypeString},
+		{Name: "priority", Type: TypeString},
+		{Name: "project", Type: TypeString},
+		{Name: "assigned_to", Type: TypeString},
+		{Name: "assignee_ids", Type: TypeString},
+		{Name: "created_by", Type: TypeString},
+		{Name: "estimated_effort", Type: TypeFloat},
+		{Name: "due_date", Type: TypeTimestamp},
+		{Name: "completed_at", Type: TypeTimestamp},
+		{Name: "created_at", Type: TypeTimestamp},
+		{Name: "updated_at", Type: TypeTimestamp},
+		{Name: "deleted_at", Type: TypeTimestamp},
+		{Name: "changed_at", Type: TypeTimestamp},
+	},
+	query: `
+SELECT * FROM (
+	SELECT t.id::text AS id, t.title, t.status, t.priority, t.project,
+		(SELECT ta.user_id::text FROM task_assignees ta
+			WHERE ta.task_id = t.id AND ta.is_primary) AS assigned_to,
+		(SELECT string_agg(ta.user_id::text, ',' ORDER BY ta.is_primary DESC, ta.user_id)
+			FROM task_assignees ta WHERE ta.task_id = t.id) AS assignee_ids,
+		t.created_by::text AS created_by, t.estimated_effort, t.due_date, t.completed_at,
+		t.created_at, t.updated_at, t.deleted_at,
+		GREATEST(t.updated_at, COALESCE(t.deleted_at, t.updated_at)) AS changed_at
+	FROM tasks t
+) s
+WHERE s.changed_at > ? AND s.changed_at <= ?
+ORDER BY s.changed_at`,
+}
+
+var timeEntryTable = Table{
+	Name: "time_entries",
+	Columns: []Column{
+		{Name: "id", Type: TypeString},
+		{Name: "task_id", Type: TypeString},
+		{Name: "user_id", Type: TypeString},
+		{Name: "started_at", Type: TypeTimestamp},
+		{Name: "ended_at", Type: TypeTimestamp},
+		{Name: "duration_seconds", Type: TypeInteger},
+		{Name: "manual", Type: TypeBoolean},
+		{Name: "created_at", Type: TypeTimestamp},
+		{Name: "updated_at", Type: TypeTimestamp},
+		{Name: "deleted_at", Type: TypeTimestamp},
+		{Name: "changed_at", Type: TypeTimestamp},
+	},
+	query: `
+SELECT * FROM (
+	SELECT e.id::text AS id, e.task_id::text AS task_id, e.user_id::text AS user_id,
+		e.started_at, e.ended_at, e.duration_seconds, e.manual,
+		e.created_at, e.updated_at, e.deleted_at,
+		GREATEST(e.updated_at, COALESCE(e.deleted_at, e.updated_at)) AS changed_at
+	FROM time_entries e
+) s
+WHERE s.changed_at > ? AND s.changed_at <= ?
+ORDER BY s.changed_at`,
+}
+
+// securityEventTable is append-only, so created_at is its change time
+var securityEventTable = Table{
+	Name: "security_events",
+	Columns: []Column{
+		{Name: "sequence", Type: TypeInteger},
+		{Name: "id", Type: TypeString},
+		{Name: "type", Type: TypeString},
+		{Name: "actor_id", Type: TypeString},
+		{Name: "data", Type: TypeString},
+		{Name: "created_at", Type: TypeTimestamp},
+		{Name: "changed_at", Type: TypeTimestamp},
+	},
+	query: `
+SELECT e.sequence, e.id::text AS id, e.type, e.actor_id, e.data::text AS data,
+	e.created_at, e.created_at AS changed_at
+FROM security_events e
+WHERE e.created_at > ? AND e.created_at <= ?
+ORDER BY e.sequence`,
+}
+
+// Tables lists every source exported to the warehouse
+var Tables = []Table{taskTable, timeEntryTable, securityEventTable}
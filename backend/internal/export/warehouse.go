@@ -0,0 +1,45 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Warehouse is a destination that exported rows are appended to
+type Warehouse interface {
+	Name() string
+	// EnsureTable creates the table if needed and adds any missing columns
+	EnsureTable(ctx context.Context, table Table) error
+	Load(ctx context.Context, table Table, rows []Row) error
+}
+
+// NewWarehouse builds the warehouse for the configured destination
+func NewWarehouse(ctx context.Context, destination string, bigquery BigQueryConfig, snowflake SnowflakeConfig) (Warehouse, error) {
+	switch destination {
+	case "bigquery":
+		return NewBigQuery(ctx, bigquery)
+	case "snowflake":
+		return NewSnowflake(snowflake)
+	default:
+		return nil, fmt.Errorf("unsupported export destination %q", destination)
+	}
+}
+
+// formatValue normalizes a scanned database value for JSON transport.
+// Timestamps are sent as RFC 3339 strings in UTC.
+func formatValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case time.Time:
+		return value.UTC().Format(time.RFC3339Nano)
+	case *time.Time:
+		if value == nil {
+			return nil
+		}
+		return value.UTC().Format(time.RFC3339Nano)
+	case []byte:
+		return string(value)
+	default:
+		return value
+	}
+}
@@ -0,0 +1,70 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func TestCachedReusesResultWithinTTL(t *testing.T) {
+	calls := 0
+	check := Cached(func(context.Context) error {
+		calls++
+		return errors.New("quota exceeded")
+	}, time.Hour)
+
+	for i := 0; i < 3; i++ {
+		if err := check(context.Background()); err == nil {
+			t.Fatal("cached failure was dropped")
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("check ran %d times, want 1 within the TTL", calls)
+	}
+}
+
+func TestCachedRerunsAfterTTL(t *testing.T) {
+	calls := 0
+	check := Cached(func(context.Context) error {
+		calls++
+		return nil
+	}, time.Nanosecond)
+
+	check(context.Background())
+	time.Sleep(time.Millisecond)
+	check(context.Background())
+	if calls != 2 {
+		t.Fatalf("check ran %d times, want 2 after the TTL", calls)
+	}
+}
+
+func TestReadinessHidesDependencyErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	checker := NewChecker()
+	checker.Register("database", true, func(context.Context) error {
+		return errors.New("dial tcp 10.0.0.5:5432: connection refused")
+	})
+	checker.Register("ai_provider", false, func(context.Context) error { return nil })
+
+	router := gin.New()
+	router.GET("/readyz", NewHandler(checker, zap.NewNop()).Readiness)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503 when a critical check fails", w.Code)
+	}
+	if strings.Contains(w.Body.String(), "10.0.0.5") {
+		t.Fatalf("response leaks the dependency error: %s", w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), `"database":{"status":"down"`) {
+		t.Fatalf("response = %s, want the database reported down", w.Body.String())
+	}
+}
@@ -0,0 +1,49 @@
+package health
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type Handler struct {
+	checker *Checker
+	logger  *zap.Logger
+}
+
+func NewHandler(checker *Checker, logger *zap.Logger) *Handler {
+	return &Handler{
+		checker: checker,
+		logger:  logger,
+	}
+}
+
+// Liveness reports that the process is running. It deliberately checks no
+// dependencies so a database outage does not cause restarts.
+func (h *Handler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": StatusUp})
+}
+
+// Readiness reports per-dependency status and returns 503 when a critical
+// dependency is down
+func (h *Handler) Readiness(c *gin.Context) {
+	report := h.checker.Run(c.Request.Context())
+
+	for name, result := range report.Checks {
+		if result.Status != StatusUp {
+			h.logger.Warn("Dependency check failed",
+				zap.String("check", name),
+				zap.Bool("critical", result.Critical),
+				zap.String("error", result.Error),
+			)
+		}
+	}
+
+	status := http.StatusOK
+	if report.Status == StatusDown {
+		status = http.StatusServiceUnavailable
+	}
+
+	c.JSON(status, report)
+}
@@ -0,0 +1,194 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+)
+
+// Integration states, from the most recent call or probe
+const (
+	IntegrationUp            = "up"
+	IntegrationDown          = "down"
+	IntegrationUnknown       = "unknown"
+	IntegrationNotConfigured = "not_configured"
+)
+
+// IntegrationStatus is what operators see of one external service.
+// Unlike readiness checks, errors are shown, since only administrators can
+// read them.
+type IntegrationStatus struct {
+	Name        string     `json:"name"`
+	Configured  bool       `json:"configured"`
+	Status      string     `json:"status"`
+	Probeable   bool       `json:"probeable"`
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+}
+
+type integration struct {
+	name       string
+	configured bool
+	probe      CheckFunc
+
+	lastSuccess time.Time
+	lastErrorAt time.Time
+	lastError   string
+}
+
+// Integrations remembers the last success and failure of each external
+// service, from real traffic and from probes. Outcomes are kept in memory,
+// so each replica reports its own.
+type Integrations struct {
+	mu           sync.Mutex
+	integrations []*integration
+	timeout      time.Duration
+}
+
+func NewIntegrations() *Integrations {
+	return &Integrations{timeout: defaultCheckTimeout}
+}
+
+// Register adds an integration in the order it is listed. probe is a
+// cheap check run on demand; it may be nil when there is none.
+func (i *Integrations) Register(name string, configured bool, probe CheckFunc) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.integrations = append(i.integrations, &integration{name: name, configured: configured, probe: probe})
+}
+
+// Record notes the outcome of a call to the named integration. Unknown
+// names are ignored.
+func (i *Integrations) Record(name string, err error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, in := range i.integrations {
+		if in.name != name {
+			continue
+		}
+		if err == nil {
+			in.lastSuccess = time.Now()
+		} else {
+			in.lastErrorAt = time.Now()
+			in.lastError = redactURL(err).Error()
+		}
+		return
+	}
+}
+
+// Observer returns a func that records outcomes for the named integration
+func (i *Integrations) Observer(name string) func(err error) {
+	return func(err error) { i.Record(name, err) }
+}
+
+// Status reports every integration. With probe set, the probes of the
+// configured integrations run first, concurrently and each under its own
+// timeout, and their outcomes are recorded.
+func (i *Integrations) Status(ctx context.Context, probe bool) []IntegrationStatus {
+	i.mu.Lock()
+	integrations := append([]*integration(nil), i.integrations...)
+	i.mu.Unlock()
+
+	if probe {
+		var wg sync.WaitGroup
+		for _, in := range integrations {
+			if !in.configured || in.probe == nil {
+				continue
+			}
+			wg.Add(1)
+			go func(in *integration) {
+				defer wg.Done()
+				probeCtx, cancel := context.WithTimeout(ctx, i.timeout)
+				defer cancel()
+				i.Record(in.name, in.probe(probeCtx))
+			}(in)
+		}
+		wg.Wait()
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	statuses := make([]IntegrationStatus, 0, len(integrations))
+	for _, in := range integrations {
+		status := IntegrationStatus{
+			Name:       in.name,
+			Configured: in.configured,
+			Status:     IntegrationUnknown,
+			Probeable:  in.probe != nil,
+			LastError:  in.lastError,
+		}
+		if lastSuccess := in.lastSuccess; !lastSuccess.IsZero() {
+			status.LastSuccess = &lastSuccess
+			status.Status = IntegrationUp
+		}
+		if lastErrorAt := in.lastErrorAt; !lastErrorAt.IsZero() {
+			status.LastErrorAt = &lastErrorAt
+			if lastErrorAt.After(in.lastSuccess) {
+				status.Status = IntegrationDown
+			}
+		}
+		if !in.configured {
+			status.Status = IntegrationNotConfigured
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// Check fails while a configured integration is down, judged by its last
+// outcome; it probes nothing
+func (i *Integrations) Check(ctx context.Context) error {
+	var down []string
+	for _, status := range i.Status(ctx, false) {
+		if status.Status == IntegrationDown {
+			down = append(down, status.Name)
+		}
+	}
+	if len(down) > 0 {
+		return fmt.Errorf("integrations down: %s", strings.Join(down, ", "))
+	}
+	return nil
+}
+
+// redactURL drops the URL from HTTP client errors, since webhook URLs
+// carry their credentials
+func redactURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
+
+type IntegrationsHandler struct {
+	integrations *Integrations
+}
+
+func NewIntegrationsHandler(integrations *Integrations) *IntegrationsHandler {
+	return &IntegrationsHandler{integrations: integrations}
+}
+
+// Status lists the integrations; ?probe=true checks them first
+func (h *IntegrationsHandler) Status(c *gin.Context) {
+	var params struct {
+		Probe bool `form:"probe"`
+	}
+	if err := c.ShouldBindQuery(&params); err != nil {
+		_ = c.Error(common.NewInvalidInputError("probe must be true or false"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"integrations": h.integrations.Status(c.Request.Context(), params.Probe),
+		"checked_at":   time.Now(),
+	})
+}
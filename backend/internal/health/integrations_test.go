@@ -0,0 +1,82 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestIntegrationsStatusFollowsLatestOutcome(t *testing.T) {
+	integrations := NewIntegrations()
+	integrations.Register("slack", true, nil)
+	integrations.Register("discord", false, nil)
+	integrations.Register("redis", true, nil)
+
+	integrations.Record("slack", nil)
+	integrations.Record("slack", errors.New("webhook returned 500"))
+	integrations.Record("missing", errors.New("ignored"))
+
+	statuses := integrations.Status(context.Background(), false)
+	want := map[string]string{"slack": IntegrationDown, "discord": IntegrationNotConfigured, "redis": IntegrationUnknown}
+	for i, status := range statuses {
+		if name := []string{"slack", "discord", "redis"}[i]; status.Name != name {
+			t.Fatalf("integration %d = %s, want %s in registration order", i, status.Name, name)
+		}
+		if status.Status != want[status.Name] {
+			t.Errorf("%s status = %s, want %s", status.Name, status.Status, want[status.Name])
+		}
+	}
+	if statuses[0].LastSuccess == nil || statuses[0].LastError != "webhook returned 500" {
+		t.Fatalf("slack = %+v, want both the last success and the last error", statuses[0])
+	}
+
+	integrations.Record("slack", nil)
+	if status := integrations.Status(context.Background(), false)[0]; status.Status != IntegrationUp {
+		t.Fatalf("slack status = %s after a success, want up", status.Status)
+	}
+}
+
+func TestIntegrationsProbeOnlyConfigured(t *testing.T) {
+	integrations := NewIntegrations()
+	probed := map[string]bool{}
+	probe := func(name string, err error) CheckFunc {
+		return func(context.Context) error {
+			probed[name] = true
+			return err
+		}
+	}
+	integrations.Register("ai_provider", true, probe("ai_provider", errors.New("quota exceeded")))
+	integrations.Register("redis", false, probe("redis", nil))
+
+	statuses := integrations.Status(context.Background(), false)
+	if len(probed) != 0 {
+		t.Fatalf("probed %v without probe requested", probed)
+	}
+
+	statuses = integrations.Status(context.Background(), true)
+	if !probed["ai_provider"] || probed["redis"] {
+		t.Fatalf("probed %v, want only the configured integration", probed)
+	}
+	if statuses[0].Status != IntegrationDown || statuses[0].LastError != "quota exceeded" {
+		t.Fatalf("ai_provider = %+v, want the probe failure", statuses[0])
+	}
+}
+
+func TestIntegrationsRedactWebhookURLs(t *testing.T) {
+	integrations := NewIntegrations()
+	integrations.Register("slack", true, nil)
+
+	integrations.Record("slack", &url.Error{
+		Op:  http.MethodPost,
+		URL: "https://hooks.slack.com/services/T000/B000/secret",
+		Err: errors.New("connection refused"),
+	})
+
+	status := integrations.Status(context.Background(), false)[0]
+	if strings.Contains(status.LastError, "secret") || status.LastError != "connection refused" {
+		t.Fatalf("last error = %q, want it without the webhook URL", status.LastError)
+	}
+}
@@ -0,0 +1,63 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	initialWaitBackoff = 500 * time.Millisecond
+	maxWaitBackoff     = 5 * time.Second
+)
+
+// WaitFor retries check with backoff until it passes or timeout elapses, so
+// the server waits for dependencies that start alongside it instead of
+// crashing. A timeout of zero tries once.
+func WaitFor(ctx context.Context, name string, check CheckFunc, timeout time.Duration, logger *zap.Logger) error {
+	start := time.Now()
+	deadline := start.Add(timeout)
+	backoff := initialWaitBackoff
+
+	for attempt := 1; ; attempt++ {
+		checkCtx, cancel := context.WithTimeout(ctx, defaultCheckTimeout)
+		err := check(checkCtx)
+		cancel()
+		if err == nil {
+			if attempt > 1 {
+				logger.Info("Dependency available",
+					zap.String("dependency", name),
+					zap.Int("attempts", attempt),
+					zap.Duration("waited", time.Since(start)),
+				)
+			}
+			return nil
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return fmt.Errorf("%s not available after %s: %w", name, timeout, err)
+		}
+		if backoff > remaining {
+			backoff = remaining
+		}
+		logger.Warn("Waiting for dependency",
+			zap.String("dependency", name),
+			zap.Int("attempt", attempt),
+			zap.Duration("retry_in", backoff),
+			zap.Duration("remaining", remaining),
+			zap.Error(err),
+		)
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return fmt.Errorf("%s not available: %w", name, ctx.Err())
+		}
+		if backoff *= 2; backoff > maxWaitBackoff {
+			backoff = maxWaitBackoff
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestWaitForRetriesUntilAvailable(t *testing.T) {
+	calls := 0
+	err := WaitFor(context.Background(), "database", func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("connection refused")
+		}
+		return nil
+	}, 5*time.Second, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 {
+		t.Fatalf("check ran %d times, want 3", calls)
+	}
+}
+
+func TestWaitForGivesUpAfterTimeout(t *testing.T) {
+	start := time.Now()
+	err := WaitFor(context.Background(), "redis", func(ctx context.Context) error {
+		return errors.New("connection refused")
+	}, 300*time.Millisecond, zap.NewNop())
+	if err == nil || !strings.Contains(err.Error(), "redis not available after 300ms: connection refused") {
+		t.Fatalf("err = %v, want timeout naming the dependency and last error", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("waited %s, want about the 300ms timeout", elapsed)
+	}
+}
+
+func TestWaitForWithoutTimeoutTriesOnce(t *testing.T) {
+	calls := 0
+	err := WaitFor(context.Background(), "database", func(ctx context.Context) error {
+		calls++
+		return errors.New("down")
+	}, 0, zap.NewNop())
+	if err == nil || calls != 1 {
+		t.Fatalf("calls = %d, err = %v; want one failed attempt", calls, err)
+	}
+}
@@ -0,0 +1,138 @@
+package intake
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"go.uber.org/zap"
+)
+
+// errInvalidIntakeToken is recorded when an email arrives with a wrong token
+var errInvalidIntakeToken = errors.New("email rejected: invalid intake token")
+
+type Handler struct {
+	service    *Service
+	emailToken string
+	logger     *zap.Logger
+	// observeEmail receives the outcome of each inbound email delivery
+	observeEmail func(err error)
+}
+
+func NewHandler(service *Service, emailToken string, logger *zap.Logger) *Handler {
+	return &Handler{
+		service:    service,
+		emailToken: emailToken,
+		logger:     logger,
+	}
+}
+
+// SetEmailObserver reports whether each email forwarded by the inbound
+// mail provider was accepted. Rejected tokens count as failures, since they
+// usually mean the provider's configuration is out of date.
+func (h *Handler) SetEmailObserver(observe func(err error)) {
+	h.observeEmail = observe
+}
+
+func (h *Handler) SubmitForm(c *gin.Context) {
+	var req SubmissionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(common.NewValidationError(err))
+		return
+	}
+
+	h.submit(c, req, SourceForm)
+}
+
+func (h *Handler) SubmitEmail(c *gin.Context) {
+	token := c.GetHeader("X-Intake-Token")
+	if h.emailToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(h.emailToken)) != 1 {
+		h.recordEmail(SourceEmail, errInvalidIntakeToken)
+		_ = c.Error(common.NewUnauthorizedError("invalid intake token"))
+		return
+	}
+
+	var req EmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(common.NewValidationError(err))
+		return
+	}
+
+	h.submit(c, SubmissionRequest{
+		Title:       req.Subject,
+		Description: req.Text,
+		Project:     req.Project,
+		Email:       req.From,
+	}, SourceEmail)
+}
+
+func (h *Handler) submit(c *gin.Context, req SubmissionRequest, source string) {
+	resp, err := h.service.Submit(c.Request.Context(), req, source)
+	if err != nil {
+		if errors.Is(err, ErrInvalidSubmission) {
+			_ = c.Error(common.NewInvalidInputError(err.Error()))
+			return
+		}
+		h.logger.Error("Failed to process intake submission", zap.Error(err), zap.String("source", source))
+		h.recordEmail(source, err)
+		_ = c.Error(common.NewInternalServerError("failed to process submission"))
+		return
+	}
+
+	h.recordEmail(source, nil)
+	if resp.TaskID != "" {
+		c.JSON(http.StatusCreated, resp)
+		return
+	}
+	c.JSON(http.StatusAccepted, resp)
+}
+
+// recordEmail reports the outcome of a submission that came in by email
+func (h *Handler) recordEmail(source string, err error) {
+	if h.observeEmail != nil && source == SourceEmail {
+		h.observeEmail(err)
+	}
+}
+
+func (h *Handler) ListSubmissions(c *gin.Context) {
+	submissions, err := h.service.ListSubmissions(c.Request.Context(), c.Query("status"))
+	if err != nil {
+		h.logger.Error("Failed to list intake submissions", zap.Error(err))
+		_ = c.Error(common.NewInternalServerError("failed to list submissions"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"submissions": submissions})
+}
+
+func (h *Handler) ApproveSubmission(c *gin.Context) {
+	submission, err := h.service.Approve(c.Request.Context(), c.Param("id"), c.GetString("user_id"))
+	if err != nil {
+		h.reviewError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, submission)
+}
+
+func (h *Handler) RejectSubmission(c *gin.Context) {
+	submission, err := h.service.Reject(c.Request.Context(), c.Param("id"), c.GetString("user_id"))
+	if err != nil {
+		h.reviewError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, submission)
+}
+
+func (h *Handler) reviewError(c *gin.Context, err error) {
+	switch err {
+	case ErrSubmissionNotFound:
+		_ = c.Error(common.NewNotFoundError(err.Error()))
+	case ErrAlreadyReviewed:
+		_ = c.Error(common.NewConflictError(err.Error()))
+	default:
+		h.logger.Error("Failed to review intake submission", zap.Error(err))
+		_ = c.Error(common.NewInternalServerError("failed to review submission"))
+	}
+}
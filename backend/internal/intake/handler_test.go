@@ -0,0 +1,67 @@
+package intake
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"github.com/iSparshP/real-time-task-management-system/internal/moderation"
+	"go.uber.org/zap"
+)
+
+func newTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	common.AppConfig.TaskMaxDescLength = 1000
+
+	service := NewService(nil, nil, moderation.Chain{moderation.NewWordlist(moderation.ActionQuarantine, nil)}, "owner", zap.NewNop())
+	handler := NewHandler(service, "secret", zap.NewNop())
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(common.ErrorHandler(zap.NewNop()))
+	router.POST("/intake/form", handler.SubmitForm)
+	router.POST("/intake/email", handler.SubmitEmail)
+	return router
+}
+
+func post(router *gin.Engine, path string, body interface{}) *httptest.ResponseRecorder {
+	payload, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Intake-Token", "secret")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestSubmitRejectsInvalidRequestsWith400(t *testing.T) {
+	router := newTestRouter(t)
+
+	cases := map[string]struct {
+		path string
+		body interface{}
+	}{
+		"bad priority": {"/intake/form", map[string]interface{}{"title": "Broken link", "priority": "urgent"}},
+		"past due date": {"/intake/form", map[string]interface{}{
+			"title": "Broken link", "due_date": time.Now().Add(-time.Hour).Format(time.RFC3339),
+		}},
+		"long description": {"/intake/form", map[string]interface{}{
+			"title": "Broken link", "description": strings.Repeat("a", 1001),
+		}},
+		"long subject": {"/intake/email", map[string]interface{}{
+			"from": "sender@example.com", "subject": strings.Repeat("a", 256),
+		}},
+	}
+
+	for name, tc := range cases {
+		if w := post(router, tc.path, tc.body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400 (%s)", name, w.Code, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package intake
+
+import (
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+)
+
+type Submission = models.IntakeSubmission
+
+const (
+	SourceForm  = "form"
+	SourceEmail = "email"
+)
+
+type SubmissionRequest struct {
+	Title       string     `json:"title" binding:"required,max=255"`
+	Description string     `json:"description"`
+	Priority    string     `json:"priority"`
+	DueDate     *time.Time `json:"due_date"`
+	Project     string     `json:"project"`
+	Email       string     `json:"email"`
+}
+
+// EmailRequest is the parsed inbound email posted by the mail provider webhook
+type EmailRequest struct {
+	From    string `json:"from" binding:"required"`
+	Subject string `json:"subject" binding:"required,max=255"`
+	Text    string `json:"text"`
+	Project string `json:"project"`
+}
+
+type SubmissionResponse struct {
+	Status       string `json:"status"`
+	TaskID       string `json:"task_id,omitempty"`
+	SubmissionID string `json:"submission_id,omitempty"`
+}
@@ -0,0 +1,217 @@
+package intake
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+	"github.com/iSparshP/real-time-task-management-system/internal/moderation"
+	"github.com/iSparshP/real-time-task-management-system/internal/task"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const defaultDueIn = 7 * 24 * time.Hour
+
+var (
+	ErrSubmissionNotFound = errors.New("submission not found")
+	ErrAlreadyReviewed    = errors.New("submission already reviewed")
+	ErrInvalidSubmission  = errors.New("invalid submission")
+)
+
+type Service struct {
+	db        *gorm.DB
+	tasks     *task.Service
+	moderator moderation.Moderator
+	ownerID   string
+	logger    *zap.Logger
+}
+
+// NewService creates the intake service. Tasks accepted from public intake
+// are created on behalf of, and assigned to, ownerID.
+func NewService(db *gorm.DB, tasks *task.Service, moderator moderation.Moderator, ownerID string, logger *zap.Logger) *Service {
+	return &Service{
+		db:        db,
+		tasks:     tasks,
+		moderator: moderator,
+		ownerID:   ownerID,
+		logger:    logger,
+	}
+}
+
+// Submit moderates a public submission. Clean content becomes a task;
+// anything flagged or quarantined is held for triage instead.
+func (s *Service) Submit(ctx context.Context, req SubmissionRequest, source string) (*SubmissionResponse, error) {
+	if err := validateSubmission(req); err != nil {
+		return nil, err
+	}
+
+	verdict, err := s.moderator.Moderate(ctx, req.Title+"\n"+req.Description)
+	if err != nil {
+		// Fail closed: hold the submission rather than publish unchecked content
+		s.logger.Warn("Moderation failed, quarantining submission", zap.Error(err))
+		verdict = moderation.Verdict{
+			Action:  moderation.ActionQuarantine,
+			Reasons: []string{"moderation unavailable"},
+		}
+	}
+
+	if verdict.Allowed() {
+		resp, err := s.createTask(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return &SubmissionResponse{Status: "accepted", TaskID: resp.Task.ID}, nil
+	}
+
+	submission := &Submission{
+		ID:             uuid.New().String(),
+		Source:         source,
+		SubmitterEmail: req.Email,
+		Title:          req.Title,
+		Description:    req.Description,
+		Project:        req.Project,
+		Priority:       req.Priority,
+		DueDate:        req.DueDate,
+		Action:         string(verdict.Action),
+		Reasons:        strings.Join(verdict.Reasons, "; "),
+		Status:         models.IntakePendingReview,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+	if err := s.db.WithContext(ctx).Create(submission).Error; err != nil {
+		return nil, fmt.Errorf("failed to store submission: %w", err)
+	}
+
+	s.logger.Info("Intake submission held for triage",
+		zap.String("submission_id", submission.ID),
+		zap.String("source", source),
+		zap.String("action", submission.Action),
+	)
+	return &SubmissionResponse{Status: string(models.IntakePendingReview), SubmissionID: submission.ID}, nil
+}
+
+// validateSubmission rejects requests that could never become a task, so
+// they are not held for triage only to fail on approval
+func validateSubmission(req SubmissionRequest) error {
+	switch models.TaskPriority(req.Priority) {
+	case "", models.PriorityLow, models.PriorityMedium, models.PriorityHigh:
+	default:
+		return fmt.Errorf("%w: priority must be low, medium or high", ErrInvalidSubmission)
+	}
+	if req.DueDate != nil && req.DueDate.Before(time.Now()) {
+		return fmt.Errorf("%w: due_date must be in the future", ErrInvalidSubmission)
+	}
+	if len(req.Title) > 255 {
+		return fmt.Errorf("%w: title exceeds maximum length of 255 characters", ErrInvalidSubmission)
+	}
+	if len(req.Description) > common.AppConfig.TaskMaxDescLength {
+		return fmt.Errorf("%w: description exceeds maximum length of %d characters", ErrInvalidSubmission, common.AppConfig.TaskMaxDescLength)
+	}
+	return nil
+}
+
+func (s *Service) createTask(ctx context.Context, req SubmissionRequest) (*task.TaskResponse, error) {
+	priority := req.Priority
+	if priority == "" {
+		priority = string(models.PriorityLow)
+	}
+	// A requested due date can lapse while a submission waits for triage;
+	// fall back to the default rather than failing the approval
+	dueDate := time.Now().Add(defaultDueIn)
+	if req.DueDate != nil && req.DueDate.After(time.Now()) {
+		dueDate = *req.DueDate
+	}
+	// Visitors do not know the project's calendar; a due date on a day off
+	// moves to the next working day
+	dueDate, err := s.tasks.NextWorkingDay(ctx, req.Project, dueDate)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.tasks.CreateTask(ctx, task.CreateTaskRequest{
+		Title:       req.Title,
+		Description: req.Description,
+		Priority:    priority,
+		AssignedTo:  s.ownerID,
+		DueDate:     dueDate,
+		Project:     req.Project,
+	}, s.ownerID)
+}
+
+// ListSubmissions returns held submissions, optionally filtered by status
+func (s *Service) ListSubmissions(ctx context.Context, status string) ([]Submission, error) {
+	query := s.db.WithContext(ctx).Order("created_at desc")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	submissions := []Submission{}
+	if err := query.Find(&submissions).Error; err != nil {
+		return nil, fmt.Errorf("failed to list submissions: %w", err)
+	}
+	return submissions, nil
+}
+
+// Approve publishes a held submission as a task
+func (s *Service) Approve(ctx context.Context, id string, reviewerID string) (*Submission, error) {
+	submission, err := s.pendingSubmission(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.createTask(ctx, SubmissionRequest{
+		Title:       submission.Title,
+		Description: submission.Description,
+		Priority:    submission.Priority,
+		DueDate:     submission.DueDate,
+		Project:     submission.Project,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.review(ctx, submission, models.IntakeApproved, reviewerID, &resp.Task.ID)
+}
+
+// Reject discards a held submission
+func (s *Service) Reject(ctx context.Context, id string, reviewerID string) (*Submission, error) {
+	submission, err := s.pendingSubmission(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.review(ctx, submission, models.IntakeRejected, reviewerID, nil)
+}
+
+func (s *Service) pendingSubmission(ctx context.Context, id string) (*Submission, error) {
+	var submission Submission
+	if err := s.db.WithContext(ctx).First(&submission, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSubmissionNotFound
+		}
+		return nil, err
+	}
+	if submission.Status != models.IntakePendingReview {
+		return nil, ErrAlreadyReviewed
+	}
+	return &submission, nil
+}
+
+func (s *Service) review(ctx context.Context, submission *Submission, status models.IntakeStatus, reviewerID string, taskID *string) (*Submission, error) {
+	now := time.Now()
+	submission.Status = status
+	submission.ReviewedBy = &reviewerID
+	submission.ReviewedAt = &now
+	submission.TaskID = taskID
+	submission.UpdatedAt = now
+
+	if err := s.db.WithContext(ctx).Save(submission).Error; err != nil {
+		return nil, fmt.Errorf("failed to update submission: %w", err)
+	}
+	return submission, nil
+}
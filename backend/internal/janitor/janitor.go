@@ -0,0 +1,128 @@
+package janitor
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/metrics"
+	"go.uber.org/zap"
+)
+
+// Sweeper drops the stale entries of one in-process store and returns how
+// many it dropped
+type Sweeper interface {
+	Sweep(ctx context.Context) (int, error)
+}
+
+// SweepFunc adapts a function to Sweeper
+type SweepFunc func(ctx context.Context) (int, error)
+
+func (f SweepFunc) Sweep(ctx context.Context) (int, error) {
+	return f(ctx)
+}
+
+type store struct {
+	name      string
+	sweeper   Sweeper
+	reclaimed *metrics.Counter
+	failures  *metrics.Counter
+	duration  *metrics.Histogram
+}
+
+// Janitor periodically sweeps in-process stores that would otherwise only
+// be cleaned up as a side effect of being used, so long-running replicas
+// keep a flat memory profile
+type Janitor struct {
+	registry *metrics.Registry
+	logger   *zap.Logger
+
+	mu     sync.Mutex
+	stores []*store
+}
+
+func New(logger *zap.Logger) *Janitor {
+	return &Janitor{logger: logger}
+}
+
+// Register adds a store to sweep, labelled name in the janitor's metrics
+func (j *Janitor) Register(name string, sweeper Sweeper) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	st := &store{name: name, sweeper: sweeper}
+	j.instrument(st)
+	j.stores = append(j.stores, st)
+}
+
+// SetMetrics reports how much each store's sweeps reclaim, how long they
+// take and how often they fail
+func (j *Janitor) SetMetrics(registry *metrics.Registry) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.registry = registry
+	for _, st := range j.stores {
+		j.instrument(st)
+	}
+}
+
+// instrument registers st's metrics once there is a registry. Callers hold
+// j.mu.
+func (j *Janitor) instrument(st *store) {
+	if j.registry == nil {
+		return
+	}
+	labels := map[string]string{"store": st.name}
+	st.reclaimed = j.registry.RegisterCounter("janitor_reclaimed_total", "Stale entries dropped by the janitor", labels)
+	st.failures = j.registry.RegisterCounter("janitor_sweep_failures_total", "Janitor sweeps that failed", labels)
+	st.duration = j.registry.RegisterHistogram("janitor_sweep_duration_seconds", "Time taken by janitor sweeps",
+		metrics.LatencyBuckets, labels)
+}
+
+// Start sweeps every registered store every interval until ctx is
+// cancelled
+func (j *Janitor) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			j.Run(ctx)
+		}
+	}()
+}
+
+// Run sweeps every registered store once and returns how many entries each
+// dropped. A failing store is logged and does not stop the others.
+func (j *Janitor) Run(ctx context.Context) map[string]int {
+	j.mu.Lock()
+	stores := append([]*store(nil), j.stores...)
+	j.mu.Unlock()
+
+	reclaimed := make(map[string]int, len(stores))
+	total := 0
+	for _, st := range stores {
+		start := time.Now()
+		n, err := st.sweeper.Sweep(ctx)
+		st.duration.Observe(time.Since(start).Seconds())
+		if n > 0 {
+			st.reclaimed.Add(uint64(n))
+		}
+		if err != nil {
+			st.failures.Inc()
+			if ctx.Err() == nil {
+				j.logger.Error("Janitor sweep failed", zap.String("store", st.name), zap.Error(err))
+			}
+		}
+		reclaimed[st.name] = n
+		total += n
+	}
+	if total > 0 {
+		j.logger.Info("Janitor reclaimed stale entries", zap.Any("reclaimed", reclaimed))
+	}
+	return reclaimed
+}
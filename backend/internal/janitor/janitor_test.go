@@ -0,0 +1,41 @@
+package janitor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/metrics"
+	"go.uber.org/zap"
+)
+
+func TestRunSweepsEveryStoreAndCountsReclaimed(t *testing.T) {
+	registry := metrics.NewRegistry()
+	j := New(zap.NewNop())
+	j.Register("cache", SweepFunc(func(context.Context) (int, error) { return 3, nil }))
+	j.SetMetrics(registry)
+	j.Register("broken", SweepFunc(func(context.Context) (int, error) { return 1, errors.New("store unavailable") }))
+
+	j.Run(context.Background())
+	reclaimed := j.Run(context.Background())
+	if reclaimed["cache"] != 3 || reclaimed["broken"] != 1 {
+		t.Fatalf("reclaimed = %v, want every store swept despite the failure", reclaimed)
+	}
+
+	var out strings.Builder
+	if err := registry.WritePrometheus(&out); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`janitor_reclaimed_total{store="cache"} 6`,
+		`janitor_reclaimed_total{store="broken"} 2`,
+		`janitor_sweep_failures_total{store="broken"} 2`,
+		`janitor_sweep_failures_total{store="cache"} 0`,
+		`janitor_sweep_duration_seconds_count{store="cache"} 2`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("metrics missing %q:\n%s", want, out.String())
+		}
+	}
+}
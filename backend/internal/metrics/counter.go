@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync/atomic"
+)
+
+// Counter is a count that only goes up. Counters sharing a name are one
+// Prometheus metric family told apart by their labels.
+type Counter struct {
+	name   string
+	help   string
+	labels string
+	value  atomic.Uint64
+}
+
+// RegisterCounter adds a counter with constant labels and returns it for
+// counting
+func (r *Registry) RegisterCounter(name, help string, labels map[string]string) *Counter {
+	c := &Counter{
+		name:   name,
+		help:   help,
+		labels: formatLabels(labels),
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.counters = append(r.counters, c)
+	sort.Slice(r.counters, func(i, j int) bool {
+		if r.counters[i].name != r.counters[j].name {
+			return r.counters[i].name < r.counters[j].name
+		}
+		return r.counters[i].labels < r.counters[j].labels
+	})
+	return c
+}
+
+// Inc adds one. It is safe on a nil counter.
+func (c *Counter) Inc() {
+	if c == nil {
+		return
+	}
+	c.value.Add(1)
+}
+
+// Add adds n. It is safe on a nil counter.
+func (c *Counter) Add(n uint64) {
+	if c == nil {
+		return
+	}
+	c.value.Add(n)
+}
+
+func (c *Counter) write(w io.Writer, header bool) error {
+	if header {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "%s%s %d\n", c.name, c.labels, c.value.Load())
+	return err
+}
@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+)
+
+type Handler struct {
+	registry *Registry
+	token    string
+}
+
+// NewHandler serves the registry. A non-empty token must be sent as a
+// bearer token.
+func NewHandler(registry *Registry, token string) *Handler {
+	return &Handler{
+		registry: registry,
+		token:    token,
+	}
+}
+
+// RequireToken rejects requests without the configured bearer token
+func (h *Handler) RequireToken(c *gin.Context) {
+	if h.token == "" {
+		c.Next()
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), []byte("Bearer "+h.token)) != 1 {
+		common.Abort(c, common.NewUnauthorizedError("unauthorized"))
+		return
+	}
+	c.Next()
+}
+
+// Scaling returns the current gauges as a flat JSON object, the shape
+// KEDA's metrics-api scaler reads with valueLocation
+func (h *Handler) Scaling(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"metrics":      h.registry.Snapshot(),
+		"collected_at": time.Now().UTC(),
+	})
+}
+
+// Prometheus returns the current gauges and histograms in the text
+// exposition format, for Prometheus Adapter based HPA custom metrics and
+// latency dashboards
+func (h *Handler) Prometheus(c *gin.Context) {
+	c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.Status(http.StatusOK)
+	_ = h.registry.WritePrometheus(c.Writer)
+}
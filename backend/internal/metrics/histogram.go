@@ -0,0 +1,115 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// LatencyBuckets are histogram bounds in seconds for request and delivery
+// latencies
+var LatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Histogram counts observations into cumulative buckets. Histograms sharing
+// a name are one Prometheus metric family told apart by their labels.
+type Histogram struct {
+	name   string
+	help   string
+	labels string
+	bounds []float64
+
+	mu     sync.Mutex
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// RegisterHistogram adds a histogram with constant labels and returns it
+// for observing
+func (r *Registry) RegisterHistogram(name, help string, bounds []float64, labels map[string]string) *Histogram {
+	h := &Histogram{
+		name:   name,
+		help:   help,
+		labels: formatLabels(labels),
+		bounds: bounds,
+		counts: make([]uint64, len(bounds)),
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.histograms = append(r.histograms, h)
+	sort.Slice(r.histograms, func(i, j int) bool {
+		if r.histograms[i].name != r.histograms[j].name {
+			return r.histograms[i].name < r.histograms[j].name
+		}
+		return r.histograms[i].labels < r.histograms[j].labels
+	})
+	return h
+}
+
+// Observe records one value. It is safe on a nil histogram.
+func (h *Histogram) Observe(value float64) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, bound := range h.bounds {
+		if value <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += value
+	h.count++
+}
+
+func (h *Histogram) write(w io.Writer, header bool) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if header {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name); err != nil {
+			return err
+		}
+	}
+	for i, bound := range h.bounds {
+		le := `le="` + strconv.FormatFloat(bound, 'g', -1, 64) + `"`
+		if _, err := fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, withLabel(h.labels, le), h.counts[i]); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "%s_bucket%s %d\n%s_sum%s %s\n%s_count%s %d\n",
+		h.name, withLabel(h.labels, `le="+Inf"`), h.count,
+		h.name, h.labels, strconv.FormatFloat(h.sum, 'g', -1, 64),
+		h.name, h.labels, h.count)
+	return err
+}
+
+// formatLabels renders labels as {a="1",b="2"}, sorted by name
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + `="` + escaper.Replace(labels[name]) + `"`
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func withLabel(labels, label string) string {
+	if labels == "" {
+		return "{" + label + "}"
+	}
+	return labels[:len(labels)-1] + "," + label + "}"
+}
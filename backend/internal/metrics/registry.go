@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// Gauge is a value sampled each time metrics are read
+type Gauge struct {
+	Name  string
+	Help  string
+	Value func() float64
+}
+
+// Registry holds the gauges served to autoscalers and the counters and
+// histograms served to Prometheus. Values are per replica; autoscalers sum
+// or average them across pods.
+type Registry struct {
+	mu         sync.RWMutex
+	gauges     []Gauge
+	counters   []*Counter
+	histograms []*Histogram
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// RegisterGauge adds a gauge. Names follow Prometheus conventions
+// (snake_case, unit suffixes).
+func (r *Registry) RegisterGauge(name, help string, value func() float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.gauges = append(r.gauges, Gauge{Name: name, Help: help, Value: value})
+	sort.Slice(r.gauges, func(i, j int) bool { return r.gauges[i].Name < r.gauges[j].Name })
+}
+
+// Snapshot samples every gauge
+func (r *Registry) Snapshot() map[string]float64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	values := make(map[string]float64, len(r.gauges))
+	for _, g := range r.gauges {
+		values[g.Name] = g.Value()
+	}
+	return values
+}
+
+// WritePrometheus writes every gauge, counter and histogram in the
+// Prometheus text exposition format
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, g := range r.gauges {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %s\n",
+			g.Name, g.Help, g.Name, g.Name, strconv.FormatFloat(g.Value(), 'g', -1, 64)); err != nil {
+			return err
+		}
+	}
+	for i, c := range r.counters {
+		if err := c.write(w, i == 0 || r.counters[i-1].name != c.name); err != nil {
+			return err
+		}
+	}
+	for i, h := range r.histograms {
+		if err := h.write(w, i == 0 || r.histograms[i-1].name != h.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestRouter(token string) *gin.Engine {
+	registry := NewRegistry()
+	registry.RegisterGauge("websocket_connections", "Open WebSocket connections", func() float64 { return 42 })
+	registry.RegisterGauge("notification_queue_depth", "Notification messages still being sent", func() float64 { return 0.5 })
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	h := NewHandler(registry, token)
+	router.GET("/internal/scaling", h.RequireToken, h.Scaling)
+	router.GET("/internal/metrics", h.RequireToken, h.Prometheus)
+	return router
+}
+
+func get(router *gin.Engine, path, auth string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestPrometheusFormat(t *testing.T) {
+	w := get(newTestRouter(""), "/internal/metrics", "")
+	want := "# HELP notification_queue_depth Notification messages still being sent\n" +
+		"# TYPE notification_queue_depth gauge\n" +
+		"notification_queue_depth 0.5\n" +
+		"# HELP websocket_connections Open WebSocket connections\n" +
+		"# TYPE websocket_connections gauge\n" +
+		"websocket_connections 42\n"
+	if w.Code != http.StatusOK || w.Body.String() != want {
+		t.Fatalf("got %d:\n%s\nwant:\n%s", w.Code, w.Body.String(), want)
+	}
+}
+
+func TestScalingJSON(t *testing.T) {
+	w := get(newTestRouter(""), "/internal/scaling", "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"websocket_connections":42`) {
+		t.Fatalf("got %d %s, want websocket_connections 42", w.Code, w.Body.String())
+	}
+}
+
+func TestRequireToken(t *testing.T) {
+	router := newTestRouter("s3cret")
+	for auth, want := range map[string]int{
+		"":               http.StatusUnauthorized,
+		"Bearer wrong":   http.StatusUnauthorized,
+		"Bearer s3cret":  http.StatusOK,
+		"Basic s3cret":   http.StatusUnauthorized,
+		"Bearer s3cret ": http.StatusUnauthorized,
+	} {
+		if w := get(router, "/internal/scaling", auth); w.Code != want {
+			t.Errorf("Authorization %q: status %d, want %d", auth, w.Code, want)
+		}
+	}
+}
+
+func TestHistogramPrometheusFormat(t *testing.T) {
+	registry := NewRegistry()
+	probe := registry.RegisterHistogram("delivery_seconds", "Delivery latency", []float64{0.1, 1}, map[string]string{"source": "probe", "region": `eu"1`})
+	client := registry.RegisterHistogram("delivery_seconds", "Delivery latency", []float64{0.1, 1}, map[string]string{"source": "client", "region": "eu"})
+	probe.Observe(0.05)
+	probe.Observe(0.5)
+	probe.Observe(3)
+	client.Observe(1)
+
+	var b strings.Builder
+	if err := registry.WritePrometheus(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := "# HELP delivery_seconds Delivery latency\n" +
+		"# TYPE delivery_seconds histogram\n" +
+		"delivery_seconds_bucket{region=\"eu\",source=\"client\",le=\"0.1\"} 0\n" +
+		"delivery_seconds_bucket{region=\"eu\",source=\"client\",le=\"1\"} 1\n" +
+		"delivery_seconds_bucket{region=\"eu\",source=\"client\",le=\"+Inf\"} 1\n" +
+		"delivery_seconds_sum{region=\"eu\",source=\"client\"} 1\n" +
+		"delivery_seconds_count{region=\"eu\",source=\"client\"} 1\n" +
+		"delivery_seconds_bucket{region=\"eu\\\"1\",source=\"probe\",le=\"0.1\"} 1\n" +
+		"delivery_seconds_bucket{region=\"eu\\\"1\",source=\"probe\",le=\"1\"} 2\n" +
+		"delivery_seconds_bucket{region=\"eu\\\"1\",source=\"probe\",le=\"+Inf\"} 3\n" +
+		"delivery_seconds_sum{region=\"eu\\\"1\",source=\"probe\"} 3.55\n" +
+		"delivery_seconds_count{region=\"eu\\\"1\",source=\"probe\"} 3\n"
+	if b.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", b.String(), want)
+	}
+
+	var nilHistogram *Histogram
+	nilHistogram.Observe(1)
+}
@@ -0,0 +1,190 @@
+package metrics
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Route labels for requests outside the allowlist
+const (
+	// RouteOther labels requests to routes that are not allowlisted
+	RouteOther = "other"
+	// RouteUnmatched labels requests that matched no route, whose raw
+	// paths would be unbounded
+	RouteUnmatched = "unmatched"
+)
+
+// Outcomes of WebSocket upgrade requests
+const (
+	UpgradeAccepted     = "accepted"
+	UpgradeUnauthorized = "unauthorized"
+	UpgradeRateLimited  = "rate_limited"
+	// UpgradeUnsupported is a refused protocol version
+	UpgradeUnsupported = "unsupported_protocol"
+	UpgradeRejected    = "rejected"
+	UpgradeError       = "error"
+)
+
+// knownMethods are labelled as themselves; anything else is OTHER
+var knownMethods = map[string]bool{
+	http.MethodGet: true, http.MethodHead: true, http.MethodPost: true, http.MethodPut: true,
+	http.MethodPatch: true, http.MethodDelete: true, http.MethodOptions: true,
+}
+
+// RouteMetrics counts and times requests by route template rather than
+// raw path, so IDs in paths do not become label values. Only allowlisted
+// templates get their own label. WebSocket upgrades are counted by outcome
+// in a family of their own, since their duration is the connection's.
+type RouteMetrics struct {
+	registry *Registry
+
+	mu        sync.RWMutex
+	allowed   map[string]bool
+	requests  map[string]*Counter
+	durations map[string]*Histogram
+	upgrades  map[string]*Counter
+}
+
+func NewRouteMetrics(registry *Registry) *RouteMetrics {
+	return &RouteMetrics{
+		registry:  registry,
+		allowed:   make(map[string]bool),
+		requests:  make(map[string]*Counter),
+		durations: make(map[string]*Histogram),
+		upgrades:  make(map[string]*Counter),
+	}
+}
+
+// Allow adds route templates, as registered with gin, that get their own
+// route label
+func (m *RouteMetrics) Allow(routes ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, route := range routes {
+		m.allowed[route] = true
+	}
+}
+
+// Middleware records every request once its handlers have run. It must be
+// installed before the routes it measures are registered.
+func (m *RouteMetrics) Middleware(c *gin.Context) {
+	if isUpgrade(c.Request) {
+		writer := &upgradeWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		writer.onHijack = func() { m.upgrade(UpgradeAccepted).Inc() }
+		c.Next()
+		if !writer.hijacked {
+			m.upgrade(upgradeOutcome(c.Writer.Status())).Inc()
+		}
+		return
+	}
+
+	start := time.Now()
+	c.Next()
+
+	method := c.Request.Method
+	if !knownMethods[method] {
+		method = "OTHER"
+	}
+	route := m.route(c.FullPath())
+	status := strconv.Itoa(c.Writer.Status()/100) + "xx"
+	m.counter(method, route, status).Inc()
+	m.histogram(method, route).Observe(time.Since(start).Seconds())
+}
+
+func (m *RouteMetrics) route(fullPath string) string {
+	if fullPath == "" {
+		return RouteUnmatched
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if !m.allowed[fullPath] {
+		return RouteOther
+	}
+	return fullPath
+}
+
+func (m *RouteMetrics) counter(method, route, status string) *Counter {
+	key := method + " " + route + " " + status
+	return lookup(m, m.requests, key, func() *Counter {
+		return m.registry.RegisterCounter("http_requests_total", "HTTP requests by route template and status class",
+			map[string]string{"method": method, "route": route, "status": status})
+	})
+}
+
+func (m *RouteMetrics) upgrade(outcome string) *Counter {
+	return lookup(m, m.upgrades, outcome, func() *Counter {
+		return m.registry.RegisterCounter("websocket_upgrades_total", "WebSocket upgrade requests by outcome",
+			map[string]string{"outcome": outcome})
+	})
+}
+
+func (m *RouteMetrics) histogram(method, route string) *Histogram {
+	return lookup(m, m.durations, method+" "+route, func() *Histogram {
+		return m.registry.RegisterHistogram("http_request_duration_seconds", "HTTP request latency by route template",
+			LatencyBuckets, map[string]string{"method": method, "route": route})
+	})
+}
+
+// lookup returns the metric for key, registering it on first use
+func lookup[T any](m *RouteMetrics, metrics map[string]T, key string, register func() T) T {
+	m.mu.RLock()
+	metric, ok := metrics[key]
+	m.mu.RUnlock()
+	if ok {
+		return metric
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if metric, ok := metrics[key]; ok {
+		return metric
+	}
+	metric = register()
+	metrics[key] = metric
+	return metric
+}
+
+func isUpgrade(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+}
+
+// upgradeOutcome classifies an upgrade request that was answered instead
+// of upgraded
+func upgradeOutcome(status int) string {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return UpgradeUnauthorized
+	case status == http.StatusTooManyRequests:
+		return UpgradeRateLimited
+	case status == http.StatusUpgradeRequired:
+		return UpgradeUnsupported
+	case status >= http.StatusInternalServerError:
+		return UpgradeError
+	}
+	return UpgradeRejected
+}
+
+// upgradeWriter notices the hijack that completes a WebSocket upgrade, so
+// it is counted when it happens rather than when the connection closes
+type upgradeWriter struct {
+	gin.ResponseWriter
+	hijacked bool
+	onHijack func()
+}
+
+func (w *upgradeWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := w.ResponseWriter.Hijack()
+	if err == nil {
+		w.hijacked = true
+		w.onHijack()
+	}
+	return conn, rw, err
+}
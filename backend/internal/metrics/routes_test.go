@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+func newRouteTestServer(t *testing.T) (*Registry, *httptest.Server) {
+	t.Helper()
+	registry := NewRegistry()
+	routeMetrics := NewRouteMetrics(registry)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(routeMetrics.Middleware)
+	router.GET("/tasks/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/tasks/:id/history", func(c *gin.Context) { c.Status(http.StatusNotFound) })
+	router.GET("/ws", func(c *gin.Context) {
+		if c.Query("protocol") == "0" {
+			c.Status(http.StatusUpgradeRequired)
+			return
+		}
+		conn, err := (&websocket.Upgrader{}).Upgrade(c.Writer, c.Request, nil)
+		if err == nil {
+			conn.Close()
+		}
+	})
+	routeMetrics.Allow("/tasks/:id", "/ws")
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return registry, server
+}
+
+func TestRouteMetricsLabelByTemplate(t *testing.T) {
+	registry, server := newRouteTestServer(t)
+	for _, path := range []string{"/tasks/1", "/tasks/2", "/tasks/3/history", "/random/9f8e"} {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	var b strings.Builder
+	if err := registry.WritePrometheus(&b); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		`http_requests_total{method="GET",route="/tasks/:id",status="2xx"} 2`,
+		`http_requests_total{method="GET",route="other",status="4xx"} 1`,
+		`http_requests_total{method="GET",route="unmatched",status="4xx"} 1`,
+		`http_request_duration_seconds_count{method="GET",route="/tasks/:id"} 2`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %s in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "9f8e") || strings.Contains(out, "/tasks/1") {
+		t.Errorf("raw paths leaked into labels:\n%s", out)
+	}
+}
+
+func TestRouteMetricsCountUpgradeOutcomes(t *testing.T) {
+	registry, server := newRouteTestServer(t)
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	if _, _, err := websocket.DefaultDialer.Dial(url+"?protocol=0", nil); err == nil {
+		t.Fatal("want the upgrade refused")
+	}
+
+	var b strings.Builder
+	if err := registry.WritePrometheus(&b); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"# TYPE websocket_upgrades_total counter\n",
+		`websocket_upgrades_total{outcome="accepted"} 1`,
+		`websocket_upgrades_total{outcome="unsupported_protocol"} 1`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %s in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, `route="/ws"`) {
+		t.Errorf("upgrades should not be timed as requests:\n%s", out)
+	}
+}
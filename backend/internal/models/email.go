@@ -0,0 +1,20 @@
+package models
+
+import "strings"
+
+// NormalizeEmail returns the form of an email address that identifies an
+// account: trimmed and lowercased. With foldGmail, dots and any +tag are
+// also dropped from Gmail addresses, which Gmail delivers to the same
+// mailbox.
+func NormalizeEmail(email string, foldGmail bool) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if !foldGmail {
+		return email
+	}
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || (domain != "gmail.com" && domain != "googlemail.com") {
+		return email
+	}
+	local, _, _ = strings.Cut(local, "+")
+	return strings.ReplaceAll(local, ".", "") + "@gmail.com"
+}
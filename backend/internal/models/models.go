@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"gorm.io/gorm"
@@ -14,8 +15,21 @@ type User struct {
 	UpdatedAt time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
-	AssignedTasks []Task `gorm:"foreignKey:AssignedTo;constraint:OnDelete:SET NULL" json:"assigned_tasks,omitempty"`
-	CreatedTasks  []Task `gorm:"foreignKey:CreatedBy;constraint:OnDelete:SET NULL" json:"created_tasks,omitempty"`
+	// NormalizedEmail is the email as NormalizeEmail returns it, which must
+	// be unique. It is NULL for deleted accounts and for older accounts
+	// that matched an earlier account when it was backfilled.
+	NormalizedEmail *string `gorm:"type:varchar(255);uniqueIndex" json:"-"`
+
+	// AIPlan names the plan whose daily AI quota applies to the user
+	AIPlan string `gorm:"type:varchar(50);not null;default:'free'" json:"-"`
+
+	// Administrators disable accounts, and force a password reset, which
+	// also revokes the tokens issued before SessionsRevokedAt
+	DisabledAt            *time.Time `json:"disabled_at,omitempty"`
+	PasswordResetRequired bool       `gorm:"not null;default:false" json:"password_reset_required"`
+	SessionsRevokedAt     *time.Time `json:"-"`
+
+	CreatedTasks []Task `gorm:"foreignKey:CreatedBy;constraint:OnDelete:SET NULL" json:"created_tasks,omitempty"`
 }
 
 type TaskStatus string
@@ -31,19 +45,703 @@ const (
 	PriorityHigh   TaskPriority = "high"
 )
 
+// DeadlineType is how strictly a task's due date is held. Past a soft
+// deadline a task is only flagged; past a hard one it is escalated, and
+// only its creator can move the deadline or reassign it.
+type DeadlineType string
+
+const (
+	DeadlineSoft DeadlineType = "soft"
+	DeadlineHard DeadlineType = "hard"
+)
+
 type Task struct {
 	ID          string         `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
 	Title       string         `gorm:"type:varchar(255);not null" json:"title"`
 	Description string         `gorm:"type:text" json:"description"`
 	Status      TaskStatus     `gorm:"type:varchar(50);not null;default:'pending';check:status IN ('pending', 'in_progress', 'completed')" json:"status"`
 	Priority    TaskPriority   `gorm:"type:varchar(50);not null;check:priority IN ('low', 'medium', 'high')" json:"priority"`
-	AssignedTo  string         `gorm:"type:uuid;index" json:"assigned_to"`
 	CreatedBy   string         `gorm:"type:uuid;not null;index" json:"created_by"`
 	CreatedAt   time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
 	UpdatedAt   time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
 	DueDate     time.Time      `gorm:"not null;index" json:"due_date"`
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
 
-	AssignedUser *User `gorm:"foreignKey:AssignedTo;references:ID" json:"assigned_user,omitempty"`
-	Creator      *User `gorm:"foreignKey:CreatedBy;references:ID" json:"creator,omitempty"`
+	Project         string     `gorm:"type:varchar(100);index" json:"project,omitempty"`
+	EstimatedEffort float64    `gorm:"not null;default:0" json:"estimated_effort"`
+	CompletedAt     *time.Time `gorm:"index" json:"completed_at,omitempty"`
+	// ArchivedAt is set when a completed task is archived, leaving it out
+	// of task lists and search until it is restored or reopened
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+
+	DeadlineType DeadlineType `gorm:"type:varchar(10);not null;default:'soft';check:deadline_type IN ('soft', 'hard')" json:"deadline_type"`
+
+	Creator   *User           `gorm:"foreignKey:CreatedBy;references:ID" json:"creator,omitempty"`
+	Assignees []TaskAssignee  `gorm:"foreignKey:TaskID;constraint:OnDelete:CASCADE" json:"assignees,omitempty"`
+	Checklist []ChecklistItem `gorm:"foreignKey:TaskID;constraint:OnDelete:CASCADE" json:"checklist,omitempty"`
+	Watchers  []TaskWatcher   `gorm:"foreignKey:TaskID;constraint:OnDelete:CASCADE" json:"watchers,omitempty"`
+
+	// AssignedTo is the primary assignee, derived from Assignees for clients
+	// that predate multiple assignees. The tasks.assigned_to column it used
+	// to map to is deprecated: it is no longer written and will be dropped.
+	AssignedTo string `gorm:"-" json:"assigned_to"`
+}
+
+// AfterFind fills AssignedTo from the primary assignee when Assignees was
+// preloaded
+func (t *Task) AfterFind(tx *gorm.DB) error {
+	for _, a := range t.Assignees {
+		if a.IsPrimary {
+			t.AssignedTo = a.UserID
+			break
+		}
+	}
+	return nil
+}
+
+// IsOverdue reports whether the task is open and was due before now
+func (t Task) IsOverdue(now time.Time) bool {
+	return t.Status != StatusCompleted && !t.DueDate.IsZero() && t.DueDate.Before(now)
+}
+
+// HardDeadlinePassed reports whether the task is open past a hard deadline
+func (t Task) HardDeadlinePassed(now time.Time) bool {
+	return t.DeadlineType == DeadlineHard && t.IsOverdue(now)
+}
+
+// MarshalJSON adds is_overdue, derived when the task is encoded so it is
+// never stale
+func (t Task) MarshalJSON() ([]byte, error) {
+	type task Task
+	return json.Marshal(struct {
+		task
+		IsOverdue bool `json:"is_overdue"`
+	}{task(t), t.IsOverdue(time.Now())})
+}
+
+// TaskAssignee links a task to one of its assignees. Exactly one assignee
+// per task is primary; Task.AssignedTo exposes it for existing clients.
+type TaskAssignee struct {
+	TaskID    string    `gorm:"primaryKey;type:uuid" json:"task_id"`
+	UserID    string    `gorm:"primaryKey;type:uuid;index" json:"user_id"`
+	IsPrimary bool      `gorm:"not null;default:false" json:"is_primary"`
+	CreatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+
+	User *User `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// TaskWatcher links a task to a user who follows its changes without
+// being assigned to it
+type TaskWatcher struct {
+	TaskID    string    `gorm:"primaryKey;type:uuid" json:"task_id"`
+	UserID    string    `gorm:"primaryKey;type:uuid;index" json:"user_id"`
+	CreatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+
+	User *User `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// TaskEditLock marks a task as being edited by a user until ExpiresAt.
+// An expired lock is free, and is replaced by the next one taken.
+type TaskEditLock struct {
+	TaskID     string    `gorm:"primaryKey;type:uuid" json:"task_id"`
+	UserID     string    `gorm:"type:uuid;not null;index" json:"user_id"`
+	AcquiredAt time.Time `gorm:"not null" json:"acquired_at"`
+	ExpiresAt  time.Time `gorm:"not null" json:"expires_at"`
+
+	User *User `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// TransferStatus is where a task transfer is in its acceptance flow
+type TransferStatus string
+
+const (
+	TransferPending  TransferStatus = "pending"
+	TransferAccepted TransferStatus = "accepted"
+	TransferDeclined TransferStatus = "declined"
+	TransferExpired  TransferStatus = "expired"
+)
+
+// TaskTransfer hands a task from its primary assignee to another user,
+// who must accept it before ExpiresAt. A declined or expired transfer
+// gives the task back to FromUserID.
+type TaskTransfer struct {
+	ID          string         `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	TaskID      string         `gorm:"type:uuid;not null;index" json:"task_id"`
+	FromUserID  string         `gorm:"type:uuid;not null" json:"from_user_id"`
+	ToUserID    string         `gorm:"type:uuid;not null;index" json:"to_user_id"`
+	RequestedBy string         `gorm:"type:uuid;not null" json:"requested_by"`
+	Note        string         `gorm:"type:varchar(500)" json:"note,omitempty"`
+	Status      TransferStatus `gorm:"type:varchar(20);not null;index" json:"status"`
+	Reason      string         `gorm:"type:varchar(500)" json:"reason,omitempty"`
+	ExpiresAt   time.Time      `gorm:"not null;index" json:"expires_at"`
+	RespondedAt *time.Time     `json:"responded_at,omitempty"`
+	CreatedAt   time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// Assignment history events
+const (
+	AssignmentAssigned          = "assigned"
+	AssignmentTransferRequested = "transfer_requested"
+	AssignmentTransferAccepted  = "transfer_accepted"
+	AssignmentTransferDeclined  = "transfer_declined"
+	AssignmentTransferExpired   = "transfer_expired"
+)
+
+// AssignmentEvent records a change of a task's primary assignee. ActorID
+// is empty for changes the server made itself, such as expired transfers.
+type AssignmentEvent struct {
+	ID         string    `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	TaskID     string    `gorm:"type:uuid;not null;index" json:"task_id"`
+	Event      string    `gorm:"type:varchar(30);not null" json:"event"`
+	ActorID    *string   `gorm:"type:uuid" json:"actor_id,omitempty"`
+	FromUserID *string   `gorm:"type:uuid" json:"from_user_id,omitempty"`
+	ToUserID   *string   `gorm:"type:uuid" json:"to_user_id,omitempty"`
+	TransferID *string   `gorm:"type:uuid" json:"transfer_id,omitempty"`
+	Reason     string    `gorm:"type:varchar(500)" json:"reason,omitempty"`
+	CreatedAt  time.Time `gorm:"not null;default:CURRENT_TIMESTAMP;index" json:"created_at"`
+}
+
+// ChecklistItem is one step of a task, shown in Position order
+type ChecklistItem struct {
+	ID        string         `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	TaskID    string         `gorm:"type:uuid;not null;index" json:"task_id"`
+	Text      string         `gorm:"type:varchar(500);not null" json:"text"`
+	Done      bool           `gorm:"not null;default:false" json:"done"`
+	Position  int            `gorm:"not null;default:0" json:"position"`
+	CreatedAt time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+type TimeEntry struct {
+	ID              string         `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	TaskID          string         `gorm:"type:uuid;not null;index;uniqueIndex:idx_time_entries_running,where:ended_at IS NULL AND deleted_at IS NULL" json:"task_id"`
+	UserID          string         `gorm:"type:uuid;not null;index;uniqueIndex:idx_time_entries_running,where:ended_at IS NULL AND deleted_at IS NULL" json:"user_id"`
+	StartedAt       time.Time      `gorm:"not null" json:"started_at"`
+	EndedAt         *time.Time     `json:"ended_at,omitempty"`
+	DurationSeconds int64          `gorm:"not null;default:0" json:"duration_seconds"`
+	Note            string         `gorm:"type:text" json:"note,omitempty"`
+	Manual          bool           `gorm:"not null;default:false" json:"manual"`
+	CreatedAt       time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt       time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Task *Task `gorm:"foreignKey:TaskID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+	User *User `gorm:"foreignKey:UserID;references:ID" json:"-"`
+}
+
+// TaskTemplate holds the content of a recurring kind of task. Tasks created
+// from it are due DueInDays after creation unless a due date is given.
+type TaskTemplate struct {
+	ID              string         `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	Name            string         `gorm:"type:varchar(100);not null" json:"name"`
+	Title           string         `gorm:"type:varchar(255);not null" json:"title"`
+	Description     string         `gorm:"type:text" json:"description"`
+	Priority        TaskPriority   `gorm:"type:varchar(50);not null;check:priority IN ('low', 'medium', 'high')" json:"priority"`
+	Project         string         `gorm:"type:varchar(100)" json:"project,omitempty"`
+	EstimatedEffort float64        `gorm:"not null;default:0" json:"estimated_effort"`
+	DueInDays       int            `gorm:"not null;default:0" json:"due_in_days"`
+	Checklist       []string       `gorm:"type:jsonb;serializer:json" json:"checklist"`
+	CreatedBy       string         `gorm:"type:uuid;not null;index" json:"created_by"`
+	CreatedAt       time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt       time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// ProjectFieldSchema says, for each configurable task field of a project,
+// whether its tasks must, may or must not set it. DefinitionOfDone lists
+// the checklist items its tasks must have checked to be completed.
+type ProjectFieldSchema struct {
+	Project          string            `gorm:"primaryKey;type:varchar(100)" json:"project"`
+	Fields           map[string]string `gorm:"type:jsonb;serializer:json;not null" json:"fields"`
+	DefinitionOfDone []string          `gorm:"type:jsonb;serializer:json" json:"definition_of_done"`
+	DeadlineType     DeadlineType      `gorm:"type:varchar(10)" json:"deadline_type"`
+	UpdatedBy        string            `gorm:"type:uuid" json:"updated_by,omitempty"`
+	UpdatedAt        time.Time         `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// ProjectEscalationRule says how many hours before their due date the
+// project's tasks that are still pending get a higher priority. Zero
+// turns escalation off for the project.
+type ProjectEscalationRule struct {
+	Project     string    `gorm:"primaryKey;type:varchar(100)" json:"project"`
+	WithinHours int       `gorm:"not null" json:"within_hours"`
+	UpdatedBy   string    `gorm:"type:uuid" json:"updated_by,omitempty"`
+	UpdatedAt   time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// ProjectCalendar is a project's business calendar: the weekdays its
+// people work and its holidays, both in Timezone. Due dates of the
+// project's tasks must fall on working days, and "due in N days" counts
+// working days.
+type ProjectCalendar struct {
+	Project  string `gorm:"primaryKey;type:varchar(100)" json:"project"`
+	Timezone string `gorm:"type:varchar(64);not null" json:"timezone"`
+	// WorkingDays are weekdays, 0 for Sunday to 6 for Saturday
+	WorkingDays []time.Weekday `gorm:"type:jsonb;serializer:json;not null" json:"working_days"`
+	// Holidays are YYYY-MM-DD dates
+	Holidays  []string  `gorm:"type:jsonb;serializer:json" json:"holidays"`
+	UpdatedBy string    `gorm:"type:uuid" json:"updated_by,omitempty"`
+	UpdatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// Location is the calendar's time zone, or UTC if it cannot be loaded
+func (c ProjectCalendar) Location() *time.Location {
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// IsWorkingDay reports whether t falls on a working day that is not a
+// holiday, in the calendar's time zone
+func (c ProjectCalendar) IsWorkingDay(t time.Time) bool {
+	t = t.In(c.Location())
+	worked := false
+	for _, day := range c.WorkingDays {
+		if day == t.Weekday() {
+			worked = true
+			break
+		}
+	}
+	if !worked {
+		return false
+	}
+	date := t.Format(time.DateOnly)
+	for _, holiday := range c.Holidays {
+		if holiday == date {
+			return false
+		}
+	}
+	return true
+}
+
+// NextWorkingDay returns t if it falls on a working day, or else the same
+// time of day on the next working day. A calendar without working days
+// returns t.
+func (c ProjectCalendar) NextWorkingDay(t time.Time) time.Time {
+	if len(c.WorkingDays) == 0 {
+		return t
+	}
+	t = t.In(c.Location())
+	for !c.IsWorkingDay(t) {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t
+}
+
+// AddWorkingDays returns the time of day of from, days working days later.
+// A calendar without working days adds calendar days.
+func (c ProjectCalendar) AddWorkingDays(from time.Time, days int) time.Time {
+	if len(c.WorkingDays) == 0 {
+		return from.AddDate(0, 0, days)
+	}
+	t := from.In(c.Location())
+	for days > 0 {
+		t = t.AddDate(0, 0, 1)
+		if c.IsWorkingDay(t) {
+			days--
+		}
+	}
+	return t
+}
+
+// AddWorkingHours returns the time hours after from, counting only the
+// hours of working days. A calendar without working days adds every hour.
+func (c ProjectCalendar) AddWorkingHours(from time.Time, hours int) time.Time {
+	remaining := time.Duration(hours) * time.Hour
+	if len(c.WorkingDays) == 0 {
+		return from.Add(remaining)
+	}
+	loc := c.Location()
+	t := from.In(loc)
+	for {
+		year, month, day := t.Date()
+		midnight := time.Date(year, month, day+1, 0, 0, 0, 0, loc)
+		if c.IsWorkingDay(t) {
+			if left := midnight.Sub(t); remaining <= left {
+				return t.Add(remaining)
+			}
+			remaining -= midnight.Sub(t)
+		}
+		t = midnight
+	}
+}
+
+// TaskEscalation records a raise of a task's priority by the escalation
+// job, which found it pending WithinHours or less before DueDate
+type TaskEscalation struct {
+	ID           string       `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	TaskID       string       `gorm:"type:uuid;not null;index" json:"task_id"`
+	FromPriority TaskPriority `gorm:"type:varchar(50);not null" json:"from_priority"`
+	ToPriority   TaskPriority `gorm:"type:varchar(50);not null" json:"to_priority"`
+	DueDate      time.Time    `gorm:"not null" json:"due_date"`
+	WithinHours  int          `gorm:"not null" json:"within_hours"`
+	CreatedAt    time.Time    `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+
+	Task *Task `gorm:"foreignKey:TaskID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// AISettings is the organization's choice of AI model and generation
+// parameters, kept in a single row. Unset fields use the server's
+// configuration.
+type AISettings struct {
+	ID          int       `gorm:"primaryKey;autoIncrement:false" json:"-"`
+	Model       string    `gorm:"type:varchar(100)" json:"model,omitempty"`
+	Temperature *float32  `json:"temperature,omitempty"`
+	MaxTokens   int       `gorm:"not null;default:0" json:"max_tokens,omitempty"`
+	UpdatedBy   string    `gorm:"type:uuid" json:"updated_by,omitempty"`
+	UpdatedAt   time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// RuntimeSetting overrides the configured value of an operational setting
+// while the server runs. Settings without a row use the configuration.
+type RuntimeSetting struct {
+	Key       string    `gorm:"primaryKey;type:varchar(100)" json:"key"`
+	Value     string    `gorm:"type:varchar(500);not null" json:"value"`
+	UpdatedBy string    `gorm:"type:uuid" json:"updated_by,omitempty"`
+	UpdatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// ProjectActivity is what happened to a project's tasks in a period.
+// OpenTasks and Overdue are counted at its end.
+type ProjectActivity struct {
+	Project         string   `json:"project"`
+	Created         int64    `json:"created"`
+	Completed       int64    `json:"completed"`
+	Updated         int64    `json:"updated"`
+	OpenTasks       int64    `json:"open_tasks"`
+	Overdue         int64    `json:"overdue"`
+	LoggedHours     float64  `json:"logged_hours"`
+	CompletedTitles []string `json:"completed_titles,omitempty"`
+}
+
+// ProjectReport is the AI-written summary of a project's week, which
+// starts on WeekStart, a Monday at midnight UTC
+type ProjectReport struct {
+	ID        string          `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	Project   string          `gorm:"type:varchar(100);not null;uniqueIndex:idx_project_reports_week,priority:1" json:"project"`
+	WeekStart time.Time       `gorm:"not null;uniqueIndex:idx_project_reports_week,priority:2" json:"week_start"`
+	Summary   string          `gorm:"type:text;not null" json:"summary"`
+	Activity  ProjectActivity `gorm:"type:jsonb;serializer:json;not null" json:"activity"`
+	Model     string          `gorm:"type:varchar(100)" json:"model"`
+	CreatedAt time.Time       `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// AIUsage counts the AI calls that returned a reply to a user on one day
+// (UTC), and the tokens they used
+type AIUsage struct {
+	UserID       string    `gorm:"primaryKey;type:uuid" json:"-"`
+	Day          time.Time `gorm:"primaryKey;type:date" json:"day"`
+	Calls        int64     `gorm:"not null;default:0" json:"calls"`
+	InputTokens  int64     `gorm:"not null;default:0" json:"input_tokens"`
+	OutputTokens int64     `gorm:"not null;default:0" json:"output_tokens"`
+}
+
+// ProjectWebhook sends the notifications of a project's tasks for one chat
+// channel to its own webhook instead of the global one. The URL is a
+// credential, so only URLHint is ever returned.
+type ProjectWebhook struct {
+	Project   string    `gorm:"primaryKey;type:varchar(100)" json:"project"`
+	Channel   string    `gorm:"primaryKey;type:varchar(20)" json:"channel"`
+	URL       string    `gorm:"type:varchar(2048);not null" json:"-"`
+	URLHint   string    `gorm:"-" json:"url_hint"`
+	UpdatedBy string    `gorm:"type:uuid" json:"updated_by,omitempty"`
+	CreatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// ProjectNotificationRule sends a project's notifications that match its
+// filters to a webhook of their own. Empty filters match every event.
+type ProjectNotificationRule struct {
+	ID             string    `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	Project        string    `gorm:"type:varchar(100);not null;index" json:"project"`
+	Channel        string    `gorm:"type:varchar(20);not null" json:"channel"`
+	Name           string    `gorm:"type:varchar(100)" json:"name,omitempty"`
+	URL            string    `gorm:"type:varchar(2048);not null" json:"-"`
+	URLHint        string    `gorm:"-" json:"url_hint"`
+	EventTypes     []string  `gorm:"type:jsonb;serializer:json" json:"event_types"`
+	Priorities     []string  `gorm:"type:jsonb;serializer:json" json:"priorities"`
+	TaskPriorities []string  `gorm:"type:jsonb;serializer:json" json:"task_priorities"`
+	UpdatedBy      string    `gorm:"type:uuid" json:"updated_by,omitempty"`
+	CreatedAt      time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt      time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// BoardLayout is how a user arranges one task board
+type BoardLayout struct {
+	ColumnOrder        []string `json:"column_order,omitempty"`
+	CollapsedSwimlanes []string `json:"collapsed_swimlanes,omitempty"`
+	SwimlaneBy         string   `json:"swimlane_by,omitempty"`
+}
+
+// UIPreferences is UI state that follows a user across devices: the view
+// and list filters to land on, and saved layouts by board
+type UIPreferences struct {
+	DefaultView    string                 `json:"default_view,omitempty"`
+	DefaultFilters map[string]string      `json:"default_filters,omitempty"`
+	Boards         map[string]BoardLayout `json:"boards,omitempty"`
+	// Language is the language tag, such as "de" or "pt-BR", notifications
+	// are written in
+	Language string `json:"language,omitempty"`
+}
+
+// Preference holds the UI preferences of a user, or, with an empty UserID,
+// the organization's defaults. Version goes up with every change so
+// concurrent edits from two devices cannot overwrite each other.
+type Preference struct {
+	Scope     string        `gorm:"primaryKey;type:varchar(10)" json:"scope"`
+	UserID    string        `gorm:"primaryKey;type:varchar(36)" json:"-"`
+	Data      UIPreferences `gorm:"type:jsonb;serializer:json;not null" json:"preferences"`
+	Version   int           `gorm:"not null" json:"version"`
+	UpdatedBy string        `gorm:"type:varchar(36)" json:"updated_by,omitempty"`
+	UpdatedAt time.Time     `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// ClientError is a sampled error report from a browser client.
+// SampleRate is the share of reports like it that are stored, so each row
+// stands for 1/SampleRate reports.
+type ClientError struct {
+	ID          string            `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	Kind        string            `gorm:"type:varchar(30);not null" json:"kind"`
+	Fingerprint string            `gorm:"type:varchar(16);not null;index" json:"fingerprint"`
+	Message     string            `gorm:"type:varchar(1000);not null" json:"message"`
+	Stack       string            `gorm:"type:text" json:"stack,omitempty"`
+	Source      string            `gorm:"type:varchar(500)" json:"source,omitempty"`
+	Page        string            `gorm:"type:varchar(500)" json:"page,omitempty"`
+	Release     string            `gorm:"type:varchar(100)" json:"release,omitempty"`
+	RequestID   string            `gorm:"type:varchar(100)" json:"request_id,omitempty"`
+	Attempts    int               `gorm:"not null;default:0" json:"attempts,omitempty"`
+	Context     map[string]string `gorm:"type:jsonb;serializer:json" json:"context,omitempty"`
+	UserAgent   string            `gorm:"type:varchar(500)" json:"user_agent,omitempty"`
+	SampleRate  float64           `gorm:"not null" json:"sample_rate"`
+	CreatedAt   time.Time         `gorm:"not null;default:CURRENT_TIMESTAMP;index" json:"created_at"`
+}
+
+// NotificationDeliveryStatus is where a failed notification is in its
+// retries
+type NotificationDeliveryStatus string
+
+const (
+	NotificationRetrying NotificationDeliveryStatus = "retrying"
+	NotificationDead     NotificationDeliveryStatus = "dead"
+)
+
+// NotificationDelivery is a chat message whose webhook send failed. It is
+// retried with backoff and deleted once sent; after its last attempt it
+// stays dead until an administrator requeues it. The webhook is looked up
+// again from Channel and Project on every attempt, so URLs are not stored.
+type NotificationDelivery struct {
+	ID        string `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	Channel   string `gorm:"type:varchar(20);not null" json:"channel"`
+	Project   string `gorm:"type:varchar(100)" json:"project,omitempty"`
+	EventType string `gorm:"type:varchar(50);not null" json:"event_type"`
+	TaskID    string `gorm:"type:varchar(100)" json:"task_id,omitempty"`
+	Recipient string `gorm:"type:varchar(100)" json:"recipient"`
+	// Rule is the project notification rule the message was routed by;
+	// empty for the project's or global webhook
+	Rule          string                     `gorm:"type:varchar(36)" json:"rule,omitempty"`
+	Payload       []byte                     `gorm:"type:jsonb;not null" json:"-"`
+	Status        NotificationDeliveryStatus `gorm:"type:varchar(20);not null;index:idx_notification_deliveries_due,priority:1" json:"status"`
+	Attempts      int                        `gorm:"not null" json:"attempts"`
+	LastError     string                     `gorm:"type:text" json:"last_error,omitempty"`
+	NextAttemptAt time.Time                  `gorm:"not null;index:idx_notification_deliveries_due,priority:2" json:"next_attempt_at"`
+	CreatedAt     time.Time                  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt     time.Time                  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+type OCRStatus string
+
+const (
+	OCRPending OCRStatus = "pending"
+	OCRDone    OCRStatus = "done"
+	OCRFailed  OCRStatus = "failed"
+	// OCRSkipped marks attachments that are neither images nor PDFs
+	OCRSkipped OCRStatus = "skipped"
+)
+
+// TaskAttachment is a file uploaded to a task. Images and PDFs are queued
+// for OCR; the extracted text is indexed for task search.
+type TaskAttachment struct {
+	ID          string         `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	TaskID      string         `gorm:"type:uuid;not null;index" json:"task_id"`
+	UploadedBy  string         `gorm:"type:uuid;not null" json:"uploaded_by"`
+	FileName    string         `gorm:"type:varchar(255);not null" json:"file_name"`
+	ContentType string         `gorm:"type:varchar(100);not null" json:"content_type"`
+	SizeBytes   int64          `gorm:"not null" json:"size_bytes"`
+	Data        []byte         `gorm:"type:bytea;not null" json:"-"`
+	OCRStatus   OCRStatus      `gorm:"type:varchar(20);not null;index" json:"ocr_status"`
+	OCRText     string         `gorm:"type:text;not null;default:''" json:"-"`
+	OCRAttempts int            `gorm:"not null;default:0" json:"ocr_attempts"`
+	OCRError    string         `gorm:"type:text" json:"ocr_error,omitempty"`
+	CreatedAt   time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt   time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Task *Task `gorm:"foreignKey:TaskID;references:ID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+type IntakeStatus string
+
+const (
+	IntakePendingReview IntakeStatus = "pending_review"
+	IntakeApproved      IntakeStatus = "approved"
+	IntakeRejected      IntakeStatus = "rejected"
+)
+
+// IntakeSubmission records a public form or inbound email submission that
+// moderation flagged or quarantined, so it can be triaged
+type IntakeSubmission struct {
+	ID             string         `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	Source         string         `gorm:"type:varchar(20);not null" json:"source"`
+	SubmitterEmail string         `gorm:"type:varchar(255)" json:"submitter_email,omitempty"`
+	Title          string         `gorm:"type:varchar(255);not null" json:"title"`
+	Description    string         `gorm:"type:text" json:"description"`
+	Project        string         `gorm:"type:varchar(100)" json:"project,omitempty"`
+	Priority       string         `gorm:"type:varchar(10)" json:"priority,omitempty"`
+	DueDate        *time.Time     `json:"due_date,omitempty"`
+	Action         string         `gorm:"type:varchar(20);not null" json:"action"`
+	Reasons        string         `gorm:"type:text" json:"reasons"`
+	Status         IntakeStatus   `gorm:"type:varchar(20);not null;default:'pending_review';index" json:"status"`
+	TaskID         *string        `gorm:"type:uuid" json:"task_id,omitempty"`
+	ReviewedBy     *string        `gorm:"type:uuid" json:"reviewed_by,omitempty"`
+	ReviewedAt     *time.Time     `json:"reviewed_at,omitempty"`
+	CreatedAt      time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt      time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+type ExportRunStatus string
+
+const (
+	ExportRunning   ExportRunStatus = "running"
+	ExportSucceeded ExportRunStatus = "succeeded"
+	ExportFailed    ExportRunStatus = "failed"
+)
+
+// ExportRun records one incremental export of a source table to the
+// warehouse. The WatermarkTo of the latest succeeded run is where the next
+// run resumes.
+type ExportRun struct {
+	ID            string          `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	Destination   string          `gorm:"type:varchar(20);not null;index:idx_export_runs_source" json:"destination"`
+	Source        string          `gorm:"type:varchar(50);not null;index:idx_export_runs_source" json:"source"`
+	WatermarkFrom time.Time       `gorm:"not null" json:"watermark_from"`
+	WatermarkTo   time.Time       `gorm:"not null" json:"watermark_to"`
+	Rows          int64           `gorm:"not null;default:0" json:"rows"`
+	Status        ExportRunStatus `gorm:"type:varchar(20);not null;index" json:"status"`
+	Error         string          `gorm:"type:text" json:"error,omitempty"`
+	StartedAt     time.Time       `gorm:"not null" json:"started_at"`
+	FinishedAt    *time.Time      `json:"finished_at,omitempty"`
+}
+
+// SecurityEvent is an append-only audit record. Events are delivered to
+// security webhooks in Sequence order.
+type SecurityEvent struct {
+	Sequence  int64     `gorm:"primaryKey;autoIncrement" json:"sequence"`
+	ID        string    `gorm:"type:uuid;not null;uniqueIndex;default:gen_random_uuid()" json:"id"`
+	Type      string    `gorm:"type:varchar(50);not null;index" json:"type"`
+	ActorID   string    `gorm:"type:varchar(255)" json:"actor_id,omitempty"`
+	Data      string    `gorm:"type:jsonb;not null;default:'{}'" json:"data"`
+	CreatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// ReportingToken records an issued reporting token. Tokens are only
+// accepted while their row exists and is not revoked.
+type ReportingToken struct {
+	ID        string     `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	UserID    string     `gorm:"type:uuid;not null;index" json:"user_id"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// UserSession is a device signed in to an account. Member tokens name their
+// session, which lasts as long as its latest token and stops every token
+// naming it once revoked.
+type UserSession struct {
+	ID         string     `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	UserID     string     `gorm:"type:uuid;not null;index" json:"-"`
+	UserAgent  string     `gorm:"type:varchar(512);not null;default:''" json:"user_agent"`
+	IPAddress  string     `gorm:"type:varchar(64);not null;default:''" json:"ip_address"`
+	CreatedAt  time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	LastSeenAt time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"last_seen_at"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt  *time.Time `json:"-"`
+	// Current marks the session of the token listing the sessions
+	Current bool `gorm:"-" json:"current"`
+}
+
+// SecurityWebhook receives every SecurityEvent after DeliveredSequence.
+// Delivery stops at the first failure and resumes from the same event, so
+// receivers always see events in order.
+type SecurityWebhook struct {
+	ID                  string         `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	URL                 string         `gorm:"type:varchar(2048);not null" json:"url"`
+	Secret              string         `gorm:"type:varchar(128);not null" json:"-"`
+	CreatedBy           string         `gorm:"type:uuid;not null" json:"created_by"`
+	DeliveredSequence   int64          `gorm:"not null;default:0" json:"delivered_sequence"`
+	ConsecutiveFailures int            `gorm:"not null;default:0" json:"consecutive_failures"`
+	LastError           string         `gorm:"type:text" json:"last_error,omitempty"`
+	NextAttemptAt       *time.Time     `json:"next_attempt_at,omitempty"`
+	CreatedAt           time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt           time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+	DeletedAt           gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// StatusIncident is a note about an outage or maintenance shown on the
+// public status page. Resolved incidents stay on the page for a week.
+type StatusIncident struct {
+	ID         string     `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	Title      string     `gorm:"type:varchar(200);not null" json:"title"`
+	Message    string     `gorm:"type:text;not null" json:"message"`
+	Status     string     `gorm:"type:varchar(20);not null" json:"status"`
+	CreatedBy  string     `gorm:"type:uuid;not null" json:"-"`
+	ResolvedAt *time.Time `gorm:"index" json:"resolved_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	UpdatedAt  time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"updated_at"`
+}
+
+// TaskLink is a pre-authorized link, such as one printed as a QR code,
+// that creates tasks in Project from TemplateID on behalf of CreatedBy
+// without a login. Only the SHA-256 of its token is stored. MaxUses of 0
+// is unlimited.
+type TaskLink struct {
+	ID         string     `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	TokenHash  string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	Name       string     `gorm:"type:varchar(100);not null" json:"name"`
+	Project    string     `gorm:"type:varchar(100);not null" json:"project"`
+	TemplateID *string    `gorm:"type:uuid" json:"template_id,omitempty"`
+	CreatedBy  string     `gorm:"type:uuid;not null;index" json:"created_by"`
+	MaxUses    int        `gorm:"not null;default:0" json:"max_uses"`
+	UseCount   int        `gorm:"not null;default:0" json:"use_count"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+type ComplianceReportStatus string
+
+const (
+	ComplianceReportRunning   ComplianceReportStatus = "running"
+	ComplianceReportCompleted ComplianceReportStatus = "completed"
+	ComplianceReportFailed    ComplianceReportStatus = "failed"
+)
+
+// ComplianceReport is the audit report of the days from PeriodFrom up to,
+// but not including, PeriodTo. Content is the signed JSON, kept as text so
+// its bytes, and so its Signature, stay exactly as generated.
+type ComplianceReport struct {
+	ID          string                 `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	PeriodFrom  time.Time              `gorm:"not null;uniqueIndex:idx_compliance_reports_period" json:"period_from"`
+	PeriodTo    time.Time              `gorm:"not null;uniqueIndex:idx_compliance_reports_period" json:"period_to"`
+	Status      ComplianceReportStatus `gorm:"type:varchar(20);not null;index" json:"status"`
+	RequestedBy string                 `gorm:"type:uuid;not null" json:"requested_by"`
+	Content     string                 `gorm:"type:text" json:"-"`
+	Signature   string                 `gorm:"type:varchar(64)" json:"signature,omitempty"`
+	Error       string                 `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt   time.Time              `gorm:"not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	CompletedAt *time.Time             `json:"completed_at,omitempty"`
 }
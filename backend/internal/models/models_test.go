@@ -0,0 +1,93 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTaskAfterFindDerivesAssignedTo(t *testing.T) {
+	task := Task{Assignees: []TaskAssignee{
+		{UserID: "user-2"},
+		{UserID: "user-1", IsPrimary: true},
+	}}
+	if err := task.AfterFind(nil); err != nil {
+		t.Fatal(err)
+	}
+	if task.AssignedTo != "user-1" {
+		t.Fatalf("AssignedTo = %q, want the primary assignee user-1", task.AssignedTo)
+	}
+}
+
+func TestTaskJSONIncludesIsOverdue(t *testing.T) {
+	due := time.Now().Add(-time.Hour)
+	cases := []struct {
+		task Task
+		want string
+	}{
+		{Task{ID: "task-1", Status: StatusPending, DueDate: due}, `"is_overdue":true`},
+		{Task{ID: "task-1", Status: StatusCompleted, DueDate: due}, `"is_overdue":false`},
+		{Task{ID: "task-1", Status: StatusInProgress, DueDate: time.Now().Add(time.Hour)}, `"is_overdue":false`},
+	}
+	for _, c := range cases {
+		data, err := json.Marshal(c.task)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), c.want) || !strings.Contains(string(data), `"id":"task-1"`) {
+			t.Errorf("%s task encoded as %s, want %s", c.task.Status, data, c.want)
+		}
+	}
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	cases := []struct {
+		email     string
+		foldGmail bool
+		want      string
+	}{
+		{" Ana@Example.COM ", false, "ana@example.com"},
+		{"ana+work@example.com", true, "ana+work@example.com"},
+		{"A.Na+work@GMail.com", false, "a.na+work@gmail.com"},
+		{"A.Na+work@GMail.com", true, "ana@gmail.com"},
+		{"a.na@googlemail.com", true, "ana@gmail.com"},
+	}
+	for _, c := range cases {
+		if got := NormalizeEmail(c.email, c.foldGmail); got != c.want {
+			t.Errorf("NormalizeEmail(%q, %v) = %q, want %q", c.email, c.foldGmail, got, c.want)
+		}
+	}
+}
+
+func TestProjectCalendarCountsWorkingDays(t *testing.T) {
+	calendar := ProjectCalendar{
+		Timezone:    "Europe/Berlin",
+		WorkingDays: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+		Holidays:    []string{"2024-04-01"},
+	}
+	berlin := calendar.Location()
+	// Thursday 28 March 2024, 15:00 in Berlin
+	thursday := time.Date(2024, 3, 28, 15, 0, 0, 0, berlin)
+
+	if got, want := calendar.AddWorkingDays(thursday, 2), time.Date(2024, 4, 2, 15, 0, 0, 0, berlin); !got.Equal(want) {
+		t.Errorf("two working days after Thursday = %v, want Tuesday after the Easter Monday holiday %v", got, want)
+	}
+	saturday := time.Date(2024, 3, 30, 9, 0, 0, 0, berlin)
+	if calendar.IsWorkingDay(saturday) {
+		t.Error("Saturday is a working day")
+	}
+	if got, want := calendar.NextWorkingDay(saturday), time.Date(2024, 4, 2, 9, 0, 0, 0, berlin); !got.Equal(want) {
+		t.Errorf("next working day after Saturday = %v, want %v", got, want)
+	}
+	if got, want := calendar.AddWorkingHours(thursday, 40), time.Date(2024, 4, 2, 7, 0, 0, 0, berlin); !got.Equal(want) {
+		t.Errorf("40 working hours after Thursday 15:00 = %v, want Tuesday 07:00 after the weekend and holiday %v", got, want)
+	}
+	if got, want := calendar.AddWorkingHours(saturday, 2), time.Date(2024, 4, 2, 2, 0, 0, 0, berlin); !got.Equal(want) {
+		t.Errorf("two working hours after Saturday = %v, want %v", got, want)
+	}
+	// 23:30 UTC on Friday is already Saturday in Berlin
+	if calendar.IsWorkingDay(time.Date(2024, 3, 29, 23, 30, 0, 0, time.UTC)) {
+		t.Error("Friday 23:30 UTC, Saturday in Berlin, is a working day")
+	}
+}
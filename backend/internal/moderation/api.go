@@ -0,0 +1,89 @@
+package moderation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// APIModerator asks a moderation API with the request and response format
+// of OpenAI's /v1/moderations whether text is flagged
+type APIModerator struct {
+	url    string
+	apiKey string
+	action Action
+	client *http.Client
+}
+
+func NewAPIModerator(url, apiKey string, action Action) *APIModerator {
+	return &APIModerator{
+		url:    url,
+		apiKey: apiKey,
+		action: action,
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+type apiResponse struct {
+	Results []struct {
+		Flagged    bool            `json:"flagged"`
+		Categories map[string]bool `json:"categories"`
+	} `json:"results"`
+}
+
+func (m *APIModerator) Moderate(ctx context.Context, text string) (Verdict, error) {
+	if strings.TrimSpace(text) == "" {
+		return Verdict{Action: ActionAllow}, nil
+	}
+
+	body, err := json.Marshal(map[string]string{"input": text})
+	if err != nil {
+		return Verdict{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.url, bytes.NewReader(body))
+	if err != nil {
+		return Verdict{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if m.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+m.apiKey)
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return Verdict{}, fmt.Errorf("moderation API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Verdict{}, fmt.Errorf("moderation API returned status %d", resp.StatusCode)
+	}
+	var result apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return Verdict{}, fmt.Errorf("invalid moderation API response: %w", err)
+	}
+
+	var reasons []string
+	for _, r := range result.Results {
+		if !r.Flagged {
+			continue
+		}
+		for category, flagged := range r.Categories {
+			if flagged {
+				reasons = append(reasons, "api: "+category)
+			}
+		}
+		if len(reasons) == 0 {
+			reasons = append(reasons, "api: flagged")
+		}
+	}
+	if len(reasons) == 0 {
+		return Verdict{Action: ActionAllow}, nil
+	}
+	sort.Strings(reasons)
+	return Verdict{Action: m.action, Reasons: reasons}, nil
+}
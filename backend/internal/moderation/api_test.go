@@ -0,0 +1,44 @@
+package moderation
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPIModeratorReportsFlaggedCategories(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Input string `json:"input"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if r.Header.Get("Authorization") != "Bearer key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		flagged := req.Input == "bad"
+		json.NewEncoder(w).Encode(map[string]any{"results": []map[string]any{{
+			"flagged":    flagged,
+			"categories": map[string]bool{"violence": flagged, "hate": false, "harassment": flagged},
+		}}})
+	}))
+	defer server.Close()
+
+	m := NewAPIModerator(server.URL, "key", ActionQuarantine)
+	verdict, err := m.Moderate(context.Background(), "bad")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verdict.Action != ActionQuarantine || len(verdict.Reasons) != 2 || verdict.Reasons[0] != "api: harassment" {
+		t.Fatalf("verdict = %+v, want quarantine for harassment and violence", verdict)
+	}
+	if verdict, err := m.Moderate(context.Background(), "fine"); err != nil || !verdict.Allowed() {
+		t.Fatalf("verdict = %+v, err = %v, want allowed", verdict, err)
+	}
+
+	if _, err := NewAPIModerator(server.URL, "wrong", ActionQuarantine).Moderate(context.Background(), "bad"); err == nil {
+		t.Fatal("want an error when the API refuses the request")
+	}
+}
@@ -0,0 +1,87 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Action is what should happen to a submission after moderation
+type Action string
+
+const (
+	ActionAllow      Action = "allow"
+	ActionFlag       Action = "flag"
+	ActionQuarantine Action = "quarantine"
+)
+
+// Verdict is the outcome of moderating a piece of content
+type Verdict struct {
+	Action  Action   `json:"action"`
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+func (v Verdict) Allowed() bool {
+	return v.Action == ActionAllow || v.Action == ""
+}
+
+// Moderator inspects user-submitted text. Implementations are combined with
+// Chain so cheap checks run before expensive ones.
+type Moderator interface {
+	Moderate(ctx context.Context, text string) (Verdict, error)
+}
+
+// Classifier is implemented by AI backends that can judge whether content
+// is abusive
+type Classifier interface {
+	ClassifyContent(ctx context.Context, text string) (abusive bool, reason string, err error)
+}
+
+// Chain runs moderators in order and returns the first verdict that is not
+// an allow
+type Chain []Moderator
+
+func (c Chain) Moderate(ctx context.Context, text string) (Verdict, error) {
+	for _, m := range c {
+		verdict, err := m.Moderate(ctx, text)
+		if err != nil {
+			return Verdict{}, err
+		}
+		if !verdict.Allowed() {
+			return verdict, nil
+		}
+	}
+	return Verdict{Action: ActionAllow}, nil
+}
+
+// AIModerator adapts a Classifier into a Moderator
+type AIModerator struct {
+	classifier Classifier
+	action     Action
+}
+
+func NewAIModerator(classifier Classifier, action Action) *AIModerator {
+	return &AIModerator{
+		classifier: classifier,
+		action:     action,
+	}
+}
+
+func (m *AIModerator) Moderate(ctx context.Context, text string) (Verdict, error) {
+	if strings.TrimSpace(text) == "" {
+		return Verdict{Action: ActionAllow}, nil
+	}
+
+	abusive, reason, err := m.classifier.ClassifyContent(ctx, text)
+	if err != nil {
+		return Verdict{}, fmt.Errorf("AI classification failed: %w", err)
+	}
+	if !abusive {
+		return Verdict{Action: ActionAllow}, nil
+	}
+
+	if reason == "" {
+		reason = "classified as abusive"
+	}
+	return Verdict{Action: m.action, Reasons: []string{"ai: " + reason}}, nil
+}
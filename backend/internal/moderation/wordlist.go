@@ -0,0 +1,110 @@
+package moderation
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// defaultTerms holds blocked terms per language, already normalized
+var defaultTerms = map[string][]string{
+	"en": {"fuck", "fucking", "shit", "bitch", "asshole", "bastard", "cunt", "dickhead", "motherfucker", "retard"},
+	"es": {"mierda", "puta", "puto", "cabron", "pendejo", "gilipollas", "coño", "joder"},
+	"fr": {"merde", "putain", "connard", "connasse", "salope", "encule", "batard"},
+	"de": {"scheisse", "arschloch", "fotze", "wichser", "hurensohn", "missgeburt"},
+	"pt": {"caralho", "porra", "merda", "puta", "foda", "cacete"},
+	"it": {"cazzo", "stronzo", "vaffanculo", "merda", "puttana", "coglione"},
+}
+
+var leetReplacer = strings.NewReplacer(
+	"0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "@", "a", "$", "s",
+)
+
+var diacriticReplacer = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ä", "a", "ã", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "ö", "o", "õ", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ß", "ss",
+)
+
+// Wordlist flags content containing blocked terms in any supported language
+type Wordlist struct {
+	terms  map[string]string // normalized term -> languages, e.g. "es/pt"
+	action Action
+}
+
+// NewWordlist builds a wordlist from the default terms plus any extra terms
+func NewWordlist(action Action, extra []string) *Wordlist {
+	w := &Wordlist{
+		terms:  make(map[string]string),
+		action: action,
+	}
+	// Walk languages in a fixed order so a term shared by several languages
+	// always gets the same label
+	langs := make([]string, 0, len(defaultTerms))
+	for lang := range defaultTerms {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	for _, lang := range langs {
+		for _, term := range defaultTerms[lang] {
+			w.addTerm(normalize(term), lang)
+		}
+	}
+	for _, term := range extra {
+		if term = normalize(strings.TrimSpace(term)); term != "" {
+			w.addTerm(term, "custom")
+		}
+	}
+	return w
+}
+
+func (w *Wordlist) addTerm(term, lang string) {
+	existing, ok := w.terms[term]
+	if !ok {
+		w.terms[term] = lang
+		return
+	}
+	for _, l := range strings.Split(existing, "/") {
+		if l == lang {
+			return
+		}
+	}
+	w.terms[term] = existing + "/" + lang
+}
+
+func (w *Wordlist) Moderate(_ context.Context, text string) (Verdict, error) {
+	hits := make(map[string]bool)
+	for _, token := range tokenize(normalize(text)) {
+		if lang, ok := w.terms[token]; ok {
+			hits["wordlist: blocked term ("+lang+")"] = true
+		}
+	}
+	if len(hits) == 0 {
+		return Verdict{Action: ActionAllow}, nil
+	}
+
+	reasons := make([]string, 0, len(hits))
+	for reason := range hits {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	return Verdict{Action: w.action, Reasons: reasons}, nil
+}
+
+// normalize lowercases text, folds common diacritics and undoes simple
+// character substitutions so "Sh1t" and "scheiße" match their list entries
+func normalize(text string) string {
+	text = strings.ToLower(text)
+	text = diacriticReplacer.Replace(text)
+	return leetReplacer.Replace(text)
+}
+
+func tokenize(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+}
@@ -0,0 +1,79 @@
+package moderation
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWordlistNormalizesObfuscatedTerms(t *testing.T) {
+	w := NewWordlist(ActionQuarantine, nil)
+
+	for _, text := range []string{"this is Sh1t", "SCHEISSE!", "so ein Scheiße"} {
+		verdict, err := w.Moderate(context.Background(), text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if verdict.Action != ActionQuarantine {
+			t.Errorf("%q: action = %q, want quarantine", text, verdict.Action)
+		}
+	}
+}
+
+func TestWordlistLabelsSharedTermsDeterministically(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		verdict, err := NewWordlist(ActionFlag, nil).Moderate(context.Background(), "merda")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(verdict.Reasons) != 1 || verdict.Reasons[0] != "wordlist: blocked term (it/pt)" {
+			t.Fatalf("reasons = %v, want the it/pt label every time", verdict.Reasons)
+		}
+	}
+}
+
+func TestWordlistAllowsCleanText(t *testing.T) {
+	verdict, err := NewWordlist(ActionFlag, []string{"widget"}).Moderate(context.Background(), "Please fix the login page")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !verdict.Allowed() {
+		t.Fatalf("verdict = %+v, want allow", verdict)
+	}
+}
+
+type stubClassifier struct {
+	abusive bool
+	ctx     context.Context
+}
+
+func (c *stubClassifier) ClassifyContent(ctx context.Context, text string) (bool, string, error) {
+	c.ctx = ctx
+	return c.abusive, "", nil
+}
+
+type ctxKey struct{}
+
+func TestChainStopsAtFirstNonAllowAndPassesContext(t *testing.T) {
+	classifier := &stubClassifier{abusive: true}
+	chain := Chain{NewWordlist(ActionQuarantine, nil), NewAIModerator(classifier, ActionFlag)}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "request")
+
+	verdict, err := chain.Moderate(ctx, "nothing on the wordlist")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verdict.Action != ActionFlag || verdict.Reasons[0] != "ai: classified as abusive" {
+		t.Fatalf("verdict = %+v, want AI flag", verdict)
+	}
+	if classifier.ctx == nil || classifier.ctx.Value(ctxKey{}) != "request" {
+		t.Fatal("classifier did not receive the request context")
+	}
+
+	classifier.ctx = nil
+	if verdict, _ := chain.Moderate(ctx, "shit"); verdict.Action != ActionQuarantine {
+		t.Fatalf("verdict = %+v, want wordlist quarantine", verdict)
+	}
+	if classifier.ctx != nil {
+		t.Fatal("classifier ran after the wordlist already blocked the text")
+	}
+}
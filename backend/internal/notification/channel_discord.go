@@ -0,0 +1,63 @@
+package notification
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// discordSender posts embeds to Discord webhooks
+type discordSender struct {
+	webhook
+}
+
+func (discordSender) Render(event NotificationEvent, r Recipient) ([]byte, error) {
+	l := newLocalizer(r.Language)
+	embed := map[string]interface{}{
+		"title":       fmt.Sprintf("%s: %s", l.text(msgTaskUpdate), event.Task.Title),
+		"description": l.text(msgTaskUpdatedBody),
+		"fields": []map[string]interface{}{
+			{
+				"name":   l.text(msgUpdatedBy),
+				"value":  event.Task.CreatedBy,
+				"inline": true,
+			},
+			{
+				"name":   l.text(msgStatus),
+				"value":  l.status(string(event.Task.Status)),
+				"inline": true,
+			},
+			{
+				"name":   l.text(messageKey(r.Role)),
+				"value":  r.UserID,
+				"inline": false,
+			},
+		},
+		"timestamp": time.Now().Format(time.RFC3339),
+		"color":     discordColorForEvent(event),
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"content": l.text(msgNotification),
+		"embeds":  []interface{}{embed},
+	})
+}
+
+func discordColorForEvent(event NotificationEvent) int {
+	switch event.Type {
+	case NotificationTypeTaskCreated:
+		return 3066993 // Green
+	case NotificationTypeTaskUpdated:
+		return 5814783 // Blue
+	case NotificationTypeTaskDeleted:
+		return 15158332 // Red
+	case NotificationTypeTaskDue:
+		return 16776960 // Yellow
+	case NotificationTypeDeadlineMissed:
+		return 10038562 // Dark red
+	case NotificationTypePriorityEscalated:
+		return 15105570 // Orange
+	default:
+		return 10197915 // Gray
+	}
+}
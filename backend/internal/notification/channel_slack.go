@@ -0,0 +1,44 @@
+package notification
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// slackSender posts Block Kit messages to Slack incoming webhooks
+type slackSender struct {
+	webhook
+}
+
+func (slackSender) Render(event NotificationEvent, r Recipient) ([]byte, error) {
+	l := newLocalizer(r.Language)
+	blocks := []map[string]interface{}{
+		{
+			"type": "section",
+			"text": map[string]interface{}{
+				"type": "mrkdwn",
+				"text": fmt.Sprintf("*%s*\n*%s:* %s\n*%s:* %s\n*%s:* %s\n*%s:* %s",
+					l.text(msgTaskUpdate),
+					l.text(msgTask), event.Task.Title,
+					l.text(msgUpdatedBy), event.Task.CreatedBy,
+					l.text(msgStatus), l.status(string(event.Task.Status)),
+					l.text(messageKey(r.Role)), r.UserID),
+			},
+		},
+		{
+			"type": "context",
+			"elements": []map[string]interface{}{
+				{
+					"type": "mrkdwn",
+					"text": fmt.Sprintf("%s: %s", l.text(msgTimestamp), time.Now().Format(time.RFC3339)),
+				},
+			},
+		},
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"text":   l.text(msgTaskUpdatedText, event.Task.Title),
+		"blocks": blocks,
+	})
+}
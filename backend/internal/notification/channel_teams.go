@@ -0,0 +1,33 @@
+package notification
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// teamsSender posts message cards to Microsoft Teams incoming webhooks and
+// Workflows webhooks
+type teamsSender struct {
+	webhook
+}
+
+func (teamsSender) Render(event NotificationEvent, r Recipient) ([]byte, error) {
+	l := newLocalizer(r.Language)
+	return json.Marshal(map[string]interface{}{
+		"@type":      "MessageCard",
+		"@context":   "https://schema.org/extensions",
+		"summary":    l.text(msgTaskUpdate) + ": " + event.Task.Title,
+		"themeColor": strings.TrimPrefix(colorForEvent(event), "#"),
+		"title":      notificationTitle(event, l),
+		"sections": []map[string]interface{}{
+			{
+				"activityTitle": event.Task.Title,
+				"facts": []map[string]string{
+					{"name": l.text(msgUpdatedBy), "value": event.Task.CreatedBy},
+					{"name": l.text(msgStatus), "value": l.status(string(event.Task.Status))},
+					{"name": l.text(messageKey(r.Role)), "value": r.UserID},
+				},
+			},
+		},
+	})
+}
@@ -0,0 +1,149 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ChannelSender is the driver of one notification channel. The dispatch
+// core renders a message per recipient, sends it to the channel's target
+// (a webhook URL for the built-in channels) and keeps the rendered payload
+// for retries.
+type ChannelSender interface {
+	// Render builds the message about event for one recipient
+	Render(event NotificationEvent, r Recipient) ([]byte, error)
+	// Send delivers a rendered message to target
+	Send(ctx context.Context, payload []byte, target string) error
+	// Validate checks a target before it is configured for a project.
+	// Errors wrap ErrInvalidProjectWebhook.
+	Validate(target string) error
+	// Ping checks that target still accepts messages without sending one
+	Ping(ctx context.Context, target string) error
+}
+
+// ChannelRegistry maps channel names to their drivers
+type ChannelRegistry struct {
+	mu      sync.RWMutex
+	senders map[NotificationChannel]ChannelSender
+}
+
+// NewChannelRegistry returns a registry with the built-in Slack, Discord
+// and Teams drivers, which post with client
+func NewChannelRegistry(client *http.Client) *ChannelRegistry {
+	r := &ChannelRegistry{senders: make(map[NotificationChannel]ChannelSender)}
+	r.Register(ChannelSlack, slackSender{webhook{client: client, hosts: []string{"hooks.slack.com"}}})
+	r.Register(ChannelDiscord, discordSender{webhook{client: client, hosts: []string{"discord.com", "discordapp.com"}}})
+	r.Register(ChannelTeams, teamsSender{webhook{client: client, hosts: []string{".webhook.office.com", ".logic.azure.com"}}})
+	return r
+}
+
+// Register adds a channel, replacing any driver it had
+func (r *ChannelRegistry) Register(ch NotificationChannel, sender ChannelSender) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.senders[ch] = sender
+}
+
+// Sender returns the channel's driver
+func (r *ChannelRegistry) Sender(ch NotificationChannel) (ChannelSender, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	sender, ok := r.senders[ch]
+	return sender, ok
+}
+
+// Channels lists the registered channels by name
+func (r *ChannelRegistry) Channels() []NotificationChannel {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	channels := make([]NotificationChannel, 0, len(r.senders))
+	for ch := range r.senders {
+		channels = append(channels, ch)
+	}
+	sort.Slice(channels, func(i, j int) bool { return channels[i] < channels[j] })
+	return channels
+}
+
+// validate checks target with the channel's driver
+func (r *ChannelRegistry) validate(ch NotificationChannel, target string) error {
+	sender, ok := r.Sender(ch)
+	if !ok {
+		channels := r.Channels()
+		names := make([]string, len(channels))
+		for i, ch := range channels {
+			names[i] = string(ch)
+		}
+		return fmt.Errorf("%w: channel must be one of %s", ErrInvalidProjectWebhook, strings.Join(names, ", "))
+	}
+	return sender.Validate(target)
+}
+
+// webhook sends JSON messages to incoming webhooks on a fixed set of hosts.
+// A host starting with a dot matches its subdomains.
+type webhook struct {
+	client *http.Client
+	hosts  []string
+}
+
+func (w webhook) Send(ctx context.Context, payload []byte, target string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("webhook request failed with status: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Validate only accepts https URLs on the channel's hosts, so
+// notifications cannot be pointed at arbitrary servers
+func (w webhook) Validate(target string) error {
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme != "https" {
+		return fmt.Errorf("%w: url must be an https URL", ErrInvalidProjectWebhook)
+	}
+	for _, host := range w.hosts {
+		if u.Hostname() == host || (strings.HasPrefix(host, ".") && strings.HasSuffix(u.Hostname(), host)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: webhooks must be on %s", ErrInvalidProjectWebhook, strings.Join(w.hosts, " or "))
+}
+
+// Ping sends a GET, which webhooks answer without posting a message.
+// Revoked webhooks answer 401, 403, 404 or 410.
+func (w webhook) Ping(ctx context.Context, target string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized, resp.StatusCode == http.StatusForbidden,
+		resp.StatusCode == http.StatusNotFound, resp.StatusCode == http.StatusGone:
+		return fmt.Errorf("webhook was revoked or is invalid (status %d)", resp.StatusCode)
+	case resp.StatusCode >= 500:
+		return fmt.Errorf("webhook check failed with status: %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package notification
+
+import (
+	"context"
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/task"
+)
+
+const (
+	// NotificationTypeDeadlineMissed escalates a task past its hard deadline
+	NotificationTypeDeadlineMissed NotificationType = "deadline_missed"
+	// NotificationTypePriorityEscalated tells of a pending task whose
+	// priority was raised as its due date neared
+	NotificationTypePriorityEscalated NotificationType = "priority_escalated"
+)
+
+// EscalateMissedDeadline sends an urgent deadline_missed notification about
+// a task whose hard deadline passed to the default channels. The event ID
+// names the deadline, so with a deduplicator each missed deadline is
+// escalated once.
+func (s *Service) EscalateMissedDeadline(ctx context.Context, t task.Task) {
+	event := NotificationEvent{
+		EventID:  "deadline_missed:" + t.ID + ":" + t.DueDate.UTC().Format(time.RFC3339),
+		Type:     NotificationTypeDeadlineMissed,
+		Task:     t,
+		Priority: PriorityUrgent,
+	}
+	if s.IsDuplicate(ctx, event) {
+		return
+	}
+	s.SendNotification(ctx, event)
+}
+
+// EscalatePriority sends a priority_escalated notification about a pending
+// task whose priority the escalation job raised. The event ID names the
+// due date and new priority, so each escalation is sent once.
+func (s *Service) EscalatePriority(ctx context.Context, t task.Task) {
+	event := NotificationEvent{
+		EventID:  "priority_escalated:" + t.ID + ":" + string(t.Priority) + ":" + t.DueDate.UTC().Format(time.RFC3339),
+		Type:     NotificationTypePriorityEscalated,
+		Task:     t,
+		Priority: PriorityNormal,
+	}
+	if s.IsDuplicate(ctx, event) {
+		return
+	}
+	s.SendNotification(ctx, event)
+}
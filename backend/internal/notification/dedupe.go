@@ -0,0 +1,89 @@
+package notification
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Deduplicator remembers event IDs for a while so retried events are not
+// sent twice. Seen records id and reports whether it was already recorded.
+type Deduplicator interface {
+	Seen(ctx context.Context, id string) (bool, error)
+}
+
+// RedisDeduplicator shares seen event IDs across replicas. Each ID is a key
+// set with NX and an expiry, so recording and checking are one round trip.
+type RedisDeduplicator struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+func NewRedisDeduplicator(client *redis.Client, ttl time.Duration) *RedisDeduplicator {
+	return &RedisDeduplicator{client: client, ttl: ttl}
+}
+
+func (d *RedisDeduplicator) Seen(ctx context.Context, id string) (bool, error) {
+	created, err := d.client.SetNX(ctx, "notification:event:"+id, 1, d.ttl).Result()
+	if err != nil {
+		return false, err
+	}
+	return !created, nil
+}
+
+// MemoryDeduplicator keeps seen event IDs in process, for single-replica
+// deployments
+type MemoryDeduplicator struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu        sync.Mutex
+	seen      map[string]time.Time
+	lastSweep time.Time
+}
+
+func NewMemoryDeduplicator(ttl time.Duration) *MemoryDeduplicator {
+	return &MemoryDeduplicator{
+		ttl:  ttl,
+		now:  time.Now,
+		seen: make(map[string]time.Time),
+	}
+}
+
+func (d *MemoryDeduplicator) Seen(ctx context.Context, id string) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	if now.Sub(d.lastSweep) >= d.ttl {
+		d.sweep(now)
+	}
+
+	if expires, ok := d.seen[id]; ok && now.Before(expires) {
+		return true, nil
+	}
+	d.seen[id] = now.Add(d.ttl)
+	return false, nil
+}
+
+// Sweep drops the expired event IDs, which Seen otherwise only does once
+// per TTL while events keep arriving
+func (d *MemoryDeduplicator) Sweep(context.Context) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.sweep(d.now()), nil
+}
+
+func (d *MemoryDeduplicator) sweep(now time.Time) int {
+	swept := 0
+	for key, expires := range d.seen {
+		if !now.Before(expires) {
+			delete(d.seen, key)
+			swept++
+		}
+	}
+	d.lastSweep = now
+	return swept
+}
@@ -0,0 +1,123 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+func TestMemoryDeduplicatorExpiresIDs(t *testing.T) {
+	now := time.Now()
+	d := NewMemoryDeduplicator(time.Minute)
+	d.now = func() time.Time { return now }
+
+	if seen, _ := d.Seen(context.Background(), "evt-1"); seen {
+		t.Fatal("first sighting reported as seen")
+	}
+	if seen, _ := d.Seen(context.Background(), "evt-1"); !seen {
+		t.Fatal("retry within the TTL not reported as seen")
+	}
+
+	now = now.Add(time.Minute)
+	if seen, _ := d.Seen(context.Background(), "evt-1"); seen {
+		t.Fatal("ID still seen after the TTL")
+	}
+	if len(d.seen) != 1 {
+		t.Fatalf("%d IDs kept, want expired ones swept", len(d.seen))
+	}
+}
+
+func TestMemoryDeduplicatorSweep(t *testing.T) {
+	now := time.Now()
+	d := NewMemoryDeduplicator(time.Minute)
+	d.now = func() time.Time { return now }
+	d.Seen(context.Background(), "evt-1")
+	now = now.Add(30 * time.Second)
+	d.Seen(context.Background(), "evt-2")
+
+	now = now.Add(30 * time.Second)
+	swept, err := d.Sweep(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if swept != 1 || len(d.seen) != 1 {
+		t.Fatalf("swept %d, kept %d, want only the expired ID swept", swept, len(d.seen))
+	}
+}
+
+func TestRedisDeduplicatorSharesIDs(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	// Two replicas sharing one Redis
+	a := NewRedisDeduplicator(client, time.Minute)
+	b := NewRedisDeduplicator(client, time.Minute)
+
+	if seen, err := a.Seen(context.Background(), "evt-1"); err != nil || seen {
+		t.Fatalf("first sighting: seen=%v err=%v", seen, err)
+	}
+	if seen, err := b.Seen(context.Background(), "evt-1"); err != nil || !seen {
+		t.Fatalf("retry on other replica: seen=%v err=%v, want seen", seen, err)
+	}
+
+	mr.FastForward(time.Minute)
+	if seen, _ := a.Seen(context.Background(), "evt-1"); seen {
+		t.Fatal("ID still seen after the TTL")
+	}
+}
+
+type failingDeduplicator struct{}
+
+func (failingDeduplicator) Seen(ctx context.Context, id string) (bool, error) {
+	return false, errors.New("redis down")
+}
+
+func postEvent(t *testing.T, s *Service, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/notifications/events", NewHandler(s, zap.NewNop()).HandleTaskEvent)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/notifications/events", strings.NewReader(body)))
+	return w
+}
+
+func TestHandleTaskEventSuppressesDuplicates(t *testing.T) {
+	s, _ := NewService(NotificationConfig{}, zap.NewNop())
+	s.SetDeduplicator(NewMemoryDeduplicator(time.Minute))
+	body := `{"event_id":"evt-1","type":"task_created","task":{"id":"task-1"}}`
+
+	if w := postEvent(t, s, body); w.Code != http.StatusAccepted || !strings.Contains(w.Body.String(), `"duplicate":false`) {
+		t.Fatalf("first: %d %s, want 202 not duplicate", w.Code, w.Body.String())
+	}
+	if w := postEvent(t, s, body); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"duplicate":true`) {
+		t.Fatalf("retry: %d %s, want 200 duplicate", w.Code, w.Body.String())
+	}
+
+	// Without an event_id nothing is suppressed
+	noID := `{"type":"task_created","task":{"id":"task-1"}}`
+	for i := 0; i < 2; i++ {
+		if w := postEvent(t, s, noID); w.Code != http.StatusAccepted {
+			t.Fatalf("event without ID: %d, want 202", w.Code)
+		}
+	}
+	s.Close()
+}
+
+func TestIsDuplicateFailsOpen(t *testing.T) {
+	s, _ := NewService(NotificationConfig{}, zap.NewNop())
+	s.SetDeduplicator(failingDeduplicator{})
+	if s.IsDuplicate(context.Background(), NotificationEvent{EventID: "evt-1"}) {
+		t.Fatal("event suppressed while the deduplicator is down")
+	}
+}
@@ -1,9 +1,14 @@
 package notification
 
 import (
+	"context"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -23,20 +28,183 @@ func (h *Handler) HandleTaskEvent(c *gin.Context) {
 	var event NotificationEvent
 	if err := c.ShouldBindJSON(&event); err != nil {
 		h.logger.Error("Invalid notification event", zap.Error(err))
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(common.NewValidationError(err))
 		return
 	}
 
 	// Validate event data
 	if event.Task.ID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid task data"})
+		_ = c.Error(common.NewInvalidInputError("invalid task data"))
 		return
 	}
 
-	// Send notification asynchronously
+	if h.service.IsDuplicate(c.Request.Context(), event) {
+		c.JSON(http.StatusOK, gin.H{"message": "duplicate event ignored", "duplicate": true})
+		return
+	}
+
+	// Send notification asynchronously, keeping the trace but not the
+	// request's cancellation
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.SpanContextFromContext(c.Request.Context()))
 	go func() {
-		h.service.SendNotification(event)
+		h.service.SendNotification(ctx, event)
 	}()
 
-	c.JSON(http.StatusAccepted, gin.H{"message": "notification queued"})
+	c.JSON(http.StatusAccepted, gin.H{"message": "notification queued", "duplicate": false})
+}
+
+func (h *Handler) ListProjectWebhooks(c *gin.Context) {
+	webhooks, err := h.service.routes.List(c.Request.Context(), c.Param("project"))
+	if err != nil {
+		h.logger.Error("Failed to list project webhooks", zap.Error(err))
+		_ = c.Error(common.NewInternalServerError("failed to list project webhooks"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"project": c.Param("project"), "webhooks": webhooks})
+}
+
+func (h *Handler) SetProjectWebhook(c *gin.Context) {
+	var req SetProjectWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(common.NewValidationError(err))
+		return
+	}
+
+	webhook, err := h.service.routes.Set(c.Request.Context(), c.Param("project"),
+		NotificationChannel(c.Param("channel")), req, c.GetString("user_id"))
+	if err != nil {
+		if errors.Is(err, ErrInvalidProjectWebhook) {
+			_ = c.Error(common.NewInvalidInputError(err.Error()))
+			return
+		}
+		h.logger.Error("Failed to save project webhook", zap.Error(err))
+		_ = c.Error(common.NewInternalServerError("failed to save project webhook"))
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+func (h *Handler) DeleteProjectWebhook(c *gin.Context) {
+	err := h.service.routes.Delete(c.Request.Context(), c.Param("project"), NotificationChannel(c.Param("channel")))
+	if err != nil {
+		if errors.Is(err, ErrProjectWebhookNotFound) {
+			_ = c.Error(common.NewNotFoundError(err.Error()))
+			return
+		}
+		h.logger.Error("Failed to delete project webhook", zap.Error(err))
+		_ = c.Error(common.NewInternalServerError("failed to delete project webhook"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "project webhook deleted"})
+}
+
+// ListNotificationRules lists the project's routing rules
+func (h *Handler) ListNotificationRules(c *gin.Context) {
+	rules, err := h.service.routes.ListRules(c.Request.Context(), c.Param("project"))
+	if err != nil {
+		h.logger.Error("Failed to list notification rules", zap.Error(err))
+		_ = c.Error(common.NewInternalServerError("failed to list notification rules"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"project": c.Param("project"), "rules": rules})
+}
+
+// CreateNotificationRule adds a routing rule to the project
+func (h *Handler) CreateNotificationRule(c *gin.Context) {
+	var req NotificationRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(common.NewValidationError(err))
+		return
+	}
+
+	rule, err := h.service.routes.CreateRule(c.Request.Context(), c.Param("project"), req, c.GetString("user_id"))
+	if err != nil {
+		h.ruleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// UpdateNotificationRule replaces one of the project's routing rules
+func (h *Handler) UpdateNotificationRule(c *gin.Context) {
+	var req NotificationRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(common.NewValidationError(err))
+		return
+	}
+
+	rule, err := h.service.routes.UpdateRule(c.Request.Context(), c.Param("project"), c.Param("id"), req, c.GetString("user_id"))
+	if err != nil {
+		h.ruleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteNotificationRule removes one of the project's routing rules
+func (h *Handler) DeleteNotificationRule(c *gin.Context) {
+	if err := h.service.routes.DeleteRule(c.Request.Context(), c.Param("project"), c.Param("id")); err != nil {
+		h.ruleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "notification rule deleted"})
+}
+
+func (h *Handler) ruleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidNotificationRule), errors.Is(err, ErrInvalidProjectWebhook):
+		_ = c.Error(common.NewInvalidInputError(err.Error()))
+	case errors.Is(err, ErrNotificationRuleNotFound):
+		_ = c.Error(common.NewNotFoundError(err.Error()))
+	default:
+		h.logger.Error("Failed to change notification rules", zap.Error(err))
+		_ = c.Error(common.NewInternalServerError("failed to change notification rules"))
+	}
+}
+
+func (h *Handler) ListFailedNotifications(c *gin.Context) {
+	status := c.Query("status")
+	if status != "" && status != string(models.NotificationRetrying) && status != string(models.NotificationDead) {
+		_ = c.Error(common.NewInvalidInputError("status must be retrying or dead"))
+		return
+	}
+
+	// Without a retry queue nothing is kept
+	if h.service.retries == nil {
+		c.JSON(http.StatusOK, gin.H{"deliveries": []Delivery{}})
+		return
+	}
+	deliveries, err := h.service.retries.List(c.Request.Context(), status)
+	if err != nil {
+		h.logger.Error("Failed to list failed notifications", zap.Error(err))
+		_ = c.Error(common.NewInternalServerError("failed to list failed notifications"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries})
+}
+
+func (h *Handler) RequeueNotification(c *gin.Context) {
+	if h.service.retries == nil {
+		_ = c.Error(common.NewNotFoundError(ErrDeliveryNotFound.Error()))
+		return
+	}
+	if err := h.service.retries.Requeue(c.Request.Context(), c.Param("id")); err != nil {
+		if errors.Is(err, ErrDeliveryNotFound) {
+			_ = c.Error(common.NewNotFoundError(err.Error()))
+			return
+		}
+		h.logger.Error("Failed to requeue notification", zap.Error(err))
+		_ = c.Error(common.NewInternalServerError("failed to requeue notification"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "notification requeued"})
 }
@@ -0,0 +1,253 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// defaultLanguage is used when neither the recipient nor the organization
+// chose a language the catalog has
+const defaultLanguage = "en"
+
+type messageKey string
+
+const (
+	msgTaskUpdate      messageKey = "task_update"
+	msgTaskUpdatedText messageKey = "task_updated_text"
+	msgTaskUpdatedBody messageKey = "task_updated_body"
+	msgNotification    messageKey = "notification"
+	msgTask            messageKey = "task"
+	msgUpdatedBy       messageKey = "updated_by"
+	msgStatus          messageKey = "status"
+	msgTimestamp       messageKey = "timestamp"
+	// Recipient roles are their own keys
+	msgAssignee          messageKey = "Assignee"
+	msgWatcher           messageKey = "Watcher"
+	msgTitleCreated      messageKey = "title_task_created"
+	msgTitleUpdated      messageKey = "title_task_updated"
+	msgTitleDeleted      messageKey = "title_task_deleted"
+	msgTitleDue          messageKey = "title_task_due"
+	msgTitleMissed       messageKey = "title_deadline_missed"
+	msgTitleEscalated    messageKey = "title_priority_escalated"
+	msgTitleNotification messageKey = "title_notification"
+	msgStatusPending     messageKey = "pending"
+	msgStatusInProgress  messageKey = "in_progress"
+	msgStatusCompleted   messageKey = "completed"
+)
+
+// catalog holds the text of notifications in each language. Messages
+// missing from a language are taken from the default language.
+var catalog = map[string]map[messageKey]string{
+	"en": {
+		msgTaskUpdate:        "Task Update",
+		msgTaskUpdatedText:   "Task Update: Task '%s' has been updated.",
+		msgTaskUpdatedBody:   "The task has been updated.",
+		msgNotification:      "Task Update Notification",
+		msgTask:              "Task",
+		msgUpdatedBy:         "Updated by",
+		msgStatus:            "Status",
+		msgTimestamp:         "Timestamp",
+		msgAssignee:          "Assignee",
+		msgWatcher:           "Watcher",
+		msgTitleCreated:      "🆕 New Task Created",
+		msgTitleUpdated:      "📝 Task Updated",
+		msgTitleDeleted:      "🗑️ Task Deleted",
+		msgTitleDue:          "⏰ Task Due Soon",
+		msgTitleMissed:       "🚨 Hard Deadline Missed",
+		msgTitleEscalated:    "⏫ Task Priority Raised",
+		msgTitleNotification: "Task Notification",
+		msgStatusPending:     "pending",
+		msgStatusInProgress:  "in progress",
+		msgStatusCompleted:   "completed",
+	},
+	"es": {
+		msgTaskUpdate:        "Actualización de tarea",
+		msgTaskUpdatedText:   "Actualización de tarea: la tarea '%s' se ha actualizado.",
+		msgTaskUpdatedBody:   "La tarea se ha actualizado.",
+		msgNotification:      "Notificación de tarea",
+		msgTask:              "Tarea",
+		msgUpdatedBy:         "Actualizada por",
+		msgStatus:            "Estado",
+		msgTimestamp:         "Fecha",
+		msgAssignee:          "Asignada a",
+		msgWatcher:           "Seguidor",
+		msgTitleCreated:      "🆕 Nueva tarea creada",
+		msgTitleUpdated:      "📝 Tarea actualizada",
+		msgTitleDeleted:      "🗑️ Tarea eliminada",
+		msgTitleDue:          "⏰ Tarea próxima a vencer",
+		msgTitleMissed:       "🚨 Fecha límite estricta incumplida",
+		msgTitleEscalated:    "⏫ Prioridad de tarea elevada",
+		msgTitleNotification: "Notificación de tarea",
+		msgStatusPending:     "pendiente",
+		msgStatusInProgress:  "en curso",
+		msgStatusCompleted:   "completada",
+	},
+	"fr": {
+		msgTaskUpdate:        "Mise à jour de tâche",
+		msgTaskUpdatedText:   "Mise à jour de tâche : la tâche « %s » a été mise à jour.",
+		msgTaskUpdatedBody:   "La tâche a été mise à jour.",
+		msgNotification:      "Notification de tâche",
+		msgTask:              "Tâche",
+		msgUpdatedBy:         "Mise à jour par",
+		msgStatus:            "Statut",
+		msgTimestamp:         "Horodatage",
+		msgAssignee:          "Responsable",
+		msgWatcher:           "Observateur",
+		msgTitleCreated:      "🆕 Nouvelle tâche créée",
+		msgTitleUpdated:      "📝 Tâche mise à jour",
+		msgTitleDeleted:      "🗑️ Tâche supprimée",
+		msgTitleDue:          "⏰ Échéance proche",
+		msgTitleMissed:       "🚨 Échéance stricte dépassée",
+		msgTitleEscalated:    "⏫ Priorité de la tâche relevée",
+		msgTitleNotification: "Notification de tâche",
+		msgStatusPending:     "en attente",
+		msgStatusInProgress:  "en cours",
+		msgStatusCompleted:   "terminée",
+	},
+	"de": {
+		msgTaskUpdate:        "Aufgabenänderung",
+		msgTaskUpdatedText:   "Aufgabenänderung: Die Aufgabe „%s“ wurde geändert.",
+		msgTaskUpdatedBody:   "Die Aufgabe wurde geändert.",
+		msgNotification:      "Aufgabenbenachrichtigung",
+		msgTask:              "Aufgabe",
+		msgUpdatedBy:         "Geändert von",
+		msgStatus:            "Status",
+		msgTimestamp:         "Zeitpunkt",
+		msgAssignee:          "Zuständig",
+		msgWatcher:           "Beobachter",
+		msgTitleCreated:      "🆕 Neue Aufgabe erstellt",
+		msgTitleUpdated:      "📝 Aufgabe geändert",
+		msgTitleDeleted:      "🗑️ Aufgabe gelöscht",
+		msgTitleDue:          "⏰ Aufgabe bald fällig",
+		msgTitleMissed:       "🚨 Feste Frist verpasst",
+		msgTitleEscalated:    "⏫ Aufgabenpriorität erhöht",
+		msgTitleNotification: "Aufgabenbenachrichtigung",
+		msgStatusPending:     "offen",
+		msgStatusInProgress:  "in Arbeit",
+		msgStatusCompleted:   "erledigt",
+	},
+	"pt": {
+		msgTaskUpdate:        "Atualização de tarefa",
+		msgTaskUpdatedText:   "Atualização de tarefa: a tarefa '%s' foi atualizada.",
+		msgTaskUpdatedBody:   "A tarefa foi atualizada.",
+		msgNotification:      "Notificação de tarefa",
+		msgTask:              "Tarefa",
+		msgUpdatedBy:         "Atualizada por",
+		msgStatus:            "Status",
+		msgTimestamp:         "Data",
+		msgAssignee:          "Responsável",
+		msgWatcher:           "Observador",
+		msgTitleCreated:      "🆕 Nova tarefa criada",
+		msgTitleUpdated:      "📝 Tarefa atualizada",
+		msgTitleDeleted:      "🗑️ Tarefa excluída",
+		msgTitleDue:          "⏰ Tarefa perto do prazo",
+		msgTitleMissed:       "🚨 Prazo rígido perdido",
+		msgTitleEscalated:    "⏫ Prioridade da tarefa elevada",
+		msgTitleNotification: "Notificação de tarefa",
+		msgStatusPending:     "pendente",
+		msgStatusInProgress:  "em andamento",
+		msgStatusCompleted:   "concluída",
+	},
+	"it": {
+		msgTaskUpdate:        "Aggiornamento attività",
+		msgTaskUpdatedText:   "Aggiornamento attività: l'attività '%s' è stata aggiornata.",
+		msgTaskUpdatedBody:   "L'attività è stata aggiornata.",
+		msgNotification:      "Notifica attività",
+		msgTask:              "Attività",
+		msgUpdatedBy:         "Aggiornata da",
+		msgStatus:            "Stato",
+		msgTimestamp:         "Data",
+		msgAssignee:          "Assegnatario",
+		msgWatcher:           "Osservatore",
+		msgTitleCreated:      "🆕 Nuova attività creata",
+		msgTitleUpdated:      "📝 Attività aggiornata",
+		msgTitleDeleted:      "🗑️ Attività eliminata",
+		msgTitleDue:          "⏰ Attività in scadenza",
+		msgTitleMissed:       "🚨 Scadenza rigida mancata",
+		msgTitleEscalated:    "⏫ Priorità dell'attività aumentata",
+		msgTitleNotification: "Notifica attività",
+		msgStatusPending:     "in attesa",
+		msgStatusInProgress:  "in corso",
+		msgStatusCompleted:   "completata",
+	},
+}
+
+// LanguageLookup is implemented by the preferences service so each
+// recipient is messaged in their language, or else the organization's
+type LanguageLookup interface {
+	RecipientLanguages(ctx context.Context, userIDs []string) (map[string]string, error)
+}
+
+// SetLanguageLookup renders messages in each recipient's language instead
+// of English
+func (s *Service) SetLanguageLookup(languages LanguageLookup) {
+	s.languages = languages
+}
+
+// resolveLanguages sets the language of each recipient. If languages
+// cannot be looked up, recipients get the default language.
+func (s *Service) resolveLanguages(ctx context.Context, recipients []Recipient) {
+	if s.languages == nil {
+		return
+	}
+	ids := make([]string, len(recipients))
+	for i, r := range recipients {
+		ids[i] = r.UserID
+	}
+	languages, err := s.languages.RecipientLanguages(ctx, ids)
+	if err != nil {
+		s.logger.Warn("Failed to look up recipient languages, sending in English", zap.Error(err))
+		return
+	}
+	for i := range recipients {
+		recipients[i].Language = languages[recipients[i].UserID]
+	}
+}
+
+// localizer returns the text of messages in lang, such as "pt-BR", the
+// catalog's closest language
+type localizer map[messageKey]string
+
+func newLocalizer(lang string) localizer {
+	lang = strings.ToLower(lang)
+	if messages, ok := catalog[lang]; ok {
+		return messages
+	}
+	if base, _, found := strings.Cut(lang, "-"); found {
+		if messages, ok := catalog[base]; ok {
+			return messages
+		}
+	}
+	return catalog[defaultLanguage]
+}
+
+// text returns the message of key, formatted with args
+func (l localizer) text(key messageKey, args ...any) string {
+	message, ok := l[key]
+	if !ok {
+		message = catalog[defaultLanguage][key]
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
+
+// statusMessages are the messages of task statuses
+var statusMessages = map[string]messageKey{
+	"pending":     msgStatusPending,
+	"in_progress": msgStatusInProgress,
+	"completed":   msgStatusCompleted,
+}
+
+// status returns a task status in the localizer's language; statuses the
+// catalog does not know are returned as they are
+func (l localizer) status(status string) string {
+	if key, ok := statusMessages[status]; ok {
+		return l.text(key)
+	}
+	return status
+}
@@ -18,21 +18,36 @@ type NotificationChannel string
 const (
 	ChannelSlack   NotificationChannel = "slack"
 	ChannelDiscord NotificationChannel = "discord"
+	ChannelTeams   NotificationChannel = "teams"
 )
 
 type NotificationConfig struct {
-	SlackWebhookURL     string
-	DiscordWebhookURL   string
+	SlackWebhookURL   string
+	DiscordWebhookURL string
+	// Targets are the global targets of further channels, such as Teams
+	// or channels registered by other drivers
+	Targets             map[NotificationChannel]string
 	DefaultChannels     []NotificationChannel
 	TaskUpdateThreshold int    // Minimum priority level for task update notifications
 	DefaultUsername     string // Added for identifying the updater
+
+	// Workers is how many messages are sent at once; PriorityBudgets caps
+	// how many of them a priority may use. Zero values use the defaults.
+	Workers         int
+	PriorityBudgets map[Priority]int
 }
 
 type NotificationEvent struct {
+	// EventID identifies the event for duplicate suppression; producers
+	// reuse it when retrying. Events without one are always sent.
+	EventID  string                 `json:"event_id" binding:"max=200"`
 	Type     NotificationType       `json:"type"`
 	Task     task.Task              `json:"task"`
 	Channels []NotificationChannel  `json:"channels,omitempty"`
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
+
+	// Priority overrides the priority derived from the event type and task
+	Priority Priority `json:"priority,omitempty" binding:"omitempty,oneof=urgent normal low"`
 }
 
 type SlackBlock struct {
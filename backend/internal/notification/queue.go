@@ -0,0 +1,121 @@
+package notification
+
+import (
+	"sync"
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+)
+
+// Priority decides how soon a notification's messages are sent when the
+// workers are busy
+type Priority string
+
+const (
+	// PriorityUrgent is for alerts that need attention now, such as
+	// overdue high-priority tasks and security alerts
+	PriorityUrgent Priority = "urgent"
+	PriorityNormal Priority = "normal"
+	// PriorityLow is for messages that can wait, such as digests
+	PriorityLow Priority = "low"
+)
+
+// priorities is the order in which queued messages are dispatched
+var priorities = []Priority{PriorityUrgent, PriorityNormal, PriorityLow}
+
+const defaultWorkers = 8
+
+// defaultBudgets keep two workers free of normal and low priority messages
+// by default, so urgent ones never wait behind them
+var defaultBudgets = map[Priority]int{
+	PriorityUrgent: defaultWorkers,
+	PriorityNormal: 4,
+	PriorityLow:    2,
+}
+
+// eventPriority is the event's own priority if it has one. Otherwise a
+// due reminder for a high-priority task that is already overdue is urgent
+// and everything else is normal.
+func eventPriority(event NotificationEvent) Priority {
+	if event.Priority != "" {
+		return event.Priority
+	}
+	if event.Type == NotificationTypeTaskDue && event.Task.Priority == models.PriorityHigh &&
+		!event.Task.DueDate.IsZero() && event.Task.DueDate.Before(time.Now()) {
+		return PriorityUrgent
+	}
+	return PriorityNormal
+}
+
+// dispatchQueue runs jobs on at most workers goroutines, and at most a
+// priority's budget of them for that priority. Queued jobs start in
+// priority order, oldest first within a priority.
+type dispatchQueue struct {
+	workers int
+	budgets map[Priority]int
+
+	mu      sync.Mutex
+	running map[Priority]int
+	busy    int
+	queued  map[Priority][]func()
+}
+
+// newDispatchQueue falls back to the defaults for a worker count or budget
+// that is not positive. Budgets are capped at the worker count.
+func newDispatchQueue(workers int, budgets map[Priority]int) *dispatchQueue {
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+	q := &dispatchQueue{
+		workers: workers,
+		budgets: make(map[Priority]int, len(priorities)),
+		running: make(map[Priority]int, len(priorities)),
+		queued:  make(map[Priority][]func(), len(priorities)),
+	}
+	for _, p := range priorities {
+		budget := budgets[p]
+		if budget <= 0 {
+			budget = defaultBudgets[p]
+		}
+		q.budgets[p] = min(budget, workers)
+	}
+	return q
+}
+
+// submit queues job and starts whatever the budgets allow
+func (q *dispatchQueue) submit(p Priority, job func()) {
+	if _, ok := q.budgets[p]; !ok {
+		p = PriorityNormal
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.queued[p] = append(q.queued[p], job)
+	q.startQueued()
+}
+
+// startQueued starts queued jobs, most urgent first, until the workers or
+// the budgets of the priorities with queued jobs are used up. Callers hold
+// q.mu.
+func (q *dispatchQueue) startQueued() {
+	for _, p := range priorities {
+		for len(q.queued[p]) > 0 && q.busy < q.workers && q.running[p] < q.budgets[p] {
+			job := q.queued[p][0]
+			q.queued[p][0] = nil
+			q.queued[p] = q.queued[p][1:]
+			q.running[p]++
+			q.busy++
+			go q.run(p, job)
+		}
+	}
+}
+
+func (q *dispatchQueue) run(p Priority, job func()) {
+	defer func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		q.running[p]--
+		q.busy--
+		q.startQueued()
+	}()
+	job()
+}
@@ -0,0 +1,117 @@
+package notification
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+	"github.com/iSparshP/real-time-task-management-system/internal/task"
+)
+
+func TestDispatchQueueStartsUrgentFirstWithinBudgets(t *testing.T) {
+	q := newDispatchQueue(2, map[Priority]int{PriorityUrgent: 2, PriorityNormal: 1, PriorityLow: 1})
+
+	var mu sync.Mutex
+	var order []string
+	done := make(chan struct{}, 8)
+	job := func(name string, release chan struct{}) func() {
+		return func() {
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+			if release != nil {
+				<-release
+			}
+			done <- struct{}{}
+		}
+	}
+
+	releaseLow, releaseNormal := make(chan struct{}), make(chan struct{})
+	q.submit(PriorityLow, job("low-1", releaseLow))
+	q.submit(PriorityNormal, job("normal-1", releaseNormal))
+	q.submit(PriorityLow, job("low-2", nil))
+	q.submit(PriorityNormal, job("normal-2", nil))
+	q.submit(PriorityUrgent, job("urgent", nil))
+
+	// Both workers are busy, so everything else waits
+	mu.Lock()
+	if len(order) > 2 {
+		t.Fatalf("started %v with two workers", order)
+	}
+	mu.Unlock()
+
+	wait := func(n int) {
+		for range n {
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatal("jobs did not finish")
+			}
+		}
+	}
+	// The freed worker goes to the urgent job, which finishes while
+	// normal-1 still holds the other
+	close(releaseLow)
+	wait(2)
+	close(releaseNormal)
+	wait(3)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if order[2] != "urgent" {
+		t.Fatalf("order = %v, want the urgent job started before the waiting ones", order)
+	}
+}
+
+func TestDispatchQueueKeepsWorkersForUrgent(t *testing.T) {
+	q := newDispatchQueue(3, map[Priority]int{PriorityNormal: 1, PriorityLow: 1})
+
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan Priority, 4)
+	for _, p := range []Priority{PriorityLow, PriorityLow, PriorityNormal, PriorityNormal} {
+		q.submit(p, func() {
+			started <- p
+			<-release
+		})
+	}
+	for range 2 {
+		<-started
+	}
+
+	urgent := make(chan struct{})
+	q.submit(PriorityUrgent, func() { close(urgent) })
+	select {
+	case <-urgent:
+	case <-time.After(time.Second):
+		t.Fatal("urgent job waited behind low and normal ones")
+	}
+	select {
+	case p := <-started:
+		t.Fatalf("a %s job started beyond its budget", p)
+	default:
+	}
+}
+
+func TestEventPriority(t *testing.T) {
+	overdue := task.Task{Priority: models.PriorityHigh, DueDate: time.Now().Add(-time.Hour)}
+	tests := []struct {
+		name  string
+		event NotificationEvent
+		want  Priority
+	}{
+		{"overdue high priority", NotificationEvent{Type: NotificationTypeTaskDue, Task: overdue}, PriorityUrgent},
+		{"due later", NotificationEvent{Type: NotificationTypeTaskDue,
+			Task: task.Task{Priority: models.PriorityHigh, DueDate: time.Now().Add(time.Hour)}}, PriorityNormal},
+		{"overdue low priority", NotificationEvent{Type: NotificationTypeTaskDue,
+			Task: task.Task{Priority: models.PriorityLow, DueDate: time.Now().Add(-time.Hour)}}, PriorityNormal},
+		{"update", NotificationEvent{Type: NotificationTypeTaskUpdated, Task: overdue}, PriorityNormal},
+		{"explicit", NotificationEvent{Type: NotificationTypeTaskCreated, Priority: PriorityLow}, PriorityLow},
+	}
+	for _, tt := range tests {
+		if got := eventPriority(tt.event); got != tt.want {
+			t.Errorf("%s: priority = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
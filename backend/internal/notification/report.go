@@ -0,0 +1,82 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// NotificationTypeProjectReport is the event type of a delivered report
+const NotificationTypeProjectReport NotificationType = "project_report"
+
+// Report is a summary of a project posted to its channels
+type Report struct {
+	Project string
+	Title   string
+	Summary string
+}
+
+// ReportRenderer is implemented by channel drivers that can post reports.
+// Reports skip channels whose driver cannot.
+type ReportRenderer interface {
+	RenderReport(report Report) ([]byte, error)
+}
+
+// SendReport posts report once to each default channel, at the webhook of
+// its project if it has one. Reports are low priority, like digests.
+func (s *Service) SendReport(ctx context.Context, report Report) {
+	webhooks := s.webhookURLs(ctx, report.Project)
+	for _, ch := range s.config.DefaultChannels {
+		delivery := Delivery{
+			Channel:   string(ch),
+			Project:   report.Project,
+			EventType: string(NotificationTypeProjectReport),
+		}
+		s.dispatch(ctx, PriorityLow, webhooks[ch], delivery, func(sender ChannelSender) ([]byte, error) {
+			renderer, ok := sender.(ReportRenderer)
+			if !ok {
+				return nil, fmt.Errorf("channel %s cannot post reports", ch)
+			}
+			return renderer.RenderReport(report)
+		})
+	}
+}
+
+func (slackSender) RenderReport(report Report) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"text": report.Title,
+		"blocks": []map[string]interface{}{
+			{
+				"type": "header",
+				"text": map[string]interface{}{"type": "plain_text", "text": report.Title},
+			},
+			{
+				"type": "section",
+				"text": map[string]interface{}{"type": "mrkdwn", "text": report.Summary},
+			},
+		},
+	})
+}
+
+func (discordSender) RenderReport(report Report) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"content": report.Title,
+		"embeds": []interface{}{map[string]interface{}{
+			"title":       report.Title,
+			"description": report.Summary,
+			"timestamp":   time.Now().Format(time.RFC3339),
+			"color":       5814783, // Blue
+		}},
+	})
+}
+
+func (teamsSender) RenderReport(report Report) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"@type":    "MessageCard",
+		"@context": "https://schema.org/extensions",
+		"summary":  report.Title,
+		"title":    report.Title,
+		"text":     report.Summary,
+	})
+}
@@ -0,0 +1,46 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestSendReportSkipsChannelsThatCannotPostReports(t *testing.T) {
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Embeds []struct {
+				Description string `json:"description"`
+			} `json:"embeds"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("decode payload: %v", err)
+			return
+		}
+		got = append(got, payload.Embeds[0].Description)
+	}))
+	defer server.Close()
+
+	pager := &recordingSender{}
+	s, _ := NewService(NotificationConfig{
+		DiscordWebhookURL: server.URL,
+		DefaultChannels:   []NotificationChannel{ChannelDiscord, "pager"},
+		Targets:           map[NotificationChannel]string{"pager": "oncall"},
+	}, zap.NewNop())
+	s.Channels().Register("pager", pager)
+
+	s.SendReport(context.Background(), Report{Project: "web", Title: "Weekly report", Summary: "A steady week."})
+	s.Close()
+
+	if len(got) != 1 || got[0] != "A steady week." {
+		t.Fatalf("discord received %v, want the summary once", got)
+	}
+	if len(pager.bodies) != 0 {
+		t.Fatalf("pager received %v, want nothing from a driver without reports", pager.bodies)
+	}
+}
@@ -0,0 +1,228 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	retryPollInterval = 10 * time.Second
+	retryBatchSize    = 50
+	retryBaseDelay    = 30 * time.Second
+	maxRetryDelay     = time.Hour
+	// retryLease keeps a claimed delivery from being retried by another
+	// replica while it is being sent
+	retryLease  = 2 * time.Minute
+	maxListed   = 100
+	maxErrorLen = 1000
+)
+
+var ErrDeliveryNotFound = errors.New("notification delivery not found")
+
+type Delivery = models.NotificationDelivery
+
+// RetryQueue stores failed notification sends in the database, so they
+// survive restarts and any replica can retry them
+type RetryQueue struct {
+	db          *gorm.DB
+	maxAttempts int
+	logger      *zap.Logger
+}
+
+func NewRetryQueue(db *gorm.DB, maxAttempts int, logger *zap.Logger) *RetryQueue {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return &RetryQueue{db: db, maxAttempts: maxAttempts, logger: logger}
+}
+
+// enqueue stores a delivery whose first send failed with sendErr
+func (q *RetryQueue) enqueue(ctx context.Context, delivery *Delivery, sendErr error) {
+	now := time.Now()
+	delivery.Attempts = 1
+	delivery.Status = models.NotificationRetrying
+	delivery.LastError = describeError(sendErr)
+	delivery.NextAttemptAt = now.Add(retryDelay(1))
+	delivery.CreatedAt = now
+	delivery.UpdatedAt = now
+	if q.maxAttempts <= 1 {
+		delivery.Status = models.NotificationDead
+	}
+	if err := q.db.WithContext(ctx).Create(delivery).Error; err != nil {
+		q.logger.Error("Failed to queue notification for retry",
+			zap.String("channel", delivery.Channel), zap.String("recipient", delivery.Recipient), zap.Error(err))
+	}
+}
+
+// claim takes up to retryBatchSize due deliveries and pushes their next
+// attempt past the lease. Rows are locked with SKIP LOCKED, so replicas
+// never claim the same delivery.
+func (q *RetryQueue) claim(ctx context.Context) ([]Delivery, error) {
+	var due []Delivery
+	err := q.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.NotificationRetrying, now).
+			Order("next_attempt_at ASC").
+			Limit(retryBatchSize).
+			Find(&due).Error; err != nil {
+			return err
+		}
+		if len(due) == 0 {
+			return nil
+		}
+
+		ids := make([]string, len(due))
+		for i := range due {
+			ids[i] = due[i].ID
+		}
+		return tx.Model(&Delivery{}).Where("id IN ?", ids).
+			UpdateColumn("next_attempt_at", now.Add(retryLease)).Error
+	})
+	return due, err
+}
+
+// fail records another failed attempt, giving up after the last one
+func (q *RetryQueue) fail(ctx context.Context, delivery *Delivery, sendErr error) error {
+	attempts := delivery.Attempts + 1
+	updates := map[string]interface{}{
+		"attempts":   attempts,
+		"last_error": describeError(sendErr),
+		"updated_at": time.Now(),
+	}
+	if attempts >= q.maxAttempts {
+		updates["status"] = models.NotificationDead
+	} else {
+		updates["next_attempt_at"] = time.Now().Add(retryDelay(attempts))
+	}
+	return q.db.WithContext(ctx).Model(&Delivery{}).Where("id = ?", delivery.ID).Updates(updates).Error
+}
+
+// List returns up to 100 deliveries in status, or in any status if it is
+// empty, oldest first
+func (q *RetryQueue) List(ctx context.Context, status string) ([]Delivery, error) {
+	deliveries := []Delivery{}
+	query := q.db.WithContext(ctx).Order("created_at ASC").Limit(maxListed)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Find(&deliveries).Error; err != nil {
+		return nil, fmt.Errorf("failed to list notification deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// Requeue gives a delivery a fresh set of attempts, starting now
+func (q *RetryQueue) Requeue(ctx context.Context, id string) error {
+	result := q.db.WithContext(ctx).Model(&Delivery{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":          models.NotificationRetrying,
+		"attempts":        0,
+		"next_attempt_at": time.Now(),
+		"updated_at":      time.Now(),
+	})
+	if result.Error != nil {
+		return fmt.Errorf("failed to requeue notification delivery: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrDeliveryNotFound
+	}
+	return nil
+}
+
+// StartRetries resends due deliveries every 10 seconds until ctx is
+// cancelled
+func (s *Service) StartRetries(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(retryPollInterval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := s.RetryDue(ctx); err != nil && ctx.Err() == nil {
+				s.logger.Error("Failed to retry notifications", zap.Error(err))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// RetryDue resends the deliveries whose next attempt is due to the current
+// webhook of their rule, or of their channel and project when they were not
+// routed by a rule or the rule is gone. It returns how many were sent.
+func (s *Service) RetryDue(ctx context.Context) (int, error) {
+	due, err := s.retries.claim(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	webhooks := make(map[string]map[NotificationChannel]string)
+	for i := range due {
+		delivery := &due[i]
+		if webhooks[delivery.Project] == nil {
+			webhooks[delivery.Project] = s.webhookURLs(ctx, delivery.Project)
+		}
+		ch := NotificationChannel(delivery.Channel)
+		webhookURL := webhooks[delivery.Project][ch]
+		if delivery.Rule != "" && s.routes != nil {
+			ruleURL, found, err := s.routes.ruleURL(ctx, delivery.Project, delivery.Rule)
+			if err != nil {
+				s.logger.Warn("Failed to load notification rule, using the project webhook",
+					zap.String("delivery_id", delivery.ID), zap.Error(err))
+			} else if found {
+				webhookURL = ruleURL
+			}
+		}
+
+		sendErr := s.sendMessage(ctx, ch, webhookURL, delivery.Payload)
+		if webhookURL != "" {
+			s.observe(ch, sendErr)
+		}
+		if sendErr == nil {
+			sent++
+			if err := s.retries.db.WithContext(ctx).Delete(&Delivery{}, "id = ?", delivery.ID).Error; err != nil {
+				s.logger.Error("Failed to remove sent notification", zap.String("delivery_id", delivery.ID), zap.Error(err))
+			}
+			continue
+		}
+		if err := s.retries.fail(ctx, delivery, sendErr); err != nil {
+			s.logger.Error("Failed to record notification retry", zap.String("delivery_id", delivery.ID), zap.Error(err))
+		}
+	}
+	return sent, nil
+}
+
+// retryDelay doubles from 30 seconds with each attempt, up to an hour
+func retryDelay(attempts int) time.Duration {
+	delay := retryBaseDelay << min(attempts-1, 10)
+	if delay > maxRetryDelay {
+		return maxRetryDelay
+	}
+	return delay
+}
+
+// describeError keeps a send error for administrators, without the URL that
+// HTTP client errors include, since webhook URLs carry their credentials
+func describeError(err error) string {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	msg := err.Error()
+	if len(msg) > maxErrorLen {
+		return msg[:maxErrorLen]
+	}
+	return msg
+}
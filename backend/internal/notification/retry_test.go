@@ -0,0 +1,122 @@
+package notification
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestQueue(t *testing.T, maxAttempts int) (*RetryQueue, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewRetryQueue(db, maxAttempts, zap.NewNop()), mock
+}
+
+func statusServer(t *testing.T, status int) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFailedSendIsQueued(t *testing.T) {
+	queue, mock := newTestQueue(t, 3)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "notification_deliveries"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("delivery-1"))
+	mock.ExpectCommit()
+
+	s, _ := NewService(NotificationConfig{
+		DiscordWebhookURL: statusServer(t, http.StatusBadGateway).URL,
+		DefaultChannels:   []NotificationChannel{ChannelDiscord},
+	}, zap.NewNop())
+	s.SetRetryQueue(queue)
+
+	s.SendNotification(context.Background(), NotificationEvent{
+		Type: NotificationTypeTaskUpdated,
+		Task: models.Task{ID: "task-1", Title: "Ship it", AssignedTo: "user-1"},
+	})
+	s.Close()
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRetryDueDeletesSentAndKillsExhausted(t *testing.T) {
+	queue, mock := newTestQueue(t, 2)
+	s, _ := NewService(NotificationConfig{
+		SlackWebhookURL:   statusServer(t, http.StatusInternalServerError).URL,
+		DiscordWebhookURL: statusServer(t, http.StatusNoContent).URL,
+	}, zap.NewNop())
+	s.SetRetryQueue(queue)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "notification_deliveries" WHERE status = \$1 AND next_attempt_at <= \$2 ORDER BY next_attempt_at ASC LIMIT \$3 FOR UPDATE SKIP LOCKED`).
+		WithArgs(models.NotificationRetrying, sqlmock.AnyArg(), retryBatchSize).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "channel", "payload", "status", "attempts"}).
+			AddRow("delivery-1", "discord", []byte(`{"content":"hi"}`), "retrying", 1).
+			AddRow("delivery-2", "slack", []byte(`{"text":"hi"}`), "retrying", 1))
+	mock.ExpectExec(`UPDATE "notification_deliveries" SET "next_attempt_at"=\$1 WHERE id IN \(\$2,\$3\)`).
+		WithArgs(sqlmock.AnyArg(), "delivery-1", "delivery-2").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "notification_deliveries" WHERE id = \$1`).
+		WithArgs("delivery-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// The second attempt was the last, so the delivery is dead
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "notification_deliveries" SET "attempts"=\$1,"last_error"=\$2,"status"=\$3,"updated_at"=\$4 WHERE id = \$5`).
+		WithArgs(2, "webhook request failed with status: 500", models.NotificationDead, sqlmock.AnyArg(), "delivery-2").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	sent, err := s.RetryDue(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent != 1 {
+		t.Fatalf("sent = %d, want 1", sent)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRetryDelayBacksOffToAnHour(t *testing.T) {
+	for attempts, want := range map[int]time.Duration{
+		1:  30 * time.Second,
+		2:  time.Minute,
+		4:  4 * time.Minute,
+		8:  time.Hour,
+		50: time.Hour,
+	} {
+		if got := retryDelay(attempts); got != want {
+			t.Errorf("retryDelay(%d) = %s, want %s", attempts, got, want)
+		}
+	}
+}
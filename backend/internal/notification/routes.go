@@ -0,0 +1,144 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+	"github.com/patrickmn/go-cache"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// routeTTL is how long a replica keeps using a project's webhooks after
+// another replica changed them
+const routeTTL = time.Minute
+
+var (
+	ErrInvalidProjectWebhook  = errors.New("invalid project webhook")
+	ErrProjectWebhookNotFound = errors.New("project webhook not found")
+)
+
+type ProjectWebhook = models.ProjectWebhook
+
+type SetProjectWebhookRequest struct {
+	URL string `json:"url" binding:"required"`
+}
+
+// ProjectRoutes stores per-project webhooks, which take the place of the
+// global webhook of their channel for the project's tasks, and rules that
+// send some of the project's notifications elsewhere
+type ProjectRoutes struct {
+	db       *gorm.DB
+	channels *ChannelRegistry
+	cache    *cache.Cache
+}
+
+// NewProjectRoutes stores project webhooks, which the drivers in channels
+// validate
+func NewProjectRoutes(db *gorm.DB, channels *ChannelRegistry) *ProjectRoutes {
+	return &ProjectRoutes{db: db, channels: channels, cache: cache.New(routeTTL, 2*routeTTL)}
+}
+
+// List returns the project's webhooks, by channel
+func (r *ProjectRoutes) List(ctx context.Context, project string) ([]ProjectWebhook, error) {
+	webhooks := []ProjectWebhook{}
+	if err := r.db.WithContext(ctx).Where("project = ?", project).
+		Order("channel ASC").
+		Find(&webhooks).Error; err != nil {
+		return nil, fmt.Errorf("failed to list project webhooks: %w", err)
+	}
+	for i := range webhooks {
+		webhooks[i].URLHint = urlHint(webhooks[i].URL)
+	}
+	return webhooks, nil
+}
+
+// Set points the project's notifications for channel at webhookURL
+func (r *ProjectRoutes) Set(ctx context.Context, project string, channel NotificationChannel, req SetProjectWebhookRequest, userID string) (*ProjectWebhook, error) {
+	if strings.TrimSpace(project) == "" || len(project) > 100 {
+		return nil, fmt.Errorf("%w: project must be 1 to 100 characters", ErrInvalidProjectWebhook)
+	}
+	if err := r.channels.validate(channel, req.URL); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	webhook := &ProjectWebhook{
+		Project:   project,
+		Channel:   string(channel),
+		URL:       req.URL,
+		UpdatedBy: userID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "project"}, {Name: "channel"}},
+		DoUpdates: clause.AssignmentColumns([]string{"url", "updated_by", "updated_at"}),
+	}).Create(webhook).Error; err != nil {
+		return nil, fmt.Errorf("failed to save project webhook: %w", err)
+	}
+	r.cache.Delete(project)
+
+	webhook.URLHint = urlHint(webhook.URL)
+	return webhook, nil
+}
+
+// Delete sends the project's notifications for channel back to the global
+// webhook
+func (r *ProjectRoutes) Delete(ctx context.Context, project string, channel NotificationChannel) error {
+	result := r.db.WithContext(ctx).Where("project = ? AND channel = ?", project, string(channel)).
+		Delete(&ProjectWebhook{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete project webhook: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrProjectWebhookNotFound
+	}
+	r.cache.Delete(project)
+	return nil
+}
+
+// projectRouting is where a project's notifications go: its webhook per
+// channel, and its rules
+type projectRouting struct {
+	webhooks map[NotificationChannel]string
+	rules    []NotificationRule
+}
+
+// routing returns the project's webhooks and rules, cached for a minute
+func (r *ProjectRoutes) routing(ctx context.Context, project string) (*projectRouting, error) {
+	if cached, found := r.cache.Get(project); found {
+		return cached.(*projectRouting), nil
+	}
+
+	var stored []ProjectWebhook
+	if err := r.db.WithContext(ctx).Where("project = ?", project).Find(&stored).Error; err != nil {
+		return nil, err
+	}
+	routing := &projectRouting{webhooks: make(map[NotificationChannel]string, len(stored))}
+	for _, webhook := range stored {
+		routing.webhooks[NotificationChannel(webhook.Channel)] = webhook.URL
+	}
+	if err := r.db.WithContext(ctx).Where("project = ?", project).
+		Order("created_at ASC").
+		Find(&routing.rules).Error; err != nil {
+		return nil, err
+	}
+	r.cache.SetDefault(project, routing)
+	return routing, nil
+}
+
+// urlHint identifies a webhook without revealing it: its host and the last
+// four characters
+func urlHint(webhookURL string) string {
+	u, err := url.Parse(webhookURL)
+	if err != nil || len(webhookURL) < 4 {
+		return "…"
+	}
+	return u.Host + "/…" + webhookURL[len(webhookURL)-4:]
+}
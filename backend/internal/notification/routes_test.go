@@ -0,0 +1,98 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestRoutes(t *testing.T) (*ProjectRoutes, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewProjectRoutes(db, NewChannelRegistry(http.DefaultClient)), mock
+}
+
+func TestSendNotificationUsesProjectWebhook(t *testing.T) {
+	global, globalReceived := recordAssignees(t)
+	project, projectReceived := recordAssignees(t)
+	routes, mock := newTestRoutes(t)
+	mock.ExpectQuery(`SELECT \* FROM "project_webhooks" WHERE project = \$1`).
+		WithArgs("web").
+		WillReturnRows(sqlmock.NewRows([]string{"project", "channel", "url"}).AddRow("web", "discord", project.URL))
+	mock.ExpectQuery(`SELECT \* FROM "project_notification_rules" WHERE project = \$1`).
+		WithArgs("web").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	s, _ := NewService(NotificationConfig{
+		DiscordWebhookURL: global.URL,
+		DefaultChannels:   []NotificationChannel{ChannelDiscord},
+	}, zap.NewNop())
+	s.SetProjectRoutes(routes)
+
+	for i := 0; i < 2; i++ {
+		s.SendNotification(context.Background(), NotificationEvent{
+			Type: NotificationTypeTaskUpdated,
+			Task: models.Task{Title: "Ship it", Project: "web", AssignedTo: "user-1"},
+		})
+	}
+	s.SendNotification(context.Background(), NotificationEvent{
+		Type: NotificationTypeTaskUpdated,
+		Task: models.Task{Title: "Other", AssignedTo: "user-2"},
+	})
+	s.Close()
+
+	if got := projectReceived(); len(got) != 2 || got[0] != "user-1" {
+		t.Fatalf("project webhook got %v, want both messages of the project's task", got)
+	}
+	if got := globalReceived(); len(got) != 1 || got[0] != "user-2" {
+		t.Fatalf("global webhook got %v, want only the task without a project", got)
+	}
+	// The second event of the project was routed from the cache
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSetProjectWebhookValidatesURL(t *testing.T) {
+	routes, _ := newTestRoutes(t)
+
+	cases := map[string]struct {
+		channel NotificationChannel
+		url     string
+	}{
+		"unknown channel": {"sms", "https://hooks.slack.com/services/T0/B0/x"},
+		"plain http":      {ChannelSlack, "http://hooks.slack.com/services/T0/B0/x"},
+		"other host":      {ChannelSlack, "https://example.com/services/T0/B0/x"},
+		"wrong channel":   {ChannelDiscord, "https://hooks.slack.com/services/T0/B0/x"},
+		"teams lookalike": {ChannelTeams, "https://evilwebhook.office.com/webhookb2/x"},
+	}
+	for name, c := range cases {
+		_, err := routes.Set(context.Background(), "web", c.channel, SetProjectWebhookRequest{URL: c.url}, "user-1")
+		if !errors.Is(err, ErrInvalidProjectWebhook) {
+			t.Errorf("%s: err = %v, want ErrInvalidProjectWebhook", name, err)
+		}
+	}
+}
+
+func TestURLHintHidesWebhookPath(t *testing.T) {
+	if got := urlHint("https://hooks.slack.com/services/T000/B000/abcd1234"); got != "hooks.slack.com/…1234" {
+		t.Fatalf("hint = %q", got)
+	}
+}
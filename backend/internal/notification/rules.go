@@ -0,0 +1,218 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+)
+
+// maxProjectRules caps the rules of one project
+const maxProjectRules = 20
+
+var (
+	ErrInvalidNotificationRule  = errors.New("invalid notification rule")
+	ErrNotificationRuleNotFound = errors.New("notification rule not found")
+)
+
+type NotificationRule = models.ProjectNotificationRule
+
+// NotificationRuleRequest creates or replaces a rule. URL may be left out
+// when updating a rule to keep its webhook.
+type NotificationRuleRequest struct {
+	Channel        NotificationChannel `json:"channel" binding:"required"`
+	Name           string              `json:"name" binding:"max=100"`
+	URL            string              `json:"url"`
+	EventTypes     []string            `json:"event_types" binding:"max=10"`
+	Priorities     []string            `json:"priorities" binding:"max=3"`
+	TaskPriorities []string            `json:"task_priorities" binding:"max=3"`
+}
+
+var (
+	ruleEventTypes = []string{
+		string(NotificationTypeTaskCreated), string(NotificationTypeTaskUpdated),
+		string(NotificationTypeTaskDeleted), string(NotificationTypeTaskDue),
+	}
+	rulePriorities     = []string{string(PriorityUrgent), string(PriorityNormal), string(PriorityLow)}
+	ruleTaskPriorities = []string{string(models.PriorityLow), string(models.PriorityMedium), string(models.PriorityHigh)}
+)
+
+// ListRules returns the project's rules, oldest first
+func (r *ProjectRoutes) ListRules(ctx context.Context, project string) ([]NotificationRule, error) {
+	rules := []NotificationRule{}
+	if err := r.db.WithContext(ctx).Where("project = ?", project).
+		Order("created_at ASC").
+		Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to list notification rules: %w", err)
+	}
+	for i := range rules {
+		rules[i].URLHint = urlHint(rules[i].URL)
+	}
+	return rules, nil
+}
+
+// CreateRule adds a rule to the project
+func (r *ProjectRoutes) CreateRule(ctx context.Context, project string, req NotificationRuleRequest, userID string) (*NotificationRule, error) {
+	if err := r.validateRule(project, req, true); err != nil {
+		return nil, err
+	}
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&NotificationRule{}).Where("project = ?", project).
+		Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("failed to count notification rules: %w", err)
+	}
+	if count >= maxProjectRules {
+		return nil, fmt.Errorf("%w: a project has at most %d rules", ErrInvalidNotificationRule, maxProjectRules)
+	}
+
+	now := time.Now()
+	rule := &NotificationRule{Project: project, URL: req.URL, UpdatedBy: userID, CreatedAt: now}
+	applyRuleRequest(rule, req, now)
+	if err := r.db.WithContext(ctx).Create(rule).Error; err != nil {
+		return nil, fmt.Errorf("failed to save notification rule: %w", err)
+	}
+	r.cache.Delete(project)
+
+	rule.URLHint = urlHint(rule.URL)
+	return rule, nil
+}
+
+// UpdateRule replaces the project's rule id
+func (r *ProjectRoutes) UpdateRule(ctx context.Context, project, id string, req NotificationRuleRequest, userID string) (*NotificationRule, error) {
+	if err := r.validateRule(project, req, false); err != nil {
+		return nil, err
+	}
+	var rule NotificationRule
+	if err := r.db.WithContext(ctx).Where("project = ? AND id = ?", project, id).
+		Limit(1).Find(&rule).Error; err != nil {
+		return nil, fmt.Errorf("failed to load notification rule: %w", err)
+	}
+	if rule.ID == "" {
+		return nil, ErrNotificationRuleNotFound
+	}
+	if req.URL == "" && NotificationChannel(rule.Channel) != req.Channel {
+		return nil, fmt.Errorf("%w: a new url is required to change the channel", ErrInvalidNotificationRule)
+	}
+
+	if req.URL != "" {
+		rule.URL = req.URL
+	}
+	rule.UpdatedBy = userID
+	applyRuleRequest(&rule, req, time.Now())
+	if err := r.db.WithContext(ctx).Save(&rule).Error; err != nil {
+		return nil, fmt.Errorf("failed to save notification rule: %w", err)
+	}
+	r.cache.Delete(project)
+
+	rule.URLHint = urlHint(rule.URL)
+	return &rule, nil
+}
+
+// DeleteRule removes the project's rule id
+func (r *ProjectRoutes) DeleteRule(ctx context.Context, project, id string) error {
+	result := r.db.WithContext(ctx).Where("project = ? AND id = ?", project, id).Delete(&NotificationRule{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete notification rule: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotificationRuleNotFound
+	}
+	r.cache.Delete(project)
+	return nil
+}
+
+// ruleURL returns the current webhook of the project's rule id, or false if
+// the rule is gone
+func (r *ProjectRoutes) ruleURL(ctx context.Context, project, id string) (string, bool, error) {
+	routing, err := r.routing(ctx, project)
+	if err != nil {
+		return "", false, err
+	}
+	for _, rule := range routing.rules {
+		if rule.ID == id {
+			return rule.URL, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+func (r *ProjectRoutes) validateRule(project string, req NotificationRuleRequest, create bool) error {
+	if strings.TrimSpace(project) == "" || len(project) > 100 {
+		return fmt.Errorf("%w: project must be 1 to 100 characters", ErrInvalidNotificationRule)
+	}
+	// Errors of the channel drivers wrap ErrInvalidProjectWebhook
+	if req.URL != "" || create {
+		if err := r.channels.validate(req.Channel, req.URL); err != nil {
+			return err
+		}
+	} else if _, ok := r.channels.Sender(req.Channel); !ok {
+		return fmt.Errorf("%w: unknown channel %q", ErrInvalidNotificationRule, req.Channel)
+	}
+	for _, filter := range []struct {
+		name    string
+		values  []string
+		allowed []string
+	}{
+		{"event_types", req.EventTypes, ruleEventTypes},
+		{"priorities", req.Priorities, rulePriorities},
+		{"task_priorities", req.TaskPriorities, ruleTaskPriorities},
+	} {
+		for _, value := range filter.values {
+			if !slices.Contains(filter.allowed, value) {
+				return fmt.Errorf("%w: %s must be among %s", ErrInvalidNotificationRule,
+					filter.name, strings.Join(filter.allowed, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+func applyRuleRequest(rule *NotificationRule, req NotificationRuleRequest, now time.Time) {
+	rule.Channel = string(req.Channel)
+	rule.Name = req.Name
+	rule.EventTypes = req.EventTypes
+	rule.Priorities = req.Priorities
+	rule.TaskPriorities = req.TaskPriorities
+	rule.UpdatedAt = now
+}
+
+// ruleMatches reports whether the rule takes the event, sent at priority
+func ruleMatches(rule NotificationRule, event NotificationEvent, priority Priority) bool {
+	matches := func(values []string, value string) bool {
+		return len(values) == 0 || slices.Contains(values, value)
+	}
+	return matches(rule.EventTypes, string(event.Type)) &&
+		matches(rule.Priorities, string(priority)) &&
+		matches(rule.TaskPriorities, string(event.Task.Priority))
+}
+
+// target is where a channel's message goes: the webhook of the rule it was
+// routed by, or the project's or global webhook when rule is empty
+type target struct {
+	url  string
+	rule string
+}
+
+// eventTargets resolves each channel's targets for the event. The webhooks
+// of the project's matching rules take the event; without any, it goes to
+// the project's webhook, or else the global one.
+func (s *Service) eventTargets(ctx context.Context, event NotificationEvent, priority Priority, channels []NotificationChannel) map[NotificationChannel][]target {
+	webhooks, rules := s.projectTargets(ctx, event.Task.Project)
+	targets := make(map[NotificationChannel][]target, len(channels))
+	for _, ch := range channels {
+		for _, rule := range rules {
+			if NotificationChannel(rule.Channel) == ch && ruleMatches(rule, event, priority) &&
+				!slices.ContainsFunc(targets[ch], func(t target) bool { return t.url == rule.URL }) {
+				targets[ch] = append(targets[ch], target{url: rule.URL, rule: rule.ID})
+			}
+		}
+		if len(targets[ch]) == 0 {
+			targets[ch] = []target{{url: webhooks[ch]}}
+		}
+	}
+	return targets
+}
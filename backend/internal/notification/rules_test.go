@@ -0,0 +1,77 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+	"go.uber.org/zap"
+)
+
+func TestSendNotificationFollowsProjectRules(t *testing.T) {
+	global, globalReceived := recordAssignees(t)
+	urgent, urgentReceived := recordAssignees(t)
+	created, createdReceived := recordAssignees(t)
+	routes, mock := newTestRoutes(t)
+	mock.ExpectQuery(`SELECT \* FROM "project_webhooks" WHERE project = \$1`).
+		WithArgs("web").
+		WillReturnRows(sqlmock.NewRows([]string{"project", "channel", "url"}))
+	mock.ExpectQuery(`SELECT \* FROM "project_notification_rules" WHERE project = \$1`).
+		WithArgs("web").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "project", "channel", "url", "event_types", "task_priorities"}).
+			AddRow("rule-1", "web", "discord", urgent.URL, nil, []byte(`["high"]`)).
+			AddRow("rule-2", "web", "discord", created.URL, []byte(`["task_created"]`), nil).
+			AddRow("rule-3", "web", "slack", urgent.URL, nil, nil))
+
+	s, _ := NewService(NotificationConfig{
+		DiscordWebhookURL: global.URL,
+		DefaultChannels:   []NotificationChannel{ChannelDiscord},
+	}, zap.NewNop())
+	s.SetProjectRoutes(routes)
+
+	send := func(eventType NotificationType, priority models.TaskPriority, assignee string) {
+		s.SendNotification(context.Background(), NotificationEvent{
+			Type: eventType,
+			Task: models.Task{Title: "Ship it", Project: "web", Priority: priority, AssignedTo: assignee},
+		})
+	}
+	send(NotificationTypeTaskCreated, models.PriorityHigh, "user-1")
+	send(NotificationTypeTaskUpdated, models.PriorityLow, "user-2")
+	send(NotificationTypeTaskCreated, models.PriorityLow, "user-3")
+	s.Close()
+
+	if got := urgentReceived(); len(got) != 1 || got[0] != "user-1" {
+		t.Fatalf("high priority rule got %v, want only the high priority task", got)
+	}
+	if got := createdReceived(); len(got) != 2 || got[0] != "user-1" || got[1] != "user-3" {
+		t.Fatalf("task_created rule got %v, want both created tasks", got)
+	}
+	if got := globalReceived(); len(got) != 1 || got[0] != "user-2" {
+		t.Fatalf("global webhook got %v, want only the event no rule matched", got)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCreateRuleValidatesFilters(t *testing.T) {
+	routes, _ := newTestRoutes(t)
+	url := "https://hooks.slack.com/services/T0/B0/x"
+
+	cases := map[string]struct {
+		req  NotificationRuleRequest
+		want error
+	}{
+		"unknown event type": {NotificationRuleRequest{Channel: ChannelSlack, URL: url, EventTypes: []string{"task_moved"}}, ErrInvalidNotificationRule},
+		"unknown priority":   {NotificationRuleRequest{Channel: ChannelSlack, URL: url, Priorities: []string{"critical"}}, ErrInvalidNotificationRule},
+		"missing url":        {NotificationRuleRequest{Channel: ChannelSlack}, ErrInvalidProjectWebhook},
+		"wrong host":         {NotificationRuleRequest{Channel: ChannelDiscord, URL: url}, ErrInvalidProjectWebhook},
+	}
+	for name, c := range cases {
+		if _, err := routes.CreateRule(context.Background(), "web", c.req, "user-1"); !errors.Is(err, c.want) {
+			t.Errorf("%s: err = %v, want %v", name, err, c.want)
+		}
+	}
+}
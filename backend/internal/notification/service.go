@@ -1,172 +1,404 @@
 package notification
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"net/http"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/iSparshP/real-time-task-management-system/internal/breaker"
+	"github.com/iSparshP/real-time-task-management-system/internal/telemetry"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
 type Service struct {
-	config NotificationConfig
-	logger *zap.Logger
-	client *http.Client
-	wg     sync.WaitGroup
+	config   NotificationConfig
+	logger   *zap.Logger
+	channels *ChannelRegistry
+	queue    *dispatchQueue
+	wg       sync.WaitGroup
+	dedupe   Deduplicator
+
+	// pending counts messages being sent, for autoscaling
+	pending atomic.Int64
+
+	// observeResult receives the outcome of every send to a configured
+	// channel
+	observeResult func(ok bool)
+	// observeChannel receives the error, or nil, of every send to a
+	// configured channel
+	observeChannel func(ch NotificationChannel, err error)
+
+	// paused drops every new message and muted those of its channels, as
+	// administrators set them while the server runs
+	paused atomic.Bool
+	muted  atomic.Pointer[[]NotificationChannel]
+
+	watchers  WatcherLookup
+	routes    *ProjectRoutes
+	retries   *RetryQueue
+	breaker   *breaker.Set
+	languages LanguageLookup
+}
+
+// WatcherLookup is implemented by the task service so watchers of a task
+// are notified along with its assignees
+type WatcherLookup interface {
+	TaskWatchers(ctx context.Context, taskID string) ([]string, error)
+}
+
+// SetWatcherLookup enables notifying the watchers of a task
+func (s *Service) SetWatcherLookup(watchers WatcherLookup) {
+	s.watchers = watchers
+}
+
+// SetProjectRoutes enables per-project webhooks
+func (s *Service) SetProjectRoutes(routes *ProjectRoutes) {
+	s.routes = routes
+}
+
+// SetRetryQueue keeps failed sends for retrying instead of dropping them
+func (s *Service) SetRetryQueue(retries *RetryQueue) {
+	s.retries = retries
+}
+
+// SetBreaker fails sends fast, without calling the target, while the
+// target's circuit in breakers is open. Failed sends are still queued for
+// retry.
+func (s *Service) SetBreaker(breakers *breaker.Set) {
+	s.breaker = breakers
 }
 
 func NewService(config NotificationConfig, logger *zap.Logger) (*Service, error) {
 	return &Service{
 		config: config,
 		logger: logger,
-		client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		queue:  newDispatchQueue(config.Workers, config.PriorityBudgets),
+		channels: NewChannelRegistry(&http.Client{
+			Timeout:   10 * time.Second,
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
+		}),
 	}, nil
 }
 
-func (s *Service) SendNotification(event NotificationEvent) {
+// Channels is the registry of channel drivers. Registering a driver there
+// and configuring a target for it is all a new channel needs.
+func (s *Service) Channels() *ChannelRegistry {
+	return s.channels
+}
+
+// SetDeduplicator enables duplicate suppression for events with an ID
+func (s *Service) SetDeduplicator(dedupe Deduplicator) {
+	s.dedupe = dedupe
+}
+
+// IsDuplicate records the event's ID and reports whether it was already
+// accepted. Events without an ID are never duplicates, and if the
+// deduplicator fails the event is treated as new so it is still sent.
+func (s *Service) IsDuplicate(ctx context.Context, event NotificationEvent) bool {
+	if s.dedupe == nil || event.EventID == "" {
+		return false
+	}
+	seen, err := s.dedupe.Seen(ctx, event.EventID)
+	if err != nil {
+		s.logger.Warn("Notification deduplication unavailable, sending event",
+			zap.String("event_id", event.EventID), zap.Error(err))
+		return false
+	}
+	return seen
+}
+
+// SetResultObserver reports whether each message sent to a configured
+// channel was delivered
+func (s *Service) SetResultObserver(observe func(ok bool)) {
+	s.observeResult = observe
+}
+
+// SetChannelObserver reports the outcome of each message sent to a
+// configured channel, per channel
+func (s *Service) SetChannelObserver(observe func(ch NotificationChannel, err error)) {
+	s.observeChannel = observe
+}
+
+// ChannelConfigured reports whether the channel has a global target
+func (s *Service) ChannelConfigured(ch NotificationChannel) bool {
+	return s.globalTargets()[ch] != ""
+}
+
+// globalTargets returns the configured target of each channel
+func (s *Service) globalTargets() map[NotificationChannel]string {
+	targets := map[NotificationChannel]string{
+		ChannelSlack:   s.config.SlackWebhookURL,
+		ChannelDiscord: s.config.DiscordWebhookURL,
+	}
+	for ch, target := range s.config.Targets {
+		targets[ch] = target
+	}
+	return targets
+}
+
+// SetPaused drops new messages while paused is set. Messages already queued
+// or waiting for a retry are still sent.
+func (s *Service) SetPaused(paused bool) {
+	s.paused.Store(paused)
+}
+
+// MuteChannels drops new messages to channels, replacing those muted before
+func (s *Service) MuteChannels(channels []NotificationChannel) {
+	s.muted.Store(&channels)
+}
+
+// QueueDepth is the number of notification messages still being sent
+func (s *Service) QueueDepth() int64 {
+	return s.pending.Load()
+}
+
+// SendNotification fans the event out to its channels, sending one message
+// per assignee and watcher, in their language, so a failed delivery to one
+// does not hide the others. Each channel goes to the webhooks of the
+// project's rules that match the event, or without any to the webhook of the
+// task's project, if it has one, or else the global target. Channels
+// without a driver are skipped.
+// Messages wait for a worker in the queue of the event's priority. ctx
+// carries the trace of the originating request; it is not used for
+// cancellation.
+func (s *Service) SendNotification(ctx context.Context, event NotificationEvent) {
+	if s.paused.Load() {
+		return
+	}
 	channels := event.Channels
 	if len(channels) == 0 {
 		channels = s.config.DefaultChannels
 	}
+	if muted := s.muted.Load(); muted != nil {
+		channels = slices.DeleteFunc(slices.Clone(channels), func(ch NotificationChannel) bool {
+			return slices.Contains(*muted, ch)
+		})
+	}
 
-	for _, channel := range channels {
-		s.wg.Add(1)
-		go func(ch NotificationChannel) {
-			defer s.wg.Done()
-
-			var err error
-			switch ch {
-			case ChannelSlack:
-				err = s.sendSlackNotification(event)
-			case ChannelDiscord:
-				err = s.sendDiscordNotification(event)
+	priority := eventPriority(event)
+	targets := s.eventTargets(ctx, event, priority, channels)
+	recipients := s.eventRecipients(ctx, event)
+	s.resolveLanguages(ctx, recipients)
+	for _, ch := range channels {
+		for _, t := range targets[ch] {
+			for _, r := range recipients {
+				delivery := Delivery{
+					Channel:   string(ch),
+					Project:   event.Task.Project,
+					EventType: string(event.Type),
+					TaskID:    event.Task.ID,
+					Recipient: r.UserID,
+					Rule:      t.rule,
+				}
+				s.dispatch(ctx, priority, t.url, delivery, func(sender ChannelSender) ([]byte, error) {
+					return sender.Render(event, r)
+				})
 			}
+		}
+	}
+}
 
-			if err != nil {
-				s.logger.Error("Failed to send notification",
-					zap.String("channel", string(ch)),
-					zap.Error(err),
-				)
+// dispatch queues one message at priority. On a worker it renders the
+// message with the driver of delivery's channel, skipping channels without
+// a driver, and sends it to target. Failed sends to a configured target are
+// kept for retrying.
+func (s *Service) dispatch(ctx context.Context, priority Priority, target string, delivery Delivery, render func(ChannelSender) ([]byte, error)) {
+	ch := NotificationChannel(delivery.Channel)
+	s.wg.Add(1)
+	s.pending.Add(1)
+	s.queue.submit(priority, func() {
+		defer s.wg.Done()
+		defer s.pending.Add(-1)
+
+		ctx, span := telemetry.Tracer().Start(ctx, "notification.send",
+			trace.WithAttributes(
+				attribute.String("notification.channel", delivery.Channel),
+				attribute.String("notification.type", delivery.EventType),
+				attribute.String("notification.priority", string(priority)),
+				attribute.String("notification.recipient", delivery.Recipient),
+			),
+		)
+		defer span.End()
+
+		sender, ok := s.channels.Sender(ch)
+		if !ok {
+			return
+		}
+		body, err := render(sender)
+		if err == nil {
+			err = s.sendMessage(ctx, ch, target, body)
+		}
+
+		if target != "" {
+			s.observe(ch, err)
+			if err != nil && s.retries != nil {
+				delivery.Payload = body
+				s.retries.enqueue(ctx, &delivery, err)
 			}
-		}(channel)
-	}
+		}
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			s.logger.Error("Failed to send notification",
+				zap.String("channel", delivery.Channel),
+				zap.String("recipient", delivery.Recipient),
+				zap.Error(err),
+			)
+		}
+	})
 }
 
-func (s *Service) sendSlackNotification(event NotificationEvent) error {
-	if s.config.SlackWebhookURL == "" {
-		return fmt.Errorf("slack webhook URL not configured")
+// webhookURLs returns the target of each channel for a project's tasks
+func (s *Service) webhookURLs(ctx context.Context, project string) map[NotificationChannel]string {
+	urls, _ := s.projectTargets(ctx, project)
+	return urls
+}
+
+// projectTargets returns the target of each channel for a project's tasks,
+// and the project's rules. If the project's routing cannot be loaded, the
+// global targets are used.
+func (s *Service) projectTargets(ctx context.Context, project string) (map[NotificationChannel]string, []NotificationRule) {
+	urls := s.globalTargets()
+	if s.routes == nil || project == "" {
+		return urls, nil
+	}
+	routing, err := s.routes.routing(ctx, project)
+	if err != nil {
+		s.logger.Warn("Failed to load project webhooks, using the global webhooks",
+			zap.String("project", project), zap.Error(err))
+		return urls, nil
 	}
+	for ch, webhookURL := range routing.webhooks {
+		urls[ch] = webhookURL
+	}
+	return urls, routing.rules
+}
 
-	// Create Slack-specific payload
-	blocks := []map[string]interface{}{
-		{
-			"type": "section",
-			"text": map[string]interface{}{
-				"type": "mrkdwn",
-				"text": fmt.Sprintf("*Task Update*\n*Task:* %s\n*Updated by:* %s\n*Status:* %s",
-					event.Task.Title,
-					event.Task.CreatedBy,
-					event.Task.Status),
-			},
-		},
-		{
-			"type": "context",
-			"elements": []map[string]interface{}{
-				{
-					"type": "mrkdwn",
-					"text": fmt.Sprintf("Timestamp: %s", time.Now().Format(time.RFC3339)),
-				},
-			},
-		},
+// observe reports the outcome of a send to a configured webhook
+func (s *Service) observe(ch NotificationChannel, err error) {
+	if s.observeResult != nil {
+		s.observeResult(err == nil)
 	}
+	if s.observeChannel != nil {
+		s.observeChannel(ch, err)
+	}
+}
 
-	payload := map[string]interface{}{
-		"text":   fmt.Sprintf("Task Update: Task '%s' has been updated.", event.Task.Title),
-		"blocks": blocks,
+// sendMessage sends a rendered message to the channel's target
+func (s *Service) sendMessage(ctx context.Context, ch NotificationChannel, target string, body []byte) error {
+	if target == "" {
+		return fmt.Errorf("%s webhook URL not configured", ch)
 	}
+	sender, ok := s.channels.Sender(ch)
+	if !ok {
+		return fmt.Errorf("no driver for channel %s", ch)
+	}
+	return s.breaker.Do(destination(ch, target), func() error {
+		return sender.Send(ctx, body, target)
+	}, func(error) bool { return ctx.Err() == nil })
+}
 
-	return s.sendWebhookRequest(s.config.SlackWebhookURL, payload)
+// destination names the circuit breaker of a channel's target. Webhook
+// URLs hold their secret, so only a digest of target is kept.
+func destination(ch NotificationChannel, target string) string {
+	sum := sha256.Sum256([]byte(target))
+	return string(ch) + ":" + hex.EncodeToString(sum[:4])
 }
 
-func (s *Service) sendDiscordNotification(event NotificationEvent) error {
-	if s.config.DiscordWebhookURL == "" {
-		return fmt.Errorf("discord webhook URL not configured")
+// PingChannel checks that the channel's global target still accepts
+// messages without sending one
+func (s *Service) PingChannel(ctx context.Context, ch NotificationChannel) error {
+	target := s.globalTargets()[ch]
+	if target == "" {
+		return fmt.Errorf("%s webhook URL not configured", ch)
 	}
-
-	// Create Discord-specific payload
-	embed := map[string]interface{}{
-		"title":       fmt.Sprintf("Task Update: %s", event.Task.Title),
-		"description": "The task has been updated.",
-		"fields": []map[string]interface{}{
-			{
-				"name":   "Updated by",
-				"value":  event.Task.CreatedBy,
-				"inline": true,
-			},
-			{
-				"name":   "Status",
-				"value":  string(event.Task.Status),
-				"inline": true,
-			},
-		},
-		"timestamp": time.Now().Format(time.RFC3339),
-		"color":     s.getDiscordColorForEvent(event),
+	sender, ok := s.channels.Sender(ch)
+	if !ok {
+		return fmt.Errorf("no driver for channel %s", ch)
 	}
+	return sender.Ping(ctx, target)
+}
 
-	payload := map[string]interface{}{
-		"content": "Task Update Notification",
-		"embeds":  []interface{}{embed},
-	}
+const (
+	roleAssignee = "Assignee"
+	roleWatcher  = "Watcher"
+)
 
-	return s.sendWebhookRequest(s.config.DiscordWebhookURL, payload)
+// Recipient is a user messaged about an event, and why
+type Recipient struct {
+	UserID string
+	// Role is Assignee or Watcher
+	Role string
+	// Language is the language tag messages to the user are written in;
+	// empty for the default language
+	Language string
 }
 
-func (s *Service) sendWebhookRequest(webhookURL string, payload interface{}) error {
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("failed to marshal payload: %w", err)
+// eventRecipients returns every assignee of the event's task, primary
+// first, then its watchers who are not assignees. A task with neither
+// gets one "unassigned" message so it is still announced. If watchers
+// cannot be looked up, only assignees are messaged.
+func (s *Service) eventRecipients(ctx context.Context, event NotificationEvent) []Recipient {
+	recipients := []Recipient{}
+	seen := make(map[string]bool)
+	add := func(id, role string) {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			recipients = append(recipients, Recipient{UserID: id, Role: role})
+		}
 	}
 
-	req, err := http.NewRequest("POST", webhookURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	add(event.Task.AssignedTo, roleAssignee)
+	for _, a := range event.Task.Assignees {
+		add(a.UserID, roleAssignee)
 	}
-
-	req.Header.Set("Content-Type", "application/json")
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to send webhook request: %w", err)
+	if s.watchers != nil && event.Task.ID != "" {
+		watchers, err := s.watchers.TaskWatchers(ctx, event.Task.ID)
+		if err != nil {
+			s.logger.Warn("Failed to look up task watchers, notifying assignees only",
+				zap.String("task_id", event.Task.ID), zap.Error(err))
+		}
+		for _, id := range watchers {
+			add(id, roleWatcher)
+		}
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		return fmt.Errorf("webhook request failed with status: %d", resp.StatusCode)
+	if len(recipients) == 0 {
+		return []Recipient{{UserID: "unassigned", Role: roleAssignee}}
 	}
-
-	return nil
+	return recipients
 }
 
-func (s *Service) getNotificationTitle(event NotificationEvent) string {
+// notificationTitle is the headline of a message about event
+func notificationTitle(event NotificationEvent, l localizer) string {
 	switch event.Type {
 	case NotificationTypeTaskCreated:
-		return "🆕 New Task Created"
+		return l.text(msgTitleCreated)
 	case NotificationTypeTaskUpdated:
-		return "📝 Task Updated"
+		return l.text(msgTitleUpdated)
 	case NotificationTypeTaskDeleted:
-		return "🗑️ Task Deleted"
+		return l.text(msgTitleDeleted)
 	case NotificationTypeTaskDue:
-		return "⏰ Task Due Soon"
+		return l.text(msgTitleDue)
+	case NotificationTypeDeadlineMissed:
+		return l.text(msgTitleMissed)
+	case NotificationTypePriorityEscalated:
+		return l.text(msgTitleEscalated)
 	default:
-		return "Task Notification"
+		return l.text(msgTitleNotification)
 	}
 }
 
-func (s *Service) getColorForEvent(event NotificationEvent) string {
+func colorForEvent(event NotificationEvent) string {
 	switch event.Type {
 	case NotificationTypeTaskCreated:
 		return "#36a64f" // green
@@ -176,26 +408,15 @@ func (s *Service) getColorForEvent(event NotificationEvent) string {
 		return "#f44336" // red
 	case NotificationTypeTaskDue:
 		return "#ff9800" // orange
+	case NotificationTypeDeadlineMissed:
+		return "#b71c1c" // dark red
+	case NotificationTypePriorityEscalated:
+		return "#e65100" // deep orange
 	default:
 		return "#9e9e9e" // grey
 	}
 }
 
-func (s *Service) getDiscordColorForEvent(event NotificationEvent) int {
-	switch event.Type {
-	case NotificationTypeTaskCreated:
-		return 3066993 // Green
-	case NotificationTypeTaskUpdated:
-		return 5814783 // Blue
-	case NotificationTypeTaskDeleted:
-		return 15158332 // Red
-	case NotificationTypeTaskDue:
-		return 16776960 // Yellow
-	default:
-		return 10197915 // Gray
-	}
-}
-
 func (s *Service) Close() {
 	s.wg.Wait()
 }
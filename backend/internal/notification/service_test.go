@@ -0,0 +1,308 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+	"go.uber.org/zap"
+)
+
+// recordAssignees starts a Discord-style webhook that records the assignee
+// field of every message it receives
+func recordAssignees(t *testing.T) (*httptest.Server, func() []string) {
+	t.Helper()
+	var mu sync.Mutex
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Embeds []struct {
+				Fields []struct {
+					Name  string `json:"name"`
+					Value string `json:"value"`
+				} `json:"fields"`
+			} `json:"embeds"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("decode payload: %v", err)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, f := range payload.Embeds[0].Fields {
+			if f.Name == "Assignee" {
+				got = append(got, f.Value)
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		sort.Strings(got)
+		return got
+	}
+}
+
+func TestSendNotificationMessagesEachAssignee(t *testing.T) {
+	server, received := recordAssignees(t)
+	s, _ := NewService(NotificationConfig{
+		DiscordWebhookURL: server.URL,
+		DefaultChannels:   []NotificationChannel{ChannelDiscord},
+	}, zap.NewNop())
+
+	s.SendNotification(context.Background(), NotificationEvent{
+		Type: NotificationTypeTaskUpdated,
+		Task: models.Task{
+			Title:      "Ship it",
+			AssignedTo: "user-1",
+			Assignees: []models.TaskAssignee{
+				{UserID: "user-1", IsPrimary: true},
+				{UserID: "user-2"},
+			},
+		},
+	})
+	s.Close()
+
+	got := received()
+	if len(got) != 2 || got[0] != "user-1" || got[1] != "user-2" {
+		t.Fatalf("assignees messaged = %v, want [user-1 user-2]", got)
+	}
+}
+
+func TestSendNotificationUnassignedTask(t *testing.T) {
+	server, received := recordAssignees(t)
+	s, _ := NewService(NotificationConfig{
+		DiscordWebhookURL: server.URL,
+		DefaultChannels:   []NotificationChannel{ChannelDiscord},
+	}, zap.NewNop())
+
+	s.SendNotification(context.Background(), NotificationEvent{
+		Type: NotificationTypeTaskCreated,
+		Task: models.Task{Title: "Triage me"},
+	})
+	s.Close()
+
+	if got := received(); len(got) != 1 || got[0] != "unassigned" {
+		t.Fatalf("assignees messaged = %v, want [unassigned]", got)
+	}
+}
+
+func TestPausedAndMutedChannelsDropMessages(t *testing.T) {
+	server, received := recordAssignees(t)
+	s, _ := NewService(NotificationConfig{
+		DiscordWebhookURL: server.URL,
+		DefaultChannels:   []NotificationChannel{ChannelDiscord},
+	}, zap.NewNop())
+	event := NotificationEvent{
+		Type: NotificationTypeTaskCreated,
+		Task: models.Task{Title: "Triage me"},
+	}
+
+	s.SetPaused(true)
+	s.SendNotification(context.Background(), event)
+	s.SetPaused(false)
+	s.MuteChannels([]NotificationChannel{ChannelDiscord})
+	s.SendNotification(context.Background(), event)
+	s.MuteChannels(nil)
+	s.SendNotification(context.Background(), event)
+	s.Close()
+
+	if got := received(); len(got) != 1 {
+		t.Fatalf("messages sent = %v, want only the one sent unmuted", got)
+	}
+}
+
+func TestResultObserverSkipsUnconfiguredChannels(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(failing.Close)
+	s, _ := NewService(NotificationConfig{
+		DiscordWebhookURL: failing.URL,
+		DefaultChannels:   []NotificationChannel{ChannelDiscord, ChannelSlack},
+	}, zap.NewNop())
+
+	var mu sync.Mutex
+	var results []bool
+	s.SetResultObserver(func(ok bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		results = append(results, ok)
+	})
+	s.SendNotification(context.Background(), NotificationEvent{
+		Type: NotificationTypeTaskUpdated,
+		Task: models.Task{Title: "Ship it", AssignedTo: "user-1"},
+	})
+	s.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(results) != 1 || results[0] {
+		t.Fatalf("results = %v, want one failed Discord delivery", results)
+	}
+}
+
+type fakeWatchers []string
+
+func (f fakeWatchers) TaskWatchers(ctx context.Context, taskID string) ([]string, error) {
+	return f, nil
+}
+
+func TestSendNotificationMessagesWatchers(t *testing.T) {
+	var mu sync.Mutex
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload DiscordPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("decode payload: %v", err)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, f := range payload.Embeds[0].Fields {
+			if f.Name == "Assignee" || f.Name == "Watcher" {
+				got = append(got, f.Name+":"+f.Value)
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	s, _ := NewService(NotificationConfig{
+		DiscordWebhookURL: server.URL,
+		DefaultChannels:   []NotificationChannel{ChannelDiscord},
+	}, zap.NewNop())
+	s.SetWatcherLookup(fakeWatchers{"user-1", "user-3"})
+
+	s.SendNotification(context.Background(), NotificationEvent{
+		Type: NotificationTypeTaskUpdated,
+		Task: models.Task{ID: "task-1", Title: "Ship it", AssignedTo: "user-1"},
+	})
+	s.Close()
+
+	sort.Strings(got)
+	if len(got) != 2 || got[0] != "Assignee:user-1" || got[1] != "Watcher:user-3" {
+		t.Fatalf("messages = %v, want the assignee once and the other watcher", got)
+	}
+}
+
+type fakeLanguages map[string]string
+
+func (f fakeLanguages) RecipientLanguages(ctx context.Context, userIDs []string) (map[string]string, error) {
+	return f, nil
+}
+
+func TestSendNotificationInRecipientLanguage(t *testing.T) {
+	var mu sync.Mutex
+	got := make(map[string]DiscordEmbed)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload DiscordPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("decode payload: %v", err)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		embed := payload.Embeds[0]
+		got[embed.Fields[2].Value] = embed
+	}))
+	t.Cleanup(server.Close)
+
+	s, _ := NewService(NotificationConfig{
+		DiscordWebhookURL: server.URL,
+		DefaultChannels:   []NotificationChannel{ChannelDiscord},
+	}, zap.NewNop())
+	s.SetWatcherLookup(fakeWatchers{"user-2", "user-3"})
+	s.SetLanguageLookup(fakeLanguages{"user-1": "de-AT", "user-2": "fr", "user-3": "ja"})
+
+	s.SendNotification(context.Background(), NotificationEvent{
+		Type: NotificationTypeTaskUpdated,
+		Task: models.Task{ID: "task-1", Title: "Ship it", AssignedTo: "user-1", Status: models.StatusInProgress},
+	})
+	s.Close()
+
+	tests := []struct {
+		user, role, status, description string
+	}{
+		{"user-1", "Zuständig", "in Arbeit", "Die Aufgabe wurde geändert."},
+		{"user-2", "Observateur", "en cours", "La tâche a été mise à jour."},
+		{"user-3", "Watcher", "in progress", "The task has been updated."},
+	}
+	for _, tt := range tests {
+		embed := got[tt.user]
+		if embed.Fields[2].Name != tt.role || embed.Fields[1].Value != tt.status || embed.Description != tt.description {
+			t.Errorf("%s got %+v, want %q, %q and %q", tt.user, embed, tt.role, tt.status, tt.description)
+		}
+	}
+}
+
+// recordingSender is a channel driver that keeps what it is asked to send
+type recordingSender struct {
+	mu      sync.Mutex
+	targets []string
+	bodies  []string
+}
+
+func (r *recordingSender) Render(event NotificationEvent, rcpt Recipient) ([]byte, error) {
+	return []byte(event.Task.Title + " for " + rcpt.UserID), nil
+}
+
+func (r *recordingSender) Send(ctx context.Context, payload []byte, target string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.targets = append(r.targets, target)
+	r.bodies = append(r.bodies, string(payload))
+	return nil
+}
+
+func (r *recordingSender) Validate(target string) error                  { return nil }
+func (r *recordingSender) Ping(ctx context.Context, target string) error { return nil }
+
+func TestRegisteredChannelIsDispatched(t *testing.T) {
+	pager := &recordingSender{}
+	s, _ := NewService(NotificationConfig{
+		DefaultChannels: []NotificationChannel{"pager"},
+		Targets:         map[NotificationChannel]string{"pager": "oncall"},
+	}, zap.NewNop())
+	s.Channels().Register("pager", pager)
+
+	s.SendNotification(context.Background(), NotificationEvent{
+		Type: NotificationTypeTaskUpdated,
+		Task: models.Task{Title: "Ship it", AssignedTo: "user-1"},
+	})
+	s.Close()
+
+	if len(pager.bodies) != 1 || pager.targets[0] != "oncall" || pager.bodies[0] != "Ship it for user-1" {
+		t.Fatalf("sent %v to %v, want one rendered message to the configured target", pager.bodies, pager.targets)
+	}
+	if !s.ChannelConfigured("pager") {
+		t.Error("the pager channel should count as configured")
+	}
+}
+
+func TestEscalateMissedDeadlineOnce(t *testing.T) {
+	pager := &recordingSender{}
+	s, _ := NewService(NotificationConfig{
+		DefaultChannels: []NotificationChannel{"pager"},
+		Targets:         map[NotificationChannel]string{"pager": "oncall"},
+	}, zap.NewNop())
+	s.Channels().Register("pager", pager)
+	s.SetDeduplicator(NewMemoryDeduplicator(time.Hour))
+
+	missed := models.Task{ID: "task-1", Title: "Renew certificates", AssignedTo: "user-1",
+		DeadlineType: models.DeadlineHard, DueDate: time.Now().Add(-time.Hour)}
+	s.EscalateMissedDeadline(context.Background(), missed)
+	s.EscalateMissedDeadline(context.Background(), missed)
+	s.Close()
+
+	if len(pager.bodies) != 1 {
+		t.Fatalf("sent %v, want the missed deadline escalated once", pager.bodies)
+	}
+}
@@ -0,0 +1,84 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"go.uber.org/zap"
+)
+
+var uiTemplate = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}} API</title>
+<link rel="stylesheet" href="{{.Assets}}/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="{{.Assets}}/swagger-ui-bundle.js"></script>
+<script>
+window.ui = SwaggerUIBundle({ url: {{.SpecURL}}, dom_id: "#swagger-ui" });
+</script>
+</body>
+</html>
+`))
+
+type Handler struct {
+	spec   *Spec
+	routes func() gin.RoutesInfo
+	assets string
+	logger *zap.Logger
+
+	once     sync.Once
+	document []byte
+	err      error
+}
+
+// NewHandler serves the document built from routes, which is called once on
+// the first request so that every route has been registered by then.
+// Swagger UI is loaded from assets, a copy of the swagger-ui-dist package.
+func NewHandler(spec *Spec, routes func() gin.RoutesInfo, assets string, logger *zap.Logger) *Handler {
+	return &Handler{
+		spec:   spec,
+		routes: routes,
+		assets: assets,
+		logger: logger,
+	}
+}
+
+// Document serves the OpenAPI document as JSON
+func (h *Handler) Document(c *gin.Context) {
+	h.once.Do(func() {
+		h.document, h.err = json.Marshal(h.spec.Build(h.routes()))
+	})
+	if h.err != nil {
+		h.logger.Error("Failed to build OpenAPI document", zap.Error(h.err))
+		_ = c.Error(common.NewInternalServerError("failed to build API document"))
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", h.document)
+}
+
+// UI serves Swagger UI for the document at specURL
+func (h *Handler) UI(specURL string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var page bytes.Buffer
+		if err := uiTemplate.Execute(&page, map[string]string{
+			"Title":   h.spec.title,
+			"Assets":  h.assets,
+			"SpecURL": specURL,
+		}); err != nil {
+			h.logger.Error("Failed to render API docs", zap.Error(err))
+			c.String(http.StatusInternalServerError, "failed to render API docs")
+			return
+		}
+		c.Data(http.StatusOK, "text/html; charset=utf-8", page.Bytes())
+	}
+}
@@ -0,0 +1,524 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Operation annotates the handler of one or more routes
+type Operation struct {
+	Summary     string
+	Description string
+	// Tags default to the first path segment after /api
+	Tags []string
+	// Public operations need no bearer token
+	Public bool
+
+	// Query holds structs whose form tags are bound from the query string
+	Query []any
+	// Request is a value of the JSON request body type
+	Request any
+	// Status is the success status, 200 when zero
+	Status int
+	// Response is a value of the JSON response body type; nil for none
+	Response any
+	// Errors are statuses the handler answers besides the ones every
+	// operation may (401, 500, and 503 for writes)
+	Errors []int
+}
+
+// Fields describes a JSON object built ad hoc, such as gin.H{"tasks": tasks},
+// by example values of its fields
+type Fields map[string]any
+
+// Spec collects operation annotations and turns the router's routes into an
+// OpenAPI 3 document
+type Spec struct {
+	title   string
+	version string
+
+	operations map[string]Operation
+	enums      map[reflect.Type][]string
+	extra      map[reflect.Type]Fields
+	public     map[string]bool
+}
+
+func New(title, version string) *Spec {
+	return &Spec{
+		title:      title,
+		version:    version,
+		operations: make(map[string]Operation),
+		enums:      make(map[reflect.Type][]string),
+		extra:      make(map[reflect.Type]Fields),
+		public:     make(map[string]bool),
+	}
+}
+
+// Describe annotates every route served by handler
+func (s *Spec) Describe(handler gin.HandlerFunc, op Operation) {
+	s.operations[handlerName(handler)] = op
+}
+
+// Enum lists the values allowed for the type of value, such as a string
+// type with a set of constants
+func (s *Spec) Enum(value any, values ...string) {
+	s.enums[reflect.TypeOf(value)] = values
+}
+
+// Extend adds fields to the schema of the struct type of value, for fields
+// its MarshalJSON adds
+func (s *Spec) Extend(value any, fields Fields) {
+	s.extra[reflect.TypeOf(value)] = fields
+}
+
+// Public marks API routes, as gin names them, that need no bearer token
+// without annotating their handlers
+func (s *Spec) Public(paths ...string) {
+	for _, path := range paths {
+		s.public[path] = true
+	}
+}
+
+// handlerName names a handler the way gin names it in gin.RouteInfo
+func handlerName(handler gin.HandlerFunc) string {
+	return runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name()
+}
+
+// Build documents every route, using the annotation of its handler where
+// there is one
+func (s *Spec) Build(routes gin.RoutesInfo) *Document {
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info:    Info{Title: s.title, Version: s.version},
+		Paths:   make(map[string]map[string]*operation),
+		Components: components{
+			Schemas: make(map[string]*Schema),
+			SecuritySchemes: map[string]securityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+		Security: []map[string][]string{{"bearerAuth": {}}},
+	}
+	b := &builder{spec: s, schemas: doc.Components.Schemas}
+	doc.Components.Schemas["Error"] = errorSchema(b)
+
+	for _, route := range routes {
+		path, params := convertPath(route.Path)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*operation)
+		}
+		op, annotated := s.operations[route.Handler]
+		doc.Paths[path][strings.ToLower(route.Method)] = b.operation(route, op, annotated, params)
+	}
+	return doc
+}
+
+func (b *builder) operation(route gin.RouteInfo, op Operation, annotated bool, params []string) *operation {
+	api := strings.HasPrefix(route.Path, "/api/")
+	out := &operation{
+		OperationID: operationID(route.Method, route.Path),
+		Summary:     op.Summary,
+		Description: op.Description,
+		Tags:        op.Tags,
+		Responses:   make(map[string]*response),
+	}
+	if len(out.Tags) == 0 {
+		out.Tags = []string{defaultTag(route.Path)}
+	}
+	public := op.Public || b.spec.public[route.Path] || !api
+	if public {
+		out.Security = &[]map[string][]string{}
+	}
+
+	for _, name := range params {
+		out.Parameters = append(out.Parameters, parameter{
+			Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"},
+		})
+	}
+	for _, query := range op.Query {
+		out.Parameters = append(out.Parameters, b.queryParameters(reflect.TypeOf(query))...)
+	}
+	if op.Request != nil {
+		out.RequestBody = &requestBody{
+			Required: true,
+			Content:  jsonContent(b.schema(reflect.TypeOf(op.Request))),
+		}
+	}
+
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := &response{Description: http.StatusText(status)}
+	if op.Response != nil {
+		success.Content = jsonContent(b.responseSchema(op.Response))
+	}
+	out.Responses[strconv.Itoa(status)] = success
+
+	if !api {
+		return out
+	}
+	errs := append([]int{http.StatusInternalServerError}, op.Errors...)
+	if !public {
+		errs = append(errs, http.StatusUnauthorized)
+	}
+	switch route.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+	default:
+		errs = append(errs, http.StatusServiceUnavailable)
+		if !annotated {
+			errs = append(errs, http.StatusBadRequest)
+		}
+	}
+	for _, code := range errs {
+		out.Responses[strconv.Itoa(code)] = &response{
+			Description: http.StatusText(code),
+			Content:     jsonContent(&Schema{Ref: "#/components/schemas/Error"}),
+		}
+	}
+	return out
+}
+
+// errorSchema is the body of every error: its code, message and details,
+// the rejected fields of an invalid request, and the request's ID to quote
+// when reporting it
+func errorSchema(b *builder) *Schema {
+	type fieldError struct {
+		Field   string `json:"field" binding:"required"`
+		Rule    string `json:"rule" binding:"required"`
+		Message string `json:"message" binding:"required"`
+	}
+	type appError struct {
+		Code      string       `json:"code" binding:"required"`
+		Message   string       `json:"message" binding:"required"`
+		Details   string       `json:"details,omitempty"`
+		RequestID string       `json:"request_id,omitempty"`
+		Fields    []fieldError `json:"fields,omitempty"`
+	}
+	b.schemas["AppError"] = b.structSchema(reflect.TypeOf(appError{}))
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"error":       {Ref: "#/components/schemas/AppError"},
+			"retry_after": {Type: "string", Description: "How long to wait after a rate limit"},
+		},
+		Required: []string{"error"},
+	}
+}
+
+var pathParam = regexp.MustCompile(`[:*]([^/]+)`)
+
+// convertPath turns a gin path into an OpenAPI one and lists its parameters
+func convertPath(path string) (string, []string) {
+	var params []string
+	converted := pathParam.ReplaceAllStringFunc(path, func(segment string) string {
+		params = append(params, segment[1:])
+		return "{" + segment[1:] + "}"
+	})
+	return converted, params
+}
+
+// operationID derives a stable identifier from the method and path, such as
+// getTasksByIdChecklist for GET /api/tasks/:id/checklist
+func operationID(method, path string) string {
+	var id strings.Builder
+	id.WriteString(strings.ToLower(method))
+	for _, segment := range strings.Split(strings.TrimPrefix(path, "/api"), "/") {
+		by := strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*")
+		if by {
+			id.WriteString("By")
+			segment = segment[1:]
+		}
+		for _, word := range strings.FieldsFunc(segment, func(r rune) bool {
+			return r == '-' || r == '_' || r == '.'
+		}) {
+			id.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return id.String()
+}
+
+func defaultTag(path string) string {
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(path, "/api"), "/"), "/")
+	if segments[0] == "admin" && len(segments) > 1 {
+		return segments[1]
+	}
+	if segments[0] == "" {
+		return "root"
+	}
+	return segments[0]
+}
+
+func jsonContent(schema *Schema) map[string]mediaType {
+	return map[string]mediaType{"application/json": {Schema: schema}}
+}
+
+// builder turns Go types into schemas, adding named structs to the
+// document's components
+type builder struct {
+	spec    *Spec
+	schemas map[string]*Schema
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	durationType  = reflect.TypeOf(time.Duration(0))
+	rawType       = reflect.TypeOf(json.RawMessage{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+func (b *builder) responseSchema(value any) *Schema {
+	if fields, ok := value.(Fields); ok {
+		schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		for name, field := range fields {
+			schema.Properties[name] = b.responseSchema(field)
+			schema.Required = append(schema.Required, name)
+		}
+		sort.Strings(schema.Required)
+		return schema
+	}
+	return b.schema(reflect.TypeOf(value))
+}
+
+func (b *builder) schema(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+	if values, ok := b.spec.enums[t]; ok {
+		return &Schema{Type: "string", Enum: values}
+	}
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case durationType:
+		return &Schema{Type: "integer", Format: "int64", Description: "Nanoseconds"}
+	case rawType:
+		return &Schema{}
+	}
+	if t.Kind() != reflect.Pointer && t.Kind() != reflect.Struct && t.Implements(marshalerType) {
+		// Custom encodings, such as JSON columns, may take any shape
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema := b.schema(t.Elem())
+		if schema.Ref != "" {
+			return schema
+		}
+		nullable := *schema
+		nullable.Nullable = true
+		return &nullable
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: b.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		name := componentName(t)
+		if _, ok := b.schemas[name]; !ok {
+			// Reserve the name first so recursive types end in a reference
+			b.schemas[name] = &Schema{}
+			*b.schemas[name] = *b.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	default:
+		return &Schema{}
+	}
+}
+
+var unsafeName = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// componentName qualifies a type with its package, such as models.Task
+func componentName(t reflect.Type) string {
+	pkg := t.PkgPath()
+	if i := strings.LastIndex(pkg, "/"); i >= 0 {
+		pkg = pkg[i+1:]
+	}
+	name := t.Name()
+	if pkg != "" {
+		name = pkg + "." + name
+	}
+	return unsafeName.ReplaceAllString(name, "_")
+}
+
+func (b *builder) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	b.addFields(schema, t)
+	for name, field := range b.spec.extra[t] {
+		schema.Properties[name] = b.responseSchema(field)
+		schema.Required = append(schema.Required, name)
+	}
+	sort.Strings(schema.Required)
+	return schema
+}
+
+func (b *builder) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		fieldType := field.Type
+		if field.Anonymous && name == "" {
+			if fieldType.Kind() == reflect.Pointer {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct {
+				b.addFields(schema, fieldType)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		var property *Schema
+		if strings.Contains(opts, "string") {
+			property = &Schema{Type: "string"}
+		} else {
+			property = b.schema(fieldType)
+		}
+		if applyBinding(property, fieldType, field.Tag.Get("binding")) {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = property
+	}
+}
+
+// applyBinding copies validation rules onto the schema of a field, and
+// reports whether the field is required
+func applyBinding(schema *Schema, t reflect.Type, binding string) bool {
+	if binding == "" || schema.Ref != "" {
+		return binding != "" && strings.Contains(binding, "required")
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	required := false
+	for _, rule := range strings.Split(binding, ",") {
+		name, arg, _ := strings.Cut(rule, "=")
+		switch name {
+		case "dive":
+			// Later rules apply to elements
+			return required
+		case "required":
+			required = true
+		case "oneof":
+			schema.Enum = strings.Fields(arg)
+		case "email":
+			schema.Format = "email"
+		case "url":
+			schema.Format = "uri"
+		case "uuid":
+			schema.Format = "uuid"
+		case "min", "max", "gte", "lte":
+			n, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				continue
+			}
+			lower := name == "min" || name == "gte"
+			switch t.Kind() {
+			case reflect.String:
+				count := int(n)
+				if lower {
+					schema.MinLength = &count
+				} else {
+					schema.MaxLength = &count
+				}
+			case reflect.Slice, reflect.Array, reflect.Map:
+				count := int(n)
+				if lower {
+					schema.MinItems = &count
+				} else {
+					schema.MaxItems = &count
+				}
+			default:
+				if lower {
+					schema.Minimum = &n
+				} else {
+					schema.Maximum = &n
+				}
+			}
+		}
+	}
+	return required
+}
+
+// queryParameters lists the fields of a struct bound with form tags
+func (b *builder) queryParameters(t reflect.Type) []parameter {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	var params []parameter
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("form")
+		if tag == "" || tag == "-" || !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		schema := b.schema(fieldType)
+		if def, ok := strings.CutPrefix(opts, "default="); ok {
+			schema.Default = defaultValue(fieldType, def)
+		}
+		required := applyBinding(schema, fieldType, field.Tag.Get("binding"))
+		params = append(params, parameter{Name: name, In: "query", Required: required, Schema: schema})
+	}
+	return params
+}
+
+// defaultValue types a form default as its field
+func defaultValue(t reflect.Type, value string) any {
+	switch t.Kind() {
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n
+		}
+	case reflect.Float32, reflect.Float64:
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	}
+	return value
+}
@@ -0,0 +1,168 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type state string
+
+type item struct {
+	ID       string     `json:"id"`
+	State    state      `json:"state"`
+	Parent   *item      `json:"parent,omitempty"`
+	DoneAt   *time.Time `json:"done_at,omitempty"`
+	internal string
+	Hidden   string `json:"-"`
+}
+
+type createItemRequest struct {
+	Title string   `json:"title" binding:"required,max=200"`
+	Tags  []string `json:"tags" binding:"max=10,dive,max=50"`
+	Kind  string   `json:"kind" binding:"omitempty,oneof=bug chore"`
+}
+
+type itemFilter struct {
+	State *string `form:"state"`
+	Page  int     `form:"page,default=1"`
+}
+
+type itemHandler struct{}
+
+func (itemHandler) List(c *gin.Context)   {}
+func (itemHandler) Create(c *gin.Context) {}
+func (itemHandler) Get(c *gin.Context)    {}
+
+func buildDocument(t *testing.T) *Document {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	var h itemHandler
+	router := gin.New()
+	router.GET("/api/items", h.List)
+	router.POST("/api/items", h.Create)
+	router.GET("/api/items/:id", h.Get)
+	router.GET("/api/version", func(c *gin.Context) {})
+	router.GET("/healthz", func(c *gin.Context) {})
+
+	spec := New("Items", "v1")
+	spec.Enum(state(""), "open", "done")
+	spec.Extend(item{}, Fields{"is_late": false})
+	spec.Public("/api/version")
+	spec.Describe(h.List, Operation{
+		Summary:  "List items",
+		Query:    []any{itemFilter{}},
+		Response: Fields{"items": []item{}},
+	})
+	spec.Describe(h.Create, Operation{
+		Request:  createItemRequest{},
+		Status:   http.StatusCreated,
+		Response: item{},
+		Errors:   []int{http.StatusBadRequest},
+	})
+	return spec.Build(router.Routes())
+}
+
+func TestBuildDescribesAnnotatedRoutes(t *testing.T) {
+	doc := buildDocument(t)
+
+	list := doc.Paths["/api/items"]["get"]
+	if list == nil || list.Summary != "List items" || list.Tags[0] != "items" {
+		t.Fatalf("list operation = %+v", list)
+	}
+	if len(list.Parameters) != 2 || list.Parameters[1].Name != "page" || list.Parameters[1].Schema.Default != int64(1) {
+		t.Fatalf("query parameters = %+v", list.Parameters)
+	}
+	items := list.Responses["200"].Content["application/json"].Schema.Properties["items"]
+	if items.Items.Ref != "#/components/schemas/openapi.item" {
+		t.Fatalf("items schema = %+v", items)
+	}
+
+	create := doc.Paths["/api/items"]["post"]
+	for _, code := range []string{"201", "400", "401", "500", "503"} {
+		if create.Responses[code] == nil {
+			t.Fatalf("create lacks a %s response: %v", code, create.Responses)
+		}
+	}
+	request := doc.Components.Schemas["openapi.createItemRequest"]
+	if len(request.Required) != 1 || request.Required[0] != "title" || *request.Properties["title"].MaxLength != 200 {
+		t.Fatalf("request schema = %+v", request)
+	}
+	if tags := request.Properties["tags"]; *tags.MaxItems != 10 || tags.Items.MaxLength != nil {
+		t.Fatalf("tags schema = %+v", tags)
+	}
+	if kind := request.Properties["kind"]; strings.Join(kind.Enum, ",") != "bug,chore" {
+		t.Fatalf("kind schema = %+v", kind)
+	}
+
+	get := doc.Paths["/api/items/{id}"]["get"]
+	if get == nil || get.OperationID != "getItemsById" || get.Parameters[0].In != "path" {
+		t.Fatalf("get operation = %+v", get)
+	}
+}
+
+func TestBuildReflectsStructs(t *testing.T) {
+	doc := buildDocument(t)
+	schema := doc.Components.Schemas["openapi.item"]
+	for _, name := range []string{"id", "state", "parent", "done_at", "is_late"} {
+		if schema.Properties[name] == nil {
+			t.Fatalf("item schema lacks %s: %v", name, schema.Properties)
+		}
+	}
+	if len(schema.Properties) != 5 {
+		t.Fatalf("item schema has unexported or hidden fields: %v", schema.Properties)
+	}
+	if strings.Join(schema.Properties["state"].Enum, ",") != "open,done" {
+		t.Fatalf("state schema = %+v", schema.Properties["state"])
+	}
+	if schema.Properties["parent"].Ref != "#/components/schemas/openapi.item" {
+		t.Fatalf("recursive field = %+v", schema.Properties["parent"])
+	}
+	if done := schema.Properties["done_at"]; done.Format != "date-time" || !done.Nullable {
+		t.Fatalf("done_at schema = %+v", done)
+	}
+}
+
+func TestBuildMarksPublicRoutes(t *testing.T) {
+	doc := buildDocument(t)
+	for _, path := range []string{"/api/version", "/healthz"} {
+		op := doc.Paths[path]["get"]
+		if op.Security == nil || len(*op.Security) != 0 || op.Responses["401"] != nil {
+			t.Fatalf("%s is not public: %+v", path, op)
+		}
+	}
+	if op := doc.Paths["/api/items/{id}"]["get"]; op.Security != nil || op.Responses["401"] == nil {
+		t.Fatalf("authenticated route marked public: %+v", op)
+	}
+}
+
+func TestHandlerServesDocumentAndUI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	h := NewHandler(New("Items", "v1"), router.Routes, "https://assets.example.com", zap.NewNop())
+	router.GET("/api/openapi.json", h.Document)
+	router.GET("/docs", h.UI("/api/openapi.json"))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+	var doc Document
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil || doc.OpenAPI != "3.0.3" {
+		t.Fatalf("document = %s, err = %v", w.Body.String(), err)
+	}
+	if doc.Paths["/docs"] == nil || doc.Paths["/api/openapi.json"] == nil {
+		t.Fatalf("document lacks routes registered after the handler: %v", doc.Paths)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	if body := w.Body.String(); !strings.Contains(body, "https://assets.example.com/swagger-ui-bundle.js") ||
+		!strings.Contains(body, "/api/openapi.json") {
+		t.Fatalf("UI page = %s", body)
+	}
+}
@@ -0,0 +1,74 @@
+package preferences
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"go.uber.org/zap"
+)
+
+type Handler struct {
+	service *Service
+	logger  *zap.Logger
+}
+
+func NewHandler(service *Service, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *Handler) Get(c *gin.Context) {
+	resp, err := h.service.Get(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		h.logger.Error("Failed to load preferences", zap.Error(err))
+		_ = c.Error(common.NewInternalServerError("failed to load preferences"))
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// UpdateUser stores the caller's own preferences
+func (h *Handler) UpdateUser(c *gin.Context) {
+	h.update(c, c.GetString("user_id"))
+}
+
+// UpdateOrg stores the organization defaults; routes restrict it to
+// administrators
+func (h *Handler) UpdateOrg(c *gin.Context) {
+	h.update(c, "")
+}
+
+func (h *Handler) update(c *gin.Context, userID string) {
+	var req UpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(common.NewValidationError(err))
+		return
+	}
+
+	pref, err := h.service.Update(c.Request.Context(), userID, req, c.GetString("user_id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidPreferences):
+			_ = c.Error(common.NewInvalidInputError(err.Error()))
+		case errors.Is(err, ErrVersionConflict):
+			// The current preferences let the client merge and retry
+			// without another request
+			current, loadErr := h.service.current(c.Request.Context(), userID)
+			if loadErr != nil {
+				h.logger.Error("Failed to load preferences", zap.Error(loadErr))
+			}
+			_ = c.Error(common.NewConflictError(err.Error()).With("current", current))
+		default:
+			h.logger.Error("Failed to store preferences", zap.Error(err))
+			_ = c.Error(common.NewInternalServerError("failed to store preferences"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, pref)
+}
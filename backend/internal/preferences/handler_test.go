@@ -0,0 +1,154 @@
+package preferences
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestRouter(t *testing.T) (*gin.Engine, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := NewHandler(NewService(db, zap.NewNop()), zap.NewNop())
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(common.ErrorHandler(zap.NewNop()))
+	router.Use(func(c *gin.Context) { c.Set("user_id", "user-1") })
+	router.GET("/preferences", handler.Get)
+	router.PUT("/preferences", handler.UpdateUser)
+	return router, mock
+}
+
+func put(router *gin.Engine, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPut, "/preferences", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestGetMergesUserOverOrg(t *testing.T) {
+	router, mock := newTestRouter(t)
+	mock.ExpectQuery(`SELECT \* FROM "preferences"`).
+		WithArgs(ScopeOrg, ScopeUser, "user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"scope", "user_id", "data", "version"}).
+			AddRow(ScopeOrg, "", `{"default_view":"board","default_filters":{"status":"todo"},"boards":{"default":{"swimlane_by":"priority"}}}`, 3).
+			AddRow(ScopeUser, "user-1", `{"default_view":"list","boards":{"ops":{"collapsed_swimlanes":["low"]}}}`, 1))
+
+	req := httptest.NewRequest(http.MethodGet, "/preferences", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	body := w.Body.String()
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, body)
+	}
+	for _, want := range []string{
+		`"effective":{"default_view":"list","default_filters":{"status":"todo"}`,
+		`"default":{"swimlane_by":"priority"}`,
+		`"ops":{"collapsed_swimlanes":["low"]}`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body %s does not contain %s", body, want)
+		}
+	}
+}
+
+func TestFirstUpdateInserts(t *testing.T) {
+	router, mock := newTestRouter(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "preferences" .* ON CONFLICT DO NOTHING`).
+		WithArgs(ScopeUser, "user-1", `{"default_view":"board"}`, 1, "user-1", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(time.Now()))
+	mock.ExpectCommit()
+
+	w := put(router, `{"version": 0, "preferences": {"default_view": "board"}}`)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"version":1`) {
+		t.Fatalf("status = %d, body %s, want version 1", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpdateConflictsOnStaleVersion(t *testing.T) {
+	router, mock := newTestRouter(t)
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "preferences" SET .* WHERE scope = \$5 AND user_id = \$6 AND version = \$7`).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "user-1", 3, ScopeUser, "user-1", 2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`SELECT \* FROM "preferences" WHERE scope = \$1 AND user_id = \$2`).
+		WithArgs(ScopeUser, "user-1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"scope", "user_id", "data", "version"}).
+			AddRow(ScopeUser, "user-1", `{"default_view":"calendar"}`, 5))
+
+	w := put(router, `{"version": 2, "preferences": {"default_view": "board"}}`)
+	if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), `"version":5`) {
+		t.Fatalf("status = %d, body %s, want a conflict with the current version", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpdateValidatesPreferences(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	for _, body := range []string{
+		`{"preferences": {"default_view": "board"}}`,
+		`{"version": 0, "preferences": {"default_view": "gantt"}}`,
+		`{"version": 0, "preferences": {"default_filters": {"title": "x"}}}`,
+		`{"version": 0, "preferences": {"boards": {"default": {"swimlane_by": "due_date"}}}}`,
+	} {
+		if w := put(router, body); w.Code != http.StatusBadRequest {
+			t.Errorf("body %s: status = %d, want 400", body, w.Code)
+		}
+	}
+}
+
+func TestRecipientLanguagesFallBackToOrg(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock.ExpectQuery(`SELECT \* FROM "preferences" WHERE \(scope = \$1 AND user_id = ''\) OR \(scope = \$2 AND user_id IN \(\$3,\$4,\$5\)\)`).
+		WithArgs(ScopeOrg, ScopeUser, "user-1", "user-2", "user-3").
+		WillReturnRows(sqlmock.NewRows([]string{"scope", "user_id", "data", "version"}).
+			AddRow(ScopeOrg, "", `{"language":"de"}`, 1).
+			AddRow(ScopeUser, "user-1", `{"language":"pt-BR"}`, 2).
+			AddRow(ScopeUser, "user-2", `{"default_view":"list"}`, 1))
+
+	languages, err := NewService(db, zap.NewNop()).RecipientLanguages(context.Background(), []string{"user-1", "user-2", "user-3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(languages) != 3 || languages["user-1"] != "pt-BR" || languages["user-2"] != "de" || languages["user-3"] != "de" {
+		t.Fatalf("languages = %v, want user-1's own and the organization's for the others", languages)
+	}
+}
@@ -0,0 +1,40 @@
+package preferences
+
+import (
+	"errors"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+)
+
+type (
+	Preference    = models.Preference
+	UIPreferences = models.UIPreferences
+	BoardLayout   = models.BoardLayout
+)
+
+const (
+	ScopeUser = "user"
+	ScopeOrg  = "org"
+)
+
+var (
+	ErrInvalidPreferences = errors.New("invalid preferences")
+	// ErrVersionConflict means the preferences changed since the client
+	// read them
+	ErrVersionConflict = errors.New("preferences were changed by another session")
+)
+
+// UpdateRequest replaces the stored preferences. Version is the version the
+// client last read, 0 if it has never stored any.
+type UpdateRequest struct {
+	Version     *int          `json:"version" binding:"required,min=0"`
+	Preferences UIPreferences `json:"preferences"`
+}
+
+// Response is every preference layer that applies to a user. Effective is
+// the organization defaults with the user's own choices on top.
+type Response struct {
+	Org       *Preference   `json:"org"`
+	User      *Preference   `json:"user"`
+	Effective UIPreferences `json:"effective"`
+}
@@ -0,0 +1,240 @@
+package preferences
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	maxBoards      = 20
+	maxBoardName   = 100
+	maxColumns     = 20
+	maxSwimlanes   = 100
+	maxLayoutEntry = 100
+	maxFilterValue = 200
+)
+
+var (
+	views = map[string]bool{"list": true, "board": true, "calendar": true}
+	// filterKeys are the task list query parameters a landing page may
+	// preset
+	filterKeys = map[string]bool{
+		"status": true, "priority": true, "assigned_to": true, "created_by": true,
+		"sort_by": true, "sort_order": true, "page_size": true,
+	}
+	swimlaneFields = map[string]bool{"": true, "assignee": true, "priority": true, "project": true, "status": true}
+	// languageTag accepts BCP 47 style tags such as "de" or "pt-BR"
+	languageTag = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8}){0,3}$`)
+)
+
+// Service stores UI preferences per user and for the organization
+type Service struct {
+	db     *gorm.DB
+	logger *zap.Logger
+}
+
+func NewService(db *gorm.DB, logger *zap.Logger) *Service {
+	return &Service{db: db, logger: logger}
+}
+
+// Get returns the organization's and the user's preferences. Layers that
+// were never stored are nil.
+func (s *Service) Get(ctx context.Context, userID string) (*Response, error) {
+	var stored []Preference
+	if err := s.db.WithContext(ctx).
+		Where("(scope = ? AND user_id = '') OR (scope = ? AND user_id = ?)", ScopeOrg, ScopeUser, userID).
+		Find(&stored).Error; err != nil {
+		return nil, fmt.Errorf("failed to load preferences: %w", err)
+	}
+
+	resp := &Response{}
+	for i := range stored {
+		if stored[i].Scope == ScopeOrg {
+			resp.Org = &stored[i]
+		} else {
+			resp.User = &stored[i]
+		}
+	}
+	resp.Effective = merge(resp.Org, resp.User)
+	return resp, nil
+}
+
+// RecipientLanguages returns the language of each user: their own, or the
+// organization's default. Users with neither are left out.
+func (s *Service) RecipientLanguages(ctx context.Context, userIDs []string) (map[string]string, error) {
+	var stored []Preference
+	if err := s.db.WithContext(ctx).
+		Where("(scope = ? AND user_id = '') OR (scope = ? AND user_id IN ?)", ScopeOrg, ScopeUser, userIDs).
+		Find(&stored).Error; err != nil {
+		return nil, fmt.Errorf("failed to load preferences: %w", err)
+	}
+
+	var orgLanguage string
+	users := make(map[string]string, len(stored))
+	for _, pref := range stored {
+		if pref.Scope == ScopeOrg {
+			orgLanguage = pref.Data.Language
+		} else if pref.Data.Language != "" {
+			users[pref.UserID] = pref.Data.Language
+		}
+	}
+	languages := make(map[string]string, len(userIDs))
+	for _, id := range userIDs {
+		if lang, ok := users[id]; ok {
+			languages[id] = lang
+		} else if orgLanguage != "" {
+			languages[id] = orgLanguage
+		}
+	}
+	return languages, nil
+}
+
+// Update stores prefs for the user, or for the organization if userID is
+// empty, if the stored version is still the one the client read. Otherwise
+// it returns ErrVersionConflict and the client should reload and reapply
+// its change.
+func (s *Service) Update(ctx context.Context, userID string, req UpdateRequest, updatedBy string) (*Preference, error) {
+	if err := validate(req.Preferences); err != nil {
+		return nil, err
+	}
+
+	scope := ScopeUser
+	if userID == "" {
+		scope = ScopeOrg
+	}
+	pref := &Preference{
+		Scope:     scope,
+		UserID:    userID,
+		Data:      req.Preferences,
+		Version:   *req.Version + 1,
+		UpdatedBy: updatedBy,
+		UpdatedAt: time.Now(),
+	}
+
+	db := s.db.WithContext(ctx)
+	var result *gorm.DB
+	if *req.Version == 0 {
+		// A concurrent first write wins; the other conflicts
+		result = db.Clauses(clause.OnConflict{DoNothing: true}).Create(pref)
+	} else {
+		// Map updates skip the column serializer, so the JSON is encoded here
+		data, err := json.Marshal(pref.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode preferences: %w", err)
+		}
+		result = db.Model(&Preference{}).
+			Where("scope = ? AND user_id = ? AND version = ?", scope, userID, *req.Version).
+			Updates(map[string]interface{}{
+				"data":       string(data),
+				"version":    pref.Version,
+				"updated_by": updatedBy,
+				"updated_at": pref.UpdatedAt,
+			})
+	}
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to store preferences: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrVersionConflict
+	}
+	return pref, nil
+}
+
+// validate keeps preferences small and limited to things the frontend
+// knows how to apply
+func validate(p UIPreferences) error {
+	if p.DefaultView != "" && !views[p.DefaultView] {
+		return fmt.Errorf("%w: default_view must be list, board or calendar", ErrInvalidPreferences)
+	}
+	if p.Language != "" && !languageTag.MatchString(p.Language) {
+		return fmt.Errorf("%w: language must be a language tag such as de or pt-BR", ErrInvalidPreferences)
+	}
+	for key, value := range p.DefaultFilters {
+		if !filterKeys[key] {
+			return fmt.Errorf("%w: unknown default filter %q", ErrInvalidPreferences, key)
+		}
+		if len(value) > maxFilterValue {
+			return fmt.Errorf("%w: default filter %q is longer than %d characters", ErrInvalidPreferences, key, maxFilterValue)
+		}
+	}
+
+	if len(p.Boards) > maxBoards {
+		return fmt.Errorf("%w: at most %d board layouts", ErrInvalidPreferences, maxBoards)
+	}
+	for name, board := range p.Boards {
+		if name == "" || len(name) > maxBoardName {
+			return fmt.Errorf("%w: board names must be 1 to %d characters", ErrInvalidPreferences, maxBoardName)
+		}
+		if !swimlaneFields[board.SwimlaneBy] {
+			return fmt.Errorf("%w: board %q: swimlane_by must be assignee, priority, project or status", ErrInvalidPreferences, name)
+		}
+		if len(board.ColumnOrder) > maxColumns || len(board.CollapsedSwimlanes) > maxSwimlanes {
+			return fmt.Errorf("%w: board %q has too many columns or swimlanes", ErrInvalidPreferences, name)
+		}
+		for _, entries := range [][]string{board.ColumnOrder, board.CollapsedSwimlanes} {
+			for _, entry := range entries {
+				if len(entry) > maxLayoutEntry {
+					return fmt.Errorf("%w: board %q: entries must be at most %d characters", ErrInvalidPreferences, name, maxLayoutEntry)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// merge applies the user's preferences over the organization's. Filters
+// and boards are merged by key, so a user who saved one board still gets
+// the organization's layout for the others.
+func merge(org, user *Preference) UIPreferences {
+	var effective UIPreferences
+	for _, layer := range []*Preference{org, user} {
+		if layer == nil {
+			continue
+		}
+		if layer.Data.DefaultView != "" {
+			effective.DefaultView = layer.Data.DefaultView
+		}
+		if layer.Data.Language != "" {
+			effective.Language = layer.Data.Language
+		}
+		if len(layer.Data.DefaultFilters) > 0 {
+			if effective.DefaultFilters == nil {
+				effective.DefaultFilters = make(map[string]string)
+			}
+			for key, value := range layer.Data.DefaultFilters {
+				effective.DefaultFilters[key] = value
+			}
+		}
+		if len(layer.Data.Boards) > 0 {
+			if effective.Boards == nil {
+				effective.Boards = make(map[string]BoardLayout)
+			}
+			for name, board := range layer.Data.Boards {
+				effective.Boards[name] = board
+			}
+		}
+	}
+	return effective
+}
+
+// current loads a stored layer for conflict responses
+func (s *Service) current(ctx context.Context, userID string) (*Preference, error) {
+	scope := ScopeUser
+	if userID == "" {
+		scope = ScopeOrg
+	}
+	var pref Preference
+	err := s.db.WithContext(ctx).Where("scope = ? AND user_id = ?", scope, userID).First(&pref).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &pref, err
+}
@@ -0,0 +1,56 @@
+package readonly
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"go.uber.org/zap"
+)
+
+// UpdateRequest turns the administrator's read-only mode on or off
+type UpdateRequest struct {
+	ReadOnly *bool  `json:"read_only" binding:"required"`
+	Reason   string `json:"reason" binding:"max=300"`
+}
+
+type Handler struct {
+	mode   *Mode
+	logger *zap.Logger
+}
+
+func NewHandler(mode *Mode, logger *zap.Logger) *Handler {
+	return &Handler{
+		mode:   mode,
+		logger: logger,
+	}
+}
+
+// Get returns whether writes are refused, so clients can show it
+func (h *Handler) Get(c *gin.Context) {
+	c.JSON(http.StatusOK, h.mode.Status())
+}
+
+// Update turns the administrator's read-only mode on or off. The mode
+// stays on while the configuration or the database still has it on.
+func (h *Handler) Update(c *gin.Context) {
+	var req UpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(common.NewValidationError(err))
+		return
+	}
+
+	reason := ""
+	if *req.ReadOnly {
+		reason = req.Reason
+		if reason == "" {
+			reason = "maintenance in progress; changes are paused"
+		}
+	}
+	h.mode.Set(SourceAdmin, reason)
+	h.logger.Info("Read-only mode updated",
+		zap.String("user_id", c.GetString("user_id")),
+		zap.Bool("read_only", *req.ReadOnly),
+	)
+	c.JSON(http.StatusOK, h.mode.Status())
+}
@@ -0,0 +1,189 @@
+package readonly
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// What put the server in read-only mode
+const (
+	SourceConfig   = "config"
+	SourceAdmin    = "admin"
+	SourceDatabase = "database"
+)
+
+// retryAfter is how long, in seconds, clients are told to wait before
+// retrying a refused write
+const retryAfter = 30
+
+// readOnlyTransaction is the SQLSTATE of writes sent to a standby
+const readOnlyTransaction = "25006"
+
+// standbyReason explains read-only mode while the database is a standby
+const standbyReason = "the database is failing over; changes are paused until a new primary is promoted"
+
+// Status tells whether writes are refused, and why
+type Status struct {
+	ReadOnly bool       `json:"read_only"`
+	Source   string     `json:"source,omitempty"`
+	Reason   string     `json:"reason,omitempty"`
+	Since    *time.Time `json:"since,omitempty"`
+}
+
+// Mode refuses writes while any of its sources is on: the configuration,
+// an administrator, or the database being a standby. Reads and WebSocket
+// delivery carry on.
+type Mode struct {
+	logger *zap.Logger
+	exempt map[string]bool
+
+	mu sync.RWMutex
+	// sources holds the status of each source that is on, in precedence
+	// order
+	sources map[string]Status
+}
+
+func NewMode(logger *zap.Logger) *Mode {
+	return &Mode{
+		logger:  logger,
+		exempt:  make(map[string]bool),
+		sources: make(map[string]Status),
+	}
+}
+
+// Exempt lets writes through to the routes of paths, as gin names them,
+// such as the endpoint that ends read-only mode
+func (m *Mode) Exempt(paths ...string) {
+	for _, path := range paths {
+		m.exempt[path] = true
+	}
+}
+
+// Set turns source on with reason, or off with an empty reason
+func (m *Mode) Set(source, reason string) {
+	m.mu.Lock()
+	current, on := m.sources[source]
+	switch {
+	case reason == "" && on:
+		delete(m.sources, source)
+	case reason != "" && (!on || current.Reason != reason):
+		now := time.Now()
+		if on {
+			now = *current.Since
+		}
+		m.sources[source] = Status{ReadOnly: true, Source: source, Reason: reason, Since: &now}
+	default:
+		m.mu.Unlock()
+		return
+	}
+	m.mu.Unlock()
+
+	if reason == "" {
+		m.logger.Warn("Read-only mode turned off", zap.String("source", source))
+	} else {
+		m.logger.Warn("Read-only mode turned on", zap.String("source", source), zap.String("reason", reason))
+	}
+}
+
+// Status returns the status of the first source that is on
+func (m *Mode) Status() Status {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, source := range []string{SourceConfig, SourceAdmin, SourceDatabase} {
+		if status, ok := m.sources[source]; ok {
+			return status
+		}
+	}
+	return Status{}
+}
+
+// Middleware answers 503 with the reason to requests that may write while
+// the server is read-only
+func (m *Mode) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		status := m.Status()
+		if !status.ReadOnly || m.exempt[c.FullPath()] {
+			c.Next()
+			return
+		}
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		common.Abort(c, common.NewReadOnlyError(status.Reason))
+	}
+}
+
+// Watch checks every interval whether the database is a standby, turning
+// read-only mode on while it is and off once it is promoted, until ctx is
+// done
+func (m *Mode) Watch(ctx context.Context, db *gorm.DB, interval time.Duration) {
+	m.check(ctx, db)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.check(ctx, db)
+		}
+	}
+}
+
+// check asks the database whether it is a standby. A database that cannot
+// be reached leaves the mode as it was.
+func (m *Mode) check(ctx context.Context, db *gorm.DB) {
+	var standby bool
+	if err := db.WithContext(ctx).Raw("SELECT pg_is_in_recovery()").Scan(&standby).Error; err != nil {
+		if ctx.Err() == nil {
+			m.logger.Warn("Failed to check whether the database is a standby", zap.Error(err))
+		}
+		return
+	}
+	if standby {
+		m.Set(SourceDatabase, standbyReason)
+	} else {
+		m.Set(SourceDatabase, "")
+	}
+}
+
+// IsStandbyError reports whether err is a write refused because the
+// database is a standby
+func IsStandbyError(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == readOnlyTransaction
+}
+
+// RegisterCallbacks turns read-only mode on as soon as a write fails
+// because the database is a standby, without waiting for the next check
+func (m *Mode) RegisterCallbacks(db *gorm.DB) error {
+	observe := func(tx *gorm.DB) {
+		if IsStandbyError(tx.Error) {
+			m.Set(SourceDatabase, standbyReason)
+		}
+	}
+	callbacks := db.Callback()
+	if err := callbacks.Create().After("gorm:create").Register("readonly:observe_create", observe); err != nil {
+		return err
+	}
+	if err := callbacks.Update().After("gorm:update").Register("readonly:observe_update", observe); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().After("gorm:delete").Register("readonly:observe_delete", observe); err != nil {
+		return err
+	}
+	return callbacks.Raw().After("gorm:raw").Register("readonly:observe_raw", observe)
+}
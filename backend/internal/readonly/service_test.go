@@ -0,0 +1,113 @@
+package readonly
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newRouter(m *Mode) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(m.Middleware())
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	r.GET("/api/tasks", ok)
+	r.POST("/api/tasks", ok)
+	r.PUT("/api/admin/read-only", ok)
+	return r
+}
+
+func serve(r *gin.Engine, method, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w
+}
+
+func TestMiddlewareRefusesWritesOnlyWhileReadOnly(t *testing.T) {
+	m := NewMode(zap.NewNop())
+	m.Exempt("/api/admin/read-only")
+	r := newRouter(m)
+
+	if w := serve(r, http.MethodPost, "/api/tasks"); w.Code != http.StatusNoContent {
+		t.Fatalf("write before read-only: status = %d", w.Code)
+	}
+
+	m.Set(SourceAdmin, "upgrading")
+	w := serve(r, http.MethodPost, "/api/tasks")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("write while read-only: status = %d, want 503", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" || !strings.Contains(w.Body.String(), "upgrading") {
+		t.Fatalf("refusal lacks Retry-After or reason: %v %s", w.Header(), w.Body.String())
+	}
+	if w := serve(r, http.MethodGet, "/api/tasks"); w.Code != http.StatusNoContent {
+		t.Fatalf("read while read-only: status = %d", w.Code)
+	}
+	if w := serve(r, http.MethodPut, "/api/admin/read-only"); w.Code != http.StatusNoContent {
+		t.Fatalf("exempt route while read-only: status = %d", w.Code)
+	}
+
+	m.Set(SourceAdmin, "")
+	if w := serve(r, http.MethodPost, "/api/tasks"); w.Code != http.StatusNoContent {
+		t.Fatalf("write after read-only: status = %d", w.Code)
+	}
+}
+
+func TestAdminCannotEndDatabaseReadOnly(t *testing.T) {
+	m := NewMode(zap.NewNop())
+	m.Set(SourceDatabase, standbyReason)
+	m.Set(SourceAdmin, "upgrading")
+	if got := m.Status().Source; got != SourceAdmin {
+		t.Fatalf("source = %q, want admin", got)
+	}
+
+	m.Set(SourceAdmin, "")
+	status := m.Status()
+	if !status.ReadOnly || status.Source != SourceDatabase {
+		t.Fatalf("status = %+v, want read-only from the database", status)
+	}
+}
+
+func TestCheckFollowsStandbyAndPromotion(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewMode(zap.NewNop())
+	query := `SELECT pg_is_in_recovery\(\)`
+	mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"pg_is_in_recovery"}).AddRow(true))
+	m.check(context.Background(), db)
+	if !m.Status().ReadOnly {
+		t.Fatal("standby did not turn read-only mode on")
+	}
+
+	mock.ExpectQuery(query).WillReturnError(context.DeadlineExceeded)
+	m.check(context.Background(), db)
+	if !m.Status().ReadOnly {
+		t.Fatal("failed check turned read-only mode off")
+	}
+
+	mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"pg_is_in_recovery"}).AddRow(false))
+	m.check(context.Background(), db)
+	if m.Status().ReadOnly {
+		t.Fatal("promotion did not turn read-only mode off")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
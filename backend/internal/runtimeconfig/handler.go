@@ -0,0 +1,76 @@
+package runtimeconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"go.uber.org/zap"
+)
+
+// UpdateRequest overrides settings by key. Values may be JSON strings,
+// numbers or booleans; null goes back to the configured default.
+type UpdateRequest struct {
+	Settings map[string]json.RawMessage `json:"settings" binding:"required"`
+}
+
+type Handler struct {
+	service *Service
+	logger  *zap.Logger
+}
+
+func NewHandler(service *Service, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// List returns every runtime setting with the value in effect
+func (h *Handler) List(c *gin.Context) {
+	values, err := h.service.List(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to list runtime settings", zap.Error(err))
+		_ = c.Error(common.NewInternalServerError("failed to list runtime settings"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"settings": values})
+}
+
+// Update overrides runtime settings, or drops their overrides
+func (h *Handler) Update(c *gin.Context) {
+	var req UpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(common.NewValidationError(err))
+		return
+	}
+
+	changes := make(map[string]*string, len(req.Settings))
+	for key, raw := range req.Settings {
+		if bytes.Equal(raw, []byte("null")) {
+			changes[key] = nil
+			continue
+		}
+		value := string(raw)
+		var text string
+		if json.Unmarshal(raw, &text) == nil {
+			value = text
+		}
+		changes[key] = &value
+	}
+
+	userID := c.GetString("user_id")
+	values, err := h.service.Update(c.Request.Context(), changes, userID)
+	if err != nil {
+		if _, expected := common.ToAppError(err); !expected {
+			h.logger.Error("Failed to update runtime settings", zap.Error(err))
+			err = common.NewInternalServerError("failed to update runtime settings")
+		}
+		_ = c.Error(err)
+		return
+	}
+	h.logger.Info("Runtime settings updated", zap.String("user_id", userID), zap.Int("settings", len(changes)))
+	c.JSON(http.StatusOK, gin.H{"settings": values})
+}
@@ -0,0 +1,310 @@
+package runtimeconfig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrInvalidSetting = errors.New("invalid runtime setting")
+
+func init() {
+	common.RegisterError(ErrInvalidSetting, http.StatusBadRequest)
+}
+
+// Setting is an operational knob administrators can change while the
+// server runs
+type Setting struct {
+	Key         string
+	Description string
+	// Default is the configured value, in effect while no override is
+	// stored
+	Default string
+	// Parse checks value and returns what puts it into effect
+	Parse func(value string) (apply func(), err error)
+}
+
+// Int is a setting holding a whole number from min to max
+func Int(key, description string, defaultValue, min, max int, apply func(int)) Setting {
+	return Setting{
+		Key:         key,
+		Description: description,
+		Default:     strconv.Itoa(defaultValue),
+		Parse: func(value string) (func(), error) {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < min || n > max {
+				return nil, fmt.Errorf("%w: %s must be a whole number from %d to %d", ErrInvalidSetting, key, min, max)
+			}
+			return func() { apply(n) }, nil
+		},
+	}
+}
+
+// Bool is a setting holding true or false
+func Bool(key, description string, defaultValue bool, apply func(bool)) Setting {
+	return Setting{
+		Key:         key,
+		Description: description,
+		Default:     strconv.FormatBool(defaultValue),
+		Parse: func(value string) (func(), error) {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s must be true or false", ErrInvalidSetting, key)
+			}
+			return func() { apply(b) }, nil
+		},
+	}
+}
+
+// Enum is a setting holding one of allowed
+func Enum(key, description, defaultValue string, allowed []string, apply func(string)) Setting {
+	return Setting{
+		Key:         key,
+		Description: description,
+		Default:     defaultValue,
+		Parse: func(value string) (func(), error) {
+			if !slices.Contains(allowed, value) {
+				return nil, fmt.Errorf("%w: %s must be one of %s", ErrInvalidSetting, key, strings.Join(allowed, ", "))
+			}
+			return func() { apply(value) }, nil
+		},
+	}
+}
+
+// List is a setting holding a comma-separated list of values among allowed
+func List(key, description string, defaultValue, allowed []string, apply func([]string)) Setting {
+	return Setting{
+		Key:         key,
+		Description: description,
+		Default:     strings.Join(defaultValue, ","),
+		Parse: func(value string) (func(), error) {
+			var values []string
+			for _, v := range strings.Split(value, ",") {
+				if v = strings.TrimSpace(v); v == "" {
+					continue
+				}
+				if !slices.Contains(allowed, v) {
+					return nil, fmt.Errorf("%w: %s may only list %s", ErrInvalidSetting, key, strings.Join(allowed, ", "))
+				}
+				values = append(values, v)
+			}
+			return func() { apply(values) }, nil
+		},
+	}
+}
+
+// Value is a setting as administrators see it
+type Value struct {
+	Key         string     `json:"key"`
+	Description string     `json:"description"`
+	Value       string     `json:"value"`
+	Default     string     `json:"default"`
+	Overridden  bool       `json:"overridden"`
+	UpdatedBy   string     `json:"updated_by,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+}
+
+// Service keeps overrides of the registered settings in the database and
+// puts them into effect on every replica, without a restart
+type Service struct {
+	db     *gorm.DB
+	logger *zap.Logger
+
+	mu       sync.Mutex
+	settings []Setting
+	// applied is the value in effect of each setting
+	applied map[string]string
+}
+
+func NewService(db *gorm.DB, logger *zap.Logger) *Service {
+	return &Service{
+		db:      db,
+		logger:  logger,
+		applied: make(map[string]string),
+	}
+}
+
+// Register adds settings, whose defaults are taken to be in effect already
+func (s *Service) Register(settings ...Setting) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, setting := range settings {
+		s.settings = append(s.settings, setting)
+		s.applied[setting.Key] = setting.Default
+	}
+}
+
+// Watch applies the stored overrides now and every interval, so changes
+// made through another replica reach this one, until ctx is done
+func (s *Service) Watch(ctx context.Context, interval time.Duration) {
+	s.refresh(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.refresh(ctx)
+		}
+	}
+}
+
+// refresh applies the stored overrides. A database that cannot be reached
+// leaves the settings as they were.
+func (s *Service) refresh(ctx context.Context) {
+	stored, err := s.load(ctx)
+	if err != nil {
+		s.logger.Warn("Failed to load runtime settings", zap.Error(err))
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, setting := range s.settings {
+		value := setting.Default
+		if row, ok := stored[setting.Key]; ok {
+			value = row.Value
+		}
+		s.apply(setting, value)
+	}
+}
+
+// apply puts value into effect unless it already is. A stored value this
+// build rejects, as after a setting's bounds changed, is logged and the
+// setting is left as it was.
+func (s *Service) apply(setting Setting, value string) {
+	if s.applied[setting.Key] == value {
+		return
+	}
+	apply, err := setting.Parse(value)
+	if err != nil {
+		s.logger.Warn("Ignoring invalid runtime setting", zap.String("key", setting.Key), zap.Error(err))
+		return
+	}
+	apply()
+	s.applied[setting.Key] = value
+	s.logger.Info("Runtime setting changed", zap.String("key", setting.Key), zap.String("value", value))
+}
+
+func (s *Service) load(ctx context.Context) (map[string]models.RuntimeSetting, error) {
+	var rows []models.RuntimeSetting
+	if err := s.db.WithContext(ctx).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	stored := make(map[string]models.RuntimeSetting, len(rows))
+	for _, row := range rows {
+		stored[row.Key] = row
+	}
+	return stored, nil
+}
+
+// List returns every setting with the value in effect
+func (s *Service) List(ctx context.Context) ([]Value, error) {
+	stored, err := s.load(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load runtime settings: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	values := make([]Value, 0, len(s.settings))
+	for _, setting := range s.settings {
+		value := Value{
+			Key:         setting.Key,
+			Description: setting.Description,
+			Value:       s.applied[setting.Key],
+			Default:     setting.Default,
+		}
+		if row, ok := stored[setting.Key]; ok {
+			value.Overridden = true
+			value.UpdatedBy = row.UpdatedBy
+			value.UpdatedAt = &row.UpdatedAt
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// Update stores the overrides of changes and puts them into effect on this
+// replica at once; the others follow within their watch interval. A nil
+// value drops the override, going back to the configured default. Nothing
+// changes unless every value is valid.
+func (s *Service) Update(ctx context.Context, changes map[string]*string, userID string) ([]Value, error) {
+	if len(changes) == 0 {
+		return nil, fmt.Errorf("%w: no settings given", ErrInvalidSetting)
+	}
+	updated, err := s.lookup(changes)
+	if err != nil {
+		return nil, err
+	}
+
+	// The lock is only held to put the stored values into effect, so a
+	// slow write does not hold up reads of the settings
+	now := time.Now()
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, setting := range updated {
+			value := changes[setting.Key]
+			if value == nil {
+				if err := tx.Delete(&models.RuntimeSetting{}, "key = ?", setting.Key).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "key"}},
+				DoUpdates: clause.AssignmentColumns([]string{"value", "updated_by", "updated_at"}),
+			}).Create(&models.RuntimeSetting{Key: setting.Key, Value: *value, UpdatedBy: userID, UpdatedAt: now}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store runtime settings: %w", err)
+	}
+	s.mu.Lock()
+	for _, setting := range updated {
+		value := setting.Default
+		if changes[setting.Key] != nil {
+			value = *changes[setting.Key]
+		}
+		s.apply(setting, value)
+	}
+	s.mu.Unlock()
+
+	return s.List(ctx)
+}
+
+// lookup returns the settings of changes in key order, or fails with
+// ErrInvalidSetting if a key is unknown or a value invalid
+func (s *Service) lookup(changes map[string]*string) ([]Setting, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	updated := make([]Setting, 0, len(changes))
+	for _, key := range slices.Sorted(maps.Keys(changes)) {
+		value := changes[key]
+		i := slices.IndexFunc(s.settings, func(setting Setting) bool { return setting.Key == key })
+		if i < 0 {
+			return nil, fmt.Errorf("%w: unknown setting %q", ErrInvalidSetting, key)
+		}
+		if value != nil {
+			if _, err := s.settings[i].Parse(*value); err != nil {
+				return nil, err
+			}
+		}
+		updated = append(updated, s.settings[i])
+	}
+	return updated, nil
+}
@@ -0,0 +1,137 @@
+package runtimeconfig
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var settingColumns = []string{"key", "value", "updated_by", "updated_at"}
+
+// newTestService registers a page size setting, from 1 to 100 and 10 by
+// default, whose value in effect is returned by the func
+func newTestService(t *testing.T) (*Service, sqlmock.Sqlmock, func() int) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pageSize := 10
+	s := NewService(db, zap.NewNop())
+	s.Register(Int("page_size", "Page size", 10, 1, 100, func(n int) { pageSize = n }))
+	return s, mock, func() int { return pageSize }
+}
+
+func TestUpdateRejectsInvalidSettings(t *testing.T) {
+	s, mock, pageSize := newTestService(t)
+	tooBig := "500"
+	for name, changes := range map[string]map[string]*string{
+		"none":         {},
+		"unknown key":  {"colour": &tooBig},
+		"out of range": {"page_size": &tooBig},
+	} {
+		if _, err := s.Update(context.Background(), changes, "admin-1"); !errors.Is(err, ErrInvalidSetting) {
+			t.Errorf("%s: err = %v, want ErrInvalidSetting", name, err)
+		}
+	}
+	if pageSize() != 10 {
+		t.Fatalf("page size = %d after rejected updates", pageSize())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpdateStoresAndAppliesOverride(t *testing.T) {
+	s, mock, pageSize := newTestService(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "runtime_settings" .* ON CONFLICT \("key"\) DO UPDATE SET`).
+		WithArgs("page_size", "25", "admin-1", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(time.Now()))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`SELECT \* FROM "runtime_settings"`).
+		WillReturnRows(sqlmock.NewRows(settingColumns).AddRow("page_size", "25", "admin-1", time.Now()))
+
+	value := "25"
+	values, err := s.Update(context.Background(), map[string]*string{"page_size": &value}, "admin-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pageSize() != 25 || len(values) != 1 || values[0].Value != "25" || values[0].Default != "10" || !values[0].Overridden {
+		t.Fatalf("page size = %d, values = %+v", pageSize(), values)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpdateDoesNotLockSettingsDuringWrite(t *testing.T) {
+	s, mock, pageSize := newTestService(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "runtime_settings"`).
+		WillDelayFor(300 * time.Millisecond).
+		WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(time.Now()))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`SELECT \* FROM "runtime_settings"`).
+		WillReturnRows(sqlmock.NewRows(settingColumns).AddRow("page_size", "25", "admin-1", time.Now()))
+
+	done := make(chan error, 1)
+	value := "25"
+	go func() {
+		_, err := s.Update(context.Background(), map[string]*string{"page_size": &value}, "admin-1")
+		done <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+	if !s.mu.TryLock() {
+		t.Fatal("settings locked while the write is in progress")
+	}
+	s.mu.Unlock()
+
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if pageSize() != 25 {
+		t.Fatalf("page size = %d, want the stored 25", pageSize())
+	}
+}
+
+func TestRefreshFollowsStoredOverrides(t *testing.T) {
+	s, mock, pageSize := newTestService(t)
+
+	mock.ExpectQuery(`SELECT \* FROM "runtime_settings"`).
+		WillReturnRows(sqlmock.NewRows(settingColumns).AddRow("page_size", "50", "admin-1", time.Now()))
+	s.refresh(context.Background())
+	if pageSize() != 50 {
+		t.Fatalf("page size = %d, want the stored 50", pageSize())
+	}
+
+	// A value this build rejects leaves the setting as it was
+	mock.ExpectQuery(`SELECT \* FROM "runtime_settings"`).
+		WillReturnRows(sqlmock.NewRows(settingColumns).AddRow("page_size", "0", "admin-1", time.Now()))
+	s.refresh(context.Background())
+	if pageSize() != 50 {
+		t.Fatalf("page size = %d after an invalid stored value", pageSize())
+	}
+
+	// Dropping the override goes back to the default
+	mock.ExpectQuery(`SELECT \* FROM "runtime_settings"`).WillReturnRows(sqlmock.NewRows(settingColumns))
+	s.refresh(context.Background())
+	if pageSize() != 10 {
+		t.Fatalf("page size = %d, want the default 10", pageSize())
+	}
+}
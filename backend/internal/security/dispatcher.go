@@ -0,0 +1,191 @@
+package security
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	pollInterval      = 5 * time.Second
+	deliveryBatchSize = 100
+	maxBackoff        = 5 * time.Minute
+)
+
+// Start delivers pending events to every webhook until ctx is cancelled.
+// Each webhook has its own worker, so a slow receiver does not hold up the
+// others, and is delivered strictly in sequence order: a failed event is
+// retried with backoff and nothing after it is sent until it succeeds.
+func (s *Service) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		for {
+			s.dispatch(ctx)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-s.wake:
+			}
+		}
+	}()
+}
+
+func (s *Service) dispatch(ctx context.Context) {
+	var webhooks []Webhook
+	if err := s.db.WithContext(ctx).
+		Where("next_attempt_at IS NULL OR next_attempt_at <= ?", time.Now()).
+		Find(&webhooks).Error; err != nil {
+		s.logger.Error("Failed to load security webhooks", zap.Error(err))
+		return
+	}
+
+	for i := range webhooks {
+		webhook := webhooks[i]
+		if !s.claim(webhook.ID) {
+			// Its worker from an earlier pass is still delivering
+			continue
+		}
+
+		s.workers.Add(1)
+		go func() {
+			defer s.workers.Done()
+			defer s.release(webhook.ID)
+			s.deliverPending(ctx, &webhook)
+		}()
+	}
+}
+
+// claim marks the webhook as having a running worker, reporting false if it
+// already has one
+func (s *Service) claim(webhookID string) bool {
+	s.inflightMu.Lock()
+	defer s.inflightMu.Unlock()
+	if s.inflight[webhookID] {
+		return false
+	}
+	s.inflight[webhookID] = true
+	return true
+}
+
+func (s *Service) release(webhookID string) {
+	s.inflightMu.Lock()
+	delete(s.inflight, webhookID)
+	s.inflightMu.Unlock()
+}
+
+func (s *Service) deliverPending(ctx context.Context, webhook *Webhook) {
+	var events []Event
+	if err := s.db.WithContext(ctx).
+		Where("sequence > ?", webhook.DeliveredSequence).
+		Order("sequence asc").
+		Limit(deliveryBatchSize).
+		Find(&events).Error; err != nil {
+		s.logger.Error("Failed to load security events", zap.Error(err))
+		return
+	}
+
+	for _, event := range events {
+		if err := s.deliver(ctx, webhook, event); err != nil {
+			s.recordFailure(ctx, webhook, event, err)
+			return
+		}
+
+		webhook.DeliveredSequence = event.Sequence
+		if err := s.db.WithContext(ctx).Model(webhook).Updates(map[string]interface{}{
+			"delivered_sequence":   event.Sequence,
+			"consecutive_failures": 0,
+			"last_error":           "",
+			"next_attempt_at":      nil,
+			"updated_at":           time.Now(),
+		}).Error; err != nil {
+			// Stop so the event is redelivered rather than skipped
+			s.logger.Error("Failed to record security webhook progress", zap.String("webhook_id", webhook.ID), zap.Error(err))
+			return
+		}
+	}
+}
+
+func (s *Service) recordFailure(ctx context.Context, webhook *Webhook, event Event, deliveryErr error) {
+	failures := webhook.ConsecutiveFailures + 1
+	backoff := time.Duration(1<<min(failures, 9)) * time.Second
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	next := time.Now().Add(backoff)
+
+	s.logger.Warn("Security webhook delivery failed",
+		zap.String("webhook_id", webhook.ID),
+		zap.Int64("sequence", event.Sequence),
+		zap.Int("failures", failures),
+		zap.Duration("retry_in", backoff),
+		zap.Error(deliveryErr),
+	)
+
+	if err := s.db.WithContext(ctx).Model(webhook).Updates(map[string]interface{}{
+		"consecutive_failures": failures,
+		"last_error":           deliveryErr.Error(),
+		"next_attempt_at":      next,
+		"updated_at":           time.Now(),
+	}).Error; err != nil {
+		s.logger.Error("Failed to record security webhook failure", zap.String("webhook_id", webhook.ID), zap.Error(err))
+	}
+}
+
+// deliver POSTs one event. The body is signed as
+// hex(HMAC-SHA256(secret, timestamp + "." + body)) in X-Signature-256.
+func (s *Service) deliver(ctx context.Context, webhook *Webhook, event Event) error {
+	body, err := json.Marshal(deliveryPayload{
+		ID:        event.ID,
+		Sequence:  event.Sequence,
+		Type:      event.Type,
+		ActorID:   event.ActorID,
+		Data:      json.RawMessage(event.Data),
+		CreatedAt: event.CreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Event-ID", event.ID)
+	req.Header.Set("X-Event-Sequence", strconv.FormatInt(event.Sequence, 10))
+	req.Header.Set("X-Signature-256", "sha256="+sign(webhook.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,155 @@
+package security
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestService(t *testing.T) (*Service, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewService(db, zap.NewNop()), mock
+}
+
+func eventRows(sequences ...int64) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"sequence", "id", "type", "data", "created_at"})
+	for _, seq := range sequences {
+		rows.AddRow(seq, "event-"+strconv.FormatInt(seq, 10), string(EventRoleGranted), "{}", time.Now())
+	}
+	return rows
+}
+
+func TestDeliverPendingStopsAtFirstFailure(t *testing.T) {
+	s, mock := newTestService(t)
+
+	var mu sync.Mutex
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seq := r.Header.Get("X-Event-Sequence")
+		mu.Lock()
+		received = append(received, seq)
+		mu.Unlock()
+		if seq == "2" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	webhook := &Webhook{ID: "webhook-1", URL: server.URL, Secret: "secret"}
+	mock.ExpectQuery(`SELECT \* FROM "security_webhooks"|SELECT \* FROM "security_events" WHERE sequence > \$1 ORDER BY sequence asc`).
+		WithArgs(0, deliveryBatchSize).
+		WillReturnRows(eventRows(1, 2, 3))
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "security_webhooks" SET .*"delivered_sequence"=\$2`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "security_webhooks" SET "consecutive_failures"=\$1`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	s.deliverPending(context.Background(), webhook)
+
+	if strings.Join(received, ",") != "1,2" {
+		t.Fatalf("delivered sequences = %v, want 1 then 2 and nothing after the failure", received)
+	}
+	if webhook.DeliveredSequence != 1 {
+		t.Fatalf("delivered sequence = %d, want 1", webhook.DeliveredSequence)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeliverSignsTimestampAndBody(t *testing.T) {
+	s, _ := newTestService(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte(r.Header.Get("X-Webhook-Timestamp") + "."))
+		mac.Write(body)
+		want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+		if got := r.Header.Get("X-Signature-256"); !hmac.Equal([]byte(got), []byte(want)) {
+			t.Errorf("signature = %s, want %s", got, want)
+		}
+	}))
+	defer server.Close()
+
+	err := s.deliver(context.Background(), &Webhook{URL: server.URL, Secret: "secret"}, Event{
+		Sequence: 7, ID: "event-7", Type: string(EventRoleGranted), Data: `{"role":"reporting"}`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDispatchDeliversWebhooksIndependently(t *testing.T) {
+	s, mock := newTestService(t)
+	mock.MatchExpectationsInOrder(false)
+
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer slow.Close()
+	fastDelivered := make(chan struct{})
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(fastDelivered)
+	}))
+	defer fast.Close()
+
+	mock.ExpectQuery(`SELECT \* FROM "security_webhooks"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "url", "secret", "delivered_sequence"}).
+			AddRow("slow", slow.URL, "secret", 0).
+			AddRow("fast", fast.URL, "secret", 10))
+	mock.ExpectQuery(`SELECT \* FROM "security_events"`).WithArgs(0, deliveryBatchSize).WillReturnRows(eventRows(1))
+	mock.ExpectQuery(`SELECT \* FROM "security_events"`).WithArgs(10, deliveryBatchSize).WillReturnRows(eventRows(11))
+	for i := 0; i < 2; i++ {
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "security_webhooks"`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+	}
+
+	s.dispatch(context.Background())
+
+	select {
+	case <-fastDelivered:
+	case <-time.After(2 * time.Second):
+		t.Fatal("fast webhook waited for the slow one")
+	}
+	if s.claim("slow") {
+		t.Fatal("slow webhook was claimable while its worker was still running")
+	}
+
+	close(release)
+	s.workers.Wait()
+	if !s.claim("slow") {
+		t.Fatal("slow webhook was not released after its worker finished")
+	}
+}
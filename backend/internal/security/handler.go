@@ -0,0 +1,64 @@
+package security
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"go.uber.org/zap"
+)
+
+type Handler struct {
+	service *Service
+	logger  *zap.Logger
+}
+
+func NewHandler(service *Service, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *Handler) CreateWebhook(c *gin.Context) {
+	var req CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(common.NewValidationError(err))
+		return
+	}
+
+	resp, err := h.service.RegisterWebhook(c.Request.Context(), req, c.GetString("user_id"))
+	if err != nil {
+		h.logger.Error("Failed to register security webhook", zap.Error(err))
+		_ = c.Error(common.NewInternalServerError("failed to register security webhook"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+func (h *Handler) ListWebhooks(c *gin.Context) {
+	webhooks, err := h.service.ListWebhooks(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to list security webhooks", zap.Error(err))
+		_ = c.Error(common.NewInternalServerError("failed to list security webhooks"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"webhooks": webhooks})
+}
+
+func (h *Handler) DeleteWebhook(c *gin.Context) {
+	err := h.service.DeleteWebhook(c.Request.Context(), c.Param("id"), c.GetString("user_id"))
+	if err != nil {
+		if err == ErrWebhookNotFound {
+			_ = c.Error(common.NewNotFoundError(err.Error()))
+			return
+		}
+		h.logger.Error("Failed to delete security webhook", zap.Error(err))
+		_ = c.Error(common.NewInternalServerError("failed to delete security webhook"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "security webhook deleted"})
+}
@@ -0,0 +1,50 @@
+package security
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+)
+
+type Event = models.SecurityEvent
+type Webhook = models.SecurityWebhook
+
+type EventType string
+
+const (
+	EventLoginFailedBurst          EventType = "login.failed_burst"
+	EventLoginAfterFailures        EventType = "login.succeeded_after_failures"
+	EventRoleGranted               EventType = "role.granted"
+	EventDataExportRequested       EventType = "data_export.requested"
+	EventDataExportCompleted       EventType = "data_export.completed"
+	EventSecurityWebhookCreated    EventType = "security_webhook.created"
+	EventSecurityWebhookDeleted    EventType = "security_webhook.deleted"
+	EventComplianceReportRequested EventType = "compliance_report.requested"
+	EventAccountDisabled           EventType = "account.disabled"
+	EventAccountEnabled            EventType = "account.enabled"
+	EventPasswordResetForced       EventType = "account.password_reset_forced"
+	EventImpersonationStarted      EventType = "impersonation.started"
+	EventImpersonatedRequest       EventType = "impersonation.request"
+)
+
+type CreateWebhookRequest struct {
+	URL string `json:"url" binding:"required,url"`
+}
+
+// CreateWebhookResponse includes the signing secret, which is only ever
+// returned at creation time
+type CreateWebhookResponse struct {
+	Webhook Webhook `json:"webhook"`
+	Secret  string  `json:"secret"`
+}
+
+// deliveryPayload is the JSON body POSTed to security webhooks
+type deliveryPayload struct {
+	ID        string          `json:"id"`
+	Sequence  int64           `json:"sequence"`
+	Type      string          `json:"type"`
+	ActorID   string          `json:"actor_id,omitempty"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"created_at"`
+}
@@ -0,0 +1,153 @@
+package security
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// eventLockKey serializes event inserts so that sequence order matches
+// commit order; otherwise a dispatcher could skip an event whose sequence
+// was allocated before, but committed after, a later one
+const eventLockKey = 72_410_045
+
+var ErrWebhookNotFound = errors.New("security webhook not found")
+
+// Service records security events and delivers them to the deployment's
+// webhooks. A deployment serves a single organization, so every webhook
+// receives every event.
+type Service struct {
+	db     *gorm.DB
+	logger *zap.Logger
+	client *http.Client
+	wake   chan struct{}
+
+	// inflight holds the webhooks that have a delivery worker running
+	inflightMu sync.Mutex
+	inflight   map[string]bool
+	workers    sync.WaitGroup
+}
+
+func NewService(db *gorm.DB, logger *zap.Logger) *Service {
+	return &Service{
+		db:     db,
+		logger: logger,
+		client: &http.Client{Timeout: 10 * time.Second},
+		wake:   make(chan struct{}, 1),
+
+		inflight: make(map[string]bool),
+	}
+}
+
+// Record appends a security event. Failures are logged rather than returned
+// so auditing never blocks the action being audited. A nil *Service is a
+// no-op, which lets callers leave auditing unconfigured.
+func (s *Service) Record(ctx context.Context, eventType EventType, actorID string, data map[string]interface{}) {
+	if s == nil {
+		return
+	}
+
+	encoded := []byte("{}")
+	if len(data) > 0 {
+		var err error
+		if encoded, err = json.Marshal(data); err != nil {
+			s.logger.Error("Failed to encode security event", zap.String("type", string(eventType)), zap.Error(err))
+			return
+		}
+	}
+
+	event := &Event{
+		ID:        uuid.New().String(),
+		Type:      string(eventType),
+		ActorID:   actorID,
+		Data:      string(encoded),
+		CreatedAt: time.Now(),
+	}
+	err := s.db.WithContext(context.WithoutCancel(ctx)).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", eventLockKey).Error; err != nil {
+			return err
+		}
+		return tx.Create(event).Error
+	})
+	if err != nil {
+		s.logger.Error("Failed to record security event", zap.String("type", string(eventType)), zap.Error(err))
+		return
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// RegisterWebhook adds a webhook that receives events recorded from now on
+func (s *Service) RegisterWebhook(ctx context.Context, req CreateWebhookRequest, userID string) (*CreateWebhookResponse, error) {
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	var latest int64
+	if err := s.db.WithContext(ctx).Model(&Event{}).
+		Select("COALESCE(MAX(sequence), 0)").
+		Scan(&latest).Error; err != nil {
+		return nil, fmt.Errorf("failed to load latest event: %w", err)
+	}
+
+	webhook := &Webhook{
+		URL:               req.URL,
+		Secret:            secret,
+		CreatedBy:         userID,
+		DeliveredSequence: latest,
+		CreatedAt:         time.Now(),
+		UpdatedAt:         time.Now(),
+	}
+	if err := s.db.WithContext(ctx).Create(webhook).Error; err != nil {
+		return nil, fmt.Errorf("failed to create security webhook: %w", err)
+	}
+
+	s.Record(ctx, EventSecurityWebhookCreated, userID, map[string]interface{}{
+		"webhook_id": webhook.ID,
+		"url":        webhook.URL,
+	})
+	return &CreateWebhookResponse{Webhook: *webhook, Secret: secret}, nil
+}
+
+func (s *Service) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	webhooks := []Webhook{}
+	if err := s.db.WithContext(ctx).Order("created_at asc").Find(&webhooks).Error; err != nil {
+		return nil, fmt.Errorf("failed to list security webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+func (s *Service) DeleteWebhook(ctx context.Context, id string, userID string) error {
+	result := s.db.WithContext(ctx).Delete(&Webhook{}, "id = ?", id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete security webhook: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrWebhookNotFound
+	}
+
+	s.Record(ctx, EventSecurityWebhookDeleted, userID, map[string]interface{}{"webhook_id": id})
+	return nil
+}
+
+func newSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,69 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const slackAPIBaseURL = "https://slack.com/api"
+
+// Directory looks up the email address of a Slack user, which is how Slack
+// users are matched to accounts
+type Directory interface {
+	UserEmail(ctx context.Context, slackUserID string) (string, error)
+}
+
+// APIDirectory reads email addresses with the Web API's users.info method.
+// The bot token needs the users:read.email scope.
+type APIDirectory struct {
+	token   string
+	client  *http.Client
+	baseURL string
+}
+
+func NewAPIDirectory(token string) *APIDirectory {
+	return &APIDirectory{
+		token:   token,
+		client:  &http.Client{Timeout: 5 * time.Second},
+		baseURL: slackAPIBaseURL,
+	}
+}
+
+func (d *APIDirectory) UserEmail(ctx context.Context, slackUserID string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		d.baseURL+"/users.info?user="+url.QueryEscape(slackUserID), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+d.token)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up Slack user: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("slack users.info failed with status: %d", resp.StatusCode)
+	}
+
+	var body struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+		User  struct {
+			Profile struct {
+				Email string `json:"email"`
+			} `json:"profile"`
+		} `json:"user"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode users.info response: %w", err)
+	}
+	if !body.OK {
+		return "", fmt.Errorf("slack users.info failed: %s", body.Error)
+	}
+	return body.User.Profile.Email, nil
+}
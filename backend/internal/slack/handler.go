@@ -0,0 +1,120 @@
+package slack
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"go.uber.org/zap"
+)
+
+const (
+	maxRequestBytes = 64 << 10
+	// maxRequestAge bounds how old a signed request may be, so captured
+	// requests cannot be replayed later
+	maxRequestAge = 5 * time.Minute
+)
+
+var ErrInvalidSignature = errors.New("invalid Slack request signature")
+
+type Handler struct {
+	service       *Service
+	signingSecret string
+	logger        *zap.Logger
+}
+
+func NewHandler(service *Service, signingSecret string, logger *zap.Logger) *Handler {
+	return &Handler{
+		service:       service,
+		signingSecret: signingSecret,
+		logger:        logger,
+	}
+}
+
+// Commands receives both slash commands and interactive message callbacks.
+// Slack posts them as forms signed with the app's signing secret;
+// callbacks carry their JSON in the payload field.
+func (h *Handler) Commands(c *gin.Context) {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxRequestBytes))
+	if err != nil {
+		_ = c.Error(common.NewInvalidInputError("invalid request body"))
+		return
+	}
+	err = verifySignature(h.signingSecret, c.GetHeader("X-Slack-Request-Timestamp"),
+		c.GetHeader("X-Slack-Signature"), body, time.Now())
+	if err != nil {
+		_ = c.Error(common.NewUnauthorizedError(err.Error()))
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		_ = c.Error(common.NewInvalidInputError("invalid form body"))
+		return
+	}
+
+	if payload := form.Get("payload"); payload != "" {
+		h.interaction(c, payload)
+		return
+	}
+	if form.Get("user_id") == "" {
+		_ = c.Error(common.NewInvalidInputError("user_id is required"))
+		return
+	}
+	c.JSON(http.StatusOK, h.service.RunCommand(c.Request.Context(), Command{
+		Command: form.Get("command"),
+		Text:    form.Get("text"),
+		UserID:  form.Get("user_id"),
+	}))
+}
+
+// interaction runs a button press. Slack ignores the response body of
+// callbacks, so the outcome is posted to the callback's response URL.
+func (h *Handler) interaction(c *gin.Context, payload string) {
+	var interaction Interaction
+	if err := json.Unmarshal([]byte(payload), &interaction); err != nil {
+		_ = c.Error(common.NewInvalidInputError("invalid interaction payload"))
+		return
+	}
+	if interaction.Type != "block_actions" {
+		c.Status(http.StatusOK)
+		return
+	}
+
+	msg := h.service.HandleAction(c.Request.Context(), interaction)
+	if interaction.ResponseURL != "" {
+		if err := h.service.Respond(c.Request.Context(), interaction.ResponseURL, msg); err != nil {
+			h.logger.Warn("Failed to answer Slack interaction", zap.Error(err))
+		}
+	}
+	c.Status(http.StatusOK)
+}
+
+// verifySignature checks Slack's v0 signature, an HMAC-SHA256 of
+// "v0:<timestamp>:<body>", and that the request is recent
+func verifySignature(secret, timestamp, signature string, body []byte, now time.Time) error {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > maxRequestAge || age < -maxRequestAge {
+		return ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+package slack
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"github.com/iSparshP/real-time-task-management-system/internal/task"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const testSecret = "signing-secret"
+
+type fakeDirectory map[string]string
+
+func (d fakeDirectory) UserEmail(ctx context.Context, slackUserID string) (string, error) {
+	return d[slackUserID], nil
+}
+
+type fakeTasks struct {
+	created   task.CreateTaskRequest
+	updated   map[string]task.UpdateTaskRequest
+	open      []task.Task
+	createdBy string
+}
+
+func (f *fakeTasks) CreateTask(ctx context.Context, req task.CreateTaskRequest, userID string) (*task.TaskResponse, error) {
+	f.created, f.createdBy = req, userID
+	return &task.TaskResponse{Task: task.Task{ID: "task-1", Title: req.Title, Priority: task.TaskPriority(req.Priority), DueDate: req.DueDate}}, nil
+}
+
+func (f *fakeTasks) UpdateTask(ctx context.Context, taskID string, req task.UpdateTaskRequest, userID string) (*task.TaskResponse, error) {
+	if userID != "user-1" {
+		return nil, task.ErrUnauthorized
+	}
+	f.updated[taskID] = req
+	return &task.TaskResponse{Task: task.Task{ID: taskID, Title: "Ship <it>"}}, nil
+}
+
+func (f *fakeTasks) AssignTask(ctx context.Context, taskID string, req task.AssignTaskRequest, userID string) (*task.TaskResponse, error) {
+	return &task.TaskResponse{Task: task.Task{ID: taskID, AssignedTo: req.AssignedTo}}, nil
+}
+
+func (f *fakeTasks) NextWorkingDay(ctx context.Context, project string, t time.Time) (time.Time, error) {
+	return t, nil
+}
+
+func (f *fakeTasks) OpenTasksAssignedTo(ctx context.Context, userID string, limit int) ([]task.Task, error) {
+	return f.open, nil
+}
+
+func newTestRouter(t *testing.T, tasks *fakeTasks) (*gin.Engine, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	directory := fakeDirectory{"U1": "Ana@Example.com", "U2": "nobody@example.com"}
+	service := NewService(db, tasks, directory, zap.NewNop())
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(common.ErrorHandler(zap.NewNop()))
+	router.POST("/slack/commands", NewHandler(service, testSecret, zap.NewNop()).Commands)
+	return router, mock
+}
+
+func expectUser(mock sqlmock.Sqlmock, email, id string) *sqlmock.ExpectedQuery {
+	rows := sqlmock.NewRows([]string{"id", "disabled_at"})
+	if id != "" {
+		rows.AddRow(id, nil)
+	}
+	return mock.ExpectQuery(`SELECT "id","disabled_at" FROM "users" WHERE \(normalized_email = \$1 OR \(normalized_email IS NULL AND lower\(email\) = \$2\)\)`).
+		WithArgs(email, email, 1).
+		WillReturnRows(rows)
+}
+
+func sign(body string, at time.Time) (string, string) {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(testSecret))
+	mac.Write([]byte("v0:" + timestamp + ":" + body))
+	return timestamp, "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func postSigned(router *gin.Engine, form url.Values) *httptest.ResponseRecorder {
+	body := form.Encode()
+	timestamp, signature := sign(body, time.Now())
+	req := httptest.NewRequest(http.MethodPost, "/slack/commands", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	req.Header.Set("X-Slack-Signature", signature)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestVerifySignature(t *testing.T) {
+	now := time.Now()
+	body := []byte("command=%2Ftask&text=list")
+	timestamp, signature := sign(string(body), now)
+
+	if err := verifySignature(testSecret, timestamp, signature, body, now); err != nil {
+		t.Fatalf("valid signature rejected: %v", err)
+	}
+	if err := verifySignature(testSecret, timestamp, signature, []byte("command=%2Ftask&text=create"), now); err == nil {
+		t.Fatal("signature of a different body accepted")
+	}
+	if err := verifySignature(testSecret, timestamp, signature, body, now.Add(10*time.Minute)); err == nil {
+		t.Fatal("replayed request accepted")
+	}
+	if err := verifySignature(testSecret, "", signature, body, now); err == nil {
+		t.Fatal("request without a timestamp accepted")
+	}
+}
+
+func TestCommandsRejectUnsignedRequests(t *testing.T) {
+	router, _ := newTestRouter(t, &fakeTasks{})
+
+	req := httptest.NewRequest(http.MethodPost, "/slack/commands", strings.NewReader("command=%2Ftask&text=list&user_id=U1"))
+	req.Header.Set("X-Slack-Request-Timestamp", strconv.FormatInt(time.Now().Unix(), 10))
+	req.Header.Set("X-Slack-Signature", "v0=deadbeef")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401", w.Code)
+	}
+}
+
+func TestCreateCommandParsesOptions(t *testing.T) {
+	tasks := &fakeTasks{}
+	router, mock := newTestRouter(t, tasks)
+	expectUser(mock, "ana@example.com", "user-1")
+
+	w := postSigned(router, url.Values{
+		"command": {"/task"},
+		"text":    {"create Update release notes due:2024-03-10 priority:HIGH project:web"},
+		"user_id": {"U1"},
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (body %s)", w.Code, w.Body.String())
+	}
+	var msg Message
+	if err := json.Unmarshal(w.Body.Bytes(), &msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.ResponseType != ResponseInChannel || len(msg.Blocks) != 2 || msg.Blocks[1].Elements[0].ActionID != ActionAssign {
+		t.Fatalf("message = %+v, want a channel message with task buttons", msg)
+	}
+
+	want := time.Date(2024, 3, 10, 23, 59, 59, 0, time.UTC)
+	if tasks.createdBy != "user-1" || tasks.created.Title != "Update release notes" ||
+		tasks.created.Priority != "high" || tasks.created.Project != "web" || !tasks.created.DueDate.Equal(want) {
+		t.Fatalf("created %+v by %s", tasks.created, tasks.createdBy)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCommandsFromUnknownSlackUserAreRefused(t *testing.T) {
+	tasks := &fakeTasks{}
+	router, mock := newTestRouter(t, tasks)
+	expectUser(mock, "nobody@example.com", "")
+
+	w := postSigned(router, url.Values{"command": {"/task"}, "text": {"create Hello"}, "user_id": {"U2"}})
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "does not match an account") {
+		t.Fatalf("status = %d, body %s, want an ephemeral refusal", w.Code, w.Body.String())
+	}
+	if tasks.createdBy != "" {
+		t.Fatal("task created for an unknown Slack user")
+	}
+}
+
+func TestCommandsOfDisabledAccountsAreRefused(t *testing.T) {
+	tasks := &fakeTasks{}
+	router, mock := newTestRouter(t, tasks)
+	expectUser(mock, "ana@example.com", "user-1")
+	expectUser(mock, "ana@example.com", "").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "disabled_at"}).AddRow("user-1", time.Now()))
+
+	// The account is looked up on every command, not just when the Slack
+	// user is first seen
+	w := postSigned(router, url.Values{"command": {"/task"}, "text": {"list"}, "user_id": {"U1"}})
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "no open tasks") {
+		t.Fatalf("status = %d, body %s, want the task list", w.Code, w.Body.String())
+	}
+	w = postSigned(router, url.Values{"command": {"/task"}, "text": {"create Hello"}, "user_id": {"U1"}})
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "account is disabled") {
+		t.Fatalf("status = %d, body %s, want an ephemeral refusal", w.Code, w.Body.String())
+	}
+	if tasks.createdBy != "" {
+		t.Fatal("task created for a disabled account")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCompleteButtonRespondsToResponseURL(t *testing.T) {
+	tasks := &fakeTasks{updated: map[string]task.UpdateTaskRequest{}}
+	router, mock := newTestRouter(t, tasks)
+	expectUser(mock, "ana@example.com", "user-1")
+
+	responses := make(chan Message, 1)
+	slackServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg Message
+		json.NewDecoder(r.Body).Decode(&msg)
+		responses <- msg
+	}))
+	defer slackServer.Close()
+
+	payload, _ := json.Marshal(map[string]interface{}{
+		"type":         "block_actions",
+		"user":         map[string]string{"id": "U1"},
+		"response_url": slackServer.URL,
+		"actions":      []map[string]string{{"action_id": ActionComplete, "value": "task-9"}},
+	})
+	w := postSigned(router, url.Values{"payload": {string(payload)}})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+
+	if req, ok := tasks.updated["task-9"]; !ok || *req.Status != "completed" {
+		t.Fatalf("updates = %+v, want task-9 completed", tasks.updated)
+	}
+	msg := <-responses
+	if msg.Text != "Completed *Ship &lt;it&gt;*." {
+		t.Fatalf("response = %q, want the escaped task title", msg.Text)
+	}
+}
@@ -0,0 +1,67 @@
+package slack
+
+// Where Slack shows a message
+const (
+	ResponseEphemeral = "ephemeral"
+	ResponseInChannel = "in_channel"
+)
+
+// Action IDs of the buttons on task messages
+const (
+	ActionComplete = "task_complete"
+	ActionAssign   = "task_assign"
+)
+
+// Message is a Slack message, returned to slash commands and posted to the
+// response URLs of interactive callbacks
+type Message struct {
+	ResponseType    string  `json:"response_type,omitempty"`
+	ReplaceOriginal bool    `json:"replace_original,omitempty"`
+	Text            string  `json:"text"`
+	Blocks          []Block `json:"blocks,omitempty"`
+}
+
+// Block is a Block Kit section or actions block
+type Block struct {
+	Type      string      `json:"type"`
+	Text      *TextObject `json:"text,omitempty"`
+	Accessory *Element    `json:"accessory,omitempty"`
+	Elements  []Element   `json:"elements,omitempty"`
+}
+
+type TextObject struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// Element is a Block Kit button
+type Element struct {
+	Type     string      `json:"type"`
+	Text     *TextObject `json:"text,omitempty"`
+	ActionID string      `json:"action_id,omitempty"`
+	Value    string      `json:"value,omitempty"`
+	Style    string      `json:"style,omitempty"`
+}
+
+// Command is a slash command invocation
+type Command struct {
+	Command string
+	Text    string
+	UserID  string
+}
+
+// Interaction is the payload of an interactive message callback
+type Interaction struct {
+	Type string `json:"type"`
+	User struct {
+		ID string `json:"id"`
+	} `json:"user"`
+	ResponseURL string   `json:"response_url"`
+	Actions     []Action `json:"actions"`
+}
+
+// Action is a button press; Value carries the task ID
+type Action struct {
+	ActionID string `json:"action_id"`
+	Value    string `json:"value"`
+}
@@ -0,0 +1,326 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+	"github.com/iSparshP/real-time-task-management-system/internal/task"
+	"github.com/patrickmn/go-cache"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	maxListedTasks = 10
+	defaultDueIn   = 7 * 24 * time.Hour
+	// userTTL is how long a Slack user's email address is remembered
+	userTTL = 10 * time.Minute
+)
+
+var (
+	ErrUnknownUser     = errors.New("no account matches this Slack user")
+	ErrAccountDisabled = errors.New("the account of this Slack user is disabled")
+)
+
+// Tasks is the part of the task service that Slack commands use
+type Tasks interface {
+	CreateTask(ctx context.Context, req task.CreateTaskRequest, userID string) (*task.TaskResponse, error)
+	UpdateTask(ctx context.Context, taskID string, req task.UpdateTaskRequest, userID string) (*task.TaskResponse, error)
+	AssignTask(ctx context.Context, taskID string, req task.AssignTaskRequest, userID string) (*task.TaskResponse, error)
+	OpenTasksAssignedTo(ctx context.Context, userID string, limit int) ([]task.Task, error)
+	NextWorkingDay(ctx context.Context, project string, t time.Time) (time.Time, error)
+}
+
+// Service runs slash commands and button presses for the account whose
+// email matches the Slack user's, with that account's permissions
+type Service struct {
+	db        *gorm.DB
+	tasks     Tasks
+	directory Directory
+	users     *cache.Cache
+	client    *http.Client
+	logger    *zap.Logger
+}
+
+func NewService(db *gorm.DB, tasks Tasks, directory Directory, logger *zap.Logger) *Service {
+	return &Service{
+		db:        db,
+		tasks:     tasks,
+		directory: directory,
+		users:     cache.New(userTTL, 2*userTTL),
+		client:    &http.Client{Timeout: 5 * time.Second},
+		logger:    logger,
+	}
+}
+
+// RunCommand answers a slash command: create, list, or anything else for
+// the usage
+func (s *Service) RunCommand(ctx context.Context, cmd Command) Message {
+	userID, err := s.resolveUser(ctx, cmd.UserID)
+	if err != nil {
+		return s.userError(err)
+	}
+
+	sub, rest := cutWord(strings.TrimSpace(cmd.Text))
+	switch strings.ToLower(sub) {
+	case "create":
+		return s.create(ctx, rest, cmd.UserID, userID)
+	case "list":
+		return s.list(ctx, userID)
+	}
+	return ephemeral(fmt.Sprintf("Usage:\n• `%[1]s create <title> [due:YYYY-MM-DD] [priority:low|medium|high] [project:<name>]`\n• `%[1]s list`", commandName(cmd)))
+}
+
+// HandleAction runs the first button press of an interactive callback
+func (s *Service) HandleAction(ctx context.Context, interaction Interaction) Message {
+	if len(interaction.Actions) == 0 {
+		return ephemeral("Nothing to do.")
+	}
+	userID, err := s.resolveUser(ctx, interaction.User.ID)
+	if err != nil {
+		return s.userError(err)
+	}
+
+	action := interaction.Actions[0]
+	switch action.ActionID {
+	case ActionComplete:
+		status := string(models.StatusCompleted)
+		resp, err := s.tasks.UpdateTask(ctx, action.Value, task.UpdateTaskRequest{Status: &status}, userID)
+		if err != nil {
+			return s.taskError("complete the task", err)
+		}
+		return ephemeral(fmt.Sprintf("Completed *%s*.", escape(resp.Task.Title)))
+	case ActionAssign:
+		resp, err := s.tasks.AssignTask(ctx, action.Value, task.AssignTaskRequest{AssignedTo: userID}, userID)
+		if err != nil {
+			return s.taskError("assign the task", err)
+		}
+		return ephemeral(fmt.Sprintf("*%s* is now assigned to you.", escape(resp.Task.Title)))
+	}
+	return ephemeral("This button is no longer supported.")
+}
+
+// Respond posts a message to the response URL of an interactive callback
+func (s *Service) Respond(ctx context.Context, responseURL string, msg Message) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, responseURL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post response: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("response URL returned status: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// create makes a task from "<title> [due:…] [priority:…] [project:…]". It
+// is left unassigned and shown in the channel, so anyone can take it.
+func (s *Service) create(ctx context.Context, text, slackUserID, userID string) Message {
+	req := task.CreateTaskRequest{
+		Priority: string(models.PriorityMedium),
+		DueDate:  time.Now().Add(defaultDueIn),
+	}
+	var title []string
+	dueGiven := false
+	for _, word := range strings.Fields(text) {
+		key, value, _ := strings.Cut(word, ":")
+		switch strings.ToLower(key) {
+		case "due":
+			due, err := time.Parse(time.DateOnly, value)
+			if err != nil {
+				return ephemeral("The due date must be a YYYY-MM-DD date, for example `due:2024-03-10`.")
+			}
+			req.DueDate = due.Add(24*time.Hour - time.Second)
+			dueGiven = true
+		case "priority":
+			req.Priority = strings.ToLower(value)
+		case "project":
+			req.Project = value
+		default:
+			title = append(title, word)
+		}
+	}
+	req.Title = strings.Join(title, " ")
+	if req.Title == "" {
+		return ephemeral("Give the task a title, for example `create Update the release notes due:2024-03-10`.")
+	}
+	if !dueGiven {
+		due, err := s.tasks.NextWorkingDay(ctx, req.Project, req.DueDate)
+		if err != nil {
+			return s.taskError("create the task", err)
+		}
+		req.DueDate = due
+	}
+
+	resp, err := s.tasks.CreateTask(ctx, req, userID)
+	if err != nil {
+		return s.taskError("create the task", err)
+	}
+	created := resp.Task
+	return Message{
+		ResponseType: ResponseInChannel,
+		Text:         fmt.Sprintf("<@%s> created %s", slackUserID, escape(created.Title)),
+		Blocks: []Block{
+			section(fmt.Sprintf("<@%s> created %s", slackUserID, describe(created))),
+			{Type: "actions", Elements: []Element{
+				button("Assign to me", ActionAssign, created.ID, ""),
+				button("Complete", ActionComplete, created.ID, "primary"),
+			}},
+		},
+	}
+}
+
+// list shows the user's open tasks, soonest due first
+func (s *Service) list(ctx context.Context, userID string) Message {
+	tasks, err := s.tasks.OpenTasksAssignedTo(ctx, userID, maxListedTasks)
+	if err != nil {
+		return s.taskError("list your tasks", err)
+	}
+	if len(tasks) == 0 {
+		return ephemeral("You have no open tasks.")
+	}
+
+	msg := ephemeral(fmt.Sprintf("Your open tasks (%d shown)", len(tasks)))
+	msg.Blocks = append(msg.Blocks, section("*Your open tasks*"))
+	for _, t := range tasks {
+		block := section(describe(t))
+		complete := button("Complete", ActionComplete, t.ID, "primary")
+		block.Accessory = &complete
+		msg.Blocks = append(msg.Blocks, block)
+	}
+	return msg
+}
+
+// resolveUser finds the account with the Slack user's email address,
+// matched as sign-in matches it. Only the address is cached, so accounts
+// disabled since are refused on their next command.
+func (s *Service) resolveUser(ctx context.Context, slackUserID string) (string, error) {
+	if slackUserID == "" {
+		return "", ErrUnknownUser
+	}
+	email, err := s.slackEmail(ctx, slackUserID)
+	if err != nil {
+		return "", err
+	}
+
+	var user models.User
+	err = s.db.WithContext(ctx).Select("id", "disabled_at").
+		Where("normalized_email = ? OR (normalized_email IS NULL AND lower(email) = ?)",
+			models.NormalizeEmail(email, common.AppConfig.EmailFoldGmail), strings.ToLower(strings.TrimSpace(email))).
+		First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", ErrUnknownUser
+	}
+	if err != nil {
+		return "", err
+	}
+	if user.DisabledAt != nil {
+		return "", ErrAccountDisabled
+	}
+	return user.ID, nil
+}
+
+// slackEmail returns the Slack user's email address
+func (s *Service) slackEmail(ctx context.Context, slackUserID string) (string, error) {
+	if cached, found := s.users.Get(slackUserID); found {
+		return cached.(string), nil
+	}
+	email, err := s.directory.UserEmail(ctx, slackUserID)
+	if err != nil {
+		return "", err
+	}
+	if email == "" {
+		return "", ErrUnknownUser
+	}
+	s.users.SetDefault(slackUserID, email)
+	return email, nil
+}
+
+func (s *Service) userError(err error) Message {
+	if errors.Is(err, ErrUnknownUser) {
+		return ephemeral("Your Slack email address does not match an account. Sign up with the same address to use this command.")
+	}
+	if errors.Is(err, ErrAccountDisabled) {
+		return ephemeral("Your account is disabled. Ask an administrator to enable it.")
+	}
+	s.logger.Error("Failed to match Slack user", zap.Error(err))
+	return ephemeral("Something went wrong looking up your account. Please try again.")
+}
+
+// taskError explains errors the user can fix and hides the rest
+func (s *Service) taskError(doing string, err error) Message {
+	for _, known := range []error{
+		task.ErrTaskNotFound, task.ErrUnauthorized, task.ErrInvalidPriority, task.ErrInvalidStatus,
+		task.ErrInvalidDueDate, task.ErrDescriptionTooLong, task.ErrInvalidAssignment,
+		task.ErrFieldRequired, task.ErrFieldHidden, task.ErrDefinitionOfDone, task.ErrHardDeadlinePassed,
+		task.ErrNonWorkingDueDate,
+	} {
+		if errors.Is(err, known) {
+			return ephemeral(fmt.Sprintf("Could not %s: %s.", doing, err))
+		}
+	}
+	s.logger.Error("Slack command failed", zap.String("action", doing), zap.Error(err))
+	return ephemeral(fmt.Sprintf("Could not %s. Please try again.", doing))
+}
+
+func ephemeral(text string) Message {
+	return Message{ResponseType: ResponseEphemeral, Text: text}
+}
+
+func section(text string) Block {
+	return Block{Type: "section", Text: &TextObject{Type: "mrkdwn", Text: text}}
+}
+
+func button(label, actionID, value, style string) Element {
+	return Element{
+		Type:     "button",
+		Text:     &TextObject{Type: "plain_text", Text: label},
+		ActionID: actionID,
+		Value:    value,
+		Style:    style,
+	}
+}
+
+// describe formats a task as one mrkdwn line
+func describe(t task.Task) string {
+	line := fmt.Sprintf("*%s* · due %s · %s priority", escape(t.Title), t.DueDate.Format("Jan 2"), t.Priority)
+	if t.Project != "" {
+		line += " · " + escape(t.Project)
+	}
+	return line
+}
+
+// escape keeps user text from being read as Slack markup
+func escape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+func cutWord(text string) (string, string) {
+	word, rest, _ := strings.Cut(text, " ")
+	return word, strings.TrimSpace(rest)
+}
+
+func commandName(cmd Command) string {
+	if cmd.Command == "" {
+		return "/task"
+	}
+	return cmd.Command
+}
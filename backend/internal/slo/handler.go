@@ -0,0 +1,32 @@
+package slo
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+)
+
+type Handler struct {
+	tracker *Tracker
+}
+
+func NewHandler(tracker *Tracker) *Handler {
+	return &Handler{tracker: tracker}
+}
+
+// Report returns rolling compliance for every objective
+func (h *Handler) Report(c *gin.Context) {
+	c.JSON(http.StatusOK, h.tracker.Report())
+}
+
+// ObserveRequests records each request's latency against the named
+// objective. Server errors, including timeouts, count as bad.
+func (t *Tracker) ObserveRequests(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		t.ObserveLatency(name, time.Since(start), common.ResponseStatus(c) < http.StatusInternalServerError)
+	}
+}
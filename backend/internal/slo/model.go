@@ -0,0 +1,21 @@
+package slo
+
+type Report struct {
+	Window     string            `json:"window"`
+	Indicators []IndicatorReport `json:"indicators"`
+}
+
+// IndicatorReport is one SLI over the window. Compliance, ErrorBudgetRemaining
+// and P99Ms are omitted when there were no events.
+type IndicatorReport struct {
+	Name                 string   `json:"name"`
+	Kind                 Kind     `json:"kind"`
+	ThresholdMs          int64    `json:"threshold_ms,omitempty"`
+	Target               float64  `json:"target"`
+	Events               int64    `json:"events"`
+	Good                 int64    `json:"good"`
+	Compliance           *float64 `json:"compliance,omitempty"`
+	ErrorBudgetRemaining *float64 `json:"error_budget_remaining,omitempty"`
+	P99Ms                *int64   `json:"p99_ms,omitempty"`
+	Met                  bool     `json:"met"`
+}
@@ -0,0 +1,204 @@
+package slo
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// slotWidth is the resolution of the rolling window
+const slotWidth = time.Minute
+
+// latencyBounds are the upper bounds of the latency histogram buckets used
+// to estimate percentiles
+var latencyBounds = []time.Duration{
+	5 * time.Millisecond, 10 * time.Millisecond, 25 * time.Millisecond, 50 * time.Millisecond,
+	100 * time.Millisecond, 250 * time.Millisecond, 500 * time.Millisecond,
+	time.Second, 2500 * time.Millisecond, 5 * time.Second, 10 * time.Second,
+}
+
+type Kind string
+
+const (
+	// KindLatency counts an event as good when it succeeded within Threshold
+	KindLatency Kind = "latency"
+	// KindSuccessRate counts an event as good when it succeeded
+	KindSuccessRate Kind = "success_rate"
+)
+
+// Objective is a service level objective: Target is the fraction of events
+// in the window that must be good
+type Objective struct {
+	Name      string
+	Kind      Kind
+	Threshold time.Duration
+	Target    float64
+}
+
+type slot struct {
+	start time.Time
+	total int64
+	good  int64
+	// histogram has one extra bucket for latencies above every bound
+	histogram []int64
+	max       time.Duration
+}
+
+type indicator struct {
+	objective Objective
+	slots     []slot
+}
+
+// Tracker computes service level indicators over a rolling window and
+// compares them with their objectives
+type Tracker struct {
+	window time.Duration
+	now    func() time.Time
+
+	mu         sync.Mutex
+	indicators map[string]*indicator
+}
+
+func NewTracker(window time.Duration, objectives ...Objective) *Tracker {
+	if window < slotWidth {
+		window = slotWidth
+	}
+	t := &Tracker{
+		window:     window,
+		now:        time.Now,
+		indicators: make(map[string]*indicator),
+	}
+	slots := int(window / slotWidth)
+	for _, o := range objectives {
+		t.indicators[o.Name] = &indicator{objective: o, slots: make([]slot, slots)}
+	}
+	return t
+}
+
+// ObserveLatency records an event for a latency objective. Failed events
+// are bad however fast they were. Unknown names are ignored.
+func (t *Tracker) ObserveLatency(name string, latency time.Duration, ok bool) {
+	t.observe(name, latency, ok)
+}
+
+// ObserveResult records an event for a success rate objective
+func (t *Tracker) ObserveResult(name string, ok bool) {
+	t.observe(name, 0, ok)
+}
+
+func (t *Tracker) observe(name string, latency time.Duration, ok bool) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ind, found := t.indicators[name]
+	if !found {
+		return
+	}
+	s := t.currentSlot(ind)
+	s.total++
+	if ok && (ind.objective.Kind != KindLatency || latency <= ind.objective.Threshold) {
+		s.good++
+	}
+	if ind.objective.Kind == KindLatency {
+		s.histogram[sort.Search(len(latencyBounds), func(i int) bool { return latencyBounds[i] >= latency })]++
+		if latency > s.max {
+			s.max = latency
+		}
+	}
+}
+
+// currentSlot returns the slot for now, resetting it if it last held an
+// older minute
+func (t *Tracker) currentSlot(ind *indicator) *slot {
+	start := t.now().Truncate(slotWidth)
+	s := &ind.slots[int(start.Unix()/int64(slotWidth.Seconds()))%len(ind.slots)]
+	if !s.start.Equal(start) {
+		*s = slot{start: start, histogram: make([]int64, len(latencyBounds)+1)}
+	}
+	return s
+}
+
+// Report summarizes every indicator over the window
+func (t *Tracker) Report() Report {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	oldest := t.now().Truncate(slotWidth).Add(-t.window + slotWidth)
+	report := Report{Window: t.window.String(), Indicators: []IndicatorReport{}}
+	for _, ind := range t.indicators {
+		report.Indicators = append(report.Indicators, ind.report(oldest))
+	}
+	sort.Slice(report.Indicators, func(i, j int) bool { return report.Indicators[i].Name < report.Indicators[j].Name })
+	return report
+}
+
+func (ind *indicator) report(oldest time.Time) IndicatorReport {
+	o := ind.objective
+	r := IndicatorReport{
+		Name:   o.Name,
+		Kind:   o.Kind,
+		Target: o.Target,
+		Met:    true,
+	}
+	if o.Kind == KindLatency {
+		r.ThresholdMs = o.Threshold.Milliseconds()
+	}
+
+	histogram := make([]int64, len(latencyBounds)+1)
+	var max time.Duration
+	for _, s := range ind.slots {
+		if s.start.Before(oldest) || s.total == 0 {
+			continue
+		}
+		r.Events += s.total
+		r.Good += s.good
+		for i, n := range s.histogram {
+			histogram[i] += n
+		}
+		if s.max > max {
+			max = s.max
+		}
+	}
+	if r.Events == 0 {
+		return r
+	}
+
+	compliance := float64(r.Good) / float64(r.Events)
+	r.Compliance = &compliance
+	r.Met = compliance >= o.Target
+	if o.Target < 1 {
+		// The share of allowed bad events not yet used; negative once the
+		// budget is overspent
+		remaining := 1 - (1-compliance)/(1-o.Target)
+		r.ErrorBudgetRemaining = &remaining
+	}
+	if o.Kind == KindLatency {
+		p99 := percentile(histogram, 0.99, max).Milliseconds()
+		r.P99Ms = &p99
+	}
+	return r
+}
+
+// percentile estimates the q-th percentile as the upper bound of the
+// bucket it falls in, or the largest observation beyond the last bound
+func percentile(histogram []int64, q float64, max time.Duration) time.Duration {
+	var total int64
+	for _, n := range histogram {
+		total += n
+	}
+	rank := int64(q*float64(total) + 0.999999)
+	var seen int64
+	for i, n := range histogram {
+		seen += n
+		if seen >= rank {
+			if i < len(latencyBounds) {
+				return latencyBounds[i]
+			}
+			break
+		}
+	}
+	return max
+}
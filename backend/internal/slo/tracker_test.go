@@ -0,0 +1,130 @@
+package slo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestTracker(now *time.Time, objectives ...Objective) *Tracker {
+	t := NewTracker(10*time.Minute, objectives...)
+	t.now = func() time.Time { return *now }
+	return t
+}
+
+func findIndicator(t *testing.T, report Report, name string) IndicatorReport {
+	t.Helper()
+	for _, r := range report.Indicators {
+		if r.Name == name {
+			return r
+		}
+	}
+	t.Fatalf("no indicator %q in %+v", name, report)
+	return IndicatorReport{}
+}
+
+func TestLatencyComplianceAndErrorBudget(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tracker := newTestTracker(&now, Objective{Name: "create", Kind: KindLatency, Threshold: 500 * time.Millisecond, Target: 0.9})
+
+	for i := 0; i < 95; i++ {
+		tracker.ObserveLatency("create", 20*time.Millisecond, true)
+	}
+	for i := 0; i < 4; i++ {
+		tracker.ObserveLatency("create", 3*time.Second, true)
+	}
+	tracker.ObserveLatency("create", time.Millisecond, false)
+
+	r := findIndicator(t, tracker.Report(), "create")
+	if r.Events != 100 || r.Good != 95 {
+		t.Fatalf("events = %d good = %d, want 100 and 95", r.Events, r.Good)
+	}
+	if r.Compliance == nil || *r.Compliance != 0.95 || !r.Met {
+		t.Fatalf("compliance = %v met = %v, want 0.95 and met", r.Compliance, r.Met)
+	}
+	if r.ErrorBudgetRemaining == nil || *r.ErrorBudgetRemaining < 0.49 || *r.ErrorBudgetRemaining > 0.51 {
+		t.Fatalf("error budget remaining = %v, want 0.5", r.ErrorBudgetRemaining)
+	}
+	if r.P99Ms == nil || *r.P99Ms != 5000 {
+		t.Fatalf("p99 = %v, want the 5s bucket", r.P99Ms)
+	}
+}
+
+func TestP99BeyondLastBucketUsesLargestObservation(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tracker := newTestTracker(&now, Objective{Name: "ws", Kind: KindLatency, Threshold: time.Second, Target: 0.99})
+
+	tracker.ObserveLatency("ws", 42*time.Second, true)
+
+	r := findIndicator(t, tracker.Report(), "ws")
+	if r.P99Ms == nil || *r.P99Ms != 42000 {
+		t.Fatalf("p99 = %v, want 42000", r.P99Ms)
+	}
+	if r.Met {
+		t.Fatal("objective met with every event over the threshold")
+	}
+}
+
+func TestSuccessRateWindowExpires(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tracker := newTestTracker(&now, Objective{Name: "notify", Kind: KindSuccessRate, Target: 0.99})
+
+	tracker.ObserveResult("notify", false)
+	now = now.Add(5 * time.Minute)
+	tracker.ObserveResult("notify", true)
+
+	r := findIndicator(t, tracker.Report(), "notify")
+	if r.Events != 2 || r.Met {
+		t.Fatalf("events = %d met = %v, want 2 events and the objective missed", r.Events, r.Met)
+	}
+	if r.P99Ms != nil {
+		t.Fatalf("p99 = %v on a success rate objective", *r.P99Ms)
+	}
+
+	now = now.Add(6 * time.Minute)
+	r = findIndicator(t, tracker.Report(), "notify")
+	if r.Events != 1 || !r.Met {
+		t.Fatalf("events = %d met = %v, want the failure aged out", r.Events, r.Met)
+	}
+
+	now = now.Add(time.Hour)
+	r = findIndicator(t, tracker.Report(), "notify")
+	if r.Events != 0 || r.Compliance != nil || !r.Met {
+		t.Fatalf("report = %+v, want an empty window reported as met", r)
+	}
+}
+
+func TestObserveIgnoresUnknownNamesAndNilTracker(t *testing.T) {
+	var tracker *Tracker
+	tracker.ObserveResult("notify", true)
+
+	tracker = NewTracker(time.Hour)
+	tracker.ObserveLatency("unknown", time.Second, true)
+	if got := tracker.Report().Indicators; len(got) != 0 {
+		t.Fatalf("indicators = %+v, want none", got)
+	}
+}
+
+func TestObserveRequestsCountsServerErrorsAsBad(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tracker := NewTracker(time.Hour, Objective{Name: "create", Kind: KindLatency, Threshold: time.Minute, Target: 0.5})
+
+	router := gin.New()
+	router.POST("/tasks", tracker.ObserveRequests("create"), func(c *gin.Context) {
+		c.Status(http.StatusInternalServerError)
+	})
+	router.GET("/tasks", tracker.ObserveRequests("create"), func(c *gin.Context) {
+		c.Status(http.StatusBadRequest)
+	})
+	for _, method := range []string{http.MethodPost, http.MethodGet} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/tasks", nil))
+	}
+
+	r := findIndicator(t, tracker.Report(), "create")
+	if r.Events != 2 || r.Good != 1 {
+		t.Fatalf("events = %d good = %d, want client errors good and server errors bad", r.Events, r.Good)
+	}
+}
@@ -0,0 +1,123 @@
+package status
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"go.uber.org/zap"
+)
+
+var pageTemplate = template.Must(template.New("status").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Service status</title>
+<style>
+body { font-family: sans-serif; max-width: 40rem; margin: 2rem auto; padding: 0 1rem; color: #222; }
+.up { color: #1a7f37; } .degraded { color: #9a6700; } .down { color: #cf222e; }
+li { margin: .25rem 0; } article { border-top: 1px solid #ddd; padding: .5rem 0; }
+small { color: #666; }
+</style>
+</head>
+<body>
+<h1>Service status: <span class="{{.Status}}">{{.Status}}</span></h1>
+<ul>
+{{range .Components}}<li>{{.Name}}: <span class="{{.Status}}">{{.Status}}</span></li>
+{{end}}</ul>
+<h2>Incidents</h2>
+{{range .Incidents}}<article>
+<h3>{{.Title}} <small>({{.Status}})</small></h3>
+<p>{{.Message}}</p>
+<small>Posted {{.CreatedAt.UTC.Format "2006-01-02 15:04 MST"}}, updated {{.UpdatedAt.UTC.Format "2006-01-02 15:04 MST"}}</small>
+</article>
+{{else}}<p>No recent incidents.</p>
+{{end}}<p><small>Checked {{.UpdatedAt.UTC.Format "2006-01-02 15:04:05 MST"}}</small></p>
+</body>
+</html>
+`))
+
+type Handler struct {
+	service *Service
+	logger  *zap.Logger
+}
+
+func NewHandler(service *Service, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// Page serves the status page as JSON, or as HTML to browsers. It answers
+// 200 whatever the state, since the page itself is up.
+func (h *Handler) Page(c *gin.Context) {
+	page := h.service.Page(c.Request.Context())
+	c.Header("Cache-Control", "public, max-age=30")
+	if c.Query("format") == "html" || c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML) == gin.MIMEHTML {
+		var html bytes.Buffer
+		if err := pageTemplate.Execute(&html, page); err != nil {
+			h.logger.Error("Failed to render status page", zap.Error(err))
+			c.String(http.StatusInternalServerError, "failed to render status page")
+			return
+		}
+		c.Data(http.StatusOK, "text/html; charset=utf-8", html.Bytes())
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *Handler) CreateIncident(c *gin.Context) {
+	var req CreateIncidentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(common.NewValidationError(err))
+		return
+	}
+
+	incident, err := h.service.CreateIncident(c.Request.Context(), req, c.GetString("user_id"))
+	if err != nil {
+		h.logger.Error("Failed to create status incident", zap.Error(err))
+		_ = c.Error(common.NewInternalServerError("failed to create incident"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, incident)
+}
+
+func (h *Handler) UpdateIncident(c *gin.Context) {
+	var req UpdateIncidentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(common.NewValidationError(err))
+		return
+	}
+
+	incident, err := h.service.UpdateIncident(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		h.incidentError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, incident)
+}
+
+func (h *Handler) DeleteIncident(c *gin.Context) {
+	if err := h.service.DeleteIncident(c.Request.Context(), c.Param("id")); err != nil {
+		h.incidentError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) incidentError(c *gin.Context, err error) {
+	if errors.Is(err, ErrIncidentNotFound) {
+		_ = c.Error(common.NewNotFoundError(err.Error()))
+		return
+	}
+	h.logger.Error("Failed to change status incident", zap.Error(err))
+	_ = c.Error(common.NewInternalServerError("failed to change incident"))
+}
@@ -0,0 +1,137 @@
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"github.com/iSparshP/real-time-task-management-system/internal/health"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestRouter(t *testing.T) (*gin.Engine, *Service, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service := NewService(db, zap.NewNop())
+	handler := NewHandler(service, zap.NewNop())
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(common.ErrorHandler(zap.NewNop()))
+	router.GET("/status", handler.Page)
+	router.PUT("/admin/status/incidents/:id", handler.UpdateIncident)
+	return router, service, mock
+}
+
+func expectIncidents(mock sqlmock.Sqlmock) *sqlmock.ExpectedQuery {
+	return mock.ExpectQuery(`SELECT \* FROM "status_incidents" WHERE resolved_at IS NULL OR resolved_at > \$1 ORDER BY created_at DESC LIMIT \$2`).
+		WithArgs(sqlmock.AnyArg(), maxIncidents)
+}
+
+func get(router *gin.Engine, target, accept string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestPageHidesErrorsAndIsReused(t *testing.T) {
+	router, service, mock := newTestRouter(t)
+	checks := 0
+	service.Register("api", true, func(ctx context.Context) error { return nil })
+	service.Register("integrations", false, func(ctx context.Context) error {
+		checks++
+		return errors.New("integrations down: slack")
+	})
+	now := time.Now()
+	expectIncidents(mock).WillReturnRows(sqlmock.NewRows([]string{"id", "title", "message", "status", "created_at", "updated_at"}).
+		AddRow("incident-1", "Slack alerts delayed", "Slack is rejecting webhooks.", IncidentIdentified, now, now))
+
+	w := get(router, "/status", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "integrations down") || strings.Contains(w.Body.String(), "latency") {
+		t.Fatalf("body %s leaks check details", w.Body.String())
+	}
+	var page Page
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	if page.Status != health.StatusDegraded || len(page.Components) != 2 ||
+		page.Components[0] != (Component{Name: "api", Status: health.StatusUp}) ||
+		page.Components[1] != (Component{Name: "integrations", Status: health.StatusDown}) {
+		t.Fatalf("page = %+v, want degraded with integrations down", page)
+	}
+	if len(page.Incidents) != 1 || page.Incidents[0].Title != "Slack alerts delayed" {
+		t.Fatalf("incidents = %+v, want the open incident", page.Incidents)
+	}
+
+	// Browsers get the same page as HTML, without checking again
+	w = get(router, "/status", "text/html,application/xhtml+xml,*/*;q=0.8")
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") ||
+		!strings.Contains(w.Body.String(), "Slack alerts delayed") {
+		t.Fatalf("content type %q, body %s, want the HTML page", w.Header().Get("Content-Type"), w.Body.String())
+	}
+	if checks != 1 {
+		t.Fatalf("components checked %d times, want once", checks)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpdateIncidentResolvesAndRefreshesPage(t *testing.T) {
+	router, service, mock := newTestRouter(t)
+	now := time.Now()
+	expectIncidents(mock).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	get(router, "/status", "")
+
+	mock.ExpectQuery(`SELECT \* FROM "status_incidents" WHERE id = \$1 ORDER BY "status_incidents"."id" LIMIT \$2`).
+		WithArgs("incident-1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "message", "status", "created_at", "updated_at"}).
+			AddRow("incident-1", "Slack alerts delayed", "Slack is rejecting webhooks.", IncidentMonitoring, now, now))
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "status_incidents" SET "title"=\$1,"message"=\$2,"status"=\$3,"resolved_at"=\$4,"updated_at"=\$5 WHERE "id" = \$6`).
+		WithArgs("Slack alerts delayed", "Slack accepts webhooks again.", IncidentResolved, sqlmock.AnyArg(), sqlmock.AnyArg(), "incident-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	req := httptest.NewRequest(http.MethodPut, "/admin/status/incidents/incident-1",
+		strings.NewReader(`{"status": "resolved", "message": "Slack accepts webhooks again."}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"resolved_at"`) {
+		t.Fatalf("status = %d, body %s, want the resolved incident", w.Code, w.Body.String())
+	}
+
+	// The cached page is dropped, so the change shows at once
+	expectIncidents(mock).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	service.Page(context.Background())
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,53 @@
+package status
+
+import (
+	"errors"
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/health"
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+)
+
+type Incident = models.StatusIncident
+
+// Incident states, in the order an incident usually moves through them
+const (
+	IncidentInvestigating = "investigating"
+	IncidentIdentified    = "identified"
+	IncidentMonitoring    = "monitoring"
+	IncidentResolved      = "resolved"
+)
+
+var ErrIncidentNotFound = errors.New("incident not found")
+
+// Component is the public state of one part of the service. Unlike
+// readiness reports, it carries no latency or error details.
+type Component struct {
+	Name   string        `json:"name"`
+	Status health.Status `json:"status"`
+}
+
+// Page is the public status page: the overall state, each component, and
+// the open incidents and those resolved in the last week, newest first
+type Page struct {
+	Status     health.Status `json:"status"`
+	Components []Component   `json:"components"`
+	Incidents  []Incident    `json:"incidents"`
+	UpdatedAt  time.Time     `json:"updated_at"`
+}
+
+// CreateIncidentRequest posts an incident note. Status defaults to
+// investigating.
+type CreateIncidentRequest struct {
+	Title   string `json:"title" binding:"required,max=200"`
+	Message string `json:"message" binding:"required,max=5000"`
+	Status  string `json:"status" binding:"omitempty,oneof=investigating identified monitoring resolved"`
+}
+
+// UpdateIncidentRequest changes the given fields of an incident. Moving it
+// to resolved stamps resolved_at; moving it back clears it.
+type UpdateIncidentRequest struct {
+	Title   *string `json:"title" binding:"omitempty,min=1,max=200"`
+	Message *string `json:"message" binding:"omitempty,min=1,max=5000"`
+	Status  *string `json:"status" binding:"omitempty,oneof=investigating identified monitoring resolved"`
+}
@@ -0,0 +1,177 @@
+package status
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/health"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// pageTTL is how long the page is reused, so a busy public endpoint
+	// runs the checks at most this often per replica
+	pageTTL = 30 * time.Second
+	// resolvedWindow is how long a resolved incident stays on the page
+	resolvedWindow = 7 * 24 * time.Hour
+	maxIncidents   = 20
+	// pageTimeout bounds building the page, incidents included
+	pageTimeout = 3 * time.Second
+)
+
+// Service builds the public status page from component checks and the
+// incident notes administrators post
+type Service struct {
+	db         *gorm.DB
+	checker    *health.Checker
+	components []string
+	logger     *zap.Logger
+
+	mu      sync.Mutex
+	page    *Page
+	expires time.Time
+}
+
+func NewService(db *gorm.DB, logger *zap.Logger) *Service {
+	return &Service{
+		db:      db,
+		checker: health.NewChecker(),
+		logger:  logger,
+	}
+}
+
+// Register adds a component in the order it is listed on the page. A
+// failing critical component shows the service as down; any other only
+// degrades it.
+func (s *Service) Register(name string, critical bool, fn health.CheckFunc) {
+	s.checker.Register(name, critical, fn)
+	s.components = append(s.components, name)
+}
+
+// Page returns the status page, checking the components at most every 30
+// seconds. Should the incidents fail to load, the page is served without
+// them.
+func (s *Service) Page(ctx context.Context) *Page {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.page != nil && time.Now().Before(s.expires) {
+		return s.page
+	}
+
+	// The page is shared, so one caller giving up must not cache its
+	// cancelled checks for everyone; each check has its own timeout
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), pageTimeout)
+	defer cancel()
+	report := s.checker.Run(ctx)
+	page := &Page{
+		Status:     report.Status,
+		Components: make([]Component, 0, len(s.components)),
+		UpdatedAt:  report.CheckedAt,
+	}
+	for _, name := range s.components {
+		result := report.Checks[name]
+		if result.Status != health.StatusUp {
+			s.logger.Warn("Status component is down", zap.String("component", name), zap.String("error", result.Error))
+		}
+		page.Components = append(page.Components, Component{Name: name, Status: result.Status})
+	}
+
+	incidents, err := s.recentIncidents(ctx)
+	if err != nil {
+		s.logger.Warn("Failed to load status incidents", zap.Error(err))
+		incidents = []Incident{}
+	}
+	page.Incidents = incidents
+
+	s.page = page
+	s.expires = time.Now().Add(pageTTL)
+	return page
+}
+
+// recentIncidents returns the open incidents and those resolved in the last
+// week, newest first
+func (s *Service) recentIncidents(ctx context.Context) ([]Incident, error) {
+	incidents := []Incident{}
+	err := s.db.WithContext(ctx).
+		Where("resolved_at IS NULL OR resolved_at > ?", time.Now().Add(-resolvedWindow)).
+		Order("created_at DESC").
+		Limit(maxIncidents).
+		Find(&incidents).Error
+	return incidents, err
+}
+
+// invalidate makes the next Page show incident changes at once. Other
+// replicas show them within 30 seconds.
+func (s *Service) invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.page = nil
+}
+
+func (s *Service) CreateIncident(ctx context.Context, req CreateIncidentRequest, userID string) (*Incident, error) {
+	incident := &Incident{
+		Title:     req.Title,
+		Message:   req.Message,
+		Status:    req.Status,
+		CreatedBy: userID,
+	}
+	if incident.Status == "" {
+		incident.Status = IncidentInvestigating
+	}
+	if incident.Status == IncidentResolved {
+		now := time.Now()
+		incident.ResolvedAt = &now
+	}
+	if err := s.db.WithContext(ctx).Create(incident).Error; err != nil {
+		return nil, fmt.Errorf("failed to create incident: %w", err)
+	}
+	s.invalidate()
+	return incident, nil
+}
+
+func (s *Service) UpdateIncident(ctx context.Context, id string, req UpdateIncidentRequest) (*Incident, error) {
+	var incident Incident
+	if err := s.db.WithContext(ctx).First(&incident, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrIncidentNotFound
+		}
+		return nil, fmt.Errorf("failed to load incident: %w", err)
+	}
+
+	if req.Title != nil {
+		incident.Title = *req.Title
+	}
+	if req.Message != nil {
+		incident.Message = *req.Message
+	}
+	if req.Status != nil && *req.Status != incident.Status {
+		incident.Status = *req.Status
+		incident.ResolvedAt = nil
+		if incident.Status == IncidentResolved {
+			now := time.Now()
+			incident.ResolvedAt = &now
+		}
+	}
+	if err := s.db.WithContext(ctx).Select("title", "message", "status", "resolved_at", "updated_at").
+		Save(&incident).Error; err != nil {
+		return nil, fmt.Errorf("failed to update incident: %w", err)
+	}
+	s.invalidate()
+	return &incident, nil
+}
+
+func (s *Service) DeleteIncident(ctx context.Context, id string) error {
+	result := s.db.WithContext(ctx).Delete(&Incident{}, "id = ?", id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete incident: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrIncidentNotFound
+	}
+	s.invalidate()
+	return nil
+}
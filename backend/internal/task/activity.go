@@ -0,0 +1,87 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// maxCompletedTitles caps the completed task titles listed per project
+const maxCompletedTitles = 10
+
+// ProjectActivity returns the activity of every project with a task
+// changed between since and until, ordered by project. Tasks without a
+// project are left out.
+func (s *Service) ProjectActivity(ctx context.Context, since, until time.Time) ([]ProjectActivity, error) {
+	window := map[string]interface{}{"since": since, "until": until}
+	activity := []ProjectActivity{}
+	if err := s.db.WithContext(ctx).Raw(`
+		SELECT t.project,
+			COUNT(*) FILTER (WHERE t.created_at >= @since AND t.created_at < @until) AS created,
+			COUNT(*) FILTER (WHERE t.completed_at >= @since AND t.completed_at < @until) AS completed,
+			COUNT(*) FILTER (WHERE t.updated_at >= @since AND t.updated_at < @until) AS updated,
+			COUNT(*) FILTER (WHERE t.status <> 'completed' AND t.created_at < @until) AS open_tasks,
+			COUNT(*) FILTER (WHERE t.status <> 'completed' AND t.due_date < @until) AS overdue
+		FROM tasks t
+		WHERE t.project <> '' AND t.deleted_at IS NULL
+		GROUP BY t.project
+		HAVING COUNT(*) FILTER (WHERE t.updated_at >= @since AND t.updated_at < @until) > 0
+		ORDER BY t.project`, window).
+		Scan(&activity).Error; err != nil {
+		return nil, fmt.Errorf("failed to load project activity: %w", err)
+	}
+	if len(activity) == 0 {
+		return activity, nil
+	}
+
+	byProject := make(map[string]*ProjectActivity, len(activity))
+	for i := range activity {
+		byProject[activity[i].Project] = &activity[i]
+	}
+
+	var logged []struct {
+		Project string
+		Seconds int64
+	}
+	if err := s.db.WithContext(ctx).Raw(`
+		SELECT t.project, SUM(e.duration_seconds) AS seconds
+		FROM time_entries e JOIN tasks t ON t.id = e.task_id
+		WHERE t.project <> '' AND e.deleted_at IS NULL AND e.ended_at >= @since AND e.ended_at < @until
+		GROUP BY t.project`, window).
+		Scan(&logged).Error; err != nil {
+		return nil, fmt.Errorf("failed to load logged time: %w", err)
+	}
+	for _, l := range logged {
+		if a := byProject[l.Project]; a != nil {
+			a.LoggedHours = float64(l.Seconds) / 3600
+		}
+	}
+
+	var completed []struct {
+		Project string
+		Title   string
+	}
+	if err := s.db.WithContext(ctx).Raw(`
+		SELECT t.project, t.title FROM tasks t
+		WHERE t.project <> '' AND t.deleted_at IS NULL AND t.completed_at >= @since AND t.completed_at < @until
+		ORDER BY t.project, t.completed_at DESC`, window).
+		Scan(&completed).Error; err != nil {
+		return nil, fmt.Errorf("failed to load completed tasks: %w", err)
+	}
+	for _, c := range completed {
+		if a := byProject[c.Project]; a != nil && len(a.CompletedTitles) < maxCompletedTitles {
+			a.CompletedTitles = append(a.CompletedTitles, c.Title)
+		}
+	}
+	return activity, nil
+}
+
+// IsProjectMember reports whether userID created or is assigned to a task
+// in project, the rule canViewTask uses for project members
+func (s *Service) IsProjectMember(ctx context.Context, userID, project string) (bool, error) {
+	members, err := s.memberProjects(ctx, userID, []string{project})
+	if err != nil {
+		return false, err
+	}
+	return members[project], nil
+}
@@ -0,0 +1,94 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// archiveBatch is how many tasks one archival statement archives, so no
+// statement holds many row locks
+const archiveBatch = 500
+
+// StartArchiver archives the tasks completed more than after ago every
+// interval until ctx is cancelled. A non-positive after disables archival.
+func (s *Service) StartArchiver(ctx context.Context, interval, after time.Duration) {
+	if after <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			n, err := s.ArchiveCompletedTasks(ctx, time.Now().Add(-after))
+			if err != nil && ctx.Err() == nil {
+				s.logger.Error("Failed to archive completed tasks", zap.Error(err))
+			}
+			if n > 0 {
+				s.logger.Info("Archived completed tasks", zap.Int64("count", n))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// ArchiveCompletedTasks archives the tasks completed before cutoff and
+// returns how many it archived. Rows locked by a write are skipped and
+// archived on a later run, so replicas never wait on each other.
+func (s *Service) ArchiveCompletedTasks(ctx context.Context, cutoff time.Time) (int64, error) {
+	args := map[string]interface{}{"now": time.Now(), "cutoff": cutoff, "batch": archiveBatch}
+	var total int64
+	for {
+		result := s.db.WithContext(ctx).Exec(`
+			UPDATE tasks SET archived_at = @now
+			WHERE id IN (
+				SELECT id FROM tasks
+				WHERE status = 'completed' AND completed_at < @cutoff
+				AND archived_at IS NULL AND deleted_at IS NULL
+				LIMIT @batch
+				FOR UPDATE SKIP LOCKED
+			)`, args)
+		if result.Error != nil {
+			return total, fmt.Errorf("failed to archive tasks: %w", result.Error)
+		}
+		total += result.RowsAffected
+		if result.RowsAffected < archiveBatch {
+			return total, nil
+		}
+	}
+}
+
+// ListArchivedTasks is ListTasksWithFilters over the archived tasks
+func (s *Service) ListArchivedTasks(ctx context.Context, filter TaskFilter, pagination PaginationParams, sort SortParams) (*TaskListResponse, error) {
+	filter.Archived = true
+	return s.ListTasksWithFilters(ctx, filter, pagination, sort)
+}
+
+// RestoreTask brings an archived task userID may change back into task
+// lists and search. It stays completed; reopening a task restores it too.
+func (s *Service) RestoreTask(ctx context.Context, taskID, userID string) (*TaskResponse, error) {
+	task, err := s.findModifiableTask(ctx, taskID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if task.ArchivedAt == nil {
+		return nil, ErrTaskNotArchived
+	}
+
+	if err := s.db.WithContext(ctx).Model(task).Update("archived_at", nil).Error; err != nil {
+		return nil, fmt.Errorf("failed to restore task: %w", err)
+	}
+	task.ArchivedAt = nil
+
+	s.publish(TaskUpdatedEvent(*task))
+	s.notifyFollowers(ctx, MessageTypeTaskUpdated, *task)
+	return s.taskResponse(ctx, *task), nil
+}
@@ -0,0 +1,84 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestArchiveCompletedTasksRunsUntilABatchIsShort(t *testing.T) {
+	s, mock := newTestService(t)
+	archive := `UPDATE tasks SET archived_at = \$1 WHERE id IN \( SELECT id FROM tasks ` +
+		`WHERE status = 'completed' AND completed_at < \$2 AND archived_at IS NULL AND deleted_at IS NULL ` +
+		`LIMIT \$3 FOR UPDATE SKIP LOCKED \)`
+	mock.ExpectExec(archive).WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), archiveBatch).
+		WillReturnResult(sqlmock.NewResult(0, archiveBatch))
+	mock.ExpectExec(archive).WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), archiveBatch).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	n, err := s.ArchiveCompletedTasks(context.Background(), time.Now().Add(-90*24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != archiveBatch+3 {
+		t.Fatalf("archived %d, want %d", n, archiveBatch+3)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func expectArchivableTask(mock sqlmock.Sqlmock, archivedAt interface{}) {
+	mock.ExpectQuery(`SELECT \* FROM "tasks" WHERE id = \$1`).
+		WithArgs("task-1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status", "created_by", "archived_at"}).
+			AddRow("task-1", "completed", "user-1", archivedAt))
+	mock.ExpectQuery(`SELECT \* FROM "task_assignees"`).
+		WillReturnRows(sqlmock.NewRows([]string{"task_id", "user_id"}))
+}
+
+func TestRestoreTaskClearsArchivedAt(t *testing.T) {
+	s, mock := newTestService(t)
+	expectArchivableTask(mock, time.Now().Add(-time.Hour))
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "tasks" SET "archived_at"=\$1,"updated_at"=\$2 WHERE "tasks"."deleted_at" IS NULL AND "id" = \$3`).
+		WithArgs(nil, sqlmock.AnyArg(), "task-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectChecklistProgress(mock, 0, 0)
+	mock.ExpectQuery(`SELECT COALESCE\(SUM\(duration_seconds\), 0\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(0))
+
+	resp, err := s.RestoreTask(context.Background(), "task-1", "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Task.ArchivedAt != nil || resp.Task.Status != StatusCompleted {
+		t.Fatalf("task = %+v, want it restored and still completed", resp.Task)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRestoreTaskNotArchivedIsConflict(t *testing.T) {
+	s, mock := newTestService(t)
+	expectArchivableTask(mock, nil)
+
+	if _, err := s.RestoreTask(context.Background(), "task-1", "user-1"); !errors.Is(err, ErrTaskNotArchived) {
+		t.Fatalf("err = %v, want ErrTaskNotArchived", err)
+	}
+}
+
+func TestReopeningTaskRestoresIt(t *testing.T) {
+	archived := time.Now()
+	task := &Task{Status: StatusCompleted, CompletedAt: &archived, ArchivedAt: &archived}
+	task.Status = TaskStatus("in_progress")
+	setCompletedAt(task)
+	if task.ArchivedAt != nil || task.CompletedAt != nil {
+		t.Fatalf("task = %+v, want it reopened out of the archive", task)
+	}
+}
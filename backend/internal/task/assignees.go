@@ -0,0 +1,133 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// resolveAssignees merges the primary assignee and the assignee list into a
+// de-duplicated list with the primary first. If no primary is given, the
+// first listed assignee becomes primary. An empty result is non-nil so
+// callers can tell "unassign" apart from "leave unchanged".
+func resolveAssignees(primary string, ids []string) (string, []string) {
+	seen := make(map[string]bool)
+	var resolved []string
+	for _, id := range append([]string{primary}, ids...) {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		resolved = append(resolved, id)
+	}
+	if len(resolved) == 0 {
+		return "", []string{}
+	}
+	return resolved[0], resolved
+}
+
+func assigneeIDs(task *Task) []string {
+	ids := make([]string, 0, len(task.Assignees))
+	for _, a := range task.Assignees {
+		ids = append(ids, a.UserID)
+	}
+	return ids
+}
+
+func isAssignee(task *Task, userID string) bool {
+	if task.AssignedTo == userID {
+		return true
+	}
+	for _, a := range task.Assignees {
+		if a.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// validateAssignees checks every ID belongs to a user. An empty list is
+// valid and leaves the task unassigned.
+func (s *Service) validateAssignees(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.User{}).Where("id IN ?", ids).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to validate assignees: %w", err)
+	}
+	if int(count) != len(ids) {
+		return ErrInvalidAssignment
+	}
+	return nil
+}
+
+// replaceAssignees rewrites the task's assignee rows inside tx and updates
+// task.Assignees to match
+func replaceAssignees(tx *gorm.DB, task *Task, primary string, ids []string) error {
+	if err := tx.Where("task_id = ?", task.ID).Delete(&models.TaskAssignee{}).Error; err != nil {
+		return fmt.Errorf("failed to clear assignees: %w", err)
+	}
+
+	task.AssignedTo = primary
+	task.Assignees = nil
+	if len(ids) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	assignees := make([]models.TaskAssignee, 0, len(ids))
+	for _, id := range ids {
+		assignees = append(assignees, models.TaskAssignee{
+			TaskID:    task.ID,
+			UserID:    id,
+			IsPrimary: id == primary,
+			CreatedAt: now,
+		})
+	}
+	if err := tx.Create(&assignees).Error; err != nil {
+		return fmt.Errorf("failed to save assignees: %w", err)
+	}
+
+	task.Assignees = assignees
+	return nil
+}
+
+// saveTask persists the task and, when ids is non-nil, its assignees
+// with primary first, inside an existing transaction
+func saveTask(tx *gorm.DB, task *Task, create bool, primary string, ids []string) error {
+	var err error
+	if create {
+		err = tx.Omit(clause.Associations).Create(task).Error
+		if err == nil && len(task.Checklist) > 0 {
+			err = tx.Create(&task.Checklist).Error
+		}
+	} else {
+		err = tx.Omit(clause.Associations).Save(task).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	if ids == nil {
+		return nil
+	}
+	return replaceAssignees(tx, task, primary, ids)
+}
+
+// whereAssignedToAny restricts the query to tasks assigned to any of the
+// comma-separated user IDs
+func whereAssignedToAny(query *gorm.DB, assignedTo string) *gorm.DB {
+	_, ids := resolveAssignees("", strings.Split(assignedTo, ","))
+	if len(ids) == 0 {
+		return query
+	}
+	return query.Where("EXISTS (SELECT 1 FROM task_assignees ta WHERE ta.task_id = tasks.id AND ta.user_id IN ?)", ids)
+}
@@ -0,0 +1,221 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	StrategyRoundRobin = "round_robin"
+	StrategyBinPacking = "bin_packing"
+)
+
+// maxBalanceTasks caps how many tasks one proposal covers, matching the
+// binding limits on task_ids and assignments
+const maxBalanceTasks = 200
+
+var ErrInvalidStrategy = errors.New("invalid balancing strategy")
+
+// ProposeBalance distributes unassigned tasks across the given members. With
+// bin packing, the largest tasks go first to whoever has the least open
+// effort; with round robin, members take turns starting from the least
+// loaded. Nothing is persisted; the caller confirms via ApplyBalance.
+func (s *Service) ProposeBalance(ctx context.Context, req BalanceRequest) (*BalanceProposal, error) {
+	strategy := req.Strategy
+	if strategy == "" {
+		strategy = StrategyBinPacking
+	}
+	if strategy != StrategyRoundRobin && strategy != StrategyBinPacking {
+		return nil, ErrInvalidStrategy
+	}
+
+	_, members := resolveAssignees("", req.MemberIDs)
+	if len(members) == 0 {
+		return nil, ErrInvalidAssignment
+	}
+	if err := s.validateAssignees(ctx, members); err != nil {
+		return nil, err
+	}
+
+	tasks, err := s.unassignedTasks(ctx, req.TaskIDs, req.Project, maxBalanceTasks+1)
+	if err != nil {
+		return nil, err
+	}
+	truncated := len(tasks) > maxBalanceTasks
+	if truncated {
+		tasks = tasks[:maxBalanceTasks]
+	}
+
+	loads, err := s.openEffortByUser(ctx, members)
+	if err != nil {
+		return nil, err
+	}
+
+	proposal := &BalanceProposal{
+		Strategy:    strategy,
+		Assignments: []BalanceAssignment{},
+		Truncated:   truncated,
+	}
+	for _, id := range members {
+		proposal.Before = append(proposal.Before, MemberLoad{UserID: id, OpenEffort: loads[id]})
+	}
+
+	// Stable member order: least loaded first, ties by ID
+	sort.SliceStable(members, func(i, j int) bool {
+		if loads[members[i]] != loads[members[j]] {
+			return loads[members[i]] < loads[members[j]]
+		}
+		return members[i] < members[j]
+	})
+
+	if strategy == StrategyBinPacking {
+		sort.SliceStable(tasks, func(i, j int) bool {
+			return tasks[i].EstimatedEffort > tasks[j].EstimatedEffort
+		})
+	}
+
+	for i, task := range tasks {
+		var member string
+		if strategy == StrategyRoundRobin {
+			member = members[i%len(members)]
+		} else {
+			member = members[0]
+			for _, m := range members[1:] {
+				if loads[m] < loads[member] {
+					member = m
+				}
+			}
+		}
+
+		loads[member] += task.EstimatedEffort
+		proposal.Assignments = append(proposal.Assignments, BalanceAssignment{
+			TaskID: task.ID,
+			Title:  task.Title,
+			UserID: member,
+			Effort: task.EstimatedEffort,
+		})
+	}
+
+	for _, before := range proposal.Before {
+		proposal.After = append(proposal.After, MemberLoad{UserID: before.UserID, OpenEffort: loads[before.UserID]})
+	}
+
+	return proposal, nil
+}
+
+// ApplyBalance assigns each task in the confirmed proposal to its member in
+// one transaction. A task that was assigned since the proposal was made
+// fails the whole batch with ErrAlreadyAssigned rather than being
+// reassigned.
+func (s *Service) ApplyBalance(ctx context.Context, req ApplyBalanceRequest) ([]TaskResponse, error) {
+	userIDs := make([]string, 0, len(req.Assignments))
+	for _, a := range req.Assignments {
+		userIDs = append(userIDs, a.UserID)
+	}
+	_, members := resolveAssignees("", userIDs)
+	if len(members) == 0 {
+		return nil, ErrInvalidAssignment
+	}
+	if err := s.validateAssignees(ctx, members); err != nil {
+		return nil, err
+	}
+
+	tasks := make([]Task, 0, len(req.Assignments))
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, a := range req.Assignments {
+			task, err := lockUnassignedTask(tx, a.TaskID)
+			if err != nil {
+				return fmt.Errorf("task %s: %w", a.TaskID, err)
+			}
+
+			task.UpdatedAt = time.Now()
+			if err := tx.Model(task).Update("updated_at", task.UpdatedAt).Error; err != nil {
+				return fmt.Errorf("failed to assign task %s: %w", a.TaskID, err)
+			}
+			if err := replaceAssignees(tx, task, a.UserID, []string{a.UserID}); err != nil {
+				return fmt.Errorf("failed to assign task %s: %w", a.TaskID, err)
+			}
+			tasks = append(tasks, *task)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make([]TaskResponse, 0, len(tasks))
+	for _, task := range tasks {
+		s.publish(TaskUpdatedEvent(task))
+		s.notifyFollowers(ctx, MessageTypeTaskUpdated, task)
+		applied = append(applied, *s.taskResponse(ctx, task))
+	}
+	s.forgetCounters(ctx, tasks)
+	return applied, nil
+}
+
+// lockUnassignedTask locks the task row for the rest of tx and confirms it
+// still has no assignees
+func lockUnassignedTask(tx *gorm.DB, taskID string) (*Task, error) {
+	task := &Task{}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(task, "id = ?", taskID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		return nil, err
+	}
+
+	var assigned int64
+	if err := tx.Model(&models.TaskAssignee{}).Where("task_id = ?", taskID).Count(&assigned).Error; err != nil {
+		return nil, err
+	}
+	if assigned > 0 {
+		return nil, ErrAlreadyAssigned
+	}
+	return task, nil
+}
+
+func (s *Service) unassignedTasks(ctx context.Context, taskIDs []string, project string, limit int) ([]Task, error) {
+	query := s.db.WithContext(ctx).Model(&Task{}).
+		Where("status <> ?", models.StatusCompleted).
+		Where("NOT EXISTS (SELECT 1 FROM task_assignees ta WHERE ta.task_id = tasks.id)")
+	if len(taskIDs) > 0 {
+		query = query.Where("id IN ?", taskIDs)
+	}
+	if project != "" {
+		query = query.Where("project = ?", project)
+	}
+
+	var tasks []Task
+	if err := query.Order("due_date asc").Limit(limit).Find(&tasks).Error; err != nil {
+		return nil, fmt.Errorf("failed to load unassigned tasks: %w", err)
+	}
+	return tasks, nil
+}
+
+func (s *Service) openEffortByUser(ctx context.Context, userIDs []string) (map[string]float64, error) {
+	var rows []MemberLoad
+	if err := s.db.WithContext(ctx).Model(&Task{}).
+		Joins("JOIN task_assignees ta ON ta.task_id = tasks.id").
+		Select("ta.user_id AS user_id, COALESCE(SUM(tasks.estimated_effort), 0) AS open_effort").
+		Where("ta.user_id IN ? AND tasks.status <> ?", userIDs, models.StatusCompleted).
+		Group("ta.user_id").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to load member workload: %w", err)
+	}
+
+	loads := make(map[string]float64, len(userIDs))
+	for _, id := range userIDs {
+		loads[id] = 0
+	}
+	for _, row := range rows {
+		loads[row.UserID] = row.OpenEffort
+	}
+	return loads, nil
+}
@@ -0,0 +1,207 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"go.uber.org/zap"
+)
+
+func expectMembersExist(mock sqlmock.Sqlmock, count int) {
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "users"`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(count))
+}
+
+func expectLockTask(mock sqlmock.Sqlmock, taskID string, assignees int) {
+	mock.ExpectQuery(`SELECT \* FROM "tasks" WHERE id = \$1 .* FOR UPDATE`).
+		WithArgs(taskID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow(taskID, "Task "+taskID))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "task_assignees" WHERE task_id = \$1`).
+		WithArgs(taskID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(assignees))
+}
+
+func TestApplyBalanceAssignsInOneTransaction(t *testing.T) {
+	s, mock := newTestService(t)
+
+	expectMembersExist(mock, 1)
+	mock.ExpectBegin()
+	for _, id := range []string{"task-1", "task-2"} {
+		expectLockTask(mock, id, 0)
+		mock.ExpectExec(`UPDATE "tasks" SET "updated_at"`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`DELETE FROM "task_assignees"`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`INSERT INTO "task_assignees"`).
+			WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(time.Now()))
+	}
+	mock.ExpectCommit()
+	for i := 0; i < 2; i++ {
+		expectChecklistProgress(mock, 0, 0)
+		mock.ExpectQuery(`SELECT COALESCE\(SUM\(duration_seconds\), 0\)`).
+			WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(0))
+	}
+
+	applied, err := s.ApplyBalance(context.Background(), ApplyBalanceRequest{Assignments: []BalanceAssignment{
+		{TaskID: "task-1", UserID: "user-1"},
+		{TaskID: "task-2", UserID: "user-1"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 2 || applied[0].Task.AssignedTo != "user-1" {
+		t.Fatalf("applied = %+v, want both tasks assigned to user-1", applied)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestApplyBalanceRollsBackWhenTaskWasAssignedMeanwhile(t *testing.T) {
+	s, mock := newTestService(t)
+
+	expectMembersExist(mock, 2)
+	mock.ExpectBegin()
+	expectLockTask(mock, "task-1", 0)
+	mock.ExpectExec(`UPDATE "tasks" SET "updated_at"`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM "task_assignees"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`INSERT INTO "task_assignees"`).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(time.Now()))
+	expectLockTask(mock, "task-2", 1)
+	mock.ExpectRollback()
+
+	applied, err := s.ApplyBalance(context.Background(), ApplyBalanceRequest{Assignments: []BalanceAssignment{
+		{TaskID: "task-1", UserID: "user-1"},
+		{TaskID: "task-2", UserID: "user-2"},
+	}})
+	if !errors.Is(err, ErrAlreadyAssigned) {
+		t.Fatalf("err = %v, want ErrAlreadyAssigned", err)
+	}
+	if applied != nil {
+		t.Fatalf("applied = %+v, want nothing applied", applied)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProposeBalanceCapsUnfilteredProposals(t *testing.T) {
+	s, mock := newTestService(t)
+
+	expectMembersExist(mock, 1)
+	rows := sqlmock.NewRows([]string{"id", "estimated_effort"})
+	for i := 0; i <= maxBalanceTasks; i++ {
+		rows.AddRow("task", 1)
+	}
+	mock.ExpectQuery(`SELECT \* FROM "tasks" .* LIMIT \$2`).
+		WithArgs("completed", maxBalanceTasks+1).
+		WillReturnRows(rows)
+	mock.ExpectQuery(`SELECT ta.user_id AS user_id`).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "open_effort"}))
+
+	proposal, err := s.ProposeBalance(context.Background(), BalanceRequest{MemberIDs: []string{"user-1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !proposal.Truncated || len(proposal.Assignments) != maxBalanceTasks {
+		t.Fatalf("truncated = %v with %d assignments, want truncated at %d",
+			proposal.Truncated, len(proposal.Assignments), maxBalanceTasks)
+	}
+}
+
+func TestAssignmentCandidatesDefaultsToProjectMembers(t *testing.T) {
+	s, mock := newTestService(t)
+
+	mock.ExpectQuery(`SELECT user_id FROM \(`).
+		WithArgs("web", "web", maxCandidates).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow("user-1").AddRow("user-2"))
+	mock.ExpectQuery(`SELECT u.id AS user_id, u.email`).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "user-1", "user-2").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "email", "open_tasks", "overdue"}).
+			AddRow("user-1", "raj@example.com", 5, 3).
+			AddRow("user-2", "ana@example.com", 1, 0))
+
+	workloads, err := s.AssignmentCandidates(context.Background(), "web", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(workloads) != 2 || workloads[0].Email != "raj@example.com" || workloads[0].Overdue != 3 {
+		t.Fatalf("workloads = %+v, want both members with their load", workloads)
+	}
+
+	// A deleted or unknown user has no row
+	mock.ExpectQuery(`SELECT u.id AS user_id, u.email`).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "email"}).AddRow("user-1", "raj@example.com"))
+	if _, err := s.AssignmentCandidates(context.Background(), "", []string{"user-1", "ghost"}); !errors.Is(err, ErrInvalidAssignment) {
+		t.Fatalf("err = %v, want ErrInvalidAssignment", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestApplyBalanceHandlerStatusCodes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	body := `{"assignments":[{"task_id":"task-1","user_id":"user-1"}]}`
+
+	tests := []struct {
+		name   string
+		expect func(sqlmock.Sqlmock)
+		want   int
+	}{
+		{
+			name:   "unknown member",
+			expect: func(mock sqlmock.Sqlmock) { expectMembersExist(mock, 0) },
+			want:   http.StatusBadRequest,
+		},
+		{
+			name: "missing task",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectMembersExist(mock, 1)
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT \* FROM "tasks"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectRollback()
+			},
+			want: http.StatusNotFound,
+		},
+		{
+			name: "already assigned",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectMembersExist(mock, 1)
+				mock.ExpectBegin()
+				expectLockTask(mock, "task-1", 1)
+				mock.ExpectRollback()
+			},
+			want: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock := newTestService(t)
+			tt.expect(mock)
+
+			router := gin.New()
+			router.Use(common.ErrorHandler(zap.NewNop()))
+			router.POST("/tasks/balance/apply", NewHandler(s, zap.NewNop()).ApplyBalance)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/tasks/balance/apply", strings.NewReader(body)))
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d (body %s)", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestResolveAssigneesEmptyMeansUnassign(t *testing.T) {
+	primary, ids := resolveAssignees(" ", nil)
+	if primary != "" || ids == nil || len(ids) != 0 {
+		t.Fatalf("resolveAssignees = %q, %#v, want an empty non-nil list", primary, ids)
+	}
+}
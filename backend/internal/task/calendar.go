@@ -0,0 +1,138 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxHolidays bounds the holidays of a calendar
+const maxHolidays = 366
+
+// GetCalendar returns the project's business calendar; nil if the project
+// has none and its tasks may be due on any day
+func (s *Service) GetCalendar(ctx context.Context, project string) (*ProjectCalendar, error) {
+	var calendar ProjectCalendar
+	err := s.db.WithContext(ctx).First(&calendar, "project = ?", project).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &calendar, nil
+}
+
+// SetCalendar replaces the project's business calendar. Existing tasks
+// keep their due dates; they are checked against the calendar when their
+// due date is next changed.
+func (s *Service) SetCalendar(ctx context.Context, project string, req CalendarRequest, userID string) (*ProjectCalendar, error) {
+	if strings.TrimSpace(project) == "" || len(project) > 100 {
+		return nil, fmt.Errorf("%w: project must be 1 to 100 characters", ErrInvalidCalendar)
+	}
+	if req.Timezone == "" || req.Timezone == "Local" {
+		return nil, fmt.Errorf("%w: timezone must be an IANA time zone such as Europe/Berlin", ErrInvalidCalendar)
+	}
+	if _, err := time.LoadLocation(req.Timezone); err != nil {
+		return nil, fmt.Errorf("%w: unknown timezone %q", ErrInvalidCalendar, req.Timezone)
+	}
+
+	seen := make(map[time.Weekday]bool, len(req.WorkingDays))
+	days := make([]time.Weekday, 0, len(req.WorkingDays))
+	for _, day := range req.WorkingDays {
+		if day < time.Sunday || day > time.Saturday {
+			return nil, fmt.Errorf("%w: working days must be 0 (Sunday) to 6 (Saturday)", ErrInvalidCalendar)
+		}
+		if !seen[day] {
+			seen[day] = true
+			days = append(days, day)
+		}
+	}
+	if len(days) == 0 {
+		return nil, fmt.Errorf("%w: at least one working day is required", ErrInvalidCalendar)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i] < days[j] })
+
+	if len(req.Holidays) > maxHolidays {
+		return nil, fmt.Errorf("%w: at most %d holidays", ErrInvalidCalendar, maxHolidays)
+	}
+	holidays := make([]string, 0, len(req.Holidays))
+	for _, holiday := range req.Holidays {
+		date, err := time.Parse(time.DateOnly, holiday)
+		if err != nil {
+			return nil, fmt.Errorf("%w: holiday %q is not a YYYY-MM-DD date", ErrInvalidCalendar, holiday)
+		}
+		if !containsString(holidays, date.Format(time.DateOnly)) {
+			holidays = append(holidays, date.Format(time.DateOnly))
+		}
+	}
+	sort.Strings(holidays)
+
+	calendar := &ProjectCalendar{
+		Project:     project,
+		Timezone:    req.Timezone,
+		WorkingDays: days,
+		Holidays:    holidays,
+		UpdatedBy:   userID,
+		UpdatedAt:   time.Now(),
+	}
+	if err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "project"}},
+		DoUpdates: clause.AssignmentColumns([]string{"timezone", "working_days", "holidays", "updated_by", "updated_at"}),
+	}).Create(calendar).Error; err != nil {
+		return nil, err
+	}
+	return calendar, nil
+}
+
+// checkWorkingDay fails with ErrNonWorkingDueDate if the project has a
+// calendar and due is not one of its working days
+func (s *Service) checkWorkingDay(ctx context.Context, project string, due time.Time) error {
+	calendar, err := s.GetCalendar(ctx, project)
+	if err != nil || calendar == nil {
+		return err
+	}
+	if !calendar.IsWorkingDay(due) {
+		return fmt.Errorf("%w: %s is not a working day in %s", ErrNonWorkingDueDate,
+			due.In(calendar.Location()).Format(time.DateOnly), calendar.Timezone)
+	}
+	return nil
+}
+
+// NextWorkingDay moves t to the next working day of the project's
+// calendar if it is not one. Defaults such as "due in a week" use it, so
+// they never fall on a day the project does not work.
+func (s *Service) NextWorkingDay(ctx context.Context, project string, t time.Time) (time.Time, error) {
+	if project == "" {
+		return t, nil
+	}
+	calendar, err := s.GetCalendar(ctx, project)
+	if err != nil || calendar == nil {
+		return t, err
+	}
+	return calendar.NextWorkingDay(t), nil
+}
+
+// DueIn is the due date of a task of the project due in the given number
+// of days: working days of the project's calendar, or calendar days for
+// projects without one
+func (s *Service) DueIn(ctx context.Context, project string, days int) (time.Time, error) {
+	now := time.Now()
+	if project == "" {
+		return now.AddDate(0, 0, days), nil
+	}
+	calendar, err := s.GetCalendar(ctx, project)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if calendar == nil {
+		return now.AddDate(0, 0, days), nil
+	}
+	return calendar.AddWorkingDays(now, days), nil
+}
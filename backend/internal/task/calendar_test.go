@@ -0,0 +1,136 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// expectCalendar loads the project's calendar, given as its JSON working
+// days, in Europe/Berlin without holidays, or finds none when workingDays
+// is empty
+func expectCalendar(mock sqlmock.Sqlmock, project, workingDays string) {
+	rows := sqlmock.NewRows([]string{"project", "timezone", "working_days", "holidays"})
+	if workingDays != "" {
+		rows.AddRow(project, "Europe/Berlin", workingDays, `[]`)
+	}
+	mock.ExpectQuery(`SELECT \* FROM "project_calendars" WHERE project = \$1`).
+		WithArgs(project, 1).
+		WillReturnRows(rows)
+}
+
+// nextWeekday is the first time after now on day at noon in Berlin
+func nextWeekday(day time.Weekday) time.Time {
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	t := time.Now().In(berlin).AddDate(0, 0, 1)
+	for t.Weekday() != day {
+		t = t.AddDate(0, 0, 1)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 12, 0, 0, 0, berlin)
+}
+
+func TestCreateTaskRejectsDueDatesOnDaysOff(t *testing.T) {
+	s, mock := newTestService(t)
+	expectFieldSchema(mock, "web", "")
+	expectCalendar(mock, "web", `[1,2,3,4,5]`)
+
+	_, err := s.CreateTask(context.Background(), CreateTaskRequest{
+		Title: "Ship it", Priority: "medium", Project: "web", DueDate: nextWeekday(time.Sunday),
+	}, "user-1")
+	if !errors.Is(err, ErrNonWorkingDueDate) {
+		t.Fatalf("err = %v, want ErrNonWorkingDueDate", err)
+	}
+
+	expectFieldSchema(mock, "web", "")
+	expectCalendar(mock, "web", `[1,2,3,4,5]`)
+	expectCreateTask(mock)
+	if _, err := s.CreateTask(context.Background(), CreateTaskRequest{
+		Title: "Ship it", Priority: "medium", Project: "web", DueDate: nextWeekday(time.Monday),
+	}, "user-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDueInCountsWorkingDays(t *testing.T) {
+	s, mock := newTestService(t)
+	expectCalendar(mock, "web", `[1,2,3,4,5]`)
+
+	due, err := s.DueIn(context.Background(), "web", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Ten working days are two weeks
+	if days := time.Until(due).Hours() / 24; days < 11.9 || days > 14.1 {
+		t.Fatalf("due in %.1f days, want two weeks", days)
+	}
+	if day := due.Weekday(); day == time.Saturday || day == time.Sunday {
+		t.Fatalf("due on %s, want a working day", day)
+	}
+}
+
+func TestSetCalendarValidatesRequest(t *testing.T) {
+	s, _ := newTestService(t)
+	for name, req := range map[string]CalendarRequest{
+		"unknown timezone": {Timezone: "Mars/Olympus", WorkingDays: []time.Weekday{time.Monday}},
+		"local timezone":   {Timezone: "Local", WorkingDays: []time.Weekday{time.Monday}},
+		"bad weekday":      {Timezone: "UTC", WorkingDays: []time.Weekday{7}},
+		"bad holiday":      {Timezone: "UTC", WorkingDays: []time.Weekday{time.Monday}, Holidays: []string{"25/12/2024"}},
+	} {
+		if _, err := s.SetCalendar(context.Background(), "web", req, "admin-1"); !errors.Is(err, ErrInvalidCalendar) {
+			t.Errorf("%s: err = %v, want ErrInvalidCalendar", name, err)
+		}
+	}
+}
+
+func TestSetCalendarNormalizesDaysAndHolidays(t *testing.T) {
+	s, mock := newTestService(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "project_calendars" .* ON CONFLICT \("project"\) DO UPDATE SET .*"updated_at"="excluded"."updated_at"`).
+		WithArgs("web", "America/New_York", "[1,3,5]", `["2024-07-04","2024-12-25"]`, "admin-1", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(time.Now()))
+	mock.ExpectCommit()
+
+	calendar, err := s.SetCalendar(context.Background(), "web", CalendarRequest{
+		Timezone:    "America/New_York",
+		WorkingDays: []time.Weekday{time.Friday, time.Monday, time.Wednesday, time.Monday},
+		Holidays:    []string{"2024-12-25", "2024-07-04", "2024-12-25"},
+	}, "admin-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calendar.WorkingDays) != 3 || calendar.WorkingDays[0] != time.Monday || len(calendar.Holidays) != 2 {
+		t.Fatalf("calendar = %+v, want sorted, distinct days and holidays", calendar)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpdateTaskChecksDueDateWhenMovedToCalendarProject(t *testing.T) {
+	s, mock := newTestService(t)
+	mock.ExpectQuery(`SELECT \* FROM "tasks" WHERE id = \$1`).
+		WithArgs("task-1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "status", "priority", "project", "created_by", "due_date"}).
+			AddRow("task-1", "Ship it", "pending", "medium", "ops", "user-1", nextWeekday(time.Sunday)))
+	mock.ExpectQuery(`SELECT \* FROM "task_assignees"`).
+		WillReturnRows(sqlmock.NewRows([]string{"task_id", "user_id"}))
+	expectFieldSchema(mock, "web", "")
+	expectCalendar(mock, "web", `[1,2,3,4,5]`)
+
+	// The due date is unchanged, but falls on a day the new project does
+	// not work
+	project := "web"
+	_, err := s.UpdateTask(context.Background(), "task-1", UpdateTaskRequest{Project: &project}, "user-1")
+	if !errors.Is(err, ErrNonWorkingDueDate) {
+		t.Fatalf("err = %v, want ErrNonWorkingDueDate", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,89 @@
+package task
+
+import (
+	"sort"
+
+	"github.com/gorilla/websocket"
+)
+
+// PreferencesMessageType is the type of an EventPreferences frame
+const PreferencesMessageType = "preferences"
+
+// Event categories a client can turn off. Replies to the client's own
+// frames, such as welcome and subscription messages, are always sent.
+const (
+	CategoryTasks         = "tasks"
+	CategoryOverdue       = "overdue"
+	CategoryChecklist     = "checklist"
+	CategoryNotifications = "notifications"
+	CategoryTransfers     = "transfers"
+	CategoryPresence      = "presence"
+)
+
+// eventCategories maps each broadcast message type to its category
+var eventCategories = map[MessageType]string{
+	MessageTypeTaskCreated:          CategoryTasks,
+	MessageTypeTaskUpdated:          CategoryTasks,
+	MessageTypeTaskDeleted:          CategoryTasks,
+	MessageTypeTaskAssigned:         CategoryTasks,
+	MessageTypeTaskOverdue:          CategoryOverdue,
+	MessageTypeChecklistItemCreated: CategoryChecklist,
+	MessageTypeChecklistItemUpdated: CategoryChecklist,
+	MessageTypeChecklistItemDeleted: CategoryChecklist,
+	MessageTypeTaskNotification:     CategoryNotifications,
+	MessageTypeTaskTransfer:         CategoryTransfers,
+	MessageTypePresenceJoin:         CategoryPresence,
+	MessageTypePresenceLeave:        CategoryPresence,
+	MessageTypeTaskViewing:          CategoryPresence,
+	MessageTypeTaskLock:             CategoryPresence,
+}
+
+// EventPreferences is sent by clients to stop receiving the events of
+// categories they do not render. Each frame replaces the previous one; an
+// empty Disabled turns every category back on.
+type EventPreferences struct {
+	Type     string   `json:"type"`
+	Disabled []string `json:"disabled"`
+}
+
+// EventPreferencesStatus answers EventPreferences with the categories now
+// disabled. Ignored lists the names that are not categories of this
+// server.
+type EventPreferencesStatus struct {
+	Disabled []string `json:"disabled"`
+	Ignored  []string `json:"ignored,omitempty"`
+}
+
+// SetEventPreferences stops conn receiving the events of the disabled
+// categories, and tells the client which categories that turned off
+func (s *Service) SetEventPreferences(conn *websocket.Conn, disabled []string) {
+	known := make(map[string]bool, len(eventCategories))
+	for _, category := range eventCategories {
+		known[category] = true
+	}
+
+	status := EventPreferencesStatus{Disabled: []string{}}
+	categories := make(map[string]bool)
+	for _, category := range disabled {
+		switch {
+		case !known[category]:
+			if len(status.Ignored) < len(known) {
+				status.Ignored = append(status.Ignored, category)
+			}
+		case !categories[category]:
+			categories[category] = true
+			status.Disabled = append(status.Disabled, category)
+		}
+	}
+	sort.Strings(status.Disabled)
+
+	s.clientsMux.Lock()
+	client, ok := s.clients[conn]
+	if ok {
+		client.disabled = categories
+	}
+	s.clientsMux.Unlock()
+	if ok {
+		s.sendDirect(conn, client, NewWebSocketMessage(MessageTypePreferences, status))
+	}
+}
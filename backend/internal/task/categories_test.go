@@ -0,0 +1,40 @@
+package task
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEventPreferencesWithholdDisabledCategories(t *testing.T) {
+	s, _ := newTestService(t)
+	conn := dialHubAs(t, s, "")
+
+	prefs := EventPreferences{Type: PreferencesMessageType, Disabled: []string{"checklist", "reactions", "checklist", "overdue"}}
+	if err := conn.WriteJSON(prefs); err != nil {
+		t.Fatal(err)
+	}
+	msg := readMessage(t, conn)
+	payload, _ := json.Marshal(msg.Payload)
+	if msg.Type != MessageTypePreferences || string(payload) != `{"disabled":["checklist","overdue"],"ignored":["reactions"]}` {
+		t.Fatalf("got %s %s, want the disabled categories and the ignored name", msg.Type, payload)
+	}
+
+	s.publish(NewWebSocketMessage(MessageTypeChecklistItemCreated, "item-1"))
+	s.publish(NewWebSocketMessage(MessageTypeTaskOverdue, "task-1"))
+	s.publish(NewWebSocketMessage(MessageTypeTaskCreated, "task-2"))
+	if msgType := readMessage(t, conn).Type; msgType != MessageTypeTaskCreated {
+		t.Fatalf("message type = %q, want disabled categories withheld", msgType)
+	}
+
+	// Each frame replaces the previous preferences
+	if err := conn.WriteJSON(EventPreferences{Type: PreferencesMessageType}); err != nil {
+		t.Fatal(err)
+	}
+	if msgType := readMessage(t, conn).Type; msgType != MessageTypePreferences {
+		t.Fatalf("message type = %q, want the preferences reply", msgType)
+	}
+	s.publish(NewWebSocketMessage(MessageTypeChecklistItemCreated, "item-1"))
+	if msgType := readMessage(t, conn).Type; msgType != MessageTypeChecklistItemCreated {
+		t.Fatalf("message type = %q, want checklist events back on", msgType)
+	}
+}
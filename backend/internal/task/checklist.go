@@ -0,0 +1,289 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// newChecklist builds the checklist items of a new task from their text
+func newChecklist(taskID string, texts []string) ([]ChecklistItem, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	now := time.Now()
+	items := make([]ChecklistItem, 0, len(texts))
+	for i, text := range texts {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return nil, ErrEmptyChecklistItem
+		}
+		items = append(items, ChecklistItem{
+			ID:        uuid.New().String(),
+			TaskID:    taskID,
+			Text:      text,
+			Position:  i,
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+	}
+	return items, nil
+}
+
+func checklistTexts(items []ChecklistItem) []string {
+	texts := make([]string, len(items))
+	for i, item := range items {
+		texts[i] = item.Text
+	}
+	return texts
+}
+
+func percentComplete(total, done int64) int {
+	if total == 0 {
+		return 0
+	}
+	return int(done * 100 / total)
+}
+
+// checklistProgress counts the task's checklist items and how many are
+// done. Failures are logged and reported as an empty checklist.
+func (s *Service) checklistProgress(ctx context.Context, taskID string) (total int64, done int64) {
+	var progress struct {
+		Total int64
+		Done  int64
+	}
+	if err := s.db.WithContext(ctx).Model(&ChecklistItem{}).
+		Select("COUNT(*) AS total, COUNT(*) FILTER (WHERE done) AS done").
+		Where("task_id = ?", taskID).
+		Scan(&progress).Error; err != nil {
+		s.logger.Warn("Failed to load checklist progress", zap.String("task_id", taskID), zap.Error(err))
+	}
+	return progress.Total, progress.Done
+}
+
+// taskResponse adds logged time, checklist progress and any deadline
+// warning to the task
+func (s *Service) taskResponse(ctx context.Context, task Task) *TaskResponse {
+	total, done := s.checklistProgress(ctx, task.ID)
+	return &TaskResponse{
+		Task:               task,
+		TotalLoggedSeconds: s.totalLoggedSeconds(ctx, task.ID),
+		ChecklistItems:     total,
+		PercentComplete:    percentComplete(total, done),
+		Warning:            deadlineWarning(task, time.Now()),
+	}
+}
+
+// deadlineWarning is the warning of a single-task response about a task
+// past its deadline, empty for one that is not
+func deadlineWarning(task Task, now time.Time) string {
+	switch {
+	case task.HardDeadlinePassed(now):
+		return "the task is past its hard deadline"
+	case task.IsOverdue(now):
+		return "the task is past its soft deadline"
+	}
+	return ""
+}
+
+// findModifiableTask loads the task if userID may change it
+func (s *Service) findModifiableTask(ctx context.Context, taskID string, userID string) (*Task, error) {
+	task := &Task{}
+	if err := s.db.WithContext(ctx).Preload("Assignees").First(task, "id = ?", taskID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		return nil, err
+	}
+	if !s.canModifyTask(userID, task) {
+		return nil, ErrUnauthorized
+	}
+	return task, nil
+}
+
+func (s *Service) findChecklistItem(ctx context.Context, taskID string, itemID string) (*ChecklistItem, error) {
+	item := &ChecklistItem{}
+	if err := s.db.WithContext(ctx).First(item, "id = ? AND task_id = ?", itemID, taskID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrChecklistItemNotFound
+		}
+		return nil, err
+	}
+	return item, nil
+}
+
+// publishChecklist tells clients about a changed item along with the
+// task's new progress
+func (s *Service) publishChecklist(ctx context.Context, msgType MessageType, item ChecklistItem) ChecklistUpdate {
+	total, done := s.checklistProgress(ctx, item.TaskID)
+	update := ChecklistUpdate{
+		TaskID:          item.TaskID,
+		Item:            item,
+		ChecklistItems:  total,
+		PercentComplete: percentComplete(total, done),
+	}
+	s.publish(ChecklistEvent(msgType, update))
+	return update
+}
+
+func (s *Service) GetChecklist(ctx context.Context, taskID string) (*ChecklistResponse, error) {
+	if _, err := s.findTask(ctx, taskID); err != nil {
+		return nil, err
+	}
+	return s.loadChecklist(ctx, taskID)
+}
+
+func (s *Service) loadChecklist(ctx context.Context, taskID string) (*ChecklistResponse, error) {
+	items := []ChecklistItem{}
+	if err := s.db.WithContext(ctx).Where("task_id = ?", taskID).
+		Order("position ASC, created_at ASC").
+		Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	var done int64
+	for _, item := range items {
+		if item.Done {
+			done++
+		}
+	}
+	return &ChecklistResponse{
+		TaskID:          taskID,
+		Items:           items,
+		PercentComplete: percentComplete(int64(len(items)), done),
+	}, nil
+}
+
+// AddChecklistItem adds an item to the task's checklist, after the last
+// item unless a position is given
+func (s *Service) AddChecklistItem(ctx context.Context, taskID string, req CreateChecklistItemRequest, userID string) (*ChecklistUpdate, error) {
+	text := strings.TrimSpace(req.Text)
+	if text == "" {
+		return nil, ErrEmptyChecklistItem
+	}
+	if _, err := s.findModifiableTask(ctx, taskID, userID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	item := ChecklistItem{
+		ID:        uuid.New().String(),
+		TaskID:    taskID,
+		Text:      text,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if req.Position != nil {
+		item.Position = *req.Position
+	} else if err := s.db.WithContext(ctx).Model(&ChecklistItem{}).
+		Select("COALESCE(MAX(position) + 1, 0)").
+		Where("task_id = ?", taskID).
+		Scan(&item.Position).Error; err != nil {
+		return nil, err
+	}
+
+	if err := s.db.WithContext(ctx).Create(&item).Error; err != nil {
+		return nil, fmt.Errorf("failed to add checklist item: %w", err)
+	}
+
+	update := s.publishChecklist(ctx, MessageTypeChecklistItemCreated, item)
+	return &update, nil
+}
+
+// AddChecklistItems appends several items to the task's checklist in one
+// transaction, so either all of them are added or none
+func (s *Service) AddChecklistItems(ctx context.Context, taskID string, texts []string, userID string) (*ChecklistResponse, error) {
+	if _, err := s.findModifiableTask(ctx, taskID, userID); err != nil {
+		return nil, err
+	}
+	items, err := newChecklist(taskID, texts)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return s.loadChecklist(ctx, taskID)
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var next int
+		if err := tx.Model(&ChecklistItem{}).
+			Select("COALESCE(MAX(position) + 1, 0)").
+			Where("task_id = ?", taskID).
+			Scan(&next).Error; err != nil {
+			return err
+		}
+		for i := range items {
+			items[i].Position += next
+		}
+		return tx.Create(&items).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to add checklist items: %w", err)
+	}
+
+	for _, item := range items {
+		s.publishChecklist(ctx, MessageTypeChecklistItemCreated, item)
+	}
+	return s.loadChecklist(ctx, taskID)
+}
+
+func (s *Service) UpdateChecklistItem(ctx context.Context, taskID string, itemID string, req UpdateChecklistItemRequest, userID string) (*ChecklistUpdate, error) {
+	if _, err := s.findModifiableTask(ctx, taskID, userID); err != nil {
+		return nil, err
+	}
+	item, err := s.findChecklistItem(ctx, taskID, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyChecklistUpdate(item, req); err != nil {
+		return nil, err
+	}
+	if err := s.db.WithContext(ctx).Save(item).Error; err != nil {
+		return nil, fmt.Errorf("failed to update checklist item: %w", err)
+	}
+
+	update := s.publishChecklist(ctx, MessageTypeChecklistItemUpdated, *item)
+	return &update, nil
+}
+
+// applyChecklistUpdate applies the fields set in req to item
+func applyChecklistUpdate(item *ChecklistItem, req UpdateChecklistItemRequest) error {
+	if req.Text != nil {
+		item.Text = strings.TrimSpace(*req.Text)
+		if item.Text == "" {
+			return ErrEmptyChecklistItem
+		}
+	}
+	if req.Done != nil {
+		item.Done = *req.Done
+	}
+	if req.Position != nil {
+		item.Position = *req.Position
+	}
+	item.UpdatedAt = time.Now()
+	return nil
+}
+
+func (s *Service) DeleteChecklistItem(ctx context.Context, taskID string, itemID string, userID string) error {
+	if _, err := s.findModifiableTask(ctx, taskID, userID); err != nil {
+		return err
+	}
+	item, err := s.findChecklistItem(ctx, taskID, itemID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.WithContext(ctx).Delete(item).Error; err != nil {
+		return fmt.Errorf("failed to delete checklist item: %w", err)
+	}
+
+	s.publishChecklist(ctx, MessageTypeChecklistItemDeleted, *item)
+	return nil
+}
@@ -0,0 +1,233 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"go.uber.org/zap"
+)
+
+func expectChecklistProgress(mock sqlmock.Sqlmock, total, done int) {
+	mock.ExpectQuery(`SELECT COUNT\(\*\) AS total, COUNT\(\*\) FILTER \(WHERE done\) AS done FROM "checklist_items"`).
+		WillReturnRows(sqlmock.NewRows([]string{"total", "done"}).AddRow(total, done))
+}
+
+// expectModifiableTask loads a task created by creator with no assignees
+func expectModifiableTask(mock sqlmock.Sqlmock, taskID, creator string) {
+	mock.ExpectQuery(`SELECT \* FROM "tasks" WHERE id = \$1`).
+		WithArgs(taskID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_by"}).AddRow(taskID, creator))
+	mock.ExpectQuery(`SELECT \* FROM "task_assignees"`).
+		WillReturnRows(sqlmock.NewRows([]string{"task_id", "user_id"}))
+}
+
+func TestPercentComplete(t *testing.T) {
+	tests := []struct {
+		total, done int64
+		want        int
+	}{
+		{0, 0, 0},
+		{3, 1, 33},
+		{3, 2, 66},
+		{4, 4, 100},
+	}
+	for _, tt := range tests {
+		if got := percentComplete(tt.total, tt.done); got != tt.want {
+			t.Errorf("percentComplete(%d, %d) = %d, want %d", tt.total, tt.done, got, tt.want)
+		}
+	}
+}
+
+func TestNewChecklistRejectsBlankItems(t *testing.T) {
+	items, err := newChecklist("task-1", []string{" Reproduce ", "Fix"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].Text != "Reproduce" || items[1].Position != 1 || items[1].TaskID != "task-1" {
+		t.Fatalf("items = %+v, want trimmed items in order", items)
+	}
+	if _, err := newChecklist("task-1", []string{"Fix", "  "}); !errors.Is(err, ErrEmptyChecklistItem) {
+		t.Fatalf("err = %v, want ErrEmptyChecklistItem", err)
+	}
+}
+
+func TestAddChecklistItemAppendsAndBroadcasts(t *testing.T) {
+	s, mock := newTestService(t)
+	conn := dialHub(t, s)
+
+	expectModifiableTask(mock, "task-1", "user-1")
+	mock.ExpectQuery(`SELECT COALESCE\(MAX\(position\) \+ 1, 0\) FROM "checklist_items"`).
+		WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(2))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "checklist_items"`).WillReturnRows(sqlmock.NewRows([]string{"done"}).AddRow(false))
+	mock.ExpectCommit()
+	expectChecklistProgress(mock, 3, 2)
+
+	update, err := s.AddChecklistItem(context.Background(), "task-1", CreateChecklistItemRequest{Text: "Deploy"}, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if update.Item.Position != 2 || update.ChecklistItems != 3 || update.PercentComplete != 66 {
+		t.Fatalf("update = %+v, want the item appended at position 2 and 66%% complete", update)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	var msg struct {
+		Type    MessageType     `json:"type"`
+		Payload ChecklistUpdate `json:"payload"`
+	}
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Type != MessageTypeChecklistItemCreated || msg.Payload.Item.Text != "Deploy" || msg.Payload.PercentComplete != 66 {
+		t.Fatalf("message = %+v, want the new item with progress", msg)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAddChecklistItemsAppendsInOneTransaction(t *testing.T) {
+	s, mock := newTestService(t)
+
+	expectModifiableTask(mock, "task-1", "user-1")
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT COALESCE\(MAX\(position\) \+ 1, 0\) FROM "checklist_items"`).
+		WillReturnRows(sqlmock.NewRows([]string{"position"}).AddRow(3))
+	mock.ExpectQuery(`INSERT INTO "checklist_items"`).
+		WithArgs("task-1", "Write tests", false, 3, nil, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			"task-1", "Ship", false, 4, nil, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"done"}).AddRow(false).AddRow(false))
+	mock.ExpectCommit()
+	expectChecklistProgress(mock, 4, 0)
+	expectChecklistProgress(mock, 5, 0)
+	mock.ExpectQuery(`SELECT \* FROM "checklist_items" WHERE task_id = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "task_id", "text", "position"}).
+			AddRow("item-1", "task-1", "Write tests", 3).
+			AddRow("item-2", "task-1", "Ship", 4))
+
+	resp, err := s.AddChecklistItems(context.Background(), "task-1", []string{"Write tests", " Ship "}, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Items) != 2 || resp.Items[1].Position != 4 {
+		t.Fatalf("checklist = %+v, want both items appended", resp)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+
+	expectModifiableTask(mock, "task-1", "user-1")
+	if _, err := s.AddChecklistItems(context.Background(), "task-1", []string{"Ship", ""}, "user-1"); !errors.Is(err, ErrEmptyChecklistItem) {
+		t.Fatalf("err = %v, want ErrEmptyChecklistItem before anything is written", err)
+	}
+}
+
+func TestUpdateChecklistItemMarksDone(t *testing.T) {
+	s, mock := newTestService(t)
+
+	expectModifiableTask(mock, "task-1", "user-1")
+	mock.ExpectQuery(`SELECT \* FROM "checklist_items" WHERE \(id = \$1 AND task_id = \$2\)`).
+		WithArgs("item-1", "task-1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "task_id", "text", "done", "position"}).AddRow("item-1", "task-1", "Deploy", false, 0))
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "checklist_items" SET`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectChecklistProgress(mock, 1, 1)
+
+	done := true
+	update, err := s.UpdateChecklistItem(context.Background(), "task-1", "item-1", UpdateChecklistItemRequest{Done: &done}, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !update.Item.Done || update.Item.Text != "Deploy" || update.PercentComplete != 100 {
+		t.Fatalf("update = %+v, want the item done and the checklist complete", update)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetTaskReportsChecklistProgress(t *testing.T) {
+	s, mock := newTestService(t)
+
+	expectModifiableTask(mock, "task-1", "user-1")
+	expectChecklistProgress(mock, 4, 1)
+	mock.ExpectQuery(`SELECT COALESCE\(SUM\(duration_seconds\), 0\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(0))
+
+	resp, err := s.GetTask(context.Background(), "task-1", "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ChecklistItems != 4 || resp.PercentComplete != 25 {
+		t.Fatalf("progress = %d items at %d%%, want 4 at 25%%", resp.ChecklistItems, resp.PercentComplete)
+	}
+}
+
+func TestChecklistHandlerStatusCodes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		expect func(sqlmock.Sqlmock)
+		want   int
+	}{
+		{
+			name:   "not the creator or an assignee",
+			method: http.MethodPost,
+			path:   "/tasks/task-1/checklist",
+			body:   `{"text":"Deploy"}`,
+			expect: func(mock sqlmock.Sqlmock) { expectModifiableTask(mock, "task-1", "user-2") },
+			want:   http.StatusForbidden,
+		},
+		{
+			name:   "blank text",
+			method: http.MethodPost,
+			path:   "/tasks/task-1/checklist",
+			body:   `{"text":"   "}`,
+			expect: func(sqlmock.Sqlmock) {},
+			want:   http.StatusBadRequest,
+		},
+		{
+			name:   "unknown item",
+			method: http.MethodDelete,
+			path:   "/tasks/task-1/checklist/item-9",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectModifiableTask(mock, "task-1", "user-1")
+				mock.ExpectQuery(`SELECT \* FROM "checklist_items"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			want: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock := newTestService(t)
+			tt.expect(mock)
+
+			h := NewHandler(s, zap.NewNop())
+			router := gin.New()
+			router.Use(common.ErrorHandler(zap.NewNop()))
+			router.Use(func(c *gin.Context) { c.Set("user_id", "user-1") })
+			router.POST("/tasks/:id/checklist", h.AddChecklistItem)
+			router.DELETE("/tasks/:id/checklist/:item_id", h.DeleteChecklistItem)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d (body %s)", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
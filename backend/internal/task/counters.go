@@ -0,0 +1,200 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// TaskCounters are the counts a dashboard shows a user. Open, Overdue and
+// DueToday count the unfinished tasks the user created or is assigned;
+// AssignedToMe counts the unfinished tasks assigned to the user.
+type TaskCounters struct {
+	Open         int64 `json:"open"`
+	Overdue      int64 `json:"overdue"`
+	DueToday     int64 `json:"due_today"`
+	AssignedToMe int64 `json:"assigned_to_me"`
+}
+
+// CountersCache keeps each user's task counters until a task of theirs
+// changes. Get reports found as false on a miss.
+type CountersCache interface {
+	Get(ctx context.Context, userID string) (counters *TaskCounters, found bool, err error)
+	Set(ctx context.Context, userID string, counters TaskCounters) error
+	Forget(ctx context.Context, userIDs ...string) error
+}
+
+// SetCountersCache enables caching of task counters
+func (s *Service) SetCountersCache(cache CountersCache) {
+	s.counters = cache
+}
+
+// Counters returns userID's task counters, counted in one query. Tasks
+// due before the end of the server's day and not yet overdue are due
+// today. Cached counters are dropped when a task of the user is created,
+// changed or deleted, but overdue and due-today counts can lag the clock
+// by the cache's TTL.
+func (s *Service) Counters(ctx context.Context, userID string) (*TaskCounters, error) {
+	if s.counters != nil {
+		counters, found, err := s.counters.Get(ctx, userID)
+		if err != nil {
+			s.logger.Warn("Task counters cache unavailable", zap.Error(err))
+		} else if found {
+			return counters, nil
+		}
+	}
+
+	now := time.Now()
+	args := map[string]interface{}{
+		"user":     userID,
+		"now":      now,
+		"tomorrow": time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location()),
+	}
+	counters := &TaskCounters{}
+	err := s.db.WithContext(ctx).Model(&Task{}).
+		Select(`COUNT(*) AS open,
+			COUNT(*) FILTER (WHERE due_date < @now) AS overdue,
+			COUNT(*) FILTER (WHERE due_date >= @now AND due_date < @tomorrow) AS due_today,
+			COUNT(*) FILTER (WHERE `+assignedTo+`) AS assigned_to_me`, args).
+		Where("status <> 'completed'").
+		Where("tasks.created_by = @user OR "+assignedTo, args).
+		Scan(counters).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count tasks: %w", err)
+	}
+
+	if s.counters != nil {
+		if err := s.counters.Set(ctx, userID, *counters); err != nil {
+			s.logger.Warn("Failed to cache task counters", zap.Error(err))
+		}
+	}
+	return counters, nil
+}
+
+// assignedTo restricts a task query to the tasks assigned to @user
+const assignedTo = `EXISTS (SELECT 1 FROM task_assignees ta WHERE ta.task_id = tasks.id AND ta.user_id = @user)`
+
+// forgetCounters drops the cached counters of the users of tasks and of
+// the other users given, such as assignees a task was taken from
+func (s *Service) forgetCounters(ctx context.Context, tasks []Task, userIDs ...string) {
+	if s.counters == nil {
+		return
+	}
+	seen := make(map[string]bool)
+	var users []string
+	add := func(id string) {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			users = append(users, id)
+		}
+	}
+	for _, task := range tasks {
+		add(task.CreatedBy)
+		add(task.AssignedTo)
+		for _, a := range task.Assignees {
+			add(a.UserID)
+		}
+	}
+	for _, id := range userIDs {
+		add(id)
+	}
+	if len(users) == 0 {
+		return
+	}
+	if err := s.counters.Forget(ctx, users...); err != nil {
+		s.logger.Warn("Failed to drop cached task counters", zap.Strings("user_ids", users), zap.Error(err))
+	}
+}
+
+// MemoryCountersCache keeps task counters in process, for single-replica
+// deployments. Expired counters are never served, but are only dropped by
+// Sweep.
+type MemoryCountersCache struct {
+	cache *cache.Cache
+}
+
+func NewMemoryCountersCache(ttl time.Duration) *MemoryCountersCache {
+	return &MemoryCountersCache{cache: cache.New(ttl, 0)}
+}
+
+func (c *MemoryCountersCache) Get(ctx context.Context, userID string) (*TaskCounters, bool, error) {
+	cached, found := c.cache.Get(userID)
+	if !found {
+		return nil, false, nil
+	}
+	counters := cached.(TaskCounters)
+	return &counters, true, nil
+}
+
+func (c *MemoryCountersCache) Set(ctx context.Context, userID string, counters TaskCounters) error {
+	c.cache.SetDefault(userID, counters)
+	return nil
+}
+
+func (c *MemoryCountersCache) Forget(ctx context.Context, userIDs ...string) error {
+	for _, id := range userIDs {
+		c.cache.Delete(id)
+	}
+	return nil
+}
+
+// Sweep drops the expired counters
+func (c *MemoryCountersCache) Sweep(context.Context) (int, error) {
+	before := c.cache.ItemCount()
+	c.cache.DeleteExpired()
+	// Counters set meanwhile can make the difference negative
+	return max(before-c.cache.ItemCount(), 0), nil
+}
+
+// RedisCountersCache shares task counters across replicas as JSON values
+// that expire after the TTL, so every replica sees a change forgotten by
+// another
+type RedisCountersCache struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+func NewRedisCountersCache(client *redis.Client, ttl time.Duration) *RedisCountersCache {
+	return &RedisCountersCache{client: client, ttl: ttl}
+}
+
+func (c *RedisCountersCache) Get(ctx context.Context, userID string) (*TaskCounters, bool, error) {
+	data, err := c.client.Get(ctx, countersKey(userID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	counters := &TaskCounters{}
+	if err := json.Unmarshal(data, counters); err != nil {
+		return nil, false, err
+	}
+	return counters, true, nil
+}
+
+func (c *RedisCountersCache) Set(ctx context.Context, userID string, counters TaskCounters) error {
+	data, err := json.Marshal(counters)
+	if err != nil {
+		return err
+	}
+	return c.client.Set(ctx, countersKey(userID), data, c.ttl).Err()
+}
+
+func (c *RedisCountersCache) Forget(ctx context.Context, userIDs ...string) error {
+	keys := make([]string, 0, len(userIDs))
+	for _, id := range userIDs {
+		keys = append(keys, countersKey(id))
+	}
+	return c.client.Del(ctx, keys...).Err()
+}
+
+func countersKey(userID string) string {
+	return "task_counters:" + userID
+}
@@ -0,0 +1,66 @@
+package task
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+)
+
+func TestCountersCountsOnceUntilForgotten(t *testing.T) {
+	s, mock := newTestService(t)
+	s.SetCountersCache(NewMemoryCountersCache(time.Minute))
+
+	for i := 0; i < 2; i++ {
+		mock.ExpectQuery(`SELECT COUNT\(\*\) AS open,.*`+
+			`COUNT\(\*\) FILTER \(WHERE due_date < \$1\) AS overdue,.*`+
+			`COUNT\(\*\) FILTER \(WHERE due_date >= \$2 AND due_date < \$3\) AS due_today,.*`+
+			`COUNT\(\*\) FILTER \(WHERE EXISTS \(SELECT 1 FROM task_assignees ta WHERE ta.task_id = tasks.id AND ta.user_id = \$4\)\) AS assigned_to_me `+
+			`FROM "tasks" WHERE status <> 'completed' AND \(tasks.created_by = \$5 OR EXISTS .*\) `+
+			`AND "tasks"."deleted_at" IS NULL`).
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "user-1", "user-1", "user-1").
+			WillReturnRows(sqlmock.NewRows([]string{"open", "overdue", "due_today", "assigned_to_me"}).AddRow(5, 1, 2, 3))
+	}
+
+	for i := 0; i < 2; i++ {
+		counters, err := s.Counters(context.Background(), "user-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := TaskCounters{Open: 5, Overdue: 1, DueToday: 2, AssignedToMe: 3}
+		if *counters != want {
+			t.Fatalf("counters = %+v, want %+v", *counters, want)
+		}
+	}
+
+	// Taking a task from the user drops the user's counters
+	task := Task{ID: "task-1", CreatedBy: "user-2", Assignees: []models.TaskAssignee{{UserID: "user-3"}}}
+	s.forgetCounters(context.Background(), []Task{task}, "user-1")
+	if _, err := s.Counters(context.Background(), "user-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMemoryCountersCacheForgetsTaskUsers(t *testing.T) {
+	s, _ := newTestService(t)
+	cache := NewMemoryCountersCache(time.Minute)
+	s.SetCountersCache(cache)
+	ctx := context.Background()
+	for _, id := range []string{"creator", "assignee", "bystander"} {
+		if err := cache.Set(ctx, id, TaskCounters{Open: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s.forgetCounters(ctx, []Task{{CreatedBy: "creator", AssignedTo: "assignee"}})
+	for id, cached := range map[string]bool{"creator": false, "assignee": false, "bystander": true} {
+		if _, found, _ := cache.Get(ctx, id); found != cached {
+			t.Errorf("%s cached = %v, want %v", id, found, cached)
+		}
+	}
+}
@@ -1,6 +1,11 @@
 package task
 
-import "errors"
+import (
+	"errors"
+	"net/http"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+)
 
 var (
 	ErrTaskNotFound       = errors.New("task not found")
@@ -10,7 +15,72 @@ var (
 	ErrUnauthorized       = errors.New("unauthorized to perform this action")
 	ErrDescriptionTooLong = errors.New("description exceeds maximum length")
 	ErrInvalidAssignment  = errors.New("invalid task assignment")
-	ErrInvalidPageSize    = errors.New("invalid page size")
-	ErrInvalidSortField   = errors.New("invalid sort field")
+	ErrInvalidPage        = errors.New("page must be at least 1")
+	ErrInvalidPageSize    = errors.New("page_size must be between 1 and 100")
+	ErrInvalidSortField   = errors.New("sort_by must be one of created_at, updated_at, due_date, completed_at, title, status, priority")
+	ErrInvalidSortOrder   = errors.New("sort_order must be asc or desc")
 	ErrInvalidTimeFormat  = errors.New("invalid time format")
+	ErrTimerRunning       = errors.New("timer already running for this task")
+	ErrTimerNotRunning    = errors.New("no running timer for this task")
+	ErrInvalidWorklog     = errors.New("invalid worklog entry")
+	ErrInvalidEffort      = errors.New("estimated effort must not be negative")
+	ErrAlreadyAssigned    = errors.New("task is already assigned")
+	ErrTaskLocked         = errors.New("task is being edited by another user")
+	ErrTaskNotArchived    = errors.New("task is not archived")
+
+	ErrInvalidLanguage        = errors.New("lang must be a language tag such as de or pt-BR")
+	ErrTranslationUnavailable = errors.New("translation is unavailable")
+	ErrSimilarityUnsupported  = errors.New("similar tasks need an AI provider with embeddings")
+	ErrSimilarityUnavailable  = errors.New("similar tasks are unavailable")
+	ErrEmptySearch            = errors.New("search query q is required")
+	ErrTypeaheadTooLong       = errors.New("search query q must be at most 100 characters")
+	ErrTemplateNotFound       = errors.New("task template not found")
+	ErrDueDateRequired        = errors.New("due_date is required")
+	ErrChecklistItemNotFound  = errors.New("checklist item not found")
+	ErrEmptyChecklistItem     = errors.New("checklist item text must not be empty")
+	ErrFieldRequired          = errors.New("field is required in this project")
+	ErrFieldHidden            = errors.New("field is not used in this project")
+	ErrInvalidFieldSchema     = errors.New("invalid field schema")
+	ErrDefinitionOfDone       = errors.New("definition of done is not met")
+	ErrInvalidDeadlineType    = errors.New("deadline_type must be soft or hard")
+	ErrHardDeadlinePassed     = errors.New("the task's hard deadline has passed: only its creator can change its deadline or assignees")
+	ErrInvalidImport          = errors.New("invalid import file")
+	ErrTooManyImportRows      = errors.New("import file has too many rows")
+	ErrImportTooLarge         = errors.New("import file is too large")
+	ErrInvalidTransfer        = errors.New("invalid task transfer")
+	ErrTransferPending        = errors.New("task already has a pending transfer")
+	ErrNoPendingTransfer      = errors.New("task has no pending transfer")
+	ErrInvalidSyncCursor      = errors.New("since must be a cursor returned by a previous sync")
+	ErrInvalidSyncLimit       = errors.New("limit must be between 1 and 500")
+	ErrInvalidEscalationRule  = errors.New("invalid escalation rule")
+	ErrInvalidCalendar        = errors.New("invalid business calendar")
+	ErrNonWorkingDueDate      = errors.New("due date is not a working day in the project's calendar")
 )
+
+// errorStatus is the status each error of the package is answered with
+var errorStatus = map[int][]error{
+	http.StatusBadRequest: {
+		ErrInvalidStatus, ErrInvalidPriority, ErrInvalidDueDate, ErrDescriptionTooLong, ErrInvalidAssignment,
+		ErrInvalidPage, ErrInvalidPageSize, ErrInvalidSortField, ErrInvalidSortOrder, ErrInvalidTimeFormat,
+		ErrInvalidWorklog, ErrInvalidEffort, ErrInvalidLanguage, ErrEmptySearch, ErrTypeaheadTooLong,
+		ErrDueDateRequired, ErrEmptyChecklistItem, ErrFieldRequired, ErrFieldHidden, ErrInvalidFieldSchema,
+		ErrInvalidDeadlineType, ErrInvalidImport, ErrTooManyImportRows, ErrInvalidTransfer,
+		ErrInvalidSyncCursor, ErrInvalidSyncLimit, ErrInvalidStrategy, ErrInvalidEscalationRule,
+		ErrInvalidCalendar, ErrNonWorkingDueDate,
+	},
+	http.StatusForbidden:             {ErrUnauthorized},
+	http.StatusNotFound:              {ErrTaskNotFound, ErrTemplateNotFound, ErrChecklistItemNotFound, ErrNoPendingTransfer},
+	http.StatusConflict:              {ErrTimerRunning, ErrTimerNotRunning, ErrAlreadyAssigned, ErrHardDeadlinePassed, ErrTransferPending, ErrTaskLocked, ErrTaskNotArchived},
+	http.StatusRequestEntityTooLarge: {ErrImportTooLarge},
+	http.StatusUpgradeRequired:       {ErrProtocolUnsupported, ErrSchemaUnsupported},
+	http.StatusNotImplemented:        {ErrSimilarityUnsupported},
+	http.StatusServiceUnavailable:    {ErrTranslationUnavailable, ErrSimilarityUnavailable},
+}
+
+func init() {
+	for status, errs := range errorStatus {
+		for _, err := range errs {
+			common.RegisterError(err, status)
+		}
+	}
+}
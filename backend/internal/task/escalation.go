@@ -0,0 +1,253 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// escalationBatchSize bounds how many tasks one escalation claim takes
+const escalationBatchSize = 500
+
+// PriorityEscalator is implemented by the notification service
+type PriorityEscalator interface {
+	EscalatePriority(ctx context.Context, task Task)
+}
+
+// SetPriorityEscalator has the assignees of tasks whose priority the
+// escalation job raised told by escalator
+func (s *Service) SetPriorityEscalator(escalator PriorityEscalator) {
+	s.priorityEscalator = escalator
+}
+
+// StartEscalation raises the priority of pending tasks nearing their due
+// date every interval until ctx is cancelled. Tasks of projects without an
+// escalation rule are escalated within hours of it; zero escalates only
+// the tasks of projects with a rule.
+func (s *Service) StartEscalation(ctx context.Context, interval time.Duration, within int) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			n, err := s.EscalateTasks(ctx, time.Now(), within)
+			if err != nil && ctx.Err() == nil {
+				s.logger.Error("Failed to escalate tasks", zap.Error(err))
+			}
+			if n > 0 {
+				s.logger.Info("Escalated tasks", zap.Int("count", n))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// escalatedTask is a task the escalation claim raised
+type escalatedTask struct {
+	ID           string
+	FromPriority TaskPriority
+	ToPriority   TaskPriority
+	DueDate      time.Time
+	WithinHours  int
+}
+
+// escalationHorizon is when the escalation window after now ends for the
+// tasks of a project with a business calendar
+type escalationHorizon struct {
+	Project string    `json:"project"`
+	Until   time.Time `json:"until"`
+}
+
+// escalationHorizons returns, as JSON, the end of the escalation window
+// after now of each project with a business calendar, whose window counts
+// only the hours of its working days
+func (s *Service) escalationHorizons(ctx context.Context, now time.Time, within int) (string, error) {
+	var calendars []ProjectCalendar
+	if err := s.db.WithContext(ctx).Find(&calendars).Error; err != nil {
+		return "", err
+	}
+	horizons := []escalationHorizon{}
+	if len(calendars) > 0 {
+		projects := make([]string, len(calendars))
+		for i, calendar := range calendars {
+			projects[i] = calendar.Project
+		}
+		var rules []ProjectEscalationRule
+		if err := s.db.WithContext(ctx).Where("project IN ?", projects).Find(&rules).Error; err != nil {
+			return "", err
+		}
+		hours := make(map[string]int, len(rules))
+		for _, rule := range rules {
+			hours[rule.Project] = rule.WithinHours
+		}
+		for _, calendar := range calendars {
+			h, ok := hours[calendar.Project]
+			if !ok {
+				h = within
+			}
+			if h > 0 {
+				horizons = append(horizons, escalationHorizon{Project: calendar.Project, Until: calendar.AddWorkingHours(now, h)})
+			}
+		}
+	}
+	encoded, err := json.Marshal(horizons)
+	return string(encoded), err
+}
+
+// EscalateTasks raises the priority of each pending, unarchived task due
+// within its project's escalation window after now by one step, low to
+// medium or medium to high, and returns how many it raised. The window of
+// a project with a business calendar counts only the hours of its working
+// days. A task is escalated once per due date; moving the due date lets it
+// escalate again. Tasks are claimed with skip-locked row locks as
+// EmitOverdue claims them, and each raise is logged to the task's
+// escalation history.
+func (s *Service) EscalateTasks(ctx context.Context, now time.Time, within int) (int, error) {
+	horizons, err := s.escalationHorizons(ctx, now, within)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load business calendars: %w", err)
+	}
+
+	escalated := 0
+	for {
+		var claimed []escalatedTask
+		err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Raw(`
+				UPDATE tasks t SET
+					priority = CASE WHEN c.priority = 'low' THEN 'medium' ELSE 'high' END,
+					escalated_at = @now, updated_at = @now
+				FROM (
+					SELECT t2.id, t2.priority, COALESCE(r.within_hours, @within) AS within_hours
+					FROM tasks t2
+					LEFT JOIN project_escalation_rules r ON r.project = t2.project
+					LEFT JOIN jsonb_to_recordset(CAST(@horizons AS jsonb)) AS h(project text, until timestamptz)
+						ON h.project = t2.project
+					WHERE t2.deleted_at IS NULL AND t2.archived_at IS NULL
+						AND t2.status = 'pending' AND t2.priority <> 'high'
+						AND COALESCE(r.within_hours, @within) > 0
+						AND t2.due_date > @now
+						AND t2.due_date <= COALESCE(h.until, @now + make_interval(hours => COALESCE(r.within_hours, @within)))
+						AND NOT EXISTS (
+							SELECT 1 FROM task_escalations e
+							WHERE e.task_id = t2.id AND e.due_date = t2.due_date)
+					ORDER BY t2.due_date
+					LIMIT @limit
+					FOR UPDATE OF t2 SKIP LOCKED) c
+				WHERE t.id = c.id
+				RETURNING t.id, c.priority AS from_priority, t.priority AS to_priority, t.due_date, c.within_hours`,
+				map[string]interface{}{"now": now, "within": within, "horizons": horizons, "limit": escalationBatchSize}).
+				Scan(&claimed).Error; err != nil {
+				return err
+			}
+			if len(claimed) == 0 {
+				return nil
+			}
+
+			history := make([]TaskEscalation, len(claimed))
+			for i, c := range claimed {
+				history[i] = TaskEscalation{
+					TaskID:       c.ID,
+					FromPriority: c.FromPriority,
+					ToPriority:   c.ToPriority,
+					DueDate:      c.DueDate,
+					WithinHours:  c.WithinHours,
+					CreatedAt:    now,
+				}
+			}
+			return tx.Create(&history).Error
+		})
+		if err != nil {
+			return escalated, fmt.Errorf("failed to escalate tasks: %w", err)
+		}
+		if len(claimed) == 0 {
+			return escalated, nil
+		}
+
+		ids := make([]string, len(claimed))
+		for i, c := range claimed {
+			ids[i] = c.ID
+		}
+		var tasks []Task
+		if err := s.db.WithContext(ctx).Preload("Assignees").
+			Where("id IN ?", ids).
+			Order("due_date ASC").
+			Find(&tasks).Error; err != nil {
+			return escalated, fmt.Errorf("failed to load escalated tasks: %w", err)
+		}
+		for _, task := range tasks {
+			s.publish(TaskUpdatedEvent(task))
+			s.notifyFollowers(ctx, MessageTypeTaskUpdated, task)
+			if s.priorityEscalator != nil {
+				s.priorityEscalator.EscalatePriority(ctx, task)
+			}
+			escalated++
+		}
+		if len(claimed) < escalationBatchSize {
+			return escalated, nil
+		}
+	}
+}
+
+// GetEscalationRule returns the project's escalation rule; nil if the
+// project has none and its tasks use the default window
+func (s *Service) GetEscalationRule(ctx context.Context, project string) (*ProjectEscalationRule, error) {
+	var rule ProjectEscalationRule
+	err := s.db.WithContext(ctx).First(&rule, "project = ?", project).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// SetEscalationRule has the project's pending tasks escalated within the
+// given hours of their due date, or never for zero. Tasks already
+// escalated for their due date are not escalated again.
+func (s *Service) SetEscalationRule(ctx context.Context, project string, req EscalationRuleRequest, userID string) (*ProjectEscalationRule, error) {
+	if strings.TrimSpace(project) == "" || len(project) > 100 {
+		return nil, fmt.Errorf("%w: project must be 1 to 100 characters", ErrInvalidEscalationRule)
+	}
+
+	rule := &ProjectEscalationRule{
+		Project:     project,
+		WithinHours: *req.WithinHours,
+		UpdatedBy:   userID,
+		UpdatedAt:   time.Now(),
+	}
+	if err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "project"}},
+		DoUpdates: clause.AssignmentColumns([]string{"within_hours", "updated_by", "updated_at"}),
+	}).Create(rule).Error; err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// TaskEscalations lists the priority escalations of a task userID can see
+func (s *Service) TaskEscalations(ctx context.Context, taskID, userID string) (*EscalationHistoryResponse, error) {
+	if _, err := s.findVisibleTask(ctx, taskID, userID); err != nil {
+		return nil, err
+	}
+
+	escalations := []TaskEscalation{}
+	if err := s.db.WithContext(ctx).Where("task_id = ?", taskID).
+		Order("created_at ASC").
+		Find(&escalations).Error; err != nil {
+		return nil, err
+	}
+	return &EscalationHistoryResponse{TaskID: taskID, Escalations: escalations}, nil
+}
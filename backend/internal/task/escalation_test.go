@@ -0,0 +1,150 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type fakePriorityEscalator struct {
+	tasks []string
+}
+
+func (f *fakePriorityEscalator) EscalatePriority(ctx context.Context, task Task) {
+	f.tasks = append(f.tasks, task.ID+":"+string(task.Priority))
+}
+
+// expectCalendars loads every business calendar, given as the project of
+// each, with its tasks worked Monday to Friday in Europe/Berlin
+func expectCalendars(mock sqlmock.Sqlmock, projects ...string) {
+	rows := sqlmock.NewRows([]string{"project", "timezone", "working_days", "holidays"})
+	for _, project := range projects {
+		rows.AddRow(project, "Europe/Berlin", `[1,2,3,4,5]`, `[]`)
+	}
+	mock.ExpectQuery(`SELECT \* FROM "project_calendars"$`).WillReturnRows(rows)
+}
+
+func TestEscalateTasksRaisesAndLogsClaimedTasks(t *testing.T) {
+	s, mock := newTestService(t)
+	escalator := &fakePriorityEscalator{}
+	s.SetPriorityEscalator(escalator)
+	now := time.Date(2024, 3, 11, 17, 0, 0, 0, time.UTC)
+	due := now.Add(20 * time.Hour)
+
+	expectCalendars(mock)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE tasks t SET priority = CASE WHEN c.priority = 'low' THEN 'medium' ELSE 'high' END, ` +
+		`escalated_at = \$1, updated_at = \$2 FROM \( SELECT t2.id, t2.priority, COALESCE\(r.within_hours, \$3\) AS within_hours ` +
+		`FROM tasks t2 LEFT JOIN project_escalation_rules r ON r.project = t2.project .*` +
+		`AND t2.status = 'pending' AND t2.priority <> 'high' .*FOR UPDATE OF t2 SKIP LOCKED\) c WHERE t.id = c.id RETURNING`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "from_priority", "to_priority", "due_date", "within_hours"}).
+			AddRow("task-1", "low", "medium", due, 24))
+	mock.ExpectQuery(`INSERT INTO "task_escalations" \("task_id","from_priority","to_priority","due_date","within_hours","created_at"\)`).
+		WithArgs("task-1", "low", "medium", due, 24, now).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("escalation-1"))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`SELECT \* FROM "tasks" WHERE id IN \(\$1\)`).
+		WithArgs("task-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status", "priority", "due_date"}).
+			AddRow("task-1", "pending", "medium", due))
+	mock.ExpectQuery(`SELECT \* FROM "task_assignees" WHERE "task_assignees"."task_id" = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"task_id", "user_id"}))
+
+	escalated, err := s.EscalateTasks(context.Background(), now, 24)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if escalated != 1 || len(escalator.tasks) != 1 || escalator.tasks[0] != "task-1:medium" {
+		t.Fatalf("escalated = %d and notified %v, want task-1 raised to medium", escalated, escalator.tasks)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEscalateTasksCountsWorkingHoursOfCalendarProjects(t *testing.T) {
+	s, mock := newTestService(t)
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	// Friday 8 March 2024, 17:00 in Berlin
+	now := time.Date(2024, 3, 8, 17, 0, 0, 0, berlin)
+
+	expectCalendars(mock, "web")
+	mock.ExpectQuery(`SELECT \* FROM "project_escalation_rules" WHERE project IN \(\$1\)`).
+		WithArgs("web").
+		WillReturnRows(sqlmock.NewRows([]string{"project", "within_hours"}))
+	// 24 working hours skip the weekend: 7 on Friday and 17 on Monday
+	horizons, _ := json.Marshal([]escalationHorizon{{Project: "web", Until: time.Date(2024, 3, 11, 17, 0, 0, 0, berlin)}})
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE tasks t SET priority .*LEFT JOIN jsonb_to_recordset\(CAST\(\$4 AS jsonb\)\) AS h\(project text, until timestamptz\)`).
+		WithArgs(now, now, 24, string(horizons), 24, now, now, 24, escalationBatchSize).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "from_priority", "to_priority", "due_date", "within_hours"}))
+	mock.ExpectCommit()
+
+	if _, err := s.EscalateTasks(context.Background(), now, 24); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEscalateTasksWithNothingDue(t *testing.T) {
+	s, mock := newTestService(t)
+	expectCalendars(mock)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE tasks t SET priority`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "from_priority", "to_priority", "due_date", "within_hours"}))
+	mock.ExpectCommit()
+
+	escalated, err := s.EscalateTasks(context.Background(), time.Now(), 24)
+	if err != nil || escalated != 0 {
+		t.Fatalf("escalated = %d, %v, want none", escalated, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSetEscalationRuleRejectsLongProjectNames(t *testing.T) {
+	s, _ := newTestService(t)
+	hours := 48
+	project := string(make([]byte, 101))
+	_, err := s.SetEscalationRule(context.Background(), project, EscalationRuleRequest{WithinHours: &hours}, "admin-1")
+	if !errors.Is(err, ErrInvalidEscalationRule) {
+		t.Fatalf("err = %v, want ErrInvalidEscalationRule", err)
+	}
+}
+
+func TestGetEscalationRuleWithoutRule(t *testing.T) {
+	s, mock := newTestService(t)
+	mock.ExpectQuery(`SELECT \* FROM "project_escalation_rules" WHERE project = \$1`).
+		WithArgs("web", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"project", "within_hours"}))
+
+	rule, err := s.GetEscalationRule(context.Background(), "web")
+	if err != nil || rule != nil {
+		t.Fatalf("rule = %+v, %v, want none", rule, err)
+	}
+}
+
+func TestSetEscalationRuleUpdatesStoredRule(t *testing.T) {
+	s, mock := newTestService(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "project_escalation_rules" .* ON CONFLICT \("project"\) DO UPDATE SET `+
+		`"within_hours"="excluded"."within_hours","updated_by"="excluded"."updated_by","updated_at"="excluded"."updated_at"`).
+		WithArgs("web", 48, "admin-1", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(time.Now()))
+	mock.ExpectCommit()
+
+	hours := 48
+	if _, err := s.SetEscalationRule(context.Background(), "web", EscalationRuleRequest{WithinHours: &hours}, "admin-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
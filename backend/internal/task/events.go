@@ -0,0 +1,121 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Event schema versions. A message's SchemaVersion is the version of its
+// payload; the protocol version only decides how messages are framed.
+//
+// Adding a field to a payload keeps the version. Removing or renaming a
+// field, or changing its type or meaning, needs a new version: raise
+// LatestSchemaVersion, build the new payload in the typed constructor for
+// the event and add a downgrade to the previous version, so frontends
+// released before the change keep working. Clients ask for a version when
+// they connect and get the oldest supported one if they do not. At least
+// the version before the latest is always served; older ones are retired
+// by raising MinSchemaVersion and removing their downgrade.
+const (
+	MinSchemaVersion    = 1
+	LatestSchemaVersion = 2
+)
+
+var ErrSchemaUnsupported = errors.New("unsupported event schema version")
+
+// downgrades turn a message of a schema version into one of the version
+// before it. Version 2 sends a TaskDeleted for deleted tasks instead of a
+// stub Task.
+var downgrades = map[int]func(WebSocketMessage) WebSocketMessage{
+	2: func(msg WebSocketMessage) WebSocketMessage {
+		if deleted, ok := msg.Payload.(TaskDeleted); ok && msg.Type == MessageTypeTaskDeleted {
+			msg.Payload = Task{ID: deleted.TaskID, Status: "deleted"}
+		}
+		return msg
+	},
+}
+
+// NegotiateSchema picks the event schema version for a client asking for
+// requested: the latest one if the client is newer than the server
+func NegotiateSchema(requested int) (int, error) {
+	if requested > LatestSchemaVersion {
+		requested = LatestSchemaVersion
+	}
+	if requested < MinSchemaVersion {
+		return 0, fmt.Errorf("%w: %d, the oldest supported is %d", ErrSchemaUnsupported, requested, MinSchemaVersion)
+	}
+	return requested, nil
+}
+
+// forSchema returns msg as a client of schema version would expect it.
+// Messages without a version are taken to be the latest.
+func (msg WebSocketMessage) forSchema(version int) WebSocketMessage {
+	if msg.SchemaVersion == 0 {
+		msg.SchemaVersion = LatestSchemaVersion
+	}
+	for msg.SchemaVersion > max(version, MinSchemaVersion) {
+		if downgrade := downgrades[msg.SchemaVersion]; downgrade != nil {
+			msg = downgrade(msg)
+		}
+		msg.SchemaVersion--
+	}
+	return msg
+}
+
+// TaskDeleted is the payload of a task_deleted message
+type TaskDeleted struct {
+	TaskID  string `json:"task_id"`
+	Project string `json:"project,omitempty"`
+}
+
+func TaskCreatedEvent(task Task) WebSocketMessage {
+	return NewWebSocketMessage(MessageTypeTaskCreated, task)
+}
+
+func TaskUpdatedEvent(task Task) WebSocketMessage {
+	return NewWebSocketMessage(MessageTypeTaskUpdated, task)
+}
+
+func TaskOverdueEvent(task Task) WebSocketMessage {
+	return NewWebSocketMessage(MessageTypeTaskOverdue, task)
+}
+
+func TaskDeletedEvent(task Task) WebSocketMessage {
+	return NewWebSocketMessage(MessageTypeTaskDeleted, TaskDeleted{TaskID: task.ID, Project: task.Project})
+}
+
+// ChecklistEvent announces a created, updated or deleted checklist item
+func ChecklistEvent(msgType MessageType, update ChecklistUpdate) WebSocketMessage {
+	return NewWebSocketMessage(msgType, update)
+}
+
+// TaskNotificationEvent is sent to the followers of notification's task
+// and to connections subscribed to its project
+func TaskNotificationEvent(notification TaskNotification) WebSocketMessage {
+	msg := NewWebSocketMessage(MessageTypeTaskNotification, notification)
+	msg.project = notification.Task.Project
+	return msg
+}
+
+func TaskTransferEvent(transfer TaskTransfer) WebSocketMessage {
+	return NewWebSocketMessage(MessageTypeTaskTransfer, transfer)
+}
+
+// PresenceEvent announces a user's first connection to the replica, with
+// presence_join, or the last one closing, with presence_leave
+func PresenceEvent(msgType MessageType, userID string) WebSocketMessage {
+	msg := NewWebSocketMessage(msgType, PresenceChange{UserID: userID})
+	msg.presence = true
+	return msg
+}
+
+// TaskViewingEvent announces a change of a user's viewing state on a task
+func TaskViewingEvent(viewing TaskViewing) WebSocketMessage {
+	return NewWebSocketMessage(MessageTypeTaskViewing, viewing)
+}
+
+// TaskLockEvent announces that a task's edit lock was taken, renewed or
+// released
+func TaskLockEvent(state TaskLockState) WebSocketMessage {
+	return NewWebSocketMessage(MessageTypeTaskLock, state)
+}
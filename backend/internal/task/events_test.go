@@ -0,0 +1,62 @@
+package task
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestForSchemaDowngradesDeletedTask(t *testing.T) {
+	msg := TaskDeletedEvent(Task{ID: "task-1", Project: "web"})
+	if got := msg.forSchema(LatestSchemaVersion); got.Payload != (TaskDeleted{TaskID: "task-1", Project: "web"}) {
+		t.Fatalf("latest payload = %#v, want TaskDeleted", got.Payload)
+	}
+
+	got := msg.forSchema(1)
+	if got.SchemaVersion != 1 {
+		t.Fatalf("schema version = %d, want 1", got.SchemaVersion)
+	}
+	if stub, ok := got.Payload.(Task); !ok || stub.ID != "task-1" || stub.Status != "deleted" || stub.Project != "" {
+		t.Fatalf("version 1 payload = %#v, want the deleted task stub", got.Payload)
+	}
+
+	// Payloads that did not change between versions are left alone
+	updated := TaskUpdatedEvent(Task{ID: "task-1"}).forSchema(1)
+	if updated.SchemaVersion != 1 || updated.Payload.(Task).ID != "task-1" {
+		t.Fatalf("downgraded update = %#v", updated)
+	}
+}
+
+func TestClientReceivesItsSchemaVersion(t *testing.T) {
+	s, _ := newTestService(t)
+	if _, resp, err := dialProtocol(t, s, "?schema=0"); err == nil || resp.StatusCode != http.StatusUpgradeRequired {
+		t.Fatalf("schema 0 = %v, want 426", err)
+	}
+
+	conn, resp, err := dialProtocol(t, s, "?schema=99")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Header.Get("X-Schema-Version"); got != "2" {
+		t.Fatalf("X-Schema-Version = %q, want the latest version", got)
+	}
+	deadline := time.Now().Add(time.Second)
+	for s.ConnectedClients() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	s.publish(TaskDeletedEvent(Task{ID: "task-1"}))
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	var msg struct {
+		EventID       string          `json:"event_id"`
+		SchemaVersion int             `json:"schema_version"`
+		Payload       json.RawMessage `json:"payload"`
+	}
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.EventID == "" || msg.SchemaVersion != 2 || string(msg.Payload) != `{"task_id":"task-1"}` {
+		t.Fatalf("message = %+v (payload %s), want a version 2 deletion", msg, msg.Payload)
+	}
+}
@@ -0,0 +1,271 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Field requirements of a project's field schema
+const (
+	FieldRequired = "required"
+	FieldOptional = "optional"
+	FieldHidden   = "hidden"
+)
+
+// configurableFields lists the task fields a project can configure, with
+// the requirements each allows; the first is the default. The due date is
+// always required because tasks cannot be stored without one.
+var configurableFields = map[string][]string{
+	"description":      {FieldOptional, FieldRequired, FieldHidden},
+	"due_date":         {FieldRequired},
+	"estimated_effort": {FieldOptional, FieldRequired, FieldHidden},
+}
+
+// fieldIsSet reports whether the task has a value for a configurable field
+func fieldIsSet(task *Task, field string) bool {
+	switch field {
+	case "description":
+		return strings.TrimSpace(task.Description) != ""
+	case "due_date":
+		return !task.DueDate.IsZero()
+	case "estimated_effort":
+		return task.EstimatedEffort > 0
+	}
+	return false
+}
+
+func defaultFieldSchema(project string) *ProjectFieldSchema {
+	fields := make(map[string]string, len(configurableFields))
+	for field, allowed := range configurableFields {
+		fields[field] = allowed[0]
+	}
+	return &ProjectFieldSchema{Project: project, Fields: fields, DefinitionOfDone: []string{}, DeadlineType: models.DeadlineSoft}
+}
+
+// GetFieldSchema returns the project's field schema, with the default
+// requirement for every field it does not configure
+func (s *Service) GetFieldSchema(ctx context.Context, project string) (*ProjectFieldSchema, error) {
+	schema := defaultFieldSchema(project)
+	var stored ProjectFieldSchema
+	err := s.db.WithContext(ctx).First(&stored, "project = ?", project).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return schema, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for field, requirement := range stored.Fields {
+		if _, ok := configurableFields[field]; ok {
+			schema.Fields[field] = requirement
+		}
+	}
+	if stored.DefinitionOfDone != nil {
+		schema.DefinitionOfDone = stored.DefinitionOfDone
+	}
+	if stored.DeadlineType != "" {
+		schema.DeadlineType = stored.DeadlineType
+	}
+	schema.UpdatedBy = stored.UpdatedBy
+	schema.UpdatedAt = stored.UpdatedAt
+	return schema, nil
+}
+
+// SetFieldSchema changes the requirements of the given fields for the
+// project's tasks, and its definition of done and default deadline type if
+// given. Existing tasks are only checked against the new schema when those
+// fields are next changed, and against the definition of done when next
+// completed; their deadline types are kept.
+func (s *Service) SetFieldSchema(ctx context.Context, project string, req FieldSchemaRequest, userID string) (*ProjectFieldSchema, error) {
+	if strings.TrimSpace(project) == "" || len(project) > 100 {
+		return nil, fmt.Errorf("%w: project must be 1 to 100 characters", ErrInvalidFieldSchema)
+	}
+	for field, requirement := range req.Fields {
+		allowed, ok := configurableFields[field]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown field %q, want one of %s",
+				ErrInvalidFieldSchema, field, strings.Join(configurableFieldNames(), ", "))
+		}
+		if !containsString(allowed, requirement) {
+			return nil, fmt.Errorf("%w: %s must be one of %s",
+				ErrInvalidFieldSchema, field, strings.Join(allowed, ", "))
+		}
+	}
+
+	if req.DeadlineType != nil && !isValidDeadlineType(models.DeadlineType(*req.DeadlineType)) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFieldSchema, ErrInvalidDeadlineType)
+	}
+
+	var definition []string
+	if req.DefinitionOfDone != nil {
+		definition = make([]string, 0, len(*req.DefinitionOfDone))
+		for _, item := range *req.DefinitionOfDone {
+			item = strings.TrimSpace(item)
+			if item == "" {
+				return nil, fmt.Errorf("%w: definition of done items must not be empty", ErrInvalidFieldSchema)
+			}
+			if indexFold(definition, item) >= 0 {
+				return nil, fmt.Errorf("%w: definition of done lists %q twice", ErrInvalidFieldSchema, item)
+			}
+			definition = append(definition, item)
+		}
+	}
+
+	schema, err := s.GetFieldSchema(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+	for field, requirement := range req.Fields {
+		schema.Fields[field] = requirement
+	}
+	if definition != nil {
+		schema.DefinitionOfDone = definition
+	}
+	if req.DeadlineType != nil {
+		schema.DeadlineType = models.DeadlineType(*req.DeadlineType)
+	}
+	schema.UpdatedBy = userID
+	schema.UpdatedAt = time.Now()
+
+	if err := s.db.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(schema).Error; err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// validateProjectFields checks the task against its project's field
+// schema, which it returns, and its due date against the project's
+// calendar; nil for a task without a project. Only fields for which
+// changed returns true are checked, so a stricter schema or calendar does
+// not block unrelated edits to older tasks.
+func (s *Service) validateProjectFields(ctx context.Context, task *Task, changed func(field string) bool) (*ProjectFieldSchema, error) {
+	if task.Project == "" {
+		return nil, nil
+	}
+	schema, err := s.GetFieldSchema(ctx, task.Project)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkFieldSchema(schema, task, changed); err != nil {
+		return schema, err
+	}
+	if changed("due_date") && !task.DueDate.IsZero() {
+		return schema, s.checkWorkingDay(ctx, task.Project, task.DueDate)
+	}
+	return schema, nil
+}
+
+// checkFieldSchema checks the fields of task for which changed returns true
+// against an already loaded schema
+func checkFieldSchema(schema *ProjectFieldSchema, task *Task, changed func(field string) bool) error {
+	for _, field := range configurableFieldNames() {
+		if !changed(field) {
+			continue
+		}
+		switch set := fieldIsSet(task, field); {
+		case schema.Fields[field] == FieldRequired && !set:
+			return fmt.Errorf("%w: %s", ErrFieldRequired, field)
+		case schema.Fields[field] == FieldHidden && set:
+			return fmt.Errorf("%w: %s", ErrFieldHidden, field)
+		}
+	}
+	return nil
+}
+
+func configurableFieldNames() []string {
+	names := make([]string, 0, len(configurableFields))
+	for field := range configurableFields {
+		names = append(names, field)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// allFields is the changed func for new tasks
+func allFields(string) bool { return true }
+
+// DefinitionOfDoneError lists the items of the project's definition of
+// done a task has not checked, in the project's order
+type DefinitionOfDoneError struct {
+	Unmet []string
+}
+
+func (e *DefinitionOfDoneError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrDefinitionOfDone, strings.Join(e.Unmet, ", "))
+}
+
+func (e *DefinitionOfDoneError) Unwrap() error {
+	return ErrDefinitionOfDone
+}
+
+// checkDefinitionOfDone returns a DefinitionOfDoneError unless each item of
+// definition is on the task's checklist, read through db, and checked.
+// Items match their checklist item regardless of case.
+func checkDefinitionOfDone(ctx context.Context, db *gorm.DB, taskID string, definition []string) error {
+	if len(definition) == 0 {
+		return nil
+	}
+	var items []ChecklistItem
+	if err := db.WithContext(ctx).Select("text", "done").Where("task_id = ?", taskID).Find(&items).Error; err != nil {
+		return fmt.Errorf("failed to load checklist: %w", err)
+	}
+	checked := make([]string, 0, len(items))
+	for _, item := range items {
+		if item.Done {
+			checked = append(checked, item.Text)
+		}
+	}
+
+	var unmet []string
+	for _, item := range definition {
+		if indexFold(checked, item) < 0 {
+			unmet = append(unmet, item)
+		}
+	}
+	if len(unmet) > 0 {
+		return &DefinitionOfDoneError{Unmet: unmet}
+	}
+	return nil
+}
+
+// withDefinitionOfDone adds the items of definition a new task's checklist
+// does not have yet to its end
+func withDefinitionOfDone(taskID string, checklist []ChecklistItem, definition []string) []ChecklistItem {
+	texts := checklistTexts(checklist)
+	now := time.Now()
+	for _, item := range definition {
+		if indexFold(texts, item) >= 0 {
+			continue
+		}
+		texts = append(texts, item)
+		checklist = append(checklist, ChecklistItem{
+			ID:        uuid.New().String(),
+			TaskID:    taskID,
+			Text:      item,
+			Position:  len(checklist),
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+	}
+	return checklist
+}
+
+// indexFold returns the index of the first of values equal to value
+// regardless of case, or -1
+func indexFold(values []string, value string) int {
+	for i, v := range values {
+		if strings.EqualFold(v, value) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,165 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// expectFieldSchema loads the project's stored schema, given as its JSON
+// fields, or finds none when fields is empty
+func expectFieldSchema(mock sqlmock.Sqlmock, project, fields string) {
+	rows := sqlmock.NewRows([]string{"project", "fields"})
+	if fields != "" {
+		rows.AddRow(project, fields)
+	}
+	mock.ExpectQuery(`SELECT \* FROM "project_field_schemas" WHERE project = \$1`).
+		WithArgs(project, 1).
+		WillReturnRows(rows)
+}
+
+func TestGetFieldSchemaFillsDefaults(t *testing.T) {
+	s, mock := newTestService(t)
+	expectFieldSchema(mock, "web", `{"description":"required","labels":"hidden"}`)
+
+	schema, err := s.GetFieldSchema(context.Background(), "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"description": "required", "due_date": "required", "estimated_effort": "optional"}
+	if len(schema.Fields) != len(want) {
+		t.Fatalf("fields = %v, want %v", schema.Fields, want)
+	}
+	for field, requirement := range want {
+		if schema.Fields[field] != requirement {
+			t.Fatalf("fields = %v, want %v", schema.Fields, want)
+		}
+	}
+}
+
+func TestSetFieldSchemaRejectsUnsupportedRequirements(t *testing.T) {
+	s, _ := newTestService(t)
+	for _, fields := range []map[string]string{
+		{"labels": "required"},
+		{"due_date": "hidden"},
+		{"description": "mandatory"},
+	} {
+		_, err := s.SetFieldSchema(context.Background(), "web", FieldSchemaRequest{Fields: fields}, "admin-1")
+		if !errors.Is(err, ErrInvalidFieldSchema) {
+			t.Fatalf("fields %v: err = %v, want ErrInvalidFieldSchema", fields, err)
+		}
+	}
+	for _, definition := range [][]string{
+		{"Tests pass", "  "},
+		{"Tests pass", "tests pass"},
+	} {
+		_, err := s.SetFieldSchema(context.Background(), "web", FieldSchemaRequest{DefinitionOfDone: &definition}, "admin-1")
+		if !errors.Is(err, ErrInvalidFieldSchema) {
+			t.Fatalf("definition of done %q: err = %v, want ErrInvalidFieldSchema", definition, err)
+		}
+	}
+}
+
+func TestWithDefinitionOfDoneAddsMissingItems(t *testing.T) {
+	checklist, err := newChecklist("task-1", []string{"Reproduce", "tests pass"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checklist = withDefinitionOfDone("task-1", checklist, []string{"Tests pass", "Reviewed"})
+	if got := checklistTexts(checklist); len(got) != 3 || got[2] != "Reviewed" || checklist[2].Position != 2 {
+		t.Fatalf("checklist = %q, want Reviewed added last", got)
+	}
+}
+
+func TestUpdateTaskEnforcesDefinitionOfDone(t *testing.T) {
+	s, mock := newTestService(t)
+	mock.ExpectQuery(`SELECT \* FROM "tasks" WHERE id = \$1`).
+		WithArgs("task-1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "status", "priority", "project", "created_by", "due_date"}).
+			AddRow("task-1", "Ship it", "in_progress", "high", "web", "user-1", time.Now().Add(time.Hour)))
+	mock.ExpectQuery(`SELECT \* FROM "task_assignees"`).
+		WillReturnRows(sqlmock.NewRows([]string{"task_id", "user_id"}))
+	mock.ExpectQuery(`SELECT \* FROM "project_field_schemas" WHERE project = \$1`).
+		WithArgs("web", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"project", "fields", "definition_of_done"}).
+			AddRow("web", `{}`, `["Tests pass","Docs updated","Reviewed"]`))
+	mock.ExpectQuery(`SELECT "text","done" FROM "checklist_items" WHERE task_id = \$1`).
+		WithArgs("task-1").
+		WillReturnRows(sqlmock.NewRows([]string{"text", "done"}).
+			AddRow("tests pass", true).
+			AddRow("Docs updated", false))
+
+	status := "completed"
+	_, err := s.UpdateTask(context.Background(), "task-1", UpdateTaskRequest{Status: &status}, "user-1")
+	var unmet *DefinitionOfDoneError
+	if !errors.As(err, &unmet) || !errors.Is(err, ErrDefinitionOfDone) {
+		t.Fatalf("err = %v, want a DefinitionOfDoneError", err)
+	}
+	if len(unmet.Unmet) != 2 || unmet.Unmet[0] != "Docs updated" || unmet.Unmet[1] != "Reviewed" {
+		t.Fatalf("unmet = %q, want the unchecked and missing items", unmet.Unmet)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCreateTaskValidatesProjectFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     CreateTaskRequest
+		wantErr error
+	}{
+		{
+			name:    "missing required description",
+			req:     CreateTaskRequest{Title: "Ship it", EstimatedEffort: 3},
+			wantErr: ErrFieldRequired,
+		},
+		{
+			name:    "hidden estimate given",
+			req:     CreateTaskRequest{Title: "Ship it", Description: "Release 2.0", EstimatedEffort: 3},
+			wantErr: ErrFieldHidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock := newTestService(t)
+			expectFieldSchema(mock, "web", `{"description":"required","estimated_effort":"hidden"}`)
+
+			tt.req.Priority = "medium"
+			tt.req.Project = "web"
+			tt.req.DueDate = time.Now().Add(time.Hour)
+			if _, err := s.CreateTask(context.Background(), tt.req, "user-1"); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUpdateTaskOnlyChecksChangedFields(t *testing.T) {
+	s, mock := newTestService(t)
+	mock.ExpectQuery(`SELECT \* FROM "tasks" WHERE id = \$1`).
+		WithArgs("task-1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "priority", "project", "created_by", "due_date"}).
+			AddRow("task-1", "Old task", "low", "web", "user-1", time.Now().Add(time.Hour)))
+	mock.ExpectQuery(`SELECT \* FROM "task_assignees"`).
+		WillReturnRows(sqlmock.NewRows([]string{"task_id", "user_id"}))
+	expectFieldSchema(mock, "web", `{"description":"required"}`)
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "tasks"`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectChecklistProgress(mock, 0, 0)
+	mock.ExpectQuery(`SELECT COALESCE\(SUM\(duration_seconds\), 0\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(0))
+
+	priority := "high"
+	if _, err := s.UpdateTask(context.Background(), "task-1", UpdateTaskRequest{Priority: &priority}, "user-1"); err != nil {
+		t.Fatalf("err = %v, want the priority change allowed without a description", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,11 +1,19 @@
 package task
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"github.com/iSparshP/real-time-task-management-system/internal/auth"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
 	"go.uber.org/zap"
 )
 
@@ -22,6 +30,8 @@ func NewHandler(service *Service, logger *zap.Logger) *Handler {
 		upgrader: websocket.Upgrader{
 			ReadBufferSize:  1024,
 			WriteBufferSize: 1024,
+			// Browsers must get back the subprotocol that carried their token
+			Subprotocols: []string{auth.WebSocketSubprotocol},
 			CheckOrigin: func(r *http.Request) bool {
 				// Implement proper origin checking in production
 				return true
@@ -30,24 +40,78 @@ func NewHandler(service *Service, logger *zap.Logger) *Handler {
 	}
 }
 
+// fail answers err: errors of the package with their status, anything else
+// as a logged internal error while trying to do action
+func (h *Handler) fail(c *gin.Context, err error, action string) {
+	if _, expected := common.ToAppError(err); !expected {
+		h.logger.Error("Failed to "+action, zap.Error(err))
+		err = common.NewInternalServerError("failed to " + action)
+	}
+	_ = c.Error(err)
+}
+
 func (h *Handler) WebSocket(c *gin.Context) {
-	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	// The protocol is negotiated with the query parameter, or else with a
+	// hello as the first frame while the legacy protocol is allowed
+	version := LegacyProtocolVersion
+	negotiated := c.Query("protocol") != ""
+	if negotiated {
+		requested, err := strconv.Atoi(c.Query("protocol"))
+		if err != nil {
+			_ = c.Error(common.NewInvalidInputError("protocol must be a version number"))
+			return
+		}
+		if version, err = h.service.NegotiateProtocol(requested); err != nil {
+			_ = c.Error(err)
+			return
+		}
+	} else if !h.service.LegacyProtocolAllowed() {
+		_ = c.Error(fmt.Errorf("%w: pass the protocol query parameter", ErrProtocolUnsupported))
+		return
+	}
+
+	// Frontends that predate schema versions get the oldest one served
+	schema := MinSchemaVersion
+	if c.Query("schema") != "" {
+		requested, err := strconv.Atoi(c.Query("schema"))
+		if err != nil {
+			_ = c.Error(common.NewInvalidInputError("schema must be a version number"))
+			return
+		}
+		if schema, err = NegotiateSchema(requested); err != nil {
+			_ = c.Error(err)
+			return
+		}
+	}
+
+	header := http.Header{
+		"X-Protocol-Version": {strconv.Itoa(version)},
+		"X-Schema-Version":   {strconv.Itoa(schema)},
+	}
+	if protocols[version].deprecation != "" {
+		header.Set("Deprecation", "true")
+	}
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, header)
 	if err != nil {
 		h.logger.Error("WebSocket upgrade failed", zap.Error(err))
 		return
 	}
 
 	// Set read deadline
-	conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+	conn.SetReadDeadline(time.Now().Add(clientReadTimeout))
 
-	h.service.RegisterClient(conn)
+	userID := c.GetString("user_id")
+	h.service.RegisterClient(conn, userID, version, schema)
 	defer func() {
 		h.service.UnregisterClient(conn)
 		conn.Close()
 	}()
+	if negotiated {
+		h.service.SwitchProtocol(conn, version)
+	}
 
 	for {
-		messageType, _, err := conn.ReadMessage()
+		messageType, data, err := conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				h.logger.Error("WebSocket read error", zap.Error(err))
@@ -56,7 +120,8 @@ func (h *Handler) WebSocket(c *gin.Context) {
 		}
 
 		// Reset read deadline after successful read
-		conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+		conn.SetReadDeadline(time.Now().Add(clientReadTimeout))
+		h.service.touch(conn)
 
 		if messageType == websocket.PingMessage {
 			if err := conn.WriteMessage(websocket.PongMessage, nil); err != nil {
@@ -64,27 +129,108 @@ func (h *Handler) WebSocket(c *gin.Context) {
 				break
 			}
 		}
+
+		// Anything other than the hello, receipts, subscription changes,
+		// preferences and presence, such as keep-alive pings, is ignored
+		if messageType == websocket.TextMessage {
+			if !negotiated {
+				negotiated = true
+				if hello, ok := h.hello(conn, data); ok {
+					if !hello {
+						break
+					}
+					continue
+				}
+			}
+			h.clientFrame(c.Request.Context(), conn, userID, data)
+		}
+	}
+}
+
+// hello negotiates the protocol if data is a ClientHello, reporting whether
+// it was one. A hello for an unsupported version closes the connection:
+// accepted is false then.
+func (h *Handler) hello(conn *websocket.Conn, data []byte) (accepted bool, ok bool) {
+	var hello ClientHello
+	if json.Unmarshal(data, &hello) != nil || hello.Type != HelloMessageType {
+		return false, false
+	}
+
+	version, err := h.service.NegotiateProtocol(hello.Protocol)
+	if err != nil {
+		conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseProtocolError, err.Error()), time.Now().Add(time.Second))
+		return false, true
+	}
+	h.service.SwitchProtocol(conn, version)
+	return true, true
+}
+
+func (h *Handler) clientFrame(ctx context.Context, conn *websocket.Conn, userID string, data []byte) {
+	var frame struct {
+		Type string `json:"type"`
+	}
+	if json.Unmarshal(data, &frame) != nil {
+		return
+	}
+
+	switch frame.Type {
+	case ReceiptMessageType:
+		var receipt ClientReceipt
+		if json.Unmarshal(data, &receipt) == nil {
+			h.service.RecordReceipt(receipt)
+		}
+	case SubscribeMessageType, UnsubscribeMessageType:
+		var req SubscriptionRequest
+		if json.Unmarshal(data, &req) != nil {
+			return
+		}
+		if req.Type == UnsubscribeMessageType {
+			h.service.Unsubscribe(conn, req.Project)
+			return
+		}
+		if err := h.service.Subscribe(ctx, conn, userID, req.Project); err != nil {
+			h.logger.Error("Failed to subscribe to project", zap.String("project", req.Project), zap.Error(err))
+		}
+	case PreferencesMessageType:
+		var prefs EventPreferences
+		if json.Unmarshal(data, &prefs) == nil {
+			h.service.SetEventPreferences(conn, prefs.Disabled)
+		}
+	case PresenceMessageType:
+		if err := h.service.FollowPresence(ctx, conn, userID); err != nil {
+			h.logger.Error("Failed to send presence", zap.Error(err))
+		}
+	case ViewingMessageType:
+		var req ViewingRequest
+		if json.Unmarshal(data, &req) != nil {
+			return
+		}
+		if err := h.service.View(ctx, conn, userID, req.TaskID, req.Editing); err != nil {
+			if _, expected := common.ToAppError(err); !expected {
+				h.logger.Error("Failed to record viewed task", zap.String("task_id", req.TaskID), zap.Error(err))
+			}
+		}
 	}
 }
 
 func (h *Handler) CreateTask(c *gin.Context) {
 	var req CreateTaskRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(common.NewValidationError(err))
 		return
 	}
 
 	// Get user ID from context (set by auth middleware)
 	userID := c.GetString("user_id")
 	if userID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		_ = c.Error(common.NewUnauthorizedError("unauthorized"))
 		return
 	}
 
-	resp, err := h.service.CreateTask(req, userID)
+	resp, err := h.service.CreateTask(c.Request.Context(), req, userID)
 	if err != nil {
-		h.logger.Error("Failed to create task", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create task"})
+		h.fail(c, err, "create task")
 		return
 	}
 
@@ -95,24 +241,24 @@ func (h *Handler) UpdateTask(c *gin.Context) {
 	taskID := c.Param("id")
 	var req UpdateTaskRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(common.NewValidationError(err))
 		return
 	}
 
 	userID := c.GetString("user_id")
 	if userID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		_ = c.Error(common.NewUnauthorizedError("unauthorized"))
 		return
 	}
 
-	resp, err := h.service.UpdateTask(taskID, req, userID)
+	resp, err := h.service.UpdateTask(c.Request.Context(), taskID, req, userID)
 	if err != nil {
-		if err == ErrTaskNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		var unmet *DefinitionOfDoneError
+		if errors.As(err, &unmet) {
+			_ = c.Error(common.NewConflictError(ErrDefinitionOfDone.Error()).With("unmet_items", unmet.Unmet))
 			return
 		}
-		h.logger.Error("Failed to update task", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update task"})
+		h.fail(c, err, "update task")
 		return
 	}
 
@@ -122,14 +268,9 @@ func (h *Handler) UpdateTask(c *gin.Context) {
 func (h *Handler) GetTask(c *gin.Context) {
 	taskID := c.Param("id")
 
-	resp, err := h.service.GetTask(taskID)
+	resp, err := h.service.GetTask(c.Request.Context(), taskID, c.GetString("user_id"))
 	if err != nil {
-		if err == ErrTaskNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
-			return
-		}
-		h.logger.Error("Failed to get task", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get task"})
+		h.fail(c, err, "get task")
 		return
 	}
 
@@ -137,15 +278,28 @@ func (h *Handler) GetTask(c *gin.Context) {
 }
 
 func (h *Handler) ListTasks(c *gin.Context) {
-	// Get filters from query parameters
-	status := c.Query("status")
-	assignedTo := c.Query("assigned_to")
-	limit := 10 // Default limit
+	h.listTasks(c, h.service.ListTasksWithFilters)
+}
+
+func (h *Handler) ListArchivedTasks(c *gin.Context) {
+	h.listTasks(c, h.service.ListArchivedTasks)
+}
+
+// listTasks answers a task list request with list
+func (h *Handler) listTasks(c *gin.Context, list func(context.Context, TaskFilter, PaginationParams, SortParams) (*TaskListResponse, error)) {
+	var filter TaskFilter
+	var pagination PaginationParams
+	var sort SortParams
+	for _, params := range []interface{}{&filter, &pagination, &sort} {
+		if err := c.ShouldBindQuery(params); err != nil {
+			_ = c.Error(common.NewValidationError(err))
+			return
+		}
+	}
 
-	resp, err := h.service.ListTasks(status, assignedTo, limit)
+	resp, err := list(c.Request.Context(), filter, pagination, sort)
 	if err != nil {
-		h.logger.Error("Failed to list tasks", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list tasks"})
+		h.fail(c, err, "list tasks")
 		return
 	}
 
@@ -155,14 +309,9 @@ func (h *Handler) ListTasks(c *gin.Context) {
 func (h *Handler) DeleteTask(c *gin.Context) {
 	taskID := c.Param("id")
 
-	err := h.service.DeleteTask(taskID)
+	err := h.service.DeleteTask(c.Request.Context(), taskID, c.GetString("user_id"))
 	if err != nil {
-		if err == ErrTaskNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
-			return
-		}
-		h.logger.Error("Failed to delete task", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete task"})
+		h.fail(c, err, "delete task")
 		return
 	}
 
@@ -171,25 +320,658 @@ func (h *Handler) DeleteTask(c *gin.Context) {
 
 func (h *Handler) AssignTask(c *gin.Context) {
 	taskID := c.Param("id")
-	var req struct {
-		AssignedTo string `json:"assigned_to" binding:"required"`
+	var req AssignTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(common.NewValidationError(err))
+		return
+	}
+
+	resp, err := h.service.AssignTask(c.Request.Context(), taskID, req, c.GetString("user_id"))
+	if err != nil {
+		h.fail(c, err, "assign task")
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) StartTimer(c *gin.Context) {
+	taskID := c.Param("id")
+	userID := c.GetString("user_id")
+	if userID == "" {
+		_ = c.Error(common.NewUnauthorizedError("unauthorized"))
+		return
+	}
+
+	entry, err := h.service.StartTimer(c.Request.Context(), taskID, userID)
+	if err != nil {
+		h.fail(c, err, "start timer")
+		return
+	}
+
+	c.JSON(http.StatusCreated, entry)
+}
+
+func (h *Handler) StopTimer(c *gin.Context) {
+	taskID := c.Param("id")
+	userID := c.GetString("user_id")
+	if userID == "" {
+		_ = c.Error(common.NewUnauthorizedError("unauthorized"))
+		return
+	}
+
+	entry, err := h.service.StopTimer(c.Request.Context(), taskID, userID)
+	if err != nil {
+		h.fail(c, err, "stop timer")
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+func (h *Handler) LogWork(c *gin.Context) {
+	taskID := c.Param("id")
+	var req WorklogRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(common.NewValidationError(err))
+		return
+	}
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		_ = c.Error(common.NewUnauthorizedError("unauthorized"))
+		return
+	}
+
+	entry, err := h.service.LogWork(c.Request.Context(), taskID, req, userID)
+	if err != nil {
+		h.fail(c, err, "log work")
+		return
+	}
+
+	c.JSON(http.StatusCreated, entry)
+}
+
+func (h *Handler) GetTaskTime(c *gin.Context) {
+	taskID := c.Param("id")
+	userID := c.GetString("user_id")
+	if userID == "" {
+		_ = c.Error(common.NewUnauthorizedError("unauthorized"))
+		return
+	}
+
+	resp, err := h.service.GetTaskTimeSummary(c.Request.Context(), taskID, userID)
+	if err != nil {
+		h.fail(c, err, "get task time summary")
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) GetMyTime(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		_ = c.Error(common.NewUnauthorizedError("unauthorized"))
+		return
+	}
+
+	var params struct {
+		From *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+		To   *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	}
+	if err := c.ShouldBindQuery(&params); err != nil {
+		_ = c.Error(ErrInvalidTimeFormat)
+		return
+	}
+
+	resp, err := h.service.GetUserTimeSummary(c.Request.Context(), userID, params.From, params.To)
+	if err != nil {
+		h.fail(c, err, "get user time summary")
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Sync lets offline clients catch up on task changes page by page
+func (h *Handler) Sync(c *gin.Context) {
+	limit := 0
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			_ = c.Error(ErrInvalidSyncLimit)
+			return
+		}
+		limit = parsed
+	}
+
+	resp, err := h.service.Sync(c.Request.Context(), c.GetString("user_id"), c.Query("since"), limit)
+	if err != nil {
+		h.fail(c, err, "sync tasks")
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// ApplySync applies a batch of offline changes, reporting conflicts for the
+// client to resolve
+func (h *Handler) ApplySync(c *gin.Context) {
+	var req ApplyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(common.NewValidationError(err))
+		return
+	}
+
+	resp, err := h.service.ApplySync(c.Request.Context(), req, c.GetString("user_id"))
+	if err != nil {
+		h.fail(c, err, "apply offline changes")
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) ProposeBalance(c *gin.Context) {
+	var req BalanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(common.NewValidationError(err))
+		return
+	}
+
+	resp, err := h.service.ProposeBalance(c.Request.Context(), req)
+	if err != nil {
+		h.fail(c, err, "propose task balance")
+		return
 	}
 
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) ApplyBalance(c *gin.Context) {
+	var req ApplyBalanceRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(common.NewValidationError(err))
+		return
+	}
+
+	applied, err := h.service.ApplyBalance(c.Request.Context(), req)
+	if err != nil {
+		h.fail(c, err, "apply task balance")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"applied": applied})
+}
+
+func (h *Handler) ImportTasks(c *gin.Context) {
+	var params struct {
+		DryRun bool `form:"dry_run"`
+	}
+	if err := c.ShouldBindQuery(&params); err != nil {
+		_ = c.Error(common.NewInvalidInputError("dry_run must be true or false"))
+		return
+	}
+
+	// Leave room for the multipart envelope around the file itself
+	maxBytes := common.AppConfig.ImportMaxBytes
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes+1<<20)
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			_ = c.Error(ErrImportTooLarge)
+			return
+		}
+		_ = c.Error(common.NewInvalidInputError("a file is required in the \"file\" field"))
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		_ = c.Error(common.NewInvalidInputError("failed to read uploaded file"))
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxBytes+1))
+	if err != nil {
+		_ = c.Error(common.NewInvalidInputError("failed to read uploaded file"))
 		return
 	}
+	if int64(len(data)) > maxBytes {
+		_ = c.Error(ErrImportTooLarge)
+		return
+	}
+
+	result, err := h.service.ImportTasks(c.Request.Context(), c.GetString("user_id"), header.Filename, data, params.DryRun)
+	if err != nil {
+		if _, expected := common.ToAppError(err); !expected && result != nil {
+			// Earlier batches were committed, so say how many
+			h.logger.Error("Failed to import tasks", zap.Int("imported", result.Imported), zap.Error(err))
+			_ = c.Error(common.NewInternalServerError("failed to import tasks").With("imported", result.Imported))
+			return
+		}
+		h.fail(c, err, "import tasks")
+		return
+	}
+
+	status := http.StatusOK
+	if result.Imported > 0 {
+		status = http.StatusCreated
+	}
+	c.JSON(status, result)
+}
+
+func (h *Handler) TranslateTask(c *gin.Context) {
+	resp, err := h.service.TranslateTask(c.Request.Context(), c.Param("id"), c.Query("lang"), c.GetString("user_id"))
+	if err != nil {
+		if errors.Is(err, ErrTranslationUnavailable) {
+			_ = c.Error(common.NewUnavailableError(err.Error()).With("retry_after", "30s"))
+			return
+		}
+		h.fail(c, err, "translate task")
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// SimilarTasks lists the tasks that read most like a task, to spot
+// duplicates
+func (h *Handler) SimilarTasks(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
 
-	resp, err := h.service.AssignTask(taskID, req.AssignedTo)
+	tasks, err := h.service.SimilarTasks(c.Request.Context(), c.Param("id"), c.GetString("user_id"), limit)
 	if err != nil {
-		if err == ErrTaskNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+		if errors.Is(err, ErrSimilarityUnavailable) {
+			_ = c.Error(common.NewUnavailableError(err.Error()).With("retry_after", "30s"))
 			return
 		}
-		h.logger.Error("Failed to assign task", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to assign task"})
+		h.fail(c, err, "find similar tasks")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tasks": tasks})
+}
+
+func (h *Handler) SearchTasks(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	tasks, err := h.service.SearchTasks(c.Request.Context(), c.Query("q"), limit)
+	if err != nil {
+		h.fail(c, err, "search tasks")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tasks": tasks})
+}
+
+// Typeahead answers quick-switcher lookups as the user types
+func (h *Handler) Typeahead(c *gin.Context) {
+	tasks, err := h.service.Typeahead(c.Request.Context(), c.Query("q"), c.GetString("user_id"))
+	if err != nil {
+		h.fail(c, err, "search tasks for typeahead")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tasks": tasks})
+}
+
+// Presence returns who is online and, for a task_id, who has the task open
+func (h *Handler) Presence(c *gin.Context) {
+	presence, err := h.service.Presence(c.Request.Context(), c.GetString("user_id"), c.Query("task_id"))
+	if err != nil {
+		h.fail(c, err, "get presence")
+		return
+	}
+
+	c.JSON(http.StatusOK, presence)
+}
+
+// Counters returns the dashboard counts of the user's tasks
+func (h *Handler) Counters(c *gin.Context) {
+	counters, err := h.service.Counters(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		h.fail(c, err, "count tasks")
+		return
+	}
+
+	c.JSON(http.StatusOK, counters)
+}
+
+// TaskStats counts every task, for administrators
+func (h *Handler) TaskStats(c *gin.Context) {
+	stats, err := h.service.TaskStats(c.Request.Context())
+	if err != nil {
+		h.fail(c, err, "count tasks")
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// WebSocketStats describes this replica's WebSocket clients, for
+// administrators
+func (h *Handler) WebSocketStats(c *gin.Context) {
+	c.JSON(http.StatusOK, h.service.WebSocketStats())
+}
+
+func (h *Handler) CreateTemplate(c *gin.Context) {
+	var req CreateTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(common.NewValidationError(err))
+		return
+	}
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		_ = c.Error(common.NewUnauthorizedError("unauthorized"))
+		return
+	}
+
+	template, err := h.service.CreateTemplate(c.Request.Context(), req, userID)
+	if err != nil {
+		h.fail(c, err, "create task template")
+		return
+	}
+
+	c.JSON(http.StatusCreated, template)
+}
+
+func (h *Handler) ListTemplates(c *gin.Context) {
+	templates, err := h.service.ListTemplates(c.Request.Context())
+	if err != nil {
+		h.fail(c, err, "list task templates")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"templates": templates})
+}
+
+func (h *Handler) CreateTaskFromTemplate(c *gin.Context) {
+	h.instantiateTask(c, h.service.CreateTaskFromTemplate)
+}
+
+func (h *Handler) CloneTask(c *gin.Context) {
+	h.instantiateTask(c, h.service.CloneTask)
+}
+
+// instantiateTask creates a task from the template or task named by the id
+// parameter. The body is optional.
+func (h *Handler) instantiateTask(c *gin.Context, create func(context.Context, string, InstantiateTaskRequest, string) (*TaskResponse, error)) {
+	var req InstantiateTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		_ = c.Error(common.NewValidationError(err))
+		return
+	}
+
+	userID := c.GetString("user_id")
+	if userID == "" {
+		_ = c.Error(common.NewUnauthorizedError("unauthorized"))
+		return
+	}
+
+	resp, err := create(c.Request.Context(), c.Param("id"), req, userID)
+	if err != nil {
+		h.fail(c, err, "create task")
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+func (h *Handler) GetChecklist(c *gin.Context) {
+	resp, err := h.service.GetChecklist(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.fail(c, err, "change checklist")
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) AddChecklistItem(c *gin.Context) {
+	var req CreateChecklistItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(common.NewValidationError(err))
+		return
+	}
+
+	resp, err := h.service.AddChecklistItem(c.Request.Context(), c.Param("id"), req, c.GetString("user_id"))
+	if err != nil {
+		h.fail(c, err, "change checklist")
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+func (h *Handler) UpdateChecklistItem(c *gin.Context) {
+	var req UpdateChecklistItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(common.NewValidationError(err))
+		return
+	}
+
+	resp, err := h.service.UpdateChecklistItem(c.Request.Context(), c.Param("id"), c.Param("item_id"), req, c.GetString("user_id"))
+	if err != nil {
+		h.fail(c, err, "change checklist")
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) DeleteChecklistItem(c *gin.Context) {
+	if err := h.service.DeleteChecklistItem(c.Request.Context(), c.Param("id"), c.Param("item_id"), c.GetString("user_id")); err != nil {
+		h.fail(c, err, "change checklist")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "checklist item deleted successfully"})
+}
+
+func (h *Handler) WatchTask(c *gin.Context) {
+	resp, err := h.service.WatchTask(c.Request.Context(), c.Param("id"), c.GetString("user_id"))
+	if err != nil {
+		h.fail(c, err, "change task watchers")
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) UnwatchTask(c *gin.Context) {
+	if err := h.service.UnwatchTask(c.Request.Context(), c.Param("id"), c.GetString("user_id")); err != nil {
+		h.fail(c, err, "change task watchers")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "stopped watching task"})
+}
+
+func (h *Handler) LockTask(c *gin.Context) {
+	lock, err := h.service.LockTask(c.Request.Context(), c.Param("id"), c.GetString("user_id"))
+	if err != nil {
+		h.fail(c, err, "lock task")
+		return
+	}
+
+	c.JSON(http.StatusOK, lock)
+}
+
+func (h *Handler) RestoreTask(c *gin.Context) {
+	resp, err := h.service.RestoreTask(c.Request.Context(), c.Param("id"), c.GetString("user_id"))
+	if err != nil {
+		h.fail(c, err, "restore task")
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) UnlockTask(c *gin.Context) {
+	if err := h.service.UnlockTask(c.Request.Context(), c.Param("id"), c.GetString("user_id")); err != nil {
+		h.fail(c, err, "unlock task")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "task unlocked"})
+}
+
+func (h *Handler) ListWatchers(c *gin.Context) {
+	resp, err := h.service.ListWatchers(c.Request.Context(), c.Param("id"), c.GetString("user_id"))
+	if err != nil {
+		h.fail(c, err, "change task watchers")
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) TransferTask(c *gin.Context) {
+	var req TransferTaskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(common.NewValidationError(err))
+		return
+	}
+
+	transfer, err := h.service.TransferTask(c.Request.Context(), c.Param("id"), req, c.GetString("user_id"))
+	if err != nil {
+		h.fail(c, err, "transfer task")
+		return
+	}
+
+	c.JSON(http.StatusCreated, transfer)
+}
+
+func (h *Handler) AcceptTransfer(c *gin.Context) {
+	transfer, err := h.service.AcceptTransfer(c.Request.Context(), c.Param("id"), c.GetString("user_id"))
+	if err != nil {
+		h.fail(c, err, "transfer task")
+		return
+	}
+
+	c.JSON(http.StatusOK, transfer)
+}
+
+func (h *Handler) DeclineTransfer(c *gin.Context) {
+	var req DeclineTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(common.NewValidationError(err))
+		return
+	}
+
+	transfer, err := h.service.DeclineTransfer(c.Request.Context(), c.Param("id"), req.Reason, c.GetString("user_id"))
+	if err != nil {
+		h.fail(c, err, "transfer task")
+		return
+	}
+
+	c.JSON(http.StatusOK, transfer)
+}
+
+func (h *Handler) AssignmentHistory(c *gin.Context) {
+	resp, err := h.service.AssignmentHistory(c.Request.Context(), c.Param("id"), c.GetString("user_id"))
+	if err != nil {
+		h.fail(c, err, "transfer task")
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *Handler) TaskEscalations(c *gin.Context) {
+	resp, err := h.service.TaskEscalations(c.Request.Context(), c.Param("id"), c.GetString("user_id"))
+	if err != nil {
+		h.fail(c, err, "list task escalations")
 		return
 	}
 
 	c.JSON(http.StatusOK, resp)
 }
+
+// GetFieldSchema tells clients which task fields to show and require for a
+// project
+func (h *Handler) GetFieldSchema(c *gin.Context) {
+	schema, err := h.service.GetFieldSchema(c.Request.Context(), c.Param("project"))
+	if err != nil {
+		h.fail(c, err, "get field schema")
+		return
+	}
+
+	c.JSON(http.StatusOK, schema)
+}
+
+func (h *Handler) SetFieldSchema(c *gin.Context) {
+	var req FieldSchemaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(common.NewValidationError(err))
+		return
+	}
+
+	schema, err := h.service.SetFieldSchema(c.Request.Context(), c.Param("project"), req, c.GetString("user_id"))
+	if err != nil {
+		h.fail(c, err, "set field schema")
+		return
+	}
+
+	c.JSON(http.StatusOK, schema)
+}
+
+// GetEscalationRule returns the project's escalation rule, or null when
+// its tasks use the default window
+func (h *Handler) GetEscalationRule(c *gin.Context) {
+	rule, err := h.service.GetEscalationRule(c.Request.Context(), c.Param("project"))
+	if err != nil {
+		h.fail(c, err, "get escalation rule")
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+func (h *Handler) SetEscalationRule(c *gin.Context) {
+	var req EscalationRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(common.NewValidationError(err))
+		return
+	}
+
+	rule, err := h.service.SetEscalationRule(c.Request.Context(), c.Param("project"), req, c.GetString("user_id"))
+	if err != nil {
+		h.fail(c, err, "set escalation rule")
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// GetCalendar returns the project's business calendar, or null when its
+// tasks may be due on any day
+func (h *Handler) GetCalendar(c *gin.Context) {
+	calendar, err := h.service.GetCalendar(c.Request.Context(), c.Param("project"))
+	if err != nil {
+		h.fail(c, err, "get calendar")
+		return
+	}
+
+	c.JSON(http.StatusOK, calendar)
+}
+
+func (h *Handler) SetCalendar(c *gin.Context) {
+	var req CalendarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(common.NewValidationError(err))
+		return
+	}
+
+	calendar, err := h.service.SetCalendar(c.Request.Context(), c.Param("project"), req, c.GetString("user_id"))
+	if err != nil {
+		h.fail(c, err, "set calendar")
+		return
+	}
+
+	c.JSON(http.StatusOK, calendar)
+}
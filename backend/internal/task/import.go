@@ -0,0 +1,326 @@
+package task
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const importBatchSize = 100
+
+// importColumns are the columns a CSV import file may have, in any order.
+// Only title is required.
+var importColumns = []string{
+	"title", "description", "priority", "status", "due_date", "project", "estimated_effort", "assignees",
+}
+
+// importRow is a parsed row with the problems found while parsing it
+type importRow struct {
+	ImportTask
+	errs []string
+}
+
+// ImportTasks creates tasks from a CSV or JSON file, told apart by the
+// file name's extension. Every row is validated like a new task; rows with
+// errors are reported and skipped. Valid rows are inserted in transactions
+// of 100, so a failure part way through leaves the earlier batches
+// imported and the returned result counts them. A dry run only validates.
+func (s *Service) ImportTasks(ctx context.Context, userID, fileName string, data []byte, dryRun bool) (*ImportResult, error) {
+	rows, err := parseImport(fileName, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: no tasks found", ErrInvalidImport)
+	}
+	if limit := common.AppConfig.ImportMaxRows; limit > 0 && len(rows) > limit {
+		return nil, fmt.Errorf("%w: at most %d are allowed", ErrTooManyImportRows, limit)
+	}
+
+	users, err := s.usersByEmail(ctx, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ImportResult{DryRun: dryRun, Rows: len(rows), Errors: []ImportRowError{}}
+	schemas := make(map[string]*ProjectFieldSchema)
+	tasks := make([]Task, 0, len(rows))
+	for i, row := range rows {
+		task, errs, err := s.importTask(ctx, row, userID, users, schemas)
+		if err != nil {
+			return nil, err
+		}
+		if len(errs) > 0 {
+			result.Errors = append(result.Errors, ImportRowError{Row: i + 1, Title: row.Title, Errors: errs})
+			continue
+		}
+		tasks = append(tasks, *task)
+	}
+	result.Valid = len(tasks)
+	if dryRun {
+		return result, nil
+	}
+
+	for start := 0; start < len(tasks); start += importBatchSize {
+		batch := tasks[start:min(start+importBatchSize, len(tasks))]
+		if err := s.insertImportBatch(ctx, batch); err != nil {
+			return result, fmt.Errorf("failed to import tasks: %w", err)
+		}
+		result.Imported += len(batch)
+		for _, task := range batch {
+			s.publish(TaskCreatedEvent(task))
+		}
+		s.forgetCounters(ctx, batch)
+	}
+	return result, nil
+}
+
+// importTask builds the task for a row. It returns the row's problems, or
+// an error when they could not be checked.
+func (s *Service) importTask(ctx context.Context, row importRow, userID string, users map[string]string, schemas map[string]*ProjectFieldSchema) (*Task, []string, error) {
+	errs := row.errs
+	now := time.Now()
+	task := &Task{
+		ID:          uuid.New().String(),
+		Title:       strings.TrimSpace(row.Title),
+		Description: row.Description,
+		Status:      models.TaskStatus(strings.ToLower(strings.TrimSpace(row.Status))),
+		Priority:    models.TaskPriority(strings.ToLower(strings.TrimSpace(row.Priority))),
+		CreatedBy:   userID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+
+		Project:         strings.TrimSpace(row.Project),
+		EstimatedEffort: row.EstimatedEffort,
+		DeadlineType:    models.DeadlineSoft,
+	}
+	if task.Status == "" {
+		task.Status = models.StatusPending
+	}
+	setCompletedAt(task)
+
+	dueDate, err := parseImportDate(strings.TrimSpace(row.DueDate))
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+	task.DueDate = dueDate
+
+	if err := s.validateTask(ctx, task); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if task.Project != "" {
+		schema, ok := schemas[task.Project]
+		if !ok {
+			if schema, err = s.GetFieldSchema(ctx, task.Project); err != nil {
+				return nil, nil, err
+			}
+			schemas[task.Project] = schema
+		}
+		task.DeadlineType = schema.DeadlineType
+		// The due date is always required and was checked above
+		notDueDate := func(field string) bool { return field != "due_date" }
+		if err := checkFieldSchema(schema, task, notDueDate); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	ids := make([]string, 0, len(row.Assignees))
+	for _, email := range row.Assignees {
+		id, ok := users[normalizeEmail(email)]
+		if !ok {
+			errs = append(errs, fmt.Sprintf("no user has the email %s", strings.TrimSpace(email)))
+			continue
+		}
+		ids = append(ids, id)
+	}
+	primary, ids := resolveAssignees("", ids)
+	task.AssignedTo = primary
+	for _, id := range ids {
+		task.Assignees = append(task.Assignees, TaskAssignee{
+			TaskID:    task.ID,
+			UserID:    id,
+			IsPrimary: id == primary,
+			CreatedAt: now,
+		})
+	}
+
+	return task, errs, nil
+}
+
+// parseImportDate reads an RFC 3339 time or a YYYY-MM-DD date, which means
+// the end of that day in UTC
+func parseImportDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, ErrDueDateRequired
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t.Add(24*time.Hour - time.Second), nil
+	}
+	return time.Time{}, errors.New("due_date must be an RFC 3339 time or a YYYY-MM-DD date")
+}
+
+// usersByEmail maps the lowercased assignee emails of the rows to user IDs
+func (s *Service) usersByEmail(ctx context.Context, rows []importRow) (map[string]string, error) {
+	seen := make(map[string]bool)
+	var emails []string
+	for _, row := range rows {
+		for _, email := range row.Assignees {
+			email = normalizeEmail(email)
+			if email != "" && !seen[email] {
+				seen[email] = true
+				emails = append(emails, email)
+			}
+		}
+	}
+
+	users := make(map[string]string, len(emails))
+	if len(emails) == 0 {
+		return users, nil
+	}
+	var found []struct {
+		ID    string
+		Email string
+	}
+	if err := s.db.WithContext(ctx).Model(&models.User{}).
+		Select("id, email").
+		Where("lower(email) IN ?", emails).
+		Scan(&found).Error; err != nil {
+		return nil, fmt.Errorf("failed to look up assignees: %w", err)
+	}
+	for _, user := range found {
+		users[normalizeEmail(user.Email)] = user.ID
+	}
+	return users, nil
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// insertImportBatch saves the tasks and their assignees in one transaction
+func (s *Service) insertImportBatch(ctx context.Context, tasks []Task) error {
+	var assignees []TaskAssignee
+	for _, task := range tasks {
+		assignees = append(assignees, task.Assignees...)
+	}
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(&tasks).Error; err != nil {
+			return err
+		}
+		if len(assignees) == 0 {
+			return nil
+		}
+		return tx.Create(&assignees).Error
+	})
+}
+
+func parseImport(fileName string, data []byte) ([]importRow, error) {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv":
+		return parseImportCSV(data)
+	case ".json":
+		return parseImportJSON(data)
+	}
+	return nil, fmt.Errorf("%w: file name must end in .csv or .json", ErrInvalidImport)
+}
+
+// parseImportJSON reads an array of ImportTask objects. Unknown keys are
+// rejected so a misspelt field is not silently dropped.
+func parseImportJSON(data []byte) ([]importRow, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var tasks []ImportTask
+	if err := decoder.Decode(&tasks); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+
+	rows := make([]importRow, 0, len(tasks))
+	for _, task := range tasks {
+		rows = append(rows, importRow{ImportTask: task})
+	}
+	return rows, nil
+}
+
+// parseImportCSV reads a header row naming importColumns, then one task per
+// record. Assignee emails are separated by semicolons, commas or spaces.
+func parseImportCSV(data []byte) ([]importRow, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff"))))
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !containsString(importColumns, name) {
+			return nil, fmt.Errorf("%w: unknown column %q, want any of %s",
+				ErrInvalidImport, name, strings.Join(importColumns, ", "))
+		}
+		if _, ok := columns[name]; ok {
+			return nil, fmt.Errorf("%w: duplicate column %q", ErrInvalidImport, name)
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, fmt.Errorf("%w: the title column is required", ErrInvalidImport)
+	}
+
+	var rows []importRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+		}
+
+		value := func(column string) string {
+			if i, ok := columns[column]; ok {
+				return record[i]
+			}
+			return ""
+		}
+		row := importRow{ImportTask: ImportTask{
+			Title:       value("title"),
+			Description: value("description"),
+			Priority:    value("priority"),
+			Status:      value("status"),
+			DueDate:     value("due_date"),
+			Project:     value("project"),
+			Assignees: strings.FieldsFunc(value("assignees"), func(r rune) bool {
+				return r == ';' || r == ',' || unicode.IsSpace(r)
+			}),
+		}}
+		if effort := strings.TrimSpace(value("estimated_effort")); effort != "" {
+			if row.EstimatedEffort, err = strconv.ParseFloat(effort, 64); err != nil {
+				row.errs = append(row.errs, "estimated_effort must be a number")
+			}
+		}
+		rows = append(rows, row)
+	}
+}
@@ -0,0 +1,126 @@
+package task
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"go.uber.org/zap"
+)
+
+func TestParseImportCSV(t *testing.T) {
+	data := "\ufeffTitle, Assignees,estimated_effort\n" +
+		"Write runbook,\"ana@example.com; li@example.com\",2.5\n" +
+		"Renew certificates,,lots\n"
+
+	rows, err := parseImportCSV([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want 2", len(rows))
+	}
+	if rows[0].Title != "Write runbook" || rows[0].EstimatedEffort != 2.5 || len(rows[0].errs) != 0 {
+		t.Fatalf("row 1 = %+v", rows[0])
+	}
+	if len(rows[0].Assignees) != 2 || rows[0].Assignees[1] != "li@example.com" {
+		t.Fatalf("assignees = %q, want both emails", rows[0].Assignees)
+	}
+	if len(rows[1].errs) != 1 {
+		t.Fatalf("row 2 errors = %q, want the effort error", rows[1].errs)
+	}
+
+	if _, err := parseImportCSV([]byte("title,owner\nWrite runbook,ana\n")); !errors.Is(err, ErrInvalidImport) {
+		t.Fatalf("unknown column err = %v, want ErrInvalidImport", err)
+	}
+}
+
+func TestImportTasksDryRunReportsEveryRowError(t *testing.T) {
+	s, mock := newTestService(t)
+	due := time.Now().Add(48 * time.Hour).Format(time.DateOnly)
+	data := `[
+		{"title": "Write runbook", "priority": "High", "due_date": "` + due + `", "project": "ops", "assignees": ["Ana@example.com"]},
+		{"title": "Renew certificates", "priority": "urgent", "due_date": "` + due + `", "project": "ops", "assignees": ["bo@example.com"]},
+		{"title": "Plan offsite", "priority": "low"}
+	]`
+
+	mock.ExpectQuery(`SELECT id, email FROM "users" WHERE lower\(email\) IN \(\$1,\$2\)`).
+		WithArgs("ana@example.com", "bo@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow("user-2", "ana@example.com"))
+	expectFieldSchema(mock, "ops", "")
+
+	result, err := s.ImportTasks(context.Background(), "user-1", "tasks.json", []byte(data), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Rows != 3 || result.Valid != 1 || result.Imported != 0 {
+		t.Fatalf("result = %+v, want 3 rows, 1 valid, none imported", result)
+	}
+	if len(result.Errors) != 2 || result.Errors[0].Row != 2 || len(result.Errors[0].Errors) != 2 {
+		t.Fatalf("errors = %+v, want both problems with row 2", result.Errors)
+	}
+	if result.Errors[1].Row != 3 || result.Errors[1].Errors[0] != ErrDueDateRequired.Error() {
+		t.Fatalf("errors = %+v, want the missing due date of row 3", result.Errors)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestImportTasksInsertsValidRows(t *testing.T) {
+	s, mock := newTestService(t)
+	due := time.Now().Add(48 * time.Hour).Format(time.RFC3339)
+	data := "title,priority,due_date,assignees\n" +
+		"Write runbook,medium," + due + ",ana@example.com\n" +
+		"Renew certificates,medium,yesterday,\n"
+
+	mock.ExpectQuery(`SELECT id, email FROM "users"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow("user-2", "ana@example.com"))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "tasks"`).WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("pending"))
+	mock.ExpectQuery(`INSERT INTO "task_assignees"`).
+		WillReturnRows(sqlmock.NewRows([]string{"is_primary"}).AddRow(true))
+	mock.ExpectCommit()
+
+	result, err := s.ImportTasks(context.Background(), "user-1", "tasks.csv", []byte(data), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Valid != 1 || result.Imported != 1 || len(result.Errors) != 1 {
+		t.Fatalf("result = %+v, want one task imported and one error", result)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestImportTasksHandlerRejectsUnknownFormat(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	common.AppConfig.ImportMaxBytes = 1 << 20
+	s, _ := newTestService(t)
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", "tasks.xlsx")
+	part.Write([]byte("title\nWrite runbook\n"))
+	form.Close()
+
+	router := gin.New()
+	router.Use(common.ErrorHandler(zap.NewNop()))
+	router.POST("/tasks/import", NewHandler(s, zap.NewNop()).ImportTasks)
+	req := httptest.NewRequest(http.MethodPost, "/tasks/import", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400 (body %s)", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,180 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// EditLockMode is what an update of a task's title or description does
+// when another user holds the task's edit lock
+type EditLockMode string
+
+const (
+	// EditLockWarn saves the update and sets the response's warning
+	EditLockWarn EditLockMode = "warn"
+	// EditLockReject refuses the update with ErrTaskLocked
+	EditLockReject EditLockMode = "reject"
+)
+
+// defaultEditLockTTL is how long an edit lock lasts unless renewed
+const defaultEditLockTTL = 2 * time.Minute
+
+// TaskLockState is the payload of a task_lock message. UserID and
+// ExpiresAt are empty once the lock is released.
+type TaskLockState struct {
+	TaskID    string     `json:"task_id"`
+	Locked    bool       `json:"locked"`
+	UserID    string     `json:"user_id,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// SetEditLockPolicy sets how long edit locks last, and what updates of a
+// task locked by someone else do
+func (s *Service) SetEditLockPolicy(ttl time.Duration, mode EditLockMode) error {
+	if mode != EditLockWarn && mode != EditLockReject {
+		return fmt.Errorf("unknown edit lock mode %q", mode)
+	}
+	if ttl <= 0 {
+		return fmt.Errorf("edit lock TTL must be positive, got %s", ttl)
+	}
+	s.lockTTL = ttl
+	s.lockMode = mode
+	return nil
+}
+
+// LockTask takes the edit lock of a task userID may change, or renews it
+// if the user already holds it. Editors renew it well within the TTL while
+// editing. A lock held by another user fails with ErrTaskLocked until it
+// expires. Followers and viewers of the task are sent a task_lock message.
+func (s *Service) LockTask(ctx context.Context, taskID, userID string) (*TaskEditLock, error) {
+	if _, err := s.findModifiableTask(ctx, taskID, userID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var locks []TaskEditLock
+	err := s.db.WithContext(ctx).Raw(`
+		INSERT INTO task_edit_locks (task_id, user_id, acquired_at, expires_at)
+		VALUES (@task, @user, @now, @expires)
+		ON CONFLICT (task_id) DO UPDATE SET
+			acquired_at = CASE WHEN task_edit_locks.user_id = excluded.user_id
+				THEN task_edit_locks.acquired_at ELSE excluded.acquired_at END,
+			user_id = excluded.user_id,
+			expires_at = excluded.expires_at
+		WHERE task_edit_locks.user_id = excluded.user_id OR task_edit_locks.expires_at <= excluded.acquired_at
+		RETURNING task_id, user_id, acquired_at, expires_at`,
+		map[string]interface{}{"task": taskID, "user": userID, "now": now, "expires": now.Add(s.lockTTL)}).
+		Scan(&locks).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock task: %w", err)
+	}
+	if len(locks) == 0 {
+		holder, err := s.heldEditLock(ctx, taskID, userID)
+		if err != nil {
+			return nil, err
+		}
+		return nil, lockedBy(holder)
+	}
+
+	lock := locks[0]
+	s.publishLock(ctx, TaskLockState{TaskID: taskID, Locked: true, UserID: lock.UserID, ExpiresAt: &lock.ExpiresAt})
+	return &lock, nil
+}
+
+// UnlockTask releases userID's edit lock of the task. Releasing a lock the
+// user does not hold is not an error.
+func (s *Service) UnlockTask(ctx context.Context, taskID, userID string) error {
+	if _, err := s.findTask(ctx, taskID); err != nil {
+		return err
+	}
+	result := s.db.WithContext(ctx).
+		Where("task_id = ? AND user_id = ?", taskID, userID).
+		Delete(&TaskEditLock{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to unlock task: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		s.publishLock(ctx, TaskLockState{TaskID: taskID})
+	}
+	return nil
+}
+
+// heldEditLock returns the unexpired edit lock of the task held by a user
+// other than userID, or nil if there is none
+func (s *Service) heldEditLock(ctx context.Context, taskID, userID string) (*TaskEditLock, error) {
+	lock := &TaskEditLock{}
+	err := s.db.WithContext(ctx).
+		Where("task_id = ? AND user_id <> ? AND expires_at > ?", taskID, userID, time.Now()).
+		First(lock).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return lock, nil
+}
+
+// checkEditLock applies the edit lock mode to an update of the task's
+// title or description by userID. It returns the warning for the response
+// in warn mode. The lock is advisory, so a failed lookup lets the update
+// through.
+func (s *Service) checkEditLock(ctx context.Context, taskID string, req UpdateTaskRequest, userID string) (string, error) {
+	if req.Title == nil && req.Description == nil {
+		return "", nil
+	}
+	holder, err := s.heldEditLock(ctx, taskID, userID)
+	if err != nil {
+		s.logger.Warn("Failed to look up task edit lock", zap.String("task_id", taskID), zap.Error(err))
+		return "", nil
+	}
+	if holder == nil {
+		return "", nil
+	}
+	if s.lockMode == EditLockReject {
+		return "", lockedBy(holder)
+	}
+	return fmt.Sprintf("the task is being edited by %s until %s", holder.UserID, holder.ExpiresAt.UTC().Format(time.RFC3339)), nil
+}
+
+// lockedBy is ErrTaskLocked naming the holder of the lock, if it is still
+// held
+func lockedBy(holder *TaskEditLock) error {
+	if holder == nil {
+		return ErrTaskLocked
+	}
+	return fmt.Errorf("%w: %s holds the lock until %s", ErrTaskLocked, holder.UserID, holder.ExpiresAt.UTC().Format(time.RFC3339))
+}
+
+// publishLock sends a task_lock message to the task's followers and to
+// the users who have it open. It skips the lookup when nobody is
+// connected.
+func (s *Service) publishLock(ctx context.Context, state TaskLockState) {
+	if s.ConnectedClients() == 0 {
+		return
+	}
+	ids, err := s.taskFollowers(ctx, state.TaskID)
+	if err != nil {
+		s.logger.Warn("Failed to look up task followers", zap.String("task_id", state.TaskID), zap.Error(err))
+		return
+	}
+
+	msg := TaskLockEvent(state)
+	msg.recipients = make(map[string]bool, len(ids))
+	for _, id := range ids {
+		msg.recipients[id] = true
+	}
+	s.clientsMux.RLock()
+	for _, client := range s.clients {
+		if client.viewing == state.TaskID && client.userID != "" {
+			msg.recipients[client.userID] = true
+		}
+	}
+	s.clientsMux.RUnlock()
+	s.publish(msg)
+}
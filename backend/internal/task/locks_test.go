@@ -0,0 +1,106 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"go.uber.org/zap"
+)
+
+// lockedTask is a TaskRepository with one valid task of user-1, which
+// saves updates
+type lockedTask struct {
+	TaskRepository
+}
+
+func (lockedTask) Get(_ context.Context, id string) (*Task, error) {
+	return &Task{ID: id, Title: "Write docs", Status: "pending", Priority: "medium", CreatedBy: "user-1",
+		DueDate: time.Now().Add(time.Hour)}, nil
+}
+
+func (lockedTask) Save(context.Context, *Task, bool, string, []string) error {
+	return nil
+}
+
+func expectEditLock(mock sqlmock.Sqlmock, holder string, expires time.Time) {
+	mock.ExpectQuery(`SELECT \* FROM "task_edit_locks" WHERE task_id = \$1 AND user_id <> \$2 AND expires_at > \$3`).
+		WithArgs("task-1", sqlmock.AnyArg(), sqlmock.AnyArg(), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"task_id", "user_id", "acquired_at", "expires_at"}).
+			AddRow("task-1", holder, expires.Add(-time.Minute), expires))
+}
+
+func TestLockTaskHeldByAnotherUserIsConflict(t *testing.T) {
+	s, mock := newTestService(t)
+	mock.ExpectQuery(`SELECT \* FROM "tasks" WHERE id = \$1`).
+		WithArgs("task-1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_by"}).AddRow("task-1", "user-1"))
+	mock.ExpectQuery(`SELECT \* FROM "task_assignees"`).
+		WillReturnRows(sqlmock.NewRows([]string{"task_id", "user_id"}))
+	mock.ExpectQuery(`INSERT INTO task_edit_locks .* ON CONFLICT \(task_id\) DO UPDATE .* RETURNING`).
+		WithArgs("task-1", "user-1", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"task_id", "user_id", "acquired_at", "expires_at"}))
+	expires := time.Date(2024, 3, 10, 15, 6, 5, 0, time.UTC)
+	expectEditLock(mock, "user-2", expires)
+
+	_, err := s.LockTask(context.Background(), "task-1", "user-1")
+	if !errors.Is(err, ErrTaskLocked) || !strings.Contains(err.Error(), "user-2 holds the lock until 2024-03-10T15:06:05Z") {
+		t.Fatalf("err = %v, want ErrTaskLocked naming user-2", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpdateTaskWhileAnotherUserHoldsLock(t *testing.T) {
+	for _, mode := range []EditLockMode{EditLockWarn, EditLockReject} {
+		t.Run(string(mode), func(t *testing.T) {
+			db, mock := newTestDB(t)
+			s := NewServiceWithRepository(db, lockedTask{}, zap.NewNop())
+			t.Cleanup(func() { s.Shutdown(context.Background()) })
+			if err := s.SetEditLockPolicy(time.Minute, mode); err != nil {
+				t.Fatal(err)
+			}
+			expectEditLock(mock, "user-2", time.Now().Add(time.Minute))
+
+			description := "Rewritten"
+			resp, err := s.UpdateTask(context.Background(), "task-1", UpdateTaskRequest{Description: &description}, "user-1")
+			if mode == EditLockReject {
+				if !errors.Is(err, ErrTaskLocked) {
+					t.Fatalf("err = %v, want ErrTaskLocked", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(resp.Warning, "being edited by user-2") || resp.Task.Description != description {
+				t.Fatalf("got %+v, want the update saved with a warning naming user-2", resp)
+			}
+		})
+	}
+}
+
+func TestUpdateTaskIgnoresLockForOtherFields(t *testing.T) {
+	db, _ := newTestDB(t)
+	s := NewServiceWithRepository(db, lockedTask{}, zap.NewNop())
+	t.Cleanup(func() { s.Shutdown(context.Background()) })
+	if err := s.SetEditLockPolicy(time.Minute, EditLockReject); err != nil {
+		t.Fatal(err)
+	}
+
+	priority := "high"
+	if _, err := s.UpdateTask(context.Background(), "task-1", UpdateTaskRequest{Priority: &priority}, "user-1"); err != nil {
+		t.Fatalf("err = %v, want a priority change allowed without a lock lookup", err)
+	}
+}
+
+func TestSetEditLockPolicyRejectsUnknownMode(t *testing.T) {
+	s, _ := newTestService(t)
+	if err := s.SetEditLockPolicy(time.Minute, "ignore"); err == nil {
+		t.Fatal("want an error for an unknown mode")
+	}
+}
@@ -10,14 +10,40 @@ import (
 type Task = models.Task
 type TaskStatus = models.TaskStatus
 type TaskPriority = models.TaskPriority
+type TimeEntry = models.TimeEntry
+type TaskAssignee = models.TaskAssignee
+type TaskTemplate = models.TaskTemplate
+type ChecklistItem = models.ChecklistItem
+type TaskWatcher = models.TaskWatcher
+type TaskEditLock = models.TaskEditLock
+type ProjectFieldSchema = models.ProjectFieldSchema
+type TaskTransfer = models.TaskTransfer
+type AssignmentEvent = models.AssignmentEvent
+type ProjectEscalationRule = models.ProjectEscalationRule
+type TaskEscalation = models.TaskEscalation
+type ProjectCalendar = models.ProjectCalendar
+type ProjectActivity = models.ProjectActivity
 
 // Request/response types
 type CreateTaskRequest struct {
-	Title       string    `json:"title" binding:"required"`
+	Title       string    `json:"title" binding:"required,max=255"`
 	Description string    `json:"description"`
-	Priority    string    `json:"priority" binding:"required"`
-	AssignedTo  string    `json:"assigned_to" binding:"required"`
-	DueDate     time.Time `json:"due_date" binding:"required"`
+	Priority    string    `json:"priority" binding:"required,oneof=low medium high"`
+	AssignedTo  string    `json:"assigned_to"`
+	DueDate     time.Time `json:"due_date" binding:"required,future"`
+
+	// AssigneeIDs lists additional assignees; AssignedTo is the primary.
+	// Leaving both empty creates an unassigned task.
+	AssigneeIDs []string `json:"assignee_ids"`
+
+	Project         string  `json:"project"`
+	EstimatedEffort float64 `json:"estimated_effort" binding:"min=0"`
+
+	// Checklist lists the text of the task's checklist items, in order
+	Checklist []string `json:"checklist" binding:"max=100,dive,max=500"`
+
+	// DeadlineType defaults to the project's, or soft without a project
+	DeadlineType string `json:"deadline_type" binding:"omitempty,oneof=soft hard"`
 }
 
 type UpdateTaskRequest struct {
@@ -27,10 +53,55 @@ type UpdateTaskRequest struct {
 	Priority    *string    `json:"priority"`
 	AssignedTo  *string    `json:"assigned_to"`
 	DueDate     *time.Time `json:"due_date"`
+
+	AssigneeIDs *[]string `json:"assignee_ids"`
+
+	Project         *string  `json:"project"`
+	EstimatedEffort *float64 `json:"estimated_effort"`
+	DeadlineType    *string  `json:"deadline_type" binding:"omitempty,oneof=soft hard"`
+}
+
+type CreateTemplateRequest struct {
+	Name            string   `json:"name" binding:"required,max=100"`
+	Title           string   `json:"title" binding:"required"`
+	Description     string   `json:"description"`
+	Priority        string   `json:"priority" binding:"required"`
+	Project         string   `json:"project"`
+	EstimatedEffort float64  `json:"estimated_effort" binding:"min=0"`
+	DueInDays       int      `json:"due_in_days" binding:"min=0,max=3650"`
+	Checklist       []string `json:"checklist" binding:"max=100,dive,max=500"`
+}
+
+// InstantiateTaskRequest creates a task from a template or by cloning
+// another task. DueDate is required when cloning, and when the template
+// has no due_in_days.
+type InstantiateTaskRequest struct {
+	DueDate     *time.Time `json:"due_date"`
+	AssignedTo  string     `json:"assigned_to"`
+	AssigneeIDs []string   `json:"assignee_ids"`
+}
+
+// AssignTaskRequest replaces the task's assignees; an empty request
+// unassigns the task
+type AssignTaskRequest struct {
+	AssignedTo  string   `json:"assigned_to"`
+	AssigneeIDs []string `json:"assignee_ids"`
 }
 
 type TaskResponse struct {
-	Task Task `json:"task"`
+	Task               Task  `json:"task"`
+	TotalLoggedSeconds int64 `json:"total_logged_seconds"`
+
+	// PercentComplete is the share of checklist items done, rounded down;
+	// 0 for a task without a checklist
+	ChecklistItems  int64 `json:"checklist_items"`
+	PercentComplete int   `json:"percent_complete"`
+
+	// PossibleDuplicates are open tasks that read very much like a task
+	// just created. Warning is set when there are any, or when the task is
+	// past its deadline.
+	PossibleDuplicates []SimilarTask `json:"possible_duplicates,omitempty"`
+	Warning            string        `json:"warning,omitempty"`
 }
 
 type TaskListResponse struct {
@@ -42,3 +113,203 @@ type TaskListResponse struct {
 		TotalPages  int   `json:"total_pages"`
 	} `json:"pagination"`
 }
+
+type WorklogRequest struct {
+	StartedAt       time.Time `json:"started_at" binding:"required"`
+	DurationMinutes int       `json:"duration_minutes" binding:"required,min=1"`
+	Note            string    `json:"note"`
+}
+
+type UserTimeTotal struct {
+	UserID       string `json:"user_id"`
+	TotalSeconds int64  `json:"total_seconds"`
+}
+
+type TaskTimeTotal struct {
+	TaskID       string `json:"task_id"`
+	TotalSeconds int64  `json:"total_seconds"`
+}
+
+type TaskTimeSummary struct {
+	TaskID       string          `json:"task_id"`
+	TotalSeconds int64           `json:"total_seconds"`
+	ByUser       []UserTimeTotal `json:"by_user"`
+	Entries      []TimeEntry     `json:"entries"`
+}
+
+type UserTimeSummary struct {
+	UserID       string          `json:"user_id"`
+	From         *time.Time      `json:"from,omitempty"`
+	To           *time.Time      `json:"to,omitempty"`
+	TotalSeconds int64           `json:"total_seconds"`
+	ByTask       []TaskTimeTotal `json:"by_task"`
+}
+
+type BalanceRequest struct {
+	MemberIDs []string `json:"member_ids" binding:"required,min=1"`
+	TaskIDs   []string `json:"task_ids" binding:"max=200"`
+	Project   string   `json:"project"`
+	Strategy  string   `json:"strategy"`
+}
+
+type BalanceAssignment struct {
+	TaskID string  `json:"task_id" binding:"required"`
+	Title  string  `json:"title,omitempty"`
+	UserID string  `json:"user_id" binding:"required"`
+	Effort float64 `json:"effort"`
+}
+
+type MemberLoad struct {
+	UserID     string  `json:"user_id"`
+	OpenEffort float64 `json:"open_effort"`
+}
+
+// Workload is a user's open tasks and recent throughput, used to suggest
+// who to assign a task to
+type Workload struct {
+	UserID     string  `json:"user_id"`
+	Email      string  `json:"email"`
+	OpenTasks  int64   `json:"open_tasks"`
+	OpenHigh   int64   `json:"open_high"`
+	OpenMedium int64   `json:"open_medium"`
+	OpenLow    int64   `json:"open_low"`
+	Overdue    int64   `json:"overdue"`
+	OpenEffort float64 `json:"open_effort"`
+	// CompletedRecently counts the tasks completed in the last
+	// WorkloadWindow
+	CompletedRecently int64 `json:"completed_recently"`
+}
+
+type BalanceProposal struct {
+	Strategy    string              `json:"strategy"`
+	Assignments []BalanceAssignment `json:"assignments"`
+	Before      []MemberLoad        `json:"before"`
+	After       []MemberLoad        `json:"after"`
+
+	// Truncated is set when more unassigned tasks matched than one
+	// proposal covers; apply this one and propose again for the rest
+	Truncated bool `json:"truncated"`
+}
+
+type ApplyBalanceRequest struct {
+	Assignments []BalanceAssignment `json:"assignments" binding:"required,min=1,max=200,dive"`
+}
+
+// TranslationResponse carries a task's content in the requested language
+type TranslationResponse struct {
+	TaskID      string `json:"task_id"`
+	Language    string `json:"lang"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+type CreateChecklistItemRequest struct {
+	Text string `json:"text" binding:"required,max=500"`
+
+	// Position defaults to after the last item
+	Position *int `json:"position" binding:"omitempty,min=0"`
+}
+
+type UpdateChecklistItemRequest struct {
+	Text     *string `json:"text" binding:"omitempty,max=500"`
+	Done     *bool   `json:"done"`
+	Position *int    `json:"position" binding:"omitempty,min=0"`
+}
+
+type ChecklistResponse struct {
+	TaskID          string          `json:"task_id"`
+	Items           []ChecklistItem `json:"items"`
+	PercentComplete int             `json:"percent_complete"`
+}
+
+// ChecklistUpdate is the WebSocket payload for checklist changes
+type ChecklistUpdate struct {
+	TaskID          string        `json:"task_id"`
+	Item            ChecklistItem `json:"item"`
+	ChecklistItems  int64         `json:"checklist_items"`
+	PercentComplete int           `json:"percent_complete"`
+}
+
+// WatchersResponse lists the users watching a task
+type WatchersResponse struct {
+	TaskID   string        `json:"task_id"`
+	Watchers []TaskWatcher `json:"watchers"`
+}
+
+// FieldSchemaRequest sets the requirement of some fields of a project's
+// tasks; fields left out keep their current requirement. DefinitionOfDone,
+// when given, replaces the project's definition of done.
+type FieldSchemaRequest struct {
+	Fields           map[string]string `json:"fields"`
+	DefinitionOfDone *[]string         `json:"definition_of_done" binding:"omitempty,max=20,dive,max=500"`
+	DeadlineType     *string           `json:"deadline_type" binding:"omitempty,oneof=soft hard"`
+}
+
+// ImportTask is one row of a task import file. Assignees are user emails;
+// the first is the primary assignee.
+type ImportTask struct {
+	Title           string   `json:"title"`
+	Description     string   `json:"description"`
+	Priority        string   `json:"priority"`
+	Status          string   `json:"status"`
+	DueDate         string   `json:"due_date"`
+	Project         string   `json:"project"`
+	EstimatedEffort float64  `json:"estimated_effort"`
+	Assignees       []string `json:"assignees"`
+}
+
+// ImportResult reports what an import did, or would do on a dry run
+type ImportResult struct {
+	DryRun   bool             `json:"dry_run"`
+	Rows     int              `json:"rows"`
+	Valid    int              `json:"valid"`
+	Imported int              `json:"imported"`
+	Errors   []ImportRowError `json:"errors"`
+}
+
+// ImportRowError lists everything wrong with one row of an import file.
+// Row 1 is the first task, after the header of a CSV file.
+type ImportRowError struct {
+	Row    int      `json:"row"`
+	Title  string   `json:"title,omitempty"`
+	Errors []string `json:"errors"`
+}
+
+type TransferTaskRequest struct {
+	ToUserID string `json:"to_user_id" binding:"required"`
+	Note     string `json:"note" binding:"max=500"`
+
+	// AcceptWithinHours defaults to TRANSFER_ACCEPT_WINDOW_HOURS
+	AcceptWithinHours int `json:"accept_within_hours" binding:"omitempty,min=1,max=168"`
+}
+
+type DeclineTransferRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// EscalationRuleRequest sets a project's escalation threshold; zero turns
+// escalation off for the project
+type EscalationRuleRequest struct {
+	WithinHours *int `json:"within_hours" binding:"required,min=0,max=720"`
+}
+
+// CalendarRequest replaces a project's business calendar. WorkingDays are
+// weekdays, 0 for Sunday; Holidays are YYYY-MM-DD dates.
+type CalendarRequest struct {
+	Timezone    string         `json:"timezone" binding:"required,max=64"`
+	WorkingDays []time.Weekday `json:"working_days" binding:"required,min=1,max=7"`
+	Holidays    []string       `json:"holidays" binding:"max=366"`
+}
+
+// EscalationHistoryResponse lists a task's priority escalations, oldest
+// first
+type EscalationHistoryResponse struct {
+	TaskID      string           `json:"task_id"`
+	Escalations []TaskEscalation `json:"escalations"`
+}
+
+// AssignmentHistoryResponse lists a task's assignment changes, oldest first
+type AssignmentHistoryResponse struct {
+	TaskID string            `json:"task_id"`
+	Events []AssignmentEvent `json:"events"`
+}
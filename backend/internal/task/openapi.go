@@ -0,0 +1,349 @@
+package task
+
+import (
+	"net/http"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+	"github.com/iSparshP/real-time-task-management-system/internal/openapi"
+)
+
+// Describe annotates the task routes for the OpenAPI document
+func (h *Handler) Describe(spec *openapi.Spec) {
+	spec.Enum(models.TaskStatus(""), string(models.StatusPending), string(models.StatusInProgress), string(models.StatusCompleted))
+	spec.Enum(models.TaskPriority(""), string(models.PriorityLow), string(models.PriorityMedium), string(models.PriorityHigh))
+	spec.Enum(models.DeadlineType(""), string(models.DeadlineSoft), string(models.DeadlineHard))
+	spec.Enum(models.TransferStatus(""), string(models.TransferPending), string(models.TransferAccepted),
+		string(models.TransferDeclined), string(models.TransferExpired))
+	spec.Extend(Task{}, openapi.Fields{"is_overdue": false})
+
+	message := openapi.Fields{"message": ""}
+	tasks := openapi.Fields{"tasks": []Task{}}
+	limit := struct {
+		Limit int `form:"limit"`
+	}{}
+
+	spec.Describe(h.WebSocket, openapi.Operation{
+		Summary:     "Open the task event stream",
+		Description: "Upgrades the connection to a WebSocket carrying task events.",
+		Query: []any{struct {
+			Protocol int `form:"protocol"`
+			Schema   int `form:"schema"`
+		}{}},
+		Status: http.StatusSwitchingProtocols,
+		Errors: []int{http.StatusBadRequest},
+	})
+	spec.Describe(h.CreateTask, openapi.Operation{
+		Summary:  "Create a task",
+		Request:  CreateTaskRequest{},
+		Status:   http.StatusCreated,
+		Response: TaskResponse{},
+		Errors:   []int{http.StatusBadRequest},
+	})
+	spec.Describe(h.ListTasks, openapi.Operation{
+		Summary:  "List tasks",
+		Query:    []any{TaskFilter{}, PaginationParams{}, SortParams{}},
+		Response: TaskListResponse{},
+		Errors:   []int{http.StatusBadRequest},
+	})
+	spec.Describe(h.ListArchivedTasks, openapi.Operation{
+		Summary:     "List archived tasks",
+		Description: "Takes the filters of the task list.",
+		Query:       []any{TaskFilter{}, PaginationParams{}, SortParams{}},
+		Response:    TaskListResponse{},
+		Errors:      []int{http.StatusBadRequest},
+	})
+	spec.Describe(h.RestoreTask, openapi.Operation{
+		Summary:  "Restore an archived task",
+		Response: TaskResponse{},
+		Errors:   []int{http.StatusForbidden, http.StatusNotFound, http.StatusConflict},
+	})
+	spec.Describe(h.GetTask, openapi.Operation{
+		Summary:  "Get a task",
+		Response: TaskResponse{},
+		Errors:   []int{http.StatusForbidden, http.StatusNotFound},
+	})
+	spec.Describe(h.UpdateTask, openapi.Operation{
+		Summary:     "Update a task",
+		Description: "Only the fields given change. Completing a task with unmet checklist items answers 409 with unmet_items.",
+		Request:     UpdateTaskRequest{},
+		Response:    TaskResponse{},
+		Errors:      []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+	})
+	spec.Describe(h.DeleteTask, openapi.Operation{
+		Summary:  "Delete a task",
+		Response: message,
+		Errors:   []int{http.StatusForbidden, http.StatusNotFound},
+	})
+	spec.Describe(h.AssignTask, openapi.Operation{
+		Summary:  "Replace a task's assignees",
+		Request:  AssignTaskRequest{},
+		Response: TaskResponse{},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+	})
+	spec.Describe(h.SearchTasks, openapi.Operation{
+		Summary: "Search tasks",
+		Query: []any{struct {
+			Q     string `form:"q" binding:"required"`
+			Limit int    `form:"limit"`
+		}{}},
+		Response: tasks,
+		Errors:   []int{http.StatusBadRequest},
+	})
+	spec.Describe(h.Typeahead, openapi.Operation{
+		Summary: "Suggest tasks as the user types",
+		Query: []any{struct {
+			Q string `form:"q" binding:"required"`
+		}{}},
+		Response: openapi.Fields{"tasks": []TypeaheadResult{}},
+		Errors:   []int{http.StatusBadRequest},
+	})
+	spec.Describe(h.Presence, openapi.Operation{
+		Summary: "List the users online and the viewers of a task",
+		Query: []any{struct {
+			TaskID string `form:"task_id"`
+		}{}},
+		Response: PresenceResponse{},
+		Errors:   []int{http.StatusForbidden, http.StatusNotFound},
+	})
+	spec.Describe(h.Counters, openapi.Operation{
+		Summary:  "Count the user's open, overdue and due-today tasks",
+		Response: TaskCounters{},
+	})
+	spec.Describe(h.TaskStats, openapi.Operation{
+		Summary:  "Count every task by status, for administrators",
+		Response: TaskStats{},
+		Errors:   []int{http.StatusForbidden},
+	})
+	spec.Describe(h.WebSocketStats, openapi.Operation{
+		Summary:     "Describe the WebSocket clients, for administrators",
+		Description: "Counts are of the replica that answers; each replica serves its own clients.",
+		Response:    WebSocketStats{},
+		Errors:      []int{http.StatusForbidden},
+	})
+	spec.Describe(h.SimilarTasks, openapi.Operation{
+		Summary:  "List tasks similar to a task",
+		Query:    []any{limit},
+		Response: openapi.Fields{"tasks": []SimilarTask{}},
+		Errors:   []int{http.StatusForbidden, http.StatusNotFound},
+	})
+	spec.Describe(h.TranslateTask, openapi.Operation{
+		Summary: "Translate a task",
+		Query: []any{struct {
+			Lang string `form:"lang" binding:"required"`
+		}{}},
+		Response: TranslationResponse{},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound},
+	})
+
+	spec.Describe(h.StartTimer, openapi.Operation{
+		Summary:  "Start the caller's timer on a task",
+		Status:   http.StatusCreated,
+		Response: TimeEntry{},
+		Errors:   []int{http.StatusNotFound, http.StatusConflict},
+	})
+	spec.Describe(h.StopTimer, openapi.Operation{
+		Summary:  "Stop the caller's timer on a task",
+		Response: TimeEntry{},
+		Errors:   []int{http.StatusConflict},
+	})
+	spec.Describe(h.LogWork, openapi.Operation{
+		Summary:  "Log time spent on a task",
+		Request:  WorklogRequest{},
+		Status:   http.StatusCreated,
+		Response: TimeEntry{},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound},
+	})
+	spec.Describe(h.GetTaskTime, openapi.Operation{
+		Summary:  "Total time logged on a task",
+		Response: TaskTimeSummary{},
+		Errors:   []int{http.StatusForbidden, http.StatusNotFound},
+	})
+	spec.Describe(h.GetMyTime, openapi.Operation{
+		Summary: "Time the caller logged",
+		Query: []any{struct {
+			From string `form:"from"`
+			To   string `form:"to"`
+		}{}},
+		Response: UserTimeSummary{},
+		Errors:   []int{http.StatusBadRequest},
+	})
+
+	spec.Describe(h.Sync, openapi.Operation{
+		Summary: "Changes since a sync cursor",
+		Query: []any{struct {
+			Since string `form:"since"`
+			Limit int    `form:"limit"`
+		}{}},
+		Response: SyncResponse{},
+		Errors:   []int{http.StatusBadRequest},
+	})
+	spec.Describe(h.ApplySync, openapi.Operation{
+		Summary:  "Apply changes made offline",
+		Request:  ApplyRequest{},
+		Response: ApplyResponse{},
+		Errors:   []int{http.StatusBadRequest},
+	})
+	spec.Describe(h.ProposeBalance, openapi.Operation{
+		Summary:  "Propose moving work between assignees",
+		Request:  BalanceRequest{},
+		Response: BalanceProposal{},
+		Errors:   []int{http.StatusBadRequest},
+	})
+	spec.Describe(h.ApplyBalance, openapi.Operation{
+		Summary:  "Apply a balance proposal",
+		Request:  ApplyBalanceRequest{},
+		Response: openapi.Fields{"applied": []TaskResponse{}},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict},
+	})
+	spec.Describe(h.ImportTasks, openapi.Operation{
+		Summary:     "Import tasks from a CSV file",
+		Description: "Send the file as multipart/form-data in the file field.",
+		Query: []any{struct {
+			DryRun bool `form:"dry_run"`
+		}{}},
+		Errors: []int{http.StatusBadRequest},
+	})
+
+	spec.Describe(h.CreateTemplate, openapi.Operation{
+		Summary:  "Create a task template",
+		Request:  CreateTemplateRequest{},
+		Status:   http.StatusCreated,
+		Response: TaskTemplate{},
+		Errors:   []int{http.StatusBadRequest},
+	})
+	spec.Describe(h.ListTemplates, openapi.Operation{
+		Summary:  "List task templates",
+		Response: openapi.Fields{"templates": []TaskTemplate{}},
+	})
+	spec.Describe(h.CreateTaskFromTemplate, openapi.Operation{
+		Summary:  "Create a task from a template",
+		Request:  InstantiateTaskRequest{},
+		Status:   http.StatusCreated,
+		Response: TaskResponse{},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound},
+	})
+	spec.Describe(h.CloneTask, openapi.Operation{
+		Summary:  "Clone a task",
+		Request:  InstantiateTaskRequest{},
+		Status:   http.StatusCreated,
+		Response: TaskResponse{},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound},
+	})
+
+	spec.Describe(h.GetChecklist, openapi.Operation{
+		Summary:  "Get a task's checklist",
+		Response: ChecklistResponse{},
+		Errors:   []int{http.StatusNotFound},
+	})
+	spec.Describe(h.AddChecklistItem, openapi.Operation{
+		Summary:  "Add a checklist item",
+		Request:  CreateChecklistItemRequest{},
+		Status:   http.StatusCreated,
+		Response: ChecklistUpdate{},
+		Errors:   []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound},
+	})
+	spec.Describe(h.UpdateChecklistItem, openapi.Operation{
+		Summary:  "Update a checklist item",
+		Request:  UpdateChecklistItemRequest{},
+		Response: ChecklistUpdate{},
+		Errors:   []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound},
+	})
+	spec.Describe(h.DeleteChecklistItem, openapi.Operation{
+		Summary:  "Delete a checklist item",
+		Response: message,
+		Errors:   []int{http.StatusForbidden, http.StatusNotFound},
+	})
+
+	spec.Describe(h.WatchTask, openapi.Operation{
+		Summary:  "Watch a task",
+		Response: WatchersResponse{},
+		Errors:   []int{http.StatusForbidden, http.StatusNotFound},
+	})
+	spec.Describe(h.UnwatchTask, openapi.Operation{
+		Summary:  "Stop watching a task",
+		Response: message,
+		Errors:   []int{http.StatusForbidden, http.StatusNotFound},
+	})
+	spec.Describe(h.ListWatchers, openapi.Operation{
+		Summary:  "List a task's watchers",
+		Response: WatchersResponse{},
+		Errors:   []int{http.StatusForbidden, http.StatusNotFound},
+	})
+
+	spec.Describe(h.LockTask, openapi.Operation{
+		Summary:     "Take or renew a task's edit lock",
+		Description: "Answers 409 while another user holds the lock.",
+		Response:    TaskEditLock{},
+		Errors:      []int{http.StatusForbidden, http.StatusNotFound, http.StatusConflict},
+	})
+	spec.Describe(h.UnlockTask, openapi.Operation{
+		Summary:  "Release a task's edit lock",
+		Response: message,
+		Errors:   []int{http.StatusNotFound},
+	})
+
+	transferErrors := []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusConflict}
+	spec.Describe(h.TransferTask, openapi.Operation{
+		Summary:  "Offer a task to another user",
+		Request:  TransferTaskRequest{},
+		Status:   http.StatusCreated,
+		Response: TaskTransfer{},
+		Errors:   transferErrors,
+	})
+	spec.Describe(h.AcceptTransfer, openapi.Operation{
+		Summary:  "Accept a task transfer",
+		Response: TaskTransfer{},
+		Errors:   transferErrors,
+	})
+	spec.Describe(h.DeclineTransfer, openapi.Operation{
+		Summary:  "Decline a task transfer",
+		Request:  DeclineTransferRequest{},
+		Response: TaskTransfer{},
+		Errors:   transferErrors,
+	})
+	spec.Describe(h.AssignmentHistory, openapi.Operation{
+		Summary:  "List a task's assignment changes",
+		Response: AssignmentHistoryResponse{},
+		Errors:   []int{http.StatusForbidden, http.StatusNotFound},
+	})
+	spec.Describe(h.TaskEscalations, openapi.Operation{
+		Summary:  "List a task's priority escalations",
+		Response: EscalationHistoryResponse{},
+		Errors:   []int{http.StatusForbidden, http.StatusNotFound},
+	})
+
+	spec.Describe(h.GetFieldSchema, openapi.Operation{
+		Summary:  "Get a project's custom field schema",
+		Response: ProjectFieldSchema{},
+	})
+	spec.Describe(h.SetFieldSchema, openapi.Operation{
+		Summary:  "Set a project's custom field schema",
+		Request:  FieldSchemaRequest{},
+		Response: ProjectFieldSchema{},
+		Errors:   []int{http.StatusBadRequest},
+	})
+	spec.Describe(h.GetEscalationRule, openapi.Operation{
+		Summary:     "Get a project's priority escalation rule",
+		Description: "Null when the project's tasks use the default window.",
+		Response:    ProjectEscalationRule{},
+	})
+	spec.Describe(h.SetEscalationRule, openapi.Operation{
+		Summary:     "Set a project's priority escalation rule",
+		Description: "Pending tasks are raised one priority step within_hours before their due date; 0 turns escalation off for the project.",
+		Request:     EscalationRuleRequest{},
+		Response:    ProjectEscalationRule{},
+		Errors:      []int{http.StatusBadRequest},
+	})
+	spec.Describe(h.GetCalendar, openapi.Operation{
+		Summary:     "Get a project's business calendar",
+		Description: "Null when the project's tasks may be due on any day.",
+		Response:    ProjectCalendar{},
+	})
+	spec.Describe(h.SetCalendar, openapi.Operation{
+		Summary:     "Set a project's business calendar",
+		Description: "Due dates of the project's tasks must then fall on working days, and template due_in_days counts working days.",
+		Request:     CalendarRequest{},
+		Response:    ProjectCalendar{},
+		Errors:      []int{http.StatusBadRequest},
+	})
+}
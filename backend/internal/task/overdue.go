@@ -0,0 +1,112 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+	"go.uber.org/zap"
+)
+
+// overdueBatchSize bounds how many tasks one overdue claim takes
+const overdueBatchSize = 500
+
+// DeadlineEscalator is implemented by the notification service
+type DeadlineEscalator interface {
+	EscalateMissedDeadline(ctx context.Context, task Task)
+}
+
+// SetDeadlineEscalator has tasks that pass a hard deadline escalated by
+// escalator, besides the overdue event every overdue task gets
+func (s *Service) SetDeadlineEscalator(escalator DeadlineEscalator) {
+	s.escalator = escalator
+}
+
+// nextOverdueScan is the next time after now that it is hour o'clock UTC
+func nextOverdueScan(now time.Time, hour int) time.Time {
+	now = now.UTC()
+	next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// StartOverdueScan emits the overdue events of newly overdue tasks every
+// night at hour o'clock UTC until ctx is cancelled. A negative hour
+// disables it.
+func (s *Service) StartOverdueScan(ctx context.Context, hour int) {
+	if hour < 0 {
+		return
+	}
+
+	go func() {
+		for {
+			timer := time.NewTimer(time.Until(nextOverdueScan(time.Now(), hour)))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			if emitted, err := s.EmitOverdue(ctx, time.Now()); err != nil && ctx.Err() == nil {
+				s.logger.Error("Failed to emit overdue tasks", zap.Error(err))
+			} else if emitted > 0 {
+				s.logger.Info("Emitted overdue tasks", zap.Int("tasks", emitted))
+			}
+		}
+	}()
+}
+
+// EmitOverdue publishes a task_overdue event, to every client and to the
+// task's followers, for each open task due before now that has not had
+// one since its due date, and returns how many it published. Tasks with a
+// hard deadline are escalated as well. Tasks are
+// claimed with skip-locked row locks before they are published, so
+// replicas never emit the same one, and a task lost to a crash in between
+// gets no event.
+func (s *Service) EmitOverdue(ctx context.Context, now time.Time) (int, error) {
+	emitted := 0
+	for {
+		var ids []string
+		if err := s.db.WithContext(ctx).Raw(`
+			UPDATE tasks SET overdue_notified_at = @now
+			WHERE id IN (
+				SELECT id FROM tasks
+				WHERE deleted_at IS NULL AND status <> 'completed' AND due_date < @now
+					AND (overdue_notified_at IS NULL OR overdue_notified_at < due_date)
+				ORDER BY due_date
+				LIMIT @limit
+				FOR UPDATE SKIP LOCKED)
+			RETURNING id`,
+			map[string]interface{}{"now": now, "limit": overdueBatchSize}).
+			Scan(&ids).Error; err != nil {
+			return emitted, fmt.Errorf("failed to claim overdue tasks: %w", err)
+		}
+		if len(ids) == 0 {
+			return emitted, nil
+		}
+
+		var tasks []Task
+		if err := s.db.WithContext(ctx).Preload("Assignees").
+			Where("id IN ?", ids).
+			Order("due_date ASC").
+			Find(&tasks).Error; err != nil {
+			return emitted, fmt.Errorf("failed to load overdue tasks: %w", err)
+		}
+		for _, task := range tasks {
+			s.publish(TaskOverdueEvent(task))
+			s.notifyFollowers(ctx, MessageTypeTaskOverdue, task)
+			if task.DeadlineType == models.DeadlineHard && s.escalator != nil {
+				s.escalator.EscalateMissedDeadline(ctx, task)
+			}
+			emitted++
+		}
+		s.forgetCounters(ctx, tasks)
+		if len(ids) < overdueBatchSize {
+			return emitted, nil
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+)
+
+type fakeEscalator struct {
+	tasks []string
+}
+
+func (f *fakeEscalator) EscalateMissedDeadline(ctx context.Context, task Task) {
+	f.tasks = append(f.tasks, task.ID)
+}
+
+func TestNextOverdueScan(t *testing.T) {
+	cases := []struct {
+		now, want time.Time
+	}{
+		{time.Date(2024, 3, 11, 23, 30, 0, 0, time.UTC), time.Date(2024, 3, 12, 1, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 3, 12, 0, 30, 0, 0, time.UTC), time.Date(2024, 3, 12, 1, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 3, 12, 1, 0, 0, 0, time.UTC), time.Date(2024, 3, 13, 1, 0, 0, 0, time.UTC)},
+		// 03:00 in Karachi is still 22:00 the day before in UTC
+		{time.Date(2024, 3, 12, 3, 0, 0, 0, time.FixedZone("PKT", 5*3600)), time.Date(2024, 3, 12, 1, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		if got := nextOverdueScan(c.now, 1); !got.Equal(c.want) {
+			t.Errorf("nextOverdueScan(%v) = %v, want %v", c.now, got, c.want)
+		}
+	}
+}
+
+func TestEmitOverdueClaimsNewlyOverdueTasks(t *testing.T) {
+	s, mock := newTestService(t)
+	escalator := &fakeEscalator{}
+	s.SetDeadlineEscalator(escalator)
+	now := time.Date(2024, 3, 12, 1, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`UPDATE tasks SET overdue_notified_at = \$1 WHERE id IN \( SELECT id FROM tasks `+
+		`WHERE deleted_at IS NULL AND status <> 'completed' AND due_date < \$2 `+
+		`AND \(overdue_notified_at IS NULL OR overdue_notified_at < due_date\) `+
+		`ORDER BY due_date LIMIT \$3 FOR UPDATE SKIP LOCKED\) RETURNING id`).
+		WithArgs(now, now, overdueBatchSize).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("task-1").AddRow("task-2"))
+	mock.ExpectQuery(`SELECT \* FROM "tasks" WHERE id IN \(\$1,\$2\)`).
+		WithArgs("task-1", "task-2").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status", "due_date", "deadline_type"}).
+			AddRow("task-1", "pending", now.Add(-time.Hour), "soft").
+			AddRow("task-2", "in_progress", now.Add(-time.Minute), "hard"))
+	mock.ExpectQuery(`SELECT \* FROM "task_assignees" WHERE "task_assignees"."task_id" IN \(\$1,\$2\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"task_id", "user_id"}))
+
+	emitted, err := s.EmitOverdue(context.Background(), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if emitted != 2 || len(escalator.tasks) != 1 || escalator.tasks[0] != "task-2" {
+		t.Fatalf("emitted = %d and escalated %v, want 2 emitted and the hard deadline of task-2 escalated",
+			emitted, escalator.tasks)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCreateTaskUsesProjectDeadlineType(t *testing.T) {
+	s, mock := newTestService(t)
+	mock.ExpectQuery(`SELECT \* FROM "project_field_schemas" WHERE project = \$1`).
+		WithArgs("ops", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"project", "fields", "deadline_type"}).AddRow("ops", `{}`, "hard"))
+	expectCalendar(mock, "ops", "")
+	expectCreateTask(mock)
+
+	resp, err := s.CreateTask(context.Background(), CreateTaskRequest{
+		Title: "Renew certificates", Priority: "high", Project: "ops", DueDate: time.Now().Add(time.Hour),
+	}, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Task.DeadlineType != models.DeadlineHard {
+		t.Fatalf("deadline type = %q, want the project's hard default", resp.Task.DeadlineType)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpdateTaskPastHardDeadlineKeepsDeadlineWithCreator(t *testing.T) {
+	s, mock := newTestService(t)
+	mock.ExpectQuery(`SELECT \* FROM "tasks" WHERE id = \$1`).
+		WithArgs("task-1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "status", "priority", "created_by", "due_date", "deadline_type"}).
+			AddRow("task-1", "Renew certificates", "in_progress", "high", "user-1", time.Now().Add(-time.Hour), "hard"))
+	mock.ExpectQuery(`SELECT \* FROM "task_assignees"`).
+		WillReturnRows(sqlmock.NewRows([]string{"task_id", "user_id", "is_primary"}).AddRow("task-1", "user-2", true))
+
+	due := time.Now().Add(24 * time.Hour)
+	_, err := s.UpdateTask(context.Background(), "task-1", UpdateTaskRequest{DueDate: &due}, "user-2")
+	if !errors.Is(err, ErrHardDeadlinePassed) {
+		t.Fatalf("err = %v, want ErrHardDeadlinePassed for the assignee", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
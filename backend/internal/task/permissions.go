@@ -0,0 +1,41 @@
+package task
+
+import "context"
+
+// canViewTask reports whether userID may read the task. Besides its
+// creator and assignees, its watchers and the members of its project can:
+// anyone who created or is assigned to a task in the same project. task
+// must have Assignees preloaded.
+func (s *Service) canViewTask(ctx context.Context, userID string, task *Task) (bool, error) {
+	if s.canModifyTask(userID, task) {
+		return true, nil
+	}
+
+	var visible bool
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT EXISTS (SELECT 1 FROM task_watchers WHERE task_id = @task AND user_id = @user)
+		OR (@project <> '' AND EXISTS (
+			SELECT 1 FROM tasks t
+			WHERE t.project = @project AND t.deleted_at IS NULL
+			AND (t.created_by = @user OR EXISTS (
+				SELECT 1 FROM task_assignees ta WHERE ta.task_id = t.id AND ta.user_id = @user))))`,
+		map[string]interface{}{"task": task.ID, "user": userID, "project": task.Project}).
+		Scan(&visible).Error
+	return visible, err
+}
+
+// findVisibleTask loads a task with its assignees if userID may read it
+func (s *Service) findVisibleTask(ctx context.Context, taskID string, userID string) (*Task, error) {
+	task, err := s.tasks.Get(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	visible, err := s.canViewTask(ctx, userID, task)
+	if err != nil {
+		return nil, err
+	}
+	if !visible {
+		return nil, ErrUnauthorized
+	}
+	return task, nil
+}
@@ -0,0 +1,80 @@
+package task
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func expectVisibility(mock sqlmock.Sqlmock, visible bool) {
+	mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM task_watchers`).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(visible))
+}
+
+func TestGetTaskVisibility(t *testing.T) {
+	tests := []struct {
+		name    string
+		userID  string
+		visible bool
+		wantErr error
+	}{
+		{name: "creator", userID: "user-1", wantErr: nil},
+		{name: "watcher or project member", userID: "user-2", visible: true, wantErr: nil},
+		{name: "stranger", userID: "user-3", visible: false, wantErr: ErrUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock := newTestService(t)
+			expectModifiableTask(mock, "task-1", "user-1")
+			if tt.userID != "user-1" {
+				expectVisibility(mock, tt.visible)
+			}
+			if tt.wantErr == nil {
+				expectChecklistProgress(mock, 0, 0)
+				mock.ExpectQuery(`SELECT COALESCE\(SUM\(duration_seconds\), 0\)`).
+					WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(0))
+			}
+
+			if _, err := s.GetTask(context.Background(), "task-1", tt.userID); err != tt.wantErr {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestDeleteTaskRequiresCreator(t *testing.T) {
+	s, mock := newTestService(t)
+	mock.ExpectQuery(`SELECT \* FROM "tasks" WHERE id = \$1`).
+		WithArgs("task-1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_by"}).AddRow("task-1", "user-1"))
+
+	if err := s.DeleteTask(context.Background(), "task-1", "user-2"); err != ErrUnauthorized {
+		t.Fatalf("err = %v, want ErrUnauthorized", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeleteTaskByCreator(t *testing.T) {
+	s, mock := newTestService(t)
+	mock.ExpectQuery(`SELECT \* FROM "tasks" WHERE id = \$1`).
+		WithArgs("task-1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_by"}).AddRow("task-1", "user-1"))
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "tasks" SET "deleted_at"=\$1 WHERE \(id = \$2 AND created_by = \$3\)`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := s.DeleteTask(context.Background(), "task-1", "user-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
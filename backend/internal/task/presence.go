@@ -0,0 +1,240 @@
+package task
+
+import (
+	"context"
+	"sort"
+
+	"github.com/gorilla/websocket"
+)
+
+// Client frames of presence: a PresenceRequest to follow who comes online,
+// and a ViewingRequest when the task the client has open changes
+const (
+	PresenceMessageType = "presence"
+	ViewingMessageType  = "viewing"
+)
+
+// Viewing states of a user on a task, as sent in task_viewing messages
+const (
+	ViewingOpen    = "viewing"
+	ViewingEditing = "editing"
+	ViewingLeft    = "left"
+)
+
+// PresenceRequest is sent by clients to start receiving presence_join and
+// presence_leave messages. They are answered with the current presence,
+// see Presence.
+type PresenceRequest struct {
+	Type string `json:"type"`
+}
+
+// ViewingRequest is sent by clients when they open a task, start or stop
+// editing it, or close it with an empty TaskID
+type ViewingRequest struct {
+	Type    string `json:"type"`
+	TaskID  string `json:"task_id"`
+	Editing bool   `json:"editing"`
+}
+
+// PresenceChange is the payload of presence_join and presence_leave
+type PresenceChange struct {
+	UserID string `json:"user_id"`
+}
+
+// TaskViewing is the payload of a task_viewing message: the user's state
+// on the task across all of the user's connections
+type TaskViewing struct {
+	TaskID string `json:"task_id"`
+	UserID string `json:"user_id"`
+	State  string `json:"state"`
+}
+
+// TaskViewer is a user who has a task open
+type TaskViewer struct {
+	UserID  string `json:"user_id"`
+	Editing bool   `json:"editing"`
+}
+
+// PresenceResponse is who is connected to this replica and, when asked
+// for a task, who has it open
+type PresenceResponse struct {
+	Online  []string     `json:"online"`
+	Viewers []TaskViewer `json:"viewers,omitempty"`
+}
+
+// FollowPresence sends conn presence_join and presence_leave messages from
+// now on, and a presence message with the users online now
+func (s *Service) FollowPresence(ctx context.Context, conn *websocket.Conn, userID string) error {
+	s.clientsMux.Lock()
+	client, ok := s.clients[conn]
+	if ok {
+		client.presence = true
+	}
+	s.clientsMux.Unlock()
+	if !ok {
+		return nil
+	}
+
+	presence, err := s.Presence(ctx, userID, "")
+	if err != nil {
+		return err
+	}
+	s.sendDirect(conn, client, NewWebSocketMessage(MessageTypePresence, presence))
+	return nil
+}
+
+// View records which task, if any, conn has open and whether it is being
+// edited. Viewing a task needs permission to see it. Users viewing the
+// task are told when the user's state on it changes.
+func (s *Service) View(ctx context.Context, conn *websocket.Conn, userID, taskID string, editing bool) error {
+	if userID == "" {
+		return nil
+	}
+	if taskID != "" {
+		if _, err := s.findVisibleTask(ctx, taskID, userID); err != nil {
+			return err
+		}
+	} else {
+		editing = false
+	}
+
+	s.clientsMux.Lock()
+	client, ok := s.clients[conn]
+	if !ok {
+		s.clientsMux.Unlock()
+		return nil
+	}
+	previous := client.viewing
+	tasks := []string{taskID}
+	if previous != "" && previous != taskID {
+		tasks = append(tasks, previous)
+	}
+	before := make([]string, len(tasks))
+	for i, id := range tasks {
+		before[i] = s.viewingState(id, userID)
+	}
+	client.viewing, client.editing = taskID, editing
+	var changes []WebSocketMessage
+	for i, id := range tasks {
+		if state := s.viewingState(id, userID); id != "" && state != before[i] {
+			changes = append(changes, s.viewingEvent(id, userID, state))
+		}
+	}
+	s.clientsMux.Unlock()
+
+	for _, msg := range changes {
+		s.publish(msg)
+	}
+	return nil
+}
+
+// Presence returns the users connected to this replica and, if taskID is
+// set, who has that task open. Seeing a task's viewers needs permission to
+// see the task.
+func (s *Service) Presence(ctx context.Context, userID, taskID string) (*PresenceResponse, error) {
+	if taskID != "" {
+		if _, err := s.findVisibleTask(ctx, taskID, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	online := make(map[string]bool)
+	viewers := make(map[string]bool)
+	s.clientsMux.RLock()
+	for _, client := range s.clients {
+		if client.userID == "" {
+			continue
+		}
+		online[client.userID] = true
+		if taskID != "" && client.viewing == taskID {
+			viewers[client.userID] = viewers[client.userID] || client.editing
+		}
+	}
+	s.clientsMux.RUnlock()
+
+	resp := &PresenceResponse{Online: make([]string, 0, len(online))}
+	for id := range online {
+		resp.Online = append(resp.Online, id)
+	}
+	sort.Strings(resp.Online)
+	if taskID != "" {
+		resp.Viewers = make([]TaskViewer, 0, len(viewers))
+		for id, editing := range viewers {
+			resp.Viewers = append(resp.Viewers, TaskViewer{UserID: id, Editing: editing})
+		}
+		sort.Slice(resp.Viewers, func(i, j int) bool { return resp.Viewers[i].UserID < resp.Viewers[j].UserID })
+	}
+	return resp, nil
+}
+
+// addClient adds conn to the broadcast set and returns the presence_join
+// of its user if it is the user's first connection. Callers hold
+// clientsMux.
+func (s *Service) addClient(conn *websocket.Conn, client *wsClient) []WebSocketMessage {
+	s.clients[conn] = client
+	if client.userID == "" || s.userConnections(client.userID) > 1 {
+		return nil
+	}
+	return []WebSocketMessage{PresenceEvent(MessageTypePresenceJoin, client.userID)}
+}
+
+// removeClient drops conn from the broadcast set and returns the messages
+// announcing what its user left: the task it had open, unless another
+// connection of the user still has it open the same way, and the replica
+// if it was the user's last connection. Callers hold clientsMux.
+func (s *Service) removeClient(conn *websocket.Conn, client *wsClient) []WebSocketMessage {
+	before := s.viewingState(client.viewing, client.userID)
+	delete(s.clients, conn)
+	if client.userID == "" {
+		return nil
+	}
+
+	var msgs []WebSocketMessage
+	if after := s.viewingState(client.viewing, client.userID); client.viewing != "" && after != before {
+		msgs = append(msgs, s.viewingEvent(client.viewing, client.userID, after))
+	}
+	if s.userConnections(client.userID) == 0 {
+		msgs = append(msgs, PresenceEvent(MessageTypePresenceLeave, client.userID))
+	}
+	return msgs
+}
+
+// userConnections counts the user's connections. Callers hold clientsMux.
+func (s *Service) userConnections(userID string) int {
+	n := 0
+	for _, client := range s.clients {
+		if client.userID == userID {
+			n++
+		}
+	}
+	return n
+}
+
+// viewingState is the user's state on the task across the user's
+// connections. Callers hold clientsMux.
+func (s *Service) viewingState(taskID, userID string) string {
+	state := ViewingLeft
+	for _, client := range s.clients {
+		if client.userID != userID || client.viewing != taskID || taskID == "" {
+			continue
+		}
+		if client.editing {
+			return ViewingEditing
+		}
+		state = ViewingOpen
+	}
+	return state
+}
+
+// viewingEvent builds the task_viewing message for the users who have the
+// task open. Callers hold clientsMux.
+func (s *Service) viewingEvent(taskID, userID, state string) WebSocketMessage {
+	msg := TaskViewingEvent(TaskViewing{TaskID: taskID, UserID: userID, State: state})
+	msg.recipients = make(map[string]bool)
+	for _, client := range s.clients {
+		if client.viewing == taskID && client.userID != "" {
+			msg.recipients[client.userID] = true
+		}
+	}
+	return msg
+}
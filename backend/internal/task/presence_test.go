@@ -0,0 +1,109 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// sharedTask is a TaskRepository with one task, which its creator user-1
+// and assignee user-2 can see
+type sharedTask struct {
+	TaskRepository
+}
+
+func (sharedTask) Get(_ context.Context, id string) (*Task, error) {
+	if id != "task-1" {
+		return nil, ErrTaskNotFound
+	}
+	return &Task{ID: id, CreatedBy: "user-1", AssignedTo: "user-2"}, nil
+}
+
+func decodePayload(t *testing.T, msg WebSocketMessage, payload interface{}) {
+	t.Helper()
+	data, err := json.Marshal(msg.Payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, payload); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPresenceFollowersSeeUsersJoinAndLeave(t *testing.T) {
+	s, _ := newTestService(t)
+	follower := dialHubAs(t, s, "user-1")
+	if err := follower.WriteJSON(PresenceRequest{Type: PresenceMessageType}); err != nil {
+		t.Fatal(err)
+	}
+	msg := readMessage(t, follower)
+	var presence PresenceResponse
+	decodePayload(t, msg, &presence)
+	if msg.Type != MessageTypePresence || len(presence.Online) != 1 || presence.Online[0] != "user-1" {
+		t.Fatalf("got %s %+v, want user-1 alone online", msg.Type, presence)
+	}
+
+	other := dialHubAs(t, s, "user-2")
+	var change PresenceChange
+	msg = readMessage(t, follower)
+	decodePayload(t, msg, &change)
+	if msg.Type != MessageTypePresenceJoin || change.UserID != "user-2" {
+		t.Fatalf("got %s %+v, want user-2 joining", msg.Type, change)
+	}
+
+	other.Close()
+	msg = readMessage(t, follower)
+	decodePayload(t, msg, &change)
+	if msg.Type != MessageTypePresenceLeave || change.UserID != "user-2" {
+		t.Fatalf("got %s %+v, want user-2 leaving", msg.Type, change)
+	}
+}
+
+func TestViewersAreToldWhoOpensAndEditsTask(t *testing.T) {
+	s := NewServiceWithRepository(nil, sharedTask{}, zap.NewNop())
+	t.Cleanup(func() { s.Shutdown(context.Background()) })
+	creator := dialHubAs(t, s, "user-1")
+	assignee := dialHubAs(t, s, "user-2")
+
+	if err := creator.WriteJSON(ViewingRequest{Type: ViewingMessageType, TaskID: "task-1"}); err != nil {
+		t.Fatal(err)
+	}
+	var viewing TaskViewing
+	decodePayload(t, readMessage(t, creator), &viewing)
+	if viewing != (TaskViewing{TaskID: "task-1", UserID: "user-1", State: ViewingOpen}) {
+		t.Fatalf("creator got %+v, want its own viewing", viewing)
+	}
+
+	if err := assignee.WriteJSON(ViewingRequest{Type: ViewingMessageType, TaskID: "task-1", Editing: true}); err != nil {
+		t.Fatal(err)
+	}
+	decodePayload(t, readMessage(t, creator), &viewing)
+	if viewing != (TaskViewing{TaskID: "task-1", UserID: "user-2", State: ViewingEditing}) {
+		t.Fatalf("creator got %+v, want the assignee editing", viewing)
+	}
+
+	presence, err := s.Presence(context.Background(), "user-1", "task-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []TaskViewer{{UserID: "user-1"}, {UserID: "user-2", Editing: true}}
+	if len(presence.Viewers) != 2 || presence.Viewers[0] != want[0] || presence.Viewers[1] != want[1] {
+		t.Fatalf("viewers = %+v, want %+v", presence.Viewers, want)
+	}
+
+	assignee.Close()
+	decodePayload(t, readMessage(t, creator), &viewing)
+	if viewing != (TaskViewing{TaskID: "task-1", UserID: "user-2", State: ViewingLeft}) {
+		t.Fatalf("creator got %+v, want the assignee leaving", viewing)
+	}
+}
+
+func TestPresenceOfUnknownTaskIsNotFound(t *testing.T) {
+	s := NewServiceWithRepository(nil, sharedTask{}, zap.NewNop())
+	t.Cleanup(func() { s.Shutdown(context.Background()) })
+	if _, err := s.Presence(context.Background(), "user-1", "task-2"); err != ErrTaskNotFound {
+		t.Fatalf("err = %v, want ErrTaskNotFound", err)
+	}
+}
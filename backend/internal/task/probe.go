@@ -0,0 +1,102 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+const (
+	// probeKeepAlive is how often the probe pings to stay under the
+	// server's 60 second read deadline
+	probeKeepAlive = 30 * time.Second
+	// probeRetryDelay is how long the probe waits before reconnecting
+	probeRetryDelay = 5 * time.Second
+)
+
+// StartDeliveryProbe runs a built-in WebSocket client that times every
+// task event from mutation to receipt. It connects over loopback to a
+// private listener, so it measures the broadcast loop, encoding and the
+// socket without the ingress or client network, and it shares the
+// server's clock. It stops when ctx is done.
+func (s *Service) StartDeliveryProbe(ctx context.Context, observe func(latency time.Duration)) error {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	router := gin.New()
+	router.GET("/ws", NewHandler(s, s.logger).WebSocket)
+	server := &http.Server{Handler: router, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Error("Delivery probe listener stopped", zap.Error(err))
+		}
+	}()
+
+	go func() {
+		defer server.Close()
+		url := "ws://" + listener.Addr().String() + "/ws"
+		for {
+			if err := s.runProbe(ctx, url, observe); err != nil && ctx.Err() == nil {
+				s.logger.Warn("Delivery probe disconnected", zap.Error(err))
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(probeRetryDelay):
+			}
+		}
+	}()
+	return nil
+}
+
+// runProbe reads events on one connection until it fails or ctx is done
+func (s *Service) runProbe(ctx context.Context, url string, observe func(latency time.Duration)) error {
+	// The oldest protocol served is the closest to unbatched delivery
+	proto := protocols[s.minProtocol]
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, url+"?protocol="+strconv.Itoa(proto.version), nil)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(probeKeepAlive)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				conn.Close()
+				return
+			case <-done:
+				return
+			case <-ticker.C:
+				// The writer is only used here, so no lock is needed
+				if err := conn.WriteMessage(websocket.TextMessage, []byte("ping")); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		msgs, err := proto.read(conn)
+		if err != nil {
+			return err
+		}
+		for _, msg := range msgs {
+			if msg.Type != MessageTypeWelcome && !msg.Timestamp.IsZero() {
+				observe(time.Since(msg.Timestamp))
+			}
+		}
+	}
+}
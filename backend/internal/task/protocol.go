@@ -0,0 +1,227 @@
+package task
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/ugorji/go/codec"
+	"go.uber.org/zap"
+)
+
+// WebSocket protocol versions. Clients that do not negotiate speak the
+// legacy version.
+const (
+	LegacyProtocolVersion = 1
+	LatestProtocolVersion = 3
+)
+
+const (
+	// HelloMessageType is the type of a ClientHello
+	HelloMessageType = "hello"
+
+	// MessageTypeWelcome answers a negotiated connection with its protocol.
+	// The payload is a Welcome.
+	MessageTypeWelcome MessageType = "welcome"
+
+	// protocolBatchWindow is how long batching protocols collect events
+	// before writing a frame
+	protocolBatchWindow = 25 * time.Millisecond
+	maxBatchEvents      = 100
+)
+
+var ErrProtocolUnsupported = errors.New("unsupported WebSocket protocol version")
+
+// protocol is how the hub frames messages for one protocol version
+type protocol struct {
+	version int
+	// batch sends events as Batch frames, collecting the events published
+	// within protocolBatchWindow
+	batch bool
+	// binary frames are MessagePack instead of JSON
+	binary bool
+	// deprecation warns clients of a version that will be removed
+	deprecation string
+}
+
+var protocols = map[int]*protocol{
+	1: {version: 1, deprecation: "protocol 1 is deprecated and will be removed; negotiate protocol 2 or later"},
+	2: {version: 2, batch: true},
+	3: {version: 3, batch: true, binary: true},
+}
+
+var msgpackHandle = &codec.MsgpackHandle{WriteExt: true}
+
+// ClientHello is the first frame of a client that negotiates its protocol
+// in-band instead of with the protocol query parameter
+type ClientHello struct {
+	Type     string `json:"type"`
+	Protocol int    `json:"protocol"`
+}
+
+// Welcome tells a client which protocol the connection uses. Warning is set
+// for deprecated versions.
+type Welcome struct {
+	Protocol   int    `json:"protocol"`
+	Supported  []int  `json:"supported"`
+	Deprecated bool   `json:"deprecated"`
+	Warning    string `json:"warning,omitempty"`
+}
+
+// Batch is a frame of protocol 2 and later: events in publish order
+type Batch struct {
+	Events []WebSocketMessage `json:"events"`
+}
+
+// NegotiateProtocol picks the protocol version for a client asking for
+// requested: the latest one if the client is newer than the server. It
+// fails for versions the server no longer supports.
+func (s *Service) NegotiateProtocol(requested int) (int, error) {
+	if requested > LatestProtocolVersion {
+		requested = LatestProtocolVersion
+	}
+	if requested < s.minProtocol || protocols[requested] == nil {
+		return 0, fmt.Errorf("%w: %d, the oldest supported is %d", ErrProtocolUnsupported, requested, s.minProtocol)
+	}
+	return requested, nil
+}
+
+// SetMinProtocolVersion retires the protocol versions older than version.
+// Clients must then negotiate a version when they connect.
+func (s *Service) SetMinProtocolVersion(version int) {
+	s.minProtocol = min(max(version, LegacyProtocolVersion), LatestProtocolVersion)
+}
+
+// LegacyProtocolAllowed reports whether clients may connect without
+// negotiating a protocol
+func (s *Service) LegacyProtocolAllowed() bool {
+	return s.minProtocol <= LegacyProtocolVersion
+}
+
+// SwitchProtocol moves conn to a negotiated protocol version and welcomes
+// the client
+func (s *Service) SwitchProtocol(conn *websocket.Conn, version int) {
+	s.clientsMux.Lock()
+	client, ok := s.clients[conn]
+	if ok {
+		client.protocol = protocols[version]
+	}
+	s.clientsMux.Unlock()
+	if ok {
+		s.welcome(conn, client)
+	}
+}
+
+// DeprecatedProtocolClients is the number of connections speaking a
+// deprecated protocol version
+func (s *Service) DeprecatedProtocolClients() int {
+	s.clientsMux.RLock()
+	defer s.clientsMux.RUnlock()
+	deprecated := 0
+	for _, client := range s.clients {
+		if client.protocol.deprecation != "" {
+			deprecated++
+		}
+	}
+	return deprecated
+}
+
+// welcome tells a client that negotiated which protocol it got
+func (s *Service) welcome(conn *websocket.Conn, client *wsClient) {
+	supported := make([]int, 0, len(protocols))
+	for version := range protocols {
+		if version >= s.minProtocol {
+			supported = append(supported, version)
+		}
+	}
+	sort.Ints(supported)
+
+	s.clientsMux.RLock()
+	proto := client.protocol
+	s.clientsMux.RUnlock()
+	msg := NewWebSocketMessage(MessageTypeWelcome, Welcome{
+		Protocol:   proto.version,
+		Supported:  supported,
+		Deprecated: proto.deprecation != "",
+		Warning:    proto.deprecation,
+	})
+	s.sendDirect(conn, client, msg)
+}
+
+// sendDirect writes a message to one connection. It is not broadcast, so it
+// has no receipt timing.
+func (s *Service) sendDirect(conn *websocket.Conn, client *wsClient, msg WebSocketMessage) {
+	msg.EventID = uuid.New().String()
+	msg = msg.forSchema(client.schema)
+	s.clientsMux.RLock()
+	proto := client.protocol
+	s.clientsMux.RUnlock()
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if err := proto.write(conn, []WebSocketMessage{msg}); err != nil {
+		s.logger.Error("Failed to send message", zap.Error(err))
+		s.UnregisterClient(conn)
+	}
+}
+
+// write sends msgs to conn in the protocol's format. Callers hold the
+// client's write lock.
+func (p *protocol) write(conn *websocket.Conn, msgs []WebSocketMessage) error {
+	if !p.batch {
+		for _, msg := range msgs {
+			if err := conn.WriteJSON(msg); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for len(msgs) > 0 {
+		n := min(len(msgs), maxBatchEvents)
+		frame := Batch{Events: msgs[:n]}
+		msgs = msgs[n:]
+
+		if !p.binary {
+			if err := conn.WriteJSON(frame); err != nil {
+				return err
+			}
+			continue
+		}
+		var data []byte
+		if err := codec.NewEncoderBytes(&data, msgpackHandle).Encode(frame); err != nil {
+			return err
+		}
+		if err := conn.WriteMessage(websocket.BinaryMessage, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// read reads one frame of the protocol from conn, as a client would
+func (p *protocol) read(conn *websocket.Conn) ([]WebSocketMessage, error) {
+	if !p.batch {
+		var msg WebSocketMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			return nil, err
+		}
+		return []WebSocketMessage{msg}, nil
+	}
+
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		return nil, err
+	}
+	var batch Batch
+	if p.binary {
+		err = codec.NewDecoderBytes(data, msgpackHandle).Decode(&batch)
+	} else {
+		err = json.Unmarshal(data, &batch)
+	}
+	return batch.Events, err
+}
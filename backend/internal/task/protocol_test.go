@@ -0,0 +1,119 @@
+package task
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"github.com/ugorji/go/codec"
+	"go.uber.org/zap"
+)
+
+// dialProtocol connects user-1 to the hub with the given query string
+func dialProtocol(t *testing.T, s *Service, query string) (*websocket.Conn, *http.Response, error) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(common.ErrorHandler(zap.NewNop()))
+	router.GET("/ws", func(c *gin.Context) { c.Set("user_id", "user-1") }, NewHandler(s, zap.NewNop()).WebSocket)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws"+query, nil)
+	if err == nil {
+		t.Cleanup(func() { conn.Close() })
+	}
+	return conn, resp, err
+}
+
+func readBatch(t *testing.T, conn *websocket.Conn) Batch {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	frameType, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var batch Batch
+	if frameType == websocket.BinaryMessage {
+		err = codec.NewDecoderBytes(data, msgpackHandle).Decode(&batch)
+	} else {
+		err = codec.NewDecoderBytes(data, &codec.JsonHandle{}).Decode(&batch)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return batch
+}
+
+func TestMessagePackProtocolBatchesEvents(t *testing.T) {
+	s, _ := newTestService(t)
+	conn, resp, err := dialProtocol(t, s, "?protocol=3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Header.Get("X-Protocol-Version") != "3" || resp.Header.Get("Deprecation") != "" {
+		t.Fatalf("headers = %v, want protocol 3 without deprecation", resp.Header)
+	}
+
+	welcome := readBatch(t, conn)
+	if len(welcome.Events) != 1 || welcome.Events[0].Type != MessageTypeWelcome {
+		t.Fatalf("first frame = %+v, want a welcome", welcome)
+	}
+
+	s.publish(NewWebSocketMessage(MessageTypeTaskCreated, "task-1"))
+	s.publish(NewWebSocketMessage(MessageTypeTaskUpdated, "task-1"))
+	batch := readBatch(t, conn)
+	if len(batch.Events) != 2 || batch.Events[0].Type != MessageTypeTaskCreated || batch.Events[1].Type != MessageTypeTaskUpdated {
+		t.Fatalf("frame = %+v, want both events in order", batch)
+	}
+}
+
+func TestHelloNegotiatesProtocol(t *testing.T) {
+	s, _ := newTestService(t)
+	conn, resp, err := dialProtocol(t, s, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Header.Get("Deprecation") != "true" {
+		t.Fatalf("headers = %v, want the legacy protocol marked deprecated", resp.Header)
+	}
+	deadline := time.Now().Add(time.Second)
+	for s.ConnectedClients() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if s.DeprecatedProtocolClients() != 1 {
+		t.Fatalf("deprecated clients = %d, want 1", s.DeprecatedProtocolClients())
+	}
+
+	// Newer clients get the latest version the server has
+	if err := conn.WriteJSON(ClientHello{Type: HelloMessageType, Protocol: 9}); err != nil {
+		t.Fatal(err)
+	}
+	welcome := readBatch(t, conn)
+	if len(welcome.Events) != 1 || welcome.Events[0].Type != MessageTypeWelcome {
+		t.Fatalf("first frame = %+v, want a welcome", welcome)
+	}
+	if s.DeprecatedProtocolClients() != 0 {
+		t.Fatalf("deprecated clients = %d, want 0 after negotiating", s.DeprecatedProtocolClients())
+	}
+}
+
+func TestRetiredProtocolIsRefused(t *testing.T) {
+	s, _ := newTestService(t)
+	s.SetMinProtocolVersion(2)
+
+	for _, query := range []string{"", "?protocol=1"} {
+		_, resp, err := dialProtocol(t, s, query)
+		if err == nil || resp == nil || resp.StatusCode != http.StatusUpgradeRequired {
+			t.Errorf("query %q: err = %v, want 426", query, err)
+		}
+	}
+	if _, _, err := dialProtocol(t, s, "?protocol=2"); err != nil {
+		t.Fatal(err)
+	}
+}
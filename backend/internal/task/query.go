@@ -3,20 +3,40 @@ package task
 import "time"
 
 type TaskFilter struct {
-	Status     *string    `form:"status"`
-	Priority   *string    `form:"priority"`
+	Status     *string    `form:"status" binding:"omitempty,oneof=pending in_progress completed"`
+	Priority   *string    `form:"priority" binding:"omitempty,oneof=low medium high"`
 	AssignedTo *string    `form:"assigned_to"`
 	CreatedBy  *string    `form:"created_by"`
 	DueBefore  *time.Time `form:"due_before"`
 	DueAfter   *time.Time `form:"due_after"`
+	// Overdue keeps only open tasks past their due date, or leaves them
+	// out when false
+	Overdue *bool `form:"overdue"`
+	// Archived lists archived tasks instead of the others
+	Archived bool `form:"-"`
 }
 
+const maxPageSize = 100
+
 type PaginationParams struct {
-	Page     int `form:"page,default=1"`
-	PageSize int `form:"page_size,default=10"`
+	Page int `form:"page,default=1"`
+	// PageSize defaults to TASK_PAGE_SIZE when omitted
+	PageSize int `form:"page_size"`
 }
 
 type SortParams struct {
 	SortBy    string `form:"sort_by,default=created_at"`
-	SortOrder string `form:"sort_order,default=desc"`
+	SortOrder string `form:"sort_order,default=desc" binding:"oneof=asc desc"`
+}
+
+// sortColumns maps the sort_by values clients may use to ORDER BY
+// expressions. Priority sorts by rank rather than alphabetically.
+var sortColumns = map[string]string{
+	"created_at":   "created_at",
+	"updated_at":   "updated_at",
+	"due_date":     "due_date",
+	"completed_at": "completed_at",
+	"title":        "title",
+	"status":       "status",
+	"priority":     "CASE priority WHEN 'high' THEN 3 WHEN 'medium' THEN 2 ELSE 1 END",
 }
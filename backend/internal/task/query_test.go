@@ -0,0 +1,137 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"go.uber.org/zap"
+)
+
+func listTasks(t *testing.T, s *Service, query string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(common.ErrorHandler(zap.NewNop()))
+	router.GET("/tasks", NewHandler(s, zap.NewNop()).ListTasks)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tasks?"+query, nil))
+	return w
+}
+
+func TestListTasksBindsFiltersAndSort(t *testing.T) {
+	s, mock := newTestService(t)
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "tasks"`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(25))
+	mock.ExpectQuery(`SELECT \* FROM "tasks" WHERE status = \$1 AND priority = \$2 AND created_by = \$3 AND due_date <= \$4 .* ORDER BY CASE priority WHEN 'high' THEN 3 .* END ASC, id ASC LIMIT \$5 OFFSET \$6`).
+		WithArgs("pending", "high", "user-1", sqlmock.AnyArg(), 20, 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	w := listTasks(t, s, "status=pending&priority=high&created_by=user-1&due_before=2030-01-01T00:00:00Z"+
+		"&page=2&page_size=20&sort_by=priority&sort_order=asc")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (body %s)", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestListTasksFiltersOverdue(t *testing.T) {
+	s, mock := newTestService(t)
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "tasks" WHERE \(status <> 'completed' AND due_date < \$1\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(`SELECT \* FROM "tasks" WHERE \(status <> 'completed' AND due_date < \$1\) AND archived_at IS NULL AND "tasks"."deleted_at" IS NULL`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	if w := listTasks(t, s, "overdue=true&page_size=20"); w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (body %s)", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestListTasksRejectsInvalidParams(t *testing.T) {
+	for _, query := range []string{
+		"sort_by=password",
+		"sort_by=created_at%3BDROP%20TABLE%20tasks",
+		"sort_order=sideways",
+		"page=0",
+		"page_size=101",
+		"status=archived",
+		"due_before=tomorrow",
+		"overdue=maybe",
+	} {
+		t.Run(query, func(t *testing.T) {
+			s, _ := newTestService(t)
+			if w := listTasks(t, s, query); w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want 400 (body %s)", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestListTasksCountsEveryPage(t *testing.T) {
+	s, mock := newTestService(t)
+	// The count matches the whole filtered set, without the page's
+	// ordering or limits
+	count := `^SELECT count\(\*\) FROM "tasks" WHERE status = \$1 AND archived_at IS NULL AND "tasks"."deleted_at" IS NULL$`
+	for _, page := range []struct {
+		offset, rows int
+	}{{0, 10}, {10, 10}, {20, 5}} {
+		mock.ExpectQuery(count).WithArgs("pending").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(25))
+		rows := sqlmock.NewRows([]string{"id"})
+		for i := 0; i < page.rows; i++ {
+			rows.AddRow(fmt.Sprintf("task-%d", page.offset+i))
+		}
+		if page.offset == 0 {
+			mock.ExpectQuery(`SELECT \* FROM "tasks" WHERE status = \$1 .* ORDER BY created_at DESC, id DESC LIMIT \$2$`).
+				WithArgs("pending", 10).WillReturnRows(rows)
+		} else {
+			mock.ExpectQuery(`SELECT \* FROM "tasks" WHERE status = \$1 .* ORDER BY created_at DESC, id DESC LIMIT \$2 OFFSET \$3$`).
+				WithArgs("pending", 10, page.offset).WillReturnRows(rows)
+		}
+		mock.ExpectQuery(`SELECT \* FROM "task_assignees"`).WillReturnRows(sqlmock.NewRows([]string{"task_id"}))
+	}
+	// Past the last page, only the count runs
+	mock.ExpectQuery(count).WithArgs("pending").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(25))
+
+	status := "pending"
+	sort := SortParams{SortBy: "created_at", SortOrder: "desc"}
+	for page, want := range []int{10, 10, 5, 0} {
+		resp, err := s.ListTasksWithFilters(context.Background(), TaskFilter{Status: &status}, PaginationParams{Page: page + 1, PageSize: 10}, sort)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p := resp.Pagination; len(resp.Tasks) != want || p.TotalItems != 25 || p.TotalPages != 3 {
+			t.Fatalf("page %d: %d tasks, pagination %+v, want %d of 25 tasks in 3 pages", page+1, len(resp.Tasks), p, want)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestListArchivedTasksOnlyListsTheArchive(t *testing.T) {
+	s, mock := newTestService(t)
+	mock.ExpectQuery(`^SELECT count\(\*\) FROM "tasks" WHERE status = \$1 AND archived_at IS NOT NULL AND "tasks"."deleted_at" IS NULL$`).
+		WithArgs("completed").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	status := "completed"
+	sort := SortParams{SortBy: "created_at", SortOrder: "desc"}
+	if _, err := s.ListArchivedTasks(context.Background(), TaskFilter{Status: &status}, PaginationParams{Page: 1, PageSize: 10}, sort); err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
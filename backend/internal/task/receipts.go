@@ -0,0 +1,70 @@
+package task
+
+import (
+	"sync"
+	"time"
+)
+
+// recentEventsSize bounds how many published events can still be
+// acknowledged with a receipt
+const recentEventsSize = 4096
+
+// recentEvents maps the IDs of the most recently published events to
+// their mutation time, forgetting the oldest first
+type recentEvents struct {
+	mu    sync.Mutex
+	times map[string]time.Time
+	order []string
+	next  int
+}
+
+func newRecentEvents(size int) *recentEvents {
+	return &recentEvents{
+		times: make(map[string]time.Time, size),
+		order: make([]string, size),
+	}
+}
+
+func (r *recentEvents) add(id string, mutatedAt time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if old := r.order[r.next]; old != "" {
+		delete(r.times, old)
+	}
+	r.order[r.next] = id
+	r.times[id] = mutatedAt
+	r.next = (r.next + 1) % len(r.order)
+}
+
+func (r *recentEvents) mutatedAt(id string) (time.Time, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.times[id]
+	return t, ok
+}
+
+// SetReceiptObserver reports, for every client receipt of a recent event,
+// how long it took from the mutation to the client
+func (s *Service) SetReceiptObserver(observe func(latency time.Duration)) {
+	s.observeReceipt = observe
+}
+
+// RecordReceipt times a client's receipt of an event. The client's
+// received_at is used unless it is before the mutation or in the future,
+// which means its clock is off. Receipts for unknown or forgotten events
+// are ignored.
+func (s *Service) RecordReceipt(receipt ClientReceipt) bool {
+	mutatedAt, ok := s.recent.mutatedAt(receipt.EventID)
+	if !ok {
+		return false
+	}
+
+	receivedAt := time.Now()
+	if receipt.ReceivedAt != nil && !receipt.ReceivedAt.Before(mutatedAt) && receipt.ReceivedAt.Before(receivedAt) {
+		receivedAt = *receipt.ReceivedAt
+	}
+	if s.observeReceipt != nil {
+		s.observeReceipt(receivedAt.Sub(mutatedAt))
+	}
+	return true
+}
@@ -0,0 +1,115 @@
+package task
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestRecentEventsForgetsOldest(t *testing.T) {
+	recent := newRecentEvents(2)
+	now := time.Now()
+	recent.add("a", now)
+	recent.add("b", now)
+	recent.add("c", now)
+
+	if _, ok := recent.mutatedAt("a"); ok {
+		t.Fatal("oldest event still remembered")
+	}
+	for _, id := range []string{"b", "c"} {
+		if _, ok := recent.mutatedAt(id); !ok {
+			t.Fatalf("event %s forgotten", id)
+		}
+	}
+}
+
+func TestRecordReceiptUsesPlausibleClientTime(t *testing.T) {
+	s := NewService(nil, zap.NewNop())
+	defer s.Shutdown(context.Background())
+	var observed []time.Duration
+	s.SetReceiptObserver(func(latency time.Duration) { observed = append(observed, latency) })
+
+	mutatedAt := time.Now().Add(-time.Minute)
+	s.recent.add("event-1", mutatedAt)
+
+	receivedAt := mutatedAt.Add(250 * time.Millisecond)
+	future := time.Now().Add(time.Hour)
+	for _, at := range []*time.Time{&receivedAt, &future, nil} {
+		if !s.RecordReceipt(ClientReceipt{Type: ReceiptMessageType, EventID: "event-1", ReceivedAt: at}) {
+			t.Fatal("receipt for a recent event not recorded")
+		}
+	}
+	if s.RecordReceipt(ClientReceipt{Type: ReceiptMessageType, EventID: "unknown"}) {
+		t.Fatal("receipt for an unknown event recorded")
+	}
+
+	if len(observed) != 3 || observed[0] != 250*time.Millisecond {
+		t.Fatalf("observed = %v, want the client's 250ms first", observed)
+	}
+	for _, latency := range observed[1:] {
+		if latency < time.Minute || latency > time.Minute+time.Second {
+			t.Fatalf("observed = %v, want implausible or missing client times replaced by the server's", observed)
+		}
+	}
+}
+
+func TestWebSocketReceiptIsTimed(t *testing.T) {
+	s := NewService(nil, zap.NewNop())
+	defer s.Shutdown(context.Background())
+	observed := make(chan time.Duration, 1)
+	s.SetReceiptObserver(func(latency time.Duration) { observed <- latency })
+	conn := dialHub(t, s)
+
+	s.publish(NewWebSocketMessage(MessageTypeTaskCreated, "task-1"))
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	var msg WebSocketMessage
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.EventID == "" {
+		t.Fatal("event has no event_id")
+	}
+	if err := conn.WriteJSON(ClientReceipt{Type: ReceiptMessageType, EventID: msg.EventID}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-observed:
+	case <-time.After(time.Second):
+		t.Fatal("receipt was never timed")
+	}
+}
+
+func TestDeliveryProbeTimesEvents(t *testing.T) {
+	s := NewService(nil, zap.NewNop())
+	defer s.Shutdown(context.Background())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	observed := make(chan time.Duration, 1)
+	if err := s.StartDeliveryProbe(ctx, func(latency time.Duration) { observed <- latency }); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for s.ConnectedClients() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("probe never connected")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	msg := NewWebSocketMessage(MessageTypeTaskUpdated, "task-1")
+	msg.Timestamp = time.Now().Add(-time.Second)
+	s.publish(msg)
+
+	select {
+	case latency := <-observed:
+		if latency < time.Second {
+			t.Fatalf("latency = %s, want it timed from the mutation", latency)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("probe never timed the event")
+	}
+}
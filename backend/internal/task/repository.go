@@ -0,0 +1,160 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+	"gorm.io/gorm"
+)
+
+// TaskRepository stores the tasks behind the core task operations:
+// creating, reading, listing, updating and deleting them. Features with
+// tables of their own still use the database directly.
+type TaskRepository interface {
+	// Get returns the task with its assignees, or ErrTaskNotFound
+	Get(ctx context.Context, id string) (*Task, error)
+	// GetByIDs returns the tasks among ids that exist, in no particular
+	// order
+	GetByIDs(ctx context.Context, ids []string) ([]Task, error)
+	// OpenAssignedTo returns up to limit unfinished tasks assigned to
+	// userID, soonest due first
+	OpenAssignedTo(ctx context.Context, userID string, limit int) ([]Task, error)
+	// List returns the page of tasks matching filter, with their
+	// assignees, and how many match in all. The arguments are valid.
+	List(ctx context.Context, filter TaskFilter, pagination PaginationParams, sort SortParams) ([]Task, int64, error)
+	// Save creates or updates the task and, when ids is non-nil, replaces
+	// its assignees with ids, primary first
+	Save(ctx context.Context, task *Task, create bool, primary string, ids []string) error
+	// Delete deletes the task if createdBy created it, or returns
+	// ErrTaskNotFound
+	Delete(ctx context.Context, id string, createdBy string) error
+}
+
+// gormTaskRepository is the TaskRepository of the Postgres database
+type gormTaskRepository struct {
+	db *gorm.DB
+}
+
+func NewTaskRepository(db *gorm.DB) TaskRepository {
+	return &gormTaskRepository{db: db}
+}
+
+func (r *gormTaskRepository) Get(ctx context.Context, id string) (*Task, error) {
+	task := &Task{}
+	if err := r.db.WithContext(ctx).Preload("Assignees").First(task, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		return nil, err
+	}
+	return task, nil
+}
+
+func (r *gormTaskRepository) GetByIDs(ctx context.Context, ids []string) ([]Task, error) {
+	var tasks []Task
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func (r *gormTaskRepository) OpenAssignedTo(ctx context.Context, userID string, limit int) ([]Task, error) {
+	tasks := []Task{}
+	err := whereAssignedToAny(r.db.WithContext(ctx).Model(&Task{}), userID).
+		Where("status <> ?", models.StatusCompleted).
+		Order("due_date ASC, id ASC").
+		Limit(limit).
+		Find(&tasks).Error
+	if err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+func (r *gormTaskRepository) List(ctx context.Context, filter TaskFilter, pagination PaginationParams, sort SortParams) ([]Task, int64, error) {
+	// The count and the page each start a new statement from the filtered
+	// query, so the page's ordering and limits never reach the count
+	query := whereTaskFilter(r.db.WithContext(ctx).Model(&Task{}), filter).Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get total count: %w", err)
+	}
+
+	tasks := []Task{}
+	offset := (pagination.Page - 1) * pagination.PageSize
+	if int64(offset) >= total {
+		return tasks, total, nil
+	}
+
+	// id breaks ties so pages do not overlap
+	order := strings.ToUpper(sort.SortOrder)
+	if err := query.
+		Order(fmt.Sprintf("%s %s, id %s", sortColumns[sort.SortBy], order, order)).
+		Offset(offset).
+		Limit(pagination.PageSize).
+		Preload("Assignees").
+		Find(&tasks).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list tasks: %w", err)
+	}
+	return tasks, total, nil
+}
+
+// whereTaskFilter restricts query to the tasks matching every filter that
+// is set
+func whereTaskFilter(query *gorm.DB, filter TaskFilter) *gorm.DB {
+	if filter.Status != nil {
+		query = query.Where("status = ?", *filter.Status)
+	}
+	if filter.Priority != nil {
+		query = query.Where("priority = ?", *filter.Priority)
+	}
+	if filter.AssignedTo != nil {
+		query = whereAssignedToAny(query, *filter.AssignedTo)
+	}
+	if filter.CreatedBy != nil {
+		query = query.Where("created_by = ?", *filter.CreatedBy)
+	}
+	if filter.DueBefore != nil {
+		query = query.Where("due_date <= ?", *filter.DueBefore)
+	}
+	if filter.DueAfter != nil {
+		query = query.Where("due_date >= ?", *filter.DueAfter)
+	}
+
+	// The literal status matches the predicate of the open due date index
+	if filter.Overdue != nil {
+		if *filter.Overdue {
+			query = query.Where("status <> 'completed' AND due_date < ?", time.Now())
+		} else {
+			query = query.Where("status = 'completed' OR due_date >= ?", time.Now())
+		}
+	}
+	if filter.Archived {
+		query = query.Where("archived_at IS NOT NULL")
+	} else {
+		query = query.Where("archived_at IS NULL")
+	}
+	return query
+}
+
+func (r *gormTaskRepository) Save(ctx context.Context, task *Task, create bool, primary string, ids []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return saveTask(tx, task, create, primary, ids)
+	})
+}
+
+func (r *gormTaskRepository) Delete(ctx context.Context, id string, createdBy string) error {
+	result := r.db.WithContext(ctx).Delete(&Task{}, "id = ? AND created_by = ?", id, createdBy)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTaskNotFound
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const maxSearchResults = 50
+
+// SearchTasks finds the tasks, other than archived ones, whose title,
+// description or attachment text matches every word in query. The
+// 'simple' configuration matches words as typed, so identifiers such as
+// invoice numbers are found and no language's stemming is assumed. The
+// to_tsvector expressions match the GIN indexes created by the database
+// package.
+func (s *Service) SearchTasks(ctx context.Context, query string, limit int) ([]Task, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, ErrEmptySearch
+	}
+	if limit <= 0 || limit > maxSearchResults {
+		limit = maxSearchResults
+	}
+
+	tasks := []Task{}
+	err := s.db.WithContext(ctx).Preload("Assignees").
+		Where(`to_tsvector('simple', title || ' ' || COALESCE(description, '')) @@ plainto_tsquery('simple', @q)
+			OR EXISTS (
+				SELECT 1 FROM task_attachments a
+				WHERE a.task_id = tasks.id AND a.deleted_at IS NULL
+				AND to_tsvector('simple', a.ocr_text) @@ plainto_tsquery('simple', @q)
+			)`, map[string]interface{}{"q": query}).
+		Where("archived_at IS NULL").
+		Order("updated_at desc").
+		Limit(limit).
+		Find(&tasks).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to search tasks: %w", err)
+	}
+	return tasks, nil
+}
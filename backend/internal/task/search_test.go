@@ -0,0 +1,34 @@
+package task
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestSearchTasksMatchesTextAndAttachments(t *testing.T) {
+	s, mock := newTestService(t)
+	mock.ExpectQuery(`to_tsvector\('simple', title .* plainto_tsquery\('simple', \$1\).*task_attachments.*plainto_tsquery\('simple', \$2\).*ORDER BY updated_at desc LIMIT \$3`).
+		WithArgs("invoice 4521", "invoice 4521", maxSearchResults).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow("task-1", "Billing dispute"))
+	mock.ExpectQuery(`SELECT \* FROM "task_assignees"`).WillReturnRows(sqlmock.NewRows([]string{"task_id"}))
+
+	tasks, err := s.SearchTasks(context.Background(), "  invoice 4521 ", 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 1 || tasks[0].ID != "task-1" {
+		t.Fatalf("tasks = %+v, want task-1", tasks)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSearchTasksRequiresQuery(t *testing.T) {
+	s, _ := newTestService(t)
+	if _, err := s.SearchTasks(context.Background(), " ", 10); err != ErrEmptySearch {
+		t.Fatalf("err = %v, want ErrEmptySearch", err)
+	}
+}
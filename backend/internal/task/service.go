@@ -1,18 +1,23 @@
 package task
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
+	"github.com/iSparshP/real-time-task-management-system/internal/chaos"
 	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"github.com/iSparshP/real-time-task-management-system/internal/database"
 	"github.com/iSparshP/real-time-task-management-system/internal/models"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 const (
@@ -29,99 +34,526 @@ const (
 
 type Service struct {
 	db         *gorm.DB
-	clients    map[*websocket.Conn]*sync.Mutex // Change to mutex per client
-	broadcast  chan WebSocketMessage           // Change to typed channel
+	tasks      TaskRepository
+	clients    map[*websocket.Conn]*wsClient
+	broadcast  chan WebSocketMessage // Change to typed channel
 	clientsMux sync.RWMutex
 	logger     *zap.Logger
+	faults     *chaos.Injector
+	translator Translator
+	typeahead  TypeaheadCache
+	counters   CountersCache
+
+	// embedder embeds task text for similar task searches; a created task
+	// at least duplicateThreshold similar to an open one is flagged
+	embedder           Embedder
+	duplicateThreshold float64
+
+	// escalator is told about tasks whose hard deadline passed
+	escalator DeadlineEscalator
+	// priorityEscalator is told about tasks whose priority was escalated
+	priorityEscalator PriorityEscalator
+
+	// broadcastMux guards closing so no publish races the channel close
+	broadcastMux  sync.RWMutex
+	closing       bool
+	broadcastDone chan struct{}
+	writers       sync.WaitGroup
+
+	// Load signal for autoscaling: messages queued for clients but not
+	// yet written
+	pendingWrites atomic.Int64
+	// observeBroadcastDrop is told each time a full broadcast queue drops
+	// its oldest message
+	observeBroadcastDrop func()
+
+	// Each client queues up to clientBuffer messages for its writer;
+	// slowClients decides what happens to a client whose queue is full,
+	// and observeSlowClient is told each time it applies
+	clientBuffer      int
+	slowClients       SlowClientPolicy
+	observeSlowClient func(policy SlowClientPolicy)
+	// draining is closed once the broadcast loop has stopped, so writers
+	// write what they have queued and exit
+	draining chan struct{}
+
+	// observeDelivery receives the time from mutation to frame written
+	observeDelivery func(latency time.Duration)
+
+	// recent remembers when recent events were published so client
+	// receipts can be timed; observeReceipt receives those times
+	recent         *recentEvents
+	observeReceipt func(latency time.Duration)
+
+	// reconcile wakes the subscription reconciler after membership changes
+	reconcile chan struct{}
+
+	// Edit locks last lockTTL; lockMode decides whether updates of a task
+	// locked by someone else are warned or rejected
+	lockTTL  time.Duration
+	lockMode EditLockMode
+
+	// minProtocol is the oldest WebSocket protocol version still served
+	minProtocol int
+
+	// pageSize is the page size of task lists that ask for none
+	pageSize atomic.Int64
 }
 
 func NewService(db *gorm.DB, logger *zap.Logger) *Service {
+	return NewServiceWithRepository(db, NewTaskRepository(db), logger)
+}
+
+// NewServiceWithRepository is NewService with the core task operations
+// going to tasks instead of db
+func NewServiceWithRepository(db *gorm.DB, tasks TaskRepository, logger *zap.Logger) *Service {
 	s := &Service{
 		db:        db,
-		clients:   make(map[*websocket.Conn]*sync.Mutex),
-		broadcast: make(chan WebSocketMessage),
+		tasks:     tasks,
+		clients:   make(map[*websocket.Conn]*wsClient),
+		broadcast: make(chan WebSocketMessage, broadcastBuffer()),
 		logger:    logger,
-	}
+
+		broadcastDone: make(chan struct{}),
+		clientBuffer:  defaultClientBuffer,
+		slowClients:   SlowClientDisconnect,
+		draining:      make(chan struct{}),
+		recent:        newRecentEvents(recentEventsSize),
+		reconcile:     make(chan struct{}, 1),
+		minProtocol:   LegacyProtocolVersion,
+		lockTTL:       defaultEditLockTTL,
+		lockMode:      EditLockWarn,
+	}
+	s.pageSize.Store(int64(common.AppConfig.TaskPageSize))
 	go s.handleBroadcast()
 	return s
 }
 
+// SetDefaultPageSize changes the page size of task lists that ask for none
+func (s *Service) SetDefaultPageSize(size int) {
+	s.pageSize.Store(int64(size))
+}
+
+// SetFaultInjector enables fault injection for WebSocket delivery
+func (s *Service) SetFaultInjector(faults *chaos.Injector) {
+	s.faults = faults
+}
+
 func (s *Service) handleBroadcast() {
+	defer close(s.broadcastDone)
+	defer close(s.draining)
+
 	for msg := range s.broadcast {
+		var slow []*websocket.Conn
 		s.clientsMux.RLock()
-		for client, mutex := range s.clients {
-			go func(c *websocket.Conn, m *sync.Mutex) {
-				m.Lock()
-				defer m.Unlock()
-				if err := c.WriteJSON(msg); err != nil {
-					s.logger.Error("Failed to send message", zap.Error(err))
-					s.UnregisterClient(c)
-				}
-			}(client, mutex)
+		for conn, client := range s.clients {
+			if msg.recipients != nil && !msg.recipients[client.userID] &&
+				(msg.project == "" || !client.projects[msg.project]) {
+				continue
+			}
+			if client.disabled[eventCategories[msg.Type]] || (msg.presence && !client.presence) {
+				continue
+			}
+			if s.faults.ShouldDropFrame() {
+				continue
+			}
+			if !s.enqueue(client, msg.forSchema(client.schema)) {
+				slow = append(slow, conn)
+			}
 		}
 		s.clientsMux.RUnlock()
+
+		for _, conn := range slow {
+			s.disconnectSlowClient(conn)
+		}
+	}
+}
+
+// SetDeliveryObserver reports, for every frame written to a client, how
+// long it took from the mutation that caused it
+func (s *Service) SetDeliveryObserver(observe func(latency time.Duration)) {
+	s.observeDelivery = observe
+}
+
+// defaultBroadcastBuffer is how many messages wait for the broadcast loop
+// when WS_BROADCAST_BUFFER is not set
+const defaultBroadcastBuffer = 1024
+
+func broadcastBuffer() int {
+	if size := common.AppConfig.WSBroadcastBuffer; size > 0 {
+		return size
+	}
+	return defaultBroadcastBuffer
+}
+
+// SetBroadcastDropObserver is told each time the broadcast queue is full
+// and drops its oldest message
+func (s *Service) SetBroadcastDropObserver(observe func()) {
+	s.observeBroadcastDrop = observe
+}
+
+// publish queues a message for all connected clients. It never blocks the
+// caller, which has usually just committed the change: when the queue is
+// full, the oldest queued message is dropped to make room. Messages
+// published after Shutdown has started are dropped.
+func (s *Service) publish(msg WebSocketMessage) {
+	if msg.Timestamp.IsZero() {
+		msg.Timestamp = time.Now()
+	}
+	if msg.EventID == "" {
+		msg.EventID = uuid.New().String()
+	}
+	if msg.SchemaVersion == 0 {
+		msg.SchemaVersion = LatestSchemaVersion
+	}
+	s.recent.add(msg.EventID, msg.Timestamp)
+	s.broadcastMux.RLock()
+	defer s.broadcastMux.RUnlock()
+	if s.closing {
+		return
+	}
+	for {
+		select {
+		case s.broadcast <- msg:
+			return
+		default:
+		}
+		// The broadcast loop may take the oldest first, leaving room
+		// either way
+		select {
+		case <-s.broadcast:
+			if s.observeBroadcastDrop != nil {
+				s.observeBroadcastDrop()
+			}
+		default:
+		}
 	}
 }
 
-func (s *Service) RegisterClient(conn *websocket.Conn) {
+// ConnectedClients is the number of open WebSocket connections
+func (s *Service) ConnectedClients() int {
+	s.clientsMux.RLock()
+	defer s.clientsMux.RUnlock()
+	return len(s.clients)
+}
+
+// BroadcastBacklog is the number of messages waiting to be broadcast
+func (s *Service) BroadcastBacklog() int64 {
+	return int64(len(s.broadcast))
+}
+
+// maxHealthyBacklog is how many task events may wait to be broadcast
+// before real-time updates count as falling behind
+const maxHealthyBacklog = 100
+
+// CheckBroadcast fails while task events wait to be broadcast faster than
+// they go out, or once the hub has shut down
+func (s *Service) CheckBroadcast(ctx context.Context) error {
+	s.broadcastMux.RLock()
+	closing := s.closing
+	s.broadcastMux.RUnlock()
+	if closing {
+		return errors.New("broadcast hub is shut down")
+	}
+	if backlog := s.BroadcastBacklog(); backlog > maxHealthyBacklog {
+		return fmt.Errorf("%d task events waiting to be broadcast", backlog)
+	}
+	return nil
+}
+
+// PendingWrites is the number of frames queued for clients but not yet
+// written
+func (s *Service) PendingWrites() int64 {
+	return s.pendingWrites.Load()
+}
+
+const (
+	// clientReadTimeout closes connections that send nothing, not even a
+	// keep-alive, for this long
+	clientReadTimeout = 60 * time.Second
+	// staleClientAge is when SweepClients gives up on a connection
+	staleClientAge = 2 * clientReadTimeout
+)
+
+// wsClient is a connected WebSocket client. mu serializes writes to the
+// connection; userID, empty for internal clients, selects the messages
+// addressed to particular users. projects, guarded by clientsMux, are the
+// projects it subscribed to, and disabled the event categories it turned
+// off.
+type wsClient struct {
+	mu       sync.Mutex
+	userID   string
+	projects map[string]bool
+	disabled map[string]bool
+	protocol *protocol
+	// schema is the event schema version the client was built against
+	schema int
+	// lastSeen is when a frame was last read from the client, in Unix
+	// nanoseconds
+	lastSeen atomic.Int64
+
+	// send queues broadcast messages for the client's writer. It is never
+	// closed; done is closed once the client leaves the broadcast set.
+	send chan WebSocketMessage
+	done chan struct{}
+
+	// viewing is the task the client has open, guarded by clientsMux;
+	// editing is set while the user edits it. presence is set once the
+	// client follows who comes online.
+	viewing  string
+	editing  bool
+	presence bool
+}
+
+// RegisterClient adds conn, opened by userID with a negotiated protocol
+// and event schema version, to the broadcast set, or closes it straight away once Shutdown
+// has started. closing stays read-locked until conn is in the set so
+// Shutdown cannot miss it. Other clients are told when the user comes
+// online.
+func (s *Service) RegisterClient(conn *websocket.Conn, userID string, version int, schema int) {
+	s.broadcastMux.RLock()
+	if s.closing {
+		s.broadcastMux.RUnlock()
+		closeClient(conn, &sync.Mutex{})
+		return
+	}
+
+	client := &wsClient{
+		userID:   userID,
+		protocol: protocols[version],
+		schema:   schema,
+		send:     make(chan WebSocketMessage, s.clientBuffer),
+		done:     make(chan struct{}),
+	}
+	client.lastSeen.Store(time.Now().UnixNano())
 	s.clientsMux.Lock()
-	s.clients[conn] = &sync.Mutex{}
+	joined := s.addClient(conn, client)
 	s.clientsMux.Unlock()
+
+	s.writers.Add(1)
+	go s.writeLoop(conn, client)
+	// publish read-locks closing too, which must not be nested
+	s.broadcastMux.RUnlock()
+	for _, msg := range joined {
+		s.publish(msg)
+	}
 }
 
+// UnregisterClient removes conn from the broadcast set and stops its
+// writer. It is safe to call more than once.
 func (s *Service) UnregisterClient(conn *websocket.Conn) {
 	s.clientsMux.Lock()
-	delete(s.clients, conn)
+	client, ok := s.clients[conn]
+	var departed []WebSocketMessage
+	if ok {
+		departed = s.removeClient(conn, client)
+	}
 	s.clientsMux.Unlock()
+	if !ok {
+		return
+	}
+	close(client.done)
+	for _, msg := range departed {
+		s.publish(msg)
+	}
 }
 
-func (s *Service) CreateTask(req CreateTaskRequest, userID string) (*TaskResponse, error) {
+// touch records that a frame was read from conn
+func (s *Service) touch(conn *websocket.Conn) {
+	s.clientsMux.RLock()
+	client, ok := s.clients[conn]
+	s.clientsMux.RUnlock()
+	if ok {
+		client.lastSeen.Store(time.Now().UnixNano())
+	}
+}
+
+// SweepClients closes and drops the connections nothing was read from for
+// staleClientAge, along with their subscriptions. Their read deadline
+// should have closed them already, so these are connections whose reader
+// never unregistered them. It returns how many were dropped.
+func (s *Service) SweepClients(context.Context) (int, error) {
+	cutoff := time.Now().Add(-staleClientAge).UnixNano()
+	stale := make(map[*websocket.Conn]*wsClient)
+	var departed []WebSocketMessage
+	s.clientsMux.Lock()
+	for conn, client := range s.clients {
+		if client.lastSeen.Load() < cutoff {
+			stale[conn] = client
+			departed = append(departed, s.removeClient(conn, client)...)
+		}
+	}
+	s.clientsMux.Unlock()
+	for _, msg := range departed {
+		s.publish(msg)
+	}
+
+	for conn, client := range stale {
+		close(client.done)
+		client.mu.Lock()
+		conn.Close()
+		client.mu.Unlock()
+	}
+	return len(stale), nil
+}
+
+// Shutdown stops accepting broadcasts, delivers any queued messages, then
+// sends a close frame to every connected client and closes its connection.
+// It returns ctx.Err() if the drain does not finish in time; remaining
+// connections are still closed.
+func (s *Service) Shutdown(ctx context.Context) error {
+	s.broadcastMux.Lock()
+	if !s.closing {
+		s.closing = true
+		close(s.broadcast)
+	}
+	s.broadcastMux.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		<-s.broadcastDone
+		s.writers.Wait()
+		close(drained)
+	}()
+
+	var err error
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	s.clientsMux.Lock()
+	clients := s.clients
+	s.clients = make(map[*websocket.Conn]*wsClient)
+	s.clientsMux.Unlock()
+
+	for conn, client := range clients {
+		close(client.done)
+		closeClient(conn, &client.mu)
+	}
+	s.logger.Info("WebSocket hub stopped", zap.Int("clients_closed", len(clients)))
+
+	return err
+}
+
+func closeClient(conn *websocket.Conn, mutex *sync.Mutex) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	msg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+	_ = conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+	conn.Close()
+}
+
+func (s *Service) CreateTask(ctx context.Context, req CreateTaskRequest, userID string) (*TaskResponse, error) {
 	task := &Task{
 		ID:          uuid.New().String(),
 		Title:       req.Title,
 		Description: req.Description,
 		Status:      models.StatusPending,
 		Priority:    models.TaskPriority(req.Priority),
-		AssignedTo:  req.AssignedTo,
 		CreatedBy:   userID,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 		DueDate:     req.DueDate,
+
+		Project:         req.Project,
+		EstimatedEffort: req.EstimatedEffort,
+		DeadlineType:    models.DeadlineType(req.DeadlineType),
 	}
 
-	if err := s.validateTask(task); err != nil {
+	primary, assignees := resolveAssignees(req.AssignedTo, req.AssigneeIDs)
+	task.AssignedTo = primary
+
+	checklist, err := newChecklist(task.ID, req.Checklist)
+	if err != nil {
+		return nil, err
+	}
+	task.Checklist = checklist
+
+	if err := s.validateTask(ctx, task); err != nil {
+		return nil, err
+	}
+	schema, err := s.validateProjectFields(ctx, task, allFields)
+	if err != nil {
+		return nil, err
+	}
+	if task.DeadlineType == "" {
+		task.DeadlineType = models.DeadlineSoft
+	}
+	if schema != nil {
+		task.Checklist = withDefinitionOfDone(task.ID, task.Checklist, schema.DefinitionOfDone)
+		if req.DeadlineType == "" && schema.DeadlineType != "" {
+			task.DeadlineType = schema.DeadlineType
+		}
+	}
+	if err := s.validateAssignees(ctx, assignees); err != nil {
 		return nil, err
 	}
 
-	if err := s.db.Create(task).Error; err != nil {
+	if err := s.tasks.Save(ctx, task, true, primary, assignees); err != nil {
 		return nil, fmt.Errorf("failed to create task: %w", err)
 	}
 
-	s.broadcast <- WebSocketMessage{
-		Type:    MessageTypeTaskCreated,
-		Payload: *task,
+	s.publish(TaskCreatedEvent(*task))
+	s.forgetCounters(ctx, []Task{*task})
+	resp := &TaskResponse{Task: *task, ChecklistItems: int64(len(task.Checklist))}
+	if s.embedder != nil {
+		resp.PossibleDuplicates = s.findDuplicates(ctx, *task, userID)
+		if n := len(resp.PossibleDuplicates); n > 0 {
+			resp.Warning = fmt.Sprintf("%d open task(s) look like duplicates of this one", n)
+		}
 	}
-	return &TaskResponse{Task: *task}, nil
+	return resp, nil
 }
 
 func (s *Service) canModifyTask(userID string, task *Task) bool {
-	return task.CreatedBy == userID || task.AssignedTo == userID
+	return task.CreatedBy == userID || isAssignee(task, userID)
 }
 
-func (s *Service) UpdateTask(taskID string, req UpdateTaskRequest, userID string) (*TaskResponse, error) {
-	var task Task
-	if err := s.db.First(&task, "id = ?", taskID).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrTaskNotFound
-		}
+func (s *Service) UpdateTask(ctx context.Context, taskID string, req UpdateTaskRequest, userID string) (*TaskResponse, error) {
+	task, err := s.tasks.Get(ctx, taskID)
+	if err != nil {
 		return nil, err
 	}
 
-	if !s.canModifyTask(userID, &task) {
+	if !s.canModifyTask(userID, task) {
 		return nil, ErrUnauthorized
 	}
+	lockWarning, err := s.checkEditLock(ctx, task.ID, req, userID)
+	if err != nil {
+		return nil, err
+	}
+	previous := assigneeIDs(task)
 
-	// Apply updates
+	primary, assignees, err := s.applyTaskUpdate(ctx, s.db, task, req, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.tasks.Save(ctx, task, false, primary, assignees); err != nil {
+		return nil, fmt.Errorf("failed to update task: %w", err)
+	}
+
+	s.publishTaskUpdate(ctx, *task, req, previous)
+	resp := s.taskResponse(ctx, *task)
+	if lockWarning != "" && resp.Warning != "" {
+		resp.Warning += "; " + lockWarning
+	} else if lockWarning != "" {
+		resp.Warning = lockWarning
+	}
+	return resp, nil
+}
+
+// applyTaskUpdate applies the fields set in req to task for userID and
+// validates the result. A task being completed must meet its project's
+// definition of done, checked against its checklist as db sees it. The
+// assignees to save are returned; assignees is nil if they do not change.
+func (s *Service) applyTaskUpdate(ctx context.Context, db *gorm.DB, task *Task, req UpdateTaskRequest, userID string) (string, []string, error) {
+	if task.HardDeadlinePassed(time.Now()) && task.CreatedBy != userID && changesDeadlineOrAssignees(task, req) {
+		return "", nil, ErrHardDeadlinePassed
+	}
+	completing := req.Status != nil && *req.Status == string(models.StatusCompleted) && task.Status != models.StatusCompleted
 	if req.Title != nil {
 		task.Title = *req.Title
 	}
@@ -130,125 +562,145 @@ func (s *Service) UpdateTask(taskID string, req UpdateTaskRequest, userID string
 	}
 	if req.Status != nil {
 		task.Status = models.TaskStatus(*req.Status)
+		setCompletedAt(task)
 	}
 	if req.Priority != nil {
 		task.Priority = models.TaskPriority(*req.Priority)
 	}
-	if req.AssignedTo != nil {
-		task.AssignedTo = *req.AssignedTo
+	var primary string
+	var assignees []string
+	if req.AssignedTo != nil || req.AssigneeIDs != nil {
+		primary = task.AssignedTo
+		if req.AssignedTo != nil {
+			primary = *req.AssignedTo
+		}
+		ids := assigneeIDs(task)
+		if req.AssigneeIDs != nil {
+			ids = *req.AssigneeIDs
+			// Keep the current primary only if it is still assigned
+			if req.AssignedTo == nil && !containsString(ids, primary) {
+				primary = ""
+			}
+		}
+		primary, assignees = resolveAssignees(primary, ids)
+		if err := s.validateAssignees(ctx, assignees); err != nil {
+			return "", nil, err
+		}
+		task.AssignedTo = primary
 	}
 	if req.DueDate != nil {
 		task.DueDate = *req.DueDate
 	}
+	if req.Project != nil {
+		task.Project = *req.Project
+	}
+	if req.EstimatedEffort != nil {
+		task.EstimatedEffort = *req.EstimatedEffort
+	}
+	if req.DeadlineType != nil {
+		task.DeadlineType = models.DeadlineType(*req.DeadlineType)
+	}
 	task.UpdatedAt = time.Now()
 
 	// Validate updated task
-	if err := s.validateTask(&task); err != nil {
-		return nil, err
+	if err := s.validateTask(ctx, task); err != nil {
+		return "", nil, err
 	}
-
-	if err := s.db.Save(&task).Error; err != nil {
-		return nil, fmt.Errorf("failed to update task: %w", err)
+	schema, err := s.validateProjectFields(ctx, task, func(field string) bool {
+		switch {
+		case req.Project != nil:
+			return true
+		case field == "description":
+			return req.Description != nil
+		case field == "due_date":
+			return req.DueDate != nil
+		case field == "estimated_effort":
+			return req.EstimatedEffort != nil
+		}
+		return false
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	if completing && schema != nil {
+		if err := checkDefinitionOfDone(ctx, db, task.ID, schema.DefinitionOfDone); err != nil {
+			return "", nil, err
+		}
 	}
+	return primary, assignees, nil
+}
 
-	s.broadcast <- WebSocketMessage{
-		Type:    MessageTypeTaskUpdated,
-		Payload: task,
+// publishTaskUpdate tells clients and followers about an updated task,
+// and has it embedded again if its text changed. previous are the
+// assignees before the update.
+func (s *Service) publishTaskUpdate(ctx context.Context, task Task, req UpdateTaskRequest, previous []string) {
+	if req.Title != nil || req.Description != nil {
+		s.refreshEmbedding(ctx, task)
+	}
+	s.publish(TaskUpdatedEvent(task))
+	s.forgetCounters(ctx, []Task{task}, previous...)
+	s.notifyFollowers(ctx, MessageTypeTaskUpdated, task)
+	if req.Project != nil || req.AssignedTo != nil || req.AssigneeIDs != nil {
+		s.membershipChanged()
 	}
-	return &TaskResponse{Task: task}, nil
 }
 
-func (s *Service) GetTask(taskID string) (*TaskResponse, error) {
-	task := &Task{}
-	if err := s.db.First(task, "id = ?", taskID).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrTaskNotFound
-		}
+// GetTask returns the task if userID may see it, see canViewTask. It may
+// read from a replica, lagging a write made just before.
+func (s *Service) GetTask(ctx context.Context, taskID string, userID string) (*TaskResponse, error) {
+	ctx = database.ReadFromReplica(ctx)
+	task, err := s.findVisibleTask(ctx, taskID, userID)
+	if err != nil {
 		return nil, err
 	}
-	return &TaskResponse{Task: *task}, nil
+	return s.taskResponse(ctx, *task), nil
 }
 
-func (s *Service) ListTasks(status string, assignedTo string, page int) (*TaskListResponse, error) {
-	var tasks []Task
-	query := s.db
-
-	if status != "" {
-		if !isValidStatus(models.TaskStatus(status)) {
-			return nil, ErrInvalidStatus
-		}
-		query = query.Where("status = ?", status)
-	}
-
-	if assignedTo != "" {
-		query = query.Where("assigned_to = ?", assignedTo)
-	}
-
-	offset := (page - 1) * common.AppConfig.TaskPageSize
-	query = query.Offset(offset).Limit(common.AppConfig.TaskPageSize)
+// GetTasksByIDs returns the tasks among ids that exist, in no particular
+// order
+func (s *Service) GetTasksByIDs(ctx context.Context, ids []string) ([]Task, error) {
+	return s.tasks.GetByIDs(ctx, ids)
+}
 
-	if err := query.Order("created_at desc").Find(&tasks).Error; err != nil {
+// OpenTasksAssignedTo returns up to limit unfinished tasks assigned to
+// userID, soonest due first
+func (s *Service) OpenTasksAssignedTo(ctx context.Context, userID string, limit int) ([]Task, error) {
+	tasks, err := s.tasks.OpenAssignedTo(ctx, userID, limit)
+	if err != nil {
 		return nil, fmt.Errorf("failed to list tasks: %w", err)
 	}
-
-	return &TaskListResponse{Tasks: tasks}, nil
+	return tasks, nil
 }
 
-func (s *Service) ListTasksWithFilters(filter TaskFilter, pagination PaginationParams, sort SortParams) (*TaskListResponse, error) {
-	var tasks []Task
-	query := s.db.Model(&Task{})
-
-	// Apply filters
-	if filter.Status != nil {
-		if !isValidStatus(TaskStatus(*filter.Status)) {
-			return nil, ErrInvalidStatus
-		}
-		query = query.Where("status = ?", *filter.Status)
+// ListTasksWithFilters returns one page of tasks matching every filter
+// that is set, read from a replica when there is one
+func (s *Service) ListTasksWithFilters(ctx context.Context, filter TaskFilter, pagination PaginationParams, sort SortParams) (*TaskListResponse, error) {
+	ctx = database.ReadFromReplica(ctx)
+	if pagination.Page < 1 {
+		return nil, ErrInvalidPage
 	}
-
-	if filter.Priority != nil {
-		if !isValidPriority(TaskPriority(*filter.Priority)) {
-			return nil, ErrInvalidPriority
-		}
-		query = query.Where("priority = ?", *filter.Priority)
+	if pagination.PageSize == 0 {
+		pagination.PageSize = int(s.pageSize.Load())
 	}
-
-	if filter.AssignedTo != nil {
-		query = query.Where("assigned_to = ?", *filter.AssignedTo)
+	if pagination.PageSize < 1 || pagination.PageSize > maxPageSize {
+		return nil, ErrInvalidPageSize
 	}
-
-	if filter.CreatedBy != nil {
-		query = query.Where("created_by = ?", *filter.CreatedBy)
+	if _, ok := sortColumns[sort.SortBy]; !ok {
+		return nil, ErrInvalidSortField
 	}
-
-	if filter.DueBefore != nil {
-		query = query.Where("due_date <= ?", *filter.DueBefore)
+	if sort.SortOrder != "asc" && sort.SortOrder != "desc" {
+		return nil, ErrInvalidSortOrder
 	}
-
-	if filter.DueAfter != nil {
-		query = query.Where("due_date >= ?", *filter.DueAfter)
+	if filter.Status != nil && !isValidStatus(TaskStatus(*filter.Status)) {
+		return nil, ErrInvalidStatus
 	}
-
-	// Apply sorting
-	sortOrder := "DESC"
-	if sort.SortOrder == "asc" {
-		sortOrder = "ASC"
+	if filter.Priority != nil && !isValidPriority(TaskPriority(*filter.Priority)) {
+		return nil, ErrInvalidPriority
 	}
-	query = query.Order(fmt.Sprintf("%s %s", sort.SortBy, sortOrder))
-
-	// Apply pagination
-	offset := (pagination.Page - 1) * pagination.PageSize
-	query = query.Offset(offset).Limit(pagination.PageSize)
 
-	// Execute query
-	if err := query.Find(&tasks).Error; err != nil {
-		return nil, fmt.Errorf("failed to list tasks: %w", err)
-	}
-
-	// Get total count for pagination
-	var total int64
-	if err := query.Count(&total).Error; err != nil {
-		return nil, fmt.Errorf("failed to get total count: %w", err)
+	tasks, total, err := s.tasks.List(ctx, filter, pagination, sort)
+	if err != nil {
+		return nil, err
 	}
 
 	return &TaskListResponse{
@@ -267,50 +719,114 @@ func (s *Service) ListTasksWithFilters(filter TaskFilter, pagination PaginationP
 	}, nil
 }
 
-func (s *Service) DeleteTask(taskID string) error {
-	result := s.db.Delete(&Task{}, "id = ?", taskID)
-	if result.Error != nil {
-		return fmt.Errorf("failed to delete task: %w", result.Error)
+// DeleteTask deletes the task if userID created it. Assignees can change
+// a task but not remove it.
+func (s *Service) DeleteTask(ctx context.Context, taskID string, userID string) error {
+	task, err := s.findTask(ctx, taskID)
+	if err != nil {
+		return err
 	}
-	if result.RowsAffected == 0 {
-		return ErrTaskNotFound
+	if task.CreatedBy != userID {
+		return ErrUnauthorized
 	}
 
-	s.broadcast <- WebSocketMessage{
-		Type: MessageTypeTaskDeleted,
-		Payload: Task{
-			ID:     taskID,
-			Status: "deleted",
-		},
+	if err := s.tasks.Delete(ctx, taskID, userID); err != nil {
+		if errors.Is(err, ErrTaskNotFound) {
+			return err
+		}
+		return fmt.Errorf("failed to delete task: %w", err)
 	}
+
+	s.publishTaskDeleted(ctx, *task)
 	return nil
 }
 
-func (s *Service) AssignTask(taskID string, assignedTo string) (*TaskResponse, error) {
+// publishTaskDeleted tells clients and followers about a deleted task
+func (s *Service) publishTaskDeleted(ctx context.Context, task Task) {
+	s.publish(TaskDeletedEvent(task))
+	s.forgetCounters(ctx, []Task{task})
+	// Subscribers of the project are told too
+	s.notifyFollowers(ctx, MessageTypeTaskDeleted, Task{ID: task.ID, Project: task.Project, Status: "deleted"})
+	s.membershipChanged()
+}
+
+// AssignTask replaces the task's assignees. If no primary is given, the
+// first listed assignee becomes primary.
+// AssignTask replaces the task's assignees. A change of its primary
+// assignee is recorded in the assignment history as made by userID.
+func (s *Service) AssignTask(ctx context.Context, taskID string, req AssignTaskRequest, userID string) (*TaskResponse, error) {
 	task := &Task{}
-	if err := s.db.First(task, "id = ?", taskID).Error; err != nil {
+	if err := s.db.WithContext(ctx).Preload("Assignees").First(task, "id = ?", taskID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrTaskNotFound
 		}
 		return nil, err
 	}
+	previous := task.AssignedTo
+	previousAssignees := assigneeIDs(task)
+	if task.HardDeadlinePassed(time.Now()) && task.CreatedBy != userID {
+		return nil, ErrHardDeadlinePassed
+	}
 
-	task.AssignedTo = assignedTo
+	primary, assignees := resolveAssignees(req.AssignedTo, req.AssigneeIDs)
+	if err := s.validateAssignees(ctx, assignees); err != nil {
+		return nil, err
+	}
+	task.AssignedTo = primary
 	task.UpdatedAt = time.Now()
 
-	if err := s.validateTask(task); err != nil {
+	if err := s.validateTask(ctx, task); err != nil {
 		return nil, err
 	}
 
-	if err := s.db.Save(task).Error; err != nil {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(task).Error; err != nil {
+			return err
+		}
+		if err := replaceAssignees(tx, task, primary, assignees); err != nil {
+			return err
+		}
+		if primary == previous {
+			return nil
+		}
+		return recordAssignment(tx, AssignmentEvent{
+			TaskID:     taskID,
+			Event:      models.AssignmentAssigned,
+			ActorID:    optionalID(userID),
+			FromUserID: optionalID(previous),
+			ToUserID:   optionalID(primary),
+		})
+	})
+	if err != nil {
 		return nil, fmt.Errorf("failed to assign task: %w", err)
 	}
 
-	s.broadcast <- WebSocketMessage{
-		Type:    MessageTypeTaskUpdated,
-		Payload: *task,
+	s.publishAssignment(ctx, *task, previousAssignees...)
+	return s.taskResponse(ctx, *task), nil
+}
+
+// setCompletedAt stamps the completion time when a task moves to completed
+// and clears it when the task is reopened, which also restores it from the
+// archive
+func setCompletedAt(task *Task) {
+	if task.Status == models.StatusCompleted {
+		if task.CompletedAt == nil {
+			now := time.Now()
+			task.CompletedAt = &now
+		}
+		return
+	}
+	task.CompletedAt = nil
+	task.ArchivedAt = nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
 	}
-	return &TaskResponse{Task: *task}, nil
+	return false
 }
 
 func isValidStatus(status models.TaskStatus) bool {
@@ -327,6 +843,10 @@ func isValidStatus(status models.TaskStatus) bool {
 	return false
 }
 
+func isValidDeadlineType(deadlineType models.DeadlineType) bool {
+	return deadlineType == models.DeadlineSoft || deadlineType == models.DeadlineHard
+}
+
 func isValidPriority(priority models.TaskPriority) bool {
 	validPriorities := []models.TaskPriority{
 		models.PriorityLow,
@@ -361,7 +881,7 @@ func (s *Service) validateTaskCreate(task *Task) error {
 	return nil
 }
 
-func (s *Service) validateTask(task *Task) error {
+func (s *Service) validateTask(ctx context.Context, task *Task) error {
 	// Title validation
 	if task.Title == "" {
 		return fmt.Errorf("title is required")
@@ -389,6 +909,15 @@ func (s *Service) validateTask(task *Task) error {
 		return ErrInvalidPriority
 	}
 
+	if task.DeadlineType != "" && !isValidDeadlineType(task.DeadlineType) {
+		return ErrInvalidDeadlineType
+	}
+
+	// Effort validation
+	if task.EstimatedEffort < 0 {
+		return ErrInvalidEffort
+	}
+
 	// Due date validation
 	if !task.DueDate.IsZero() && task.DueDate.Before(time.Now()) {
 		return ErrInvalidDueDate
@@ -397,7 +926,7 @@ func (s *Service) validateTask(task *Task) error {
 	// AssignedTo validation
 	if task.AssignedTo != "" {
 		var user models.User
-		if err := s.db.First(&user, "id = ?", task.AssignedTo).Error; err != nil {
+		if err := s.db.WithContext(ctx).First(&user, "id = ?", task.AssignedTo).Error; err != nil {
 			return ErrInvalidAssignment
 		}
 	}
@@ -0,0 +1,77 @@
+package task
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB returns a GORM handle backed by sqlmock using the postgres
+// dialect, with the same error translation as production
+func newTestDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger:         logger.Discard,
+		TranslateError: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, mock
+}
+
+func newTestService(t *testing.T) (*Service, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock := newTestDB(t)
+	s := NewService(db, zap.NewNop())
+	t.Cleanup(func() { s.Shutdown(context.Background()) })
+	return s, mock
+}
+
+// fakeTasks is a TaskRepository listing a fixed number of matching tasks
+type fakeTasks struct {
+	TaskRepository
+	matching int64
+	listed   []PaginationParams
+}
+
+func (f *fakeTasks) List(_ context.Context, _ TaskFilter, pagination PaginationParams, _ SortParams) ([]Task, int64, error) {
+	f.listed = append(f.listed, pagination)
+	return []Task{}, f.matching, nil
+}
+
+func TestListTasksWithRepository(t *testing.T) {
+	tasks := &fakeTasks{matching: 45}
+	s := NewServiceWithRepository(nil, tasks, zap.NewNop())
+	t.Cleanup(func() { s.Shutdown(context.Background()) })
+	s.SetDefaultPageSize(20)
+	sort := SortParams{SortBy: "created_at", SortOrder: "desc"}
+
+	resp, err := s.ListTasksWithFilters(context.Background(), TaskFilter{}, PaginationParams{Page: 3}, sort)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := resp.Pagination; p.PageSize != 20 || p.TotalItems != 45 || p.TotalPages != 3 {
+		t.Fatalf("pagination = %+v, want 3 pages of 20 for 45 tasks", p)
+	}
+
+	// Invalid arguments never reach the repository
+	invalid := "archived"
+	if _, err := s.ListTasksWithFilters(context.Background(), TaskFilter{Status: &invalid}, PaginationParams{Page: 1}, sort); err != ErrInvalidStatus {
+		t.Fatalf("err = %v, want ErrInvalidStatus", err)
+	}
+	if len(tasks.listed) != 1 || tasks.listed[0] != (PaginationParams{Page: 3, PageSize: 20}) {
+		t.Fatalf("listed = %+v, want only page 3 of 20", tasks.listed)
+	}
+}
@@ -0,0 +1,248 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// embedTimeout bounds embedding one task, so a slow provider delays
+	// task creation by at most that long
+	embedTimeout = 3 * time.Second
+	// embedBatchSize bounds how many tasks one backfill pass embeds
+	embedBatchSize = 20
+	// maxDuplicates caps the possible duplicates of a created task
+	maxDuplicates       = 3
+	defaultSimilarTasks = 5
+	maxSimilarTasks     = 20
+)
+
+// Embedder is implemented by AI backends that can embed text. Only
+// embeddings of the same model are compared, so tasks embedded by an
+// earlier model are embedded again by the backfill.
+type Embedder interface {
+	EmbeddingModel() string
+	EmbedText(ctx context.Context, text string) ([]float32, error)
+}
+
+// SimilarTask is a task that reads like another one. Similarity is the
+// cosine similarity of their embeddings, 1 for the same meaning.
+type SimilarTask struct {
+	ID         string       `json:"id"`
+	Title      string       `json:"title"`
+	Status     TaskStatus   `json:"status"`
+	Priority   TaskPriority `json:"priority"`
+	Project    string       `json:"project,omitempty"`
+	DueDate    time.Time    `json:"due_date"`
+	Similarity float64      `json:"similarity"`
+}
+
+// SetEmbedder enables similar task searches. Created tasks at least
+// duplicateThreshold similar to an open task are flagged as possible
+// duplicates.
+func (s *Service) SetEmbedder(embedder Embedder, duplicateThreshold float64) {
+	s.embedder = embedder
+	s.duplicateThreshold = duplicateThreshold
+}
+
+// SimilarTasks returns up to limit tasks visible to userID that read most
+// like the task, most similar first. A task not embedded yet is embedded
+// first.
+func (s *Service) SimilarTasks(ctx context.Context, taskID, userID string, limit int) ([]SimilarTask, error) {
+	if s.embedder == nil {
+		return nil, ErrSimilarityUnsupported
+	}
+	task, err := s.findVisibleTask(ctx, taskID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultSimilarTasks
+	}
+
+	var embedded bool
+	if err := s.db.WithContext(ctx).Raw(
+		"SELECT EXISTS (SELECT 1 FROM tasks WHERE id = ? AND embedding_model = ?)",
+		task.ID, s.embedder.EmbeddingModel()).
+		Scan(&embedded).Error; err != nil {
+		return nil, err
+	}
+	if !embedded {
+		embedCtx, cancel := context.WithTimeout(ctx, embedTimeout)
+		defer cancel()
+		if err := s.embedTask(embedCtx, *task); err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			s.logger.Warn("Failed to embed task", zap.String("task_id", task.ID), zap.Error(err))
+			return nil, fmt.Errorf("%w: %v", ErrSimilarityUnavailable, err)
+		}
+	}
+	return s.similarTo(ctx, task.ID, userID, -1, false, min(limit, maxSimilarTasks))
+}
+
+// findDuplicates embeds a created task and returns the open tasks visible
+// to userID that are at least duplicateThreshold similar to it. Failures
+// are only logged: the task is created either way, and the backfill embeds
+// it later.
+func (s *Service) findDuplicates(ctx context.Context, task Task, userID string) []SimilarTask {
+	ctx, cancel := context.WithTimeout(ctx, embedTimeout)
+	defer cancel()
+	if err := s.embedTask(ctx, task); err != nil {
+		s.logger.Warn("Failed to embed task", zap.String("task_id", task.ID), zap.Error(err))
+		return nil
+	}
+	duplicates, err := s.similarTo(ctx, task.ID, userID, s.duplicateThreshold, true, maxDuplicates)
+	if err != nil {
+		s.logger.Warn("Failed to look for duplicate tasks", zap.String("task_id", task.ID), zap.Error(err))
+		return nil
+	}
+	return duplicates
+}
+
+// similarTo returns up to limit tasks visible to userID, embedded by the
+// current model, that are at least minSimilarity similar to the task, most
+// similar first; openOnly leaves out completed tasks
+func (s *Service) similarTo(ctx context.Context, taskID, userID string, minSimilarity float64, openOnly bool, limit int) ([]SimilarTask, error) {
+	similar := []SimilarTask{}
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT tasks.id, tasks.title, tasks.status, tasks.priority, tasks.project, tasks.due_date,
+			1 - (tasks.embedding <=> source.embedding) AS similarity
+		FROM tasks JOIN tasks source ON source.id = @task
+		WHERE tasks.id <> source.id AND tasks.deleted_at IS NULL
+			AND tasks.embedding_model = @model AND source.embedding_model = @model
+			AND 1 - (tasks.embedding <=> source.embedding) >= @min
+			AND (NOT @open OR tasks.status <> 'completed')
+			AND `+visibleTo+`
+		ORDER BY tasks.embedding <=> source.embedding
+		LIMIT @limit`,
+		map[string]interface{}{
+			"task":  taskID,
+			"model": s.embedder.EmbeddingModel(),
+			"min":   minSimilarity,
+			"open":  openOnly,
+			"user":  userID,
+			"limit": limit,
+		}).
+		Scan(&similar).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to search similar tasks: %w", err)
+	}
+	return similar, nil
+}
+
+// refreshEmbedding drops the embedding of a task whose text changed and
+// embeds it again in the background. Should that fail, the backfill
+// embeds it later.
+func (s *Service) refreshEmbedding(ctx context.Context, task Task) {
+	if s.embedder == nil {
+		return
+	}
+	if err := s.db.WithContext(ctx).
+		Exec("UPDATE tasks SET embedding = NULL, embedding_model = NULL WHERE id = ?", task.ID).Error; err != nil {
+		s.logger.Warn("Failed to drop task embedding", zap.String("task_id", task.ID), zap.Error(err))
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), embedTimeout)
+		defer cancel()
+		if err := s.embedTask(ctx, task); err != nil {
+			s.logger.Warn("Failed to embed task", zap.String("task_id", task.ID), zap.Error(err))
+		}
+	}()
+}
+
+// embedTask stores the embedding of the task's title and description,
+// unless they changed while it was computed
+func (s *Service) embedTask(ctx context.Context, task Task) error {
+	vector, err := s.embedder.EmbedText(ctx, strings.TrimSpace(task.Title+"\n\n"+task.Description))
+	if err != nil {
+		return err
+	}
+	return s.db.WithContext(ctx).Exec(`
+		UPDATE tasks SET embedding = CAST(@vector AS vector), embedding_model = @model
+		WHERE id = @id AND title = @title AND description = @description`,
+		map[string]interface{}{
+			"vector":      vectorLiteral(vector),
+			"model":       s.embedder.EmbeddingModel(),
+			"id":          task.ID,
+			"title":       task.Title,
+			"description": task.Description,
+		}).Error
+}
+
+// vectorLiteral formats v as pgvector's text input, such as [0.1,-0.2]
+func vectorLiteral(v []float32) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, x := range v {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(x), 'g', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+// StartEmbeddingBackfill embeds tasks missing an embedding of the current
+// model every interval until ctx is cancelled: tasks written before
+// embeddings were enabled, by imports and templates, or whose embedding
+// failed. It is a no-op without an embedder.
+func (s *Service) StartEmbeddingBackfill(ctx context.Context, interval time.Duration) {
+	if s.embedder == nil {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := s.EmbedPending(ctx); err != nil && ctx.Err() == nil {
+				s.logger.Error("Failed to embed tasks", zap.Error(err))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// EmbedPending embeds up to 20 tasks missing an embedding of the current
+// model, newest first, and returns how many it embedded. Tasks are not
+// locked while the provider is called, so replicas may embed one twice.
+func (s *Service) EmbedPending(ctx context.Context) (int, error) {
+	if s.embedder == nil {
+		return 0, ErrSimilarityUnsupported
+	}
+	var tasks []Task
+	if err := s.db.WithContext(ctx).Select("id", "title", "description").
+		Where("embedding_model IS DISTINCT FROM ?", s.embedder.EmbeddingModel()).
+		Order("created_at DESC").
+		Limit(embedBatchSize).
+		Find(&tasks).Error; err != nil {
+		return 0, fmt.Errorf("failed to load tasks to embed: %w", err)
+	}
+
+	embedded := 0
+	for _, task := range tasks {
+		if err := s.embedTask(ctx, task); err != nil {
+			if ctx.Err() != nil {
+				return embedded, ctx.Err()
+			}
+			s.logger.Warn("Failed to embed task", zap.String("task_id", task.ID), zap.Error(err))
+			continue
+		}
+		embedded++
+	}
+	return embedded, nil
+}
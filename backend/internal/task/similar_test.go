@@ -0,0 +1,93 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type fakeEmbedder struct {
+	texts []string
+}
+
+func (f *fakeEmbedder) EmbeddingModel() string { return "embed-1" }
+
+func (f *fakeEmbedder) EmbedText(ctx context.Context, text string) ([]float32, error) {
+	f.texts = append(f.texts, text)
+	return []float32{0.5, -0.25, 1}, nil
+}
+
+func expectSimilarTasks(mock sqlmock.Sqlmock, min float64, open bool, limit int) *sqlmock.ExpectedQuery {
+	return mock.ExpectQuery(`SELECT tasks.id, .*1 - \(tasks.embedding <=> source.embedding\) AS similarity `+
+		`FROM tasks JOIN tasks source ON source.id = \$1 .*ORDER BY tasks.embedding <=> source.embedding LIMIT \$11`).
+		WithArgs(sqlmock.AnyArg(), "embed-1", "embed-1", min, open, "user-1", "user-1", "user-1", "user-1", "user-1", limit)
+}
+
+func TestVectorLiteral(t *testing.T) {
+	if got := vectorLiteral([]float32{0.1, -2, 3e-8}); got != "[0.1,-2,3e-08]" {
+		t.Fatalf("vectorLiteral = %s", got)
+	}
+}
+
+func TestCreateTaskWarnsAboutSimilarOpenTasks(t *testing.T) {
+	s, mock := newTestService(t)
+	embedder := &fakeEmbedder{}
+	s.SetEmbedder(embedder, 0.9)
+	expectTemplate(mock, "tpl-1", 3)
+	expectCalendar(mock, "ops", "")
+	expectFieldSchema(mock, "ops", "")
+	expectCalendar(mock, "ops", "")
+	expectCreateTask(mock)
+	mock.ExpectExec(`UPDATE tasks SET embedding = CAST\(\$1 AS vector\), embedding_model = \$2 WHERE id = \$3 AND title = \$4`).
+		WithArgs("[0.5,-0.25,1]", "embed-1", sqlmock.AnyArg(), "Write weekly report", "Summarise the week").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectSimilarTasks(mock, 0.9, true, maxDuplicates).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "status", "similarity"}).
+			AddRow("task-2", "Write the weekly report", "pending", 0.97))
+
+	resp, err := s.CreateTaskFromTemplate(context.Background(), "tpl-1", InstantiateTaskRequest{}, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(embedder.texts) != 1 || embedder.texts[0] != "Write weekly report\n\nSummarise the week" {
+		t.Fatalf("embedded %q, want the title and description", embedder.texts)
+	}
+	if len(resp.PossibleDuplicates) != 1 || resp.PossibleDuplicates[0].ID != "task-2" || resp.Warning == "" {
+		t.Fatalf("response = %+v, want a warning about task-2", resp)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSimilarTasksEmbedsTaskFirst(t *testing.T) {
+	s, mock := newTestService(t)
+	if _, err := s.SimilarTasks(context.Background(), "task-1", "user-1", 0); !errors.Is(err, ErrSimilarityUnsupported) {
+		t.Fatalf("err = %v, want ErrSimilarityUnsupported", err)
+	}
+
+	embedder := &fakeEmbedder{}
+	s.SetEmbedder(embedder, 0.9)
+	expectModifiableTask(mock, "task-1", "user-1")
+	mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM tasks WHERE id = \$1 AND embedding_model = \$2\)`).
+		WithArgs("task-1", "embed-1").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectExec(`UPDATE tasks SET embedding`).WillReturnResult(sqlmock.NewResult(0, 1))
+	expectSimilarTasks(mock, -1, false, maxSimilarTasks).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "similarity"}).
+			AddRow("task-2", "Fix the login", 0.91).
+			AddRow("task-3", "Login page", 0.62))
+
+	similar, err := s.SimilarTasks(context.Background(), "task-1", "user-1", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(embedder.texts) != 1 || len(similar) != 2 || similar[0].Similarity != 0.91 {
+		t.Fatalf("similar = %+v after %d embeddings, want both tasks after one", similar, len(embedder.texts))
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
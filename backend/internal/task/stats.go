@@ -0,0 +1,75 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// TaskStats are counts over every task for administrators. Total includes
+// archived tasks; the last week counts are of tasks created and completed
+// in the seven days before now.
+type TaskStats struct {
+	Total             int64 `json:"total"`
+	Pending           int64 `json:"pending"`
+	InProgress        int64 `json:"in_progress"`
+	Completed         int64 `json:"completed"`
+	Overdue           int64 `json:"overdue"`
+	Archived          int64 `json:"archived"`
+	CreatedLastWeek   int64 `json:"created_last_week"`
+	CompletedLastWeek int64 `json:"completed_last_week"`
+}
+
+// WebSocketStats describe the WebSocket clients of this replica; each
+// replica serves its own
+type WebSocketStats struct {
+	Connections int `json:"connections"`
+	Users       int `json:"users"`
+	// ProtocolVersions counts the connections by negotiated version
+	ProtocolVersions map[int]int `json:"protocol_versions"`
+	MaxClientQueue   int         `json:"max_client_queue"`
+	BroadcastBacklog int64       `json:"broadcast_backlog"`
+	PendingWrites    int64       `json:"pending_writes"`
+}
+
+// TaskStats counts every task in one pass
+func (s *Service) TaskStats(ctx context.Context) (*TaskStats, error) {
+	now := time.Now()
+	args := map[string]interface{}{"now": now, "week": now.AddDate(0, 0, -7)}
+	stats := &TaskStats{}
+	err := s.db.WithContext(ctx).Model(&Task{}).
+		Select(`COUNT(*) AS total,
+			COUNT(*) FILTER (WHERE status = 'pending') AS pending,
+			COUNT(*) FILTER (WHERE status = 'in_progress') AS in_progress,
+			COUNT(*) FILTER (WHERE status = 'completed') AS completed,
+			COUNT(*) FILTER (WHERE status <> 'completed' AND due_date < @now) AS overdue,
+			COUNT(*) FILTER (WHERE archived_at IS NOT NULL) AS archived,
+			COUNT(*) FILTER (WHERE created_at >= @week) AS created_last_week,
+			COUNT(*) FILTER (WHERE completed_at >= @week) AS completed_last_week`, args).
+		Scan(stats).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count tasks: %w", err)
+	}
+	return stats, nil
+}
+
+// WebSocketStats describes the clients connected to this replica
+func (s *Service) WebSocketStats() WebSocketStats {
+	stats := WebSocketStats{
+		ProtocolVersions: make(map[int]int),
+		MaxClientQueue:   s.MaxClientQueue(),
+		BroadcastBacklog: s.BroadcastBacklog(),
+		PendingWrites:    s.PendingWrites(),
+	}
+
+	s.clientsMux.RLock()
+	defer s.clientsMux.RUnlock()
+	users := make(map[string]bool)
+	for _, client := range s.clients {
+		stats.Connections++
+		users[client.userID] = true
+		stats.ProtocolVersions[client.protocol.version]++
+	}
+	stats.Users = len(users)
+	return stats
+}
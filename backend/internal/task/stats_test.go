@@ -0,0 +1,39 @@
+package task
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestTaskStatsCountsInOneQuery(t *testing.T) {
+	s, mock := newTestService(t)
+	mock.ExpectQuery(`SELECT COUNT\(\*\) AS total,.*FILTER \(WHERE archived_at IS NOT NULL\) AS archived,.* FROM "tasks" WHERE "tasks"."deleted_at" IS NULL`).
+		WillReturnRows(sqlmock.NewRows([]string{"total", "pending", "in_progress", "completed", "overdue", "archived", "created_last_week", "completed_last_week"}).
+			AddRow(10, 3, 2, 5, 1, 4, 6, 2))
+
+	stats, err := s.TaskStats(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := TaskStats{Total: 10, Pending: 3, InProgress: 2, Completed: 5, Overdue: 1, Archived: 4, CreatedLastWeek: 6, CompletedLastWeek: 2}
+	if *stats != want {
+		t.Fatalf("stats = %+v, want %+v", *stats, want)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWebSocketStatsCountsClients(t *testing.T) {
+	s, _ := newTestService(t)
+	dialHubAs(t, s, "user-1")
+	dialHubAs(t, s, "user-1")
+	dialHubAs(t, s, "user-2")
+
+	stats := s.WebSocketStats()
+	if stats.Connections != 3 || stats.Users != 2 || stats.ProtocolVersions[LegacyProtocolVersion] != 3 {
+		t.Fatalf("stats = %+v, want three legacy connections of two users", stats)
+	}
+}
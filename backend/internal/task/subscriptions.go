@@ -0,0 +1,223 @@
+package task
+
+import (
+	"context"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// Client frames that change a connection's project subscriptions
+const (
+	SubscribeMessageType   = "subscribe"
+	UnsubscribeMessageType = "unsubscribe"
+)
+
+// Subscription states reported to clients in a subscription message
+const (
+	SubscriptionSubscribed   = "subscribed"
+	SubscriptionUnsubscribed = "unsubscribed"
+	SubscriptionDenied       = "denied"
+	SubscriptionRevoked      = "revoked"
+)
+
+// maxSubscriptions bounds how many projects one connection can follow
+const maxSubscriptions = 50
+
+// SubscriptionRequest is sent by clients to follow or stop following a
+// project's task notifications
+type SubscriptionRequest struct {
+	Type    string `json:"type"`
+	Project string `json:"project"`
+}
+
+// SubscriptionStatus answers a SubscriptionRequest, and tells the client
+// when a subscription was revoked because it can no longer see the project
+type SubscriptionStatus struct {
+	Project string `json:"project"`
+	Status  string `json:"status"`
+}
+
+// Subscribe lets conn receive the task notifications of project if userID
+// is a member of it: has created or is assigned to one of its tasks. The
+// answer is sent on conn.
+func (s *Service) Subscribe(ctx context.Context, conn *websocket.Conn, userID, project string) error {
+	status := SubscriptionDenied
+	if project != "" && userID != "" {
+		members, err := s.memberProjects(ctx, userID, []string{project})
+		if err != nil {
+			return err
+		}
+		if members[project] {
+			status = SubscriptionSubscribed
+		}
+	}
+
+	s.clientsMux.Lock()
+	client, ok := s.clients[conn]
+	if ok && status == SubscriptionSubscribed {
+		if client.projects == nil {
+			client.projects = make(map[string]bool)
+		}
+		if len(client.projects) >= maxSubscriptions && !client.projects[project] {
+			status = SubscriptionDenied
+		} else {
+			client.projects[project] = true
+		}
+	}
+	s.clientsMux.Unlock()
+	if ok {
+		s.sendSubscriptionStatus(conn, client, project, status)
+	}
+	return nil
+}
+
+// Unsubscribe stops conn receiving the task notifications of project
+func (s *Service) Unsubscribe(conn *websocket.Conn, project string) {
+	s.clientsMux.Lock()
+	client, ok := s.clients[conn]
+	if ok {
+		delete(client.projects, project)
+	}
+	s.clientsMux.Unlock()
+	if ok {
+		s.sendSubscriptionStatus(conn, client, project, SubscriptionUnsubscribed)
+	}
+}
+
+// membershipChanged asks the reconciler to re-check subscriptions soon,
+// after a change that can take users out of a project
+func (s *Service) membershipChanged() {
+	select {
+	case s.reconcile <- struct{}{}:
+	default:
+	}
+}
+
+// StartSubscriptionReconciler re-checks subscriptions after every change
+// of task assignees, projects or deletions on this replica, and every
+// interval to catch changes made on other replicas, until ctx is cancelled
+func (s *Service) StartSubscriptionReconciler(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-s.reconcile:
+			}
+			if _, err := s.ReconcileSubscriptions(ctx); err != nil && ctx.Err() == nil {
+				s.logger.Error("Failed to reconcile WebSocket subscriptions", zap.Error(err))
+			}
+		}
+	}()
+}
+
+// ReconcileSubscriptions removes the project subscriptions of connections
+// whose user is no longer a member of the project, tells those clients, and
+// drops their cached typeahead results. It returns how many subscriptions
+// were revoked.
+func (s *Service) ReconcileSubscriptions(ctx context.Context) (int, error) {
+	subscribed := s.subscribedProjects()
+
+	lost := make(map[string]map[string]bool, len(subscribed))
+	for userID, projects := range subscribed {
+		list := make([]string, 0, len(projects))
+		for project := range projects {
+			list = append(list, project)
+		}
+		members, err := s.memberProjects(ctx, userID, list)
+		if err != nil {
+			return 0, err
+		}
+		for _, project := range list {
+			if !members[project] {
+				if lost[userID] == nil {
+					lost[userID] = make(map[string]bool)
+				}
+				lost[userID][project] = true
+			}
+		}
+	}
+	if len(lost) == 0 {
+		return 0, nil
+	}
+
+	type revocation struct {
+		conn    *websocket.Conn
+		client  *wsClient
+		project string
+	}
+	var revoked []revocation
+	s.clientsMux.Lock()
+	for conn, client := range s.clients {
+		for project := range client.projects {
+			if lost[client.userID][project] {
+				delete(client.projects, project)
+				revoked = append(revoked, revocation{conn, client, project})
+			}
+		}
+	}
+	s.clientsMux.Unlock()
+
+	for _, r := range revoked {
+		s.sendSubscriptionStatus(r.conn, r.client, r.project, SubscriptionRevoked)
+	}
+	if s.typeahead != nil {
+		for userID := range lost {
+			if err := s.typeahead.Forget(ctx, userID); err != nil {
+				s.logger.Warn("Failed to drop cached typeahead results", zap.String("user_id", userID), zap.Error(err))
+			}
+		}
+	}
+	if len(revoked) > 0 {
+		s.logger.Info("Revoked WebSocket subscriptions", zap.Int("revoked", len(revoked)))
+	}
+	return len(revoked), nil
+}
+
+// subscribedProjects returns the projects each connected user follows
+func (s *Service) subscribedProjects() map[string]map[string]bool {
+	s.clientsMux.RLock()
+	defer s.clientsMux.RUnlock()
+	subscribed := make(map[string]map[string]bool)
+	for _, client := range s.clients {
+		for project := range client.projects {
+			if subscribed[client.userID] == nil {
+				subscribed[client.userID] = make(map[string]bool)
+			}
+			subscribed[client.userID][project] = true
+		}
+	}
+	return subscribed
+}
+
+// memberProjects returns which of projects userID is a member of, by the
+// same rule canViewTask uses for project members
+func (s *Service) memberProjects(ctx context.Context, userID string, projects []string) (map[string]bool, error) {
+	var found []string
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT DISTINCT t.project FROM tasks t
+		WHERE t.project IN @projects AND t.deleted_at IS NULL
+		AND (t.created_by = @user OR EXISTS (
+			SELECT 1 FROM task_assignees ta WHERE ta.task_id = t.id AND ta.user_id = @user))`,
+		map[string]interface{}{"projects": projects, "user": userID}).
+		Scan(&found).Error
+	if err != nil {
+		return nil, err
+	}
+	members := make(map[string]bool, len(found))
+	for _, project := range found {
+		members[project] = true
+	}
+	return members, nil
+}
+
+// sendSubscriptionStatus writes a subscription message to one connection
+func (s *Service) sendSubscriptionStatus(conn *websocket.Conn, client *wsClient, project, status string) {
+	s.sendDirect(conn, client, NewWebSocketMessage(MessageTypeSubscription, SubscriptionStatus{Project: project, Status: status}))
+}
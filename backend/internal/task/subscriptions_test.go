@@ -0,0 +1,96 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/websocket"
+)
+
+func expectStatus(t *testing.T, conn *websocket.Conn, want string) {
+	t.Helper()
+	msg := readMessage(t, conn)
+	payload, _ := json.Marshal(msg.Payload)
+	var status SubscriptionStatus
+	json.Unmarshal(payload, &status)
+	if msg.Type != MessageTypeSubscription || status.Status != want || status.Project != "web" {
+		t.Fatalf("got %s %s, want a subscription message with status %q", msg.Type, payload, want)
+	}
+}
+
+func TestSubscriptionRevokedWhenUserLeavesProject(t *testing.T) {
+	s, mock := newTestService(t)
+	conn := dialHubAs(t, s, "user-1")
+
+	mock.ExpectQuery(`SELECT DISTINCT t.project FROM tasks t`).
+		WithArgs("web", "user-1", "user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"project"}).AddRow("web"))
+	if err := conn.WriteJSON(SubscriptionRequest{Type: SubscribeMessageType, Project: "web"}); err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, conn, SubscriptionSubscribed)
+
+	// Notifications of the project reach subscribers who do not follow the task
+	notice := WebSocketMessage{Type: MessageTypeTaskNotification, Payload: "task-1",
+		recipients: map[string]bool{"user-2": true}, project: "web"}
+	s.publish(notice)
+	if msgType := readMessage(t, conn).Type; msgType != MessageTypeTaskNotification {
+		t.Fatalf("message type = %q, want the project's notification", msgType)
+	}
+
+	mock.ExpectQuery(`SELECT DISTINCT t.project FROM tasks t`).
+		WithArgs("web", "user-1", "user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"project"}))
+	revoked, err := s.ReconcileSubscriptions(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revoked != 1 {
+		t.Fatalf("revoked = %d, want 1", revoked)
+	}
+	expectStatus(t, conn, SubscriptionRevoked)
+
+	s.publish(notice)
+	s.publish(NewWebSocketMessage(MessageTypeTaskCreated, "marker"))
+	if msgType := readMessage(t, conn).Type; msgType != MessageTypeTaskCreated {
+		t.Fatalf("message type = %q, want the notification to be withheld after revocation", msgType)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSubscribeDeniedToNonMembers(t *testing.T) {
+	s, mock := newTestService(t)
+	conn := dialHubAs(t, s, "user-1")
+
+	mock.ExpectQuery(`SELECT DISTINCT t.project FROM tasks t`).
+		WithArgs("web", "user-1", "user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"project"}))
+	if err := conn.WriteJSON(SubscriptionRequest{Type: SubscribeMessageType, Project: "web"}); err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, conn, SubscriptionDenied)
+
+	if revoked, err := s.ReconcileSubscriptions(context.Background()); err != nil || revoked != 0 {
+		t.Fatalf("revoked = %d, err = %v, want nothing to reconcile", revoked, err)
+	}
+}
+
+func TestMemoryTypeaheadCacheForgetsOneUser(t *testing.T) {
+	c := NewMemoryTypeaheadCache(time.Minute)
+	ctx := context.Background()
+	c.Set(ctx, typeaheadKeyPrefix("user-1")+"rel", []TypeaheadResult{{ID: "task-1"}})
+	c.Set(ctx, typeaheadKeyPrefix("user-2")+"rel", []TypeaheadResult{{ID: "task-1"}})
+
+	c.Forget(ctx, "user-1")
+	if _, found, _ := c.Get(ctx, typeaheadKeyPrefix("user-1")+"rel"); found {
+		t.Fatal("results of user-1 still cached")
+	}
+	if _, found, _ := c.Get(ctx, typeaheadKeyPrefix("user-2")+"rel"); !found {
+		t.Fatal("results of user-2 dropped")
+	}
+}
@@ -0,0 +1,214 @@
+package task
+
+import (
+	"context"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SyncTypeTask          = "task"
+	SyncTypeChecklistItem = "checklist_item"
+
+	defaultSyncLimit = 200
+	maxSyncLimit     = 500
+	// syncSettle holds back changes this recent: a transaction that started
+	// earlier may still commit rows with an older updated_at, which a cursor
+	// already past them would never return
+	syncSettle = 5 * time.Second
+)
+
+// SyncChange is one task or checklist item that changed since the cursor.
+// Deleted changes are tombstones and carry only the ID; a task tombstone
+// also removes its checklist.
+type SyncChange struct {
+	Type          string         `json:"type"`
+	ID            string         `json:"id"`
+	TaskID        string         `json:"task_id"`
+	ChangedAt     time.Time      `json:"changed_at"`
+	Deleted       bool           `json:"deleted"`
+	Task          *Task          `json:"task,omitempty"`
+	ChecklistItem *ChecklistItem `json:"checklist_item,omitempty"`
+}
+
+// SyncResponse is one page of changes. Cursor is passed as since for the
+// next page; while HasMore is set the client should request it right away.
+type SyncResponse struct {
+	Changes []SyncChange `json:"changes"`
+	Cursor  string       `json:"cursor"`
+	HasMore bool         `json:"has_more"`
+}
+
+// syncRow is a change as listed, before its record is loaded
+type syncRow struct {
+	Type      string
+	ID        string
+	TaskID    string
+	ChangedAt time.Time
+	Deleted   bool
+}
+
+// syncCursor is the position of the last change a client has: changes are
+// ordered by time, then type and ID
+type syncCursor struct {
+	at  time.Time
+	typ string
+	id  string
+}
+
+// Sync returns the changes to the tasks userID can see, and their
+// checklists, after the since cursor, oldest first. Without a cursor it
+// returns every visible task and checklist item, without tombstones.
+func (s *Service) Sync(ctx context.Context, userID string, since string, limit int) (*SyncResponse, error) {
+	if limit == 0 {
+		limit = defaultSyncLimit
+	}
+	if limit < 1 || limit > maxSyncLimit {
+		return nil, ErrInvalidSyncLimit
+	}
+	cursor, err := parseSyncCursor(since)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []syncRow
+	if err := s.db.WithContext(ctx).Raw(`
+		WITH projects AS (
+			SELECT DISTINCT p.project FROM tasks p
+			WHERE p.project <> '' AND p.deleted_at IS NULL
+			AND (p.created_by = @user OR EXISTS (
+				SELECT 1 FROM task_assignees ta WHERE ta.task_id = p.id AND ta.user_id = @user))
+		), visible AS (
+			SELECT t.id, t.updated_at, t.deleted_at FROM tasks t
+			WHERE t.created_by = @user
+			OR EXISTS (SELECT 1 FROM task_assignees ta WHERE ta.task_id = t.id AND ta.user_id = @user)
+			OR EXISTS (SELECT 1 FROM task_watchers tw WHERE tw.task_id = t.id AND tw.user_id = @user)
+			OR t.project IN (SELECT project FROM projects)
+		), changes AS (
+			SELECT 'task' AS type, v.id::text AS id, v.id::text AS task_id,
+				GREATEST(v.updated_at, v.deleted_at) AS changed_at, v.deleted_at IS NOT NULL AS deleted
+			FROM visible v
+			UNION ALL
+			SELECT 'checklist_item', c.id::text, c.task_id::text,
+				GREATEST(c.updated_at, c.deleted_at), c.deleted_at IS NOT NULL
+			FROM checklist_items c JOIN visible v ON v.id = c.task_id
+			WHERE v.deleted_at IS NULL
+		)
+		SELECT * FROM changes
+		WHERE changed_at <= @until AND (changed_at, type, id) > (@at, @type, @id)
+		AND (@initial = FALSE OR NOT deleted)
+		ORDER BY changed_at, type, id
+		LIMIT @limit`,
+		map[string]interface{}{
+			"user":    userID,
+			"until":   time.Now().Add(-syncSettle),
+			"at":      cursor.at,
+			"type":    cursor.typ,
+			"id":      cursor.id,
+			"initial": since == "",
+			"limit":   limit + 1,
+		}).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	resp := &SyncResponse{Changes: []SyncChange{}, Cursor: since}
+	if len(rows) > limit {
+		rows = rows[:limit]
+		resp.HasMore = true
+	}
+	if len(rows) == 0 {
+		return resp, nil
+	}
+
+	changes := make([]SyncChange, len(rows))
+	for i, row := range rows {
+		changes[i] = SyncChange{Type: row.Type, ID: row.ID, TaskID: row.TaskID, ChangedAt: row.ChangedAt, Deleted: row.Deleted}
+	}
+	if err := s.loadSyncRecords(ctx, changes); err != nil {
+		return nil, err
+	}
+
+	last := changes[len(changes)-1]
+	resp.Changes = changes
+	resp.Cursor = syncCursor{at: last.ChangedAt, typ: last.Type, id: last.ID}.String()
+	return resp, nil
+}
+
+// loadSyncRecords fills in the current task or checklist item of every
+// change that is not a tombstone
+func (s *Service) loadSyncRecords(ctx context.Context, changes []SyncChange) error {
+	var taskIDs, itemIDs []string
+	for _, change := range changes {
+		if change.Deleted {
+			continue
+		}
+		if change.Type == SyncTypeTask {
+			taskIDs = append(taskIDs, change.ID)
+		} else {
+			itemIDs = append(itemIDs, change.ID)
+		}
+	}
+
+	tasks := make(map[string]*Task, len(taskIDs))
+	if len(taskIDs) > 0 {
+		var found []Task
+		if err := s.db.WithContext(ctx).Preload("Assignees").Where("id IN ?", taskIDs).Find(&found).Error; err != nil {
+			return err
+		}
+		for i := range found {
+			tasks[found[i].ID] = &found[i]
+		}
+	}
+	items := make(map[string]*ChecklistItem, len(itemIDs))
+	if len(itemIDs) > 0 {
+		var found []ChecklistItem
+		if err := s.db.WithContext(ctx).Where("id IN ?", itemIDs).Find(&found).Error; err != nil {
+			return err
+		}
+		for i := range found {
+			items[found[i].ID] = &found[i]
+		}
+	}
+
+	for i := range changes {
+		// A record deleted since the changes were listed is sent as a
+		// tombstone now; the deletion comes again on the next sync
+		switch {
+		case changes[i].Deleted:
+		case changes[i].Type == SyncTypeTask:
+			changes[i].Task = tasks[changes[i].ID]
+			changes[i].Deleted = changes[i].Task == nil
+		default:
+			changes[i].ChecklistItem = items[changes[i].ID]
+			changes[i].Deleted = changes[i].ChecklistItem == nil
+		}
+	}
+	return nil
+}
+
+// String encodes the cursor for clients, who treat it as opaque
+func (c syncCursor) String() string {
+	raw := strconv.FormatInt(c.at.UnixMicro(), 10) + ":" + c.typ + ":" + c.id
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func parseSyncCursor(since string) (syncCursor, error) {
+	if since == "" {
+		return syncCursor{at: time.Unix(0, 0).UTC()}, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(since)
+	if err != nil {
+		return syncCursor{}, ErrInvalidSyncCursor
+	}
+	parts := strings.SplitN(string(raw), ":", 3)
+	if len(parts) != 3 {
+		return syncCursor{}, ErrInvalidSyncCursor
+	}
+	micros, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return syncCursor{}, ErrInvalidSyncCursor
+	}
+	return syncCursor{at: time.UnixMicro(micros).UTC(), typ: parts[1], id: parts[2]}, nil
+}
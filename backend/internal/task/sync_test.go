@@ -0,0 +1,64 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestSyncPagesAndTombstones(t *testing.T) {
+	s, mock := newTestService(t)
+	changedAt := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	cursor := syncCursor{at: changedAt.Add(-time.Hour), typ: SyncTypeTask, id: "task-0"}.String()
+
+	mock.ExpectQuery(`WITH projects AS`).
+		WithArgs("user-1", "user-1", "user-1", "user-1", "user-1", sqlmock.AnyArg(), changedAt.Add(-time.Hour), SyncTypeTask, "task-0", false, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"type", "id", "task_id", "changed_at", "deleted"}).
+			AddRow(SyncTypeTask, "task-1", "task-1", changedAt, false).
+			AddRow(SyncTypeTask, "task-2", "task-2", changedAt, true).
+			AddRow(SyncTypeChecklistItem, "item-1", "task-1", changedAt.Add(time.Second), false))
+	mock.ExpectQuery(`SELECT \* FROM "tasks" WHERE id IN \(\$1\)`).
+		WithArgs("task-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow("task-1", "Ship it"))
+	mock.ExpectQuery(`SELECT \* FROM "task_assignees"`).
+		WillReturnRows(sqlmock.NewRows([]string{"task_id", "user_id"}))
+
+	resp, err := s.Sync(context.Background(), "user-1", cursor, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Changes) != 2 || !resp.HasMore {
+		t.Fatalf("got %d changes, has_more %v; want 2 and more", len(resp.Changes), resp.HasMore)
+	}
+	if resp.Changes[0].Task == nil || resp.Changes[0].Task.Title != "Ship it" {
+		t.Errorf("first change = %+v, want the loaded task", resp.Changes[0])
+	}
+	if !resp.Changes[1].Deleted || resp.Changes[1].Task != nil {
+		t.Errorf("second change = %+v, want a tombstone", resp.Changes[1])
+	}
+
+	next, err := parseSyncCursor(resp.Cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !next.at.Equal(changedAt) || next.typ != SyncTypeTask || next.id != "task-2" {
+		t.Errorf("cursor = %+v, want the last change returned", next)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSyncRejectsBadInput(t *testing.T) {
+	s, _ := newTestService(t)
+
+	if _, err := s.Sync(context.Background(), "user-1", "not a cursor", 0); !errors.Is(err, ErrInvalidSyncCursor) {
+		t.Errorf("err = %v, want ErrInvalidSyncCursor", err)
+	}
+	if _, err := s.Sync(context.Background(), "user-1", "", maxSyncLimit+1); !errors.Is(err, ErrInvalidSyncLimit) {
+		t.Errorf("err = %v, want ErrInvalidSyncLimit", err)
+	}
+}
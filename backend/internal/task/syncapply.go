@@ -0,0 +1,384 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	MutationUpdate = "update"
+	MutationDelete = "delete"
+
+	MutationApplied  = "applied"
+	MutationConflict = "conflict"
+	MutationRejected = "rejected"
+
+	// ConflictChanged means the record changed since the client's base
+	// version; ConflictDeleted that it no longer exists
+	ConflictChanged = "changed"
+	ConflictDeleted = "deleted"
+)
+
+// Mutation is a change a client made offline. BaseUpdatedAt is the
+// updated_at of the record when the client last synced it, which is the
+// version the change was made against.
+type Mutation struct {
+	ID            string                      `json:"id" binding:"required,max=100"`
+	Type          string                      `json:"type" binding:"required,oneof=task checklist_item"`
+	Op            string                      `json:"op" binding:"required,oneof=update delete"`
+	TargetID      string                      `json:"target_id" binding:"required,max=36"`
+	BaseUpdatedAt time.Time                   `json:"base_updated_at" binding:"required"`
+	Task          *UpdateTaskRequest          `json:"task"`
+	ChecklistItem *UpdateChecklistItemRequest `json:"checklist_item"`
+}
+
+type ApplyRequest struct {
+	Mutations []Mutation `json:"mutations" binding:"required,min=1,max=100,dive"`
+}
+
+// FieldConflict is a field the client changed whose server value differs
+// from the client's
+type FieldConflict struct {
+	Field  string      `json:"field"`
+	Server interface{} `json:"server"`
+	Client interface{} `json:"client"`
+}
+
+// Conflict describes why a mutation was not applied. The current task or
+// checklist item is included for the client to merge with, unless it was
+// deleted.
+type Conflict struct {
+	Reason          string          `json:"reason"`
+	ServerUpdatedAt *time.Time      `json:"server_updated_at,omitempty"`
+	Fields          []FieldConflict `json:"fields,omitempty"`
+	Task            *Task           `json:"task,omitempty"`
+	ChecklistItem   *ChecklistItem  `json:"checklist_item,omitempty"`
+}
+
+// MutationResult is the outcome of one mutation, in request order. Applied
+// results carry the stored record, unless it was deleted.
+type MutationResult struct {
+	ID            string         `json:"id"`
+	Status        string         `json:"status"`
+	Error         string         `json:"error,omitempty"`
+	Conflict      *Conflict      `json:"conflict,omitempty"`
+	Task          *Task          `json:"task,omitempty"`
+	ChecklistItem *ChecklistItem `json:"checklist_item,omitempty"`
+}
+
+type ApplyResponse struct {
+	Results   []MutationResult `json:"results"`
+	Applied   int              `json:"applied"`
+	Conflicts int              `json:"conflicts"`
+	Rejected  int              `json:"rejected"`
+}
+
+// applyState is what one ApplySync call has changed so far
+type applyState struct {
+	// rebased maps a record's updated_at before this batch changed it to
+	// the new one, so later mutations of the same record made against the
+	// old version still apply
+	rebased map[string][2]time.Time
+	// publish sends the events of applied mutations after commit
+	publish []func()
+}
+
+// ApplySync applies mutations a client made offline, in order, in one
+// transaction. Mutations made against an outdated version of their record
+// are returned as conflicts with the server and client values, unless the
+// record already has the client's values. Invalid mutations are rejected;
+// neither stops the others from applying.
+func (s *Service) ApplySync(ctx context.Context, req ApplyRequest, userID string) (*ApplyResponse, error) {
+	resp := &ApplyResponse{Results: make([]MutationResult, len(req.Mutations))}
+	state := &applyState{rebased: make(map[string][2]time.Time)}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i, mutation := range req.Mutations {
+			var result MutationResult
+			var err error
+			if mutation.Type == SyncTypeTask {
+				result, err = s.applyTaskMutation(ctx, tx, state, mutation, userID)
+			} else {
+				result, err = s.applyChecklistMutation(ctx, tx, state, mutation, userID)
+			}
+			if err != nil {
+				return err
+			}
+			result.ID = mutation.ID
+			resp.Results[i] = result
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, publish := range state.publish {
+		publish()
+	}
+	for _, result := range resp.Results {
+		switch result.Status {
+		case MutationApplied:
+			resp.Applied++
+		case MutationConflict:
+			resp.Conflicts++
+		default:
+			resp.Rejected++
+		}
+	}
+	return resp, nil
+}
+
+func (s *Service) applyTaskMutation(ctx context.Context, tx *gorm.DB, state *applyState, m Mutation, userID string) (MutationResult, error) {
+	if m.Op == MutationUpdate && m.Task == nil {
+		return rejected("task is required to update a task"), nil
+	}
+
+	task, err := lockTaskWithAssignees(tx, m.TargetID)
+	if errors.Is(err, ErrTaskNotFound) {
+		if m.Op == MutationDelete {
+			return MutationResult{Status: MutationApplied}, nil
+		}
+		return MutationResult{Status: MutationConflict, Conflict: &Conflict{Reason: ConflictDeleted}}, nil
+	}
+	if err != nil {
+		return MutationResult{}, err
+	}
+
+	if m.Op == MutationDelete {
+		if task.CreatedBy != userID {
+			return rejected(ErrUnauthorized.Error()), nil
+		}
+		if !state.current(task.ID, m.BaseUpdatedAt, task.UpdatedAt) {
+			return taskConflict(task, nil), nil
+		}
+		if err := tx.Delete(&Task{}, "id = ?", task.ID).Error; err != nil {
+			return MutationResult{}, err
+		}
+		deleted := *task
+		state.publish = append(state.publish, func() { s.publishTaskDeleted(ctx, deleted) })
+		return MutationResult{Status: MutationApplied}, nil
+	}
+
+	if !s.canModifyTask(userID, task) {
+		return rejected(ErrUnauthorized.Error()), nil
+	}
+	if !state.current(task.ID, m.BaseUpdatedAt, task.UpdatedAt) {
+		fields := taskFieldConflicts(task, *m.Task)
+		if len(fields) > 0 {
+			return taskConflict(task, fields), nil
+		}
+		// Someone, likely this client before its connection dropped,
+		// already made the same change
+		return MutationResult{Status: MutationApplied, Task: task}, nil
+	}
+
+	before, previous := task.UpdatedAt, assigneeIDs(task)
+	primary, assignees, err := s.applyTaskUpdate(ctx, tx, task, *m.Task, userID)
+	if err != nil {
+		return rejected(err.Error()), nil
+	}
+	if err := saveTask(tx, task, false, primary, assignees); err != nil {
+		return MutationResult{}, err
+	}
+	state.rebased[task.ID] = [2]time.Time{before, task.UpdatedAt}
+
+	updated, update := *task, *m.Task
+	state.publish = append(state.publish, func() { s.publishTaskUpdate(ctx, updated, update, previous) })
+	return MutationResult{Status: MutationApplied, Task: task}, nil
+}
+
+func (s *Service) applyChecklistMutation(ctx context.Context, tx *gorm.DB, state *applyState, m Mutation, userID string) (MutationResult, error) {
+	if m.Op == MutationUpdate && m.ChecklistItem == nil {
+		return rejected("checklist_item is required to update a checklist item"), nil
+	}
+
+	item := &ChecklistItem{}
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(item, "id = ?", m.TargetID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if m.Op == MutationDelete {
+			return MutationResult{Status: MutationApplied}, nil
+		}
+		return MutationResult{Status: MutationConflict, Conflict: &Conflict{Reason: ConflictDeleted}}, nil
+	}
+	if err != nil {
+		return MutationResult{}, err
+	}
+
+	task, err := lockTaskWithAssignees(tx, item.TaskID)
+	if errors.Is(err, ErrTaskNotFound) {
+		// The item went with its task
+		if m.Op == MutationDelete {
+			return MutationResult{Status: MutationApplied}, nil
+		}
+		return MutationResult{Status: MutationConflict, Conflict: &Conflict{Reason: ConflictDeleted}}, nil
+	}
+	if err != nil {
+		return MutationResult{}, err
+	}
+	if !s.canModifyTask(userID, task) {
+		return rejected(ErrUnauthorized.Error()), nil
+	}
+
+	if !state.current(item.ID, m.BaseUpdatedAt, item.UpdatedAt) {
+		var fields []FieldConflict
+		if m.Op == MutationUpdate {
+			if fields = checklistFieldConflicts(item, *m.ChecklistItem); len(fields) == 0 {
+				return MutationResult{Status: MutationApplied, ChecklistItem: item}, nil
+			}
+		}
+		updatedAt := item.UpdatedAt
+		return MutationResult{Status: MutationConflict, Conflict: &Conflict{
+			Reason:          ConflictChanged,
+			ServerUpdatedAt: &updatedAt,
+			Fields:          fields,
+			ChecklistItem:   item,
+		}}, nil
+	}
+
+	if m.Op == MutationDelete {
+		if err := tx.Delete(item).Error; err != nil {
+			return MutationResult{}, err
+		}
+		deleted := *item
+		state.publish = append(state.publish, func() { s.publishChecklist(ctx, MessageTypeChecklistItemDeleted, deleted) })
+		return MutationResult{Status: MutationApplied}, nil
+	}
+
+	before := item.UpdatedAt
+	if err := applyChecklistUpdate(item, *m.ChecklistItem); err != nil {
+		return rejected(err.Error()), nil
+	}
+	if err := tx.Save(item).Error; err != nil {
+		return MutationResult{}, err
+	}
+	state.rebased[item.ID] = [2]time.Time{before, item.UpdatedAt}
+
+	updated := *item
+	state.publish = append(state.publish, func() { s.publishChecklist(ctx, MessageTypeChecklistItemUpdated, updated) })
+	return MutationResult{Status: MutationApplied, ChecklistItem: item}, nil
+}
+
+// current reports whether a mutation made against base can apply to a
+// record last updated at updatedAt. A base this batch already moved past
+// counts as current, so a client may queue several changes to a record.
+func (st *applyState) current(id string, base, updatedAt time.Time) bool {
+	if versions, ok := st.rebased[id]; ok && sameVersion(base, versions[0]) {
+		base = versions[1]
+	}
+	return sameVersion(base, updatedAt)
+}
+
+// sameVersion compares timestamps at the microsecond precision the
+// database keeps, which clients may see rounded either way
+func sameVersion(a, b time.Time) bool {
+	diff := a.Sub(b)
+	return diff > -time.Microsecond && diff < time.Microsecond
+}
+
+func rejected(msg string) MutationResult {
+	return MutationResult{Status: MutationRejected, Error: msg}
+}
+
+func taskConflict(task *Task, fields []FieldConflict) MutationResult {
+	updatedAt := task.UpdatedAt
+	return MutationResult{Status: MutationConflict, Conflict: &Conflict{
+		Reason:          ConflictChanged,
+		ServerUpdatedAt: &updatedAt,
+		Fields:          fields,
+		Task:            task,
+	}}
+}
+
+// taskFieldConflicts lists the fields set in req whose server value is
+// different
+func taskFieldConflicts(task *Task, req UpdateTaskRequest) []FieldConflict {
+	var fields []FieldConflict
+	check := func(field string, server, client interface{}, same bool) {
+		if !same {
+			fields = append(fields, FieldConflict{Field: field, Server: server, Client: client})
+		}
+	}
+	if req.Title != nil {
+		check("title", task.Title, *req.Title, task.Title == *req.Title)
+	}
+	if req.Description != nil {
+		check("description", task.Description, *req.Description, task.Description == *req.Description)
+	}
+	if req.Status != nil {
+		check("status", task.Status, *req.Status, string(task.Status) == *req.Status)
+	}
+	if req.Priority != nil {
+		check("priority", task.Priority, *req.Priority, string(task.Priority) == *req.Priority)
+	}
+	if req.AssignedTo != nil {
+		check("assigned_to", task.AssignedTo, *req.AssignedTo, task.AssignedTo == *req.AssignedTo)
+	}
+	if req.AssigneeIDs != nil {
+		ids := assigneeIDs(task)
+		check("assignee_ids", ids, *req.AssigneeIDs, sameIDs(ids, *req.AssigneeIDs))
+	}
+	if req.DueDate != nil {
+		check("due_date", task.DueDate, *req.DueDate, task.DueDate.Equal(*req.DueDate))
+	}
+	if req.Project != nil {
+		check("project", task.Project, *req.Project, task.Project == *req.Project)
+	}
+	if req.EstimatedEffort != nil {
+		check("estimated_effort", task.EstimatedEffort, *req.EstimatedEffort, task.EstimatedEffort == *req.EstimatedEffort)
+	}
+	if req.DeadlineType != nil {
+		check("deadline_type", task.DeadlineType, *req.DeadlineType, string(task.DeadlineType) == *req.DeadlineType)
+	}
+	return fields
+}
+
+// changesDeadlineOrAssignees reports whether req changes the task's due
+// date, deadline type or assignees, which past a hard deadline only the
+// task's creator may
+func changesDeadlineOrAssignees(task *Task, req UpdateTaskRequest) bool {
+	for _, field := range taskFieldConflicts(task, req) {
+		switch field.Field {
+		case "due_date", "deadline_type", "assigned_to", "assignee_ids":
+			return true
+		}
+	}
+	return false
+}
+
+func checklistFieldConflicts(item *ChecklistItem, req UpdateChecklistItemRequest) []FieldConflict {
+	var fields []FieldConflict
+	if req.Text != nil && item.Text != strings.TrimSpace(*req.Text) {
+		fields = append(fields, FieldConflict{Field: "text", Server: item.Text, Client: *req.Text})
+	}
+	if req.Done != nil && item.Done != *req.Done {
+		fields = append(fields, FieldConflict{Field: "done", Server: item.Done, Client: *req.Done})
+	}
+	if req.Position != nil && item.Position != *req.Position {
+		fields = append(fields, FieldConflict{Field: "position", Server: item.Position, Client: *req.Position})
+	}
+	return fields
+}
+
+// sameIDs reports whether a and b hold the same IDs in any order
+func sameIDs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a = append([]string(nil), a...)
+	b = append([]string(nil), b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
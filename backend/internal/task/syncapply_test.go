@@ -0,0 +1,109 @@
+package task
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func strPtr(s string) *string { return &s }
+
+func expectLockedTask(mock sqlmock.Sqlmock, taskID, creator, title string, updatedAt time.Time) {
+	mock.ExpectQuery(`SELECT \* FROM "tasks" WHERE id = \$1 AND "tasks"."deleted_at" IS NULL ORDER BY "tasks"."id" LIMIT \$2 FOR UPDATE`).
+		WithArgs(taskID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_by", "title", "updated_at"}).
+			AddRow(taskID, creator, title, updatedAt))
+	mock.ExpectQuery(`SELECT \* FROM "task_assignees" WHERE task_id = \$1`).
+		WithArgs(taskID).
+		WillReturnRows(sqlmock.NewRows([]string{"task_id", "user_id"}))
+}
+
+func TestApplySyncReportsConflicts(t *testing.T) {
+	s, mock := newTestService(t)
+	base := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	// Changed on the server to a different title: a conflict
+	expectLockedTask(mock, "task-1", "user-1", "Server title", base.Add(time.Minute))
+	// Changed on the server to the client's title: nothing left to do
+	expectLockedTask(mock, "task-2", "user-1", "Same title", base.Add(time.Minute))
+	// Deleting an item that is already gone
+	mock.ExpectQuery(`SELECT \* FROM "checklist_items" WHERE id = \$1 .* FOR UPDATE`).
+		WithArgs("item-1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectCommit()
+
+	resp, err := s.ApplySync(context.Background(), ApplyRequest{Mutations: []Mutation{
+		{ID: "m1", Type: SyncTypeTask, Op: MutationUpdate, TargetID: "task-1", BaseUpdatedAt: base,
+			Task: &UpdateTaskRequest{Title: strPtr("Client title")}},
+		{ID: "m2", Type: SyncTypeTask, Op: MutationUpdate, TargetID: "task-2", BaseUpdatedAt: base,
+			Task: &UpdateTaskRequest{Title: strPtr("Same title")}},
+		{ID: "m3", Type: SyncTypeChecklistItem, Op: MutationDelete, TargetID: "item-1", BaseUpdatedAt: base},
+	}}, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Applied != 2 || resp.Conflicts != 1 {
+		t.Fatalf("applied %d, conflicts %d; want 2 and 1", resp.Applied, resp.Conflicts)
+	}
+	conflict := resp.Results[0].Conflict
+	if resp.Results[0].ID != "m1" || conflict == nil || conflict.Reason != ConflictChanged {
+		t.Fatalf("first result = %+v, want a changed conflict", resp.Results[0])
+	}
+	if len(conflict.Fields) != 1 || conflict.Fields[0].Server != "Server title" || conflict.Fields[0].Client != "Client title" {
+		t.Errorf("conflict fields = %+v, want the server and client titles", conflict.Fields)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestApplySyncUpdatesChecklistItem(t *testing.T) {
+	s, mock := newTestService(t)
+	base := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "checklist_items" WHERE id = \$1 .* FOR UPDATE`).
+		WithArgs("item-1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "task_id", "text", "updated_at"}).
+			AddRow("item-1", "task-1", "Reproduce", base.Add(500*time.Nanosecond)))
+	expectLockedTask(mock, "task-1", "user-1", "Fix login", base)
+	mock.ExpectExec(`UPDATE "checklist_items" SET`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectChecklistProgress(mock, 1, 1)
+
+	done := true
+	resp, err := s.ApplySync(context.Background(), ApplyRequest{Mutations: []Mutation{
+		{ID: "m1", Type: SyncTypeChecklistItem, Op: MutationUpdate, TargetID: "item-1", BaseUpdatedAt: base,
+			ChecklistItem: &UpdateChecklistItemRequest{Done: &done}},
+	}}, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Applied != 1 || resp.Results[0].ChecklistItem == nil || !resp.Results[0].ChecklistItem.Done {
+		t.Fatalf("results = %+v, want the item applied as done", resp.Results)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestApplyStateFollowsBatchChanges(t *testing.T) {
+	base := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	saved := base.Add(time.Second)
+	state := &applyState{rebased: map[string][2]time.Time{"task-1": {base, saved}}}
+
+	if !state.current("task-1", base, saved) {
+		t.Error("a second change made against the version before this batch should apply")
+	}
+	if state.current("task-1", base.Add(-time.Hour), saved) {
+		t.Error("a change made against an older version should conflict")
+	}
+	if !state.current("task-2", base, base.Add(400*time.Nanosecond)) {
+		t.Error("versions within a microsecond should match")
+	}
+}
@@ -0,0 +1,112 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+	"gorm.io/gorm"
+)
+
+func (s *Service) CreateTemplate(ctx context.Context, req CreateTemplateRequest, userID string) (*TaskTemplate, error) {
+	template := &TaskTemplate{
+		ID:              uuid.New().String(),
+		Name:            req.Name,
+		Title:           req.Title,
+		Description:     req.Description,
+		Priority:        models.TaskPriority(req.Priority),
+		Project:         req.Project,
+		EstimatedEffort: req.EstimatedEffort,
+		DueInDays:       req.DueInDays,
+		Checklist:       req.Checklist,
+		CreatedBy:       userID,
+	}
+	if !isValidPriority(template.Priority) {
+		return nil, ErrInvalidPriority
+	}
+
+	if err := s.db.WithContext(ctx).Create(template).Error; err != nil {
+		return nil, fmt.Errorf("failed to create template: %w", err)
+	}
+	return template, nil
+}
+
+func (s *Service) ListTemplates(ctx context.Context) ([]TaskTemplate, error) {
+	templates := []TaskTemplate{}
+	if err := s.db.WithContext(ctx).Order("name ASC").Find(&templates).Error; err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// CreateTaskFromTemplate creates a pending task with the template's
+// content. Without a due date in req, the task is due the template's
+// DueInDays from now, in working days of its project's calendar.
+func (s *Service) CreateTaskFromTemplate(ctx context.Context, templateID string, req InstantiateTaskRequest, userID string) (*TaskResponse, error) {
+	var template TaskTemplate
+	if err := s.db.WithContext(ctx).First(&template, "id = ?", templateID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTemplateNotFound
+		}
+		return nil, err
+	}
+
+	dueDate := req.DueDate
+	if dueDate == nil && template.DueInDays > 0 {
+		due, err := s.DueIn(ctx, template.Project, template.DueInDays)
+		if err != nil {
+			return nil, err
+		}
+		dueDate = &due
+	}
+	if dueDate == nil {
+		return nil, ErrDueDateRequired
+	}
+
+	return s.CreateTask(ctx, CreateTaskRequest{
+		Title:           template.Title,
+		Description:     template.Description,
+		Priority:        string(template.Priority),
+		AssignedTo:      req.AssignedTo,
+		AssigneeIDs:     req.AssigneeIDs,
+		DueDate:         *dueDate,
+		Project:         template.Project,
+		EstimatedEffort: template.EstimatedEffort,
+		Checklist:       template.Checklist,
+	}, userID)
+}
+
+// CloneTask creates a pending copy of a task's content with a new due
+// date. Checklist items are copied unchecked; assignees, time entries and
+// attachments are not copied.
+func (s *Service) CloneTask(ctx context.Context, taskID string, req InstantiateTaskRequest, userID string) (*TaskResponse, error) {
+	if req.DueDate == nil {
+		return nil, ErrDueDateRequired
+	}
+
+	var source Task
+	err := s.db.WithContext(ctx).
+		Preload("Checklist", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC, created_at ASC") }).
+		First(&source, "id = ?", taskID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		return nil, err
+	}
+
+	return s.CreateTask(ctx, CreateTaskRequest{
+		Title:           source.Title,
+		Description:     source.Description,
+		Priority:        string(source.Priority),
+		AssignedTo:      req.AssignedTo,
+		AssigneeIDs:     req.AssigneeIDs,
+		DueDate:         *req.DueDate,
+		Project:         source.Project,
+		EstimatedEffort: source.EstimatedEffort,
+		Checklist:       checklistTexts(source.Checklist),
+		DeadlineType:    string(source.DeadlineType),
+	}, userID)
+}
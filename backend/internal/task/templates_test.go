@@ -0,0 +1,163 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"go.uber.org/zap"
+)
+
+func expectTemplate(mock sqlmock.Sqlmock, templateID string, dueInDays int) {
+	mock.ExpectQuery(`SELECT \* FROM "task_templates" WHERE id = \$1`).
+		WithArgs(templateID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "title", "description", "priority", "project", "estimated_effort", "due_in_days"}).
+			AddRow(templateID, "Weekly report", "Write weekly report", "Summarise the week", "medium", "ops", 2, dueInDays))
+}
+
+func expectCreateTask(mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "tasks"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("new-task"))
+	mock.ExpectExec(`DELETE FROM "task_assignees"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+}
+
+func TestCreateTaskFromTemplateUsesDueInDays(t *testing.T) {
+	s, mock := newTestService(t)
+	expectTemplate(mock, "tpl-1", 3)
+	expectCalendar(mock, "ops", "")
+	expectFieldSchema(mock, "ops", "")
+	expectCalendar(mock, "ops", "")
+	expectCreateTask(mock)
+
+	resp, err := s.CreateTaskFromTemplate(context.Background(), "tpl-1", InstantiateTaskRequest{}, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	task := resp.Task
+	if task.Title != "Write weekly report" || task.Project != "ops" || task.EstimatedEffort != 2 || task.Status != "pending" {
+		t.Fatalf("task = %+v, want the template's content", task)
+	}
+	if due := time.Until(task.DueDate); due < 71*time.Hour || due > 72*time.Hour {
+		t.Fatalf("due in %s, want 3 days", due)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCreateTaskFromTemplateRequiresDueDate(t *testing.T) {
+	s, mock := newTestService(t)
+	expectTemplate(mock, "tpl-1", 0)
+
+	_, err := s.CreateTaskFromTemplate(context.Background(), "tpl-1", InstantiateTaskRequest{}, "user-1")
+	if !errors.Is(err, ErrDueDateRequired) {
+		t.Fatalf("err = %v, want ErrDueDateRequired", err)
+	}
+}
+
+func TestCloneTaskCopiesContentWithNewDueDate(t *testing.T) {
+	s, mock := newTestService(t)
+	mock.ExpectQuery(`SELECT \* FROM "tasks" WHERE id = \$1`).
+		WithArgs("task-1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "description", "status", "priority", "project", "created_by"}).
+			AddRow("task-1", "Fix login", "Users are logged out", "completed", "high", "web", "user-2"))
+	mock.ExpectQuery(`SELECT \* FROM "checklist_items" WHERE "checklist_items"."task_id" = \$1 .* ORDER BY position ASC`).
+		WithArgs("task-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "task_id", "text", "done", "position"}).
+			AddRow("item-1", "task-1", "Reproduce", true, 0).
+			AddRow("item-2", "task-1", "Add a test", false, 1))
+	expectFieldSchema(mock, "web", "")
+	expectCalendar(mock, "web", "")
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "tasks"`).WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow("pending"))
+	mock.ExpectQuery(`INSERT INTO "checklist_items"`).
+		WillReturnRows(sqlmock.NewRows([]string{"done"}).AddRow(false).AddRow(false))
+	mock.ExpectExec(`DELETE FROM "task_assignees"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	due := time.Now().Add(48 * time.Hour)
+	resp, err := s.CloneTask(context.Background(), "task-1", InstantiateTaskRequest{DueDate: &due}, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	task := resp.Task
+	if task.ID == "task-1" || task.Title != "Fix login" || task.Priority != "high" || task.Project != "web" {
+		t.Fatalf("clone = %+v, want a new task with the source's content", task)
+	}
+	if task.Status != "pending" || task.CreatedBy != "user-1" || !task.DueDate.Equal(due) {
+		t.Fatalf("clone = %+v, want a pending task of user-1 due at %s", task, due)
+	}
+	if len(task.Checklist) != 2 || task.Checklist[0].Text != "Reproduce" || task.Checklist[0].Done || task.Checklist[0].TaskID != task.ID {
+		t.Fatalf("checklist = %+v, want the source's items unchecked on the clone", task.Checklist)
+	}
+	if resp.ChecklistItems != 2 || resp.PercentComplete != 0 {
+		t.Fatalf("progress = %d items at %d%%, want 2 at 0%%", resp.ChecklistItems, resp.PercentComplete)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInstantiateTaskHandlerStatusCodes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		path   string
+		body   string
+		expect func(sqlmock.Sqlmock)
+		want   int
+	}{
+		{
+			name: "unknown template",
+			path: "/tasks/from-template/tpl-1",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT \* FROM "task_templates"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			want: http.StatusNotFound,
+		},
+		{
+			name:   "clone without due date",
+			path:   "/tasks/task-1/clone",
+			body:   `{}`,
+			expect: func(sqlmock.Sqlmock) {},
+			want:   http.StatusBadRequest,
+		},
+		{
+			name: "clone of unknown task",
+			path: "/tasks/task-1/clone",
+			body: `{"due_date":"` + time.Now().Add(time.Hour).Format(time.RFC3339) + `"}`,
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT \* FROM "tasks"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			want: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock := newTestService(t)
+			tt.expect(mock)
+
+			h := NewHandler(s, zap.NewNop())
+			router := gin.New()
+			router.Use(common.ErrorHandler(zap.NewNop()))
+			router.Use(func(c *gin.Context) { c.Set("user_id", "user-1") })
+			router.POST("/tasks/:id/clone", h.CloneTask)
+			router.POST("/tasks/from-template/:id", h.CreateTaskFromTemplate)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body)))
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d (body %s)", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func (s *Service) findTask(ctx context.Context, taskID string) (*Task, error) {
+	task := &Task{}
+	if err := s.db.WithContext(ctx).First(task, "id = ?", taskID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		return nil, err
+	}
+	return task, nil
+}
+
+// StartTimer opens a running time entry for the user on the given task.
+// The partial unique index on running entries rejects a second concurrent
+// start, so there is no check-then-insert window.
+func (s *Service) StartTimer(ctx context.Context, taskID string, userID string) (*TimeEntry, error) {
+	if _, err := s.findTask(ctx, taskID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	entry := &TimeEntry{
+		ID:        uuid.New().String(),
+		TaskID:    taskID,
+		UserID:    userID,
+		StartedAt: now,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.db.WithContext(ctx).Create(entry).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, ErrTimerRunning
+		}
+		return nil, fmt.Errorf("failed to start timer: %w", err)
+	}
+
+	return entry, nil
+}
+
+// StopTimer closes the user's running time entry on the given task
+func (s *Service) StopTimer(ctx context.Context, taskID string, userID string) (*TimeEntry, error) {
+	var entry TimeEntry
+	err := s.db.WithContext(ctx).Where("task_id = ? AND user_id = ? AND ended_at IS NULL", taskID, userID).
+		Order("started_at desc").
+		First(&entry).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTimerNotRunning
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	entry.EndedAt = &now
+	entry.DurationSeconds = int64(now.Sub(entry.StartedAt).Seconds())
+	entry.UpdatedAt = now
+
+	if err := s.db.WithContext(ctx).Save(&entry).Error; err != nil {
+		return nil, fmt.Errorf("failed to stop timer: %w", err)
+	}
+
+	return &entry, nil
+}
+
+// LogWork records a manual worklog entry against the task
+func (s *Service) LogWork(ctx context.Context, taskID string, req WorklogRequest, userID string) (*TimeEntry, error) {
+	if _, err := s.findTask(ctx, taskID); err != nil {
+		return nil, err
+	}
+
+	if req.DurationMinutes <= 0 || req.StartedAt.After(time.Now()) {
+		return nil, ErrInvalidWorklog
+	}
+
+	duration := time.Duration(req.DurationMinutes) * time.Minute
+	endedAt := req.StartedAt.Add(duration)
+	entry := &TimeEntry{
+		ID:              uuid.New().String(),
+		TaskID:          taskID,
+		UserID:          userID,
+		StartedAt:       req.StartedAt,
+		EndedAt:         &endedAt,
+		DurationSeconds: int64(duration.Seconds()),
+		Note:            req.Note,
+		Manual:          true,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+	if err := s.db.WithContext(ctx).Create(entry).Error; err != nil {
+		return nil, fmt.Errorf("failed to log work: %w", err)
+	}
+
+	return entry, nil
+}
+
+// GetTaskTimeSummary returns all time entries for a task with per-user
+// totals. Only the task's creator and assignees may see it.
+func (s *Service) GetTaskTimeSummary(ctx context.Context, taskID string, userID string) (*TaskTimeSummary, error) {
+	task := &Task{}
+	if err := s.db.WithContext(ctx).Preload("Assignees").First(task, "id = ?", taskID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		return nil, err
+	}
+	if !s.canModifyTask(userID, task) {
+		return nil, ErrUnauthorized
+	}
+
+	summary := &TaskTimeSummary{TaskID: taskID}
+	if err := s.db.WithContext(ctx).Where("task_id = ?", taskID).
+		Order("started_at desc").
+		Find(&summary.Entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to list time entries: %w", err)
+	}
+
+	if err := s.db.WithContext(ctx).Model(&TimeEntry{}).
+		Select("user_id, COALESCE(SUM(duration_seconds), 0) AS total_seconds").
+		Where("task_id = ? AND ended_at IS NOT NULL", taskID).
+		Group("user_id").
+		Scan(&summary.ByUser).Error; err != nil {
+		return nil, fmt.Errorf("failed to summarize time entries: %w", err)
+	}
+
+	for _, total := range summary.ByUser {
+		summary.TotalSeconds += total.TotalSeconds
+	}
+
+	return summary, nil
+}
+
+// GetUserTimeSummary returns the user's logged time grouped by task,
+// optionally restricted to entries started within [from, to]
+func (s *Service) GetUserTimeSummary(ctx context.Context, userID string, from, to *time.Time) (*UserTimeSummary, error) {
+	query := s.db.WithContext(ctx).Model(&TimeEntry{}).
+		Where("user_id = ? AND ended_at IS NOT NULL", userID)
+	if from != nil {
+		query = query.Where("started_at >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("started_at <= ?", *to)
+	}
+
+	summary := &UserTimeSummary{UserID: userID, From: from, To: to}
+	if err := query.
+		Select("task_id, COALESCE(SUM(duration_seconds), 0) AS total_seconds").
+		Group("task_id").
+		Scan(&summary.ByTask).Error; err != nil {
+		return nil, fmt.Errorf("failed to summarize user time: %w", err)
+	}
+
+	for _, total := range summary.ByTask {
+		summary.TotalSeconds += total.TotalSeconds
+	}
+
+	return summary, nil
+}
+
+func (s *Service) totalLoggedSeconds(ctx context.Context, taskID string) int64 {
+	var total int64
+	if err := s.db.WithContext(ctx).Model(&TimeEntry{}).
+		Select("COALESCE(SUM(duration_seconds), 0)").
+		Where("task_id = ? AND ended_at IS NOT NULL", taskID).
+		Scan(&total).Error; err != nil {
+		s.logger.Warn("Failed to load logged time", zap.String("task_id", taskID), zap.Error(err))
+	}
+	return total
+}
@@ -0,0 +1,67 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm/schema"
+)
+
+func TestRunningTimerIndexIsPartialUnique(t *testing.T) {
+	s, err := schema.Parse(&models.TimeEntry{}, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, index := range s.ParseIndexes() {
+		if index.Name != "idx_time_entries_running" {
+			continue
+		}
+		if index.Class != "UNIQUE" || index.Where == "" || len(index.Fields) != 2 {
+			t.Fatalf("index = class %q where %q fields %d, want unique partial index on two columns",
+				index.Class, index.Where, len(index.Fields))
+		}
+		return
+	}
+	t.Fatal("idx_time_entries_running not declared on TimeEntry")
+}
+
+func TestStartTimerMapsUniqueViolationToConflict(t *testing.T) {
+	s, mock := newTestService(t)
+
+	mock.ExpectQuery(`SELECT \* FROM "tasks"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("task-1"))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "time_entries"`).
+		WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: "idx_time_entries_running"})
+	mock.ExpectRollback()
+
+	_, err := s.StartTimer(context.Background(), "task-1", "user-1")
+	if !errors.Is(err, ErrTimerRunning) {
+		t.Fatalf("err = %v, want ErrTimerRunning", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetTaskTimeSummaryRequiresCreatorOrAssignee(t *testing.T) {
+	s, mock := newTestService(t)
+
+	mock.ExpectQuery(`SELECT \* FROM "tasks"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_by"}).AddRow("task-1", "owner"))
+	mock.ExpectQuery(`SELECT \* FROM "task_assignees"`).
+		WillReturnRows(sqlmock.NewRows([]string{"task_id", "user_id"}).AddRow("task-1", "assignee"))
+
+	if _, err := s.GetTaskTimeSummary(context.Background(), "task-1", "stranger"); err != ErrUnauthorized {
+		t.Fatalf("err = %v, want ErrUnauthorized", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,356 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultTransferWindow = 24 * time.Hour
+	// transferExpiryBatch bounds how many transfers one expiry pass reverts
+	transferExpiryBatch = 50
+)
+
+// TransferTask hands the task from its primary assignee to req.ToUserID
+// straight away, pending their acceptance. If they decline, or do not
+// answer within the window, the task goes back to the previous assignee.
+// Only the task's creator and assignees can transfer it, one transfer at
+// a time.
+func (s *Service) TransferTask(ctx context.Context, taskID string, req TransferTaskRequest, userID string) (*TaskTransfer, error) {
+	if err := s.validateAssignees(ctx, []string{req.ToUserID}); err != nil {
+		return nil, err
+	}
+	window := time.Duration(req.AcceptWithinHours) * time.Hour
+	if window == 0 {
+		window = common.AppConfig.TransferAcceptWindow
+	}
+	if window <= 0 {
+		window = defaultTransferWindow
+	}
+
+	var task *Task
+	var transfer *TaskTransfer
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if task, err = lockTaskWithAssignees(tx, taskID); err != nil {
+			return err
+		}
+		if !s.canModifyTask(userID, task) {
+			return ErrUnauthorized
+		}
+		if task.HardDeadlinePassed(time.Now()) && task.CreatedBy != userID {
+			return ErrHardDeadlinePassed
+		}
+		switch task.AssignedTo {
+		case "":
+			return fmt.Errorf("%w: the task has no assignee to hand it over", ErrInvalidTransfer)
+		case req.ToUserID:
+			return fmt.Errorf("%w: the user is already the task's assignee", ErrInvalidTransfer)
+		}
+
+		var pending int64
+		if err := tx.Model(&TaskTransfer{}).
+			Where("task_id = ? AND status = ?", taskID, models.TransferPending).
+			Count(&pending).Error; err != nil {
+			return err
+		}
+		if pending > 0 {
+			return ErrTransferPending
+		}
+
+		now := time.Now()
+		transfer = &TaskTransfer{
+			ID:          uuid.New().String(),
+			TaskID:      taskID,
+			FromUserID:  task.AssignedTo,
+			ToUserID:    req.ToUserID,
+			RequestedBy: userID,
+			Note:        req.Note,
+			Status:      models.TransferPending,
+			ExpiresAt:   now.Add(window),
+			CreatedAt:   now,
+		}
+		if err := tx.Create(transfer).Error; err != nil {
+			return err
+		}
+		if err := handOver(tx, task, transfer.FromUserID, transfer.ToUserID); err != nil {
+			return err
+		}
+		return recordAssignment(tx, AssignmentEvent{
+			TaskID:     taskID,
+			Event:      models.AssignmentTransferRequested,
+			ActorID:    optionalID(userID),
+			FromUserID: optionalID(transfer.FromUserID),
+			ToUserID:   optionalID(transfer.ToUserID),
+			TransferID: optionalID(transfer.ID),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publishAssignment(ctx, *task, transfer.FromUserID)
+	s.sendTransfer(*transfer, transfer.ToUserID)
+	return transfer, nil
+}
+
+// AcceptTransfer confirms the pending transfer of a task to userID
+func (s *Service) AcceptTransfer(ctx context.Context, taskID string, userID string) (*TaskTransfer, error) {
+	return s.answerTransfer(ctx, taskID, userID, models.TransferAccepted, "")
+}
+
+// DeclineTransfer refuses the pending transfer of a task to userID and
+// gives the task back to its previous assignee
+func (s *Service) DeclineTransfer(ctx context.Context, taskID string, reason string, userID string) (*TaskTransfer, error) {
+	return s.answerTransfer(ctx, taskID, userID, models.TransferDeclined, reason)
+}
+
+// answerTransfer closes the task's pending transfer with userID's answer
+// and lets the sender know. Transfers past their deadline can no longer be
+// answered; they are left for ExpireTransfers.
+func (s *Service) answerTransfer(ctx context.Context, taskID string, userID string, status models.TransferStatus, reason string) (*TaskTransfer, error) {
+	transfer := &TaskTransfer{}
+	var task *Task
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("task_id = ? AND status = ? AND expires_at > ?", taskID, models.TransferPending, time.Now()).
+			First(transfer).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNoPendingTransfer
+		}
+		if err != nil {
+			return err
+		}
+		if transfer.ToUserID != userID {
+			return ErrUnauthorized
+		}
+
+		if status == models.TransferDeclined {
+			if task, err = handBack(tx, transfer); err != nil {
+				return err
+			}
+		}
+		return closeTransfer(tx, transfer, status, reason, userID, task != nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if task != nil {
+		s.publishAssignment(ctx, *task, transfer.ToUserID)
+	}
+	s.sendTransfer(*transfer, transfer.RequestedBy)
+	return transfer, nil
+}
+
+// StartTransferExpiry hands expired transfers back every interval until
+// ctx is cancelled
+func (s *Service) StartTransferExpiry(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := s.ExpireTransfers(ctx); err != nil && ctx.Err() == nil {
+				s.logger.Error("Failed to expire task transfers", zap.Error(err))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// ExpireTransfers gives up to 50 tasks whose transfer was not answered in
+// time back to their previous assignee and lets the senders know. It
+// returns how many transfers expired. Transfers are claimed with
+// skip-locked row locks, so replicas never expire the same one.
+func (s *Service) ExpireTransfers(ctx context.Context) (int, error) {
+	var expired []TaskTransfer
+	var tasks []Task
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND expires_at <= ?", models.TransferPending, time.Now()).
+			Order("expires_at ASC").
+			Limit(transferExpiryBatch).
+			Find(&expired).Error; err != nil {
+			return err
+		}
+
+		for i := range expired {
+			task, err := handBack(tx, &expired[i])
+			if err != nil {
+				return err
+			}
+			if task != nil {
+				tasks = append(tasks, *task)
+			}
+			if err := closeTransfer(tx, &expired[i], models.TransferExpired, "", "", task != nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, task := range tasks {
+		s.publishAssignment(ctx, task)
+	}
+	for _, transfer := range expired {
+		s.forgetCounters(ctx, nil, transfer.ToUserID)
+		s.sendTransfer(transfer, transfer.RequestedBy)
+	}
+	return len(expired), nil
+}
+
+// AssignmentHistory lists the changes of a task's primary assignee for a
+// user who can see the task
+func (s *Service) AssignmentHistory(ctx context.Context, taskID string, userID string) (*AssignmentHistoryResponse, error) {
+	if _, err := s.findVisibleTask(ctx, taskID, userID); err != nil {
+		return nil, err
+	}
+
+	events := []AssignmentEvent{}
+	if err := s.db.WithContext(ctx).Where("task_id = ?", taskID).
+		Order("created_at ASC").
+		Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return &AssignmentHistoryResponse{TaskID: taskID, Events: events}, nil
+}
+
+// lockTaskWithAssignees locks the task row for the rest of tx and loads
+// its assignees
+func lockTaskWithAssignees(tx *gorm.DB, taskID string) (*Task, error) {
+	task := &Task{}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(task, "id = ?", taskID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		return nil, err
+	}
+	if err := tx.Where("task_id = ?", taskID).Find(&task.Assignees).Error; err != nil {
+		return nil, err
+	}
+	task.AfterFind(tx)
+	return task, nil
+}
+
+// handOver makes to the task's primary assignee in place of from, keeping
+// its other assignees
+func handOver(tx *gorm.DB, task *Task, from, to string) error {
+	ids := []string{to}
+	for _, id := range assigneeIDs(task) {
+		if id != from && id != to {
+			ids = append(ids, id)
+		}
+	}
+
+	task.UpdatedAt = time.Now()
+	if err := tx.Model(task).Omit(clause.Associations).Update("updated_at", task.UpdatedAt).Error; err != nil {
+		return err
+	}
+	return replaceAssignees(tx, task, to, ids)
+}
+
+// handBack gives the task of a transfer back to its previous assignee. It
+// returns nil, leaving the task alone, if the task was deleted or its
+// primary assignee changed again since the transfer.
+func handBack(tx *gorm.DB, transfer *TaskTransfer) (*Task, error) {
+	task, err := lockTaskWithAssignees(tx, transfer.TaskID)
+	if errors.Is(err, ErrTaskNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if task.AssignedTo != transfer.ToUserID {
+		return nil, nil
+	}
+	if err := handOver(tx, task, transfer.ToUserID, transfer.FromUserID); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// closeTransfer records the outcome of a transfer. actorID is empty when
+// the transfer expired, and handedBack says whether the task went back to
+// its previous assignee.
+func closeTransfer(tx *gorm.DB, transfer *TaskTransfer, status models.TransferStatus, reason string, actorID string, handedBack bool) error {
+	now := time.Now()
+	transfer.Status = status
+	transfer.Reason = reason
+	if actorID != "" {
+		transfer.RespondedAt = &now
+	}
+	if err := tx.Model(transfer).Updates(map[string]interface{}{
+		"status":       transfer.Status,
+		"reason":       transfer.Reason,
+		"responded_at": transfer.RespondedAt,
+	}).Error; err != nil {
+		return err
+	}
+
+	event := AssignmentEvent{
+		TaskID:     transfer.TaskID,
+		ActorID:    optionalID(actorID),
+		TransferID: optionalID(transfer.ID),
+		Reason:     reason,
+	}
+	switch status {
+	case models.TransferAccepted:
+		event.Event = models.AssignmentTransferAccepted
+	case models.TransferDeclined:
+		event.Event = models.AssignmentTransferDeclined
+	default:
+		event.Event = models.AssignmentTransferExpired
+	}
+	if handedBack {
+		event.FromUserID = optionalID(transfer.ToUserID)
+		event.ToUserID = optionalID(transfer.FromUserID)
+	}
+	return recordAssignment(tx, event)
+}
+
+func recordAssignment(tx *gorm.DB, event AssignmentEvent) error {
+	event.ID = uuid.New().String()
+	event.CreatedAt = time.Now()
+	return tx.Create(&event).Error
+}
+
+func optionalID(id string) *string {
+	if id == "" {
+		return nil
+	}
+	return &id
+}
+
+// publishAssignment announces a change of the task's assignees. previous
+// are users the task may have been taken from.
+func (s *Service) publishAssignment(ctx context.Context, task Task, previous ...string) {
+	s.publish(TaskUpdatedEvent(task))
+	s.forgetCounters(ctx, []Task{task}, previous...)
+	s.notifyFollowers(ctx, MessageTypeTaskUpdated, task)
+	s.membershipChanged()
+}
+
+// sendTransfer tells userID's WebSocket clients about the transfer
+func (s *Service) sendTransfer(transfer TaskTransfer, userID string) {
+	msg := TaskTransferEvent(transfer)
+	msg.recipients = map[string]bool{userID: true}
+	s.publish(msg)
+}
@@ -0,0 +1,153 @@
+package task
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+)
+
+// expectLockedAssignees expects lockTaskWithAssignees to find the task
+// with primary as its only assignee
+func expectLockedAssignees(mock sqlmock.Sqlmock, taskID, creator, primary string) {
+	mock.ExpectQuery(`SELECT \* FROM "tasks" WHERE id = \$1 .* FOR UPDATE`).
+		WithArgs(taskID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_by"}).AddRow(taskID, creator))
+	mock.ExpectQuery(`SELECT \* FROM "task_assignees" WHERE task_id = \$1`).
+		WithArgs(taskID).
+		WillReturnRows(sqlmock.NewRows([]string{"task_id", "user_id", "is_primary"}).AddRow(taskID, primary, true))
+}
+
+func expectHandOver(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(`UPDATE "tasks" SET "updated_at"`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM "task_assignees"`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO "task_assignees"`).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(time.Now()))
+}
+
+func expectPendingTransfer(mock sqlmock.Sqlmock, taskID, from, to string) {
+	mock.ExpectQuery(`SELECT \* FROM "task_transfers" WHERE task_id = \$1 AND status = \$2 AND expires_at > \$3 .* FOR UPDATE`).
+		WithArgs(taskID, models.TransferPending, sqlmock.AnyArg(), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "task_id", "from_user_id", "to_user_id", "requested_by", "status"}).
+			AddRow("transfer-1", taskID, from, to, from, models.TransferPending))
+}
+
+func TestTransferTaskHandsOverStraightAway(t *testing.T) {
+	s, mock := newTestService(t)
+
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "users"`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectBegin()
+	expectLockedAssignees(mock, "task-1", "user-1", "user-1")
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "task_transfers" WHERE task_id = \$1 AND status = \$2`).
+		WithArgs("task-1", models.TransferPending).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(`INSERT INTO "task_transfers"`).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(time.Now()))
+	expectHandOver(mock)
+	mock.ExpectQuery(`INSERT INTO "assignment_events"`).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(time.Now()))
+	mock.ExpectCommit()
+
+	transfer, err := s.TransferTask(context.Background(), "task-1", TransferTaskRequest{ToUserID: "user-2", AcceptWithinHours: 2}, "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transfer.FromUserID != "user-1" || transfer.ToUserID != "user-2" || transfer.Status != models.TransferPending {
+		t.Fatalf("transfer = %+v, want a pending transfer from user-1 to user-2", transfer)
+	}
+	if window := time.Until(transfer.ExpiresAt); window < time.Hour || window > 2*time.Hour {
+		t.Fatalf("transfer expires in %s, want 2h", window)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTransferTaskRejectsSecondPendingTransfer(t *testing.T) {
+	s, mock := newTestService(t)
+
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "users"`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectBegin()
+	expectLockedAssignees(mock, "task-1", "user-1", "user-1")
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "task_transfers"`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectRollback()
+
+	if _, err := s.TransferTask(context.Background(), "task-1", TransferTaskRequest{ToUserID: "user-3"}, "user-1"); err != ErrTransferPending {
+		t.Fatalf("err = %v, want ErrTransferPending", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeclineTransferHandsTaskBack(t *testing.T) {
+	s, mock := newTestService(t)
+
+	mock.ExpectBegin()
+	expectPendingTransfer(mock, "task-1", "user-1", "user-2")
+	expectLockedAssignees(mock, "task-1", "user-1", "user-2")
+	expectHandOver(mock)
+	mock.ExpectExec(`UPDATE "task_transfers" SET .*"status"=\$\d`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO "assignment_events"`).
+		WithArgs("task-1", models.AssignmentTransferDeclined, "user-2", "user-2", "user-1",
+			"transfer-1", "On leave", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(time.Now()))
+	mock.ExpectCommit()
+
+	transfer, err := s.DeclineTransfer(context.Background(), "task-1", "On leave", "user-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transfer.Status != models.TransferDeclined || transfer.Reason != "On leave" || transfer.RespondedAt == nil {
+		t.Fatalf("transfer = %+v, want it declined with the reason", transfer)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAcceptTransferOnlyByNewAssignee(t *testing.T) {
+	s, mock := newTestService(t)
+
+	mock.ExpectBegin()
+	expectPendingTransfer(mock, "task-1", "user-1", "user-2")
+	mock.ExpectRollback()
+
+	if _, err := s.AcceptTransfer(context.Background(), "task-1", "user-1"); err != ErrUnauthorized {
+		t.Fatalf("err = %v, want ErrUnauthorized", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExpireTransfersLeavesReassignedTasks(t *testing.T) {
+	s, mock := newTestService(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "task_transfers" WHERE status = \$1 AND expires_at <= \$2 ORDER BY expires_at ASC LIMIT \$3 FOR UPDATE SKIP LOCKED`).
+		WithArgs(models.TransferPending, sqlmock.AnyArg(), transferExpiryBatch).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "task_id", "from_user_id", "to_user_id", "requested_by", "status"}).
+			AddRow("transfer-1", "task-1", "user-1", "user-2", "user-1", models.TransferPending))
+	expectLockedAssignees(mock, "task-1", "user-1", "user-3")
+	mock.ExpectExec(`UPDATE "task_transfers"`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO "assignment_events"`).
+		WithArgs("task-1", models.AssignmentTransferExpired, nil, nil, nil,
+			"transfer-1", "", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(time.Now()))
+	mock.ExpectCommit()
+
+	expired, err := s.ExpireTransfers(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expired != 1 {
+		t.Fatalf("expired %d transfers, want 1", expired)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,60 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// languageTag accepts BCP 47 style tags such as "de", "pt-BR" or "zh-Hant"
+var languageTag = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// Translator is implemented by AI backends that can translate task content.
+// Implementations cache per language; the task service does not.
+type Translator interface {
+	TranslateTask(ctx context.Context, lang, title, description, userID string) (translatedTitle, translatedDescription string, err error)
+}
+
+// SetTranslator enables on-demand translation of task content
+func (s *Service) SetTranslator(translator Translator) {
+	s.translator = translator
+}
+
+// TranslateTask returns the task's title and description in lang,
+// translated on behalf of userID
+func (s *Service) TranslateTask(ctx context.Context, taskID, lang, userID string) (*TranslationResponse, error) {
+	if !languageTag.MatchString(lang) {
+		return nil, ErrInvalidLanguage
+	}
+	if s.translator == nil {
+		return nil, ErrTranslationUnavailable
+	}
+
+	var task Task
+	if err := s.db.WithContext(ctx).First(&task, "id = ?", taskID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		return nil, err
+	}
+
+	title, description, err := s.translator.TranslateTask(ctx, lang, task.Title, task.Description, userID)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		s.logger.Warn("Task translation failed", zap.String("task_id", taskID), zap.String("lang", lang), zap.Error(err))
+		return nil, fmt.Errorf("%w: %v", ErrTranslationUnavailable, err)
+	}
+
+	return &TranslationResponse{
+		TaskID:      task.ID,
+		Language:    lang,
+		Title:       title,
+		Description: description,
+	}, nil
+}
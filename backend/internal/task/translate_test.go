@@ -0,0 +1,108 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"go.uber.org/zap"
+)
+
+type fakeTranslator struct {
+	lang string
+	err  error
+}
+
+func (f *fakeTranslator) TranslateTask(ctx context.Context, lang, title, description, userID string) (string, string, error) {
+	f.lang = lang
+	if f.err != nil {
+		return "", "", f.err
+	}
+	return "[" + lang + "] " + title, "[" + lang + "] " + description, nil
+}
+
+func expectTask(mock sqlmock.Sqlmock, taskID string) {
+	mock.ExpectQuery(`SELECT \* FROM "tasks" WHERE id = \$1`).
+		WithArgs(taskID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "description"}).AddRow(taskID, "Fix login", "Users are logged out"))
+}
+
+func TestTranslateTask(t *testing.T) {
+	s, mock := newTestService(t)
+	translator := &fakeTranslator{}
+	s.SetTranslator(translator)
+	expectTask(mock, "task-1")
+
+	resp, err := s.TranslateTask(context.Background(), "task-1", "pt-BR", "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Title != "[pt-BR] Fix login" || resp.Description != "[pt-BR] Users are logged out" || resp.Language != "pt-BR" {
+		t.Fatalf("response = %+v, want translated title and description", resp)
+	}
+}
+
+func TestTranslateTaskRejectsInvalidLanguage(t *testing.T) {
+	s, _ := newTestService(t)
+	s.SetTranslator(&fakeTranslator{})
+
+	for _, lang := range []string{"", "english please", "d", "de_DE"} {
+		if _, err := s.TranslateTask(context.Background(), "task-1", lang, "user-1"); !errors.Is(err, ErrInvalidLanguage) {
+			t.Errorf("lang %q: err = %v, want ErrInvalidLanguage", lang, err)
+		}
+	}
+}
+
+func TestTranslateTaskHandlerStatusCodes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		translator Translator
+		lang       string
+		expect     func(sqlmock.Sqlmock)
+		want       int
+	}{
+		{name: "translated", translator: &fakeTranslator{}, lang: "de", expect: func(m sqlmock.Sqlmock) { expectTask(m, "task-1") }, want: http.StatusOK},
+		{name: "invalid language", translator: &fakeTranslator{}, lang: "xx_yy", want: http.StatusBadRequest},
+		{name: "no translator", lang: "de", want: http.StatusServiceUnavailable},
+		{
+			name: "unknown task", translator: &fakeTranslator{}, lang: "de",
+			expect: func(m sqlmock.Sqlmock) {
+				m.ExpectQuery(`SELECT \* FROM "tasks"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			want: http.StatusNotFound,
+		},
+		{
+			name: "provider failure", translator: &fakeTranslator{err: errors.New("quota")}, lang: "de",
+			expect: func(m sqlmock.Sqlmock) { expectTask(m, "task-1") },
+			want:   http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock := newTestService(t)
+			if tt.translator != nil {
+				s.SetTranslator(tt.translator)
+			}
+			if tt.expect != nil {
+				tt.expect(mock)
+			}
+
+			router := gin.New()
+			router.Use(common.ErrorHandler(zap.NewNop()))
+			router.POST("/tasks/:id/translate", NewHandler(s, zap.NewNop()).TranslateTask)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/tasks/task-1/translate?lang="+tt.lang, nil))
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d (body %s)", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"github.com/patrickmn/go-cache"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	maxTypeaheadResults = 10
+	maxTypeaheadQuery   = 100
+)
+
+// TypeaheadResult is the little a quick switcher needs to show a task
+type TypeaheadResult struct {
+	ID       string       `json:"id"`
+	Title    string       `json:"title"`
+	Status   TaskStatus   `json:"status"`
+	Priority TaskPriority `json:"priority"`
+	Project  string       `json:"project,omitempty"`
+	DueDate  time.Time    `json:"due_date"`
+}
+
+// TypeaheadCache keeps recent typeahead results for a short while. Get
+// reports found as false on a miss; Forget drops a user's results once
+// they may include tasks the user can no longer see.
+type TypeaheadCache interface {
+	Get(ctx context.Context, key string) (results []TypeaheadResult, found bool, err error)
+	Set(ctx context.Context, key string, results []TypeaheadResult) error
+	Forget(ctx context.Context, userID string) error
+}
+
+// SetTypeaheadCache enables caching of typeahead results
+func (s *Service) SetTypeaheadCache(cache TypeaheadCache) {
+	s.typeahead = cache
+}
+
+// Typeahead returns up to 10 open tasks visible to userID whose title
+// starts with or closely resembles query, prefix matches first. Results
+// are cached per user and query, so they can lag changes by the cache's
+// TTL. The title expressions match the trigram index created by the
+// database package.
+func (s *Service) Typeahead(ctx context.Context, query string, userID string) ([]TypeaheadResult, error) {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return nil, ErrEmptySearch
+	}
+	if utf8.RuneCountInString(query) > maxTypeaheadQuery {
+		return nil, ErrTypeaheadTooLong
+	}
+
+	key := typeaheadKeyPrefix(userID) + query
+	if s.typeahead != nil {
+		results, found, err := s.typeahead.Get(ctx, key)
+		if err != nil {
+			s.logger.Warn("Typeahead cache unavailable", zap.Error(err))
+		} else if found {
+			return results, nil
+		}
+	}
+
+	results := []TypeaheadResult{}
+	args := map[string]interface{}{"q": query, "prefix": common.EscapeLike(query) + "%", "user": userID}
+	err := s.db.WithContext(ctx).Model(&Task{}).
+		Select("id, title, status, priority, project, due_date").
+		Where("status <> 'completed'").
+		Where("lower(title) LIKE @prefix OR @q <% lower(title)", args).
+		Where(visibleTo, args).
+		Order(clause.OrderBy{Expression: clause.NamedExpr{
+			SQL:  "lower(title) LIKE @prefix DESC, word_similarity(@q, lower(title)) DESC, updated_at DESC",
+			Vars: []interface{}{args},
+		}}).
+		Limit(maxTypeaheadResults).
+		Scan(&results).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to search tasks: %w", err)
+	}
+
+	if s.typeahead != nil {
+		if err := s.typeahead.Set(ctx, key, results); err != nil {
+			s.logger.Warn("Failed to cache typeahead results", zap.Error(err))
+		}
+	}
+	return results, nil
+}
+
+func typeaheadKeyPrefix(userID string) string {
+	return userID + ":"
+}
+
+// visibleTo restricts a task query to what canViewTask allows @user to see
+const visibleTo = `(tasks.created_by = @user
+	OR EXISTS (SELECT 1 FROM task_assignees ta WHERE ta.task_id = tasks.id AND ta.user_id = @user)
+	OR EXISTS (SELECT 1 FROM task_watchers tw WHERE tw.task_id = tasks.id AND tw.user_id = @user)
+	OR (tasks.project <> '' AND EXISTS (
+		SELECT 1 FROM tasks p
+		WHERE p.project = tasks.project AND p.deleted_at IS NULL
+		AND (p.created_by = @user OR EXISTS (
+			SELECT 1 FROM task_assignees pa WHERE pa.task_id = p.id AND pa.user_id = @user)))))`
+
+// MemoryTypeaheadCache keeps typeahead results in process, for
+// single-replica deployments. Expired results are never served, but are
+// only dropped by Sweep.
+type MemoryTypeaheadCache struct {
+	cache *cache.Cache
+}
+
+func NewMemoryTypeaheadCache(ttl time.Duration) *MemoryTypeaheadCache {
+	return &MemoryTypeaheadCache{cache: cache.New(ttl, 0)}
+}
+
+func (c *MemoryTypeaheadCache) Get(ctx context.Context, key string) ([]TypeaheadResult, bool, error) {
+	cached, found := c.cache.Get(key)
+	if !found {
+		return nil, false, nil
+	}
+	return cached.([]TypeaheadResult), true, nil
+}
+
+func (c *MemoryTypeaheadCache) Set(ctx context.Context, key string, results []TypeaheadResult) error {
+	c.cache.SetDefault(key, results)
+	return nil
+}
+
+func (c *MemoryTypeaheadCache) Forget(ctx context.Context, userID string) error {
+	prefix := typeaheadKeyPrefix(userID)
+	for key := range c.cache.Items() {
+		if strings.HasPrefix(key, prefix) {
+			c.cache.Delete(key)
+		}
+	}
+	return nil
+}
+
+// Sweep drops the expired results
+func (c *MemoryTypeaheadCache) Sweep(context.Context) (int, error) {
+	before := c.cache.ItemCount()
+	c.cache.DeleteExpired()
+	// Results set meanwhile can make the difference negative
+	return max(before-c.cache.ItemCount(), 0), nil
+}
+
+// RedisTypeaheadCache shares typeahead results across replicas as JSON
+// values that expire after the TTL
+type RedisTypeaheadCache struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+func NewRedisTypeaheadCache(client *redis.Client, ttl time.Duration) *RedisTypeaheadCache {
+	return &RedisTypeaheadCache{client: client, ttl: ttl}
+}
+
+func (c *RedisTypeaheadCache) Get(ctx context.Context, key string) ([]TypeaheadResult, bool, error) {
+	data, err := c.client.Get(ctx, "typeahead:"+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	var results []TypeaheadResult
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, false, err
+	}
+	return results, true, nil
+}
+
+func (c *RedisTypeaheadCache) Set(ctx context.Context, key string, results []TypeaheadResult) error {
+	data, err := json.Marshal(results)
+	if err != nil {
+		return err
+	}
+	return c.client.Set(ctx, "typeahead:"+key, data, c.ttl).Err()
+}
+
+// Forget deletes the user's results, found with SCAN so Redis is not
+// blocked
+func (c *RedisTypeaheadCache) Forget(ctx context.Context, userID string) error {
+	iter := c.client.Scan(ctx, 0, "typeahead:"+typeaheadKeyPrefix(userID)+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		if err := c.client.Del(ctx, iter.Val()).Err(); err != nil {
+			return err
+		}
+	}
+	return iter.Err()
+}
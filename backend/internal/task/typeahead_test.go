@@ -0,0 +1,45 @@
+package task
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestTypeaheadQueriesOpenVisibleTasksAndCaches(t *testing.T) {
+	s, mock := newTestService(t)
+	s.SetTypeaheadCache(NewMemoryTypeaheadCache(time.Minute))
+
+	mock.ExpectQuery(`SELECT id, title, status, priority, project, due_date FROM "tasks" `+
+		`WHERE status <> 'completed' AND \(lower\(title\) LIKE \$1 OR \$2 <% lower\(title\)\) AND \(\(tasks.created_by = \$3.*`+
+		`AND "tasks"."deleted_at" IS NULL `+
+		`ORDER BY lower\(title\) LIKE \$\d+ DESC, word_similarity\(\$\d+, lower\(title\)\) DESC, updated_at DESC LIMIT \$\d+`).
+		WithArgs(`50\%\_off%`, "50%_off", "user-1", "user-1", "user-1", "user-1", "user-1", `50\%\_off%`, "50%_off", maxTypeaheadResults).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "status"}).AddRow("task-1", "50%_off banner", "pending"))
+
+	for i := 0; i < 2; i++ {
+		results, err := s.Typeahead(context.Background(), " 50%_OFF ", "user-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 1 || results[0].ID != "task-1" {
+			t.Fatalf("results = %+v, want task-1", results)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTypeaheadValidatesQuery(t *testing.T) {
+	s, _ := newTestService(t)
+	if _, err := s.Typeahead(context.Background(), "  ", "user-1"); err != ErrEmptySearch {
+		t.Fatalf("err = %v, want ErrEmptySearch", err)
+	}
+	if _, err := s.Typeahead(context.Background(), strings.Repeat("a", maxTypeaheadQuery+1), "user-1"); err != ErrTypeaheadTooLong {
+		t.Fatalf("err = %v, want ErrTypeaheadTooLong", err)
+	}
+}
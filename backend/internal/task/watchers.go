@@ -0,0 +1,99 @@
+package task
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm/clause"
+)
+
+// WatchTask makes userID a watcher of a task they can see. Watching twice
+// is not an error.
+func (s *Service) WatchTask(ctx context.Context, taskID, userID string) (*WatchersResponse, error) {
+	if _, err := s.findVisibleTask(ctx, taskID, userID); err != nil {
+		return nil, err
+	}
+
+	watcher := TaskWatcher{TaskID: taskID, UserID: userID}
+	if err := s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&watcher).Error; err != nil {
+		return nil, err
+	}
+	return s.watchers(ctx, taskID)
+}
+
+// UnwatchTask stops userID watching the task. Assignees and the creator
+// keep following it.
+func (s *Service) UnwatchTask(ctx context.Context, taskID, userID string) error {
+	if _, err := s.findTask(ctx, taskID); err != nil {
+		return err
+	}
+	return s.db.WithContext(ctx).
+		Where("task_id = ? AND user_id = ?", taskID, userID).
+		Delete(&TaskWatcher{}).Error
+}
+
+func (s *Service) ListWatchers(ctx context.Context, taskID, userID string) (*WatchersResponse, error) {
+	if _, err := s.findVisibleTask(ctx, taskID, userID); err != nil {
+		return nil, err
+	}
+	return s.watchers(ctx, taskID)
+}
+
+func (s *Service) watchers(ctx context.Context, taskID string) (*WatchersResponse, error) {
+	watchers := []TaskWatcher{}
+	if err := s.db.WithContext(ctx).Where("task_id = ?", taskID).
+		Order("created_at ASC").
+		Find(&watchers).Error; err != nil {
+		return nil, err
+	}
+	return &WatchersResponse{TaskID: taskID, Watchers: watchers}, nil
+}
+
+// TaskWatchers returns the IDs of the users watching a task, so
+// notifications reach them as well as its assignees
+func (s *Service) TaskWatchers(ctx context.Context, taskID string) ([]string, error) {
+	var ids []string
+	err := s.db.WithContext(ctx).Model(&TaskWatcher{}).
+		Where("task_id = ?", taskID).
+		Order("created_at ASC").
+		Pluck("user_id", &ids).Error
+	return ids, err
+}
+
+// taskFollowers returns everyone following a task: its creator, assignees
+// and watchers. It also works for a task that was just deleted.
+func (s *Service) taskFollowers(ctx context.Context, taskID string) ([]string, error) {
+	var ids []string
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT created_by FROM tasks WHERE id = @id
+		UNION SELECT user_id FROM task_assignees WHERE task_id = @id
+		UNION SELECT user_id FROM task_watchers WHERE task_id = @id`,
+		map[string]interface{}{"id": taskID}).
+		Scan(&ids).Error
+	return ids, err
+}
+
+// notifyFollowers sends a task_notification about a change to the task
+// to its followers' WebSocket clients and to clients subscribed to its
+// project. It skips the lookup when nobody is connected.
+func (s *Service) notifyFollowers(ctx context.Context, event MessageType, task Task) {
+	if s.ConnectedClients() == 0 {
+		return
+	}
+	ids, err := s.taskFollowers(ctx, task.ID)
+	if err != nil {
+		s.logger.Warn("Failed to look up task followers", zap.String("task_id", task.ID), zap.Error(err))
+		return
+	}
+	if len(ids) == 0 && task.Project == "" {
+		return
+	}
+
+	recipients := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		recipients[id] = true
+	}
+	msg := TaskNotificationEvent(TaskNotification{TaskID: task.ID, Event: event, Task: task})
+	msg.recipients = recipients
+	s.publish(msg)
+}
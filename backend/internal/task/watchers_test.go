@@ -0,0 +1,145 @@
+package task
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"go.uber.org/zap"
+)
+
+// dialHubAs connects a WebSocket client for userID and waits until the hub
+// has registered it
+func dialHubAs(t *testing.T, s *Service, userID string) *websocket.Conn {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(common.ErrorHandler(zap.NewNop()))
+	router.GET("/ws", func(c *gin.Context) { c.Set("user_id", userID) }, NewHandler(s, zap.NewNop()).WebSocket)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	before := s.ConnectedClients()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	deadline := time.Now().Add(time.Second)
+	for s.ConnectedClients() == before {
+		if time.Now().After(deadline) {
+			t.Fatal("client was never registered")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return conn
+}
+
+func readMessage(t *testing.T, conn *websocket.Conn) WebSocketMessage {
+	t.Helper()
+	var msg WebSocketMessage
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatal(err)
+	}
+	return msg
+}
+
+func TestNotifyFollowersOnlyReachesFollowers(t *testing.T) {
+	s, mock := newTestService(t)
+	watcher := dialHubAs(t, s, "user-1")
+	other := dialHubAs(t, s, "user-2")
+
+	mock.ExpectQuery(`SELECT created_by FROM tasks WHERE id = \$1\s+UNION SELECT user_id FROM task_assignees`).
+		WithArgs("task-1", "task-1", "task-1").
+		WillReturnRows(sqlmock.NewRows([]string{"created_by"}).AddRow("user-1").AddRow("user-3"))
+
+	s.notifyFollowers(context.Background(), MessageTypeTaskUpdated, Task{ID: "task-1"})
+	s.publish(NewWebSocketMessage(MessageTypeTaskCreated, Task{ID: "task-2"}))
+
+	got := map[MessageType]bool{readMessage(t, watcher).Type: true, readMessage(t, watcher).Type: true}
+	if !got[MessageTypeTaskNotification] || !got[MessageTypeTaskCreated] {
+		t.Fatalf("watcher got %v, want the notification and the broadcast", got)
+	}
+	if msg := readMessage(t, other); msg.Type != MessageTypeTaskCreated {
+		t.Fatalf("non-follower got %s, want the broadcast", msg.Type)
+	}
+	other.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	if _, _, err := other.ReadMessage(); err == nil {
+		t.Fatal("non-follower received the follower notification")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNotifyFollowersSkipsLookupWithoutClients(t *testing.T) {
+	s, mock := newTestService(t)
+
+	s.notifyFollowers(context.Background(), MessageTypeTaskDeleted, Task{ID: "task-1"})
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatchTaskIsIdempotent(t *testing.T) {
+	s, mock := newTestService(t)
+
+	expectModifiableTask(mock, "task-1", "user-1")
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "task_watchers" .* ON CONFLICT DO NOTHING`).
+		WithArgs("task-1", "user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"created_at"}))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`SELECT \* FROM "task_watchers" WHERE task_id = \$1 ORDER BY created_at ASC`).
+		WillReturnRows(sqlmock.NewRows([]string{"task_id", "user_id"}).AddRow("task-1", "user-1"))
+
+	resp, err := s.WatchTask(context.Background(), "task-1", "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Watchers) != 1 || resp.Watchers[0].UserID != "user-1" {
+		t.Fatalf("watchers = %+v, want user-1", resp.Watchers)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUnwatchMissingTaskReturns404(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s, mock := newTestService(t)
+	mock.ExpectQuery(`SELECT \* FROM "tasks"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	router := gin.New()
+	router.Use(common.ErrorHandler(zap.NewNop()))
+	router.DELETE("/tasks/:id/watch", NewHandler(s, zap.NewNop()).UnwatchTask)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/tasks/task-1/watch", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404 (body %s)", w.Code, w.Body.String())
+	}
+}
+
+func TestWatchTaskRequiresVisibility(t *testing.T) {
+	s, mock := newTestService(t)
+
+	expectModifiableTask(mock, "task-1", "user-1")
+	expectVisibility(mock, false)
+
+	if _, err := s.WatchTask(context.Background(), "task-1", "stranger"); err != ErrUnauthorized {
+		t.Fatalf("err = %v, want ErrUnauthorized", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -11,18 +11,98 @@ const (
 	MessageTypeTaskUpdated  MessageType = "task_updated"
 	MessageTypeTaskDeleted  MessageType = "task_deleted"
 	MessageTypeTaskAssigned MessageType = "task_assigned"
+
+	// MessageTypeTaskOverdue is sent once when an open task passes its due
+	// date, by the nightly overdue scan. The payload is the Task.
+	MessageTypeTaskOverdue MessageType = "task_overdue"
+
+	MessageTypeChecklistItemCreated MessageType = "checklist_item_created"
+	MessageTypeChecklistItemUpdated MessageType = "checklist_item_updated"
+	MessageTypeChecklistItemDeleted MessageType = "checklist_item_deleted"
+
+	// MessageTypeTaskNotification is sent only to the people following
+	// a task: its creator, assignees and watchers
+	MessageTypeTaskNotification MessageType = "task_notification"
+
+	// MessageTypeTaskTransfer is sent to the new assignee when a task is
+	// transferred to them, and to the sender when they answer or the
+	// transfer expires. The payload is the TaskTransfer.
+	MessageTypeTaskTransfer MessageType = "task_transfer"
+
+	// MessageTypeSubscription answers a client's subscribe or unsubscribe
+	// frame, and says when a project subscription was revoked. The payload
+	// is a SubscriptionStatus.
+	MessageTypeSubscription MessageType = "subscription"
+
+	// MessageTypePreferences answers a client's preferences frame. The
+	// payload is an EventPreferencesStatus.
+	MessageTypePreferences MessageType = "preferences"
+
+	// MessageTypePresenceJoin and MessageTypePresenceLeave are sent to
+	// clients following presence when a user's first connection opens and
+	// last one closes. The payload is a PresenceChange.
+	MessageTypePresenceJoin  MessageType = "presence_join"
+	MessageTypePresenceLeave MessageType = "presence_leave"
+
+	// MessageTypePresence answers a client's presence frame. The payload
+	// is a PresenceResponse.
+	MessageTypePresence MessageType = "presence"
+
+	// MessageTypeTaskViewing is sent to the users who have a task open
+	// when someone opens it, starts or stops editing it, or leaves it.
+	// The payload is a TaskViewing.
+	MessageTypeTaskViewing MessageType = "task_viewing"
+
+	// MessageTypeTaskLock is sent to a task's followers and viewers when
+	// its edit lock is taken, renewed or released. The payload is a
+	// TaskLockState.
+	MessageTypeTaskLock MessageType = "task_lock"
 )
 
+// WebSocketMessage is a task event. Timestamp is when the mutation
+// happened, so clients can measure delivery latency; they acknowledge
+// EventID with a ClientReceipt. SchemaVersion is the version of Payload,
+// see LatestSchemaVersion; build messages with the typed constructors in
+// events.go so it is set.
 type WebSocketMessage struct {
-	Type      MessageType `json:"type"`
-	Payload   interface{} `json:"payload"`
-	Timestamp time.Time   `json:"timestamp"`
+	EventID       string      `json:"event_id"`
+	SchemaVersion int         `json:"schema_version"`
+	Type          MessageType `json:"type"`
+	Payload       interface{} `json:"payload"`
+	Timestamp     time.Time   `json:"timestamp"`
+
+	// recipients limits delivery to clients of these users; nil sends to
+	// every client. Clients subscribed to project also get the message.
+	recipients map[string]bool
+	project    string
+	// presence limits delivery to clients that follow presence
+	presence bool
+}
+
+// TaskNotification tells a follower of a task that it changed. Event is
+// the broadcast message type of the change.
+type TaskNotification struct {
+	TaskID string      `json:"task_id"`
+	Event  MessageType `json:"event"`
+	Task   Task        `json:"task"`
+}
+
+// ReceiptMessageType is the type of a ClientReceipt
+const ReceiptMessageType = "receipt"
+
+// ClientReceipt is sent by clients when an event arrives. ReceivedAt is
+// the client's clock; without it the server's time of the receipt is used.
+type ClientReceipt struct {
+	Type       string     `json:"type"`
+	EventID    string     `json:"event_id"`
+	ReceivedAt *time.Time `json:"received_at"`
 }
 
 func NewWebSocketMessage(msgType MessageType, payload interface{}) WebSocketMessage {
 	return WebSocketMessage{
-		Type:      msgType,
-		Payload:   payload,
-		Timestamp: time.Now(),
+		SchemaVersion: LatestSchemaVersion,
+		Type:          msgType,
+		Payload:       payload,
+		Timestamp:     time.Now(),
 	}
 }
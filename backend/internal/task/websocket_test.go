@@ -0,0 +1,336 @@
+package task
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"go.uber.org/zap"
+)
+
+// dialHub connects a WebSocket client to s and waits until it is registered
+func dialHub(t *testing.T, s *Service) *websocket.Conn {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(common.ErrorHandler(zap.NewNop()))
+	router.GET("/ws", NewHandler(s, zap.NewNop()).WebSocket)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		s.clientsMux.RLock()
+		registered := len(s.clients)
+		s.clientsMux.RUnlock()
+		if registered > 0 {
+			return conn
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("client was never registered")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestShutdownDeliversQueuedMessagesThenCloses(t *testing.T) {
+	s := NewService(nil, zap.NewNop())
+	conn := dialHub(t, s)
+
+	s.publish(NewWebSocketMessage(MessageTypeTaskCreated, "task-1"))
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	var msg WebSocketMessage
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("queued message not delivered before close: %v", err)
+	}
+	if msg.Type != MessageTypeTaskCreated {
+		t.Fatalf("message type = %q, want %q", msg.Type, MessageTypeTaskCreated)
+	}
+
+	_, _, err := conn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Fatalf("read after shutdown = %v, want a going-away close frame", err)
+	}
+}
+
+func TestPublishAfterShutdownIsDropped(t *testing.T) {
+	s := NewService(nil, zap.NewNop())
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.publish(NewWebSocketMessage(MessageTypeTaskDeleted, "task-1"))
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("publish blocked after shutdown")
+	}
+
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatalf("second Shutdown: %v", err)
+	}
+}
+
+func TestRegisterAfterShutdownClosesClient(t *testing.T) {
+	s := NewService(nil, zap.NewNop())
+	if err := s.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(common.ErrorHandler(zap.NewNop()))
+	router.GET("/ws", NewHandler(s, zap.NewNop()).WebSocket)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Fatalf("read = %v, want a going-away close frame", err)
+	}
+}
+
+func TestShutdownHonoursContextDeadline(t *testing.T) {
+	s := NewService(nil, zap.NewNop())
+	// A writer that never finishes keeps the drain from completing
+	s.writers.Add(1)
+	defer s.writers.Done()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Shutdown = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestLoadSignals(t *testing.T) {
+	s := NewService(nil, zap.NewNop())
+	defer s.Shutdown(context.Background())
+
+	if got := s.ConnectedClients(); got != 0 {
+		t.Fatalf("connected clients = %d, want 0", got)
+	}
+	conn := dialHub(t, s)
+	if got := s.ConnectedClients(); got != 1 {
+		t.Fatalf("connected clients = %d, want 1", got)
+	}
+
+	s.publish(NewWebSocketMessage(MessageTypeTaskCreated, "task-1"))
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	var msg WebSocketMessage
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatal(err)
+	}
+
+	// Counters return to zero once the frame has been written
+	deadline := time.Now().Add(time.Second)
+	for s.BroadcastBacklog() != 0 || s.PendingWrites() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("backlog = %d, pending writes = %d after delivery, want 0", s.BroadcastBacklog(), s.PendingWrites())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestDeliveryObserverMeasuresFromMutation(t *testing.T) {
+	s := NewService(nil, zap.NewNop())
+	defer s.Shutdown(context.Background())
+	observed := make(chan time.Duration, 1)
+	s.SetDeliveryObserver(func(latency time.Duration) { observed <- latency })
+	conn := dialHub(t, s)
+
+	msg := NewWebSocketMessage(MessageTypeTaskCreated, "task-1")
+	msg.Timestamp = time.Now().Add(-time.Second)
+	s.publish(msg)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case latency := <-observed:
+		if latency < time.Second {
+			t.Fatalf("latency = %s, want it measured from the message timestamp", latency)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("delivery was never observed")
+	}
+}
+
+func TestSweepClientsDropsStaleConnections(t *testing.T) {
+	s, _ := newTestService(t)
+	fresh := dialHubAs(t, s, "user-1")
+	dialHubAs(t, s, "user-2")
+
+	s.clientsMux.Lock()
+	for _, client := range s.clients {
+		if client.userID == "user-2" {
+			client.projects = map[string]bool{"apollo": true}
+			client.lastSeen.Store(time.Now().Add(-staleClientAge - time.Second).UnixNano())
+		}
+	}
+	s.clientsMux.Unlock()
+
+	swept, err := s.SweepClients(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if swept != 1 || s.ConnectedClients() != 1 {
+		t.Fatalf("swept %d, %d left, want only the stale connection dropped", swept, s.ConnectedClients())
+	}
+	if subscribed := s.subscribedProjects(); len(subscribed) != 0 {
+		t.Fatalf("subscriptions = %v, want the stale connection's dropped", subscribed)
+	}
+
+	s.publish(NewWebSocketMessage(MessageTypeTaskCreated, "task-1"))
+	if msg := readMessage(t, fresh); msg.Type != MessageTypeTaskCreated {
+		t.Fatalf("message = %+v, want the live connection still served", msg)
+	}
+}
+
+func TestSlowClientIsDisconnected(t *testing.T) {
+	s := NewService(nil, zap.NewNop())
+	defer s.Shutdown(context.Background())
+	if err := s.SetSlowClientPolicy(1, SlowClientDisconnect); err != nil {
+		t.Fatal(err)
+	}
+	observed := make(chan SlowClientPolicy, 2)
+	s.SetSlowClientObserver(func(policy SlowClientPolicy) { observed <- policy })
+	conn := dialHub(t, s)
+
+	// Holding the write lock stalls the client's writer on its first
+	// message, so the second fills the queue and the third overflows it
+	s.clientsMux.RLock()
+	var client *wsClient
+	for _, c := range s.clients {
+		client = c
+	}
+	s.clientsMux.RUnlock()
+	client.mu.Lock()
+	s.publish(NewWebSocketMessage(MessageTypeTaskCreated, "task-1"))
+	deadline := time.Now().Add(time.Second)
+	for s.BroadcastBacklog() != 0 || s.MaxClientQueue() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("writer never took the first message")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	s.publish(NewWebSocketMessage(MessageTypeTaskCreated, "task-2"))
+	s.publish(NewWebSocketMessage(MessageTypeTaskCreated, "task-3"))
+
+	select {
+	case policy := <-observed:
+		if policy != SlowClientDisconnect {
+			t.Fatalf("policy = %s, want disconnect", policy)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("slow client never detected")
+	}
+	deadline = time.Now().Add(time.Second)
+	client.mu.Unlock()
+	for s.ConnectedClients() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("slow client is still connected")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseTryAgainLater) {
+				t.Fatalf("read = %v, want a try-again-later close frame", err)
+			}
+			break
+		}
+	}
+}
+
+func TestSlowClientDropsOldestMessage(t *testing.T) {
+	s := NewService(nil, zap.NewNop())
+	defer s.Shutdown(context.Background())
+	if err := s.SetSlowClientPolicy(2, SlowClientDropOldest); err != nil {
+		t.Fatal(err)
+	}
+	dropped := 0
+	s.SetSlowClientObserver(func(SlowClientPolicy) { dropped++ })
+
+	// A client without a writer keeps everything queued
+	client := &wsClient{send: make(chan WebSocketMessage, s.clientBuffer)}
+	for _, id := range []string{"task-1", "task-2", "task-3"} {
+		if !s.enqueue(client, NewWebSocketMessage(MessageTypeTaskCreated, id)) {
+			t.Fatal("client disconnected, want its oldest message dropped")
+		}
+	}
+
+	if dropped != 1 || s.PendingWrites() != 2 {
+		t.Fatalf("dropped %d with %d pending, want 1 dropped and 2 pending", dropped, s.PendingWrites())
+	}
+	for _, want := range []string{"task-2", "task-3"} {
+		if msg := <-client.send; msg.Payload != want {
+			t.Fatalf("queued %v, want %s", msg.Payload, want)
+		}
+	}
+}
+
+func TestSetSlowClientPolicyRejectsUnknownPolicy(t *testing.T) {
+	s := NewService(nil, zap.NewNop())
+	defer s.Shutdown(context.Background())
+	if err := s.SetSlowClientPolicy(10, "block"); err == nil {
+		t.Fatal("unknown policy accepted")
+	}
+}
+
+func TestPublishDropsOldestWhenBroadcastQueueIsFull(t *testing.T) {
+	// Without a broadcast loop nothing leaves the queue
+	s := &Service{broadcast: make(chan WebSocketMessage, 2), recent: newRecentEvents(recentEventsSize)}
+	dropped := 0
+	s.SetBroadcastDropObserver(func() { dropped++ })
+
+	done := make(chan struct{})
+	go func() {
+		for _, id := range []string{"task-1", "task-2", "task-3"} {
+			s.publish(NewWebSocketMessage(MessageTypeTaskCreated, id))
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("publish blocked on a full queue")
+	}
+
+	if dropped != 1 || s.BroadcastBacklog() != 2 {
+		t.Fatalf("dropped %d with a backlog of %d, want 1 dropped and 2 queued", dropped, s.BroadcastBacklog())
+	}
+	for _, want := range []string{"task-2", "task-3"} {
+		if msg := <-s.broadcast; msg.Payload != want {
+			t.Fatalf("queued %v, want %s", msg.Payload, want)
+		}
+	}
+}
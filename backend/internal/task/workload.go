@@ -0,0 +1,66 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	// WorkloadWindow is how far back Workload.CompletedRecently looks
+	WorkloadWindow = 30 * 24 * time.Hour
+	// maxCandidates caps how many users one assignment suggestion weighs
+	maxCandidates = 20
+)
+
+// AssignmentCandidates returns the workload of each user in userIDs, or of
+// up to 20 members of project when userIDs is empty. Unknown user IDs fail
+// with ErrInvalidAssignment.
+func (s *Service) AssignmentCandidates(ctx context.Context, project string, userIDs []string) ([]Workload, error) {
+	_, candidates := resolveAssignees("", userIDs)
+	if len(candidates) == 0 && project != "" {
+		if err := s.db.WithContext(ctx).Raw(`
+			SELECT user_id FROM (
+				SELECT t.created_by AS user_id FROM tasks t
+				WHERE t.project = @project AND t.deleted_at IS NULL
+				UNION
+				SELECT ta.user_id FROM task_assignees ta JOIN tasks t ON t.id = ta.task_id
+				WHERE t.project = @project AND t.deleted_at IS NULL
+			) members ORDER BY user_id LIMIT @limit`,
+			map[string]interface{}{"project": project, "limit": maxCandidates}).
+			Scan(&candidates).Error; err != nil {
+			return nil, fmt.Errorf("failed to load project members: %w", err)
+		}
+	}
+	if len(candidates) == 0 {
+		return []Workload{}, nil
+	}
+	if len(candidates) > maxCandidates {
+		return nil, fmt.Errorf("%w: at most %d candidates", ErrInvalidAssignment, maxCandidates)
+	}
+
+	workloads := []Workload{}
+	if err := s.db.WithContext(ctx).Raw(`
+		SELECT u.id AS user_id, u.email,
+			COUNT(t.id) FILTER (WHERE t.status <> 'completed') AS open_tasks,
+			COUNT(t.id) FILTER (WHERE t.status <> 'completed' AND t.priority = 'high') AS open_high,
+			COUNT(t.id) FILTER (WHERE t.status <> 'completed' AND t.priority = 'medium') AS open_medium,
+			COUNT(t.id) FILTER (WHERE t.status <> 'completed' AND t.priority = 'low') AS open_low,
+			COUNT(t.id) FILTER (WHERE t.status <> 'completed' AND t.due_date < @now) AS overdue,
+			COALESCE(SUM(t.estimated_effort) FILTER (WHERE t.status <> 'completed'), 0) AS open_effort,
+			COUNT(t.id) FILTER (WHERE t.completed_at >= @since) AS completed_recently
+		FROM users u
+		LEFT JOIN task_assignees ta ON ta.user_id = u.id
+		LEFT JOIN tasks t ON t.id = ta.task_id AND t.deleted_at IS NULL
+		WHERE u.id IN @users AND u.deleted_at IS NULL
+		GROUP BY u.id, u.email
+		ORDER BY u.id`,
+		map[string]interface{}{"users": candidates, "now": time.Now(), "since": time.Now().Add(-WorkloadWindow)}).
+		Scan(&workloads).Error; err != nil {
+		return nil, fmt.Errorf("failed to load member workload: %w", err)
+	}
+	if len(workloads) != len(candidates) {
+		return nil, ErrInvalidAssignment
+	}
+	return workloads, nil
+}
@@ -0,0 +1,198 @@
+package task
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+)
+
+// SlowClientPolicy is what the hub does with a client whose queue is full
+// when a message is broadcast to it
+type SlowClientPolicy string
+
+const (
+	// SlowClientDisconnect closes the connection with code 1013 (try
+	// again later); the client reconnects and catches up with a sync
+	SlowClientDisconnect SlowClientPolicy = "disconnect"
+	// SlowClientDropOldest drops the oldest queued message to make room,
+	// keeping the connection but losing events
+	SlowClientDropOldest SlowClientPolicy = "drop_oldest"
+)
+
+// defaultClientBuffer is how many messages a client queues by default
+const defaultClientBuffer = 256
+
+// SetSlowClientPolicy sets how many messages each client connecting from
+// now on may queue, and what happens to a client that falls that far
+// behind
+func (s *Service) SetSlowClientPolicy(buffer int, policy SlowClientPolicy) error {
+	if policy != SlowClientDisconnect && policy != SlowClientDropOldest {
+		return fmt.Errorf("unknown slow client policy %q", policy)
+	}
+	s.clientBuffer = max(buffer, 1)
+	s.slowClients = policy
+	return nil
+}
+
+// SetSlowClientObserver is told each time the slow client policy applies
+func (s *Service) SetSlowClientObserver(observe func(policy SlowClientPolicy)) {
+	s.observeSlowClient = observe
+}
+
+// MaxClientQueue is the longest queue of messages waiting for one client,
+// the client furthest behind
+func (s *Service) MaxClientQueue() int {
+	s.clientsMux.RLock()
+	defer s.clientsMux.RUnlock()
+	longest := 0
+	for _, client := range s.clients {
+		longest = max(longest, len(client.send))
+	}
+	return longest
+}
+
+// enqueue queues msg for client without blocking. With a full queue it
+// applies the slow client policy, and reports false if the client must be
+// disconnected. Callers hold clientsMux, so the client is still
+// registered.
+func (s *Service) enqueue(client *wsClient, msg WebSocketMessage) bool {
+	select {
+	case client.send <- msg:
+		s.pendingWrites.Add(1)
+		return true
+	default:
+	}
+
+	if s.observeSlowClient != nil {
+		s.observeSlowClient(s.slowClients)
+	}
+	if s.slowClients == SlowClientDisconnect {
+		return false
+	}
+	// The writer may take the oldest first, leaving room either way
+	select {
+	case <-client.send:
+		s.pendingWrites.Add(-1)
+	default:
+	}
+	select {
+	case client.send <- msg:
+		s.pendingWrites.Add(1)
+	default:
+	}
+	return true
+}
+
+// disconnectSlowClient drops a client that fell too far behind. The close
+// frame and close run in the background, as the client's writer may be
+// stuck writing to it.
+func (s *Service) disconnectSlowClient(conn *websocket.Conn) {
+	s.UnregisterClient(conn)
+	s.logger.Warn("Disconnected slow WebSocket client", zap.String("remote_addr", conn.RemoteAddr().String()))
+	go func() {
+		msg := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "client too slow")
+		_ = conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+		conn.Close()
+	}()
+}
+
+// writeLoop writes the messages queued for client to conn until the
+// client leaves the broadcast set, or the hub drains on shutdown. Batching
+// protocols collect the messages queued within protocolBatchWindow into
+// one frame.
+func (s *Service) writeLoop(conn *websocket.Conn, client *wsClient) {
+	defer s.writers.Done()
+	// Messages still queued when the client leaves are never written
+	defer func() {
+		for {
+			select {
+			case <-client.send:
+				s.pendingWrites.Add(-1)
+			default:
+				return
+			}
+		}
+	}()
+
+	for {
+		var batch []WebSocketMessage
+		select {
+		case msg := <-client.send:
+			batch = append(batch, msg)
+		case <-client.done:
+			return
+		case <-s.draining:
+			if batch = queued(client, nil); len(batch) > 0 {
+				s.writeBatch(conn, client, batch)
+			}
+			return
+		}
+
+		s.clientsMux.RLock()
+		batching := client.protocol.batch
+		s.clientsMux.RUnlock()
+		if batching {
+			batch = s.collect(client, batch)
+		}
+		if !s.writeBatch(conn, client, batch) {
+			return
+		}
+	}
+}
+
+// collect adds the messages queued for client within protocolBatchWindow
+// to batch, stopping early if the client leaves or the hub drains
+func (s *Service) collect(client *wsClient, batch []WebSocketMessage) []WebSocketMessage {
+	timer := time.NewTimer(protocolBatchWindow)
+	defer timer.Stop()
+	for {
+		select {
+		case msg := <-client.send:
+			batch = append(batch, msg)
+		case <-timer.C:
+			return batch
+		case <-client.done:
+			return batch
+		case <-s.draining:
+			return queued(client, batch)
+		}
+	}
+}
+
+// queued adds every message already queued for client to batch
+func queued(client *wsClient, batch []WebSocketMessage) []WebSocketMessage {
+	for {
+		select {
+		case msg := <-client.send:
+			batch = append(batch, msg)
+		default:
+			return batch
+		}
+	}
+}
+
+// writeBatch writes batch to conn in the client's protocol. A failed write
+// unregisters the client and reports false.
+func (s *Service) writeBatch(conn *websocket.Conn, client *wsClient, batch []WebSocketMessage) bool {
+	s.clientsMux.RLock()
+	proto := client.protocol
+	s.clientsMux.RUnlock()
+
+	client.mu.Lock()
+	err := proto.write(conn, batch)
+	client.mu.Unlock()
+	s.pendingWrites.Add(-int64(len(batch)))
+	if err != nil {
+		s.logger.Error("Failed to send message", zap.Error(err))
+		s.UnregisterClient(conn)
+		return false
+	}
+	if s.observeDelivery != nil {
+		for _, m := range batch {
+			s.observeDelivery(time.Since(m.Timestamp))
+		}
+	}
+	return true
+}
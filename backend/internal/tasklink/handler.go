@@ -0,0 +1,192 @@
+package tasklink
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"go.uber.org/zap"
+)
+
+// formPage is the minimal page a scanned link opens. The token is posted
+// back in the body, so it never lands in a logged path.
+var formPage = template.Must(template.New("form").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>New task</title>
+<style>
+body { font-family: sans-serif; max-width: 30rem; margin: 1.5rem auto; padding: 0 1rem; }
+label { display: block; margin-top: 1rem; } input, textarea { width: 100%; box-sizing: border-box; font-size: 1rem; }
+button { margin-top: 1rem; font-size: 1rem; padding: .5rem 1rem; } .error { color: #cf222e; }
+</style>
+</head>
+<body>
+{{if .Created}}<h1>Task created</h1>
+<p>{{.Created.Title}} was added.</p>
+{{else if .Form}}<h1>{{.Form.Name}}</h1>
+<p>New task in {{.Form.Project}}</p>
+{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+<form method="post" action="{{.Action}}">
+<input type="hidden" name="token" value="{{.Token}}">
+<label>Title<input name="title" maxlength="255" placeholder="{{.Form.TemplateTitle}}"{{if not .Form.TemplateTitle}} required{{end}}></label>
+<label>Description<textarea name="description" rows="5"></textarea></label>
+<label>Your name or contact<input name="reporter" maxlength="200"></label>
+<button type="submit">Create task</button>
+</form>
+{{else}}<h1>Link not available</h1>
+<p class="error">{{.Error}}</p>
+{{end}}</body>
+</html>
+`))
+
+type formData struct {
+	Action  string
+	Token   string
+	Form    *LinkForm
+	Created *SubmitResponse
+	Error   string
+}
+
+type Handler struct {
+	service *Service
+	logger  *zap.Logger
+}
+
+func NewHandler(service *Service, logger *zap.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *Handler) CreateLink(c *gin.Context) {
+	var req CreateLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(common.NewValidationError(err))
+		return
+	}
+
+	resp, err := h.service.CreateLink(c.Request.Context(), req, c.GetString("user_id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNotProjectMember):
+			_ = c.Error(common.NewForbiddenError(err.Error()))
+		case errors.Is(err, ErrTemplateNotFound):
+			_ = c.Error(common.NewInvalidInputError(err.Error()))
+		default:
+			h.logger.Error("Failed to create task link", zap.Error(err))
+			_ = c.Error(common.NewInternalServerError("failed to create task link"))
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+func (h *Handler) ListLinks(c *gin.Context) {
+	links, err := h.service.ListLinks(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		h.logger.Error("Failed to list task links", zap.Error(err))
+		_ = c.Error(common.NewInternalServerError("failed to list task links"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"links": links})
+}
+
+func (h *Handler) RevokeLink(c *gin.Context) {
+	if err := h.service.RevokeLink(c.Request.Context(), c.Param("id"), c.GetString("user_id")); err != nil {
+		if errors.Is(err, ErrLinkNotFound) {
+			_ = c.Error(common.NewNotFoundError("task link not found"))
+			return
+		}
+		h.logger.Error("Failed to revoke task link", zap.Error(err))
+		_ = c.Error(common.NewInternalServerError("failed to revoke task link"))
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// Form serves the form of the link in ?token=, or JSON describing it when
+// that is asked for
+func (h *Handler) Form(c *gin.Context) {
+	token := c.Query("token")
+	form, err := h.service.Form(c.Request.Context(), token)
+	status := http.StatusOK
+	data := formData{Action: c.Request.URL.Path, Token: token, Form: form}
+	if err != nil {
+		status, data.Error = h.submitError(err)
+	}
+
+	if c.NegotiateFormat(gin.MIMEHTML, gin.MIMEJSON) == gin.MIMEJSON {
+		if err != nil {
+			_ = c.Error(common.NewError(status, data.Error))
+			return
+		}
+		c.JSON(status, form)
+		return
+	}
+	h.render(c, status, data)
+}
+
+// Submit creates a task from the form, or from JSON. Browsers get the page
+// back, with the outcome.
+func (h *Handler) Submit(c *gin.Context) {
+	html := c.ContentType() != gin.MIMEJSON
+	var req SubmitRequest
+	if err := c.ShouldBind(&req); err != nil {
+		if html {
+			h.render(c, http.StatusBadRequest, formData{Error: err.Error()})
+			return
+		}
+		_ = c.Error(common.NewInvalidInputError(err.Error()))
+		return
+	}
+
+	resp, err := h.service.Submit(c.Request.Context(), req)
+	if err != nil {
+		status, message := h.submitError(err)
+		if !html {
+			_ = c.Error(common.NewError(status, message))
+			return
+		}
+		// Show the form again, so the holder can fix the submission
+		form, _ := h.service.Form(c.Request.Context(), req.Token)
+		h.render(c, status, formData{Action: c.Request.URL.Path, Token: req.Token, Form: form, Error: message})
+		return
+	}
+
+	if !html {
+		c.JSON(http.StatusCreated, resp)
+		return
+	}
+	h.render(c, http.StatusCreated, formData{Created: resp})
+}
+
+func (h *Handler) submitError(err error) (int, string) {
+	switch {
+	case errors.Is(err, ErrLinkNotFound), errors.Is(err, ErrTemplateNotFound):
+		return http.StatusNotFound, ErrLinkNotFound.Error()
+	case errors.Is(err, ErrInvalidSubmission):
+		return http.StatusBadRequest, err.Error()
+	default:
+		h.logger.Error("Failed to create task through link", zap.Error(err))
+		return http.StatusInternalServerError, "failed to create task"
+	}
+}
+
+func (h *Handler) render(c *gin.Context, status int, data formData) {
+	var page bytes.Buffer
+	if err := formPage.Execute(&page, data); err != nil {
+		h.logger.Error("Failed to render task link form", zap.Error(err))
+		c.String(http.StatusInternalServerError, "failed to render form")
+		return
+	}
+	c.Data(status, "text/html; charset=utf-8", page.Bytes())
+}
@@ -0,0 +1,175 @@
+package tasklink
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"github.com/iSparshP/real-time-task-management-system/internal/task"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestRouter(t *testing.T) (*gin.Engine, sqlmock.Sqlmock) {
+	t.Helper()
+	common.AppConfig.TaskMaxDescLength = 1000
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := NewHandler(NewService(db, task.NewService(db, zap.NewNop()), "", zap.NewNop()), zap.NewNop())
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(common.ErrorHandler(zap.NewNop()))
+	router.GET(FormPath, handler.Form)
+	router.POST(FormPath, handler.Submit)
+	return router, mock
+}
+
+func expectUse(mock sqlmock.Sqlmock, token string) *sqlmock.ExpectedQuery {
+	mock.ExpectBegin()
+	return mock.ExpectQuery(`UPDATE "task_links" SET "last_used_at"=\$1,"use_count"=use_count \+ 1 `+
+		`WHERE token_hash = \$2 AND revoked_at IS NULL AND expires_at > \$3 AND \(max_uses = 0 OR use_count < max_uses\) RETURNING \*`).
+		WithArgs(sqlmock.AnyArg(), hashToken(token), sqlmock.AnyArg())
+}
+
+func TestFormShowsUsableLinksOnly(t *testing.T) {
+	router, mock := newTestRouter(t)
+	mock.ExpectQuery(`SELECT \* FROM "task_links" WHERE token_hash = \$1 AND revoked_at IS NULL`).
+		WithArgs(hashToken("good"), sqlmock.AnyArg(), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "project"}).AddRow("link-1", "Site A <defects>", "site-a"))
+	mock.ExpectQuery(`SELECT \* FROM "task_links" WHERE token_hash = \$1`).
+		WithArgs(hashToken("revoked"), sqlmock.AnyArg(), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, FormPath+"?token=good", nil))
+	body := w.Body.String()
+	if w.Code != http.StatusOK || !strings.Contains(body, "Site A &lt;defects&gt;") ||
+		!strings.Contains(body, `name="token" value="good"`) {
+		t.Fatalf("status = %d, body %s, want the escaped form", w.Code, body)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, FormPath+"?token=revoked", nil))
+	if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), ErrLinkNotFound.Error()) {
+		t.Fatalf("status = %d, body %s, want 404", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSubmitGivesBackUseOfInvalidSubmission(t *testing.T) {
+	router, mock := newTestRouter(t)
+	expectUse(mock, "used-up").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectCommit()
+
+	req := httptest.NewRequest(http.MethodPost, FormPath, strings.NewReader(`{"token": "used-up", "title": "Broken pipe"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, body %s, want 404 for a used up link", w.Code, w.Body.String())
+	}
+
+	// A link without a template needs a title
+	expectUse(mock, "good").WillReturnRows(sqlmock.NewRows([]string{"id", "project", "created_by", "expires_at"}).
+		AddRow("link-1", "site-a", "user-1", time.Now().Add(time.Hour)))
+	mock.ExpectCommit()
+	expectCreator(mock, "user-1").WillReturnRows(sqlmock.NewRows([]string{"id", "disabled_at"}).AddRow("user-1", nil))
+	expectMember(mock, "user-1", "site-a", true)
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "task_links" SET "use_count"=use_count - 1 WHERE id = \$1`).
+		WithArgs("link-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`SELECT \* FROM "task_links" WHERE token_hash = \$1`).
+		WithArgs(hashToken("good"), sqlmock.AnyArg(), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "project"}).AddRow("link-1", "Site A", "site-a"))
+
+	form := url.Values{"token": {"good"}, "description": {"Leaking since Monday"}}
+	req = httptest.NewRequest(http.MethodPost, FormPath, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "title is required") ||
+		!strings.Contains(w.Body.String(), "<form") {
+		t.Fatalf("status = %d, body %s, want the form with the error", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func expectCreator(mock sqlmock.Sqlmock, userID string) *sqlmock.ExpectedQuery {
+	return mock.ExpectQuery(`SELECT "id","disabled_at" FROM "users" WHERE id = \$1 AND "users"."deleted_at" IS NULL`).
+		WithArgs(userID, 1)
+}
+
+func expectMember(mock sqlmock.Sqlmock, userID, project string, member bool) {
+	rows := sqlmock.NewRows([]string{"project"})
+	if member {
+		rows.AddRow(project)
+	}
+	mock.ExpectQuery(`SELECT DISTINCT t.project FROM tasks t`).
+		WithArgs(project, userID, userID).
+		WillReturnRows(rows)
+}
+
+func TestSubmitRefusesLinksOfInactiveCreators(t *testing.T) {
+	cases := []struct {
+		name   string
+		expect func(mock sqlmock.Sqlmock)
+	}{
+		{"disabled", func(mock sqlmock.Sqlmock) {
+			expectCreator(mock, "user-1").WillReturnRows(sqlmock.NewRows([]string{"id", "disabled_at"}).AddRow("user-1", time.Now()))
+		}},
+		{"deleted", func(mock sqlmock.Sqlmock) {
+			expectCreator(mock, "user-1").WillReturnRows(sqlmock.NewRows([]string{"id", "disabled_at"}))
+		}},
+		{"no longer a member", func(mock sqlmock.Sqlmock) {
+			expectCreator(mock, "user-1").WillReturnRows(sqlmock.NewRows([]string{"id", "disabled_at"}).AddRow("user-1", nil))
+			expectMember(mock, "user-1", "site-a", false)
+		}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router, mock := newTestRouter(t)
+			expectUse(mock, "good").WillReturnRows(sqlmock.NewRows([]string{"id", "project", "created_by", "expires_at"}).
+				AddRow("link-1", "site-a", "user-1", time.Now().Add(time.Hour)))
+			mock.ExpectCommit()
+			tc.expect(mock)
+			mock.ExpectBegin()
+			mock.ExpectExec(`UPDATE "task_links" SET "use_count"=use_count - 1 WHERE id = \$1`).
+				WithArgs("link-1").
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			req := httptest.NewRequest(http.MethodPost, FormPath, strings.NewReader(`{"token": "good", "title": "Broken pipe"}`))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusNotFound {
+				t.Fatalf("status = %d, body %s, want 404", w.Code, w.Body.String())
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
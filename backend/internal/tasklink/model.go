@@ -0,0 +1,60 @@
+package tasklink
+
+import (
+	"errors"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+)
+
+type Link = models.TaskLink
+
+var (
+	// ErrLinkNotFound covers unknown, revoked, expired and used up links
+	// alike, so holders learn nothing about other links
+	ErrLinkNotFound      = errors.New("link is invalid or has expired")
+	ErrTemplateNotFound  = errors.New("template not found")
+	ErrNotProjectMember  = errors.New("not a member of the project")
+	ErrInvalidSubmission = errors.New("invalid submission")
+)
+
+// CreateLinkRequest describes a link. Tasks get TemplateID's content, if
+// set, and land in Project.
+type CreateLinkRequest struct {
+	Name          string  `json:"name" binding:"required,max=100"`
+	Project       string  `json:"project" binding:"required,max=100"`
+	TemplateID    *string `json:"template_id" binding:"omitempty,uuid"`
+	ExpiresInDays int     `json:"expires_in_days" binding:"omitempty,min=1,max=365"`
+	MaxUses       int     `json:"max_uses" binding:"omitempty,min=0,max=100000"`
+}
+
+// CreateLinkResponse carries the token, which is shown only once. Path is
+// what to encode in a QR code or email, after the server's address; URL
+// is set when PUBLIC_BASE_URL is configured.
+type CreateLinkResponse struct {
+	Link  Link   `json:"link"`
+	Token string `json:"token"`
+	Path  string `json:"path"`
+	URL   string `json:"url,omitempty"`
+}
+
+// SubmitRequest is what a link holder fills in. Title may be left out
+// when the link has a template; the description is added to the
+// template's.
+type SubmitRequest struct {
+	Token       string `json:"token" form:"token" binding:"required"`
+	Title       string `json:"title" form:"title" binding:"max=255"`
+	Description string `json:"description" form:"description"`
+	Reporter    string `json:"reporter" form:"reporter" binding:"max=200"`
+}
+
+// LinkForm is what the form page shows of a link
+type LinkForm struct {
+	Name          string `json:"name"`
+	Project       string `json:"project"`
+	TemplateTitle string `json:"template_title,omitempty"`
+}
+
+type SubmitResponse struct {
+	TaskID string `json:"task_id"`
+	Title  string `json:"title"`
+}
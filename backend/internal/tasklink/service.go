@@ -0,0 +1,295 @@
+package tasklink
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/iSparshP/real-time-task-management-system/internal/common"
+	"github.com/iSparshP/real-time-task-management-system/internal/models"
+	"github.com/iSparshP/real-time-task-management-system/internal/task"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultExpiryDays = 30
+	// defaultDueIn is the due date of tasks whose template sets none
+	defaultDueIn = 7 * 24 * time.Hour
+	// FormPath serves the form of a link, which is passed as ?token=. The
+	// token stays out of the path so request logs never record it.
+	FormPath = "/task-link"
+)
+
+type Service struct {
+	db      *gorm.DB
+	tasks   *task.Service
+	baseURL string
+	logger  *zap.Logger
+}
+
+// NewService creates the link service. With baseURL, the server's public
+// address, created links carry their full URL.
+func NewService(db *gorm.DB, tasks *task.Service, baseURL string, logger *zap.Logger) *Service {
+	return &Service{
+		db:      db,
+		tasks:   tasks,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		logger:  logger,
+	}
+}
+
+// hashToken is how a token is stored and looked up
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateLink creates a link to the project of a member. Its tasks are
+// created on behalf of userID.
+func (s *Service) CreateLink(ctx context.Context, req CreateLinkRequest, userID string) (*CreateLinkResponse, error) {
+	member, err := s.tasks.IsProjectMember(ctx, userID, req.Project)
+	if err != nil {
+		return nil, err
+	}
+	if !member {
+		return nil, ErrNotProjectMember
+	}
+	if req.TemplateID != nil {
+		if _, err := s.template(ctx, *req.TemplateID); err != nil {
+			return nil, err
+		}
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	days := req.ExpiresInDays
+	if days == 0 {
+		days = defaultExpiryDays
+	}
+	link := &Link{
+		TokenHash:  hashToken(token),
+		Name:       req.Name,
+		Project:    req.Project,
+		TemplateID: req.TemplateID,
+		CreatedBy:  userID,
+		MaxUses:    req.MaxUses,
+		ExpiresAt:  time.Now().AddDate(0, 0, days),
+		CreatedAt:  time.Now(),
+	}
+	if err := s.db.WithContext(ctx).Create(link).Error; err != nil {
+		return nil, fmt.Errorf("failed to store task link: %w", err)
+	}
+
+	resp := &CreateLinkResponse{
+		Link:  *link,
+		Token: token,
+		Path:  FormPath + "?token=" + url.QueryEscape(token),
+	}
+	if s.baseURL != "" {
+		resp.URL = s.baseURL + resp.Path
+	}
+	return resp, nil
+}
+
+// ListLinks returns the links userID created, newest first
+func (s *Service) ListLinks(ctx context.Context, userID string) ([]Link, error) {
+	links := []Link{}
+	if err := s.db.WithContext(ctx).
+		Where("created_by = ?", userID).
+		Order("created_at DESC").
+		Find(&links).Error; err != nil {
+		return nil, fmt.Errorf("failed to list task links: %w", err)
+	}
+	return links, nil
+}
+
+// RevokeLink stops one of userID's links from creating tasks
+func (s *Service) RevokeLink(ctx context.Context, id, userID string) error {
+	result := s.db.WithContext(ctx).Model(&Link{}).
+		Where("id = ? AND created_by = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return fmt.Errorf("failed to revoke task link: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrLinkNotFound
+	}
+	return nil
+}
+
+// Form returns what the form of a usable link shows
+func (s *Service) Form(ctx context.Context, token string) (*LinkForm, error) {
+	var link Link
+	err := s.db.WithContext(ctx).
+		Where("token_hash = ? AND "+usable, hashToken(token), time.Now()).
+		First(&link).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrLinkNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	form := &LinkForm{Name: link.Name, Project: link.Project}
+	if link.TemplateID != nil {
+		if template, err := s.template(ctx, *link.TemplateID); err == nil {
+			form.TemplateTitle = template.Title
+		}
+	}
+	return form, nil
+}
+
+// usable matches links that are neither revoked, expired nor used up
+const usable = "revoked_at IS NULL AND expires_at > ? AND (max_uses = 0 OR use_count < max_uses)"
+
+// Submit creates a task through a link. The use is counted first, so
+// concurrent submissions cannot exceed the link's uses, and given back if
+// the task cannot be created.
+func (s *Service) Submit(ctx context.Context, req SubmitRequest) (*SubmitResponse, error) {
+	var link Link
+	now := time.Now()
+	result := s.db.WithContext(ctx).Model(&link).
+		Clauses(clause.Returning{}).
+		Where("token_hash = ? AND "+usable, hashToken(req.Token), now).
+		Updates(map[string]interface{}{
+			"use_count":    gorm.Expr("use_count + 1"),
+			"last_used_at": now,
+		})
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to use task link: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrLinkNotFound
+	}
+
+	resp, err := s.createTask(ctx, link, req)
+	if err != nil {
+		if releaseErr := s.db.WithContext(context.WithoutCancel(ctx)).Model(&Link{}).
+			Where("id = ?", link.ID).
+			Update("use_count", gorm.Expr("use_count - 1")).Error; releaseErr != nil {
+			s.logger.Warn("Failed to give back task link use", zap.String("link_id", link.ID), zap.Error(releaseErr))
+		}
+		return nil, err
+	}
+
+	s.logger.Info("Task created through link",
+		zap.String("link_id", link.ID),
+		zap.String("task_id", resp.Task.ID),
+	)
+	return &SubmitResponse{TaskID: resp.Task.ID, Title: resp.Task.Title}, nil
+}
+
+func (s *Service) createTask(ctx context.Context, link Link, req SubmitRequest) (*task.TaskResponse, error) {
+	if err := s.checkCreator(ctx, link); err != nil {
+		return nil, err
+	}
+
+	createReq := task.CreateTaskRequest{
+		Title:       strings.TrimSpace(req.Title),
+		Description: strings.TrimSpace(req.Description),
+		Priority:    string(models.PriorityLow),
+		DueDate:     time.Now().Add(defaultDueIn),
+		Project:     link.Project,
+	}
+	if link.TemplateID != nil {
+		template, err := s.template(ctx, *link.TemplateID)
+		if err != nil {
+			return nil, err
+		}
+		if createReq.Title == "" {
+			createReq.Title = template.Title
+		}
+		createReq.Description = strings.TrimSpace(template.Description + "\n\n" + createReq.Description)
+		createReq.Priority = string(template.Priority)
+		createReq.EstimatedEffort = template.EstimatedEffort
+		createReq.Checklist = template.Checklist
+		if template.DueInDays > 0 {
+			due, err := s.tasks.DueIn(ctx, link.Project, template.DueInDays)
+			if err != nil {
+				return nil, err
+			}
+			createReq.DueDate = due
+		}
+	}
+	if reporter := strings.TrimSpace(req.Reporter); reporter != "" {
+		createReq.Description = strings.TrimSpace(createReq.Description + "\n\nReported by: " + reporter)
+	}
+
+	if createReq.Title == "" {
+		return nil, fmt.Errorf("%w: title is required", ErrInvalidSubmission)
+	}
+	if len(createReq.Description) > common.AppConfig.TaskMaxDescLength {
+		return nil, fmt.Errorf("%w: description exceeds maximum length of %d characters",
+			ErrInvalidSubmission, common.AppConfig.TaskMaxDescLength)
+	}
+	due, err := s.tasks.NextWorkingDay(ctx, link.Project, createReq.DueDate)
+	if err != nil {
+		return nil, err
+	}
+	createReq.DueDate = due
+	resp, err := s.tasks.CreateTask(ctx, createReq, link.CreatedBy)
+	if errors.Is(err, task.ErrFieldRequired) || errors.Is(err, task.ErrFieldHidden) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSubmission, err)
+	}
+	return resp, err
+}
+
+// checkCreator fails with ErrLinkNotFound unless the link's creator, on
+// whose behalf its tasks are created, still exists, is enabled and is a
+// member of the link's project
+func (s *Service) checkCreator(ctx context.Context, link Link) error {
+	var creator models.User
+	err := s.db.WithContext(ctx).Select("id", "disabled_at").First(&creator, "id = ?", link.CreatedBy).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	reason := ""
+	switch {
+	case err != nil:
+		reason = "deleted"
+	case creator.DisabledAt != nil:
+		reason = "disabled"
+	default:
+		member, err := s.tasks.IsProjectMember(ctx, link.CreatedBy, link.Project)
+		if err != nil {
+			return err
+		}
+		if !member {
+			reason = "not a project member"
+		}
+	}
+	if reason != "" {
+		s.logger.Info("Refused task link of inactive creator",
+			zap.String("link_id", link.ID),
+			zap.String("created_by", link.CreatedBy),
+			zap.String("reason", reason),
+		)
+		return ErrLinkNotFound
+	}
+	return nil
+}
+
+// template loads a template that has not been deleted
+func (s *Service) template(ctx context.Context, id string) (*models.TaskTemplate, error) {
+	var template models.TaskTemplate
+	err := s.db.WithContext(ctx).First(&template, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTemplateNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
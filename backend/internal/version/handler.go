@@ -0,0 +1,74 @@
+package version
+
+import (
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Version is set at build time with
+// -ldflags "-X github.com/iSparshP/real-time-task-management-system/internal/version.Version=v1.2.3"
+var Version = "dev"
+
+// Feature is whether an optional feature runs on this deployment, and why
+// not when it does not
+type Feature struct {
+	Enabled bool   `json:"enabled"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// Info is the response of the version endpoint
+type Info struct {
+	Version   string             `json:"version"`
+	Commit    string             `json:"commit,omitempty"`
+	GoVersion string             `json:"go_version"`
+	Features  map[string]Feature `json:"features"`
+}
+
+type Handler struct {
+	mu       sync.RWMutex
+	features map[string]Feature
+	commit   string
+}
+
+func NewHandler() *Handler {
+	h := &Handler{features: make(map[string]Feature)}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				h.commit = setting.Value
+			}
+		}
+	}
+	return h
+}
+
+// SetFeature records the state of an optional feature
+func (h *Handler) SetFeature(name string, feature Feature) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.features[name] = feature
+}
+
+// Info reports the build and the state of each feature
+func (h *Handler) Info() Info {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	features := make(map[string]Feature, len(h.features))
+	for name, feature := range h.features {
+		features[name] = feature
+	}
+	return Info{
+		Version:   Version,
+		Commit:    h.commit,
+		GoVersion: runtime.Version(),
+		Features:  features,
+	}
+}
+
+func (h *Handler) Get(c *gin.Context) {
+	c.JSON(http.StatusOK, h.Info())
+}
@@ -0,0 +1,23 @@
+package webapp
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// static holds the app: one page that talks to the API and the task
+// WebSocket from the browser, so the server is usable without deploying a
+// frontend
+//
+//go:embed static
+var static embed.FS
+
+// FS returns the app's files, with index.html at the root
+func FS() http.FileSystem {
+	files, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err)
+	}
+	return http.FS(files)
+}
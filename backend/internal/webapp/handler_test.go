@@ -0,0 +1,27 @@
+package webapp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestFSServesApp(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.StaticFS("/app", FS())
+
+	for path, want := range map[string]string{
+		"/app/":       `<script src="app.js">`,
+		"/app/app.js": `new WebSocket(`,
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), want) {
+			t.Fatalf("GET %s = %d %.200s", path, w.Code, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,306 @@
+// A small client for the task API: sign in, list, create and follow tasks.
+// Live updates come over the task WebSocket, which browsers authenticate
+// with the "bearer" subprotocol since they cannot set headers on it.
+(function () {
+  "use strict";
+
+  const TOKEN_KEY = "tasks.token";
+  const USER_KEY = "tasks.user";
+  const PAGE_SIZE = 20;
+  const PING_INTERVAL = 30000;
+
+  const view = document.getElementById("view");
+  const errorBox = document.getElementById("error");
+  const live = document.getElementById("live");
+
+  let session = {
+    token: localStorage.getItem(TOKEN_KEY),
+    user: JSON.parse(localStorage.getItem(USER_KEY) || "null"),
+  };
+  let listState = { page: 1, status: "", priority: "" };
+  // refresh re-renders the current view when a task event arrives
+  let refresh = null;
+
+  function showError(message) {
+    errorBox.textContent = message;
+    errorBox.hidden = !message;
+  }
+
+  // errorMessage reads the {error: {code, message, details}} envelope
+  function errorMessage(body, status) {
+    const err = body && body.error;
+    if (err && (err.details || err.message)) return err.details || err.message;
+    return "Request failed (" + status + ")";
+  }
+
+  async function api(method, path, body) {
+    const headers = { Authorization: "Bearer " + session.token };
+    if (body !== undefined) headers["Content-Type"] = "application/json";
+    const res = await fetch("/api" + path, {
+      method: method,
+      headers: headers,
+      body: body === undefined ? undefined : JSON.stringify(body),
+    });
+    const data = await res.json().catch(function () { return null; });
+    if (res.status === 401) {
+      signOut();
+      throw new Error("Your session expired, sign in again");
+    }
+    if (!res.ok) throw new Error(errorMessage(data, res.status));
+    return data;
+  }
+
+  function render(templateID) {
+    view.replaceChildren(document.getElementById(templateID).content.cloneNode(true));
+  }
+
+  function cell(row, text) {
+    const td = document.createElement("td");
+    if (text instanceof Node) td.appendChild(text);
+    else td.textContent = text;
+    row.appendChild(td);
+  }
+
+  function formatDate(value) {
+    return value ? new Date(value).toLocaleString() : "";
+  }
+
+  function assignees(task) {
+    return (task.assignees || []).map(function (a) {
+      return session.user && a.user_id === session.user.id ? "me" : a.user_id.slice(0, 8);
+    }).join(", ");
+  }
+
+  // Views
+
+  function loginView() {
+    render("login-view");
+    document.getElementById("login").addEventListener("submit", async function (e) {
+      e.preventDefault();
+      const form = new FormData(e.target);
+      const res = await fetch("/api/auth/login", {
+        method: "POST",
+        headers: { "Content-Type": "application/json" },
+        body: JSON.stringify({ email: form.get("email"), password: form.get("password") }),
+      });
+      const data = await res.json().catch(function () { return null; });
+      if (!res.ok) {
+        showError(errorMessage(data, res.status));
+        return;
+      }
+      session = { token: data.token, user: data.user };
+      localStorage.setItem(TOKEN_KEY, data.token);
+      localStorage.setItem(USER_KEY, JSON.stringify(data.user));
+      showError("");
+      connect();
+      route();
+    });
+  }
+
+  async function listView() {
+    render("list-view");
+    const filters = document.getElementById("filters");
+    filters.status.value = listState.status;
+    filters.priority.value = listState.priority;
+    filters.addEventListener("change", function () {
+      listState = { page: 1, status: filters.status.value, priority: filters.priority.value };
+      load();
+    });
+    document.getElementById("prev").addEventListener("click", function () {
+      listState.page--;
+      load();
+    });
+    document.getElementById("next").addEventListener("click", function () {
+      listState.page++;
+      load();
+    });
+
+    async function load() {
+      const query = new URLSearchParams({ page: listState.page, page_size: PAGE_SIZE, sort_by: "due_date", sort_order: "asc" });
+      if (listState.status) query.set("status", listState.status);
+      if (listState.priority) query.set("priority", listState.priority);
+      const data = await api("GET", "/tasks?" + query);
+
+      const rows = document.getElementById("tasks");
+      if (!rows) return;
+      rows.replaceChildren();
+      data.tasks.forEach(function (task) {
+        const row = document.createElement("tr");
+        const link = document.createElement("a");
+        link.href = "#/tasks/" + encodeURIComponent(task.id);
+        link.textContent = task.title;
+        cell(row, link);
+        cell(row, task.status.replace("_", " ") + (task.is_overdue ? " (overdue)" : ""));
+        cell(row, task.priority);
+        cell(row, formatDate(task.due_date));
+        cell(row, assignees(task));
+        rows.appendChild(row);
+      });
+
+      const p = data.pagination;
+      document.getElementById("page").textContent = "Page " + p.current_page + " of " + Math.max(p.total_pages, 1);
+      document.getElementById("prev").disabled = p.current_page <= 1;
+      document.getElementById("next").disabled = p.current_page >= p.total_pages;
+    }
+
+    refresh = function (event) {
+      if (event.type.startsWith("task_")) return load();
+    };
+    await load();
+  }
+
+  function newView() {
+    render("new-view");
+    document.getElementById("create").addEventListener("submit", async function (e) {
+      e.preventDefault();
+      const form = new FormData(e.target);
+      try {
+        const data = await api("POST", "/tasks", {
+          title: form.get("title"),
+          description: form.get("description"),
+          priority: form.get("priority"),
+          due_date: new Date(form.get("due_date")).toISOString(),
+          project: form.get("project"),
+        });
+        showError("");
+        location.hash = "#/tasks/" + encodeURIComponent(data.task.id);
+      } catch (err) {
+        showError(err.message);
+      }
+    });
+  }
+
+  async function detailView(id) {
+    render("detail-view");
+    const status = document.getElementById("status");
+    status.addEventListener("change", async function () {
+      try {
+        await api("PUT", "/tasks/" + encodeURIComponent(id), { status: status.value });
+        showError("");
+      } catch (err) {
+        showError(err.message);
+        load();
+      }
+    });
+
+    async function load() {
+      const [data, checklist] = await Promise.all([
+        api("GET", "/tasks/" + encodeURIComponent(id)),
+        api("GET", "/tasks/" + encodeURIComponent(id) + "/checklist"),
+      ]);
+      const card = view.querySelector("article");
+      if (!card) return;
+      const task = data.task;
+      document.getElementById("title").textContent = task.title;
+      status.value = task.status;
+      document.getElementById("priority").textContent = task.priority;
+      document.getElementById("due").textContent = formatDate(task.due_date) + (task.is_overdue ? " (overdue)" : "");
+      document.getElementById("assignees").textContent = assignees(task) || "unassigned";
+      document.getElementById("project").textContent = task.project || "none";
+      document.getElementById("updated").textContent = formatDate(task.updated_at);
+      document.getElementById("description").textContent = task.description;
+      document.getElementById("percent").textContent = checklist.items.length ? checklist.percent_complete + "%" : "";
+
+      const list = document.getElementById("checklist");
+      list.replaceChildren();
+      checklist.items.forEach(function (item) {
+        const li = document.createElement("li");
+        li.textContent = item.text;
+        if (item.done) li.className = "done";
+        list.appendChild(li);
+      });
+
+      card.classList.remove("flash");
+      void card.offsetWidth;
+      card.classList.add("flash");
+    }
+
+    refresh = function (event) {
+      const payload = event.payload || {};
+      if ((payload.id || payload.task_id) !== id) return;
+      if (event.type === "task_deleted") {
+        location.hash = "#/";
+        return;
+      }
+      if (event.type.startsWith("task_") || event.type.startsWith("checklist_")) return load();
+    };
+    await load();
+  }
+
+  // Live updates
+
+  let socket = null;
+  let pinger = null;
+  let retryDelay = 1000;
+
+  function setLive(online) {
+    live.textContent = online ? "live" : "offline";
+    live.className = online ? "online" : "offline";
+  }
+
+  function connect() {
+    if (!session.token || socket) return;
+    const scheme = location.protocol === "https:" ? "wss:" : "ws:";
+    socket = new WebSocket(scheme + "//" + location.host + "/api/tasks/ws?protocol=2&schema=2", ["bearer", session.token]);
+
+    socket.addEventListener("open", function () {
+      retryDelay = 1000;
+      setLive(true);
+      socket.send(JSON.stringify({ type: "preferences", disabled: ["notifications", "transfers"] }));
+      pinger = setInterval(function () { socket.send("ping"); }, PING_INTERVAL);
+    });
+    socket.addEventListener("message", function (e) {
+      let frame;
+      try {
+        frame = JSON.parse(e.data);
+      } catch (err) {
+        return;
+      }
+      (frame.events || []).forEach(function (event) {
+        if (refresh) Promise.resolve(refresh(event)).catch(function (err) { showError(err.message); });
+      });
+    });
+    socket.addEventListener("close", function () {
+      clearInterval(pinger);
+      socket = null;
+      setLive(false);
+      if (!session.token) return;
+      setTimeout(connect, retryDelay);
+      retryDelay = Math.min(retryDelay * 2, 30000);
+    });
+  }
+
+  function signOut() {
+    session = { token: null, user: null };
+    localStorage.removeItem(TOKEN_KEY);
+    localStorage.removeItem(USER_KEY);
+    if (socket) socket.close();
+    route();
+  }
+
+  // Routing: #/, #/new and #/tasks/:id
+
+  async function route() {
+    refresh = null;
+    document.getElementById("nav").hidden = !session.token;
+    if (!session.token) return loginView();
+
+    const hash = location.hash.replace(/^#/, "") || "/";
+    const task = hash.match(/^\/tasks\/([^/]+)$/);
+    try {
+      if (hash === "/new") newView();
+      else if (task) await detailView(decodeURIComponent(task[1]));
+      else await listView();
+    } catch (err) {
+      showError(err.message);
+    }
+  }
+
+  document.getElementById("logout").addEventListener("click", signOut);
+  window.addEventListener("hashchange", function () {
+    showError("");
+    route();
+  });
+  connect();
+  route();
+})();
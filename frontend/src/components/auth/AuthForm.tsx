@@ -25,7 +25,7 @@ export default function AuthForm({ mode }: AuthFormProps) {
       localStorage.setItem('user', JSON.stringify(data.user));
       router.push('/dashboard');
     } catch (err: any) {
-      setError(err.response?.data?.message || 'An error occurred');
+      setError(err.response?.data?.error?.details || err.response?.data?.error?.message || 'An error occurred');
     } finally {
       setLoading(false);
     }